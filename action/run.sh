@@ -0,0 +1,65 @@
+#!/usr/bin/env bash
+# Runs templr for the composite action; the inputs come as INPUT_* variables.
+set -uo pipefail
+
+values=()
+while IFS= read -r f; do
+  [ -n "$f" ] && values+=(-f "$f")
+done <<< "${INPUT_VALUES:-}"
+while IFS= read -r s; do
+  [ -n "$s" ] && values+=(--set "$s")
+done <<< "${INPUT_SET:-}"
+
+# shellcheck disable=SC2206 # extra arguments are split on whitespace on purpose
+extra=(${INPUT_ARGS:-})
+
+case "$INPUT_COMMAND" in
+  lint)
+    report="${INPUT_REPORT:-${RUNNER_TEMP:-/tmp}/templr-lint.json}"
+    args=(lint --src "${INPUT_SRC:-.}" "${values[@]}")
+    [ "${INPUT_FAIL_ON_WARN:-false}" = "true" ] && args+=(--fail-on-warn)
+    templr "${args[@]}" "${extra[@]}" --format json --output "$report" --gha
+    code=$?
+    if [ -n "${INPUT_SARIF:-}" ]; then
+      templr "${args[@]}" "${extra[@]}" --format sarif --output "$INPUT_SARIF" >/dev/null 2>&1
+      echo "sarif=$INPUT_SARIF" >> "$GITHUB_OUTPUT"
+    fi
+    ;;
+  render)
+    if [ -z "${INPUT_TEMPLATE:-}" ]; then
+      echo "::error::render needs the template input"
+      exit 1
+    fi
+    args=(render -i "$INPUT_TEMPLATE" "${values[@]}")
+    [ -n "${INPUT_OUTPUT:-}" ] && args+=(-o "$INPUT_OUTPUT")
+    templr "${args[@]}" "${extra[@]}"
+    code=$?
+    ;;
+  walk)
+    if [ -z "${INPUT_SRC:-}" ] || [ -z "${INPUT_DST:-}" ]; then
+      echo "::error::walk needs the src and dst inputs"
+      exit 1
+    fi
+    templr walk --src "$INPUT_SRC" --dst "$INPUT_DST" "${values[@]}" --gha-summary "${extra[@]}"
+    code=$?
+    ;;
+  verify)
+    if [ -z "${INPUT_PROVENANCE:-}" ]; then
+      echo "::error::verify needs the provenance input"
+      exit 1
+    fi
+    args=(verify --provenance "$INPUT_PROVENANCE")
+    [ -n "${INPUT_KEY:-}" ] && args+=(--key "$INPUT_KEY")
+    [ -n "${INPUT_SRC:-}" ] && args+=(--src "$INPUT_SRC")
+    [ -n "${INPUT_DST:-}" ] && args+=(--dst "$INPUT_DST")
+    templr "${args[@]}" "${extra[@]}"
+    code=$?
+    ;;
+  *)
+    echo "::error::unknown command '$INPUT_COMMAND' (want lint, render, walk or verify)"
+    exit 1
+    ;;
+esac
+
+echo "exit-code=$code" >> "$GITHUB_OUTPUT"
+exit "$code"
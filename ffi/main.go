@@ -0,0 +1,92 @@
+// Command ffi is templr's C-shared library (make build-cshared): a small C
+// API with JSON in and out, for tooling in other languages to render without
+// starting a templr process per file. Every returned string is allocated by
+// the library and must be released with TemplrFree.
+//
+//	char *TemplrRenderSingle(char *request); // api.RenderRequest -> api.RenderResponse
+//	char *TemplrRenderTree(char *request);   // api.TreeRequest -> api.TreeResponse
+//	char *TemplrVersion(void);
+//	void TemplrFree(char *s);
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"encoding/json"
+	"unsafe"
+
+	"github.com/kanopi/templr/pkg/templr"
+	"github.com/kanopi/templr/wasm/api"
+)
+
+// Version is set at build time, like the CLI's.
+var Version = "dev"
+
+// options converts a request to engine options whose warnings are appended
+// to warnings.
+func options(req api.RenderRequest, warnings *[]string) templr.Options {
+	opts := templr.Options{
+		Template:       req.Template,
+		Helpers:        req.Helpers,
+		ValuesYAML:     req.Values,
+		Strict:         req.Strict,
+		DefaultMissing: req.DefaultMissing,
+		InjectGuard:    req.InjectGuard,
+		GuardMarker:    req.GuardMarker,
+		WarnFunc: func(msg string) {
+			*warnings = append(*warnings, msg)
+		},
+	}
+	if len(req.Files) > 0 {
+		opts.Files = templr.FilesMap(req.Files)
+	}
+	return opts
+}
+
+//export TemplrRenderSingle
+func TemplrRenderSingle(request *C.char) *C.char {
+	var req api.RenderRequest
+	if err := json.Unmarshal([]byte(C.GoString(request)), &req); err != nil {
+		return toC(api.RenderResponse{Error: "bad JSON: " + err.Error()})
+	}
+	var warnings []string
+	res, err := templr.RenderSingle(options(req, &warnings))
+	if err != nil {
+		return toC(api.RenderResponse{Error: err.Error(), Warnings: warnings})
+	}
+	return toC(api.RenderResponse{Output: res.Output, Warnings: warnings})
+}
+
+//export TemplrRenderTree
+func TemplrRenderTree(request *C.char) *C.char {
+	var req api.TreeRequest
+	if err := json.Unmarshal([]byte(C.GoString(request)), &req); err != nil {
+		return toC(api.TreeResponse{Error: "bad JSON: " + err.Error()})
+	}
+	var warnings []string
+	outputs, err := templr.RenderTree(templr.TreeOptions{Options: options(req.RenderRequest, &warnings), Templates: req.Templates})
+	if err != nil {
+		return toC(api.TreeResponse{Error: err.Error(), Warnings: warnings})
+	}
+	return toC(api.TreeResponse{Outputs: outputs, Warnings: warnings})
+}
+
+//export TemplrVersion
+func TemplrVersion() *C.char {
+	return C.CString(Version)
+}
+
+//export TemplrFree
+func TemplrFree(s *C.char) {
+	C.free(unsafe.Pointer(s))
+}
+
+func toC(v any) *C.char {
+	b, _ := json.Marshal(v)
+	return C.CString(string(b))
+}
+
+func main() {}
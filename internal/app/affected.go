@@ -0,0 +1,258 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// includeFuncs take the name of the template they render as first argument.
+var includeFuncs = map[string]bool{"include": true, "includeCached": true}
+
+// valueDeps records the values keys the templates of a set read, following
+// {{ template }} and include calls into the templates they render.
+type valueDeps struct {
+	tpl  *template.Template
+	memo map[string]*templateDeps // keys and includes of each template itself
+}
+
+// templateDeps are the dotted keys (without the leading dot or "Values.")
+// a template reads. dynamic is set when it may read any key: it includes a
+// template whose name is computed, or uses the whole root dot other than to
+// pass it to an included template.
+type templateDeps struct {
+	keys      map[string]bool
+	includes  []string
+	templates map[string]bool // the template and those it renders (set by of)
+	dynamic   bool
+	scoped    int // depth of range and with bodies, where dot is not the root
+}
+
+func newValueDeps(tpl *template.Template) *valueDeps {
+	return &valueDeps{tpl: tpl, memo: map[string]*templateDeps{}}
+}
+
+// of returns the keys the template name reads, those of the templates it
+// renders and of the templr.vars helper (whose result every template sees)
+// included.
+func (v *valueDeps) of(name string) *templateDeps {
+	d := &templateDeps{keys: map[string]bool{}}
+	seen := map[string]bool{}
+	v.collect(name, d, seen)
+	if v.tpl.Lookup("templr.vars") != nil {
+		v.collect("templr.vars", d, seen)
+	}
+	d.templates = seen
+	return d
+}
+
+func (v *valueDeps) collect(name string, d *templateDeps, seen map[string]bool) {
+	if seen[name] {
+		return
+	}
+	seen[name] = true
+	own, ok := v.memo[name]
+	if !ok {
+		own = &templateDeps{keys: map[string]bool{}}
+		if t := v.tpl.Lookup(name); t != nil && t.Tree != nil {
+			own.walk(t.Tree.Root)
+		}
+		v.memo[name] = own
+	}
+	for k := range own.keys {
+		d.keys[k] = true
+	}
+	d.dynamic = d.dynamic || own.dynamic
+	for _, inc := range own.includes {
+		v.collect(inc, d, seen)
+	}
+}
+
+// walk adds the keys read and the templates rendered under node.
+func (d *templateDeps) walk(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			d.walk(c)
+		}
+	case *parse.ActionNode:
+		d.pipe(n.Pipe)
+	case *parse.IfNode:
+		d.branch(&n.BranchNode)
+	case *parse.RangeNode:
+		d.scopedBranch(&n.BranchNode)
+	case *parse.WithNode:
+		d.scopedBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		d.includes = append(d.includes, n.Name)
+		if n.Pipe != nil && !(len(n.Pipe.Cmds) == 1 && isRootArg(n.Pipe.Cmds[0].Args[0]) && len(n.Pipe.Cmds[0].Args) == 1) {
+			d.pipe(n.Pipe)
+		}
+	}
+}
+
+func (d *templateDeps) branch(b *parse.BranchNode) {
+	d.pipe(b.Pipe)
+	d.walk(b.List)
+	if b.ElseList != nil {
+		d.walk(b.ElseList)
+	}
+}
+
+// scopedBranch walks a range or with, whose body sees another dot.
+func (d *templateDeps) scopedBranch(b *parse.BranchNode) {
+	d.pipe(b.Pipe)
+	d.scoped++
+	d.walk(b.List)
+	d.scoped--
+	if b.ElseList != nil {
+		d.walk(b.ElseList)
+	}
+}
+
+// isRootArg reports whether arg is . or $, passed on to an included template.
+func isRootArg(arg parse.Node) bool {
+	switch a := arg.(type) {
+	case *parse.DotNode:
+		return true
+	case *parse.VariableNode:
+		return len(a.Ident) == 1 && a.Ident[0] == "$"
+	}
+	return false
+}
+
+func (d *templateDeps) pipe(p *parse.PipeNode) {
+	if p == nil {
+		return
+	}
+	for _, cmd := range p.Cmds {
+		args := cmd.Args
+		if id, ok := args[0].(*parse.IdentifierNode); ok && includeFuncs[id.Ident] && len(args) > 1 {
+			if s, ok := args[1].(*parse.StringNode); ok {
+				d.includes = append(d.includes, s.Text)
+			} else {
+				d.dynamic = true
+			}
+			// the root passed on is accounted for by the included template
+			if len(args) == 3 && isRootArg(args[2]) {
+				args = args[:2]
+			}
+		}
+		for _, arg := range args {
+			d.arg(arg)
+		}
+	}
+}
+
+func (d *templateDeps) arg(arg parse.Node) {
+	switch a := arg.(type) {
+	case *parse.FieldNode:
+		d.add(a.Ident)
+	case *parse.VariableNode:
+		// $.a.b reads the root values; other variables hold what a field gave
+		if a.Ident[0] == "$" {
+			if len(a.Ident) == 1 {
+				d.dynamic = true
+			} else {
+				d.add(a.Ident[1:])
+			}
+		}
+	case *parse.DotNode:
+		if d.scoped == 0 {
+			d.dynamic = true
+		}
+	case *parse.ChainNode:
+		d.arg(a.Node)
+	case *parse.PipeNode:
+		d.pipe(a)
+	}
+}
+
+func (d *templateDeps) add(ident []string) {
+	key := strings.TrimPrefix(strings.Join(ident, "."), "Values.")
+	if key != "" && key != "Values" {
+		d.keys[key] = true
+	}
+}
+
+// affectedBy reports whether a change of one of keys changes what the
+// template reads.
+func (d *templateDeps) affectedBy(keys []string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	if d.dynamic {
+		return true
+	}
+	refs := make([]string, 0, len(d.keys))
+	for k := range d.keys {
+		refs = append(refs, k)
+	}
+	for _, k := range keys {
+		if keyReferenced(k, refs) {
+			return true
+		}
+	}
+	return false
+}
+
+// changedKeys returns the keys that differ between values and the values
+// merged with the comma-separated files of oldSet in place of --data and -f.
+func changedKeys(baseDir, oldSet string, values map[string]any, shared SharedOptions) ([]string, error) {
+	shared.Data, shared.Files = "", strings.Split(oldSet, ",")
+	old, err := buildValues(baseDir, shared)
+	if err != nil {
+		return nil, fmt.Errorf("--affected-by-values-diff: %w", err)
+	}
+	var changes []valueChange
+	diffValues("", old, values, &changes)
+	keys := make([]string, len(changes))
+	for i, c := range changes {
+		keys[i] = c.Key
+	}
+	return keys, nil
+}
+
+// checkAffectedOptions rejects --affected-by-values-diff and --since with
+// each other and with outputs that must hold every file of the tree.
+func checkAffectedOptions(opts WalkOptions) error {
+	flag := ""
+	switch {
+	case opts.AffectedBy != "" && opts.Since != "":
+		return argsError(fmt.Errorf("--affected-by-values-diff cannot be combined with --since"))
+	case opts.AffectedBy != "":
+		flag = "--affected-by-values-diff"
+	case opts.Since != "":
+		flag = "--since"
+	default:
+		return nil
+	}
+	switch {
+	case opts.DstArchive != "":
+		return argsError(fmt.Errorf("%s cannot be combined with --dst-archive", flag))
+	case opts.HelmChart != "":
+		return argsError(fmt.Errorf("%s cannot be combined with --as-helm-chart", flag))
+	case isRemoteDst(opts.Dst):
+		return argsError(fmt.Errorf("%s cannot be combined with a remote --dst", flag))
+	case opts.Provenance != "":
+		return argsError(fmt.Errorf("%s cannot be combined with --provenance", flag))
+	}
+	return nil
+}
+
+// changedKeysSummary lists the first changed keys for a status line.
+func changedKeysSummary(keys []string) string {
+	const shown = 5
+	switch {
+	case len(keys) == 0:
+		return ""
+	case len(keys) > shown:
+		return fmt.Sprintf(": %s, ... (%d more)", strings.Join(keys[:shown], ", "), len(keys)-shown)
+	default:
+		return ": " + strings.Join(keys, ", ")
+	}
+}
@@ -0,0 +1,226 @@
+package app
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Archive formats of --dst-archive, chosen by the file extension.
+const (
+	archiveTar   = "tar"
+	archiveTarGz = "tar.gz"
+	archiveZip   = "zip"
+)
+
+// archiveFormat returns the format of an archive path, or "" if the
+// extension is not supported.
+func archiveFormat(p string) string {
+	lower := strings.ToLower(p)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return archiveTarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return archiveTar
+	case strings.HasSuffix(lower, ".zip"):
+		return archiveZip
+	}
+	return ""
+}
+
+// checkArchiveOptions validates --dst-archive before rendering.
+func checkArchiveOptions(opts WalkOptions) error {
+	if opts.DstArchive == "" {
+		return nil
+	}
+	if opts.Dst != "" {
+		return argsError(fmt.Errorf("--dst-archive and --dst are mutually exclusive"))
+	}
+	if archiveFormat(opts.DstArchive) == "" {
+		return argsError(fmt.Errorf("--dst-archive %s: want a .tar, .tar.gz, .tgz or .zip file", opts.DstArchive))
+	}
+	if opts.Provenance != "" {
+		return argsError(fmt.Errorf("--provenance cannot be used with --dst-archive"))
+	}
+	return nil
+}
+
+// outputArchive collects the outputs of a walk into a tar or zip file. The
+// archive is written to a temporary file next to path and moved into place
+// by commit, so a failed run leaves an existing archive untouched. Entries
+// get the modes a walk into a directory gives files and directories.
+type outputArchive struct {
+	path   string
+	dryRun bool
+	mtime  time.Time
+
+	f    *os.File
+	gz   *gzip.Writer
+	tw   *tar.Writer
+	zw   *zip.Writer
+	dirs map[string]bool
+}
+
+// newOutputArchive starts the archive at path; in dry-run nothing is
+// created.
+func newOutputArchive(path string, dryRun bool, mtime time.Time) (*outputArchive, error) {
+	a := &outputArchive{path: path, dryRun: dryRun, mtime: mtime, dirs: map[string]bool{}}
+	if dryRun {
+		return a, nil
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create archive: %w", err)
+	}
+	f, err := os.CreateTemp(dir, ".templr-*")
+	if err != nil {
+		return nil, fmt.Errorf("create archive: %w", err)
+	}
+	a.f = f
+	onExit(a.discard)
+	switch archiveFormat(path) {
+	case archiveTarGz:
+		a.gz = gzip.NewWriter(f)
+		a.tw = tar.NewWriter(a.gz)
+	case archiveTar:
+		a.tw = tar.NewWriter(f)
+	case archiveZip:
+		a.zw = zip.NewWriter(f)
+	}
+	return a, nil
+}
+
+// label names an entry in status lines: "out.tar.gz:conf/app.yaml".
+func (a *outputArchive) label(relOut string) string {
+	return a.path + ":" + filepath.ToSlash(relOut)
+}
+
+// writeOutput adds one rendered template to the archive the way writeOutput
+// writes it to a directory, minus the guard and change checks that only
+// make sense for existing files. keep adds empty output as an empty entry.
+func (a *outputArchive) writeOutput(w messageWriter, name, relOut string, outBytes []byte, keep bool, shared SharedOptions) (string, error) {
+	label := a.label(relOut)
+	if isEmpty(outBytes) && !keep {
+		if shared.DryRun {
+			fmt.Fprintf(w.Stdout(), "[dry-run] skip empty %s (no entry created)\n", label)
+		}
+		return "skipped (empty)", nil
+	}
+	status := "rendered"
+	if isEmpty(outBytes) {
+		outBytes, status = nil, "rendered (empty)"
+	} else {
+		if shared.InjectGuard {
+			outBytes = injectGuardForExt(relOut, outBytes, shared)
+		}
+		var err error
+		if outBytes, err = encodeOutput(relOut, outBytes, shared); err != nil {
+			return "", fmt.Errorf("encode %s: %w", label, err)
+		}
+	}
+	if a.dryRun {
+		fmt.Fprintf(w.Stdout(), "[dry-run] would render %s -> %s\n", name, label)
+		noteDryRunChange() // the archive is always written anew
+		return "dry-run", nil
+	}
+	if err := a.add(filepath.ToSlash(relOut), outBytes); err != nil {
+		return "", fmt.Errorf("write %s: %w", label, err)
+	}
+	activeAudit.written(label, outBytes)
+	if status == "rendered (empty)" {
+		fmt.Fprintf(w.Stdout(), "rendered %s -> %s (empty)\n", name, label)
+	} else {
+		fmt.Fprintf(w.Stdout(), "rendered %s -> %s\n", name, label)
+	}
+	return status, nil
+}
+
+// add writes a file entry, preceded by entries for its parent directories.
+func (a *outputArchive) add(name string, data []byte) error {
+	if err := a.addDirs(path.Dir(name)); err != nil {
+		return err
+	}
+	if a.zw != nil {
+		fh := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: a.mtime}
+		fh.SetMode(0o644)
+		w, err := a.zw.CreateHeader(fh)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	hdr := &tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: a.mtime, Format: tar.FormatPAX}
+	if err := a.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := a.tw.Write(data)
+	return err
+}
+
+func (a *outputArchive) addDirs(dir string) error {
+	if dir == "." || dir == "/" || a.dirs[dir] {
+		return nil
+	}
+	if err := a.addDirs(path.Dir(dir)); err != nil {
+		return err
+	}
+	a.dirs[dir] = true
+	if a.zw != nil {
+		fh := &zip.FileHeader{Name: dir + "/", Modified: a.mtime}
+		fh.SetMode(os.ModeDir | 0o755)
+		_, err := a.zw.CreateHeader(fh)
+		return err
+	}
+	return a.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir + "/", Mode: 0o755, ModTime: a.mtime, Format: tar.FormatPAX})
+}
+
+// commit finishes the archive and moves it into place.
+func (a *outputArchive) commit() error {
+	if a.dryRun {
+		return nil
+	}
+	var closers []io.Closer
+	if a.tw != nil {
+		closers = append(closers, a.tw)
+	}
+	if a.gz != nil {
+		closers = append(closers, a.gz)
+	}
+	if a.zw != nil {
+		closers = append(closers, a.zw)
+	}
+	closers = append(closers, a.f)
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			a.discard()
+			return fmt.Errorf("write archive %s: %w", a.path, err)
+		}
+	}
+	if err := os.Chmod(a.f.Name(), 0o644); err != nil {
+		a.discard()
+		return fmt.Errorf("write archive %s: %w", a.path, err)
+	}
+	if err := os.Rename(a.f.Name(), a.path); err != nil {
+		a.discard()
+		return fmt.Errorf("write archive %s: %w", a.path, err)
+	}
+	a.f = nil
+	return nil
+}
+
+// discard removes the unfinished archive; it does nothing after commit.
+func (a *outputArchive) discard() {
+	if a.f == nil {
+		return
+	}
+	_ = a.f.Close()
+	_ = os.Remove(a.f.Name())
+}
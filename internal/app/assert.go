@@ -0,0 +1,181 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// assertContext is the data --assert expressions and policies are evaluated with.
+type assertContext struct {
+	Path   string         // output path, "stdout" when printing
+	Output string         // rendered content, before guard injection
+	Data   any            // Output parsed as YAML/JSON/TOML, nil if it is not
+	Values map[string]any // the values the file was rendered with
+}
+
+// outputChecks evaluates the --assert expressions and --policy rules against
+// each rendered file before it is written, and counts the violations.
+type outputChecks struct {
+	exprs    []string
+	tpls     []*template.Template
+	policies *policySet
+	validate []ValidateRule
+	failed   int // violated assertions
+	denied   int // enforced policy violations
+	invalid  int // outputs rejected by a validator
+}
+
+// newOutputChecks parses the --assert expressions and loads the policies. An
+// expression is a template pipeline ("eq .Data.server.port 8080") or a full
+// template; it holds when it renders "true".
+func newOutputChecks(shared SharedOptions) (*outputChecks, error) {
+	c := &outputChecks{}
+	for _, expr := range shared.Asserts {
+		tpl, err := parseAssertion(expr, shared)
+		if err != nil {
+			return nil, argsError(fmt.Errorf("invalid --assert %q: %w", expr, err))
+		}
+		c.exprs = append(c.exprs, expr)
+		c.tpls = append(c.tpls, tpl)
+	}
+	policies, err := loadPolicies(shared)
+	if err != nil {
+		return nil, err
+	}
+	c.policies = policies
+	if err := checkValidateRules(shared.Validate); err != nil {
+		return nil, err
+	}
+	c.validate = shared.Validate
+	return c, nil
+}
+
+// parseAssertion parses an assertion expression, wrapping a bare pipeline in
+// the template delimiters.
+func parseAssertion(expr string, shared SharedOptions) (*template.Template, error) {
+	src := expr
+	if !strings.Contains(src, shared.Ldelim) {
+		src = shared.Ldelim + " " + src + " " + shared.Rdelim
+	}
+	tpl := template.New("assert").Delims(shared.Ldelim, shared.Rdelim).Option("missingkey=zero")
+	if _, err := tpl.Funcs(buildFuncMapWithOptions(&tpl, shared)).Parse(src); err != nil {
+		return nil, err
+	}
+	return tpl, nil
+}
+
+// evalAssertion reports whether tpl renders "true" for ctx.
+func evalAssertion(tpl *template.Template, ctx assertContext) (bool, error) {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, ctx); err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(buf.String()) == "true", nil
+}
+
+// check evaluates every assertion, policy and validator for one rendered
+// file, reports the violations to w and returns false if the file must not
+// be written. template names the template the file was rendered from.
+func (c *outputChecks) check(w messageWriter, template, path string, out []byte, values map[string]any) bool {
+	if len(c.tpls) == 0 && c.policies.empty() && len(c.validate) == 0 {
+		return true
+	}
+	ctx := assertContext{Path: path, Output: string(out), Data: parseOutputData(path, out), Values: values}
+	ok := true
+	for i, tpl := range c.tpls {
+		holds, err := evalAssertion(tpl, ctx)
+		switch {
+		case err != nil:
+			writeCheckError(w.Stderr(), "assert", "%s: assertion %q: %v", path, c.exprs[i], err)
+		case !holds:
+			writeCheckError(w.Stderr(), "assert", "%s: assertion failed: %s", path, c.exprs[i])
+		default:
+			continue
+		}
+		ok = false
+		c.failed++
+	}
+	if denied := c.policies.check(ctx, w.Stderr()); denied > 0 {
+		ok = false
+		c.denied += denied
+	}
+	for _, problem := range validateOutput(c.validate, template, path, out) {
+		writeCheckError(w.Stderr(), "validate", "%s (from %s): %s", path, template, problem)
+		ok = false
+		c.invalid++
+	}
+	return ok
+}
+
+// checkOutput runs c.check for the single output of a run, reporting its
+// violations together.
+func checkOutput(c *outputChecks, template, path string, out []byte, values map[string]any) bool {
+	g := sink.group()
+	defer g.flush()
+	return c.check(g, template, path, out, values)
+}
+
+// err returns the error the run fails with when an assertion or an enforced
+// policy was violated.
+func (c *outputChecks) err() error {
+	switch {
+	case c.failed > 0:
+		return exitError(ExitAssertFailed, "assert", fmt.Errorf("%d assertion%s failed", c.failed, pluralize(c.failed)))
+	case c.denied > 0:
+		return exitError(ExitPolicyViolation, "policy", fmt.Errorf("%d policy violation%s", c.denied, pluralize(c.denied)))
+	case c.invalid > 0:
+		return exitError(ExitValidateFailed, "validate", fmt.Errorf("%d output%s failed validation", c.invalid, pluralize(c.invalid)))
+	}
+	return nil
+}
+
+// outputLabel names an output in assertion reports: its path, or "stdout".
+func outputLabel(out string) string {
+	if out == "" {
+		return "stdout"
+	}
+	return out
+}
+
+// checkErrorf reports a violated check without exiting, so that every file
+// is checked.
+func checkErrorf(kind, format string, a ...any) {
+	writeCheckError(sink.Stderr(), kind, format, a...)
+}
+
+// writeCheckError writes a violated check as checkErrorf does, to w.
+func writeCheckError(w io.Writer, kind, format string, a ...any) {
+	if logFormat == LogFormatJSON {
+		writeLogRecord(w, logRecord{Level: "error", Kind: kind, Message: fmt.Sprintf(format, a...)})
+		return
+	}
+	fmt.Fprintf(w, "[templr:error:%s] %s\n", kind, fmt.Sprintf(format, a...))
+}
+
+// parseOutputData parses rendered output for .Data: TOML by extension,
+// anything else as YAML, which covers JSON. Plain text yields nil.
+func parseOutputData(path string, out []byte) any {
+	var data any
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		var m map[string]any
+		if err := toml.Unmarshal(out, &m); err != nil {
+			return nil
+		}
+		return m
+	}
+	if err := yaml.Unmarshal(out, &data); err != nil {
+		return nil
+	}
+	switch data.(type) {
+	case map[string]any, []any:
+		return data
+	}
+	return nil
+}
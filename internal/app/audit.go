@@ -0,0 +1,188 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// auditSyslog is the --audit-log value that sends records to the system log
+// instead of a file.
+const auditSyslog = "syslog"
+
+// auditRecord is one line of the audit log: who ran which render when, what
+// it read and which outputs it changed.
+type auditRecord struct {
+	Time     string      `json:"time"`
+	User     string      `json:"user"`
+	Host     string      `json:"host"`
+	Command  string      `json:"command"`
+	Argv     []string    `json:"argv"`
+	Version  string      `json:"version"`
+	Inputs   []auditFile `json:"inputs"`
+	Outputs  []auditFile `json:"outputs"`
+	Result   string      `json:"result"` // "ok" or "error"
+	Error    string      `json:"error,omitempty"`
+	Duration string      `json:"duration"`
+}
+
+// auditFile is a file read or changed by a render, with its SHA-256.
+type auditFile struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind,omitempty"` // inputs: "template" or "values"
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// auditTrail collects the record of the running command. Outputs are
+// reported by writeIfChanged and the non-directory output trees, so there
+// is one active trail per process, like the command span.
+type auditTrail struct {
+	dst     string
+	command string
+	started time.Time
+	redact  []string // --set keys hidden in the recorded arguments
+
+	mu  sync.Mutex
+	rec auditRecord
+}
+
+var activeAudit *auditTrail
+
+// errAuditExited is the error recorded for a command ended by Exit.
+var errAuditExited = errors.New("exited before completing")
+
+// startAudit starts the audit record of command when --audit-log is set. A
+// dry run changes nothing and is not recorded; both return a nil trail,
+// whose methods do nothing.
+func startAudit(command string, shared SharedOptions) *auditTrail {
+	if shared.AuditLog == "" || shared.DryRun {
+		return nil
+	}
+	a := &auditTrail{dst: shared.AuditLog, command: command, started: time.Now(), redact: shared.Redact}
+	a.rec.Inputs, a.rec.Outputs = []auditFile{}, []auditFile{}
+	activeAudit = a
+	// strict mode errors end the process without returning
+	onExit(func() {
+		if activeAudit == a {
+			_ = a.finish(errAuditExited)
+		}
+	})
+	return a
+}
+
+// input records a template or values file read from b.
+func (a *auditTrail) input(name, kind string, b []byte) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rec.Inputs = append(a.rec.Inputs, auditFile{Name: filepath.ToSlash(name), Kind: kind, SHA256: sha256Digest(b)["sha256"]})
+}
+
+// inputSource records a template from its parsed source.
+func (a *auditTrail) inputSource(name, kind, src string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rec.Inputs = append(a.rec.Inputs, auditFile{Name: filepath.ToSlash(name), Kind: kind, SHA256: sha256StringDigest(src)["sha256"]})
+}
+
+// inputFiles records files read by the run; unreadable ones are listed
+// without a digest.
+func (a *auditTrail) inputFiles(kind string, paths ...string) {
+	if a == nil {
+		return
+	}
+	for _, p := range paths {
+		b, _ := os.ReadFile(p)
+		a.input(p, kind, b)
+	}
+}
+
+// written records an output whose content changed.
+func (a *auditTrail) written(name string, b []byte) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rec.Outputs = append(a.rec.Outputs, auditFile{Name: name, SHA256: sha256Digest(b)["sha256"]})
+}
+
+// finish appends the record with the outcome of the command and returns
+// err, or the error writing the record if the command succeeded: a run
+// that cannot be audited must not pass silently.
+func (a *auditTrail) finish(err error) error {
+	if a == nil {
+		return err
+	}
+	activeAudit = nil
+	r := &a.rec
+	r.Time = a.started.UTC().Format(time.RFC3339)
+	r.Duration = time.Since(a.started).Round(time.Millisecond).String()
+	r.Command = a.command
+	r.Argv = redactArgs(os.Args, a.redact)
+	r.Version = GetVersion()
+	r.Host, _ = os.Hostname()
+	if u, uerr := user.Current(); uerr == nil {
+		r.User = u.Username
+	} else {
+		r.User = os.Getenv("USER")
+	}
+	r.Result = "ok"
+	if err != nil {
+		r.Result, r.Error = "error", scrubSecrets(err.Error())
+	}
+	line, merr := json.Marshal(r)
+	if merr != nil {
+		return firstError(err, merr)
+	}
+	var werr error
+	if a.dst == auditSyslog {
+		werr = writeSyslog(line)
+	} else {
+		werr = appendAuditLine(a.dst, line)
+	}
+	if werr != nil {
+		werr = exitError(ExitGeneral, "audit", fmt.Errorf("audit log %s: %w", a.dst, werr))
+	}
+	return firstError(err, werr)
+}
+
+// appendAuditLine appends one JSON line to the audit file at path. The line
+// is written with a single call on a file opened for appending, so
+// concurrent runs do not interleave records.
+func appendAuditLine(path string, line []byte) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// firstError returns the first non-nil error.
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
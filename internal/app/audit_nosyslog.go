@@ -0,0 +1,13 @@
+//go:build windows || plan9 || js
+
+package app
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// writeSyslog reports that the platform has no system log to audit to.
+func writeSyslog([]byte) error {
+	return fmt.Errorf("syslog is not available on %s; use a file", runtime.GOOS)
+}
@@ -0,0 +1,15 @@
+//go:build !windows && !plan9 && !js
+
+package app
+
+import "log/syslog"
+
+// writeSyslog sends an audit record to the local system log.
+func writeSyslog(line []byte) error {
+	w, err := syslog.New(syslog.LOG_NOTICE|syslog.LOG_USER, "templr")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = w.Close() }()
+	return w.Notice(string(line))
+}
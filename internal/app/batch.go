@@ -0,0 +1,276 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+// maxBatchLine is the longest job line batch mode reads (inline templates
+// and values travel in the line).
+const maxBatchLine = 64 << 20
+
+// BatchOptions holds options for batch mode
+type BatchOptions struct {
+	Shared  SharedOptions
+	Workers int    // jobs rendered at once (0: one per CPU)
+	Helpers string // helper templates loaded next to each template file
+}
+
+// batchJob is one line of the batch input.
+type batchJob struct {
+	ID       string         `json:"id,omitempty"`
+	Template string         `json:"template,omitempty"` // template file
+	Inline   string         `json:"inline,omitempty"`   // template source, instead of a file
+	Values   map[string]any `json:"values,omitempty"`   // merged over the shared values
+	Out      string         `json:"out,omitempty"`      // output file (default: "output" of the result)
+}
+
+// batchResult is one line of the batch output.
+type batchResult struct {
+	Line   int    `json:"line"`
+	ID     string `json:"id,omitempty"`
+	Out    string `json:"out,omitempty"`
+	Status string `json:"status"` // rendered, unchanged, changed (dry-run), empty, skipped, error
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RunBatchMode renders the jobs of in, one JSON object per line, with a pool
+// of opts.Workers goroutines and writes one result per line to stdout, in
+// the order the jobs finish. A failed job does not stop the others; the run
+// fails at the end when any did.
+func RunBatchMode(opts BatchOptions, in io.Reader) (err error) {
+	span := startCommandSpan("templr.batch")
+	defer func() { templr.EndSpan(span, err) }()
+
+	if err := checkPathStyle(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkGuardStyles(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkCryptoPolicy(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkMaxOutputSize(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkFeatures(opts.Shared); err != nil {
+		return err
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	base, err := buildValues(".", opts.Shared)
+	if err != nil {
+		return err
+	}
+
+	type lineJob struct {
+		line int
+		job  batchJob
+		err  error
+	}
+	jobs := make(chan lineJob)
+	results := make(chan batchResult)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for lj := range jobs {
+				res := batchResult{Line: lj.line, ID: lj.job.ID, Out: lj.job.Out}
+				if lj.err != nil {
+					res.Status, res.Error = "error", lj.err.Error()
+				} else {
+					runBatchJob(lj.job, base, opts, &res)
+				}
+				results <- res
+			}
+		}()
+	}
+
+	var readErr error
+	go func() {
+		defer close(jobs)
+		sc := bufio.NewScanner(in)
+		sc.Buffer(make([]byte, 0, 64<<10), maxBatchLine)
+		line := 0
+		for sc.Scan() {
+			line++
+			text := strings.TrimSpace(sc.Text())
+			if text == "" {
+				continue
+			}
+			lj := lineJob{line: line}
+			if err := json.Unmarshal([]byte(text), &lj.job); err != nil {
+				lj.err = fmt.Errorf("bad job: %w", err)
+			}
+			jobs <- lj
+		}
+		readErr = sc.Err()
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	enc := json.NewEncoder(sink.Stdout())
+	enc.SetEscapeHTML(false)
+	total, failed := 0, 0
+	var writeErr error
+	for res := range results {
+		total++
+		if res.Status == "error" {
+			failed++
+		}
+		if res.Out != "" {
+			res.Out = displayPath(res.Out, opts.Shared)
+		}
+		if err := enc.Encode(res); err != nil && writeErr == nil {
+			writeErr = err
+		}
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	if readErr != nil {
+		return fmt.Errorf("read jobs: %w", readErr)
+	}
+	if failed > 0 {
+		return exitError(ExitGeneral, "batch", fmt.Errorf("%d of %d job%s failed", failed, total, pluralize(total)))
+	}
+	return nil
+}
+
+// runBatchJob renders job and writes its output, recording the outcome in res.
+func runBatchJob(job batchJob, base map[string]any, opts BatchOptions, res *batchResult) {
+	out, err := renderBatchJob(job, base, opts)
+	if err == nil && job.Out != "" {
+		res.Status, err = writeBatchOutput(job.Out, out, opts.Shared)
+	}
+	switch {
+	case err != nil:
+		res.Status, res.Error = "error", err.Error()
+	case job.Out != "":
+	case isEmpty(out):
+		res.Status = "empty"
+	default:
+		res.Status, res.Output = "rendered", string(out)
+	}
+}
+
+// renderBatchJob renders one job with its own copy of the shared values, so
+// that jobs running at once never share a map.
+func renderBatchJob(job batchJob, base map[string]any, opts BatchOptions) ([]byte, error) {
+	if (job.Template == "") == (job.Inline == "") {
+		return nil, fmt.Errorf("a job needs one of template or inline")
+	}
+	values := deepMerge(copyValues(base).(map[string]any), copyValues(job.Values).(map[string]any))
+
+	src, label, filesRoot := []byte(job.Inline), "inline", "."
+	if job.Template != "" {
+		b, err := os.ReadFile(job.Template)
+		if err != nil {
+			return nil, fmt.Errorf("read template: %w", err)
+		}
+		src, label = b, job.Template
+		if abs, err := filepath.Abs(job.Template); err == nil {
+			filesRoot = filepath.Dir(abs)
+		}
+	}
+	addValuesNamespace(values, opts.Shared)
+	values["Files"] = FilesAPI{Root: filesRoot}
+	addFeatures(values, opts.Shared)
+
+	var tpl *template.Template
+	tpl = template.New("root").Funcs(buildFuncMapWithOptions(&tpl, opts.Shared)).Option("missingkey=default")
+	if opts.Shared.Strict {
+		tpl = tpl.Option("missingkey=error")
+	}
+	tpl = tpl.Delims(opts.Shared.Ldelim, opts.Shared.Rdelim)
+	sources := newTemplateSources()
+	text := string(src)
+	sources.set("root", text)
+
+	// Sidecar helpers of template files, as in render mode
+	if job.Template != "" && opts.Helpers != "" {
+		matches, _ := filepath.Glob(filepath.Join(filesRoot, opts.Helpers))
+		for _, hp := range matches {
+			b, err := os.ReadFile(hp)
+			if err != nil {
+				continue
+			}
+			name := filepath.Base(hp)
+			helper := string(b)
+			sources.set(name, helper)
+			if _, err := parseRaw(tpl.New(name), helper, opts.Shared); err != nil {
+				return nil, fmt.Errorf("parse helper %s: %w", hp, newTemplateError("parse", err, sources, ""))
+			}
+		}
+	}
+
+	tpl, err := parseRaw(tpl, text, opts.Shared)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", newTemplateError("parse", err, sources, label))
+	}
+	if err := computeHelperVars(tpl, values); err != nil {
+		return nil, fmt.Errorf("helpers: %w", newTemplateError("render", err, sources, label))
+	}
+	out, err := renderToBuffer(tpl, "", values, opts.Shared)
+	if err != nil {
+		return nil, newTemplateError("render", err, sources, label)
+	}
+	return applyDefaultMissing(out, opts.Shared.DefaultMissing), nil
+}
+
+// writeBatchOutput writes one job's output like walk mode (empty output and
+// files without the guard are skipped, unchanged files are left alone) and
+// returns its status. It prints nothing: the status goes in the result line.
+func writeBatchOutput(path string, out []byte, shared SharedOptions) (string, error) {
+	if isEmpty(out) {
+		return "empty", nil
+	}
+	ok, err := canOverwrite(path, shared.Guard)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("guard check %s: %w", path, err)
+	}
+	if !ok {
+		return "skipped", nil
+	}
+	if shared.InjectGuard {
+		out = injectGuardForExt(path, out, shared)
+	}
+	out, err = encodeOutput(path, out, shared)
+	if err != nil {
+		return "", fmt.Errorf("encode %s: %w", path, err)
+	}
+	if shared.DryRun {
+		if same, _ := fastEqual(path, out); same {
+			return "unchanged", nil
+		}
+		return "changed", nil
+	}
+	changed, err := writeIfChanged(path, out, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("write %s: %w", path, err)
+	}
+	if !changed {
+		return "unchanged", nil
+	}
+	return "rendered", nil
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// binaryExts are the extensions of common binary files. Files with one are
+// never read as templates, even when --ext or files.extensions lists it.
+var binaryExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".bmp": true, ".ico": true, ".webp": true, ".tiff": true,
+	".pdf": true, ".zip": true, ".gz": true, ".tgz": true, ".bz2": true, ".xz": true, ".zst": true, ".7z": true, ".tar": true, ".jar": true,
+	".exe": true, ".dll": true, ".so": true, ".dylib": true, ".a": true, ".o": true, ".class": true, ".pyc": true, ".wasm": true, ".bin": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".mp3": true, ".mp4": true, ".mov": true, ".wav": true, ".ogg": true,
+	".sqlite": true, ".db": true,
+}
+
+// binarySniffLen is how much of a file is searched for NUL bytes, as git
+// does to tell binary files from text.
+const binarySniffLen = 8000
+
+// binaryReason returns why the file name with content src is binary rather
+// than a template: its extension, a NUL byte, or invalid UTF-8. It returns ""
+// for text.
+func binaryReason(name string, src []byte) string {
+	if ext := strings.ToLower(filepath.Ext(name)); binaryExts[ext] {
+		return fmt.Sprintf("%s files are binary", ext)
+	}
+	if i := bytes.IndexByte(src[:min(len(src), binarySniffLen)], 0); i >= 0 {
+		return fmt.Sprintf("NUL byte at offset %d", i)
+	}
+	if !utf8.Valid(src) {
+		return "not valid UTF-8"
+	}
+	return ""
+}
+
+// skipBinary reports, with a warning, whether the template file rel with
+// content src is binary and is left out of the templates.
+func skipBinary(rel string, src []byte) bool {
+	reason := binaryReason(rel, src)
+	if reason == "" {
+		return false
+	}
+	warnf("binary", "skip %s: %s, not a template", rel, reason)
+	return true
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// What walk does when several templates render to the same output path.
+const (
+	OnCollisionError = "error" // fail before anything is written (default)
+	OnCollisionFirst = "first" // keep the output of the first template in name order
+	OnCollisionLast  = "last"  // keep the output of the last template in name order
+)
+
+// checkOnCollision validates --on-collision.
+func checkOnCollision(policy string) error {
+	switch policy {
+	case "", OnCollisionError, OnCollisionFirst, OnCollisionLast:
+		return nil
+	}
+	return argsError(fmt.Errorf("invalid --on-collision %q: want first, last or error", policy))
+}
+
+// outputCollisions finds the templates of names rendering to the same output
+// path, outputs[name] being the output path of a rendered template. With the
+// error policy it fails naming the templates of the first collision; with
+// first or last it returns the templates whose output is dropped, each with
+// the template whose output is kept.
+func outputCollisions(names []string, outputs map[string]string, policy string) (map[string]string, error) {
+	byPath := map[string][]string{}
+	var paths []string
+	for _, name := range names {
+		out, ok := outputs[name]
+		if !ok {
+			continue
+		}
+		if byPath[out] == nil {
+			paths = append(paths, out)
+		}
+		byPath[out] = append(byPath[out], name)
+	}
+	dropped := map[string]string{}
+	for _, out := range paths {
+		group := byPath[out]
+		if len(group) < 2 {
+			continue
+		}
+		keep := group[0]
+		switch policy {
+		case OnCollisionFirst:
+		case OnCollisionLast:
+			keep = group[len(group)-1]
+		default:
+			both := "both"
+			if len(group) > 2 {
+				both = "all"
+			}
+			list := strings.Join(group[:len(group)-1], ", ") + " and " + group[len(group)-1]
+			return nil, fmt.Errorf("templates %s %s render to %s; rename one, or use --on-collision first or last to keep one output", list, both, out)
+		}
+		for _, name := range group {
+			if name != keep {
+				dropped[name] = keep
+				warnf("collision", "%s renders to %s like %s; keeping the output of %s", name, out, keep, keep)
+			}
+		}
+	}
+	return dropped, nil
+}
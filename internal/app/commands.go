@@ -3,66 +3,140 @@ package app
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/kanopi/templr/pkg/templr"
+	"go.opentelemetry.io/otel/attribute"
 	"gopkg.in/yaml.v3"
 )
 
 // SharedOptions contains flags common to all commands
 type SharedOptions struct {
-	Data           string
-	Files          []string
-	Sets           []string
-	Strict         bool
-	DryRun         bool
-	Guard          string
-	InjectGuard    bool
-	DefaultMissing string
-	NoColor        bool
-	Debug          bool
-	Ldelim         string
-	Rdelim         string
-	ExtraExts      []string
+	Data             string
+	Files            []string
+	Sets             []string
+	Strict           bool
+	DryRun           bool
+	DryRunExitCode   bool   // with DryRun, exit with ExitChangesPending when an output would change
+	PathStyle        string // "slash" (default) or "native" for reported output paths
+	Guard            string
+	InjectGuard      bool
+	DefaultMissing   string
+	NoColor          bool
+	Debug            bool
+	Ldelim           string
+	Rdelim           string
+	ExtraExts        []string
+	StripSuffixes    []string            // files.strip_suffixes: suffixes trimmed from output names instead of the template extension
+	OutputExts       map[string]string   // files.output_extensions: template suffix to output extension, e.g. ".yml.j2" to ".yaml"
+	DisabledFuncs    []string            // template functions removed from the func map
+	ExplainMissing   bool                // report undefined references after a non-strict render
+	AllowDuplicates  bool                // let a later file override a template name defined by another file
+	IsolateValues    bool                // render each template with its own deep copy of the values
+	KeepEmpty        bool                // create files for empty renders instead of skipping them
+	KeepEmptyPaths   []string            // output path globs that keep empty renders
+	QuietEmpty       bool                // do not report skipped empty renders
+	Encoding         string              // force the output encoding: utf-8, utf-8-bom or utf-16le
+	PreserveEncoding bool                // keep the BOM and line endings of existing output files
+	GuardStyle       string              // comment style for the injected guard, overriding the file type
+	GuardStyles      map[string]string   // comment styles by extension (".vue") or file name
+	GuardPosition    string              // where the injected guard goes, overriding the file type
+	GuardPositions   map[string]string   // guard positions by extension or file name
+	EnvKey           string              // dotted key that env-file values are nested under
+	ResolveRefs      bool                // resolve ${.dotted.key} references between values
+	Schema           string              // schema that types --set and env-file values
+	SchemaSHA256     string              // required sha256 digest of Schema
+	TemplateScopes   []TemplateScope     // per-path delimiters and strictness for dir and walk trees
+	Asserts          []string            // expressions every rendered file must satisfy
+	Policies         []string            // policy files and directories checked against rendered files
+	PolicyMode       string              // enforce (default) or warn
+	IncludeCache     int                 // memoize include with up to this many renders
+	IncludeMaxDepth  int                 // fail include calls nested deeper than this (0: default)
+	MaxOutputSize    string              // per-file output ceiling, e.g. "100MiB"; "0" disables it
+	CryptoPolicy     string              // "fips" rejects the non-approved crypto helpers
+	ValueTemplates   bool                // let renderValueTemplate render templates stored in values
+	AuditLog         string              // append a JSON record of each non-dry-run render to this file, or "syslog"
+	DockerfileLabels bool                // append templr provenance LABELs to rendered Dockerfiles
+	Redact           []string            // keys whose values debug output, reports and errors hide
+	Validate         []ValidateRule      // built-in validators run on matching outputs before they are written
+	Engines          map[string]string   // template engine by extension, over templr.DefaultEngineExts
+	DebugTopFuncs    int                 // with Debug, how many of the slowest template functions to list
+	RequireSchema    bool                // validate the values against Schema before rendering anything
+	SchemaEnforce    string              // schema.enforce: "render" implies RequireSchema, "off" or empty
+	Features         []string            // --feature name[=value] flags, exposed as .Templr.Features
+	FeatureSets      []string            // --feature-set names of features.sets to enable
+	FeaturesEnabled  []string            // features.enabled: flags on in every render
+	FeatureSetDefs   map[string][]string // features.sets: named sets of flags
+	ValuesNamespace  bool                // expose the values under .Values too, like Helm
 }
 
 // WalkOptions contains options specific to walk mode
 type WalkOptions struct {
-	Shared SharedOptions
-	Src    string
-	Dst    string
+	Shared      SharedOptions
+	Src         string
+	Dst         string
+	DstArchive  string       // write the outputs into this tar/zip file instead of Dst
+	GHASummary  bool         // append a Markdown summary to $GITHUB_STEP_SUMMARY
+	Rename      []RenameRule // output path rewrite rules, first match wins
+	Flatten     bool         // write outputs directly under Dst, dropping source directories
+	OnCollision string       // templates rendering to the same output: error (default), first or last
+	HelmChart   string       // write the outputs as the templates of a Helm chart in this directory
+
+	Provenance    string // write a provenance statement of the run to this file
+	ProvenanceKey string // Ed25519 private key PEM signing the provenance
+	Frozen        bool   // fail on outputs Provenance does not list or that change while inputs did not
+
+	AffectedBy string // old values files; only render templates reading a key they changed
+	Since      string // git ref; only render templates changed since, or reading a changed key
+
+	tree outputTree // collects the outputs instead of Dst (set by k8s apply)
 }
 
 // DirOptions contains options specific to directory mode
 type DirOptions struct {
-	Shared SharedOptions
-	Dir    string
-	In     string
-	Out    string
+	Shared    SharedOptions
+	Dir       string
+	In        string
+	Out       string
+	Entries   []string // additional entry templates or glob patterns
+	OutputDir string   // render each entry to OutputDir/<name without template ext>
+	Entry     string   // entry template name when -i is not given (config dir.entry)
 }
 
 // RenderOptions contains options specific to single-file render mode
 type RenderOptions struct {
-	Shared  SharedOptions
-	In      string
-	Out     string
-	Helpers string
+	Shared     SharedOptions
+	In         string // template file; empty or "-" reads stdin
+	Out        string
+	Helpers    string
+	HelpersDir string // directory searched for Helpers (default: the template's directory)
+	Engine     string // template engine, overriding the one of the template's extension
 }
 
 // SchemaOptions contains options for schema commands
 type SchemaOptions struct {
 	Shared          SharedOptions
 	SchemaPath      string
+	SHA256          string // required sha256 digest of the schema
 	Mode            string
 	Output          string
 	Required        string
 	AdditionalProps bool
 	Format          string
+	OpenAPI         string // schema import: OpenAPI document
+	CRD             string // schema import: CustomResourceDefinition manifest
+	Pointer         string // schema import: JSON pointer of the schema to import
+	CRDVersion      string // schema import: CRD version (default: the storage version)
 }
 
 // buildFuncMap creates the template function map with Sprig and custom functions.
@@ -72,30 +146,63 @@ func buildFuncMap(tpl **template.Template) template.FuncMap {
 	return templr.BuildFuncMap(tpl)
 }
 
-// buildFuncMapWithOptions creates the template function map with custom options
-func buildFuncMapWithOptions(tpl **template.Template, strict bool, defaultMissing string) template.FuncMap {
+// buildFuncMapWithOptions creates the template function map for the shared options
+func buildFuncMapWithOptions(tpl **template.Template, shared SharedOptions) template.FuncMap {
+	features, _ := featureMap(shared) // validated by checkFeatures
 	return templr.BuildFuncMapWithOptions(tpl, &templr.FuncMapOptions{
-		Strict:         strict,
-		DefaultMissing: defaultMissing,
+		Strict:         shared.Strict,
+		DefaultMissing: shared.DefaultMissing,
 		WarnFunc: func(msg string) {
-			warnf("include", "%s", msg) // Output warnings for missing templates
+			// Warnings come from include (missing templates) or deprecated functions
+			if rest, ok := strings.CutPrefix(msg, "deprecated: "); ok {
+				warnf("deprecated", "%s", rest)
+				return
+			}
+			warnf("include", "%s", msg)
 		},
+		DisabledFuncs:   shared.DisabledFuncs,
+		IncludeCache:    shared.IncludeCache,
+		IncludeMaxDepth: shared.IncludeMaxDepth,
+		CryptoPolicy:    shared.CryptoPolicy,
+		ValueTemplates:  shared.ValueTemplates,
+		Calls:           funcCalls(shared),
+		Features:        features,
 	})
 }
 
 // All template functions have been moved to pkg/templr.BuildFuncMap for code sharing
 // between the CLI and web playground.
 
-// buildValues constructs the values map from defaults, data files, and --set overrides
-func buildValues(baseDir string, shared SharedOptions) (map[string]any, error) {
+// buildValues constructs the values map from defaults, data files, and --set overrides.
+// A data file whose _when guard does not hold is not merged. With shared.Schema,
+// --set and env-file values take the types the schema declares.
+func buildValues(baseDir string, shared SharedOptions) (values map[string]any, err error) {
+	span := startStepSpan("templr.load_values")
+	defer func() { templr.EndSpan(span, err) }()
+
 	debugSection(shared.Debug, "Value Loading Sequence")
-	values := map[string]any{}
+	values = map[string]any{}
+
+	// With a schema, --set and env-file values get the types it declares
+	schema, err := loadValueSchema(shared.Schema, shared.SchemaSHA256)
+	if err != nil {
+		return nil, exitError(ExitSchemaError, "schema", err)
+	}
+	if schema != nil {
+		debugf(shared.Debug, "Typing --set and env-file values by schema %s", shared.Schema)
+	}
+
+	// --set is parsed up front: the _when guards of values files can read it
+	sets, err := parseSets(shared.Sets, schema)
+	if err != nil {
+		return nil, err
+	}
 
 	// Load default values.yaml from baseDir if it exists
 	debugf(shared.Debug, "Loading default values from %s", baseDir)
 	def, err := loadDefaultValues(baseDir)
 	if err != nil {
-		return nil, fmt.Errorf("load default values: %w", err)
+		return nil, exitError(ExitDataError, "data", fmt.Errorf("load default values: %w", err))
 	}
 	if len(def) > 0 {
 		debugf(shared.Debug, "  → Loaded %d key(s) from default values.yaml", len(def))
@@ -112,9 +219,18 @@ func buildValues(baseDir string, shared SharedOptions) (map[string]any, error) {
 	// Load --data file if specified
 	if shared.Data != "" {
 		debugf(shared.Debug, "Loading data from --data=%s", shared.Data)
-		add, err := loadData(shared.Data)
+		add, err := loadValuesFile(shared.Data, shared)
 		if err != nil {
-			return nil, fmt.Errorf("load data: %w", err)
+			return nil, exitError(ExitDataError, "data", fmt.Errorf("load data: %w", err))
+		}
+		if err := coerceEnvValues(shared.Data, add, schema); err != nil {
+			return nil, exitError(ExitSchemaError, "schema", err)
+		}
+		if ok, err := valuesFileApplies(shared.Data, add, values, sets, shared); err != nil {
+			return nil, exitError(ExitDataError, "data", err)
+		} else if !ok {
+			debugf(shared.Debug, "  → Skipped: %s is false", valuesWhenKey)
+			add = nil
 		}
 		debugf(shared.Debug, "  → Loaded %d key(s)", len(add))
 		if shared.Debug {
@@ -128,9 +244,18 @@ func buildValues(baseDir string, shared SharedOptions) (map[string]any, error) {
 	// Load -f files
 	for _, f := range shared.Files {
 		debugf(shared.Debug, "Loading data from -f %s", f)
-		add, err := loadData(f)
+		add, err := loadValuesFile(f, shared)
 		if err != nil {
-			return nil, fmt.Errorf("load -f %s: %w", f, err)
+			return nil, exitError(ExitDataError, "data", fmt.Errorf("load -f %s: %w", f, err))
+		}
+		if err := coerceEnvValues(f, add, schema); err != nil {
+			return nil, exitError(ExitSchemaError, "schema", err)
+		}
+		if ok, err := valuesFileApplies(f, add, values, sets, shared); err != nil {
+			return nil, exitError(ExitDataError, "data", err)
+		} else if !ok {
+			debugf(shared.Debug, "  → Skipped: %s is false", valuesWhenKey)
+			continue
 		}
 		debugf(shared.Debug, "  → Loaded %d key(s)", len(add))
 		if shared.Debug {
@@ -145,165 +270,526 @@ func buildValues(baseDir string, shared SharedOptions) (map[string]any, error) {
 	if len(shared.Sets) > 0 {
 		debugf(shared.Debug, "Applying %d --set override(s)", len(shared.Sets))
 	}
-	for _, kv := range shared.Sets {
-		idx := strings.Index(kv, "=")
-		if idx <= 0 {
-			return nil, fmt.Errorf("--set expects key=value, got: %s", kv)
+	for _, s := range sets {
+		val := s.val
+		if sensitiveKey(strings.Split(s.key, "."), shared.Redact) {
+			val = redacted
 		}
-		key := kv[:idx]
-		val := parseScalar(kv[idx+1:])
-		debugf(shared.Debug, "  → Setting %s = %v", key, val)
-		setByDottedKey(values, key, val)
+		debugf(shared.Debug, "  → Setting %s = %v", s.key, val)
+		setByDottedKey(values, s.key, s.val)
 	}
 
-	debugValues(shared.Debug, values, "Final Merged Values")
+	if shared.ResolveRefs {
+		debugf(shared.Debug, "Resolving ${.key} references")
+		if err := resolveValueRefs(values); err != nil {
+			return nil, exitError(ExitDataError, "data", err)
+		}
+	}
+
+	noteSecrets(values, shared.Redact)
+	debugValues(shared, values, "Final Merged Values")
 
 	return values, nil
 }
 
 // RunWalkMode executes walk mode: recursively render all templates in src to dst
-func RunWalkMode(opts WalkOptions) error {
-	if opts.Src == "" || opts.Dst == "" {
-		return fmt.Errorf("-walk requires -src and -dst")
+func RunWalkMode(opts WalkOptions) (err error) {
+	defer func() { err = dryRunExit(opts.Shared, err) }()
+	span := startCommandSpan("templr.walk")
+	defer func() { templr.EndSpan(span, err) }()
+	defer reportFuncCalls(opts.Shared)
+	started := time.Now()
+
+	if err := checkDryRunExitCode(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkPathStyle(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkGuardStyles(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkCryptoPolicy(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkMaxOutputSize(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkSchemaEnforce(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkFeatures(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkEngines("", opts.Shared); err != nil {
+		return err
+	}
+	if err := checkOnCollision(opts.OnCollision); err != nil {
+		return err
+	}
+	if err := checkProvenanceOptions(opts); err != nil {
+		return err
+	}
+	if err := checkFrozenOptions(opts); err != nil {
+		return err
+	}
+	if err := checkArchiveOptions(opts); err != nil {
+		return err
+	}
+	if err := checkRemoteOptions(opts); err != nil {
+		return err
+	}
+	if err := checkHelmChartOptions(opts); err != nil {
+		return err
+	}
+	if err := checkAffectedOptions(opts); err != nil {
+		return err
+	}
+	checks, err := newOutputChecks(opts.Shared)
+	if err != nil {
+		return err
+	}
+
+	if opts.Src == "" || (opts.Dst == "" && opts.DstArchive == "" && opts.HelmChart == "" && opts.tree == nil) {
+		return argsError(fmt.Errorf("-walk requires -src and -dst"))
 	}
 
 	absSrc, _ := filepath.Abs(opts.Src)
 	absDst, _ := filepath.Abs(opts.Dst)
 
+	renameRules, err := compileRenameRules(opts.Rename)
+	if err != nil {
+		return err
+	}
+
+	audit := startAudit("walk", opts.Shared)
+	defer func() { err = audit.finish(err) }()
+
 	// Build values
+	audit.inputFiles("values", valuesInputs(absSrc, opts.Shared)...)
 	values, err := buildValues(absSrc, opts.Shared)
 	if err != nil {
 		return err
 	}
+	if err := requireSchema(values, opts.Shared); err != nil {
+		return err
+	}
+	var changed []string
+	var since *sinceChanges
+	switch {
+	case opts.AffectedBy != "":
+		if changed, err = changedKeys(absSrc, opts.AffectedBy, values, opts.Shared); err != nil {
+			return err
+		}
+	case opts.Since != "":
+		if since, err = gitChangesSince(opts.Since); err != nil {
+			return err
+		}
+		if changed, err = since.valueKeys(absSrc, values, opts.Shared); err != nil {
+			return err
+		}
+	}
 
 	// Add .Files API
+	addValuesNamespace(values, opts.Shared)
 	values["Files"] = FilesAPI{Root: absSrc}
+	addFeatures(values, opts.Shared)
 
 	// Create template with functions
 	var tpl *template.Template
-	funcs := buildFuncMapWithOptions(&tpl, opts.Shared.Strict, opts.Shared.DefaultMissing)
+	funcs := buildFuncMapWithOptions(&tpl, opts.Shared)
 	tpl = template.New("root").Funcs(funcs).Option("missingkey=default")
 	if opts.Shared.Strict {
 		tpl = tpl.Option("missingkey=error")
 	}
 	tpl = tpl.Delims(opts.Shared.Ldelim, opts.Shared.Rdelim)
 
-	// Parse ALL templates (so includes/partials are available)
+	// Parse ALL templates (so includes/partials are available); those of
+	// the Jinja engine are rendered on their own
 	allowExts := buildAllowedExts(opts.Shared.ExtraExts)
+	jinja := jinjaExts(opts.Shared)
+	goExts := map[string]bool{}
+	for ext := range allowExts {
+		goExts[ext] = !jinja[filepath.Ext(ext)] // .yaml.j2 is still Jinja
+	}
+	for ext := range jinja {
+		allowExts[ext] = true
+	}
+	scopes, err := loadTemplateScopes(absSrc, opts.Shared)
+	if err != nil {
+		return err
+	}
 	var names []string
-	var sources map[string][]byte
-	tpl, names, sources, err = readAllTplsIntoSet(tpl, absSrc, allowExts)
+	var sources *templateSources
+	tpl, names, sources, err = readAllTplsIntoSet(tpl, absSrc, goExts, opts.Shared.AllowDuplicates, scopes)
+	if err != nil {
+		return fmt.Errorf("parse tree: %w", newTemplateError("parse", err, sources, ""))
+	}
+	jinjaSrcs, jinjaNames, err := readJinjaTemplates(absSrc, jinja)
 	if err != nil {
-		return fmt.Errorf("parse tree: %w", err)
+		return fmt.Errorf("read jinja templates: %w", err)
+	}
+	for _, name := range jinjaNames {
+		sources.set(name, string(jinjaSrcs[name]))
+	}
+	names = append(names, jinjaNames...)
+	sort.Strings(names)
+	for _, name := range names {
+		src, _ := sources.get(name)
+		audit.inputSource(name, "template", src)
+	}
+	frozen, err := loadFrozenManifest(opts, absSrc, names)
+	if err != nil {
+		return err
 	}
 
 	// Compute helper-driven variables (templr.vars)
 	if err := computeHelperVars(tpl, values); err != nil {
-		return fmt.Errorf("helpers: %w", err)
+		return fmt.Errorf("helpers: %w", newTemplateError("render", err, sources, ""))
+	}
+
+	// an archive or bucket instead of a directory
+	tree := opts.tree
+	switch {
+	case tree != nil:
+	case opts.DstArchive != "":
+		if tree, err = newOutputArchive(opts.DstArchive, opts.Shared.DryRun, started); err != nil {
+			return err
+		}
+	case opts.HelmChart != "":
+		if tree, err = newHelmChart(opts.HelmChart, values, opts.Shared); err != nil {
+			return err
+		}
+	case isRemoteDst(opts.Dst):
+		if tree, err = newRemoteTree(opts.Dst, opts.Shared.DryRun); err != nil {
+			return err
+		}
+	}
+	if tree != nil {
+		defer tree.discard()
 	}
 
-	// Render each non-partial template; skip empty; enforce guard on overwrite
+	// --affected-by-values-diff and --since: templates reading no changed key
+	// and rendering no changed template are left alone
+	var deps *valueDeps
+	var defs map[string]bool
+	if opts.AffectedBy != "" || since != nil {
+		deps = newValueDeps(tpl)
+	}
+	if since != nil {
+		defs = since.changedDefs(tpl, absSrc)
+		if since.filesChanged(absSrc, names, opts.Shared) {
+			changed = append(changed, "Files")
+		}
+	}
+
+	// Output paths, checked for templates rendering to the same file before
+	// anything is written
+	outputs := map[string]string{}
 	for _, name := range names {
 		if !shouldRender(name) {
 			continue
 		}
-		relOut := trimAnyExt(name, allowExts)
+		relOut, perr := outputRelPath(name, allowExts, opts.Shared, renameRules, opts.Flatten)
+		if perr != nil {
+			return perr
+		}
+		outputs[name] = relOut
+	}
+	dropped, err := outputCollisions(names, outputs, opts.OnCollision)
+	if err != nil {
+		return err
+	}
+
+	// Render each non-partial template; skip empty; enforce guard on overwrite.
+	// The messages about a file (check violations, guard warnings, its status
+	// line) are written together once it is done.
+	var records []renderRecord
+	missing := newMissingRefs()
+	entries, affected := 0, 0
+	msgs := sink.group()
+	defer msgs.flush()
+	for _, name := range names {
+		msgs.flush()
+		relOut, ok := outputs[name]
+		if !ok {
+			continue
+		}
 		dstPath := filepath.Join(absDst, filepath.FromSlash(relOut))
+		entries++
+		if keep, ok := dropped[name]; ok {
+			records = append(records, renderRecord{name, displayPath(dstPath, opts.Shared), "skipped (collides with " + keep + ")"})
+			continue
+		}
+		src, isJinja := jinjaSrcs[name]
+		if deps != nil {
+			// Jinja templates are not analysed, so they always count as affected
+			if !isJinja && !affectedSince(deps.of(name), defs, changed) {
+				records = append(records, renderRecord{name, displayPath(dstPath, opts.Shared), "skipped (unaffected)"})
+				continue
+			}
+			affected++
+		}
 
 		// render to buffer first
-		outBytes, rerr := renderToBuffer(tpl, name, values)
-		if rerr != nil {
-			if opts.Shared.Strict {
-				strictErrf(rerr, sources, opts.Shared.NoColor)
+		tv := templateValues(values, opts.Shared)
+		tv["Existing"] = ExistingAPI{}
+		if tree == nil {
+			tv["Existing"] = ExistingAPI{Path: dstPath}
+		}
+		var outBytes []byte
+		if isJinja {
+			if outBytes, err = renderJinja(absSrc, name, src, tv, opts.Shared); err != nil {
+				return fmt.Errorf("render error %s: %w", name, err)
+			}
+		} else {
+			strict := scopes.prepare(tpl, name)
+			var rerr error
+			if outBytes, rerr = renderToBuffer(tpl, name, tv, opts.Shared); rerr != nil {
+				if strict && !errors.Is(rerr, errOutputTooLarge) {
+					strictErrf(rerr, sources, "", opts.Shared.NoColor)
+				}
+				return fmt.Errorf("render error %s: %w", name, newTemplateError("render", rerr, sources, ""))
+			}
+			if opts.Shared.ExplainMissing && !strict {
+				missing.collect(tpl, name, tv, "")
 			}
-			return fmt.Errorf("render error %s: %w", name, rerr)
 		}
+		delete(tv, "Existing")
 		// apply global default-missing replacement
 		outBytes = applyDefaultMissing(outBytes, opts.Shared.DefaultMissing)
+		if outBytes, err = addDockerfileLabels(outBytes, relOut, name, values, opts.Shared); err != nil {
+			return err
+		}
 
-		if isEmpty(outBytes) {
-			if opts.Shared.DryRun {
-				fmt.Printf("[dry-run] skip empty %s (no file created)\n", dstPath)
-			}
+		// files violating an --assert or an enforced --policy are not written
+		if !isEmpty(outBytes) && !checks.check(msgs, name, relOut, outBytes, values) {
+			records = append(records, renderRecord{name, displayPath(dstPath, opts.Shared), "skipped (check failed)"})
+			continue
+		}
+		// --frozen: outputs the provenance statement does not account for
+		if ok, ferr := frozen.allows(relOut, dstPath, outBytes, opts.Shared); ferr != nil {
+			return ferr
+		} else if !ok {
+			records = append(records, renderRecord{name, displayPath(dstPath, opts.Shared), "skipped (frozen)"})
 			continue
 		}
 
-		// Guard check BEFORE any mkdir/write
-		ok, gerr := canOverwrite(dstPath, opts.Shared.Guard)
-		if gerr != nil && !os.IsNotExist(gerr) {
-			return fmt.Errorf("guard check %s: %w", dstPath, gerr)
+		var status string
+		var werr error
+		switch {
+		case tree != nil:
+			dstPath = tree.label(relOut)
+			status, werr = tree.writeOutput(msgs, name, relOut, outBytes, keepEmpty(relOut, opts.Shared), opts.Shared)
+		case isEmpty(outBytes) && keepEmpty(relOut, opts.Shared):
+			status, werr = writeEmptyOutput(msgs, name, dstPath, opts.Shared)
+		default:
+			status, werr = writeOutput(msgs, name, dstPath, outBytes, opts.Shared)
 		}
-		if !ok {
-			if opts.Shared.DryRun {
-				fmt.Printf("[dry-run] skip (guard missing) %s\n", dstPath)
-			} else {
-				warnf("guard", "skip (guard missing) %s", dstPath)
-			}
-			continue
+		if werr != nil {
+			return werr
+		}
+		records = append(records, renderRecord{name, displayPath(dstPath, opts.Shared), status})
+	}
+	msgs.flush()
+	missing.report()
+	switch {
+	case since != nil:
+		fmt.Fprintf(sink.Stdout(), "%d of %d templates changed since %s or read one of the %d changed key%s%s\n",
+			affected, entries, opts.Since, len(changed), pluralize(len(changed)), changedKeysSummary(changed))
+	case deps != nil:
+		fmt.Fprintf(sink.Stdout(), "%d of %d templates read the %d changed key%s%s\n",
+			affected, entries, len(changed), pluralize(len(changed)), changedKeysSummary(changed))
+	}
+
+	if tree != nil {
+		if err := tree.commit(); err != nil {
+			return err
 		}
+	} else if err := templr.PruneEmptyDirs(absDst); err != nil {
+		// Cleanup: remove empty directories under dst
+		return fmt.Errorf("prune: %w", err)
+	}
+
+	if opts.GHASummary {
+		if err := appendStepSummary(renderSummaryMarkdown(records)); err != nil {
+			return err
+		}
+	}
+	if err := checks.err(); err != nil {
+		return err
+	}
+	if err := frozen.err(); err != nil {
+		return err
+	}
 
+	// attest only complete runs; a frozen run is held to the statement, not recorded in it
+	if opts.Provenance != "" && !opts.Frozen {
 		if opts.Shared.DryRun {
-			simulated := outBytes
-			if opts.Shared.InjectGuard {
-				simulated = injectGuardForExt(dstPath, simulated, opts.Shared.Guard)
-				if !bytes.Equal(simulated, outBytes) {
-					fmt.Printf("[dry-run] would inject guard into %s\n", dstPath)
-				}
-			}
-			// Check if file would change
-			same, _ := fastEqual(dstPath, simulated)
-			if same {
-				fmt.Printf("[dry-run] would skip unchanged %s\n", dstPath)
-			} else {
-				fmt.Printf("[dry-run] would render %s -> %s (changed)\n", name, dstPath)
-			}
-			continue
+			warnf("provenance", "dry run: %s not written", opts.Provenance)
+			return nil
 		}
+		return writeProvenance(opts, absSrc, names, records, started)
+	}
+	return nil
+}
 
-		// Optionally inject guard comment
-		if opts.Shared.InjectGuard {
-			outBytes = injectGuardForExt(dstPath, outBytes, opts.Shared.Guard)
+// outputTree receives the outputs of a walk that go somewhere other than a
+// directory: an archive (--dst-archive), an object storage bucket, a Helm
+// chart (--as-helm-chart), or the ConfigMap built by k8s apply.
+type outputTree interface {
+	// label names the output relOut in status lines and summaries.
+	label(relOut string) string
+	// writeOutput stores one rendered template (keep: even if empty),
+	// reporting to w, and returns its status, like writeOutput does for a
+	// directory.
+	writeOutput(w messageWriter, name, relOut string, outBytes []byte, keep bool, shared SharedOptions) (string, error)
+	// commit completes a successful walk.
+	commit() error
+	// discard cleans up after a failed walk; it does nothing after commit.
+	discard()
+}
+
+// templateValues returns the values one template of a walk or multi-entry dir
+// run renders with: the shared map, or a deep copy with --isolate-values so
+// that set/setd/mergeDeep in one template cannot leak into the next.
+func templateValues(values map[string]any, shared SharedOptions) map[string]any {
+	if !shared.IsolateValues {
+		return values
+	}
+	return copyValues(values).(map[string]any)
+}
+
+// writeOutput writes one rendered template to dstPath the way walk mode does:
+// empty output is skipped, existing files must carry the guard, dry-run only
+// reports, and the file is written only when its content changed. Messages
+// go to w; it returns the status recorded for the step summary.
+func writeOutput(w messageWriter, name, dstPath string, outBytes []byte, shared SharedOptions) (string, error) {
+	if isEmpty(outBytes) {
+		if shared.DryRun {
+			fmt.Fprintf(w.Stdout(), "[dry-run] skip empty %s (no file created)\n", displayPath(dstPath, shared))
 		}
-		// Write only if content changed
-		changed, err := writeIfChanged(dstPath, outBytes, 0o644)
+		return "skipped (empty)", nil
+	}
+
+	// Guard check BEFORE any mkdir/write
+	ok, gerr := canOverwrite(dstPath, shared.Guard)
+	if gerr != nil && !os.IsNotExist(gerr) {
+		return "", exitError(ExitGuardSkipped, "guard", fmt.Errorf("guard check %s: %w", dstPath, gerr))
+	}
+	if !ok {
+		if shared.DryRun {
+			fmt.Fprintf(w.Stdout(), "[dry-run] skip (guard missing) %s\n", displayPath(dstPath, shared))
+		} else {
+			writeWarning(w.Stderr(), "guard", "skip (guard missing) %s", displayPath(dstPath, shared))
+		}
+		return "skipped (guard missing)", nil
+	}
+
+	if shared.DryRun {
+		simulated := outBytes
+		if shared.InjectGuard {
+			simulated = injectGuardForExt(dstPath, simulated, shared)
+			if !bytes.Equal(simulated, outBytes) {
+				fmt.Fprintf(w.Stdout(), "[dry-run] would inject guard into %s\n", displayPath(dstPath, shared))
+			}
+		}
+		simulated, err := encodeOutput(dstPath, simulated, shared)
 		if err != nil {
-			return fmt.Errorf("write %s: %w", dstPath, err)
+			return "", fmt.Errorf("encode %s: %w", dstPath, err)
 		}
-		if changed {
-			fmt.Printf("rendered %s -> %s\n", name, dstPath)
+		// Check if file would change; an unchanged file is reported as in a real run
+		if same, _ := fastEqual(dstPath, simulated); same {
+			fmt.Fprintf(w.Stdout(), "[dry-run] would skip unchanged %s\n", displayPath(dstPath, shared))
+			return "unchanged", nil
 		}
+		fmt.Fprintf(w.Stdout(), "[dry-run] would render %s -> %s (changed)\n", name, displayPath(dstPath, shared))
+		noteDryRunChange()
+		return "dry-run", nil
 	}
 
-	// Cleanup: remove empty directories under dst
-	if err := templr.PruneEmptyDirs(absDst); err != nil {
-		return fmt.Errorf("prune: %w", err)
+	// Optionally inject guard comment
+	if shared.InjectGuard {
+		outBytes = injectGuardForExt(dstPath, outBytes, shared)
 	}
-
-	return nil
+	outBytes, err := encodeOutput(dstPath, outBytes, shared)
+	if err != nil {
+		return "", fmt.Errorf("encode %s: %w", dstPath, err)
+	}
+	// Write only if content changed
+	changed, err := writeIfChanged(dstPath, outBytes, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("write %s: %w", dstPath, err)
+	}
+	if !changed {
+		return "unchanged", nil
+	}
+	fmt.Fprintf(w.Stdout(), "rendered %s -> %s\n", name, displayPath(dstPath, shared))
+	return "rendered", nil
 }
 
 // RunDirMode executes directory mode: parse all templates in dir, execute one entry
 //
 //nolint:gocyclo,cyclop // orchestration function with inherent complexity
-func RunDirMode(opts DirOptions) error {
+func RunDirMode(opts DirOptions) (err error) {
+	defer func() { err = dryRunExit(opts.Shared, err) }()
+	span := startCommandSpan("templr.dir")
+	defer func() { templr.EndSpan(span, err) }()
+	defer reportFuncCalls(opts.Shared)
+
+	if err := checkDryRunExitCode(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkPathStyle(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkGuardStyles(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkCryptoPolicy(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkMaxOutputSize(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkSchemaEnforce(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkFeatures(opts.Shared); err != nil {
+		return err
+	}
+	checks, err := newOutputChecks(opts.Shared)
+	if err != nil {
+		return err
+	}
+
 	if opts.Dir == "" {
-		return fmt.Errorf("--dir is required")
+		return argsError(fmt.Errorf("--dir is required"))
 	}
 
 	absDir, _ := filepath.Abs(opts.Dir)
 
+	audit := startAudit("dir", opts.Shared)
+	defer func() { err = audit.finish(err) }()
+
 	// Build values
+	audit.inputFiles("values", valuesInputs(absDir, opts.Shared)...)
 	values, err := buildValues(absDir, opts.Shared)
 	if err != nil {
 		return err
 	}
+	if err := requireSchema(values, opts.Shared); err != nil {
+		return err
+	}
 
 	// Add .Files API
+	addValuesNamespace(values, opts.Shared)
 	values["Files"] = FilesAPI{Root: absDir}
+	addFeatures(values, opts.Shared)
 
 	// Create template with functions
 	var tpl *template.Template
-	funcs := buildFuncMapWithOptions(&tpl, opts.Shared.Strict, opts.Shared.DefaultMissing)
+	funcs := buildFuncMapWithOptions(&tpl, opts.Shared)
 	tpl = template.New("root").Funcs(funcs).Option("missingkey=default")
 	if opts.Shared.Strict {
 		tpl = tpl.Option("missingkey=error")
@@ -312,60 +798,96 @@ func RunDirMode(opts DirOptions) error {
 
 	// Parse all *.tpl in dir using path-based names
 	allowExts := buildAllowedExts(opts.Shared.ExtraExts)
+	scopes, err := loadTemplateScopes(absDir, opts.Shared)
+	if err != nil {
+		return err
+	}
 	var names []string
-	var sources map[string][]byte
-	tpl, names, sources, err = readAllTplsIntoSet(tpl, absDir, allowExts)
+	var sources *templateSources
+	tpl, names, sources, err = readAllTplsIntoSet(tpl, absDir, allowExts, opts.Shared.AllowDuplicates, scopes)
 	if err != nil {
-		return fmt.Errorf("parse dir templates: %w", err)
+		return fmt.Errorf("parse dir templates: %w", newTemplateError("parse", err, sources, ""))
+	}
+	for _, name := range names {
+		src, _ := sources.get(name)
+		audit.inputSource(name, "template", src)
+	}
+
+	// -i - reads the entry template from stdin; it can include the templates of --dir
+	if opts.In == "-" {
+		if len(opts.Entries) > 0 || opts.OutputDir != "" {
+			return argsError(fmt.Errorf("-i - cannot be combined with several entries or --output-dir"))
+		}
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read stdin: %w", err)
+		}
+		text := string(b)
+		sources.set("stdin", text)
+		audit.input("stdin", "template", b)
+		if _, err := parseRaw(tpl.New("stdin"), text, opts.Shared); err != nil {
+			return fmt.Errorf("parse stdin: %w", newTemplateError("parse", err, sources, ""))
+		}
 	}
 
 	// Compute helper-driven variables (templr.vars)
 	if err := computeHelperVars(tpl, values); err != nil {
-		return fmt.Errorf("helpers: %w", err)
+		return fmt.Errorf("helpers: %w", newTemplateError("render", err, sources, ""))
+	}
+
+	// Several entries (or a glob) render each entry to its own file
+	if len(opts.Entries) > 0 || opts.OutputDir != "" || hasGlobMeta(opts.In) {
+		return renderDirEntries(opts, tpl, names, sources, values, allowExts, checks, scopes)
 	}
 
 	// Determine entry template name
 	entryName := ""
-	if opts.In != "" {
-		// If -in is a file path, convert to rel name; otherwise assume it's already a template name.
-		if info, err := os.Stat(opts.In); err == nil && !info.IsDir() {
-			if rel, er := filepath.Rel(absDir, opts.In); er == nil {
-				entryName = filepath.ToSlash(rel)
-			} else {
-				entryName = filepath.Base(opts.In)
-			}
-		} else {
-			entryName = opts.In
-		}
-	} else if tpl.Lookup("root") != nil {
-		entryName = "root"
-	} else if len(names) > 0 {
-		entryName = names[0]
-	} else {
-		return fmt.Errorf("no templates found in --dir")
+	if opts.In == "-" {
+		entryName = "stdin"
+	} else if opts.In != "" {
+		entryName = dirEntryName(absDir, opts.In)
+	} else if entryName, err = defaultDirEntry(tpl, names, allowExts, opts.Entry); err != nil {
+		return err
 	}
 
 	// render to buffer
-	outBytes, rerr := renderToBuffer(tpl, entryName, values)
+	strict := scopes.prepare(tpl, entryName)
+	outBytes, rerr := renderToBuffer(tpl, entryName, values, opts.Shared)
 	if rerr != nil {
-		if opts.Shared.Strict {
-			strictErrf(rerr, sources, opts.Shared.NoColor)
+		if strict && !errors.Is(rerr, errOutputTooLarge) {
+			strictErrf(rerr, sources, "", opts.Shared.NoColor)
 		}
-		return rerr
+		return newTemplateError("render", rerr, sources, "")
+	}
+	if opts.Shared.ExplainMissing && !strict {
+		missing := newMissingRefs()
+		missing.collect(tpl, entryName, values, "")
+		missing.report()
 	}
 	// apply global default-missing replacement
 	outBytes = applyDefaultMissing(outBytes, opts.Shared.DefaultMissing)
+	if outBytes, err = addDockerfileLabels(outBytes, firstNonEmpty(opts.Out, entryName), entryName, values, opts.Shared); err != nil {
+		return err
+	}
+
+	if !isEmpty(outBytes) && !checkOutput(checks, entryName, outputLabel(opts.Out), outBytes, values) {
+		return checks.err()
+	}
 
 	if isEmpty(outBytes) {
 		target := "stdout"
 		if opts.Out != "" {
-			target = opts.Out
+			target = displayPath(opts.Out, opts.Shared)
+		}
+		if opts.Out != "" && keepEmpty(opts.Out, opts.Shared) {
+			_, err := writeEmptyOutput(sink, entryName, opts.Out, opts.Shared)
+			return err
 		}
 		if opts.Shared.DryRun {
-			fmt.Printf("[dry-run] skip empty render for entry %s -> %s\n", entryName, target)
+			fmt.Fprintf(sink.Stdout(), "[dry-run] skip empty render for entry %s -> %s\n", entryName, target)
 			return nil
 		}
-		fmt.Fprintf(os.Stderr, "skipping empty render for entry %s -> %s\n", entryName, target)
+		emptyNoticef(opts.Shared, "skipping empty render for entry %s -> %s", entryName, target)
 		return nil
 	}
 
@@ -373,13 +895,13 @@ func RunDirMode(opts DirOptions) error {
 	if opts.Out != "" {
 		ok, gerr := canOverwrite(opts.Out, opts.Shared.Guard)
 		if gerr != nil && !os.IsNotExist(gerr) {
-			return fmt.Errorf("guard check %s: %w", opts.Out, gerr)
+			return exitError(ExitGuardSkipped, "guard", fmt.Errorf("guard check %s: %w", opts.Out, gerr))
 		}
 		if !ok {
 			if opts.Shared.DryRun {
-				fmt.Printf("[dry-run] skip (guard missing) %s\n", opts.Out)
+				fmt.Fprintf(sink.Stdout(), "[dry-run] skip (guard missing) %s\n", displayPath(opts.Out, opts.Shared))
 			} else {
-				warnf("guard", "skip (guard missing) %s", opts.Out)
+				warnf("guard", "skip (guard missing) %s", displayPath(opts.Out, opts.Shared))
 			}
 			return nil
 		}
@@ -388,28 +910,33 @@ func RunDirMode(opts DirOptions) error {
 	if opts.Shared.DryRun {
 		target := "stdout"
 		if opts.Out != "" {
-			target = opts.Out
+			target = displayPath(opts.Out, opts.Shared)
 		}
 		if opts.Out != "" && opts.Shared.InjectGuard {
-			simulated := injectGuardForExt(opts.Out, outBytes, opts.Shared.Guard)
+			simulated := injectGuardForExt(opts.Out, outBytes, opts.Shared)
 			if !bytes.Equal(simulated, outBytes) {
-				fmt.Printf("[dry-run] would inject guard into %s\n", opts.Out)
+				fmt.Fprintf(sink.Stdout(), "[dry-run] would inject guard into %s\n", displayPath(opts.Out, opts.Shared))
 			}
 		}
 		// Check if file would change
 		if opts.Out != "" {
 			simToCheck := outBytes
 			if opts.Shared.InjectGuard {
-				simToCheck = injectGuardForExt(opts.Out, outBytes, opts.Shared.Guard)
+				simToCheck = injectGuardForExt(opts.Out, outBytes, opts.Shared)
+			}
+			simToCheck, err := encodeOutput(opts.Out, simToCheck, opts.Shared)
+			if err != nil {
+				return fmt.Errorf("encode out: %w", err)
 			}
 			same, _ := fastEqual(opts.Out, simToCheck)
 			if same {
-				fmt.Printf("[dry-run] would skip unchanged %s\n", opts.Out)
+				fmt.Fprintf(sink.Stdout(), "[dry-run] would skip unchanged %s\n", displayPath(opts.Out, opts.Shared))
 			} else {
-				fmt.Printf("[dry-run] would render entry %s -> %s (changed)\n", entryName, target)
+				fmt.Fprintf(sink.Stdout(), "[dry-run] would render entry %s -> %s (changed)\n", entryName, target)
+				noteDryRunChange()
 			}
 		} else {
-			fmt.Printf("[dry-run] would render entry %s -> %s\n", entryName, target)
+			fmt.Fprintf(sink.Stdout(), "[dry-run] would render entry %s -> %s\n", entryName, target)
 		}
 		return nil
 	}
@@ -418,7 +945,11 @@ func RunDirMode(opts DirOptions) error {
 	if opts.Out != "" {
 		// Optionally inject guard comment
 		if opts.Shared.InjectGuard {
-			outBytes = injectGuardForExt(opts.Out, outBytes, opts.Shared.Guard)
+			outBytes = injectGuardForExt(opts.Out, outBytes, opts.Shared)
+		}
+		outBytes, err := encodeOutput(opts.Out, outBytes, opts.Shared)
+		if err != nil {
+			return fmt.Errorf("encode out: %w", err)
 		}
 		// Write only if content changed
 		changed, err := writeIfChanged(opts.Out, outBytes, 0o644)
@@ -426,23 +957,249 @@ func RunDirMode(opts DirOptions) error {
 			return fmt.Errorf("write out: %w", err)
 		}
 		if changed {
-			fmt.Printf("rendered entry %s -> %s\n", entryName, opts.Out)
+			fmt.Fprintf(sink.Stdout(), "rendered entry %s -> %s\n", entryName, displayPath(opts.Out, opts.Shared))
 		}
 		return nil
 	}
 
-	if _, err := os.Stdout.Write(outBytes); err != nil {
+	outBytes, err = encodeOutput("", outBytes, opts.Shared)
+	if err != nil {
+		return fmt.Errorf("encode output: %w", err)
+	}
+	if _, err := sink.Stdout().Write(outBytes); err != nil {
 		return err
 	}
+	audit.written("stdout", outBytes)
 	return nil
 }
 
+// dirEntryName converts an -in value to a template name: a file path becomes
+// its path relative to absDir; anything else is taken as a template name.
+func dirEntryName(absDir, in string) string {
+	info, err := os.Stat(in)
+	if err != nil || info.IsDir() {
+		return in
+	}
+	abs, _ := filepath.Abs(in)
+	if rel, er := filepath.Rel(absDir, abs); er == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.Base(in)
+}
+
+// dirEntryConventions are the template names, without their template
+// extension, that make a file at the top of --dir the entry.
+var dirEntryConventions = []string{"index", "main"}
+
+// defaultDirEntry picks the entry template of dir mode when -i is not given:
+// the configured entry (dir.entry), a template defined as "root", an
+// index.tpl or main.tpl at the top of the directory, or the only template
+// that is not a partial. Several candidates are an error listing them rather
+// than an arbitrary pick.
+func defaultDirEntry(tpl *template.Template, names []string, allowExts map[string]bool, configured string) (string, error) {
+	if configured != "" {
+		name := filepath.ToSlash(filepath.Clean(configured))
+		if !slices.Contains(names, name) {
+			return "", exitError(ExitTemplateError, "template", fmt.Errorf("dir.entry template %q not found in --dir", configured))
+		}
+		return name, nil
+	}
+	if tpl.Lookup("root") != nil {
+		return "root", nil
+	}
+	var conventional, candidates []string
+	for _, n := range names {
+		if !shouldRender(n) {
+			continue
+		}
+		candidates = append(candidates, n)
+		if !strings.Contains(n, "/") && slices.Contains(dirEntryConventions, trimAnyExt(n, allowExts)) {
+			conventional = append(conventional, n)
+		}
+	}
+	switch {
+	case len(conventional) == 1:
+		return conventional[0], nil
+	case len(conventional) > 1:
+		candidates = conventional
+	case len(candidates) == 1:
+		return candidates[0], nil
+	case len(names) == 0:
+		return "", exitError(ExitTemplateError, "template", fmt.Errorf("no templates found in --dir"))
+	case len(candidates) == 0:
+		return "", exitError(ExitTemplateError, "template", fmt.Errorf("--dir only has partials (%s); pass -i to render one", strings.Join(names, ", ")))
+	}
+	return "", argsError(fmt.Errorf("cannot choose the entry template among %s; pass -i, set dir.entry, or name one index.tpl", strings.Join(candidates, ", ")))
+}
+
+// hasGlobMeta reports whether s contains glob metacharacters.
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// resolveDirEntries expands the -in values of dir mode into template names,
+// in order and without duplicates. Glob patterns match template names
+// relative to the directory (or paths relative to the working directory) and
+// skip partials; every pattern must match at least one template.
+func resolveDirEntries(absDir string, patterns, names []string) ([]string, error) {
+	var entries []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			entries = append(entries, name)
+		}
+	}
+	known := map[string]bool{}
+	for _, n := range names {
+		known[n] = true
+	}
+
+	for _, pat := range patterns {
+		if !hasGlobMeta(pat) {
+			name := dirEntryName(absDir, pat)
+			if !known[name] {
+				return nil, exitError(ExitTemplateError, "template", fmt.Errorf("entry template %q not found in --dir", pat))
+			}
+			add(name)
+			continue
+		}
+		matched := false
+		for _, n := range names {
+			if !shouldRender(n) {
+				continue
+			}
+			ok, err := path.Match(filepath.ToSlash(pat), n)
+			if err != nil {
+				return nil, argsError(fmt.Errorf("bad entry pattern %q: %w", pat, err))
+			}
+			if !ok {
+				// Also accept patterns written relative to the working directory
+				if abs, aerr := filepath.Abs(pat); aerr == nil {
+					ok, _ = filepath.Match(abs, filepath.Join(absDir, filepath.FromSlash(n)))
+				}
+			}
+			if ok {
+				add(n)
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, exitError(ExitTemplateError, "template", fmt.Errorf("entry pattern %q matched no templates in --dir", pat))
+		}
+	}
+	return entries, nil
+}
+
+// renderDirEntries renders several dir-mode entries, each to
+// OutputDir/<name without template extension>, with the walk-mode guard and
+// write rules.
+func renderDirEntries(opts DirOptions, tpl *template.Template, names []string, sources *templateSources, values map[string]any, allowExts map[string]bool, checks *outputChecks, scopes *templateScopes) error {
+	if opts.OutputDir == "" {
+		return argsError(fmt.Errorf("several entry templates require --output-dir"))
+	}
+	if opts.Out != "" {
+		return argsError(fmt.Errorf("--out cannot be combined with --output-dir"))
+	}
+	absDir, _ := filepath.Abs(opts.Dir)
+	absOut, _ := filepath.Abs(opts.OutputDir)
+
+	patterns := opts.Entries
+	if opts.In != "" {
+		patterns = append([]string{opts.In}, patterns...)
+	}
+	if len(patterns) == 0 {
+		return argsError(fmt.Errorf("--output-dir requires at least one entry (-i)"))
+	}
+	entries, err := resolveDirEntries(absDir, patterns, names)
+	if err != nil {
+		return err
+	}
+
+	missing := newMissingRefs()
+	for _, name := range entries {
+		strict := scopes.prepare(tpl, name)
+		outBytes, rerr := renderToBuffer(tpl, name, templateValues(values, opts.Shared), opts.Shared)
+		if rerr != nil {
+			if strict && !errors.Is(rerr, errOutputTooLarge) {
+				strictErrf(rerr, sources, "", opts.Shared.NoColor)
+			}
+			return fmt.Errorf("render error %s: %w", name, newTemplateError("render", rerr, sources, ""))
+		}
+		if opts.Shared.ExplainMissing && !strict {
+			missing.collect(tpl, name, values, "")
+		}
+		outBytes = applyDefaultMissing(outBytes, opts.Shared.DefaultMissing)
+
+		relOut := outputName(name, allowExts, opts.Shared)
+		if outBytes, err = addDockerfileLabels(outBytes, relOut, name, values, opts.Shared); err != nil {
+			return err
+		}
+		dstPath := filepath.Join(absOut, filepath.FromSlash(relOut))
+		msgs := sink.group()
+		if !isEmpty(outBytes) && !checks.check(msgs, name, relOut, outBytes, values) {
+			msgs.flush()
+			continue
+		}
+		var werr error
+		if isEmpty(outBytes) && keepEmpty(relOut, opts.Shared) {
+			_, werr = writeEmptyOutput(msgs, name, dstPath, opts.Shared)
+		} else {
+			_, werr = writeOutput(msgs, name, dstPath, outBytes, opts.Shared)
+		}
+		msgs.flush()
+		if werr != nil {
+			return werr
+		}
+	}
+	missing.report()
+	return checks.err()
+}
+
 // RunRenderMode executes single-file render mode
 //
 //nolint:gocyclo,cyclop // orchestration function with inherent complexity
-func RunRenderMode(opts RenderOptions) error {
+func RunRenderMode(opts RenderOptions) (err error) {
+	defer func() { err = dryRunExit(opts.Shared, err) }()
+	span := startCommandSpan("templr.render")
+	defer func() { templr.EndSpan(span, err) }()
+	defer reportFuncCalls(opts.Shared)
+
+	if err := checkDryRunExitCode(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkPathStyle(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkGuardStyles(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkCryptoPolicy(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkMaxOutputSize(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkSchemaEnforce(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkFeatures(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkEngines(opts.Engine, opts.Shared); err != nil {
+		return err
+	}
+	checks, err := newOutputChecks(opts.Shared)
+	if err != nil {
+		return err
+	}
+
 	debugSection(opts.Shared.Debug, "Template Rendering Flow")
 
+	if opts.In == "-" {
+		opts.In = ""
+	}
+
 	// Determine Files.Root (dir of -in if present)
 	filesRoot := "."
 	if opts.In != "" {
@@ -454,32 +1211,28 @@ func RunRenderMode(opts RenderOptions) error {
 	}
 	debugf(opts.Shared.Debug, "Files.Root directory: %s", filesRoot)
 
+	audit := startAudit("render", opts.Shared)
+	defer func() { err = audit.finish(err) }()
+
 	// Build values
+	audit.inputFiles("values", valuesInputs(filesRoot, opts.Shared)...)
 	values, err := buildValues(filesRoot, opts.Shared)
 	if err != nil {
 		return err
 	}
+	if err := requireSchema(values, opts.Shared); err != nil {
+		return err
+	}
 
 	// Add .Files API
+	addValuesNamespace(values, opts.Shared)
 	values["Files"] = FilesAPI{Root: filesRoot}
+	addFeatures(values, opts.Shared)
 	debugf(opts.Shared.Debug, "Added .Files API with root: %s", filesRoot)
 
-	// Create template with functions
-	debugf(opts.Shared.Debug, "Creating template with delimiters: %s ... %s", opts.Shared.Ldelim, opts.Shared.Rdelim)
-	if opts.Shared.Strict {
-		debugf(opts.Shared.Debug, "Strict mode enabled (missingkey=error)")
-	}
-	var tpl *template.Template
-	funcs := buildFuncMapWithOptions(&tpl, opts.Shared.Strict, opts.Shared.DefaultMissing)
-	tpl = template.New("root").Funcs(funcs).Option("missingkey=default")
-	if opts.Shared.Strict {
-		tpl = tpl.Option("missingkey=error")
-	}
-	tpl = tpl.Delims(opts.Shared.Ldelim, opts.Shared.Rdelim)
-
 	// Read template source
 	var srcBytes []byte
-	sources := make(map[string][]byte)
+	sources := newTemplateSources()
 	tplName := "stdin"
 	if opts.In == "" {
 		debugf(opts.Shared.Debug, "Reading template from stdin")
@@ -491,83 +1244,59 @@ func RunRenderMode(opts RenderOptions) error {
 		debugf(opts.Shared.Debug, "Reading template from file: %s", opts.In)
 		srcBytes, err = os.ReadFile(opts.In)
 		if err != nil {
-			return fmt.Errorf("read template: %w", err)
+			return exitError(ExitTemplateError, "template", fmt.Errorf("read template: %w", err))
 		}
 		tplName = filepath.Base(opts.In)
 	}
 	debugf(opts.Shared.Debug, "Main template: %s (%d bytes)", tplName, len(srcBytes))
-	sources[tplName] = srcBytes
-	sources["root"] = srcBytes // Also map to "root" since that's what template.Parse uses
-
-	// Load sidecar helpers in the same directory based on -helpers glob (default: _helpers.tpl)
-	if filesRoot != "" && filesRoot != "." && opts.Helpers != "" {
-		pattern := filepath.Join(filesRoot, opts.Helpers)
-		debugf(opts.Shared.Debug, "Looking for helper templates: %s", pattern)
-		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
-			debugf(opts.Shared.Debug, "Found %d helper template(s)", len(matches))
-			for _, hp := range matches {
-				if b, e := os.ReadFile(hp); e == nil {
-					helperName := filepath.ToSlash(filepath.Base(hp))
-					debugf(opts.Shared.Debug, "  → Loading helper: %s (%d bytes)", helperName, len(b))
-					sources[helperName] = b
-					if _, e2 := tpl.New(helperName).Parse(string(b)); e2 != nil {
-						return fmt.Errorf("parse helper %s: %w", hp, e2)
-					}
-				}
-			}
-		} else {
-			debugf(opts.Shared.Debug, "  → No helper templates found")
-		}
-	}
-
-	debugf(opts.Shared.Debug, "Parsing main template")
-	tpl, err = tpl.Parse(string(srcBytes))
-	if err != nil {
-		return fmt.Errorf("parse: %w", err)
-	}
-
-	// Compute helper-driven variables (templr.vars)
-	debugf(opts.Shared.Debug, "Checking for templr.vars template")
-	if err := computeHelperVars(tpl, values); err != nil {
-		return fmt.Errorf("helpers: %w", err)
+	text := string(srcBytes)
+	sources.set(tplName, text)
+	sources.set("root", text) // Also map to "root" since that's what template.Parse uses
+	audit.input(firstNonEmpty(opts.In, "stdin"), "template", srcBytes)
+	label := "stdin" // shown in place of "root" in errors
+	if opts.In != "" {
+		label = opts.In
 	}
-	if tpl.Lookup("templr.vars") != nil {
-		debugf(opts.Shared.Debug, "  → templr.vars executed, values updated")
-		if opts.Shared.Debug {
-			debugValues(opts.Shared.Debug, values, "Values After templr.vars")
-		}
-	} else {
-		debugf(opts.Shared.Debug, "  → No templr.vars template found")
+	if reason := binaryReason(tplName, srcBytes); reason != "" {
+		return exitError(ExitTemplateError, "template", fmt.Errorf("%s: %s, not a template", label, reason))
 	}
 
-	// render to buffer
-	debugf(opts.Shared.Debug, "Rendering template")
-	outBytes, rerr := renderToBuffer(tpl, "", values)
-	if rerr != nil {
-		if opts.Shared.Strict {
-			strictErrf(rerr, sources, opts.Shared.NoColor)
+	var outBytes []byte
+	engine := firstNonEmpty(opts.Engine, templr.EngineFor(tplName, opts.Shared.Engines))
+	if engine == templr.EngineJinja {
+		debugf(opts.Shared.Debug, "Rendering template with the jinja engine")
+		if outBytes, err = renderJinja(filesRoot, label, srcBytes, values, opts.Shared); err != nil {
+			return err
 		}
-		return rerr
+	} else if outBytes, err = renderSingleGo(opts, audit, tplName, label, filesRoot, srcBytes, sources, values); err != nil {
+		return err
 	}
 	debugf(opts.Shared.Debug, "Render complete (%d bytes)", len(outBytes))
 
 	// apply global default-missing replacement
 	outBytes = applyDefaultMissing(outBytes, opts.Shared.DefaultMissing)
+	if outBytes, err = addDockerfileLabels(outBytes, firstNonEmpty(opts.Out, label), label, values, opts.Shared); err != nil {
+		return err
+	}
+
+	if !isEmpty(outBytes) && !checkOutput(checks, label, outputLabel(opts.Out), outBytes, values) {
+		return checks.err()
+	}
 
 	if isEmpty(outBytes) {
 		target := "stdout"
 		if opts.Out != "" {
-			target = opts.Out
+			target = displayPath(opts.Out, opts.Shared)
+		}
+		if opts.Out != "" && keepEmpty(opts.Out, opts.Shared) {
+			_, err := writeEmptyOutput(sink, label, opts.Out, opts.Shared)
+			return err
 		}
 		if opts.Shared.DryRun {
-			srcLabel := "stdin"
-			if opts.In != "" {
-				srcLabel = opts.In
-			}
-			fmt.Printf("[dry-run] skip empty render %s -> %s\n", srcLabel, target)
+			fmt.Fprintf(sink.Stdout(), "[dry-run] skip empty render %s -> %s\n", label, target)
 			return nil
 		}
-		fmt.Fprintf(os.Stderr, "skipping empty render -> %s\n", target)
+		emptyNoticef(opts.Shared, "skipping empty render -> %s", target)
 		return nil
 	}
 
@@ -575,14 +1304,14 @@ func RunRenderMode(opts RenderOptions) error {
 	if opts.Out != "" {
 		ok, gerr := canOverwrite(opts.Out, opts.Shared.Guard)
 		if gerr != nil && !os.IsNotExist(gerr) {
-			return fmt.Errorf("guard check %s: %w", opts.Out, gerr)
+			return exitError(ExitGuardSkipped, "guard", fmt.Errorf("guard check %s: %w", opts.Out, gerr))
 		}
 		if !ok {
 			if opts.Shared.DryRun {
-				fmt.Printf("[dry-run] skip (guard missing) %s\n", opts.Out)
+				fmt.Fprintf(sink.Stdout(), "[dry-run] skip (guard missing) %s\n", displayPath(opts.Out, opts.Shared))
 				return nil
 			}
-			warnf("guard", "skip (guard missing) %s", opts.Out)
+			warnf("guard", "skip (guard missing) %s", displayPath(opts.Out, opts.Shared))
 			return nil
 		}
 	}
@@ -590,32 +1319,37 @@ func RunRenderMode(opts RenderOptions) error {
 	if opts.Shared.DryRun {
 		target := "stdout"
 		if opts.Out != "" {
-			target = opts.Out
+			target = displayPath(opts.Out, opts.Shared)
 		}
 		srcLabel := "stdin"
 		if opts.In != "" {
 			srcLabel = opts.In
 		}
 		if opts.Out != "" && opts.Shared.InjectGuard {
-			simulated := injectGuardForExt(opts.Out, outBytes, opts.Shared.Guard)
+			simulated := injectGuardForExt(opts.Out, outBytes, opts.Shared)
 			if !bytes.Equal(simulated, outBytes) {
-				fmt.Printf("[dry-run] would inject guard into %s\n", opts.Out)
+				fmt.Fprintf(sink.Stdout(), "[dry-run] would inject guard into %s\n", displayPath(opts.Out, opts.Shared))
 			}
 		}
 		// Check if file would change
 		if opts.Out != "" {
 			simToCheck := outBytes
 			if opts.Shared.InjectGuard {
-				simToCheck = injectGuardForExt(opts.Out, outBytes, opts.Shared.Guard)
+				simToCheck = injectGuardForExt(opts.Out, outBytes, opts.Shared)
+			}
+			simToCheck, err := encodeOutput(opts.Out, simToCheck, opts.Shared)
+			if err != nil {
+				return fmt.Errorf("encode out: %w", err)
 			}
 			same, _ := fastEqual(opts.Out, simToCheck)
 			if same {
-				fmt.Printf("[dry-run] would skip unchanged %s\n", opts.Out)
+				fmt.Fprintf(sink.Stdout(), "[dry-run] would skip unchanged %s\n", displayPath(opts.Out, opts.Shared))
 			} else {
-				fmt.Printf("[dry-run] would render %s -> %s (changed)\n", srcLabel, target)
+				fmt.Fprintf(sink.Stdout(), "[dry-run] would render %s -> %s (changed)\n", srcLabel, target)
+				noteDryRunChange()
 			}
 		} else {
-			fmt.Printf("[dry-run] would render %s -> %s\n", srcLabel, target)
+			fmt.Fprintf(sink.Stdout(), "[dry-run] would render %s -> %s\n", srcLabel, target)
 		}
 		return nil
 	}
@@ -624,7 +1358,11 @@ func RunRenderMode(opts RenderOptions) error {
 	if opts.Out != "" {
 		// Optionally inject guard comment
 		if opts.Shared.InjectGuard {
-			outBytes = injectGuardForExt(opts.Out, outBytes, opts.Shared.Guard)
+			outBytes = injectGuardForExt(opts.Out, outBytes, opts.Shared)
+		}
+		outBytes, err := encodeOutput(opts.Out, outBytes, opts.Shared)
+		if err != nil {
+			return fmt.Errorf("encode out: %w", err)
 		}
 		// Write only if content changed
 		changed, err := writeIfChanged(opts.Out, outBytes, 0o644)
@@ -636,25 +1374,111 @@ func RunRenderMode(opts RenderOptions) error {
 			if opts.In != "" {
 				srcLabel = opts.In
 			}
-			fmt.Printf("rendered %s -> %s\n", srcLabel, opts.Out)
+			fmt.Fprintf(sink.Stdout(), "rendered %s -> %s\n", srcLabel, displayPath(opts.Out, opts.Shared))
 		}
 		return nil
 	}
 
-	if _, err := os.Stdout.Write(outBytes); err != nil {
+	outBytes, err = encodeOutput("", outBytes, opts.Shared)
+	if err != nil {
+		return fmt.Errorf("encode output: %w", err)
+	}
+	if _, err := sink.Stdout().Write(outBytes); err != nil {
 		return err
 	}
+	audit.written("stdout", outBytes)
 	return nil
 }
 
-// RunSchemaValidate validates data against a schema
-func RunSchemaValidate(opts SchemaOptions, config *Config) error {
-	// Load and merge data
-	vals, err := buildValues(".", opts.Shared)
+// renderSingleGo renders the Go template src of a render run, with the
+// helpers found next to it, and returns its output.
+func renderSingleGo(opts RenderOptions, audit *auditTrail, tplName, label, filesRoot string, srcBytes []byte, sources *templateSources, values map[string]any) ([]byte, error) {
+	// Create template with functions
+	debugf(opts.Shared.Debug, "Creating template with delimiters: %s ... %s", opts.Shared.Ldelim, opts.Shared.Rdelim)
+	if opts.Shared.Strict {
+		debugf(opts.Shared.Debug, "Strict mode enabled (missingkey=error)")
+	}
+	var tpl *template.Template
+	funcs := buildFuncMapWithOptions(&tpl, opts.Shared)
+	tpl = template.New("root").Funcs(funcs).Option("missingkey=default")
+	if opts.Shared.Strict {
+		tpl = tpl.Option("missingkey=error")
+	}
+	tpl = tpl.Delims(opts.Shared.Ldelim, opts.Shared.Rdelim)
+
+	// Load sidecar helpers in the same directory based on -helpers glob (default: _helpers.tpl),
+	// or in --helpers-dir, which also works for templates read from stdin
+	helpersDir := opts.HelpersDir
+	if helpersDir == "" && filesRoot != "." {
+		helpersDir = filesRoot
+	}
+	if helpersDir != "" && opts.Helpers != "" {
+		pattern := filepath.Join(helpersDir, opts.Helpers)
+		debugf(opts.Shared.Debug, "Looking for helper templates: %s", pattern)
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			debugf(opts.Shared.Debug, "Found %d helper template(s)", len(matches))
+			for _, hp := range matches {
+				if b, e := os.ReadFile(hp); e == nil {
+					helperName := filepath.ToSlash(filepath.Base(hp))
+					debugf(opts.Shared.Debug, "  → Loading helper: %s (%d bytes)", helperName, len(b))
+					text := string(b)
+					sources.set(helperName, text)
+					audit.input(hp, "template", b)
+					if _, e2 := parseRaw(tpl.New(helperName), text, opts.Shared); e2 != nil {
+						return nil, fmt.Errorf("parse helper %s: %w", hp, newTemplateError("parse", e2, sources, ""))
+					}
+				}
+			}
+		} else {
+			debugf(opts.Shared.Debug, "  → No helper templates found")
+		}
+	}
+
+	debugf(opts.Shared.Debug, "Parsing main template")
+	parseSpan := startStepSpan("templr.parse", attribute.String("templr.template", tplName))
+	tpl, err := parseRaw(tpl, string(srcBytes), opts.Shared)
+	templr.EndSpan(parseSpan, err)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("parse: %w", newTemplateError("parse", err, sources, label))
+	}
+
+	// Compute helper-driven variables (templr.vars)
+	debugf(opts.Shared.Debug, "Checking for templr.vars template")
+	if err := computeHelperVars(tpl, values); err != nil {
+		return nil, fmt.Errorf("helpers: %w", newTemplateError("render", err, sources, label))
+	}
+	if tpl.Lookup("templr.vars") != nil {
+		debugf(opts.Shared.Debug, "  → templr.vars executed, values updated")
+		if opts.Shared.Debug {
+			debugValues(opts.Shared, values, "Values After templr.vars")
+		}
+	} else {
+		debugf(opts.Shared.Debug, "  → No templr.vars template found")
+	}
+
+	// render to buffer
+	debugf(opts.Shared.Debug, "Rendering template")
+	outBytes, rerr := renderToBuffer(tpl, "", values, opts.Shared)
+	if rerr != nil {
+		if opts.Shared.Strict && !errors.Is(rerr, errOutputTooLarge) {
+			strictErrf(rerr, sources, label, opts.Shared.NoColor)
+		}
+		var sizeErr *outputSizeError
+		if errors.As(rerr, &sizeErr) {
+			sizeErr.relabel(label)
+		}
+		return nil, newTemplateError("render", rerr, sources, label)
 	}
+	if opts.Shared.ExplainMissing && !opts.Shared.Strict {
+		missing := newMissingRefs()
+		missing.collect(tpl, "", values, label)
+		missing.report()
+	}
+	return outBytes, nil
+}
 
+// RunSchemaValidate validates data against a schema
+func RunSchemaValidate(opts SchemaOptions, config *Config) error {
 	// Determine schema path
 	schemaPath := opts.SchemaPath
 	if schemaPath == "" {
@@ -664,6 +1488,21 @@ func RunSchemaValidate(opts SchemaOptions, config *Config) error {
 			return fmt.Errorf("no schema file found (checked: %s, .templr.schema.yml, .templr/schema.yml)", config.Schema.Path)
 		}
 	}
+	pin := opts.SHA256
+	if pin == "" {
+		pin = config.Schema.SHA256
+	}
+	schemaPath, err := resolveSchema(schemaPath, pin)
+	if err != nil {
+		return err
+	}
+
+	// Load and merge data, typed by the schema
+	opts.Shared.Schema = schemaPath
+	vals, err := buildValues(".", opts.Shared)
+	if err != nil {
+		return err
+	}
 
 	// Determine mode
 	mode := opts.Mode
@@ -738,6 +1577,41 @@ func RunSchemaGenerate(opts SchemaOptions, config *Config) error {
 	return nil
 }
 
+// RunSchemaImport converts an OpenAPI or CRD schema into the templr schema format
+func RunSchemaImport(opts SchemaOptions) error {
+	var schema map[string]any
+	var err error
+	switch {
+	case opts.OpenAPI != "" && opts.CRD != "":
+		return argsError(fmt.Errorf("use either --openapi or --crd"))
+	case opts.OpenAPI != "":
+		schema, err = ImportOpenAPISchema(opts.OpenAPI, opts.Pointer)
+	case opts.CRD != "":
+		schema, err = ImportCRDSchema(opts.CRD, opts.CRDVersion, opts.Pointer)
+	default:
+		return argsError(fmt.Errorf("schema import requires --openapi or --crd"))
+	}
+	if err != nil {
+		return fmt.Errorf("import schema: %w", err)
+	}
+
+	schemaBytes, err := yaml.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("marshal schema: %w", err)
+	}
+
+	if opts.Output != "" {
+		if err := os.WriteFile(opts.Output, schemaBytes, 0o644); err != nil {
+			return fmt.Errorf("write schema file: %w", err)
+		}
+		fmt.Printf("Imported schema -> %s\n", opts.Output)
+	} else {
+		fmt.Print(string(schemaBytes))
+	}
+
+	return nil
+}
+
 // pluralize returns "s" if count is not 1
 func pluralize(count int) string {
 	if count == 1 {
@@ -749,31 +1623,32 @@ func pluralize(count int) string {
 // Debug logging helpers
 func debugf(debug bool, format string, args ...any) {
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
+		fmt.Fprintf(sink.Stderr(), "[DEBUG] "+format+"\n", args...)
 	}
 }
 
 func debugSection(debug bool, title string) {
 	if debug {
-		fmt.Fprint(os.Stderr, "\n"+strings.Repeat("=", 60)+"\n")
-		fmt.Fprintf(os.Stderr, "[DEBUG] %s\n", title)
-		fmt.Fprint(os.Stderr, strings.Repeat("=", 60)+"\n")
+		fmt.Fprint(sink.Stderr(), "\n"+strings.Repeat("=", 60)+"\n")
+		fmt.Fprintf(sink.Stderr(), "[DEBUG] %s\n", title)
+		fmt.Fprint(sink.Stderr(), strings.Repeat("=", 60)+"\n")
 	}
 }
 
-func debugValues(debug bool, values map[string]any, title string) {
-	if !debug {
+// debugValues prints values with the sensitive keys redacted.
+func debugValues(shared SharedOptions, values map[string]any, title string) {
+	if !shared.Debug {
 		return
 	}
 
-	debugSection(debug, title)
+	debugSection(shared.Debug, title)
 
 	// Convert to YAML for pretty printing
-	yamlBytes, err := yaml.Marshal(values)
+	yamlBytes, err := yaml.Marshal(redactValues(values, shared.Redact))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Error marshaling values: %v\n", err)
+		fmt.Fprintf(sink.Stderr(), "[DEBUG] Error marshaling values: %v\n", err)
 		return
 	}
 
-	fmt.Fprintf(os.Stderr, "%s\n", string(yamlBytes))
+	fmt.Fprintf(sink.Stderr(), "%s\n", string(yamlBytes))
 }
@@ -4,67 +4,151 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the complete configuration structure
 type Config struct {
-	Files    FilesConfig    `yaml:"files"`
-	Template TemplateConfig `yaml:"template"`
-	Schema   SchemaConfig   `yaml:"schema"`
-	Lint     LintConfig     `yaml:"lint"`
-	Render   RenderConfig   `yaml:"render"`
-	Output   OutputConfig   `yaml:"output"`
+	Files     FilesConfig     `yaml:"files"`
+	Template  TemplateConfig  `yaml:"template"`
+	Schema    SchemaConfig    `yaml:"schema"`
+	Lint      LintConfig      `yaml:"lint"`
+	Functions FunctionsConfig `yaml:"functions"`
+	Render    RenderConfig    `yaml:"render"`
+	Guard     GuardConfig     `yaml:"guard"`
+	Output    OutputConfig    `yaml:"output"`
+	Dir       DirConfig       `yaml:"dir"`
+	Debug     DebugConfig     `yaml:"debug"`
+	Features  FeaturesConfig  `yaml:"features"`
 }
 
 // FilesConfig contains file-related configuration
 type FilesConfig struct {
-	Extensions          []string `yaml:"extensions"`
-	DefaultTemplatesDir string   `yaml:"default_templates_dir"`
-	DefaultOutputDir    string   `yaml:"default_output_dir"`
-	DefaultValuesFile   string   `yaml:"default_values_file"`
-	Helpers             []string `yaml:"helpers"`
+	Extensions          []string          `yaml:"extensions"`
+	StripSuffixes       []string          `yaml:"strip_suffixes"`    // trimmed from output names instead of the template extension
+	OutputExtensions    map[string]string `yaml:"output_extensions"` // template suffix to output extension, e.g. .yml.j2: .yaml
+	DefaultTemplatesDir string            `yaml:"default_templates_dir"`
+	DefaultOutputDir    string            `yaml:"default_output_dir"`
+	DefaultValuesFile   string            `yaml:"default_values_file"`
+	Helpers             []string          `yaml:"helpers"`
+	EnvKey              string            `yaml:"env_key"`      // nest .env values files under this dotted key
+	ResolveRefs         bool              `yaml:"resolve_refs"` // resolve ${.dotted.key} references between values
 }
 
 // TemplateConfig contains template engine configuration
 type TemplateConfig struct {
-	LeftDelimiter  string `yaml:"left_delimiter"`
-	RightDelimiter string `yaml:"right_delimiter"`
-	DefaultMissing string `yaml:"default_missing"`
+	LeftDelimiter   string          `yaml:"left_delimiter"`
+	RightDelimiter  string          `yaml:"right_delimiter"`
+	DefaultMissing  string          `yaml:"default_missing"`
+	Scopes          []TemplateScope `yaml:"scopes"`           // per-path delimiters and strictness for dir and walk trees
+	ValuesNamespace bool            `yaml:"values_namespace"` // expose the values under .Values too, like Helm
+}
+
+// FunctionsConfig controls which template functions are available
+type FunctionsConfig struct {
+	Disable             []string `yaml:"disable"`               // removed at render time and reported by lint
+	CryptoPolicy        string   `yaml:"crypto_policy"`         // "fips" rejects the non-approved crypto helpers
+	AllowValueTemplates bool     `yaml:"allow_value_templates"` // enable renderValueTemplate
+}
+
+// DirConfig contains dir mode configuration
+type DirConfig struct {
+	Entry string `yaml:"entry"` // entry template, relative to --dir, when -i is not given
 }
 
 // LintConfig contains linting configuration
 type LintConfig struct {
-	FailOnWarn        bool     `yaml:"fail_on_warn"`
-	FailOnUndefined   bool     `yaml:"fail_on_undefined"`
-	StrictMode        bool     `yaml:"strict_mode"`
-	OutputFormat      string   `yaml:"output_format"`
-	Exclude           []string `yaml:"exclude"`
-	DisallowFunctions []string `yaml:"disallow_functions"`
-	RequiredVars      []string `yaml:"required_vars"`
-	NoUndefCheck      bool     `yaml:"no_undefined_check"`
+	FailOnWarn        bool         `yaml:"fail_on_warn"`
+	FailOnUndefined   bool         `yaml:"fail_on_undefined"`
+	StrictMode        bool         `yaml:"strict_mode"`
+	OutputFormat      string       `yaml:"output_format"`
+	Exclude           []string     `yaml:"exclude"`
+	DisallowFunctions []string     `yaml:"disallow_functions"`
+	RequiredVars      []string     `yaml:"required_vars"`
+	NoUndefCheck      bool         `yaml:"no_undefined_check"`
+	Whitespace        bool         `yaml:"whitespace"`
+	Profile           string       `yaml:"profile"`
+	Naming            NamingConfig `yaml:"naming"`
+}
+
+// NamingConfig contains the naming convention rules of lint.naming. A rule
+// without a severity is off.
+type NamingConfig struct {
+	Partials NamingRule `yaml:"partials"` // files holding only defines
+	Defines  NamingRule `yaml:"defines"`  // names given to {{ define }}
+	Files    NamingRule `yaml:"files"`    // template file names
+	Entry    NamingRule `yaml:"entry"`    // entry template of lint --dir
+}
+
+// NamingRule is one naming convention: the names it checks must match
+// Pattern (a regular expression; empty for the rule's default).
+type NamingRule struct {
+	Severity string `yaml:"severity"` // off, warn or error
+	Pattern  string `yaml:"pattern"`
 }
 
 // RenderConfig contains rendering defaults
 type RenderConfig struct {
-	DryRun         bool   `yaml:"dry_run"`
-	InjectGuard    bool   `yaml:"inject_guard"`
-	GuardString    string `yaml:"guard_string"`
-	PruneEmptyDirs bool   `yaml:"prune_empty_dirs"`
+	DryRun           bool              `yaml:"dry_run"`
+	InjectGuard      bool              `yaml:"inject_guard"`
+	GuardString      string            `yaml:"guard_string"`
+	PruneEmptyDirs   bool              `yaml:"prune_empty_dirs"`
+	Flatten          bool              `yaml:"flatten"`           // walk: drop source directories from output paths
+	OnCollision      string            `yaml:"on_collision"`      // walk: templates rendering to the same output: error, first or last
+	Rename           []RenameRule      `yaml:"rename"`            // walk: output path rewrite rules
+	KeepEmpty        bool              `yaml:"keep_empty"`        // create files for empty renders
+	KeepEmptyPaths   []string          `yaml:"keep_empty_paths"`  // output path globs that keep empty renders
+	QuietEmpty       bool              `yaml:"quiet_empty"`       // do not report skipped empty renders
+	Encoding         string            `yaml:"encoding"`          // utf-8, utf-8-bom or utf-16le
+	PreserveEncoding bool              `yaml:"preserve_encoding"` // keep BOM and line endings of existing files
+	Asserts          []string          `yaml:"asserts"`           // expressions every rendered file must satisfy
+	Policies         []string          `yaml:"policies"`          // policy files and directories
+	PolicyMode       string            `yaml:"policy_mode"`       // enforce or warn
+	IncludeCache     int               `yaml:"include_cache"`     // memoize include with up to this many renders
+	IncludeMaxDepth  int               `yaml:"include_max_depth"` // fail include calls nested deeper than this
+	MaxOutputSize    string            `yaml:"max_output_size"`   // per-file output ceiling, e.g. "100MiB"
+	DockerfileLabels bool              `yaml:"dockerfile_labels"` // append templr provenance LABELs to Dockerfiles
+	Validate         []ValidateRule    `yaml:"validate"`          // built-in validators by output path
+	AuditLog         string            `yaml:"audit_log"`         // JSON lines audit file of renders, or "syslog"
+	Engines          map[string]string `yaml:"engines"`           // template engine by extension, e.g. ".j2": jinja
+}
+
+// GuardConfig controls how the guard comment is injected
+type GuardConfig struct {
+	CommentStyles map[string]string `yaml:"comment_styles"` // ".vue": "<!-- %s -->"; file names also allowed
+	Positions     map[string]string `yaml:"positions"`      // ".xml": "after-directives"; top, after-shebang, bottom
 }
 
 // OutputConfig contains output formatting configuration
 type OutputConfig struct {
-	Color   string `yaml:"color"` // auto, always, never
-	Verbose bool   `yaml:"verbose"`
-	Quiet   bool   `yaml:"quiet"`
+	Color         string `yaml:"color"` // auto, always, never
+	Verbose       bool   `yaml:"verbose"`
+	Quiet         bool   `yaml:"quiet"`
+	NoUpdateCheck bool   `yaml:"no_update_check"` // never look up the latest release (TEMPLR_NO_UPDATE_CHECK)
+}
+
+// DebugConfig contains the settings of --debug output and other places
+// templr shows values
+type DebugConfig struct {
+	Redact       []string `yaml:"redact"`        // dotted key patterns whose values are hidden, e.g. "*.token"
+	TopFunctions int      `yaml:"top_functions"` // template functions listed by --debug (default 10)
+}
+
+// FeaturesConfig contains the feature flags templates read as
+// .Templr.Features; each flag is name or name=value.
+type FeaturesConfig struct {
+	Enabled []string            `yaml:"enabled"` // on in every render
+	Sets    map[string][]string `yaml:"sets"`    // named sets enabled with --feature-set
 }
 
 // SchemaConfig contains schema validation configuration
 type SchemaConfig struct {
-	Path     string               `yaml:"path"`     // Path to schema file (default: .templr.schema.yml)
+	Path     string               `yaml:"path"`     // Path or https URL of the schema (default: .templr.schema.yml)
 	Mode     string               `yaml:"mode"`     // error|warn|strict (default: warn)
+	SHA256   string               `yaml:"sha256"`   // Required sha256 digest of the schema
+	Enforce  string               `yaml:"enforce"`  // render: render, dir and walk validate the values first
 	Generate SchemaGenerateConfig `yaml:"generate"` // Schema generation settings
 }
 
@@ -211,6 +295,12 @@ func mergeConfigs(dst, src *Config) {
 	if len(src.Files.Extensions) > 0 {
 		dst.Files.Extensions = src.Files.Extensions
 	}
+	if len(src.Files.StripSuffixes) > 0 {
+		dst.Files.StripSuffixes = src.Files.StripSuffixes
+	}
+	if len(src.Files.OutputExtensions) > 0 {
+		dst.Files.OutputExtensions = src.Files.OutputExtensions
+	}
 	if src.Files.DefaultTemplatesDir != "" {
 		dst.Files.DefaultTemplatesDir = src.Files.DefaultTemplatesDir
 	}
@@ -223,6 +313,10 @@ func mergeConfigs(dst, src *Config) {
 	if len(src.Files.Helpers) > 0 {
 		dst.Files.Helpers = src.Files.Helpers
 	}
+	if src.Files.EnvKey != "" {
+		dst.Files.EnvKey = src.Files.EnvKey
+	}
+	dst.Files.ResolveRefs = src.Files.ResolveRefs
 
 	// Merge Template config
 	if src.Template.LeftDelimiter != "" {
@@ -234,6 +328,12 @@ func mergeConfigs(dst, src *Config) {
 	if src.Template.DefaultMissing != "" {
 		dst.Template.DefaultMissing = src.Template.DefaultMissing
 	}
+	if len(src.Template.Scopes) > 0 {
+		dst.Template.Scopes = src.Template.Scopes
+	}
+	if src.Template.ValuesNamespace {
+		dst.Template.ValuesNamespace = true
+	}
 
 	// Merge Schema config
 	if src.Schema.Path != "" {
@@ -242,6 +342,12 @@ func mergeConfigs(dst, src *Config) {
 	if src.Schema.Mode != "" {
 		dst.Schema.Mode = src.Schema.Mode
 	}
+	if src.Schema.SHA256 != "" {
+		dst.Schema.SHA256 = src.Schema.SHA256
+	}
+	if src.Schema.Enforce != "" {
+		dst.Schema.Enforce = src.Schema.Enforce
+	}
 	if src.Schema.Generate.Required != "" {
 		dst.Schema.Generate.Required = src.Schema.Generate.Required
 	}
@@ -255,7 +361,11 @@ func mergeConfigs(dst, src *Config) {
 	dst.Lint.FailOnUndefined = src.Lint.FailOnUndefined
 	dst.Lint.StrictMode = src.Lint.StrictMode
 	dst.Lint.NoUndefCheck = src.Lint.NoUndefCheck
+	dst.Lint.Whitespace = src.Lint.Whitespace
 
+	if src.Lint.Profile != "" {
+		dst.Lint.Profile = src.Lint.Profile
+	}
 	if src.Lint.OutputFormat != "" {
 		dst.Lint.OutputFormat = src.Lint.OutputFormat
 	}
@@ -268,22 +378,130 @@ func mergeConfigs(dst, src *Config) {
 	if len(src.Lint.RequiredVars) > 0 {
 		dst.Lint.RequiredVars = src.Lint.RequiredVars
 	}
+	for _, r := range []struct{ dst, src *NamingRule }{
+		{&dst.Lint.Naming.Partials, &src.Lint.Naming.Partials},
+		{&dst.Lint.Naming.Defines, &src.Lint.Naming.Defines},
+		{&dst.Lint.Naming.Files, &src.Lint.Naming.Files},
+		{&dst.Lint.Naming.Entry, &src.Lint.Naming.Entry},
+	} {
+		if r.src.Severity != "" {
+			r.dst.Severity = r.src.Severity
+		}
+		if r.src.Pattern != "" {
+			r.dst.Pattern = r.src.Pattern
+		}
+	}
+
+	// Merge Functions config
+	if len(src.Functions.Disable) > 0 {
+		dst.Functions.Disable = src.Functions.Disable
+	}
+	if src.Functions.CryptoPolicy != "" {
+		dst.Functions.CryptoPolicy = src.Functions.CryptoPolicy
+	}
+	if src.Functions.AllowValueTemplates {
+		dst.Functions.AllowValueTemplates = true
+	}
+
+	// Merge Dir config
+	if src.Dir.Entry != "" {
+		dst.Dir.Entry = src.Dir.Entry
+	}
 
 	// Merge Render config
 	dst.Render.DryRun = src.Render.DryRun
 	dst.Render.InjectGuard = src.Render.InjectGuard
 	dst.Render.PruneEmptyDirs = src.Render.PruneEmptyDirs
+	dst.Render.Flatten = src.Render.Flatten
+	if src.Render.OnCollision != "" {
+		dst.Render.OnCollision = src.Render.OnCollision
+	}
+	if len(src.Render.Rename) > 0 {
+		dst.Render.Rename = src.Render.Rename
+	}
+	dst.Render.KeepEmpty = src.Render.KeepEmpty
+	dst.Render.QuietEmpty = src.Render.QuietEmpty
+	dst.Render.PreserveEncoding = src.Render.PreserveEncoding
+	dst.Render.DockerfileLabels = src.Render.DockerfileLabels
+	if len(src.Render.Validate) > 0 {
+		dst.Render.Validate = src.Render.Validate
+	}
+	if src.Render.Encoding != "" {
+		dst.Render.Encoding = src.Render.Encoding
+	}
+	if len(src.Render.KeepEmptyPaths) > 0 {
+		dst.Render.KeepEmptyPaths = src.Render.KeepEmptyPaths
+	}
+	if len(src.Render.Asserts) > 0 {
+		dst.Render.Asserts = src.Render.Asserts
+	}
+	if len(src.Render.Policies) > 0 {
+		dst.Render.Policies = src.Render.Policies
+	}
+	if src.Render.PolicyMode != "" {
+		dst.Render.PolicyMode = src.Render.PolicyMode
+	}
+	if src.Render.IncludeCache != 0 {
+		dst.Render.IncludeCache = src.Render.IncludeCache
+	}
+	if src.Render.IncludeMaxDepth != 0 {
+		dst.Render.IncludeMaxDepth = src.Render.IncludeMaxDepth
+	}
+	if src.Render.MaxOutputSize != "" {
+		dst.Render.MaxOutputSize = src.Render.MaxOutputSize
+	}
+	if src.Render.AuditLog != "" {
+		dst.Render.AuditLog = src.Render.AuditLog
+	}
+	for ext, engine := range src.Render.Engines {
+		if dst.Render.Engines == nil {
+			dst.Render.Engines = map[string]string{}
+		}
+		dst.Render.Engines[ext] = engine
+	}
 
 	if src.Render.GuardString != "" {
 		dst.Render.GuardString = src.Render.GuardString
 	}
 
+	// Merge Guard config; styles from later files add to or replace earlier ones
+	for key, style := range src.Guard.CommentStyles {
+		if dst.Guard.CommentStyles == nil {
+			dst.Guard.CommentStyles = map[string]string{}
+		}
+		dst.Guard.CommentStyles[key] = style
+	}
+	for key, pos := range src.Guard.Positions {
+		if dst.Guard.Positions == nil {
+			dst.Guard.Positions = map[string]string{}
+		}
+		dst.Guard.Positions[key] = pos
+	}
+
+	// Redaction patterns of every config file apply
+	dst.Debug.Redact = append(dst.Debug.Redact, src.Debug.Redact...)
+	if src.Debug.TopFunctions != 0 {
+		dst.Debug.TopFunctions = src.Debug.TopFunctions
+	}
+
+	// Enabled features of every config file apply; later sets replace earlier ones
+	dst.Features.Enabled = append(dst.Features.Enabled, src.Features.Enabled...)
+	for name, set := range src.Features.Sets {
+		if dst.Features.Sets == nil {
+			dst.Features.Sets = map[string][]string{}
+		}
+		dst.Features.Sets[name] = set
+	}
+
 	// Merge Output config
 	if src.Output.Color != "" {
 		dst.Output.Color = src.Output.Color
 	}
 	dst.Output.Verbose = src.Output.Verbose
 	dst.Output.Quiet = src.Output.Quiet
+	if src.Output.NoUpdateCheck {
+		dst.Output.NoUpdateCheck = true
+	}
 }
 
 // ApplyConfigToSharedOptions applies config values to SharedOptions
@@ -334,6 +552,135 @@ func ApplyConfigToSharedOptions(opts *SharedOptions, config *Config) {
 	if !opts.NoColor && config.Output.Color == "never" {
 		opts.NoColor = true
 	}
+
+	ApplyFunctionsConfig(opts, config)
+	ApplyRenderConfig(opts, config)
+}
+
+// ApplyFunctionsConfig applies the functions section of the config to SharedOptions
+func ApplyFunctionsConfig(opts *SharedOptions, config *Config) {
+	opts.DisabledFuncs = append(opts.DisabledFuncs, config.Functions.Disable...)
+	if opts.CryptoPolicy == "" {
+		opts.CryptoPolicy = config.Functions.CryptoPolicy
+	}
+	if config.Functions.AllowValueTemplates {
+		opts.ValueTemplates = true
+	}
+}
+
+// ApplyRenderConfig applies the output settings shared by render, dir and
+// walk: the empty-output policy, the output encoding, how output names are
+// derived from template names, the guard placement and the output
+// assertions, along with the key env values files are nested under and
+// whether references between values are resolved, and the keys whose values
+// are redacted from debug output and reports. A schema found as schema
+// validate finds it types the --set and env-file values, template.scopes sets
+// the delimiters and strictness of parts of a tree, template.values_namespace
+// exposes the values under .Values, and features holds the feature flags
+// --feature-set chooses from.
+func ApplyRenderConfig(opts *SharedOptions, config *Config) {
+	if config.Render.KeepEmpty {
+		opts.KeepEmpty = true
+	}
+	if config.Render.QuietEmpty {
+		opts.QuietEmpty = true
+	}
+	opts.KeepEmptyPaths = append(opts.KeepEmptyPaths, config.Render.KeepEmptyPaths...)
+	if opts.Encoding == "" {
+		opts.Encoding = config.Render.Encoding
+	}
+	if config.Render.PreserveEncoding {
+		opts.PreserveEncoding = true
+	}
+	// output name suffixes, dotted and lowercased like template extensions
+	for _, s := range config.Files.StripSuffixes {
+		opts.StripSuffixes = append(opts.StripSuffixes, dottedSuffix(s))
+	}
+	if len(config.Files.OutputExtensions) > 0 {
+		opts.OutputExts = map[string]string{}
+		for suffix, ext := range config.Files.OutputExtensions {
+			if ext = strings.TrimSpace(ext); ext != "" && !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			opts.OutputExts[dottedSuffix(suffix)] = ext
+		}
+	}
+	if len(config.Guard.CommentStyles) > 0 {
+		opts.GuardStyles = config.Guard.CommentStyles
+	}
+	if len(config.Guard.Positions) > 0 {
+		opts.GuardPositions = config.Guard.Positions
+	}
+	if opts.EnvKey == "" {
+		opts.EnvKey = config.Files.EnvKey
+	}
+	if config.Files.ResolveRefs {
+		opts.ResolveRefs = true
+	}
+	opts.Asserts = append(opts.Asserts, config.Render.Asserts...)
+	opts.Policies = append(opts.Policies, config.Render.Policies...)
+	if opts.PolicyMode == "" {
+		opts.PolicyMode = config.Render.PolicyMode
+	}
+	if opts.IncludeCache == 0 {
+		opts.IncludeCache = config.Render.IncludeCache
+	}
+	if opts.IncludeMaxDepth == 0 {
+		opts.IncludeMaxDepth = config.Render.IncludeMaxDepth
+	}
+	if opts.MaxOutputSize == "" {
+		opts.MaxOutputSize = config.Render.MaxOutputSize
+	}
+	if opts.AuditLog == "" {
+		opts.AuditLog = config.Render.AuditLog
+	}
+	if config.Render.DockerfileLabels {
+		opts.DockerfileLabels = true
+	}
+	opts.Validate = append(opts.Validate, config.Render.Validate...)
+	if len(config.Render.Engines) > 0 {
+		opts.Engines = config.Render.Engines
+	}
+	opts.TemplateScopes = append(opts.TemplateScopes, config.Template.Scopes...)
+	if config.Template.ValuesNamespace {
+		opts.ValuesNamespace = true
+	}
+	opts.Redact = append(opts.Redact, config.Debug.Redact...)
+	if opts.DebugTopFuncs == 0 {
+		opts.DebugTopFuncs = config.Debug.TopFunctions
+	}
+	if opts.Schema == "" {
+		opts.Schema = FindSchemaFile(config.Schema.Path)
+	}
+	if opts.SchemaSHA256 == "" {
+		opts.SchemaSHA256 = config.Schema.SHA256
+	}
+	if opts.SchemaEnforce == "" {
+		opts.SchemaEnforce = config.Schema.Enforce
+	}
+	opts.FeaturesEnabled = append(opts.FeaturesEnabled, config.Features.Enabled...)
+	if len(config.Features.Sets) > 0 {
+		opts.FeatureSetDefs = config.Features.Sets
+	}
+}
+
+// ApplyWalkConfig applies the walk-mode output layout settings of the config.
+// Rename rules from the command line are tried before the configured ones.
+func ApplyWalkConfig(opts *WalkOptions, config *Config) {
+	opts.Rename = append(opts.Rename, config.Render.Rename...)
+	if config.Render.Flatten {
+		opts.Flatten = true
+	}
+	if opts.OnCollision == "" {
+		opts.OnCollision = config.Render.OnCollision
+	}
+}
+
+// ApplyDirConfig applies the dir section of the config to DirOptions
+func ApplyDirConfig(opts *DirOptions, config *Config) {
+	if opts.Entry == "" {
+		opts.Entry = config.Dir.Entry
+	}
 }
 
 // ApplyConfigToLintOptions applies config values to LintOptions
@@ -354,6 +701,14 @@ func ApplyConfigToLintOptions(opts *LintOptions, config *Config) {
 		opts.NoUndefCheck = config.Lint.NoUndefCheck
 	}
 
+	if !opts.Whitespace && config.Lint.Whitespace {
+		opts.Whitespace = config.Lint.Whitespace
+	}
+
+	if opts.Profile == "" {
+		opts.Profile = config.Lint.Profile
+	}
+
 	// Store config reference for use in linting
 	opts.Config = config
 }
@@ -2,40 +2,19 @@ package app
 
 // Exit codes for CI-friendly behavior.
 const (
-	ExitOK            = 0
-	ExitGeneral       = 1
-	ExitTemplateError = 2
-	ExitDataError     = 3
-	ExitStrictError   = 4
-	ExitGuardSkipped  = 5
-	ExitLintWarn      = 6 // lint found warnings (with --fail-on-warn)
-	ExitLintError     = 7 // lint found errors
-	ExitSchemaError   = 8 // schema validation failed
+	ExitOK              = 0
+	ExitGeneral         = 1
+	ExitTemplateError   = 2
+	ExitDataError       = 3
+	ExitStrictError     = 4
+	ExitGuardSkipped    = 5
+	ExitLintWarn        = 6  // lint found warnings (with --fail-on-warn)
+	ExitLintError       = 7  // lint found errors
+	ExitSchemaError     = 8  // schema validation failed
+	ExitAssertFailed    = 9  // an --assert expression did not hold
+	ExitPolicyViolation = 10 // an enforced --policy rule was violated
+	ExitVerifyFailed    = 11 // templr verify found changed files or a bad signature
+	ExitValidateFailed  = 12 // a render.validate validator rejected an output
+	ExitFmtCheck        = 13 // templr fmt --check found unformatted templates
+	ExitChangesPending  = 14 // --dry-run --exit-code found outputs that would change
 )
-
-// Version is set at build time via -ldflags
-var Version string
-
-// GetVersion returns a human-friendly version string.
-func GetVersion() string {
-	if Version != "" {
-		return Version
-	}
-	return "dev"
-}
-
-// Contains checks if a string contains a substring
-func Contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) &&
-		(s[:len(substr)] == substr || s[len(s)-len(substr):] == substr ||
-			containsSubstring(s, substr)))
-}
-
-func containsSubstring(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
-}
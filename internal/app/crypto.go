@@ -0,0 +1,83 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template/parse"
+
+	"github.com/kanopi/templr/pkg/lint"
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+// checkCryptoPolicy validates --crypto-policy (functions.crypto_policy). A
+// fips build cannot be relaxed to the default policy.
+func checkCryptoPolicy(shared SharedOptions) error {
+	switch shared.CryptoPolicy {
+	case "", templr.CryptoPolicyFIPS:
+		return nil
+	case templr.CryptoPolicyDefault:
+		if templr.FIPSBuild {
+			return argsError(fmt.Errorf("--crypto-policy default is not available: this templr was built with the fips tag"))
+		}
+		return nil
+	}
+	return argsError(fmt.Errorf("invalid --crypto-policy %q: want default or fips", shared.CryptoPolicy))
+}
+
+// cryptoPolicyRule reports the crypto helpers rejected by the fips policy,
+// so that templates can be fixed before a render fails on them.
+func cryptoPolicyRule() lint.Rule {
+	rejected := templr.NonApprovedCryptoFuncs()
+	return lint.RuleFunc{RuleName: "crypto-policy", Fn: func(tree *parse.Tree, ctx *lint.Context) []lint.Issue {
+		var issues []lint.Issue
+		report := func(node parse.Node, msg string) {
+			line, col := nodePosition(tree, node)
+			issues = append(issues, lint.Issue{
+				Rule:     "crypto-policy",
+				Severity: lint.SeverityError,
+				Category: "function",
+				File:     ctx.File,
+				Line:     line,
+				Column:   col,
+				Message:  msg,
+			})
+		}
+		lint.WalkPipes(tree.Root, func(pipe *parse.PipeNode) {
+			for _, cmd := range pipe.Cmds {
+				if len(cmd.Args) == 0 {
+					continue
+				}
+				ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+				if !ok {
+					continue
+				}
+				if reason, ok := rejected[ident.Ident]; ok {
+					report(cmd, fmt.Sprintf("%s is not allowed by the fips crypto policy: %s", ident.Ident, reason))
+					continue
+				}
+				if ident.Ident != "genPrivateKey" || len(cmd.Args) < 2 {
+					continue
+				}
+				if typ, ok := cmd.Args[1].(*parse.StringNode); ok {
+					if reason := templr.NonApprovedKeyType(typ.Text); reason != "" {
+						report(cmd, fmt.Sprintf("genPrivateKey %q is not allowed by the fips crypto policy: %s", typ.Text, reason))
+					}
+				}
+			}
+		})
+		return issues
+	}}
+}
+
+// nodePosition returns the line and column of node in tree (0 when unknown).
+func nodePosition(tree *parse.Tree, node parse.Node) (int, int) {
+	loc, _ := tree.ErrorContext(node) // "name:line:col"
+	parts := strings.Split(loc, ":")
+	if len(parts) < 3 {
+		return 0, 0
+	}
+	line, _ := strconv.Atoi(parts[len(parts)-2])
+	col, _ := strconv.Atoi(parts[len(parts)-1])
+	return line, col
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"time"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+// defaultDebugTopFuncs is the number of template functions --debug lists
+// when debug.top_functions is not set.
+const defaultDebugTopFuncs = 10
+
+// debugCalls records the template function calls of a --debug run.
+var debugCalls = templr.NewFuncCalls()
+
+// funcCalls returns where the function map of a run records its calls: with
+// --debug, debugCalls; otherwise nowhere, so functions are called directly.
+func funcCalls(shared SharedOptions) *templr.FuncCalls {
+	if !shared.Debug {
+		return nil
+	}
+	return debugCalls
+}
+
+// reportFuncCalls lists, with --debug, the template functions the run spent
+// the most time in, with their number of calls. A slow render is usually one
+// expensive function called in a loop.
+func reportFuncCalls(shared SharedOptions) {
+	if !shared.Debug {
+		return
+	}
+	n := shared.DebugTopFuncs
+	if n == 0 {
+		n = defaultDebugTopFuncs
+	}
+	top := debugCalls.Top(n)
+	if len(top) == 0 {
+		return
+	}
+	debugSection(true, "Template Function Calls")
+	for _, s := range top {
+		debugf(true, "%-24s %8d call%s %12s", s.Name, s.Calls, pluralize(s.Calls), s.Time.Round(time.Microsecond))
+	}
+}
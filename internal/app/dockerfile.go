@@ -0,0 +1,77 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Labels appended to rendered Dockerfiles with render.dockerfile_labels, so
+// that an image can be traced back to the inputs its Dockerfile came from.
+const (
+	DockerLabelVersion      = "templr.version"
+	DockerLabelValuesDigest = "templr.values.digest"
+	DockerLabelTemplate     = "templr.template"
+)
+
+var dockerFromRe = regexp.MustCompile(`(?im)^[ \t]*FROM[ \t]`)
+
+// isDockerfile reports whether an output path names a Dockerfile:
+// Dockerfile, Containerfile, Dockerfile.<variant> or <name>.dockerfile.
+func isDockerfile(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	for _, name := range []string{"dockerfile", "containerfile"} {
+		if base == name || strings.HasPrefix(base, name+".") || strings.HasSuffix(base, "."+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// valuesDigest returns the SHA-256 of the merged values as canonical JSON
+// (sorted keys). The .Files and .Existing APIs are left out: they only hold
+// local paths.
+func valuesDigest(values map[string]any) (string, error) {
+	data := make(map[string]any, len(values))
+	for k, v := range values {
+		if k != "Files" && k != "Existing" {
+			data[k] = v
+		}
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// addDockerfileLabels appends a LABEL instruction with the templr version,
+// the values digest and the template to a rendered Dockerfile. Being last,
+// it belongs to the final FROM stage, the one that becomes the image. Other
+// outputs, and Dockerfiles without a FROM, are returned unchanged.
+func addDockerfileLabels(out []byte, outPath, template string, values map[string]any, shared SharedOptions) ([]byte, error) {
+	if !shared.DockerfileLabels || !isDockerfile(outPath) || !dockerFromRe.Match(out) {
+		return out, nil
+	}
+	digest, err := valuesDigest(values)
+	if err != nil {
+		return nil, fmt.Errorf("dockerfile labels: values digest: %w", err)
+	}
+	var b bytes.Buffer
+	b.Write(out)
+	if !bytes.HasSuffix(out, []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "LABEL %s=%s \\\n      %s=%s \\\n      %s=%s\n",
+		DockerLabelVersion, strconv.Quote(GetVersion()),
+		DockerLabelValuesDigest, strconv.Quote(digest),
+		DockerLabelTemplate, strconv.Quote(filepath.ToSlash(template)))
+	return b.Bytes(), nil
+}
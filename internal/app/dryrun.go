@@ -0,0 +1,31 @@
+package app
+
+import "fmt"
+
+// dryRunChanges counts the outputs a dry run found it would create or
+// change, for --exit-code.
+var dryRunChanges int
+
+// noteDryRunChange records an output a dry run would create or change.
+func noteDryRunChange() {
+	dryRunChanges++
+}
+
+// dryRunExit returns err, or with --dry-run --exit-code an ExitChangesPending
+// error when the run succeeded but some output would have been written, so
+// CI can check that a render is a no-op.
+func dryRunExit(shared SharedOptions, err error) error {
+	if err != nil || !shared.DryRun || !shared.DryRunExitCode || dryRunChanges == 0 {
+		return err
+	}
+	return exitError(ExitChangesPending, "dry-run", fmt.Errorf("%d output%s would change", dryRunChanges, pluralize(dryRunChanges)))
+}
+
+// checkDryRunExitCode rejects --exit-code without --dry-run, where a change
+// is made rather than reported.
+func checkDryRunExitCode(shared SharedOptions) error {
+	if shared.DryRunExitCode && !shared.DryRun {
+		return argsError(fmt.Errorf("--exit-code requires --dry-run"))
+	}
+	return nil
+}
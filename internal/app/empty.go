@@ -0,0 +1,83 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// keepEmpty reports whether an empty render to relOut (the output path
+// relative to the destination, or as given with --out) should still create
+// the file: with --keep-empty, or when relOut matches a
+// render.keep_empty_paths pattern. Patterns without a slash match the base name.
+func keepEmpty(relOut string, shared SharedOptions) bool {
+	return shared.KeepEmpty || matchOutputPath(shared.KeepEmptyPaths, relOut)
+}
+
+// matchOutputPath reports whether relOut matches one of the glob patterns;
+// patterns without a slash match the base name.
+func matchOutputPath(patterns []string, relOut string) bool {
+	p := path.Clean(filepath.ToSlash(relOut))
+	for _, pat := range patterns {
+		target := p
+		if !strings.Contains(pat, "/") {
+			target = path.Base(p)
+		}
+		if ok, _ := path.Match(pat, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// writeEmptyOutput creates dstPath as an empty file for a kept empty render.
+// The guard is not injected, so the file stays empty; an existing empty file
+// is left alone and any other existing file still needs the guard. Messages
+// go to w.
+func writeEmptyOutput(w messageWriter, name, dstPath string, shared SharedOptions) (string, error) {
+	if info, err := os.Stat(dstPath); err == nil && !info.IsDir() && info.Size() == 0 {
+		if shared.DryRun {
+			fmt.Fprintf(w.Stdout(), "[dry-run] would skip unchanged %s\n", displayPath(dstPath, shared))
+		}
+		return "unchanged", nil
+	}
+
+	ok, gerr := canOverwrite(dstPath, shared.Guard)
+	if gerr != nil && !os.IsNotExist(gerr) {
+		return "", exitError(ExitGuardSkipped, "guard", fmt.Errorf("guard check %s: %w", dstPath, gerr))
+	}
+	if !ok {
+		if shared.DryRun {
+			fmt.Fprintf(w.Stdout(), "[dry-run] skip (guard missing) %s\n", displayPath(dstPath, shared))
+		} else {
+			writeWarning(w.Stderr(), "guard", "skip (guard missing) %s", displayPath(dstPath, shared))
+		}
+		return "skipped (guard missing)", nil
+	}
+
+	if shared.DryRun {
+		fmt.Fprintf(w.Stdout(), "[dry-run] would create empty %s from %s\n", displayPath(dstPath, shared), name)
+		noteDryRunChange()
+		return "dry-run", nil
+	}
+	if _, err := writeIfChanged(dstPath, nil, 0o644); err != nil {
+		return "", fmt.Errorf("write %s: %w", dstPath, err)
+	}
+	fmt.Fprintf(w.Stdout(), "rendered %s -> %s (empty)\n", name, displayPath(dstPath, shared))
+	return "rendered (empty)", nil
+}
+
+// emptyNoticef reports a skipped empty render on stderr, unless silenced
+// with --quiet-empty. With --log-format json it is an "info" record.
+func emptyNoticef(shared SharedOptions, format string, a ...any) {
+	if shared.QuietEmpty {
+		return
+	}
+	if logFormat == LogFormatJSON {
+		writeLogRecord(sink.Stderr(), logRecord{Level: "info", Kind: "empty", Message: fmt.Sprintf(format, a...)})
+		return
+	}
+	fmt.Fprintf(sink.Stderr(), format+"\n", a...)
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"unicode/utf16"
+)
+
+// Output encodings accepted by --encoding.
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF8BOM = "utf-8-bom"
+	EncodingUTF16LE = "utf-16le"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// textFormat is the encoding and line-ending convention of a file.
+type textFormat struct {
+	encoding string
+	crlf     bool
+}
+
+// CheckEncoding validates an --encoding value; empty keeps the rendered bytes.
+func CheckEncoding(enc string) error {
+	switch enc {
+	case "", EncodingUTF8, EncodingUTF8BOM, EncodingUTF16LE:
+		return nil
+	}
+	return argsError(fmt.Errorf("invalid --encoding %q (want %s, %s or %s)", enc, EncodingUTF8, EncodingUTF8BOM, EncodingUTF16LE))
+}
+
+// decodeText returns content as UTF-8 without a BOM. UTF-16 content is
+// recognized by its BOM; anything else is returned as is.
+func decodeText(content []byte) []byte {
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		return content[len(bomUTF8):]
+	case bytes.HasPrefix(content, bomUTF16LE):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(content, bomUTF16BE):
+		order = binary.BigEndian
+	default:
+		return content
+	}
+	body := content[2:]
+	units := make([]uint16, len(body)/2)
+	for i := range units {
+		units[i] = order.Uint16(body[2*i:])
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// detectTextFormat reports the encoding and line endings of an existing file.
+// A file without a BOM is utf-8; it uses CRLF if its first line break does.
+func detectTextFormat(content []byte) textFormat {
+	var f textFormat
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		f.encoding = EncodingUTF8BOM
+	case bytes.HasPrefix(content, bomUTF16LE):
+		f.encoding = EncodingUTF16LE
+	default:
+		f.encoding = EncodingUTF8
+	}
+	text := decodeText(content)
+	if i := bytes.IndexByte(text, '\n'); i > 0 && text[i-1] == '\r' {
+		f.crlf = true
+	}
+	return f
+}
+
+// encodeOutput converts rendered content to the output encoding. With
+// --preserve-encoding the BOM and line endings of the existing file at path
+// are kept; --encoding forces the encoding of every output. Without either,
+// content is returned unchanged.
+func encodeOutput(path string, content []byte, shared SharedOptions) ([]byte, error) {
+	if err := CheckEncoding(shared.Encoding); err != nil {
+		return nil, err
+	}
+	enc := shared.Encoding
+	if shared.PreserveEncoding && path != "" {
+		existing, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil && len(existing) > 0 {
+			f := detectTextFormat(existing)
+			content = convertLineEndings(decodeText(content), f.crlf)
+			if enc == "" {
+				enc = f.encoding
+			}
+		}
+	}
+
+	switch enc {
+	case EncodingUTF8:
+		return decodeText(content), nil
+	case EncodingUTF8BOM:
+		return append(append([]byte{}, bomUTF8...), decodeText(content)...), nil
+	case EncodingUTF16LE:
+		units := utf16.Encode([]rune(string(decodeText(content))))
+		out := make([]byte, len(bomUTF16LE), len(bomUTF16LE)+2*len(units))
+		copy(out, bomUTF16LE)
+		for _, u := range units {
+			out = binary.LittleEndian.AppendUint16(out, u)
+		}
+		return out, nil
+	}
+	return content, nil
+}
+
+// convertLineEndings rewrites every line ending of text as CRLF or LF.
+func convertLineEndings(text []byte, crlf bool) []byte {
+	text = bytes.ReplaceAll(text, []byte("\r\n"), []byte("\n"))
+	if crlf {
+		text = bytes.ReplaceAll(text, []byte("\n"), []byte("\r\n"))
+	}
+	return text
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+// checkEngines validates --engine and the engines of render.engines.
+func checkEngines(engine string, shared SharedOptions) error {
+	if engine != "" {
+		if _, err := templr.NewEngine(engine, templr.Options{}); err != nil {
+			return argsError(fmt.Errorf("invalid --engine: %w", err))
+		}
+	}
+	for ext, name := range shared.Engines {
+		if _, err := templr.NewEngine(name, templr.Options{}); err != nil {
+			return argsError(fmt.Errorf("render.engines %s: %w", ext, err))
+		}
+	}
+	return nil
+}
+
+// jinjaExts returns the template extensions rendered by the Jinja engine:
+// .j2, .jinja and .jinja2 unless render.engines maps them elsewhere, and
+// those render.engines maps to jinja.
+func jinjaExts(shared SharedOptions) map[string]bool {
+	exts := map[string]bool{}
+	for ext := range templr.DefaultEngineExts {
+		exts[ext] = true
+	}
+	for ext := range shared.Engines {
+		exts["."+strings.TrimPrefix(strings.ToLower(ext), ".")] = true
+	}
+	for ext := range exts {
+		if templr.EngineFor("x"+ext, shared.Engines) != templr.EngineJinja {
+			delete(exts, ext)
+		}
+	}
+	return exts
+}
+
+// readJinjaTemplates reads the Jinja templates under root, keyed by their
+// slash-separated path, and returns their names in render order.
+func readJinjaTemplates(root string, exts map[string]bool) (map[string][]byte, []string, error) {
+	srcs := map[string][]byte{}
+	var names []string
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !exts[strings.ToLower(filepath.Ext(d.Name()))] || skipLink(p, d) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		src, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if skipBinary(rel, src) {
+			return nil
+		}
+		srcs[rel] = src
+		names = append(names, rel)
+		return nil
+	})
+	sort.Strings(names)
+	return srcs, names, err
+}
+
+// renderJinja renders the Jinja template src, called name in errors, with
+// the strictness and output ceiling of shared. {% include %} reads templates
+// relative to root and cannot leave it.
+func renderJinja(root, name string, src []byte, values map[string]any, shared SharedOptions) ([]byte, error) {
+	limit, _ := maxOutputSize(shared) // validated by checkMaxOutputSize
+	engine := &templr.JinjaEngine{
+		Strict:        shared.Strict,
+		MaxOutputSize: int(limit),
+		Loader: func(include string) (string, error) {
+			r, err := os.OpenRoot(root)
+			if err != nil {
+				return "", err
+			}
+			defer r.Close()
+			b, err := r.ReadFile(filepath.FromSlash(include))
+			return string(b), err
+		},
+	}
+	out, err := engine.Render(name, string(src), values)
+	if err != nil {
+		return nil, exitError(ExitTemplateError, "template", err)
+	}
+	return []byte(out), nil
+}
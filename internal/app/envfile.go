@@ -0,0 +1,111 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// isEnvFile reports whether path is an environment file: .env, .env.local,
+// prod.env and the like.
+func isEnvFile(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	return base == ".env" || strings.HasPrefix(base, ".env.") || filepath.Ext(base) == ".env"
+}
+
+// parseEnvFile parses KEY=VALUE lines. Blank lines, # comments and a leading
+// "export " are skipped. Values stay strings and are not interpolated:
+// single-quoted values are literal, double-quoted values understand \n, \t,
+// \" and \\, and unquoted values end at a " #" comment.
+func parseEnvFile(content []byte) (map[string]any, error) {
+	m := map[string]any{}
+	sc := bufio.NewScanner(bytes.NewReader(normalize(content)))
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNo)
+		}
+		v, err := envValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		m[key] = v
+	}
+	return m, sc.Err()
+}
+
+func envValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	switch raw[0] {
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		return raw[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(raw); i++ {
+			c := raw[i]
+			switch {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && i+1 < len(raw):
+				i++
+				switch raw[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					b.WriteByte(raw[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double quote")
+	}
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
+
+// loadValuesFile loads a -d/-f values file. The entries of an env file are
+// placed under shared.EnvKey (a dotted key) when it is set.
+func loadValuesFile(path string, shared SharedOptions) (map[string]any, error) {
+	m, err := loadData(path)
+	if err != nil || shared.EnvKey == "" || !isEnvFile(path) {
+		return m, err
+	}
+	nested := map[string]any{}
+	setByDottedKey(nested, shared.EnvKey, m)
+	return nested, nil
+}
+
+// coerceEnvValues types the string values of an env file loaded from path by
+// schema. Other values files already carry their types.
+func coerceEnvValues(path string, m map[string]any, schema *valueSchema) error {
+	if schema == nil || !isEnvFile(path) {
+		return nil
+	}
+	if err := schema.coerceStrings(m, ""); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+// Log formats accepted by --log-format.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// logFormat selects how errors and warnings are written to stderr.
+var logFormat = LogFormatText
+
+// SetLogFormat sets the stderr format for errors and warnings: "text" (default) or "json".
+func SetLogFormat(f string) error {
+	switch f {
+	case "", LogFormatText:
+		logFormat = LogFormatText
+	case LogFormatJSON:
+		logFormat = LogFormatJSON
+	default:
+		return fmt.Errorf("invalid --log-format %q (want text or json)", f)
+	}
+	return nil
+}
+
+// logRecord is one line of --log-format json output.
+type logRecord struct {
+	Level    string `json:"level"`
+	Kind     string `json:"kind"`
+	Message  string `json:"message"`
+	Template string `json:"template,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Source   string `json:"source,omitempty"` // offending template line
+	Hint     string `json:"hint,omitempty"`
+}
+
+func writeLogRecord(w io.Writer, r logRecord) {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(r)
+}
+
+// tplErrPos matches the position prefix of text/template errors:
+// "template: NAME:LINE: ..." (parse) or "template: NAME:LINE:COL: ..." (execute).
+var tplErrPos = regexp.MustCompile(`template: ([^\s:]+(?::[^\s:]+)*?):(\d+)(?::(\d+))?: `)
+
+// TemplateError is a parse or render error located in a template source.
+// Error returns the plain message; the offending source line and a hint are
+// shown by PrintError.
+type TemplateError struct {
+	Kind     string // "parse", "render" or "strict"
+	Template string // template name or path as shown to the user
+	Line     int
+	Column   int    // byte offset in the line as reported by text/template; -1 when unknown
+	Expr     string // failing expression, e.g. ".Values.port"
+	Key      string // missing map key, if any
+	Hint     string
+	msg      string
+	source   string
+	err      error
+}
+
+func (e *TemplateError) Error() string { return e.msg }
+func (e *TemplateError) Unwrap() error { return e.err }
+
+// newTemplateError locates err in sources and attaches a hint. rootLabel names
+// the template parsed as "root" (the single-file render mode). Errors raised
+// through include carry every template position; the innermost one is used.
+func newTemplateError(kind string, err error, sources *templateSources, rootLabel string) *TemplateError {
+	msg := err.Error()
+
+	// Report each function error once: "error calling f: f: boom" -> "error calling f: boom"
+	var fe *templr.FuncError
+	if errors.As(err, &fe) {
+		msg = strings.Replace(msg, "error calling "+fe.Func+": "+fe.Func+": ", "error calling "+fe.Func+": ", 1)
+	}
+
+	te := &TemplateError{Kind: kind, Column: -1, err: err}
+	if all := tplErrPos.FindAllStringSubmatch(msg, -1); len(all) > 0 {
+		m := all[len(all)-1]
+		te.Template = m[1]
+		te.Line, _ = strconv.Atoi(m[2])
+		if m[3] != "" {
+			te.Column, _ = strconv.Atoi(m[3])
+		}
+		te.source, _ = sources.get(m[1])
+		if te.Template == "root" && rootLabel != "" {
+			te.Template = rootLabel
+		}
+	}
+	if rootLabel != "" {
+		msg = strings.ReplaceAll(msg, "template: root:", "template: "+rootLabel+":")
+	}
+	te.msg = scrubSecrets(msg)
+
+	// The failing expression and missing key, e.g.
+	// `executing "x" at <.a.b>: map has no entry for key "b"`
+	if i := strings.LastIndex(msg, "at <"); i >= 0 {
+		if j := strings.Index(msg[i+4:], ">: "); j >= 0 {
+			te.Expr = msg[i+4 : i+4+j]
+		}
+	}
+	if i := strings.LastIndex(msg, `map has no entry for key "`); i >= 0 {
+		rest := msg[i+len(`map has no entry for key "`):]
+		if j := strings.Index(rest, `"`); j >= 0 {
+			te.Key = rest[:j]
+		}
+	}
+	te.Hint = templateErrorHint(te, msg)
+	return te
+}
+
+var (
+	undefinedFuncRe = regexp.MustCompile(`function "([^"]+)" not defined`)
+	undefinedTplRe  = regexp.MustCompile(`template "([^"]+)" not (?:defined|found)`)
+	funcCallRe      = regexp.MustCompile(`error calling (\w+): `)
+	cantEvaluateRe  = regexp.MustCompile(`can't evaluate field (\w+)`)
+	unmatchedRe     = regexp.MustCompile(`unexpected (\{\{(?:end|else)\}\})`)
+	unterminatedRe  = regexp.MustCompile(`unclosed action|unterminated (?:quoted string|raw quoted string|character constant)`)
+	hintlessFuncs   = map[string]bool{"fail": true, "required": true, "requiredFields": true, "requiredAll": true, "include": true, "includeCached": true}
+)
+
+// templateErrorHint suggests a fix for the common template mistakes, in the
+// language of --lang.
+func templateErrorHint(te *TemplateError, msg string) string {
+	if te.Kind == "strict" {
+		switch {
+		case te.Key == valuesNamespaceKey:
+			return tr("Run with --values-namespace, or set template.values_namespace, to read the values under .Values as Helm charts do.")
+		case te.Key != "":
+			return trf("Define '%s' in your values file, or run without --strict to use defaults.", te.Key)
+		case te.Expr != "":
+			return trf("Define '%s' in your values file, or run without --strict to use defaults.", te.Expr)
+		}
+		return tr("Check your values file to ensure all required keys are defined, or run without --strict.")
+	}
+	if m := undefinedFuncRe.FindStringSubmatch(msg); m != nil {
+		return trf("%q is not a template function; run `templr funcs` to list the available ones.", m[1])
+	}
+	if m := undefinedTplRe.FindStringSubmatch(msg); m != nil {
+		return trf("No template named %q is loaded; declare it with {{ define %q }} in a helper or partial.", m[1], m[1])
+	}
+	if strings.Contains(msg, "unexpected EOF") {
+		return tr("A block ({{ if }}, {{ range }}, {{ with }} or {{ define }}) is missing its {{ end }}.")
+	}
+	if unterminatedRe.MatchString(msg) {
+		return tr("An action or string is not closed; check the delimiters and quotes on this line.")
+	}
+	if m := unmatchedRe.FindStringSubmatch(msg); m != nil {
+		return trf("%s has no matching {{ if }}, {{ range }} or {{ with }}.", m[1])
+	}
+	if strings.Contains(msg, "nil pointer evaluating") {
+		return tr("A value in this expression is missing; guard it with {{ with }} or provide a fallback with `default`.")
+	}
+	if m := cantEvaluateRe.FindStringSubmatch(msg); m != nil {
+		return trf("Field %q was looked up on a value that is not a map; check the structure of your values.", m[1])
+	}
+	if strings.Contains(msg, "not allowed by the fips crypto policy") {
+		return tr("Use an approved helper such as sha256sum, or run `templr lint --crypto-policy fips` to find every such call.")
+	}
+	if m := funcCallRe.FindAllStringSubmatch(msg, -1); len(m) > 0 {
+		if name := m[len(m)-1][1]; !hintlessFuncs[name] {
+			return trf("Check the arguments passed to %s.", name)
+		}
+	}
+	return ""
+}
+
+// writeLocation writes "  NAME:LINE" and a code frame of the offending line
+// with one line of context around it.
+func (e *TemplateError) writeLocation(buf *bytes.Buffer, colorize func(string, string) string) {
+	if e.Template == "" || e.Line <= 0 {
+		return
+	}
+	loc := fmt.Sprintf("  %s:%d", e.Template, e.Line)
+	if e.Column >= 0 {
+		loc += fmt.Sprintf(":%d", e.Column)
+	}
+	buf.WriteString(colorize(colorCyan, loc) + "\n")
+
+	lines := strings.Split(e.source, "\n")
+	if e.source == "" || e.Line > len(lines) {
+		return
+	}
+	buf.WriteString("\n")
+	start := max(e.Line-2, 0)
+	end := min(e.Line+1, len(lines))
+	for i := start; i < end; i++ {
+		text := strings.TrimRight(lines[i], "\r")
+		lineNumStr := fmt.Sprintf("%4d", i+1)
+		if i+1 != e.Line {
+			buf.WriteString(colorize(colorGray, lineNumStr) + " | " + text + "\n")
+			continue
+		}
+		buf.WriteString(colorize(colorGray, lineNumStr) + " | " + colorize(colorRed, text) + "\n")
+		buf.WriteString(colorize(colorGray, "     | "))
+		buf.WriteString(caretIndent(text, e.Column) + colorize(colorRed, tr("^ Error occurred here")) + "\n")
+	}
+	buf.WriteString("\n")
+}
+
+// caretIndent returns the whitespace that aligns a caret under byte col of
+// line, keeping tabs so the caret lines up in the terminal.
+func caretIndent(line string, col int) string {
+	if col <= 0 || col > len(line) {
+		return ""
+	}
+	var b strings.Builder
+	for _, r := range line[:col] {
+		if r == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+func (e *TemplateError) writeHint(buf *bytes.Buffer, colorize func(string, string) string) {
+	if e.Hint == "" {
+		return
+	}
+	buf.WriteString(colorize(colorYellow, "  💡 "+tr("Tip: ")) + e.Hint + "\n")
+}
+
+func (e *TemplateError) logRecord(level string) logRecord {
+	r := logRecord{
+		Level:    level,
+		Kind:     e.Kind,
+		Message:  e.msg,
+		Template: e.Template,
+		Line:     e.Line,
+		Hint:     e.Hint,
+	}
+	if e.Column >= 0 {
+		r.Column = e.Column
+	}
+	if lines := strings.Split(e.source, "\n"); e.source != "" && e.Line > 0 && e.Line <= len(lines) {
+		r.Source = strings.TrimRight(lines[e.Line-1], "\r")
+	}
+	return r
+}
+
+func colorizer(noColor bool) func(string, string) string {
+	return func(color, text string) string {
+		if noColor {
+			return text
+		}
+		return color + text + colorReset
+	}
+}
+
+// formatErrorContext renders the code frame and hint of a *TemplateError
+// wrapped in err, or "" if err carries no template context.
+func formatErrorContext(err error, noColor bool) string {
+	var te *TemplateError
+	if !errors.As(err, &te) {
+		return ""
+	}
+	var buf bytes.Buffer
+	colorize := colorizer(noColor)
+	te.writeLocation(&buf, colorize)
+	te.writeHint(&buf, colorize)
+	return buf.String()
+}
+
+// PrintError writes a command error to stderr: "Error: <message>" followed by
+// the offending template snippet and a hint, or a single JSON object with
+// --log-format json.
+func PrintError(err error, noColor bool) {
+	writeError(os.Stderr, tr("Error: "), ErrorKind(err), err, noColor)
+}
+
+// writeError writes err with the given text prefix; kind is used for JSON
+// records of errors that carry no template context.
+func writeError(w io.Writer, prefix, kind string, err error, noColor bool) {
+	if logFormat == LogFormatJSON {
+		var te *TemplateError
+		if errors.As(err, &te) {
+			r := te.logRecord("error")
+			r.Message = scrubSecrets(err.Error())
+			writeLogRecord(w, r)
+			return
+		}
+		if kind == "" {
+			kind = "error"
+		}
+		writeLogRecord(w, logRecord{Level: "error", Kind: kind, Message: scrubSecrets(err.Error())})
+		return
+	}
+	fmt.Fprintf(w, "%s%s\n", prefix, scrubSecrets(err.Error()))
+	fmt.Fprint(w, formatErrorContext(err, noColor))
+}
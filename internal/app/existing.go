@@ -0,0 +1,79 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ExistingAPI is the read-only .Existing facade of a walk: the file the
+// template being rendered is about to replace, so a template can keep
+// selected fields of its previous output, e.g. a generated password. Path is
+// empty when the outputs do not go to a directory.
+type ExistingAPI struct {
+	Path string
+}
+
+// Exists reports whether the destination file exists.
+func (e ExistingAPI) Exists() bool {
+	if e.Path == "" {
+		return false
+	}
+	info, err := os.Stat(e.Path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Content returns the content of the destination file, or "" when there is
+// none.
+func (e ExistingAPI) Content() (string, error) {
+	if !e.Exists() {
+		return "", nil
+	}
+	b, err := os.ReadFile(e.Path)
+	if err != nil {
+		return "", fmt.Errorf("existing %s: %w", e.Path, err)
+	}
+	return string(b), nil
+}
+
+// Data parses the destination file by its extension like a values file
+// (YAML, JSON, TOML, .env). It returns an empty map when there is no file.
+func (e ExistingAPI) Data() (map[string]any, error) {
+	if !e.Exists() {
+		return map[string]any{}, nil
+	}
+	m, err := loadData(e.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("existing %s: %w", e.Path, err)
+	}
+	if m == nil {
+		m = map[string]any{}
+	}
+	return m, nil
+}
+
+// Get returns the value at a dotted key of Data, or nil when the file or
+// the key is missing, so it combines with default:
+// {{ .Existing.Get "db.password" | default (randAlphaNum 24) }}.
+func (e ExistingAPI) Get(key string) (any, error) {
+	m, err := e.Data()
+	if err != nil {
+		return nil, err
+	}
+	var cur any = m
+	for _, part := range strings.Split(key, ".") {
+		mm, ok := cur.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		if cur, ok = mm[part]; !ok {
+			return nil, nil
+		}
+	}
+	return cur, nil
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"errors"
+	"os"
+)
+
+// ExitError is an error reported with a specific exit code. Kind names the
+// error class in "[templr:error:<kind>]" messages and JSON records.
+type ExitError struct {
+	Code int
+	Kind string // "args", "data", "guard", "template", ...
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// exitError wraps err so that it is reported with code and kind.
+func exitError(code int, kind string, err error) error {
+	return &ExitError{Code: code, Kind: kind, Err: err}
+}
+
+// argsError is an invalid flag or argument combination (ExitGeneral).
+func argsError(err error) error {
+	return exitError(ExitGeneral, "args", err)
+}
+
+// ExitCode returns the process exit code for err: the code of an ExitError
+// in its chain, ExitTemplateError (ExitStrictError for strict mode) for
+// template errors, and ExitGeneral otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var ee *ExitError
+	if errors.As(err, &ee) {
+		return ee.Code
+	}
+	var te *TemplateError
+	if errors.As(err, &te) {
+		if te.Kind == "strict" {
+			return ExitStrictError
+		}
+		return ExitTemplateError
+	}
+	return ExitGeneral
+}
+
+// ErrorKind returns the kind err is reported with, "error" if it has none.
+func ErrorKind(err error) string {
+	var ee *ExitError
+	if errors.As(err, &ee) && ee.Kind != "" {
+		return ee.Kind
+	}
+	var te *TemplateError
+	if errors.As(err, &te) {
+		return te.Kind
+	}
+	return "error"
+}
+
+// exitZero makes every exit status 0 (--exit-zero), for report-only runs.
+var exitZero bool
+
+// SetExitZero makes Exit report success whatever the exit code.
+func SetExitZero(v bool) {
+	exitZero = v
+}
+
+// exitCleanups run when Exit ends the process early, e.g. to remove the
+// temporary files of an unfinished write (deferred calls do not run).
+var exitCleanups []func()
+
+// onExit registers fn to run in Exit.
+func onExit(fn func()) {
+	exitCleanups = append(exitCleanups, fn)
+}
+
+// Exit prints a pending legacy notice and update notice, flushes pending
+// spans and exits with code, or with 0 under --exit-zero.
+func Exit(code int) {
+	for _, fn := range exitCleanups {
+		fn()
+	}
+	PrintLegacyNotice()
+	PrintUpdateNotice()
+	ShutdownTracing()
+	if exitZero {
+		code = ExitOK
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// featuresKey is the key of the values under which templates find the
+// feature flags of the render, as .Templr.Features.
+const featuresKey = "Templr"
+
+// checkFeatures validates --feature and --feature-set before rendering.
+func checkFeatures(shared SharedOptions) error {
+	if _, err := featureMap(shared); err != nil {
+		return argsError(err)
+	}
+	return nil
+}
+
+// featureMap returns the feature flags of the render: features.enabled of
+// the config, then the features.sets selected by --feature-set in order,
+// then --feature, later ones overriding earlier ones. A flag without a value
+// is true; "true" and "false" are booleans and any other value a string.
+func featureMap(shared SharedOptions) (map[string]any, error) {
+	features := map[string]any{}
+	add := func(from string, specs []string) error {
+		for _, spec := range specs {
+			name, value, hasValue := strings.Cut(spec, "=")
+			name = strings.TrimSpace(name)
+			if name == "" {
+				return fmt.Errorf("%s %q: missing feature name", from, spec)
+			}
+			switch {
+			case !hasValue || value == "true":
+				features[name] = true
+			case value == "false":
+				features[name] = false
+			default:
+				features[name] = value
+			}
+		}
+		return nil
+	}
+	if err := add("features.enabled", shared.FeaturesEnabled); err != nil {
+		return nil, err
+	}
+	for _, set := range shared.FeatureSets {
+		specs, ok := shared.FeatureSetDefs[set]
+		if !ok {
+			return nil, fmt.Errorf("--feature-set %q: no such set in features.sets", set)
+		}
+		if err := add("features.sets."+set, specs); err != nil {
+			return nil, err
+		}
+	}
+	if err := add("--feature", shared.Features); err != nil {
+		return nil, err
+	}
+	return features, nil
+}
+
+// addFeatures exposes the feature flags of the render as .Templr.Features,
+// next to any other keys of a .Templr map in the values. Without a feature
+// configured (features.enabled, features.sets, --feature or --feature-set)
+// the values are left as they are, so that renders not using features see
+// only their own keys; hasFeature works either way. A top-level Templr key
+// of the values that is not a map wins, with a warning.
+func addFeatures(values map[string]any, shared SharedOptions) {
+	if !featuresConfigured(shared) {
+		return
+	}
+	features, _ := featureMap(shared) // validated by checkFeatures
+	ns, ok := values[featuresKey].(map[string]any)
+	if !ok {
+		if v, exists := values[featuresKey]; exists && v != nil {
+			warnf("features", "the values have a top-level %s key that is not a map; use hasFeature to read the feature flags", featuresKey)
+			return
+		}
+		ns = map[string]any{}
+		values[featuresKey] = ns
+	}
+	ns["Features"] = features
+}
+
+// featuresConfigured reports whether the render has any feature flags
+// configured, even if none is enabled.
+func featuresConfigured(shared SharedOptions) bool {
+	return len(shared.FeaturesEnabled) > 0 || len(shared.FeatureSetDefs) > 0 ||
+		len(shared.FeatureSets) > 0 || len(shared.Features) > 0
+}
@@ -0,0 +1,418 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+// FmtOptions contains options for `templr fmt`
+type FmtOptions struct {
+	Shared SharedOptions
+	Paths  []string // files or directories to format; "-" (or none) formats stdin
+	Check  bool     // list unformatted templates and fail instead of rewriting them
+}
+
+// fmtIndent is the indentation added per nesting level of control structures.
+const fmtIndent = "  "
+
+// RunFmt formats templates in place, or with --check reports the ones that
+// are not formatted. Every changed (or unformatted) path is printed.
+func RunFmt(opts FmtOptions) error {
+	if len(opts.Paths) == 0 || (len(opts.Paths) == 1 && opts.Paths[0] == "-") {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read stdin: %w", err)
+		}
+		out, err := formatTemplate("stdin", string(src), opts.Shared.Ldelim, opts.Shared.Rdelim)
+		if err != nil {
+			return exitError(ExitTemplateError, "fmt", err)
+		}
+		if opts.Check {
+			if out != string(src) {
+				return exitError(ExitFmtCheck, "fmt", fmt.Errorf("stdin is not formatted"))
+			}
+			return nil
+		}
+		_, err = io.WriteString(os.Stdout, out)
+		return err
+	}
+
+	files, err := fmtFiles(opts.Paths, buildAllowedExts(opts.Shared.ExtraExts))
+	if err != nil {
+		return err
+	}
+	var unformatted, failed int
+	for _, path := range files {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+		out, err := formatTemplate(path, string(src), opts.Shared.Ldelim, opts.Shared.Rdelim)
+		if err != nil {
+			checkErrorf("fmt", "%v", err)
+			failed++
+			continue
+		}
+		if out == string(src) {
+			continue
+		}
+		unformatted++
+		fmt.Println(path)
+		if opts.Check {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if _, err := writeIfChanged(path, []byte(out), info.Mode().Perm()); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+	if failed > 0 {
+		return exitError(ExitTemplateError, "fmt", fmt.Errorf("%d template%s could not be formatted", failed, pluralize(failed)))
+	}
+	if opts.Check && unformatted > 0 {
+		return exitError(ExitFmtCheck, "fmt", fmt.Errorf("%d template%s not formatted; run templr fmt", unformatted, pluralize(unformatted)))
+	}
+	return nil
+}
+
+// fmtFiles expands paths into the template files to format: files are kept
+// as given, directories are walked for templates with an allowed extension.
+func fmtFiles(paths []string, allowExts map[string]bool) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, argsError(err)
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		var found []string
+		err = filepath.WalkDir(p, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && matchExt(path, allowExts) != "" {
+				found = append(found, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(found)
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+// fmtToken is a piece of template source: literal text or one action.
+type fmtToken struct {
+	text    string // source of the token
+	action  bool
+	comment bool
+	ltrim   bool   // action starts with a "{{- " trim marker
+	rtrim   bool   // action ends with a " -}}" trim marker
+	body    string // action source between the delimiters and trim markers
+}
+
+// formatTemplate returns src in canonical form:
+//   - actions are written "{{ x }}" / "{{- x -}}" with a single space inside
+//     the delimiters, and runs of spaces inside single-line actions collapsed,
+//     with pipes written " | " and declarations " := ";
+//   - control structures nested inside if/range/with/define/block are
+//     indented by two spaces per level relative to their opener, and
+//     else/end are aligned with it.
+//
+// Only whitespace the template engine trims is re-indented, so the rendered
+// output never changes; the parse trees of src and the result are compared
+// to make sure of it. Comments, multi-line actions and the content of raw
+// blocks are left as written.
+func formatTemplate(name, src, ldelim, rdelim string) (string, error) {
+	blocks, err := templr.FindRawBlocks(name, src, ldelim, rdelim)
+	if err != nil {
+		return "", err
+	}
+	src, raw := hideRawContent(src, blocks)
+
+	before, err := fmtParse(name, src, ldelim, rdelim)
+	if err != nil {
+		return "", err
+	}
+	tokens, err := fmtTokenize(src, ldelim, rdelim)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	var out strings.Builder
+	var openers []string // line indentation of each enclosing block opener
+	eaten := false       // the previous action trims the whitespace that follows it
+	for _, tok := range tokens {
+		if !tok.action {
+			out.WriteString(tok.text)
+			if strings.TrimLeft(tok.text, " \t\r\n") != "" {
+				eaten = false
+			}
+			continue
+		}
+		keyword := strings.Fields(tok.body + " ")[0]
+		if tok.comment || strings.Contains(tok.body, "\n") {
+			out.WriteString(tok.text)
+		} else {
+			closing := keyword == "else" || keyword == "end"
+
+			// Re-indent an action that starts its line when the indentation is trimmed
+			if indent, ok := lineIndent(out.String()); ok && (tok.ltrim || eaten) && len(openers) > 0 {
+				want := openers[len(openers)-1]
+				if !closing {
+					want += fmtIndent
+				}
+				s := out.String()
+				out.Reset()
+				out.WriteString(s[:len(s)-len(indent)])
+				out.WriteString(want)
+			}
+			writeAction(&out, tok, fmtActionBody(tok.body), ldelim, rdelim)
+		}
+		eaten = tok.rtrim
+
+		switch keyword {
+		case "if", "range", "with", "define", "block":
+			openers = append(openers, currentIndent(out.String()))
+		case "end":
+			if len(openers) > 0 {
+				openers = openers[:len(openers)-1]
+			}
+		}
+	}
+
+	result := out.String()
+	after, err := fmtParse(name, result, ldelim, rdelim)
+	if err != nil || !sameTrees(before, after) {
+		return "", fmt.Errorf("%s: formatting would change the rendered output; left unchanged", name)
+	}
+	return raw.Replace(result), nil
+}
+
+// hideRawContent replaces the content of each raw block of src with a
+// placeholder, which the returned replacer turns back into the content.
+func hideRawContent(src string, blocks []templr.RawBlock) (string, *strings.Replacer) {
+	var b strings.Builder
+	var pairs []string
+	last := 0
+	for i, blk := range blocks {
+		start := blk.ContentStart
+		placeholder := fmt.Sprintf("\x00raw%d\x00", i)
+		b.WriteString(src[last:start])
+		b.WriteString(placeholder)
+		pairs = append(pairs, placeholder, blk.Content)
+		last = start + len(blk.Content)
+	}
+	b.WriteString(src[last:])
+	return b.String(), strings.NewReplacer(pairs...)
+}
+
+// writeAction writes an action with canonical spacing around body.
+func writeAction(out *strings.Builder, tok fmtToken, body, ldelim, rdelim string) {
+	out.WriteString(ldelim)
+	if tok.ltrim {
+		out.WriteString("- ")
+	} else {
+		out.WriteString(" ")
+	}
+	out.WriteString(body)
+	if tok.rtrim {
+		out.WriteString(" -")
+	} else {
+		out.WriteString(" ")
+	}
+	out.WriteString(rdelim)
+}
+
+// fmtParse parses src into its set of named trees without checking functions.
+func fmtParse(name, src, ldelim, rdelim string) (map[string]*parse.Tree, error) {
+	t := parse.New(name)
+	t.Mode = parse.ParseComments | parse.SkipFuncCheck
+	trees := map[string]*parse.Tree{}
+	if _, err := t.Parse(src, ldelim, rdelim, trees); err != nil {
+		return nil, err
+	}
+	return trees, nil
+}
+
+// sameTrees reports whether two template sets render identically.
+func sameTrees(a, b map[string]*parse.Tree) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, ta := range a {
+		tb, ok := b[name]
+		if !ok || ta.Root.String() != tb.Root.String() {
+			return false
+		}
+	}
+	return true
+}
+
+// fmtTokenize splits src into text and action tokens.
+func fmtTokenize(src, ldelim, rdelim string) ([]fmtToken, error) {
+	var tokens []fmtToken
+	for len(src) > 0 {
+		i := strings.Index(src, ldelim)
+		if i < 0 {
+			tokens = append(tokens, fmtToken{text: src})
+			break
+		}
+		if i > 0 {
+			tokens = append(tokens, fmtToken{text: src[:i]})
+		}
+		n, err := actionLen(src[i:], ldelim, rdelim)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, newActionToken(src[i:i+n], ldelim, rdelim))
+		src = src[i+n:]
+	}
+	return tokens, nil
+}
+
+// actionLen returns the length of the action at the start of s, skipping
+// delimiters inside comments and quoted strings.
+func actionLen(s, ldelim, rdelim string) (int, error) {
+	i := len(ldelim)
+	if inner := trimLeftMarker(s[i:]); strings.HasPrefix(inner, "/*") {
+		end := strings.Index(inner, "*/")
+		if end < 0 {
+			return 0, fmt.Errorf("unclosed comment")
+		}
+		i = len(s) - len(inner) + end + 2
+		j := strings.Index(s[i:], rdelim)
+		if j < 0 {
+			return 0, fmt.Errorf("unclosed action")
+		}
+		return i + j + len(rdelim), nil
+	}
+	var quote byte
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case strings.HasPrefix(s[i:], rdelim):
+			return i + len(rdelim), nil
+		}
+	}
+	return 0, fmt.Errorf("unclosed action")
+}
+
+// trimLeftMarker strips a leading "- " trim marker from the inside of an action.
+func trimLeftMarker(s string) string {
+	if len(s) > 1 && s[0] == '-' && isSpaceByte(s[1]) {
+		return strings.TrimLeft(s[1:], " \t\r\n")
+	}
+	return strings.TrimLeft(s, " \t\r\n")
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// newActionToken splits an action into its trim markers and body.
+func newActionToken(text, ldelim, rdelim string) fmtToken {
+	tok := fmtToken{text: text, action: true}
+	inner := text[len(ldelim) : len(text)-len(rdelim)]
+	if len(inner) > 1 && inner[0] == '-' && isSpaceByte(inner[1]) {
+		tok.ltrim = true
+		inner = inner[1:]
+	}
+	if n := len(inner); n > 1 && inner[n-1] == '-' && isSpaceByte(inner[n-2]) {
+		tok.rtrim = true
+		inner = inner[:n-1]
+	}
+	tok.body = strings.TrimSpace(inner)
+	tok.comment = strings.HasPrefix(tok.body, "/*")
+	return tok
+}
+
+// fmtActionBody normalizes the spacing of a single-line action body outside
+// of string literals.
+func fmtActionBody(body string) string {
+	var b strings.Builder
+	space := false // a space is pending before the next token
+	var quote byte
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if quote != 0 {
+			b.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(body) {
+				i++
+				b.WriteByte(body[i])
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			space = true
+			continue
+		case c == '|' || c == '=' || c == ':' && i+1 < len(body) && body[i+1] == '=':
+			op := string(c)
+			if c == ':' {
+				op = ":="
+				i++
+			}
+			b.WriteString(" " + op)
+			space = true
+			continue
+		case c == ')':
+			space = false
+		case c == ',':
+			b.WriteByte(c)
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 && !strings.HasSuffix(b.String(), "(") {
+			b.WriteByte(' ')
+		}
+		space = false
+		if c == '"' || c == '\'' || c == '`' {
+			quote = c
+		}
+		b.WriteByte(c)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// lineIndent returns the indentation of the last line of s when that line
+// holds nothing but indentation.
+func lineIndent(s string) (string, bool) {
+	line := s[strings.LastIndexByte(s, '\n')+1:]
+	if strings.Trim(line, " \t") != "" {
+		return "", false
+	}
+	return line, true
+}
+
+// currentIndent returns the leading whitespace of the last line of s.
+func currentIndent(s string) string {
+	line := s[strings.LastIndexByte(s, '\n')+1:]
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
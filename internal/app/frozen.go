@@ -0,0 +1,93 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+)
+
+// frozenManifest holds a walk to the provenance statement of an earlier
+// run (walk --frozen): it may not create outputs the statement does not
+// list, nor change an output while its templates, values files and --set
+// values are the ones the statement records.
+type frozenManifest struct {
+	path       string
+	outputs    map[string]string // output path -> sha256
+	inputsSame bool              // the run reads exactly the recorded inputs
+	violations int
+}
+
+// checkFrozenOptions validates --frozen before rendering.
+func checkFrozenOptions(opts WalkOptions) error {
+	if opts.Frozen && opts.Provenance == "" {
+		return argsError(fmt.Errorf("--frozen requires --provenance, the statement of the run to hold the walk to"))
+	}
+	return nil
+}
+
+// loadFrozenManifest reads the statement of opts.Provenance, checking its
+// signature with the --provenance-key, and compares its inputs with those
+// of the current run. It returns nil without --frozen.
+func loadFrozenManifest(opts WalkOptions, absSrc string, names []string) (*frozenManifest, error) {
+	if !opts.Frozen {
+		return nil, nil
+	}
+	st, err := readProvenance(opts.Provenance, opts.ProvenanceKey)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := walkInputs(opts, absSrc, names)
+	if err != nil {
+		return nil, fmt.Errorf("frozen: %w", err)
+	}
+	recorded := st.Predicate.BuildDefinition
+	m := &frozenManifest{path: opts.Provenance, outputs: map[string]string{}}
+	m.inputsSame = slices.Equal(recorded.ExternalParameters.Sets, opts.Shared.Sets) &&
+		slices.EqualFunc(recorded.ResolvedDependencies, deps, func(a, b resourceDescriptor) bool {
+			return a.Name == b.Name && a.Digest["sha256"] == b.Digest["sha256"]
+		})
+	for _, s := range st.Subject {
+		m.outputs[s.Name] = s.Digest["sha256"]
+	}
+	return m, nil
+}
+
+// allows reports whether the output relOut, with the content the walk would
+// write, is consistent with the manifest; the violation is reported on
+// stderr otherwise. A nil manifest allows everything.
+func (m *frozenManifest) allows(relOut, dstPath string, outBytes []byte, shared SharedOptions) (bool, error) {
+	if m == nil || isEmpty(outBytes) {
+		return true, nil
+	}
+	rel := filepath.ToSlash(relOut)
+	want, listed := m.outputs[rel]
+	if !listed {
+		checkErrorf("frozen", "%s is not listed in %s; the walk would create it", rel, m.path)
+		m.violations++
+		return false, nil
+	}
+	if !m.inputsSame {
+		return true, nil
+	}
+	if shared.InjectGuard {
+		outBytes = injectGuardForExt(dstPath, outBytes, shared)
+	}
+	final, err := encodeOutput(dstPath, outBytes, shared)
+	if err != nil {
+		return false, fmt.Errorf("encode %s: %w", dstPath, err)
+	}
+	if sha256Digest(final)["sha256"] != want {
+		checkErrorf("frozen", "%s would change although its inputs match %s; the render is not deterministic", rel, m.path)
+		m.violations++
+		return false, nil
+	}
+	return true, nil
+}
+
+// err returns the error ending a walk with violations.
+func (m *frozenManifest) err() error {
+	if m == nil || m.violations == 0 {
+		return nil
+	}
+	return exitError(ExitVerifyFailed, "frozen", fmt.Errorf("--frozen: %d output%s not accounted for by %s", m.violations, pluralize(m.violations), m.path))
+}
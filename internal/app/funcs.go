@@ -0,0 +1,68 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+// FuncsOptions contains options for `templr funcs`
+type FuncsOptions struct {
+	Category  string // only list functions in this category
+	Namespace string // only list functions in this namespace (sprig, templr)
+	Format    string // text or json
+}
+
+// RunFuncs lists the available template functions grouped by category.
+func RunFuncs(opts FuncsOptions) error {
+	var funcs []templr.FuncInfo
+	for _, f := range templr.Funcs() {
+		if opts.Category != "" && f.Category != opts.Category {
+			continue
+		}
+		if opts.Namespace != "" && f.Namespace != opts.Namespace {
+			continue
+		}
+		funcs = append(funcs, f)
+	}
+
+	switch opts.Format {
+	case "json":
+		if funcs == nil {
+			funcs = []templr.FuncInfo{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(funcs)
+	case "", "text":
+	default:
+		return fmt.Errorf("unknown format %q (want text or json)", opts.Format)
+	}
+
+	category := ""
+	for _, f := range funcs {
+		if f.Category != category {
+			if category != "" {
+				fmt.Println()
+			}
+			category = f.Category
+			fmt.Printf("%s:\n", category)
+		}
+		var notes []string
+		if f.OverridesSprig {
+			notes = append(notes, "overrides sprig")
+		}
+		if f.Deprecated() {
+			notes = append(notes, fmt.Sprintf("deprecated since %s, use %s", f.DeprecatedSince, f.Replacement))
+		}
+		line := fmt.Sprintf("  %-28s %s", f.Name, f.Namespace)
+		if len(notes) > 0 {
+			line += " (" + strings.Join(notes, "; ") + ")"
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kanopi/templr/pkg/lint"
+)
+
+// ghaSummaryEnv is the file GitHub Actions renders as the job's step summary.
+const ghaSummaryEnv = "GITHUB_STEP_SUMMARY"
+
+// ghaOutputEnv is the file GitHub Actions reads the step's outputs from.
+const ghaOutputEnv = "GITHUB_OUTPUT"
+
+// problemMatcher mirrors the JSON document accepted by `::add-matcher::`.
+// See https://github.com/actions/toolkit/blob/main/docs/problem-matchers.md
+type problemMatcher struct {
+	ProblemMatcher []problemMatcherOwner `json:"problemMatcher"`
+}
+
+type problemMatcherOwner struct {
+	Owner    string                  `json:"owner"`
+	Severity string                  `json:"severity"`
+	Pattern  []problemMatcherPattern `json:"pattern"`
+}
+
+type problemMatcherPattern struct {
+	Regexp  string `json:"regexp"`
+	File    int    `json:"file"`
+	Line    int    `json:"line"`
+	Code    int    `json:"code"`
+	Message int    `json:"message"`
+}
+
+// lintMatcherRegexp matches one line of `templr lint` text output, e.g.
+// "[lint:error:parse] templates/app.tpl:3: unexpected EOF". The optional
+// escape sequences allow colored output; the line number is optional.
+const lintMatcherRegexp = `^(?:\x1b\[\d+m)?\[lint:%s:([^\]]+)\](?:\x1b\[0m)?\s+(.+?)(?::(\d+))?:\s(.*)$`
+
+// PrintProblemMatcher writes the GitHub Actions problem matcher for the lint
+// text format to w.
+func PrintProblemMatcher(w io.Writer) error {
+	owner := func(name, level, severity string) problemMatcherOwner {
+		return problemMatcherOwner{
+			Owner:    name,
+			Severity: severity,
+			Pattern: []problemMatcherPattern{{
+				Regexp:  fmt.Sprintf(lintMatcherRegexp, level),
+				Code:    1,
+				File:    2,
+				Line:    3,
+				Message: 4,
+			}},
+		}
+	}
+	m := problemMatcher{ProblemMatcher: []problemMatcherOwner{
+		owner("templr-lint-error", "error", "error"),
+		owner("templr-lint-warning", "warn", "warning"),
+	}}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("encode problem matcher: %w", err)
+	}
+	return nil
+}
+
+// appendStepSummary appends markdown to $GITHUB_STEP_SUMMARY. Outside of
+// GitHub Actions it warns and does nothing.
+func appendStepSummary(markdown string) error {
+	path := os.Getenv(ghaSummaryEnv)
+	if path == "" {
+		warnf("gha", "%s is not set; skipping step summary", ghaSummaryEnv)
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open step summary: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := io.WriteString(f, markdown); err != nil {
+		return fmt.Errorf("write step summary: %w", err)
+	}
+	return nil
+}
+
+// appendStepOutputs appends name=value lines to $GITHUB_OUTPUT, in order.
+// Outside of GitHub Actions it warns and does nothing.
+func appendStepOutputs(outputs [][2]string) error {
+	path := os.Getenv(ghaOutputEnv)
+	if path == "" {
+		warnf("gha", "%s is not set; skipping step outputs", ghaOutputEnv)
+		return nil
+	}
+	var b strings.Builder
+	for _, o := range outputs {
+		fmt.Fprintf(&b, "%s=%s\n", o[0], strings.ReplaceAll(o[1], "\n", " "))
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open step outputs: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := io.WriteString(f, b.String()); err != nil {
+		return fmt.Errorf("write step outputs: %w", err)
+	}
+	return nil
+}
+
+// lintStepOutputs are the step outputs lint --gha sets: the error and
+// warning counts, and the report file when --output is set.
+func lintStepOutputs(result *lint.Result, opts LintOptions) [][2]string {
+	outputs := [][2]string{
+		{"errors", fmt.Sprint(result.Errors)},
+		{"warnings", fmt.Sprint(result.Warns)},
+	}
+	if opts.Output != "" {
+		outputs = append(outputs, [2]string{"report", opts.Output})
+	}
+	return outputs
+}
+
+// mdCell escapes a value for use inside a Markdown table cell.
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r", "")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// lintSummaryMarkdown renders lint results as a Markdown step summary.
+func lintSummaryMarkdown(result *lint.Result) string {
+	var b strings.Builder
+	b.WriteString("### templr lint\n\n")
+	if len(result.Issues) == 0 {
+		b.WriteString(":white_check_mark: No issues found\n\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%d error(s), %d warning(s)\n\n", result.Errors, result.Warns)
+	b.WriteString("| Severity | Rule | File | Line | Message |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, issue := range result.Issues {
+		icon := ":warning:"
+		if issue.Severity == lint.SeverityError {
+			icon = ":x:"
+		}
+		line := ""
+		if issue.Line > 0 {
+			line = fmt.Sprint(issue.Line)
+		}
+		fmt.Fprintf(&b, "| %s %s | %s | %s | %s | %s |\n",
+			icon, issue.Severity, mdCell(issue.RuleID()), mdCell(issue.File), line, mdCell(issue.Message))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// renderRecord is one row of a walk render summary.
+type renderRecord struct {
+	Template string
+	Output   string
+	Status   string
+}
+
+// renderSummaryMarkdown renders walk results as a Markdown step summary.
+func renderSummaryMarkdown(records []renderRecord) string {
+	var b strings.Builder
+	b.WriteString("### templr walk\n\n")
+	if len(records) == 0 {
+		b.WriteString("No templates rendered\n\n")
+		return b.String()
+	}
+	b.WriteString("| Template | Output | Status |\n")
+	b.WriteString("| --- | --- | --- |\n")
+	for _, r := range records {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", mdCell(r.Template), mdCell(r.Output), r.Status)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
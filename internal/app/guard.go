@@ -0,0 +1,233 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GuardStyleNone as a comment style disables guard injection for the file type.
+const GuardStyleNone = "none"
+
+// Guard positions accepted by --guard-position and guard.positions.
+const (
+	GuardTop             = "top"              // first line of the file
+	GuardAfterShebang    = "after-shebang"    // below a #! line, else first
+	GuardAfterDirectives = "after-directives" // below leading lines that must stay first
+	GuardBottom          = "bottom"           // last line of the file
+)
+
+var (
+	hashCommentExts = map[string]bool{
+		".sh": true, ".bash": true, ".zsh": true, ".env": true,
+		".yml": true, ".yaml": true, ".toml": true, ".ini": true, ".conf": true,
+		".py": true, ".rb": true,
+	}
+	markupExts     = map[string]bool{".html": true, ".htm": true, ".xml": true, ".md": true}
+	slashSlashExts = map[string]bool{
+		".js": true, ".ts": true, ".mjs": true, ".cjs": true,
+		".go": true, ".java": true, ".kt": true, ".kts": true,
+		".c": true, ".h": true, ".cpp": true, ".hpp": true, ".cc": true, ".hh": true,
+		".rs": true, ".swift": true,
+	}
+)
+
+// checkGuardStyles validates the guard comment styles and positions given
+// with --guard-style, --guard-position and the guard config section.
+func checkGuardStyles(shared SharedOptions) error {
+	if shared.GuardStyle != "" {
+		if err := checkGuardStyle(shared.GuardStyle); err != nil {
+			return argsError(fmt.Errorf("invalid --guard-style: %w", err))
+		}
+	}
+	for key, style := range shared.GuardStyles {
+		if err := checkGuardStyle(style); err != nil {
+			return argsError(fmt.Errorf("invalid guard comment style for %s: %w", key, err))
+		}
+	}
+	if shared.GuardPosition != "" {
+		if err := checkGuardPosition(shared.GuardPosition); err != nil {
+			return argsError(fmt.Errorf("invalid --guard-position: %w", err))
+		}
+	}
+	for key, pos := range shared.GuardPositions {
+		if err := checkGuardPosition(pos); err != nil {
+			return argsError(fmt.Errorf("invalid guard position for %s: %w", key, err))
+		}
+	}
+	return nil
+}
+
+func checkGuardStyle(style string) error {
+	if style == GuardStyleNone || strings.Count(style, "%s") == 1 {
+		return nil
+	}
+	return fmt.Errorf("%q must contain %%s once, or be %q", style, GuardStyleNone)
+}
+
+func checkGuardPosition(pos string) error {
+	switch pos {
+	case GuardTop, GuardAfterShebang, GuardAfterDirectives, GuardBottom:
+		return nil
+	}
+	return fmt.Errorf("%q (want %s, %s, %s or %s)", pos, GuardTop, GuardAfterShebang, GuardAfterDirectives, GuardBottom)
+}
+
+// lookupGuardRule returns the rule set for path in rules. Keys starting with a
+// dot match the extension, other keys the file name (e.g. "Jenkinsfile"),
+// both case-insensitively.
+func lookupGuardRule(path string, rules map[string]string) (string, bool) {
+	base := strings.ToLower(filepath.Base(path))
+	ext := strings.ToLower(filepath.Ext(path))
+	for key, rule := range rules {
+		key = strings.ToLower(key)
+		if (strings.HasPrefix(key, ".") && key == ext) || key == base {
+			return rule, true
+		}
+	}
+	return "", false
+}
+
+// guardPlacement returns the comment style and position of the guard for
+// path. --guard-style/--guard-position and the guard config take precedence
+// over the built-in rules for the file type.
+func guardPlacement(path string, content []byte, shared SharedOptions) (style, pos string) {
+	style, pos = builtinGuardPlacement(path, content)
+	if s, ok := lookupGuardRule(path, shared.GuardStyles); ok {
+		style = s
+	}
+	if shared.GuardStyle != "" {
+		style = shared.GuardStyle
+	}
+	if p, ok := lookupGuardRule(path, shared.GuardPositions); ok {
+		pos = p
+	}
+	if shared.GuardPosition != "" {
+		pos = shared.GuardPosition
+	}
+	return style, pos
+}
+
+// builtinGuardPlacement returns the built-in comment style and position for
+// path. Unknown extensions use the comment syntax of a shebang interpreter,
+// falling back to "# %s".
+func builtinGuardPlacement(path string, content []byte) (style, pos string) {
+	base := strings.ToLower(filepath.Base(path))
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case ext == ".json":
+		return GuardStyleNone, GuardAfterDirectives
+	case base == "dockerfile" || hashCommentExts[ext]:
+		return "# %s", GuardAfterDirectives
+	case ext == ".php" || ext == ".phtml":
+		if bytes.HasPrefix(bytes.TrimPrefix(content, bomUTF8), []byte("<?php")) {
+			return "// %s", GuardAfterDirectives
+		}
+		return "<?php // %s ?>", GuardTop
+	case markupExts[ext]:
+		return "<!-- %s -->", GuardAfterDirectives
+	case ext == ".css" || ext == ".scss":
+		return "/* %s */", GuardAfterDirectives
+	case slashSlashExts[ext]:
+		return "// %s", GuardAfterDirectives
+	}
+	if s, ok := shebangGuardStyle(content); ok {
+		return s, GuardAfterDirectives
+	}
+	return "# %s", GuardAfterDirectives
+}
+
+// shebangGuardStyles maps script interpreters to their comment style.
+var shebangGuardStyles = map[string]string{
+	"sh": "# %s", "bash": "# %s", "zsh": "# %s", "ksh": "# %s", "dash": "# %s", "fish": "# %s",
+	"python": "# %s", "ruby": "# %s", "perl": "# %s", "Rscript": "# %s", "pwsh": "# %s",
+	"node": "// %s", "deno": "// %s", "bun": "// %s", "ts-node": "// %s",
+	"lua": "-- %s", "runghc": "-- %s",
+}
+
+// shebangGuardStyle guesses the comment style of a script from the
+// interpreter of its shebang line: "#!/usr/bin/env -S python3 -u" -> "# %s".
+func shebangGuardStyle(content []byte) (string, bool) {
+	if !isShebang(content) {
+		return "", false
+	}
+	line := content[2:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return "", false
+	}
+	interp := filepath.Base(fields[0])
+	if interp == "env" {
+		interp = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interp = filepath.Base(f)
+				break
+			}
+		}
+	}
+	// python3.12 -> python
+	interp = strings.TrimRight(interp, "0123456789.")
+	style, ok := shebangGuardStyles[interp]
+	return style, ok
+}
+
+// directiveLine matches lines that must stay ahead of any comment: XML
+// declarations and processing instructions, doctypes, PHP open tags and
+// declare(), Python coding cookies, YAML directives, CSS @charset and
+// Dockerfile parser directives.
+var directiveLine = regexp.MustCompile(`^(?:<\?xml|(?i:<!doctype)|<\?php|declare\s*\(|#.*coding[:=]|%YAML |%TAG |@charset |#\s*(?i:syntax|escape|check)=)`)
+
+// guardOffset returns the byte offset of content where the guard line goes.
+func guardOffset(content []byte, pos string) int {
+	switch pos {
+	case GuardTop:
+		return 0
+	case GuardBottom:
+		return len(content)
+	}
+	off := 0
+	for off < len(content) {
+		end := bytes.IndexByte(content[off:], '\n')
+		if end == -1 {
+			end = len(content)
+		} else {
+			end += off + 1
+		}
+		line := content[off:end]
+		shebang := off == 0 && isShebang(line)
+		if !shebang && (pos != GuardAfterDirectives || !directiveLine.Match(line)) {
+			return off
+		}
+		off = end
+	}
+	return off
+}
+
+// injectGuardStyle inserts the guard formatted with style into content at
+// pos. A UTF-8 BOM stays in front.
+func injectGuardStyle(content []byte, style, guard, pos string) []byte {
+	if style == GuardStyleNone {
+		return content
+	}
+	bom := bytes.HasPrefix(content, bomUTF8)
+	content = bytes.TrimPrefix(content, bomUTF8)
+	line := strings.Replace(style, "%s", guard, 1) + "\n"
+
+	off := guardOffset(content, pos)
+	out := make([]byte, 0, len(bomUTF8)+len(content)+len(line)+1)
+	if bom {
+		out = append(out, bomUTF8...)
+	}
+	out = append(out, content[:off]...)
+	if off > 0 && content[off-1] != '\n' {
+		out = append(out, '\n')
+	}
+	out = append(out, line...)
+	return append(out, content[off:]...)
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/kanopi/templr/pkg/templr"
+	"gopkg.in/yaml.v3"
+)
+
+// helmChartKey is the values key whose map becomes the Chart.yaml of
+// --as-helm-chart.
+const helmChartKey = "chart"
+
+// helmChartFields are the Chart.yaml fields written first, in this order;
+// other fields of the chart values follow sorted by name.
+var helmChartFields = []string{"apiVersion", "name", "description", "type", "version", "appVersion"}
+
+// helmChartName matches the names Helm accepts for a chart.
+var helmChartName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// checkHelmChartOptions validates --as-helm-chart before rendering.
+func checkHelmChartOptions(opts WalkOptions) error {
+	if opts.HelmChart == "" {
+		return nil
+	}
+	if opts.Dst != "" || opts.DstArchive != "" {
+		return argsError(fmt.Errorf("--as-helm-chart cannot be used with --dst or --dst-archive"))
+	}
+	if opts.Provenance != "" {
+		return argsError(fmt.Errorf("--provenance cannot be used with --as-helm-chart"))
+	}
+	return nil
+}
+
+// helmChart writes the outputs of a walk as the templates of a Helm chart in
+// dir and generates its Chart.yaml from the chart values when the walk
+// completes. Actions left in the outputs are escaped, so Helm prints them
+// as they are instead of executing them.
+type helmChart struct {
+	dir    string
+	chart  []byte
+	shared SharedOptions
+}
+
+// newHelmChart prepares the chart in dir; its Chart.yaml is built before
+// anything is written, so bad chart metadata fails the walk early.
+func newHelmChart(dir string, values map[string]any, shared SharedOptions) (*helmChart, error) {
+	chart, err := helmChartYAML(filepath.Base(filepath.Clean(dir)), values[helmChartKey])
+	if err != nil {
+		return nil, exitError(ExitDataError, "data", fmt.Errorf("--as-helm-chart: %w", err))
+	}
+	return &helmChart{dir: dir, chart: chart, shared: shared}, nil
+}
+
+// label names an output by its path in the chart: "mychart/templates/app.yaml".
+func (h *helmChart) label(relOut string) string {
+	return filepath.Join(h.dir, "templates", relOut)
+}
+
+// writeOutput writes one rendered template under templates/ the way a walk
+// into a directory does, guards included.
+func (h *helmChart) writeOutput(w messageWriter, name, relOut string, outBytes []byte, keep bool, shared SharedOptions) (string, error) {
+	dstPath := h.label(relOut)
+	if isEmpty(outBytes) && keep {
+		return writeEmptyOutput(w, name, dstPath, shared)
+	}
+	return writeOutput(w, name, dstPath, escapeHelmActions(outBytes), shared)
+}
+
+// commit writes Chart.yaml, guarded like the templates, and removes
+// directories left empty.
+func (h *helmChart) commit() error {
+	if _, err := writeOutput(sink, "Chart.yaml", filepath.Join(h.dir, "Chart.yaml"), h.chart, h.shared); err != nil {
+		return err
+	}
+	if h.shared.DryRun {
+		return nil
+	}
+	if err := templr.PruneEmptyDirs(h.dir); err != nil {
+		return fmt.Errorf("prune: %w", err)
+	}
+	return nil
+}
+
+// discard does nothing: outputs already written stay, as in --dst.
+func (h *helmChart) discard() {}
+
+// escapeHelmActions rewrites every "{{" of a rendered output as an action
+// printing it, so Helm outputs the text unchanged.
+func escapeHelmActions(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte("{{"), []byte(`{{ "{{" }}`))
+}
+
+// helmChartYAML returns the Chart.yaml of the chart named name: the fields
+// of the chart values over the apiVersion v2, version 0.1.0 application
+// chart Helm scaffolds.
+func helmChartYAML(name string, meta any) ([]byte, error) {
+	fields := map[string]any{"apiVersion": "v2", "name": name, "type": "application", "version": "0.1.0"}
+	switch m := meta.(type) {
+	case nil:
+	case map[string]any:
+		for k, v := range m {
+			fields[k] = v
+		}
+		// Chart.yaml versions are strings, even where the values have numbers
+		for _, k := range []string{"version", "appVersion"} {
+			if v, ok := fields[k]; ok {
+				fields[k] = fmt.Sprint(v)
+			}
+		}
+	default:
+		return nil, fmt.Errorf(".%s must be a map of Chart.yaml fields, got %T", helmChartKey, meta)
+	}
+	if n, _ := fields["name"].(string); !helmChartName.MatchString(n) {
+		return nil, fmt.Errorf("chart name %v: want lowercase letters, digits and dashes", fields["name"])
+	}
+	if _, err := semver.StrictNewVersion(fields["version"].(string)); err != nil {
+		return nil, fmt.Errorf("chart version %v: not a semantic version", fields["version"])
+	}
+
+	keys := append([]string(nil), helmChartFields...)
+	var rest []string
+	for k := range fields {
+		if !slices.Contains(helmChartFields, k) {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, k := range append(keys, rest...) {
+		v, ok := fields[k]
+		if !ok {
+			continue
+		}
+		var val yaml.Node
+		if err := val.Encode(v); err != nil {
+			return nil, fmt.Errorf("chart %s: %w", k, err)
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: k}, &val)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
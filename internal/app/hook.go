@@ -0,0 +1,217 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HookOptions contains options for `templr hook install`
+type HookOptions struct {
+	Shared    SharedOptions
+	PreCommit bool   // write a local hook into .pre-commit-config.yaml
+	Lefthook  bool   // write a command into lefthook.yml
+	Force     bool   // overwrite an existing hook or config file
+	Verify    string // provenance statement the hook also verifies; none when empty
+}
+
+// RunHookInstall installs a pre-commit hook that lints staged templates and
+// values files and, with --verify, checks the generated files against a
+// provenance statement. Without --pre-commit or --lefthook a plain git hook
+// is written.
+func RunHookInstall(opts HookOptions) error {
+	if opts.PreCommit && opts.Lefthook {
+		return fmt.Errorf("--pre-commit and --lefthook are mutually exclusive")
+	}
+
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return fmt.Errorf("hook install must run inside a git repository: %w", err)
+	}
+	root = strings.TrimSpace(root)
+
+	command := hookCommand(opts.Shared.ExtraExts)
+	verify, err := hookVerifyCommand(root, opts.Verify)
+	if err != nil {
+		return err
+	}
+	switch {
+	case opts.PreCommit:
+		return writeHookConfig(filepath.Join(root, ".pre-commit-config.yaml"), preCommitConfig(command, verify, opts.Shared.ExtraExts), verify != "", opts.Force)
+	case opts.Lefthook:
+		return writeHookConfig(filepath.Join(root, "lefthook.yml"), lefthookConfig(command, verify, opts.Shared.ExtraExts), verify != "", opts.Force)
+	}
+
+	hooksDir, err := gitOutput("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return fmt.Errorf("locate git hooks: %w", err)
+	}
+	hooksDir = strings.TrimSpace(hooksDir)
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(root, hooksDir)
+	}
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		return fmt.Errorf("mkdir %s: %w", hooksDir, err)
+	}
+	path := filepath.Join(hooksDir, "pre-commit")
+	if b, err := os.ReadFile(path); err == nil && !opts.Force && !bytes.Contains(b, []byte(opts.Shared.Guard)) {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+	script := fmt.Sprintf("#!/bin/sh\n# %s\nexec %s\n", opts.Shared.Guard, command)
+	if verify != "" {
+		script = fmt.Sprintf("#!/bin/sh\n# %s\n%s || exit $?\nexec %s\n", opts.Shared.Guard, command, verify)
+	}
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil { //nolint:gosec // git hooks must be executable
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	fmt.Printf("installed git hook -> %s\n", path)
+	return nil
+}
+
+// hookCommand returns the lint invocation run by installed hooks.
+func hookCommand(extraExts []string) string {
+	cmd := "templr lint --staged"
+	for _, e := range extraExts {
+		cmd += " --ext " + strings.TrimPrefix(e, ".")
+	}
+	return cmd
+}
+
+// hookVerifyCommand returns the verify invocation run by installed hooks for
+// the provenance statement at path, relative to the repository root where
+// hooks run, or "" when there is none.
+func hookVerifyCommand(root, path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("--verify %s: the provenance statement must be inside the repository", path)
+	}
+	return "templr verify --provenance " + hookQuote(filepath.ToSlash(rel)), nil
+}
+
+// hookQuote quotes s for the shell (and pre-commit's shlex) unless it is a
+// plain path.
+func hookQuote(s string) string {
+	if strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789._-/") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// hookExts returns the sorted file extensions (without dots) that should
+// trigger the hook: template extensions plus values files.
+func hookExts(extraExts []string) []string {
+	var exts []string
+	for e := range buildAllowedExts(extraExts) {
+		exts = append(exts, strings.TrimPrefix(e, "."))
+	}
+	exts = append(exts, "yaml", "yml", "json")
+	sort.Strings(exts)
+	return exts
+}
+
+// preCommitConfig and lefthookConfig run verify on every commit: an output
+// can change without any template or values file being staged.
+func preCommitConfig(command, verify string, extraExts []string) string {
+	config := fmt.Sprintf(`repos:
+  - repo: local
+    hooks:
+      - id: templr-lint
+        name: templr lint
+        entry: %s
+        language: system
+        files: \.(%s)$
+        pass_filenames: false
+`, command, strings.Join(hookExts(extraExts), "|"))
+	if verify != "" {
+		config += fmt.Sprintf(`      - id: templr-verify
+        name: templr verify
+        entry: %s
+        language: system
+        always_run: true
+        pass_filenames: false
+`, verify)
+	}
+	return config
+}
+
+func lefthookConfig(command, verify string, extraExts []string) string {
+	config := fmt.Sprintf(`pre-commit:
+  commands:
+    templr-lint:
+      glob: "*.{%s}"
+      run: %s
+`, strings.Join(hookExts(extraExts), ","), command)
+	if verify != "" {
+		config += fmt.Sprintf(`    templr-verify:
+      run: %s
+`, verify)
+	}
+	return config
+}
+
+// writeHookConfig writes a hook manager config. Existing files are left alone
+// unless force is set; the snippet to merge is printed instead, unless the
+// file already runs lint (and verify, when withVerify is set).
+func writeHookConfig(path, content string, withVerify, force bool) error {
+	if b, err := os.ReadFile(path); err == nil && !force {
+		if bytes.Contains(b, []byte("templr lint")) && (!withVerify || bytes.Contains(b, []byte("templr verify"))) {
+			fmt.Printf("templr hook already configured in %s\n", path)
+			return nil
+		}
+		fmt.Printf("%s already exists; add the following to it (or re-run with --force):\n\n%s", path, content)
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	fmt.Printf("wrote hook config -> %s\n", path)
+	return nil
+}
+
+// stagedFiles returns the absolute paths of files added, copied, modified or
+// renamed in the git index.
+func stagedFiles() ([]string, error) {
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("--staged requires a git repository: %w", err)
+	}
+	root = strings.TrimSpace(root)
+	out, err := gitOutput("diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("list staged files: %w", err)
+	}
+	var files []string
+	for _, name := range strings.Split(out, "\x00") {
+		if name == "" {
+			continue
+		}
+		files = append(files, filepath.Join(root, filepath.FromSlash(name)))
+	}
+	return files, nil
+}
+
+// gitOutput runs git with args and returns its stdout.
+func gitOutput(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
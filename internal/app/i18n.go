@@ -0,0 +1,147 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// langEnv selects the language of CLI messages when --lang is not given.
+const langEnv = "TEMPLR_LANG"
+
+// lang is the language of CLI messages; English needs no catalog.
+var lang = "en"
+
+// SetLang sets the language of CLI messages: the error prefix, the strict
+// mode report and its tips, and lint text output. An empty l falls back to
+// TEMPLR_LANG, then English. Region suffixes are ignored ("de_DE" is "de").
+func SetLang(l string) error {
+	from := "--lang"
+	if l == "" {
+		l, from = os.Getenv(langEnv), langEnv
+	}
+	if l == "" {
+		lang = "en"
+		return nil
+	}
+	base := strings.ToLower(l)
+	if i := strings.IndexAny(base, "_-."); i >= 0 {
+		base = base[:i]
+	}
+	if base != "en" && catalogs[base] == nil {
+		return fmt.Errorf("invalid %s %q (want en, de, es or ja)", from, l)
+	}
+	lang = base
+	return nil
+}
+
+// tr returns the translation of the English message s, or s.
+func tr(s string) string {
+	if t, ok := catalogs[lang][s]; ok {
+		return t
+	}
+	return s
+}
+
+// trf formats the translation of the English format.
+func trf(format string, a ...any) string {
+	return fmt.Sprintf(tr(format), a...)
+}
+
+// localize translates msg, a message formatted elsewhere (e.g. by pkg/lint),
+// by matching it against the formats of the catalog and formatting the
+// translation with the arguments it was formatted with.
+func localize(msg string) string {
+	if lang == "en" {
+		return msg
+	}
+	if t, ok := catalogs[lang][msg]; ok {
+		return t
+	}
+	for _, p := range catalogPatterns() {
+		m := p.re.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		args := make([]any, len(p.verbs))
+		for i, verb := range p.verbs {
+			args[i] = m[i+1]
+			switch verb {
+			case 'd':
+				if n, err := strconv.Atoi(m[i+1]); err == nil {
+					args[i] = n
+				}
+			case 'q':
+				if s, err := strconv.Unquote(m[i+1]); err == nil {
+					args[i] = s
+				}
+			}
+		}
+		return fmt.Sprintf(catalogs[lang][p.format], args...)
+	}
+	return msg
+}
+
+// catalogPattern matches the messages formatted with format.
+type catalogPattern struct {
+	format string
+	re     *regexp.Regexp
+	verbs  []byte // the verb of each argument, in order
+}
+
+var (
+	patternsOnce sync.Once
+	patterns     []catalogPattern
+)
+
+// catalogPatterns returns the patterns of the catalog formats with
+// arguments, longest format first so that the most specific one matches.
+func catalogPatterns() []catalogPattern {
+	patternsOnce.Do(func() {
+		seen := map[string]bool{}
+		for _, c := range catalogs {
+			for format := range c {
+				if seen[format] || !strings.Contains(format, "%") {
+					continue
+				}
+				seen[format] = true
+				p := catalogPattern{format: format}
+				var re strings.Builder
+				re.WriteString("^")
+				rest := format
+				for {
+					i := strings.IndexByte(rest, '%')
+					if i < 0 || i+1 >= len(rest) {
+						re.WriteString(regexp.QuoteMeta(rest))
+						break
+					}
+					re.WriteString(regexp.QuoteMeta(rest[:i]))
+					switch rest[i+1] {
+					case 'd':
+						re.WriteString(`(-?\d+)`)
+					case 'q':
+						re.WriteString(`("(?:[^"\\]|\\.)*")`)
+					default:
+						re.WriteString(`(.+?)`)
+					}
+					p.verbs = append(p.verbs, rest[i+1])
+					rest = rest[i+2:]
+				}
+				re.WriteString("$")
+				p.re = regexp.MustCompile(re.String())
+				patterns = append(patterns, p)
+			}
+		}
+		sort.Slice(patterns, func(i, j int) bool {
+			if len(patterns[i].format) != len(patterns[j].format) {
+				return len(patterns[i].format) > len(patterns[j].format)
+			}
+			return patterns[i].format < patterns[j].format
+		})
+	})
+	return patterns
+}
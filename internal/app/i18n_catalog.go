@@ -0,0 +1,109 @@
+package app
+
+// catalogs translate CLI messages, keyed by language and then by the English
+// message or fmt format. A translation keeps the verbs of its format, in
+// order or with explicit argument indexes (%[2]s).
+var catalogs = map[string]map[string]string{
+	"de": {
+		"Error: ":               "Fehler: ",
+		"✗ Strict Mode Error":   "✗ Fehler im Strict-Modus",
+		"Missing: ":             "Fehlt: ",
+		"Key: ":                 "Schlüssel: ",
+		"Details: ":             "Details: ",
+		"Tip: ":                 "Tipp: ",
+		"^ Error occurred here": "^ Hier ist der Fehler aufgetreten",
+		"✓ No issues found":     "✓ Keine Probleme gefunden",
+		"✗ Found %d error(s)":   "✗ %d Fehler gefunden",
+		"⚠ Found %d warning(s)": "⚠ %d Warnung(en) gefunden",
+
+		"Define '%s' in your values file, or run without --strict to use defaults.":                                          "Definieren Sie '%s' in Ihrer Values-Datei, oder verwenden Sie ohne --strict die Standardwerte.",
+		"Run with --values-namespace, or set template.values_namespace, to read the values under .Values as Helm charts do.": "Führen Sie templr mit --values-namespace aus oder setzen Sie template.values_namespace, um die Values wie in Helm-Charts unter .Values zu lesen.",
+		"Check your values file to ensure all required keys are defined, or run without --strict.":                           "Prüfen Sie, ob Ihre Values-Datei alle benötigten Schlüssel definiert, oder führen Sie templr ohne --strict aus.",
+		"%q is not a template function; run `templr funcs` to list the available ones.":                                      "%q ist keine Template-Funktion; `templr funcs` listet die verfügbaren Funktionen auf.",
+		"No template named %q is loaded; declare it with {{ define %q }} in a helper or partial.":                            "Es ist kein Template namens %q geladen; deklarieren Sie es mit {{ define %q }} in einem Helper oder Partial.",
+		"A block ({{ if }}, {{ range }}, {{ with }} or {{ define }}) is missing its {{ end }}.":                              "Einem Block ({{ if }}, {{ range }}, {{ with }} oder {{ define }}) fehlt sein {{ end }}.",
+		"An action or string is not closed; check the delimiters and quotes on this line.":                                   "Eine Aktion oder Zeichenkette ist nicht geschlossen; prüfen Sie die Begrenzer und Anführungszeichen in dieser Zeile.",
+		"%s has no matching {{ if }}, {{ range }} or {{ with }}.":                                                            "Zu %s gibt es kein passendes {{ if }}, {{ range }} oder {{ with }}.",
+		"A value in this expression is missing; guard it with {{ with }} or provide a fallback with `default`.":              "Ein Wert in diesem Ausdruck fehlt; schützen Sie ihn mit {{ with }} oder geben Sie mit `default` einen Ersatzwert an.",
+		"Field %q was looked up on a value that is not a map; check the structure of your values.":                           "Das Feld %q wurde in einem Wert gesucht, der keine Map ist; prüfen Sie die Struktur Ihrer Values.",
+		"Use an approved helper such as sha256sum, or run `templr lint --crypto-policy fips` to find every such call.":       "Verwenden Sie eine zugelassene Funktion wie sha256sum, oder finden Sie alle solchen Aufrufe mit `templr lint --crypto-policy fips`.",
+		"Check the arguments passed to %s.": "Prüfen Sie die an %s übergebenen Argumente.",
+
+		"variable %s is undefined":                    "Variable %s ist nicht definiert",
+		"disallowed function %q is used":              "nicht erlaubte Funktion %q wird verwendet",
+		"required variable %s is not defined":         "erforderliche Variable %s ist nicht definiert",
+		"duplicate key %q, first defined on line %d":  "doppelter Schlüssel %q, zuerst in Zeile %d definiert",
+		"tab in indentation; YAML only allows spaces": "Tabulator in der Einrückung; YAML erlaubt nur Leerzeichen",
+		"unquoted %s is a string here but a boolean to YAML 1.1 tools such as Helm and Ansible; quote it or use true/false": "%s ohne Anführungszeichen ist hier eine Zeichenkette, für YAML-1.1-Werkzeuge wie Helm und Ansible aber ein Boolean; setzen Sie es in Anführungszeichen oder verwenden Sie true/false",
+		"%s is read as a number and loses its zeros; quote it to keep it as written":                                        "%s wird als Zahl gelesen und verliert seine Nullen; setzen Sie es in Anführungszeichen, um es unverändert zu behalten",
+		"templr:lint-disable %s suppresses nothing; remove it":                                                              "templr:lint-disable %s unterdrückt nichts; entfernen Sie es",
+	},
+	"es": {
+		"Error: ":               "Error: ",
+		"✗ Strict Mode Error":   "✗ Error del modo estricto",
+		"Missing: ":             "Falta: ",
+		"Key: ":                 "Clave: ",
+		"Details: ":             "Detalles: ",
+		"Tip: ":                 "Consejo: ",
+		"^ Error occurred here": "^ El error ocurrió aquí",
+		"✓ No issues found":     "✓ No se encontraron problemas",
+		"✗ Found %d error(s)":   "✗ Se encontraron %d error(es)",
+		"⚠ Found %d warning(s)": "⚠ Se encontraron %d advertencia(s)",
+
+		"Define '%s' in your values file, or run without --strict to use defaults.":                                          "Defina '%s' en su archivo de valores, o ejecute sin --strict para usar los valores predeterminados.",
+		"Run with --values-namespace, or set template.values_namespace, to read the values under .Values as Helm charts do.": "Ejecute con --values-namespace, o defina template.values_namespace, para leer los valores bajo .Values como hacen los charts de Helm.",
+		"Check your values file to ensure all required keys are defined, or run without --strict.":                           "Compruebe que su archivo de valores define todas las claves necesarias, o ejecute sin --strict.",
+		"%q is not a template function; run `templr funcs` to list the available ones.":                                      "%q no es una función de plantilla; ejecute `templr funcs` para ver las disponibles.",
+		"No template named %q is loaded; declare it with {{ define %q }} in a helper or partial.":                            "No hay ninguna plantilla llamada %q cargada; declárela con {{ define %q }} en un helper o partial.",
+		"A block ({{ if }}, {{ range }}, {{ with }} or {{ define }}) is missing its {{ end }}.":                              "A un bloque ({{ if }}, {{ range }}, {{ with }} o {{ define }}) le falta su {{ end }}.",
+		"An action or string is not closed; check the delimiters and quotes on this line.":                                   "Una acción o cadena no está cerrada; revise los delimitadores y las comillas de esta línea.",
+		"%s has no matching {{ if }}, {{ range }} or {{ with }}.":                                                            "%s no tiene un {{ if }}, {{ range }} o {{ with }} correspondiente.",
+		"A value in this expression is missing; guard it with {{ with }} or provide a fallback with `default`.":              "Falta un valor en esta expresión; protéjala con {{ with }} o indique un valor alternativo con `default`.",
+		"Field %q was looked up on a value that is not a map; check the structure of your values.":                           "Se buscó el campo %q en un valor que no es un mapa; revise la estructura de sus valores.",
+		"Use an approved helper such as sha256sum, or run `templr lint --crypto-policy fips` to find every such call.":       "Use una función aprobada como sha256sum, o ejecute `templr lint --crypto-policy fips` para encontrar todas esas llamadas.",
+		"Check the arguments passed to %s.": "Revise los argumentos pasados a %s.",
+
+		"variable %s is undefined":                    "la variable %s no está definida",
+		"disallowed function %q is used":              "se usa la función no permitida %q",
+		"required variable %s is not defined":         "la variable obligatoria %s no está definida",
+		"duplicate key %q, first defined on line %d":  "clave %q duplicada, definida por primera vez en la línea %d",
+		"tab in indentation; YAML only allows spaces": "tabulador en la sangría; YAML solo admite espacios",
+		"unquoted %s is a string here but a boolean to YAML 1.1 tools such as Helm and Ansible; quote it or use true/false": "%s sin comillas es aquí una cadena, pero un booleano para herramientas de YAML 1.1 como Helm y Ansible; póngalo entre comillas o use true/false",
+		"%s is read as a number and loses its zeros; quote it to keep it as written":                                        "%s se lee como un número y pierde sus ceros; póngalo entre comillas para conservarlo tal cual",
+		"templr:lint-disable %s suppresses nothing; remove it":                                                              "templr:lint-disable %s no suprime nada; elimínelo",
+	},
+	"ja": {
+		"Error: ":               "エラー: ",
+		"✗ Strict Mode Error":   "✗ 厳格モードのエラー",
+		"Missing: ":             "未定義: ",
+		"Key: ":                 "キー: ",
+		"Details: ":             "詳細: ",
+		"Tip: ":                 "ヒント: ",
+		"^ Error occurred here": "^ ここでエラーが発生しました",
+		"✓ No issues found":     "✓ 問題は見つかりませんでした",
+		"✗ Found %d error(s)":   "✗ %d 件のエラーが見つかりました",
+		"⚠ Found %d warning(s)": "⚠ %d 件の警告が見つかりました",
+
+		"Define '%s' in your values file, or run without --strict to use defaults.":                                          "values ファイルで '%s' を定義するか、--strict なしで実行してデフォルト値を使用してください。",
+		"Run with --values-namespace, or set template.values_namespace, to read the values under .Values as Helm charts do.": "Helm チャートのように .Values から値を読むには、--values-namespace を付けて実行するか template.values_namespace を設定してください。",
+		"Check your values file to ensure all required keys are defined, or run without --strict.":                           "必要なキーがすべて values ファイルに定義されているか確認するか、--strict なしで実行してください。",
+		"%q is not a template function; run `templr funcs` to list the available ones.":                                      "%q はテンプレート関数ではありません。`templr funcs` で利用できる関数を一覧表示できます。",
+		"No template named %q is loaded; declare it with {{ define %q }} in a helper or partial.":                            "%q という名前のテンプレートは読み込まれていません。ヘルパーまたはパーシャルで {{ define %q }} として宣言してください。",
+		"A block ({{ if }}, {{ range }}, {{ with }} or {{ define }}) is missing its {{ end }}.":                              "ブロック ({{ if }}、{{ range }}、{{ with }}、{{ define }}) に {{ end }} がありません。",
+		"An action or string is not closed; check the delimiters and quotes on this line.":                                   "アクションまたは文字列が閉じられていません。この行の区切り文字と引用符を確認してください。",
+		"%s has no matching {{ if }}, {{ range }} or {{ with }}.":                                                            "%s に対応する {{ if }}、{{ range }}、{{ with }} がありません。",
+		"A value in this expression is missing; guard it with {{ with }} or provide a fallback with `default`.":              "この式の値がありません。{{ with }} で囲むか、`default` で代わりの値を指定してください。",
+		"Field %q was looked up on a value that is not a map; check the structure of your values.":                           "マップではない値からフィールド %q を参照しました。values の構造を確認してください。",
+		"Use an approved helper such as sha256sum, or run `templr lint --crypto-policy fips` to find every such call.":       "sha256sum などの承認済みの関数を使うか、`templr lint --crypto-policy fips` でそのような呼び出しをすべて見つけてください。",
+		"Check the arguments passed to %s.": "%s に渡した引数を確認してください。",
+
+		"variable %s is undefined":                    "変数 %s は定義されていません",
+		"disallowed function %q is used":              "許可されていない関数 %q が使われています",
+		"required variable %s is not defined":         "必須の変数 %s が定義されていません",
+		"duplicate key %q, first defined on line %d":  "キー %q が重複しています (最初の定義は %d 行目)",
+		"tab in indentation; YAML only allows spaces": "インデントにタブがあります。YAML ではスペースしか使えません",
+		"unquoted %s is a string here but a boolean to YAML 1.1 tools such as Helm and Ansible; quote it or use true/false": "引用符のない %s はここでは文字列ですが、Helm や Ansible などの YAML 1.1 ツールではブール値です。引用符で囲むか true/false を使ってください",
+		"%s is read as a number and loses its zeros; quote it to keep it as written":                                        "%s は数値として読み込まれ、ゼロが失われます。書いたとおりに残すには引用符で囲んでください",
+		"templr:lint-disable %s suppresses nothing; remove it":                                                              "templr:lint-disable %s は何も抑制していません。削除してください",
+	},
+}
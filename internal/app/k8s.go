@@ -0,0 +1,255 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// K8sChecksumAnnotation carries the SHA-256 of a generated ConfigMap or
+// Secret, for the usual pattern of copying it into the pod template of a
+// Deployment so that pods restart when the configuration changes.
+const K8sChecksumAnnotation = "checksum/config"
+
+// k8sMaxObjectSize is the size limit etcd puts on a ConfigMap or Secret.
+const k8sMaxObjectSize = 1 << 20
+
+var (
+	k8sKeyRe  = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+	k8sNameRe = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+)
+
+// K8sOptions contains options for `templr k8s apply`
+type K8sOptions struct {
+	Walk       WalkOptions // Src, Shared and the output path options; Dst is unused
+	ConfigMap  string      // name of the ConfigMap to generate
+	Secret     string      // name of the Secret to generate
+	Namespace  string
+	Labels     []string // extra labels, "key=value"
+	Out        string   // write the manifest to this file (default: stdout)
+	Apply      bool     // pipe the manifest to kubectl apply
+	Kubeconfig string   // --kubeconfig passed to kubectl
+	Context    string   // --context passed to kubectl
+}
+
+// k8sManifest is a ConfigMap or Secret manifest.
+type k8sManifest struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty"`
+	BinaryData map[string]string `yaml:"binaryData,omitempty"`
+}
+
+type k8sMetadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// k8sTree collects the outputs of a walk as the keys of a ConfigMap or
+// Secret. An output path becomes a key with "/" replaced by "_".
+type k8sTree struct {
+	files   map[string][]byte // key -> content
+	sources map[string]string // key -> output path, to report collisions
+}
+
+func newK8sTree() *k8sTree {
+	return &k8sTree{files: map[string][]byte{}, sources: map[string]string{}}
+}
+
+// k8sKey returns the ConfigMap key of an output path.
+func k8sKey(relOut string) string {
+	return strings.ReplaceAll(filepath.ToSlash(relOut), "/", "_")
+}
+
+func (t *k8sTree) label(relOut string) string { return k8sKey(relOut) }
+
+// writeOutput adds one rendered template as a key. No guard is injected:
+// the keys are not files that a later walk could overwrite.
+func (t *k8sTree) writeOutput(_ messageWriter, name, relOut string, outBytes []byte, keep bool, shared SharedOptions) (string, error) {
+	if isEmpty(outBytes) && !keep {
+		return "skipped (empty)", nil
+	}
+	key := k8sKey(relOut)
+	if !k8sKeyRe.MatchString(key) {
+		return "", exitError(ExitTemplateError, "k8s", fmt.Errorf("%s: %q is not a valid ConfigMap key (letters, digits, '-', '_' and '.')", name, key))
+	}
+	if prev, ok := t.sources[key]; ok {
+		return "", exitError(ExitTemplateError, "k8s", fmt.Errorf("%s and %s both map to key %q; use --rename to tell them apart", prev, filepath.ToSlash(relOut), key))
+	}
+	status := "rendered"
+	if isEmpty(outBytes) {
+		outBytes, status = []byte{}, "rendered (empty)"
+	} else {
+		var err error
+		if outBytes, err = encodeOutput(relOut, outBytes, shared); err != nil {
+			return "", fmt.Errorf("encode %s: %w", key, err)
+		}
+	}
+	t.files[key] = outBytes
+	t.sources[key] = filepath.ToSlash(relOut)
+	return status, nil
+}
+
+// commit and discard are no-ops: RunK8sApply writes the manifest.
+func (t *k8sTree) commit() error { return nil }
+func (t *k8sTree) discard()      {}
+
+// manifest builds the ConfigMap or Secret holding the collected files.
+func (t *k8sTree) manifest(opts K8sOptions) (*k8sManifest, error) {
+	m := &k8sManifest{APIVersion: "v1"}
+	m.Metadata.Namespace = opts.Namespace
+	m.Metadata.Labels = map[string]string{"app.kubernetes.io/managed-by": "templr"}
+	for _, l := range opts.Labels {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok || k == "" {
+			return nil, argsError(fmt.Errorf("invalid --label %q: want key=value", l))
+		}
+		m.Metadata.Labels[k] = v
+	}
+
+	keys := make([]string, 0, len(t.files))
+	for k := range t.files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sum := sha256.New()
+	size := 0
+	for _, k := range keys {
+		v := t.files[k]
+		fmt.Fprintf(sum, "%s\x00%d\x00", k, len(v))
+		sum.Write(v)
+		size += len(k) + len(v)
+	}
+	if size > k8sMaxObjectSize {
+		return nil, exitError(ExitTemplateError, "k8s", fmt.Errorf("generated data is %d bytes, over the 1MiB limit of a ConfigMap or Secret", size))
+	}
+	m.Metadata.Annotations = map[string]string{K8sChecksumAnnotation: hex.EncodeToString(sum.Sum(nil))}
+
+	if opts.Secret != "" {
+		m.Kind, m.Type, m.Metadata.Name = "Secret", "Opaque", opts.Secret
+		m.Data = map[string]string{}
+		for _, k := range keys {
+			m.Data[k] = base64.StdEncoding.EncodeToString(t.files[k])
+		}
+		return m, nil
+	}
+	m.Kind, m.Metadata.Name = "ConfigMap", opts.ConfigMap
+	for _, k := range keys {
+		v := t.files[k]
+		if utf8.Valid(v) {
+			if m.Data == nil {
+				m.Data = map[string]string{}
+			}
+			m.Data[k] = string(v)
+			continue
+		}
+		if m.BinaryData == nil {
+			m.BinaryData = map[string]string{}
+		}
+		m.BinaryData[k] = base64.StdEncoding.EncodeToString(v)
+	}
+	return m, nil
+}
+
+// checkK8sOptions validates the options before rendering.
+func checkK8sOptions(opts K8sOptions) error {
+	name := opts.ConfigMap
+	switch {
+	case opts.ConfigMap == "" && opts.Secret == "":
+		return argsError(fmt.Errorf("k8s apply requires --configmap or --secret"))
+	case opts.ConfigMap != "" && opts.Secret != "":
+		return argsError(fmt.Errorf("--configmap and --secret are mutually exclusive"))
+	case opts.Secret != "":
+		name = opts.Secret
+	}
+	if len(name) > 253 || !k8sNameRe.MatchString(name) {
+		return argsError(fmt.Errorf("invalid name %q: want lowercase letters, digits, '-' and '.'", name))
+	}
+	if opts.Walk.Src == "" {
+		return argsError(fmt.Errorf("k8s apply requires --src"))
+	}
+	if opts.Apply {
+		if _, err := exec.LookPath("kubectl"); err != nil {
+			return argsError(fmt.Errorf("--apply needs kubectl in PATH"))
+		}
+	}
+	return nil
+}
+
+// RunK8sApply renders the templates of opts.Walk.Src into a ConfigMap or
+// Secret manifest, written to opts.Out (default stdout) or applied with
+// kubectl.
+func RunK8sApply(opts K8sOptions) error {
+	if err := checkK8sOptions(opts); err != nil {
+		return err
+	}
+	tree := newK8sTree()
+	walk := opts.Walk
+	walk.tree = tree
+	if err := RunWalkMode(walk); err != nil {
+		return err
+	}
+	m, err := tree.manifest(opts)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+
+	if opts.Apply {
+		return kubectlApply(buf.Bytes(), opts)
+	}
+	if opts.Out == "" || opts.Out == "-" {
+		_, err := sink.Stdout().Write(buf.Bytes())
+		return err
+	}
+	if opts.Walk.Shared.DryRun {
+		fmt.Fprintf(sink.Stdout(), "[dry-run] would write %s %s with %d key%s to %s\n", m.Kind, m.Metadata.Name, len(tree.files), pluralize(len(tree.files)), opts.Out)
+		return nil
+	}
+	if _, err := writeIfChanged(opts.Out, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", opts.Out, err)
+	}
+	return nil
+}
+
+// kubectlApply pipes the manifest to `kubectl apply -f -`; --dry-run asks
+// kubectl for a client-side dry run.
+func kubectlApply(manifest []byte, opts K8sOptions) error {
+	args := []string{"apply", "-f", "-"}
+	if opts.Kubeconfig != "" {
+		args = append(args, "--kubeconfig", opts.Kubeconfig)
+	}
+	if opts.Context != "" {
+		args = append(args, "--context", opts.Context)
+	}
+	if opts.Walk.Shared.DryRun {
+		args = append(args, "--dry-run=client")
+	}
+	cmd := exec.Command("kubectl", args...)
+	cmd.Stdin = bytes.NewReader(manifest)
+	cmd.Stdout = sink.Stdout()
+	cmd.Stderr = sink.Stderr()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("kubectl apply: %w", err)
+	}
+	return nil
+}
@@ -1,116 +1,129 @@
 package app
 
 import (
-	"flag"
+	"errors"
 	"fmt"
-	"os"
+	"strings"
 )
 
-// RunLegacyMode implements backward compatibility for the old flag-based CLI.
-// This function parses flags in the old style and routes to the appropriate command.
-func RunLegacyMode() {
-	// Reset flag state
-	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+// legacyValueFlags are the flags of the old flag-based CLI that take a value.
+var legacyValueFlags = map[string]bool{
+	"in": true, "out": true, "data": true, "f": true, "set": true,
+	"dir": true, "src": true, "dst": true, "ldelim": true, "rdelim": true,
+	"guard": true, "helpers": true, "ext": true, "default-missing": true,
+}
 
-	// Define all the old flags
-	in := flag.String("in", "", "Template file OR entry template name (if -dir is used). Omit for stdin (single-file).")
-	out := flag.String("out", "", "Output file path (omit for stdout)")
-	data := flag.String("data", "", "Path to base JSON or YAML data file")
-	var files stringSlice
-	flag.Var(&files, "f", "Additional values files (YAML/JSON). Repeatable.")
-	var sets stringSlice
-	flag.Var(&sets, "set", "key=value overrides. Repeatable. Supports dotted keys.")
+// legacyIgnored lists, per command, the legacy flags the old CLI accepted but
+// ignored in that mode; they are dropped instead of failing the command.
+var legacyIgnored = map[string]map[string]bool{
+	"render": {"src": true, "dst": true},
+	"dir":    {"src": true, "dst": true, "helpers": true},
+	"walk":   {"in": true, "out": true, "dir": true, "helpers": true},
+}
 
-	dir := flag.String("dir", "", "Directory containing *.tpl templates to parse together (multi-file mode)")
-	walk := flag.Bool("walk", false, "Render all *.tpl under -src into -dst, mirroring paths and stripping .tpl")
-	src := flag.String("src", "", "Templates root for -walk mode")
-	dst := flag.String("dst", "", "Output root for -walk mode")
+// legacyFlag is one flag of a legacy invocation with its value tokens.
+type legacyFlag struct {
+	name   string
+	tokens []string
+}
 
-	ldelim := flag.String("ldelim", "{{", "Left delimiter")
-	rdelim := flag.String("rdelim", "}}", "Right delimiter")
-	strict := flag.Bool("strict", false, "Fail on missing keys")
-	dryRun := flag.Bool("dry-run", false, "Preview which files would be rendered (no writes)")
-	guard := flag.String("guard", "#templr generated", "Guard string required in existing files to allow overwrite")
-	inject := flag.Bool("inject-guard", true, "Automatically insert the guard as a comment into written files (when supported)")
-	helpers := flag.String("helpers", "_helpers*.tpl", "Glob pattern of helper templates to load (single-file mode). Set empty to skip.")
-	var extraExts stringSlice
-	flag.Var(&extraExts, "ext", "Additional template file extensions to treat as templates (e.g., md, txt). Repeatable; do not include the leading dot.")
+// legacyNotice is the deprecation notice of a translated legacy invocation,
+// printed by PrintLegacyNotice once the command finished.
+var legacyNotice string
 
-	showVersion := flag.Bool("version", false, "Print version and exit")
-	defaultMissing := flag.String("default-missing", "<no value>", "String to render when a variable/key is missing (works with missingkey=default)")
-	noColor := flag.Bool("no-color", false, "Disable colored output (useful for CI/non-ANSI terminals)")
+// legacyCmd is the subcommand a legacy invocation was translated to.
+var legacyCmd string
 
-	flag.Parse()
+// TranslateLegacyArgs rewrites an invocation of the old flag-based CLI
+// (templr -walk -src a -dst b) as the equivalent subcommand invocation
+// (walk --src a --dst b) and queues a deprecation notice naming it. Flags of
+// the new CLI, such as --config, pass through unchanged. With --no-legacy it
+// returns an error naming the new invocation instead.
+func TranslateLegacyArgs(args []string) ([]string, error) {
+	var flags []legacyFlag
+	cmd := "render"
+	noLegacy := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") || arg == "-" {
+			break // like the old CLI, stop at the first non-flag argument
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := legacyFlag{name: name, tokens: []string{longFlag(arg)}}
+		if legacyValueFlags[name] && !hasValue && i+1 < len(args) {
+			i++
+			f.tokens = append(f.tokens, args[i])
+		}
+		switch {
+		case name == "walk":
+			if !hasValue || value != "false" {
+				cmd = "walk"
+			}
+			continue
+		case name == "dir" && cmd != "walk":
+			cmd = "dir"
+		case name == "no-legacy":
+			noLegacy = !hasValue || value != "false"
+		}
+		flags = append(flags, f)
+	}
 
-	if *showVersion {
-		fmt.Println(GetVersion())
-		return
+	out := []string{cmd}
+	for _, f := range flags {
+		if !legacyIgnored[cmd][f.name] {
+			out = append(out, f.tokens...)
+		}
 	}
 
-	// Build shared options
-	shared := SharedOptions{
-		Data:           *data,
-		Files:          files,
-		Sets:           sets,
-		Strict:         *strict,
-		DryRun:         *dryRun,
-		Guard:          *guard,
-		InjectGuard:    *inject,
-		DefaultMissing: *defaultMissing,
-		NoColor:        *noColor,
-		Ldelim:         *ldelim,
-		Rdelim:         *rdelim,
-		ExtraExts:      extraExts,
+	if noLegacy {
+		return nil, argsError(fmt.Errorf("legacy flag syntax is disabled by --no-legacy; use: templr %s", shellJoin(out)))
 	}
+	legacyCmd = cmd
+	legacyNotice = "legacy flag syntax is deprecated and will be removed; use: templr " + shellJoin(out)
+	return out, nil
+}
 
-	// Route to appropriate mode
-	var err error
+// PrintLegacyNotice prints the deprecation notice of a translated legacy
+// invocation, if any. It comes last so the command's own output and errors
+// keep their place on stderr.
+func PrintLegacyNotice() {
+	if legacyNotice != "" {
+		warnf("deprecated", "%s", legacyNotice)
+		legacyNotice = ""
+	}
+}
 
-	if *walk {
-		// Walk mode
-		opts := WalkOptions{
-			Shared: shared,
-			Src:    *src,
-			Dst:    *dst,
-		}
-		err = RunWalkMode(opts)
-	} else if *dir != "" {
-		// Dir mode
-		opts := DirOptions{
-			Shared: shared,
-			Dir:    *dir,
-			In:     *in,
-			Out:    *out,
-		}
-		err = RunDirMode(opts)
-	} else {
-		// Render mode (single-file)
-		opts := RenderOptions{
-			Shared:  shared,
-			In:      *in,
-			Out:     *out,
-			Helpers: *helpers,
-		}
-		err = RunRenderMode(opts)
+// ExitLegacyError reports err in the format of the old CLI,
+// [templr:error:<kind>] message, and exits with its exit code.
+func ExitLegacyError(err error, noColor bool) {
+	// cobra rejects missing required flags before walk runs; keep the old message
+	if legacyCmd == "walk" && strings.HasPrefix(err.Error(), "required flag") {
+		err = argsError(errors.New("-walk requires -src and -dst"))
 	}
+	fatalErr(ExitCode(err), ErrorKind(err), err, noColor)
+}
+
+// longFlag turns a single-dash long flag (-data, -dry-run=false) into its
+// double-dash form; one-letter flags such as -f are kept.
+func longFlag(arg string) string {
+	if strings.HasPrefix(arg, "--") {
+		return arg
+	}
+	name, _, _ := strings.Cut(arg[1:], "=")
+	if len(name) > 1 {
+		return "-" + arg
+	}
+	return arg
+}
 
-	if err != nil {
-		// Use legacy error formatting for backward compatibility
-		errMsg := err.Error()
-		if Contains(errMsg, "requires") || Contains(errMsg, "key=value") {
-			errf(ExitGeneral, "args", "%v", err)
-		} else if Contains(errMsg, "parse") {
-			errf(ExitTemplateError, "parse", "%v", err)
-		} else if Contains(errMsg, "render") || Contains(errMsg, "template") || Contains(errMsg, "executing") {
-			errf(ExitTemplateError, "render", "%v", err)
-		} else if Contains(errMsg, "load data") || Contains(errMsg, "data") {
-			errf(ExitDataError, "data", "%v", err)
-		} else if Contains(errMsg, "guard") {
-			errf(ExitGuardSkipped, "guard", "%v", err)
-		} else if Contains(errMsg, "helper") {
-			errf(ExitTemplateError, "helpers", "%v", err)
-		} else {
-			errf(ExitGeneral, "error", "%v", err)
+// shellJoin joins args for display, quoting those the shell would split.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if a == "" || strings.ContainsAny(a, " \t\n\"'$`\\*?[]{}()<>|&;#~") {
+			a = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
 		}
+		quoted[i] = a
 	}
+	return strings.Join(quoted, " ")
 }
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/dustin/go-humanize"
+)
+
+// DefaultMaxOutputSize is the per-file output ceiling when --max-output-size
+// is not set: far above any real config file, low enough to stop a runaway
+// loop before it fills the disk.
+const DefaultMaxOutputSize = "100MiB"
+
+// errOutputTooLarge stops a render that exceeds the output ceiling.
+var errOutputTooLarge = errors.New("output too large")
+
+// limitedBuffer is a render buffer that fails writes beyond max bytes
+// (no limit when max is 0).
+type limitedBuffer struct {
+	bytes.Buffer
+	max int64
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.max > 0 && int64(b.Len()+len(p)) > b.max {
+		return 0, errOutputTooLarge
+	}
+	return b.Buffer.Write(p)
+}
+
+// maxOutputSize parses --max-output-size ("100MiB", "512KB", "1048576");
+// 0 disables the limit.
+func maxOutputSize(shared SharedOptions) (int64, error) {
+	s := strings.TrimSpace(shared.MaxOutputSize)
+	if s == "" {
+		s = DefaultMaxOutputSize
+	}
+	n, err := humanize.ParseBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("want a size like 10MiB, 500KB or 0")
+	}
+	return int64(n), nil
+}
+
+// checkMaxOutputSize validates --max-output-size before rendering.
+func checkMaxOutputSize(shared SharedOptions) error {
+	if _, err := maxOutputSize(shared); err != nil {
+		return argsError(fmt.Errorf("invalid --max-output-size %q: %w", shared.MaxOutputSize, err))
+	}
+	return nil
+}
+
+// outputTooLargeError names the template that hit the limit and the range
+// loops in it, the usual cause of runaway output.
+func outputTooLargeError(tpl *template.Template, name string, shared SharedOptions) error {
+	t := tpl
+	if name != "" {
+		t = tpl.Lookup(name)
+	} else {
+		name = tpl.Name()
+	}
+	limit := shared.MaxOutputSize
+	if limit == "" {
+		limit = DefaultMaxOutputSize
+	}
+	return exitError(ExitTemplateError, "template", &outputSizeError{template: name, limit: limit, sites: rangeSites(t)})
+}
+
+// outputSizeError is errOutputTooLarge with the details of the render.
+type outputSizeError struct {
+	template string
+	limit    string
+	sites    []string // range actions of the template, "name:line:col {{range ...}}"
+}
+
+func (e *outputSizeError) Error() string {
+	msg := fmt.Sprintf("output of %s exceeds --max-output-size %s; render stopped", e.template, e.limit)
+	if len(e.sites) == 0 {
+		return msg + "; check for runaway recursion or repetition"
+	}
+	const maxSites = 3
+	sites := e.sites
+	if len(sites) > maxSites {
+		sites = append(sites[:maxSites:maxSites], fmt.Sprintf("%d more", len(sites)-maxSites))
+	}
+	return msg + "; check the loop bounds of " + strings.Join(sites, ", ")
+}
+
+func (e *outputSizeError) Is(target error) bool { return target == errOutputTooLarge }
+
+// relabel shows the template parsed as "root" (render mode) as label.
+func (e *outputSizeError) relabel(label string) {
+	if e.template != "root" {
+		return
+	}
+	e.template = label
+	for i, s := range e.sites {
+		if rest, ok := strings.CutPrefix(s, "root:"); ok {
+			e.sites[i] = label + ":" + rest
+		}
+	}
+}
+
+// rangeSites lists the range actions of a template as "file:line:col
+// {{range ...}}", outermost first.
+func rangeSites(t *template.Template) []string {
+	if t == nil || t.Tree == nil {
+		return nil
+	}
+	var sites []string
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.RangeNode:
+			loc, _ := t.Tree.ErrorContext(n)
+			sites = append(sites, fmt.Sprintf("%s {{range %s}}", loc, n.Pipe))
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.IfNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.List)
+			walk(n.ElseList)
+		}
+	}
+	walk(t.Tree.Root)
+	return sites
+}
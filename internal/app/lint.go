@@ -2,11 +2,15 @@ package app
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
+	"sort"
 	"text/template"
-	"text/template/parse"
+
+	"github.com/kanopi/templr/pkg/lint"
+	"github.com/kanopi/templr/pkg/templr"
 )
 
 // LintOptions contains all configuration for lint mode
@@ -16,41 +20,59 @@ type LintOptions struct {
 	Dir          string  // directory to lint
 	Src          string  // source tree to walk and lint
 	FailOnWarn   bool    // exit with error on warnings
-	Format       string  // output format: text, json, github-actions
+	Format       string  // output format: text, json, github-actions, gitlab, checkstyle, sarif
+	Output       string  // write the report to this file instead of stdout
+	GHASummary   bool    // append a Markdown summary to $GITHUB_STEP_SUMMARY
+	GHA          bool    // also annotate issues on stderr, append the summary and set step outputs
+	Staged       bool    // only lint templates staged in git
+	Since        string  // only lint templates changed since this git ref
 	NoUndefCheck bool    // skip undefined variable checking
+	Whitespace   bool    // report actions that leave stray whitespace in the output
+	Fix          bool    // apply the whitespace rule's trim marker fixes
+	Profile      string  // extra rules for a kind of output: "gha"
+	Values       bool    // also lint the values files for duplicate keys and ambiguous scalars
 	Config       *Config // configuration from file
-}
-
-// LintIssue represents a single linting issue
-type LintIssue struct {
-	Severity string // "error", "warn"
-	Category string // "parse", "undefined", "function", "guard"
-	File     string // file path
-	Line     int    // line number (0 if unknown)
-	Column   int    // column number (0 if unknown)
-	Message  string // human-readable message
-}
 
-// LintResult contains the results of a lint operation
-type LintResult struct {
-	Issues []LintIssue
-	Errors int
-	Warns  int
+	scope    map[string]bool   // files in scope under --staged or --since (nil: no restriction)
+	naming   *namingRules      // enabled lint.naming rules
+	suppress *lintSuppressions // templr:lint-disable comments of the linted files
 }
 
 // RunLintMode executes lint mode
-func RunLintMode(opts LintOptions) error {
-	result := &LintResult{
-		Issues: []LintIssue{},
+func RunLintMode(opts LintOptions) (err error) {
+	span := startCommandSpan("templr.lint")
+	defer func() { templr.EndSpan(span, err) }()
+
+	if err := checkCryptoPolicy(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkLintProfile(opts.Profile); err != nil {
+		return err
+	}
+	naming, err := compileNaming(opts.Config)
+	if err != nil {
+		return err
+	}
+	opts.naming = naming
+
+	result := &lint.Result{
+		Issues: []lint.Issue{},
+	}
+	opts.suppress = newLintSuppressions()
+
+	// --values: lint the values files first; with errors in them, the
+	// templates are linted without values rather than not at all
+	if opts.Values {
+		lintValues(lintBaseDir(opts), opts, result)
 	}
 
 	// Load data values if provided (for undefined variable checking)
 	var values map[string]any
-	if !opts.NoUndefCheck && opts.Shared.Data != "" {
+	if !opts.NoUndefCheck && opts.Shared.Data != "" && result.Errors == 0 {
 		var err error
-		values, err = buildValues(".", opts.Shared)
+		values, err = buildValues(lintBaseDir(opts), opts.Shared)
 		if err != nil {
-			return fmt.Errorf("load data: %w", err)
+			return exitError(ExitDataError, "data", fmt.Errorf("load data: %w", err))
 		}
 	}
 
@@ -58,6 +80,33 @@ func RunLintMode(opts LintOptions) error {
 	if opts.Config != nil && len(opts.Config.Lint.RequiredVars) > 0 && values != nil {
 		checkRequiredVars(values, opts.Config.Lint.RequiredVars, result)
 	}
+	if values != nil {
+		addValuesNamespace(values, opts.Shared)
+	}
+
+	if opts.Staged {
+		scope, err := stagedScope(opts)
+		if err != nil {
+			return err
+		}
+		opts.scope = scope
+		if opts.In == "" && opts.Dir == "" && opts.Src == "" {
+			opts.Src = "."
+		}
+	}
+	if opts.Since != "" {
+		if opts.Staged {
+			return argsError(fmt.Errorf("--since cannot be combined with --staged"))
+		}
+		if opts.In == "" && opts.Dir == "" && opts.Src == "" {
+			opts.Src = "."
+		}
+		scope, err := sinceScope(opts)
+		if err != nil {
+			return err
+		}
+		opts.scope = scope
+	}
 
 	// Determine which mode to use
 	if opts.In != "" {
@@ -75,74 +124,224 @@ func RunLintMode(opts LintOptions) error {
 		if err := lintWalk(opts.Src, values, opts, result); err != nil {
 			return err
 		}
-	} else {
-		return fmt.Errorf("must specify -i, --dir, or --src")
+	} else if !opts.Values {
+		return fmt.Errorf("must specify -i, --dir, --src or --values")
 	}
 
+	opts.suppress.apply(result, ranRules(values, opts))
+
 	// Report results
-	printLintResults(result, opts)
+	if err := writeLintReport(result, opts); err != nil {
+		return err
+	}
+	if opts.GHA && (opts.Format != "github-actions" || opts.Output != "") {
+		// annotations are read from stderr too, so the report format is kept
+		printLintResultsGitHubActions(sink.Stderr(), result)
+	}
+	if opts.GHASummary || opts.GHA {
+		if err := appendStepSummary(lintSummaryMarkdown(result)); err != nil {
+			return err
+		}
+	}
+	if opts.GHA {
+		if err := appendStepOutputs(lintStepOutputs(result, opts)); err != nil {
+			return err
+		}
+	}
 
 	// Determine exit code
 	if result.Errors > 0 {
-		os.Exit(ExitLintError)
+		Exit(ExitLintError)
 	}
 	if result.Warns > 0 && opts.FailOnWarn {
-		os.Exit(ExitLintWarn)
+		Exit(ExitLintWarn)
 	}
 
 	return nil
 }
 
-// lintSingleFile lints a single template file
-func lintSingleFile(path string, values map[string]any, opts LintOptions, result *LintResult) error {
+// lintRules returns the built-in rules enabled by opts followed by any rules
+// registered through pkg/lint.
+func lintRules(values map[string]any, opts LintOptions) []lint.Rule {
+	var rules []lint.Rule
+
+	// Check for disallowed functions (functions disabled for rendering are disallowed too)
+	if opts.Config != nil {
+		disallowed := append(append([]string{}, opts.Config.Lint.DisallowFunctions...), opts.Config.Functions.Disable...)
+		if len(disallowed) > 0 {
+			rules = append(rules, lint.DisallowedFunctionsRule{Functions: disallowed})
+		}
+	}
+
+	// Crypto helpers rejected by --crypto-policy fips (or a fips build)
+	if templr.CryptoPolicyEnforced(opts.Shared.CryptoPolicy) {
+		rules = append(rules, cryptoPolicyRule())
+	}
+
+	// Actions leaving blank lines or trailing spaces (--whitespace, implied by --fix)
+	if opts.Whitespace || opts.Fix {
+		rules = append(rules, whitespaceRule(opts.Shared.Ldelim, opts.Shared.Rdelim))
+	}
+
+	// Rendered GitHub Actions workflows (--profile gha)
+	if opts.Profile == lintProfileGHA {
+		rules = append(rules, ghaWorkflowRule(opts))
+	}
+
+	// If we have values and undefined checking is enabled, check for undefined variables
+	if !opts.NoUndefCheck && values != nil {
+		severity := lint.SeverityWarn
+		if opts.Config != nil && opts.Config.Lint.FailOnUndefined {
+			severity = lint.SeverityError
+		}
+		rules = append(rules, lint.UndefinedRule{Severity: severity})
+	}
+
+	return append(rules, lint.RegisteredRules()...)
+}
+
+// parseIssue converts a template parse error into a lint issue.
+func parseIssue(path string, err error) lint.Issue {
+	return lint.Issue{
+		Severity: lint.SeverityError,
+		Category: "parse",
+		File:     path,
+		Line:     lint.ExtractLineNumber(err.Error()),
+		Message:  err.Error(),
+	}
+}
+
+// stagedScope returns the staged files lint should be restricted to. When a
+// values file in use is staged every template is in scope, so nil is returned.
+func stagedScope(opts LintOptions) (map[string]bool, error) {
+	files, err := stagedFiles()
+	if err != nil {
+		return nil, err
+	}
+	base := lintBaseDir(opts)
+	valueFiles := append([]string{opts.Shared.Data, filepath.Join(base, "values.yaml"), filepath.Join(base, "values.yml")}, opts.Shared.Files...)
+	scope := make(map[string]bool, len(files))
+	for _, f := range files {
+		scope[f] = true
+	}
+	for _, vf := range valueFiles {
+		if vf != "" && scope[realPath(vf)] {
+			debugf(opts.Shared.Debug, "staged values file %s: linting all templates", vf)
+			return nil, nil
+		}
+	}
+	return scope, nil
+}
+
+// lintBaseDir returns the directory the default values.yaml of a lint run
+// is read from, as render, dir and walk resolve it: the directory of the
+// -i template, --dir or --src, or the working directory for stdin.
+func lintBaseDir(opts LintOptions) string {
+	switch {
+	case opts.In != "" && opts.In != "-":
+		return filepath.Dir(opts.In)
+	case opts.Dir != "":
+		return opts.Dir
+	case opts.Src != "":
+		return opts.Src
+	}
+	return "."
+}
+
+// inScope reports whether path should be linted under --staged or --since.
+func (opts LintOptions) inScope(path string) bool {
+	return opts.scope == nil || opts.scope[realPath(path)]
+}
+
+// realPath returns the absolute, symlink-resolved form of path, falling back
+// to the absolute path when it cannot be resolved.
+func realPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if r, err := filepath.EvalSymlinks(abs); err == nil {
+		return r
+	}
+	return abs
+}
+
+// lintSingleFile lints a single template file, or stdin when path is "-"
+func lintSingleFile(path string, values map[string]any, opts LintOptions, result *lint.Result) error {
+	if path == "-" {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read stdin: %w", err)
+		}
+		lintSource("stdin", content, values, opts, result)
+		return nil
+	}
+
 	// Check if file should be excluded
 	if opts.Config != nil && shouldExcludeFile(path, opts.Config.Lint.Exclude) {
 		return nil
 	}
+	if !opts.inScope(path) {
+		return nil
+	}
 
 	// Read the file
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("read %s: %w", path, err)
 	}
+	if opts.Fix {
+		if content, err = lintFixFile(path, content, opts); err != nil {
+			return err
+		}
+	}
+	lintSource(path, content, values, opts, result)
+	return nil
+}
 
+// lintSource parses and lints the template source read from path.
+func lintSource(path string, content []byte, values map[string]any, opts LintOptions, result *lint.Result) {
+	if binaryIssue(path, content, result) {
+		return
+	}
 	// Create a new template with custom delimiters
 	tpl := template.New(filepath.Base(path))
 	tpl.Delims(opts.Shared.Ldelim, opts.Shared.Rdelim)
 	tpl.Funcs(buildFuncMap(&tpl))
+	opts.suppress.collect(path, content)
+	lintProfileSource(path, content, opts, result)
 
 	// Try to parse the template
-	_, err = tpl.Parse(string(content))
-	if err != nil {
+	if _, err := parseRaw(tpl, string(content), opts.Shared); err != nil {
 		// Parse error - add as lint issue
-		issue := LintIssue{
-			Severity: "error",
-			Category: "parse",
-			File:     path,
-			Message:  err.Error(),
-		}
-		// Try to extract line number from error message
-		issue.Line = extractLineNumber(err.Error())
-		result.Issues = append(result.Issues, issue)
-		result.Errors++
-		return nil
+		result.Add(parseIssue(path, err))
+		return
 	}
 
-	// Check for disallowed functions
-	if opts.Config != nil && len(opts.Config.Lint.DisallowFunctions) > 0 {
-		checkDisallowedFunctions(tpl, path, opts.Config.Lint.DisallowFunctions, result)
-	}
+	opts.naming.checkFile(path, tpl.Name(), tpl, result)
 
-	// If we have values and undefined checking is enabled, check for undefined variables
-	if !opts.NoUndefCheck && values != nil {
-		checkUndefinedVariables(tpl, path, values, opts, result)
-	}
+	ctx := &lint.Context{File: path, Name: tpl.Name(), Source: content, Values: values}
+	result.Add(lint.Run(tpl.Tree, ctx, lintRules(values, opts))...)
+}
 
-	return nil
+// binaryIssue reports, as a warning, whether the file path with content is
+// binary and is not linted.
+func binaryIssue(path string, content []byte, result *lint.Result) bool {
+	reason := binaryReason(path, content)
+	if reason == "" {
+		return false
+	}
+	result.Add(lint.Issue{
+		Severity: lint.SeverityWarn,
+		Category: "binary",
+		File:     path,
+		Message:  reason + ", not a template",
+	})
+	return true
 }
 
 // lintDirectory lints all templates in a directory
-func lintDirectory(dirPath string, values map[string]any, opts LintOptions, result *LintResult) error {
+func lintDirectory(dirPath string, values map[string]any, opts LintOptions, result *lint.Result) error {
 	absDir, err := filepath.Abs(dirPath)
 	if err != nil {
 		return fmt.Errorf("abs path: %w", err)
@@ -165,77 +364,96 @@ func lintDirectory(dirPath string, values map[string]any, opts LintOptions, resu
 	if len(matches) == 0 {
 		return fmt.Errorf("no template files found in %s", dirPath)
 	}
+	sort.Strings(matches)
 
 	// Parse all templates together (to support includes/defines)
 	tpl := template.New("__root__")
 	tpl.Delims(opts.Shared.Ldelim, opts.Shared.Rdelim)
 	tpl.Funcs(buildFuncMap(&tpl))
 
+	sources := make(map[string][]byte)
+	var parsed, names []string
 	for _, path := range matches {
 		content, err := os.ReadFile(path)
 		if err != nil {
-			result.Issues = append(result.Issues, LintIssue{
-				Severity: "error",
+			result.Add(lint.Issue{
+				Severity: lint.SeverityError,
 				Category: "read",
 				File:     path,
 				Message:  err.Error(),
 			})
-			result.Errors++
 			continue
 		}
-
-		_, err = tpl.New(filepath.Base(path)).Parse(string(content))
-		if err != nil {
-			issue := LintIssue{
-				Severity: "error",
-				Category: "parse",
-				File:     path,
-				Message:  err.Error(),
+		if opts.Fix && opts.inScope(path) {
+			if content, err = lintFixFile(path, content, opts); err != nil {
+				return err
 			}
-			issue.Line = extractLineNumber(err.Error())
-			result.Issues = append(result.Issues, issue)
-			result.Errors++
+		}
+		if binaryReason(path, content) != "" {
+			if opts.inScope(path) {
+				binaryIssue(path, content, result)
+			}
+			continue
+		}
+		sources[path] = content
+		if opts.inScope(path) {
+			opts.suppress.collect(path, content)
+			lintProfileSource(path, content, opts, result)
+		}
+
+		_, err = parseRaw(tpl.New(filepath.Base(path)), string(content), opts.Shared)
+		if err != nil && opts.inScope(path) {
+			result.Add(parseIssue(path, err))
+		}
+		if err == nil {
+			parsed = append(parsed, path)
+			names = append(names, filepath.Base(path))
 		}
 	}
 
-	// Check for undefined variables in each template
-	if !opts.NoUndefCheck && values != nil {
-		for _, tmpl := range tpl.Templates() {
-			if tmpl.Name() == "__root__" {
-				continue
-			}
-			// Find the file path for this template
-			var filePath string
-			for _, path := range matches {
-				if filepath.Base(path) == tmpl.Name() {
-					filePath = path
-					break
-				}
-			}
-			checkUndefinedVariables(tmpl, filePath, values, opts, result)
+	// Naming conventions of the files, their defines and the entry template
+	for _, path := range parsed {
+		if opts.inScope(path) {
+			opts.naming.checkFile(path, filepath.Base(path), tpl, result)
+		}
+	}
+	opts.naming.checkEntry(tpl, names, namingFiles(tpl, absDir), opts, result)
 
-			// Check for disallowed functions in each template
-			if opts.Config != nil && len(opts.Config.Lint.DisallowFunctions) > 0 {
-				checkDisallowedFunctions(tmpl, filePath, opts.Config.Lint.DisallowFunctions, result)
+	// Run rules against each template
+	rules := lintRules(values, opts)
+	tmpls := tpl.Templates()
+	sort.Slice(tmpls, func(i, j int) bool { return tmpls[i].Name() < tmpls[j].Name() })
+	for _, tmpl := range tmpls {
+		if tmpl.Name() == "__root__" || tmpl.Tree == nil {
+			continue
+		}
+		// Find the file path for this template
+		var filePath string
+		for _, path := range matches {
+			if filepath.Base(path) == tmpl.Name() {
+				filePath = path
+				break
 			}
 		}
+		if !opts.inScope(filePath) {
+			continue
+		}
+		ctx := &lint.Context{File: filePath, Name: tmpl.Name(), Source: sources[filePath], Values: values}
+		result.Add(lint.Run(tmpl.Tree, ctx, rules)...)
 	}
 
 	return nil
 }
 
 // lintWalk recursively walks a directory tree and lints all templates
-func lintWalk(srcDir string, values map[string]any, opts LintOptions, result *LintResult) error {
+func lintWalk(srcDir string, values map[string]any, opts LintOptions, result *lint.Result) error {
 	absSrc, err := filepath.Abs(srcDir)
 	if err != nil {
 		return fmt.Errorf("abs path: %w", err)
 	}
 
 	// Collect template extensions
-	exts := map[string]bool{".tpl": true}
-	for _, e := range opts.Shared.ExtraExts {
-		exts["."+e] = true
-	}
+	exts := buildAllowedExts(opts.Shared.ExtraExts)
 
 	// Walk the directory tree
 	err = filepath.Walk(absSrc, func(path string, info os.FileInfo, err error) error {
@@ -247,8 +465,7 @@ func lintWalk(srcDir string, values map[string]any, opts LintOptions, result *Li
 		}
 
 		// Check if this is a template file
-		ext := filepath.Ext(path)
-		if !exts[ext] {
+		if matchExt(path, exts) == "" || skipLink(path, fs.FileInfoToDirEntry(info)) {
 			return nil
 		}
 
@@ -259,280 +476,6 @@ func lintWalk(srcDir string, values map[string]any, opts LintOptions, result *Li
 	return err
 }
 
-// checkUndefinedVariables checks for undefined variables in a template
-func checkUndefinedVariables(tpl *template.Template, path string, values map[string]any, opts LintOptions, result *LintResult) {
-	if tpl.Tree == nil {
-		return
-	}
-
-	// Extract all variable references from the template
-	vars := extractVariables(tpl.Tree)
-
-	// Determine severity based on config
-	severity := "warn"
-	if opts.Config != nil && opts.Config.Lint.FailOnUndefined {
-		severity = "error"
-	}
-
-	// Check each variable against the values
-	for _, varPath := range vars {
-		if !checkVariableExists(varPath, values) {
-			result.Issues = append(result.Issues, LintIssue{
-				Severity: severity,
-				Category: "undefined",
-				File:     path,
-				Message:  fmt.Sprintf("variable %s is undefined", varPath),
-			})
-
-			if severity == "error" {
-				result.Errors++
-			} else {
-				result.Warns++
-			}
-		}
-	}
-}
-
-// extractVariables extracts all variable references from a template AST
-//
-//nolint:dupl // Similar to extractFunctionCalls but extracts different data
-func extractVariables(tree *parse.Tree) []string {
-	vars := make(map[string]bool)
-
-	var walk func(node parse.Node)
-	walk = func(node parse.Node) {
-		if node == nil {
-			return
-		}
-
-		switch n := node.(type) {
-		case *parse.ActionNode:
-			extractFromPipe(n.Pipe, vars)
-		case *parse.IfNode:
-			extractFromPipe(n.Pipe, vars)
-			walkList(n.List, walk)
-			if n.ElseList != nil {
-				walkList(n.ElseList, walk)
-			}
-		case *parse.RangeNode:
-			extractFromPipe(n.Pipe, vars)
-			walkList(n.List, walk)
-			if n.ElseList != nil {
-				walkList(n.ElseList, walk)
-			}
-		case *parse.WithNode:
-			extractFromPipe(n.Pipe, vars)
-			walkList(n.List, walk)
-			if n.ElseList != nil {
-				walkList(n.ElseList, walk)
-			}
-		case *parse.ListNode:
-			walkList(n, walk)
-		case *parse.TemplateNode:
-			if n.Pipe != nil {
-				extractFromPipe(n.Pipe, vars)
-			}
-		}
-	}
-
-	walk(tree.Root)
-
-	// Convert map to slice
-	result := make([]string, 0, len(vars))
-	for v := range vars {
-		result = append(result, v)
-	}
-	return result
-}
-
-// walkList walks all nodes in a list
-func walkList(list *parse.ListNode, walk func(parse.Node)) {
-	if list == nil {
-		return
-	}
-	for _, node := range list.Nodes {
-		walk(node)
-	}
-}
-
-// extractFromPipe extracts variable references from a pipe
-func extractFromPipe(pipe *parse.PipeNode, vars map[string]bool) {
-	if pipe == nil {
-		return
-	}
-
-	for _, cmd := range pipe.Cmds {
-		for _, arg := range cmd.Args {
-			extractFromArg(arg, vars)
-		}
-	}
-}
-
-// extractFromArg extracts variable references from an argument
-func extractFromArg(arg parse.Node, vars map[string]bool) {
-	switch a := arg.(type) {
-	case *parse.FieldNode:
-		// This is a field access like .field or .nested.field
-		path := "." + strings.Join(a.Ident, ".")
-		vars[path] = true
-	case *parse.ChainNode:
-		// This is a method chain
-		if a.Node != nil {
-			extractFromArg(a.Node, vars)
-		}
-	case *parse.PipeNode:
-		extractFromPipe(a, vars)
-	}
-}
-
-// checkVariableExists checks if a variable path exists in the values
-func checkVariableExists(varPath string, values map[string]any) bool {
-	// Remove leading dot
-	varPath = strings.TrimPrefix(varPath, ".")
-
-	// Handle special cases
-	if varPath == "" || varPath == "Files" || varPath == "Values" {
-		return true
-	}
-
-	// Split the path and traverse the values
-	parts := strings.Split(varPath, ".")
-	current := values
-
-	for i, part := range parts {
-		val, ok := current[part]
-		if !ok {
-			return false
-		}
-
-		// If this is the last part, we found it
-		if i == len(parts)-1 {
-			return true
-		}
-
-		// Otherwise, traverse deeper
-		switch v := val.(type) {
-		case map[string]any:
-			current = v
-		case map[any]any:
-			// Convert to map[string]any
-			m := make(map[string]any)
-			for k, v := range v {
-				if ks, ok := k.(string); ok {
-					m[ks] = v
-				}
-			}
-			current = m
-		default:
-			// Can't traverse further
-			return false
-		}
-	}
-
-	return false
-}
-
-// extractLineNumber tries to extract a line number from an error message
-func extractLineNumber(errMsg string) int {
-	// Go template errors often include "line X" in the message
-	// Example: "template: file.tpl:12: unexpected {{end}}"
-	parts := strings.Split(errMsg, ":")
-	for i, part := range parts {
-		part = strings.TrimSpace(part)
-		if i > 0 && i < len(parts)-1 {
-			var line int
-			if _, err := fmt.Sscanf(part, "%d", &line); err == nil {
-				return line
-			}
-		}
-	}
-	return 0
-}
-
-// printLintResults prints the lint results to stdout
-func printLintResults(result *LintResult, opts LintOptions) {
-	switch opts.Format {
-	case "json":
-		printLintResultsJSON(result)
-	case "github-actions":
-		printLintResultsGitHubActions(result)
-	default:
-		printLintResultsText(result, opts.Shared.NoColor)
-	}
-}
-
-// printLintResultsText prints results in human-readable text format
-func printLintResultsText(result *LintResult, noColor bool) {
-	if len(result.Issues) == 0 {
-		printSuccess("✓ No issues found", noColor)
-		return
-	}
-
-	for _, issue := range result.Issues {
-		var prefix string
-		if issue.Severity == "error" {
-			prefix = colorize("[lint:error:"+issue.Category+"]", "red", noColor)
-		} else {
-			prefix = colorize("[lint:warn:"+issue.Category+"]", "yellow", noColor)
-		}
-
-		location := issue.File
-		if issue.Line > 0 {
-			location = fmt.Sprintf("%s:%d", location, issue.Line)
-		}
-
-		fmt.Printf("%s %s: %s\n", prefix, location, issue.Message)
-	}
-
-	fmt.Println()
-	if result.Errors > 0 {
-		printError(fmt.Sprintf("✗ Found %d error(s)", result.Errors), noColor)
-	}
-	if result.Warns > 0 {
-		printWarning(fmt.Sprintf("⚠ Found %d warning(s)", result.Warns), noColor)
-	}
-}
-
-// printLintResultsJSON prints results in JSON format
-func printLintResultsJSON(result *LintResult) {
-	fmt.Println("{")
-	fmt.Printf("  \"errors\": %d,\n", result.Errors)
-	fmt.Printf("  \"warnings\": %d,\n", result.Warns)
-	fmt.Println("  \"issues\": [")
-
-	for i, issue := range result.Issues {
-		comma := ","
-		if i == len(result.Issues)-1 {
-			comma = ""
-		}
-		fmt.Printf("    {\"severity\": %q, \"category\": %q, \"file\": %q, \"line\": %d, \"message\": %q}%s\n",
-			issue.Severity, issue.Category, issue.File, issue.Line, issue.Message, comma)
-	}
-
-	fmt.Println("  ]")
-	fmt.Println("}")
-}
-
-// printLintResultsGitHubActions prints results in GitHub Actions format
-func printLintResultsGitHubActions(result *LintResult) {
-	for _, issue := range result.Issues {
-		// GitHub Actions annotation format:
-		// ::error file={name},line={line},col={col}::{message}
-		// ::warning file={name},line={line},col={col}::{message}
-		level := issue.Severity
-		if level == "warn" {
-			level = "warning"
-		}
-
-		location := fmt.Sprintf("file=%s", issue.File)
-		if issue.Line > 0 {
-			location += fmt.Sprintf(",line=%d", issue.Line)
-		}
-
-		fmt.Printf("::%s %s::%s\n", level, location, issue.Message)
-	}
-}
-
 // Helper functions for colored output
 func colorize(text, color string, noColor bool) string {
 	if noColor {
@@ -552,29 +495,28 @@ func colorize(text, color string, noColor bool) string {
 	return text
 }
 
-func printError(msg string, noColor bool) {
-	fmt.Println(colorize(msg, "red", noColor))
+func printError(w io.Writer, msg string, noColor bool) {
+	_, _ = fmt.Fprintln(w, colorize(msg, "red", noColor))
 }
 
-func printWarning(msg string, noColor bool) {
-	fmt.Println(colorize(msg, "yellow", noColor))
+func printWarning(w io.Writer, msg string, noColor bool) {
+	_, _ = fmt.Fprintln(w, colorize(msg, "yellow", noColor))
 }
 
-func printSuccess(msg string, noColor bool) {
-	fmt.Println(colorize(msg, "green", noColor))
+func printSuccess(w io.Writer, msg string, noColor bool) {
+	_, _ = fmt.Fprintln(w, colorize(msg, "green", noColor))
 }
 
 // checkRequiredVars ensures that all required variables are present in values
-func checkRequiredVars(values map[string]any, required []string, result *LintResult) {
+func checkRequiredVars(values map[string]any, required []string, result *lint.Result) {
 	for _, varPath := range required {
-		if !checkVariableExists(varPath, values) {
-			result.Issues = append(result.Issues, LintIssue{
-				Severity: "error",
+		if !lint.VariableExists(varPath, values) {
+			result.Add(lint.Issue{
+				Severity: lint.SeverityError,
 				Category: "required",
 				File:     "",
 				Message:  fmt.Sprintf("required variable %s is not defined", varPath),
 			})
-			result.Errors++
 		}
 	}
 }
@@ -594,99 +536,3 @@ func shouldExcludeFile(path string, patterns []string) bool {
 	}
 	return false
 }
-
-// checkDisallowedFunctions inspects template AST for disallowed function calls
-func checkDisallowedFunctions(tpl *template.Template, path string, disallowed []string, result *LintResult) {
-	if tpl.Tree == nil || len(disallowed) == 0 {
-		return
-	}
-
-	// Create a map for faster lookup
-	disallowMap := make(map[string]bool)
-	for _, fn := range disallowed {
-		disallowMap[fn] = true
-	}
-
-	// Walk the AST and find function calls
-	funcs := extractFunctionCalls(tpl.Tree)
-
-	for _, fn := range funcs {
-		if disallowMap[fn] {
-			result.Issues = append(result.Issues, LintIssue{
-				Severity: "error",
-				Category: "function",
-				File:     path,
-				Message:  fmt.Sprintf("disallowed function %q is used", fn),
-			})
-			result.Errors++
-		}
-	}
-}
-
-// extractFunctionCalls extracts all function calls from a template AST
-//
-//nolint:dupl // Similar to extractVariables but extracts different data
-func extractFunctionCalls(tree *parse.Tree) []string {
-	funcs := make(map[string]bool)
-
-	var walk func(node parse.Node)
-	walk = func(node parse.Node) {
-		if node == nil {
-			return
-		}
-
-		switch n := node.(type) {
-		case *parse.ActionNode:
-			extractFuncsFromPipe(n.Pipe, funcs)
-		case *parse.IfNode:
-			extractFuncsFromPipe(n.Pipe, funcs)
-			walkList(n.List, walk)
-			if n.ElseList != nil {
-				walkList(n.ElseList, walk)
-			}
-		case *parse.RangeNode:
-			extractFuncsFromPipe(n.Pipe, funcs)
-			walkList(n.List, walk)
-			if n.ElseList != nil {
-				walkList(n.ElseList, walk)
-			}
-		case *parse.WithNode:
-			extractFuncsFromPipe(n.Pipe, funcs)
-			walkList(n.List, walk)
-			if n.ElseList != nil {
-				walkList(n.ElseList, walk)
-			}
-		case *parse.ListNode:
-			walkList(n, walk)
-		case *parse.TemplateNode:
-			if n.Pipe != nil {
-				extractFuncsFromPipe(n.Pipe, funcs)
-			}
-		}
-	}
-
-	walk(tree.Root)
-
-	// Convert map to slice
-	result := make([]string, 0, len(funcs))
-	for fn := range funcs {
-		result = append(result, fn)
-	}
-	return result
-}
-
-// extractFuncsFromPipe extracts function names from a pipe
-func extractFuncsFromPipe(pipe *parse.PipeNode, funcs map[string]bool) {
-	if pipe == nil {
-		return
-	}
-
-	for _, cmd := range pipe.Cmds {
-		if len(cmd.Args) > 0 {
-			// First arg might be a function identifier
-			if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
-				funcs[ident.Ident] = true
-			}
-		}
-	}
-}
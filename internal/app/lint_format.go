@@ -0,0 +1,371 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kanopi/templr/pkg/lint"
+)
+
+// lintReportVersion is bumped whenever the JSON report schema changes incompatibly.
+const lintReportVersion = 1
+
+// writeLintReport prints the lint results to stdout, or to opts.Output when
+// set; failing to close opts.Output fails the report, as the data may not
+// have been written.
+func writeLintReport(result *lint.Result, opts LintOptions) (err error) {
+	var w io.Writer = os.Stdout
+	if opts.Output != "" {
+		f, cerr := os.Create(opts.Output)
+		if cerr != nil {
+			return fmt.Errorf("create lint report: %w", cerr)
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil && cerr != nil {
+				err = fmt.Errorf("write lint report: %w", cerr)
+			}
+		}()
+		w = f
+	}
+
+	noColor := opts.Shared.NoColor || opts.Output != ""
+	switch opts.Format {
+	case "json":
+		return printLintResultsJSON(w, result)
+	case "github-actions":
+		printLintResultsGitHubActions(w, result)
+	case "gitlab":
+		return printLintResultsGitLab(w, result)
+	case "checkstyle":
+		return printLintResultsCheckstyle(w, result)
+	case "sarif":
+		return printLintResultsSARIF(w, result)
+	default:
+		printLintResultsText(w, result, noColor)
+	}
+	return nil
+}
+
+// printLintResultsText prints results in human-readable text format, in the
+// language of --lang
+func printLintResultsText(w io.Writer, result *lint.Result, noColor bool) {
+	if len(result.Issues) == 0 {
+		printSuccess(w, tr("✓ No issues found"), noColor)
+		return
+	}
+
+	for _, issue := range result.Issues {
+		var prefix string
+		if issue.Severity == lint.SeverityError {
+			prefix = colorize("[lint:error:"+issue.Category+"]", "red", noColor)
+		} else {
+			prefix = colorize("[lint:warn:"+issue.Category+"]", "yellow", noColor)
+		}
+
+		location := issue.File
+		if issue.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, issue.Line)
+		}
+
+		_, _ = fmt.Fprintf(w, "%s %s: %s\n", prefix, location, localize(issue.Message))
+	}
+
+	_, _ = fmt.Fprintln(w)
+	if result.Errors > 0 {
+		printError(w, trf("✗ Found %d error(s)", result.Errors), noColor)
+	}
+	if result.Warns > 0 {
+		printWarning(w, trf("⚠ Found %d warning(s)", result.Warns), noColor)
+	}
+}
+
+// lintJSONReport is the stable schema of `templr lint --format json`.
+type lintJSONReport struct {
+	Version  int             `json:"version"`
+	Errors   int             `json:"errors"`
+	Warnings int             `json:"warnings"`
+	Issues   []lintJSONIssue `json:"issues"`
+}
+
+type lintJSONIssue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Category string `json:"category"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+}
+
+// printLintResultsJSON prints results in JSON format
+func printLintResultsJSON(w io.Writer, result *lint.Result) error {
+	report := lintJSONReport{
+		Version:  lintReportVersion,
+		Errors:   result.Errors,
+		Warnings: result.Warns,
+		Issues:   make([]lintJSONIssue, 0, len(result.Issues)),
+	}
+	for _, issue := range result.Issues {
+		report.Issues = append(report.Issues, lintJSONIssue{
+			Rule:     issue.RuleID(),
+			Severity: issue.Severity,
+			Category: issue.Category,
+			File:     issue.File,
+			Line:     issue.Line,
+			Column:   issue.Column,
+			Message:  issue.Message,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("encode lint report: %w", err)
+	}
+	return nil
+}
+
+// printLintResultsGitHubActions prints results in GitHub Actions format
+func printLintResultsGitHubActions(w io.Writer, result *lint.Result) {
+	for _, issue := range result.Issues {
+		// GitHub Actions annotation format:
+		// ::error file={name},line={line},col={col}::{message}
+		// ::warning file={name},line={line},col={col}::{message}
+		level := issue.Severity
+		if level == lint.SeverityWarn {
+			level = "warning"
+		}
+
+		location := fmt.Sprintf("file=%s", issue.File)
+		if issue.Line > 0 {
+			location += fmt.Sprintf(",line=%d", issue.Line)
+		}
+		if issue.Column > 0 {
+			location += fmt.Sprintf(",col=%d", issue.Column)
+		}
+
+		_, _ = fmt.Fprintf(w, "::%s %s::%s\n", level, location, issue.Message)
+	}
+}
+
+// gitlabIssue is a single entry of a GitLab Code Quality report.
+// See https://docs.gitlab.com/ee/ci/testing/code_quality.html#implement-a-custom-tool
+type gitlabIssue struct {
+	Description string         `json:"description"`
+	CheckName   string         `json:"check_name"`
+	Fingerprint string         `json:"fingerprint"`
+	Severity    string         `json:"severity"`
+	Location    gitlabLocation `json:"location"`
+}
+
+type gitlabLocation struct {
+	Path  string      `json:"path"`
+	Lines gitlabLines `json:"lines"`
+}
+
+type gitlabLines struct {
+	Begin int `json:"begin"`
+}
+
+// printLintResultsGitLab prints results as a GitLab Code Quality JSON array.
+func printLintResultsGitLab(w io.Writer, result *lint.Result) error {
+	issues := make([]gitlabIssue, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		severity := "minor"
+		if issue.Severity == lint.SeverityError {
+			severity = "major"
+		}
+		path := issue.File
+		if path == "" {
+			path = "."
+		}
+		line := issue.Line
+		if line == 0 {
+			line = 1
+		}
+		issues = append(issues, gitlabIssue{
+			Description: issue.Message,
+			CheckName:   "templr/" + issue.RuleID(),
+			Fingerprint: lintFingerprint(issue),
+			Severity:    severity,
+			Location:    gitlabLocation{Path: path, Lines: gitlabLines{Begin: line}},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(issues); err != nil {
+		return fmt.Errorf("encode gitlab report: %w", err)
+	}
+	return nil
+}
+
+// lintFingerprint returns a stable identifier for an issue so GitLab can
+// track it across pipelines.
+func lintFingerprint(issue lint.Issue) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d\x00%s", issue.Category, issue.File, issue.Line, issue.Message)))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkstyleReport is the root element of a Checkstyle XML report.
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// printLintResultsCheckstyle prints results as Checkstyle XML (understood by
+// Jenkins warnings-ng, reviewdog and most CI annotators).
+func printLintResultsCheckstyle(w io.Writer, result *lint.Result) error {
+	report := checkstyleReport{Version: "4.3"}
+	index := map[string]int{}
+	for _, issue := range result.Issues {
+		i, ok := index[issue.File]
+		if !ok {
+			i = len(report.Files)
+			index[issue.File] = i
+			report.Files = append(report.Files, checkstyleFile{Name: issue.File})
+		}
+		severity := "warning"
+		if issue.Severity == lint.SeverityError {
+			severity = "error"
+		}
+		report.Files[i].Errors = append(report.Files[i].Errors, checkstyleError{
+			Line:     issue.Line,
+			Column:   issue.Column,
+			Severity: severity,
+			Message:  issue.Message,
+			Source:   "templr." + issue.RuleID(),
+		})
+	}
+
+	out, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode checkstyle report: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, out)
+	return err
+}
+
+// sarifReport is a SARIF 2.1.0 log, the format GitHub code scanning and
+// most security dashboards ingest.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// printLintResultsSARIF prints results as a SARIF log with one run, listing
+// the rules that reported an issue.
+func printLintResultsSARIF(w io.Writer, result *lint.Result) error {
+	driver := sarifDriver{
+		Name:           "templr",
+		Version:        GetVersion(),
+		InformationURI: "https://github.com/kanopi/templr",
+		Rules:          []sarifRule{},
+	}
+	results := make([]sarifResult, 0, len(result.Issues))
+	seen := map[string]bool{}
+	for _, issue := range result.Issues {
+		id := issue.RuleID()
+		if !seen[id] {
+			seen[id] = true
+			driver.Rules = append(driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: "templr lint: " + issue.Category}})
+		}
+		level := "warning"
+		if issue.Severity == lint.SeverityError {
+			level = "error"
+		}
+		r := sarifResult{RuleID: id, Level: level, Message: sarifMessage{Text: issue.Message}}
+		if issue.File != "" {
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: filepath.ToSlash(issue.File)}}}
+			if issue.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: issue.Line, StartColumn: issue.Column}
+			}
+			r.Locations = []sarifLocation{loc}
+		}
+		results = append(results, r)
+	}
+	report := sarifReport{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("encode sarif report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/kanopi/templr/pkg/lint"
+	"github.com/kanopi/templr/pkg/templr"
+	"gopkg.in/yaml.v3"
+)
+
+// Lint profiles selected with --profile.
+const (
+	lintProfileGHA = "gha" // GitHub Actions workflows
+)
+
+// ghaEvents are the events a GitHub Actions workflow can be triggered by.
+var ghaEvents = []string{
+	"branch_protection_rule", "check_run", "check_suite", "create", "delete",
+	"deployment", "deployment_status", "discussion", "discussion_comment",
+	"fork", "gollum", "issue_comment", "issues", "label", "merge_group",
+	"milestone", "page_build", "project", "project_card", "project_column",
+	"public", "pull_request", "pull_request_review",
+	"pull_request_review_comment", "pull_request_target", "push",
+	"registry_package", "release", "repository_dispatch", "schedule",
+	"status", "watch", "workflow_call", "workflow_dispatch", "workflow_run",
+}
+
+// ghaJobID matches the job ids GitHub accepts.
+var ghaJobID = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// checkLintProfile validates --profile.
+func checkLintProfile(profile string) error {
+	switch profile {
+	case "", lintProfileGHA:
+		return nil
+	}
+	return argsError(fmt.Errorf("unknown lint profile %q (want %s)", profile, lintProfileGHA))
+}
+
+// isWorkflowTemplate reports whether the template at p renders a workflow:
+// a .yml or .yaml file in a workflows directory, such as
+// .github/workflows/ci.yml.tpl.
+func isWorkflowTemplate(p string, exts []string) bool {
+	p = filepath.ToSlash(p)
+	for _, ext := range append([]string{"tpl"}, exts...) {
+		if trimmed := strings.TrimSuffix(p, "."+ext); trimmed != p {
+			p = trimmed
+			break
+		}
+	}
+	ext := path.Ext(p)
+	return (ext == ".yml" || ext == ".yaml") && (path.Base(path.Dir(p)) == "workflows")
+}
+
+// lintProfileSource runs the source checks of the lint profile on the
+// template read from p. They run before parsing, because the mistakes they
+// look for usually make the template fail to parse.
+func lintProfileSource(p string, content []byte, opts LintOptions, result *lint.Result) {
+	if opts.Profile != lintProfileGHA || !isWorkflowTemplate(p, opts.Shared.ExtraExts) {
+		return
+	}
+	result.Add(ghaExpressionIssues(p, string(content), opts.Shared.Ldelim, opts.Shared.Rdelim)...)
+}
+
+// ghaExpressionIssues reports the GitHub expressions (${{ ... }}) of a
+// workflow template that templr would execute as its own actions. Raw
+// blocks and expressions printed by an action, as in {{ "${{" }}, are fine.
+func ghaExpressionIssues(file, src, ldelim, rdelim string) []lint.Issue {
+	if !strings.HasPrefix("{{", ldelim) {
+		return nil
+	}
+	blocks, err := templr.FindRawBlocks(file, src, ldelim, rdelim)
+	if err != nil {
+		return nil
+	}
+	// blank raw block content, keeping offsets and newlines
+	b := []byte(src)
+	for _, blk := range blocks {
+		for i := blk.ContentStart; i < blk.ContentStart+len(blk.Content); i++ {
+			if b[i] != '\n' {
+				b[i] = ' '
+			}
+		}
+	}
+	tokens, err := fmtTokenize(string(b), ldelim, rdelim)
+	if err != nil {
+		return nil
+	}
+	var issues []lint.Issue
+	offset := 0
+	for i, tok := range tokens {
+		if tok.action && i > 0 && strings.HasSuffix(tokens[i-1].text, "$") {
+			line := 1 + strings.Count(src[:offset], "\n")
+			col := offset - strings.LastIndex(src[:offset], "\n")
+			issues = append(issues, lint.Issue{
+				Rule:     "gha-expression",
+				Severity: lint.SeverityError,
+				Category: "gha",
+				File:     file,
+				Line:     line,
+				Column:   col,
+				Message:  fmt.Sprintf("$%s is a GitHub expression but templr executes it; wrap it in %s raw %s...%s endraw %s or write %s \"${{\" %s", firstLine(tok.text), ldelim, rdelim, ldelim, rdelim, ldelim, rdelim),
+			})
+		}
+		offset += len(tok.text)
+	}
+	return issues
+}
+
+// firstLine returns the first line of s.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + "..."
+	}
+	return s
+}
+
+// ghaWorkflowRule renders each workflow template with the lint values and
+// checks the result is a workflow GitHub accepts: valid YAML with known on:
+// triggers and unique, well-formed job ids.
+func ghaWorkflowRule(opts LintOptions) lint.Rule {
+	return lint.RuleFunc{RuleName: "gha-workflow", Fn: func(tree *parse.Tree, ctx *lint.Context) []lint.Issue {
+		if !isWorkflowTemplate(ctx.File, opts.Shared.ExtraExts) {
+			return nil
+		}
+		issue := func(severity, msg string) []lint.Issue {
+			return []lint.Issue{{Rule: "gha-workflow", Severity: severity, Category: "gha", File: ctx.File, Message: msg}}
+		}
+		var tpl *template.Template
+		tpl = template.New(ctx.Name).Funcs(buildFuncMap(&tpl))
+		if _, err := tpl.AddParseTree(ctx.Name, tree); err != nil {
+			return nil
+		}
+		values := ctx.Values
+		if values == nil {
+			values = map[string]any{}
+		}
+		var out bytes.Buffer
+		if err := tpl.Execute(&out, copyValues(values)); err != nil {
+			return issue(lint.SeverityWarn, fmt.Sprintf("workflow not checked: render: %v", err))
+		}
+		var issues []lint.Issue
+		for _, msg := range ghaWorkflowProblems(out.Bytes()) {
+			issues = append(issues, issue(lint.SeverityError, msg)...)
+		}
+		return issues
+	}}
+}
+
+// ghaWorkflowProblems returns what is wrong with the rendered workflow b.
+// Lines are lines of the rendered output.
+func ghaWorkflowProblems(b []byte) []string {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return []string{fmt.Sprintf("rendered workflow is not valid YAML: %v", err)}
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return []string{"rendered workflow is not a YAML mapping"}
+	}
+	root := doc.Content[0]
+	var problems []string
+	on, jobs := mappingValue(root, "on"), mappingValue(root, "jobs")
+	if on == nil {
+		problems = append(problems, "rendered workflow has no on: triggers")
+	} else {
+		var events []*yaml.Node
+		switch on.Kind {
+		case yaml.ScalarNode:
+			events = []*yaml.Node{on}
+		case yaml.SequenceNode:
+			events = on.Content
+		case yaml.MappingNode:
+			for i := 0; i < len(on.Content); i += 2 {
+				events = append(events, on.Content[i])
+			}
+		}
+		if len(events) == 0 {
+			problems = append(problems, fmt.Sprintf("rendered line %d: on: lists no triggers", on.Line))
+		}
+		for _, ev := range events {
+			if !slices.Contains(ghaEvents, ev.Value) {
+				problems = append(problems, fmt.Sprintf("rendered line %d: unknown trigger %q", ev.Line, ev.Value))
+			}
+		}
+	}
+	if jobs == nil || jobs.Kind != yaml.MappingNode || len(jobs.Content) == 0 {
+		return append(problems, "rendered workflow has no jobs")
+	}
+	seen := map[string]int{}
+	for i := 0; i < len(jobs.Content); i += 2 {
+		id := jobs.Content[i]
+		if first, ok := seen[id.Value]; ok {
+			problems = append(problems, fmt.Sprintf("rendered line %d: job id %q is already used on line %d", id.Line, id.Value, first))
+			continue
+		}
+		seen[id.Value] = id.Line
+		if !ghaJobID.MatchString(id.Value) {
+			problems = append(problems, fmt.Sprintf("rendered line %d: invalid job id %q: must start with a letter or _ and contain only letters, digits, - and _", id.Line, id.Value))
+		}
+	}
+	return problems
+}
+
+// mappingValue returns the value of key in the mapping node m, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
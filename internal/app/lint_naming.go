@@ -0,0 +1,173 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/kanopi/templr/pkg/lint"
+)
+
+// Default patterns of the lint.naming rules.
+const (
+	namingPartialsDefault = `^_`
+	namingDefinesDefault  = `^[a-z0-9.]+$`
+	namingFilesDefault    = `^_?[a-z0-9]+(-[a-z0-9]+)*(\.[A-Za-z0-9]+)*$` // kebab-case, then extensions
+	namingEntryDefault    = `^root$`
+)
+
+// namingCheck is one enabled lint.naming rule.
+type namingCheck struct {
+	rule     string // rule name of its issues
+	severity string
+	pattern  *regexp.Regexp
+}
+
+// namingRules are the enabled lint.naming rules; nil fields are off.
+type namingRules struct {
+	partials, defines, files, entry *namingCheck
+}
+
+// compileNaming validates lint.naming and compiles its enabled rules, or
+// returns nil when none is.
+func compileNaming(cfg *Config) (*namingRules, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	n := &namingRules{}
+	for _, r := range []struct {
+		key, rule, def string
+		conf           NamingRule
+		dst            **namingCheck
+	}{
+		{"partials", "naming-partial", namingPartialsDefault, cfg.Lint.Naming.Partials, &n.partials},
+		{"defines", "naming-define", namingDefinesDefault, cfg.Lint.Naming.Defines, &n.defines},
+		{"files", "naming-file", namingFilesDefault, cfg.Lint.Naming.Files, &n.files},
+		{"entry", "naming-entry", namingEntryDefault, cfg.Lint.Naming.Entry, &n.entry},
+	} {
+		var severity string
+		switch strings.ToLower(r.conf.Severity) {
+		case "", "off":
+			continue
+		case "warn", "warning":
+			severity = lint.SeverityWarn
+		case "error":
+			severity = lint.SeverityError
+		default:
+			return nil, argsError(fmt.Errorf("lint.naming.%s.severity %q: want off, warn or error", r.key, r.conf.Severity))
+		}
+		pattern := firstNonEmpty(r.conf.Pattern, r.def)
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, argsError(fmt.Errorf("lint.naming.%s.pattern: %w", r.key, err))
+		}
+		*r.dst = &namingCheck{rule: r.rule, severity: severity, pattern: re}
+	}
+	if n.partials == nil && n.defines == nil && n.files == nil && n.entry == nil {
+		return nil, nil
+	}
+	return n, nil
+}
+
+// ruleNames returns the names of the enabled rules.
+func (n *namingRules) ruleNames() []string {
+	if n == nil {
+		return nil
+	}
+	var names []string
+	for _, c := range []*namingCheck{n.partials, n.defines, n.files, n.entry} {
+		if c != nil {
+			names = append(names, c.rule)
+		}
+	}
+	return names
+}
+
+func (c *namingCheck) issue(file string, line int, msg string) lint.Issue {
+	return lint.Issue{Rule: c.rule, Severity: c.severity, Category: "naming", File: file, Line: line, Message: msg}
+}
+
+// checkFile checks the names of the template file at path, parsed into tpl
+// as the template base: the file name, whether a file holding only defines
+// is named as a partial, and the names of its defines.
+func (n *namingRules) checkFile(path, base string, tpl *template.Template, result *lint.Result) {
+	if n == nil {
+		return
+	}
+	var defines []*template.Template
+	for _, t := range tpl.Templates() {
+		if t.Tree != nil && t.Tree.ParseName == base && t.Name() != base {
+			defines = append(defines, t)
+		}
+	}
+	sort.Slice(defines, func(i, j int) bool { return defines[i].Tree.Root.Pos < defines[j].Tree.Root.Pos })
+
+	if path != "stdin" {
+		if c := n.files; c != nil && !c.pattern.MatchString(base) {
+			result.Add(c.issue(path, 0, fmt.Sprintf("file name %s does not match %s", base, c.pattern)))
+		}
+		own := tpl.Lookup(base)
+		partial := len(defines) > 0 && (own == nil || own.Tree == nil || parse.IsEmptyTree(own.Tree.Root))
+		if c := n.partials; c != nil && partial && !c.pattern.MatchString(base) {
+			result.Add(c.issue(path, 0, fmt.Sprintf("%s only holds defines, so it is a partial; its name does not match %s", base, c.pattern)))
+		}
+	}
+	if c := n.defines; c != nil {
+		for _, t := range defines {
+			if !c.pattern.MatchString(t.Name()) {
+				result.Add(c.issue(path, treeLine(t.Tree), fmt.Sprintf("define %q does not match %s", t.Name(), c.pattern)))
+			}
+		}
+	}
+}
+
+// checkEntry checks the name of the template templr dir renders when -i is
+// not given, among the templates of a directory parsed into tpl. files maps
+// template names to the files that define them.
+func (n *namingRules) checkEntry(tpl *template.Template, names []string, files map[string]string, opts LintOptions, result *lint.Result) {
+	if n == nil || n.entry == nil {
+		return
+	}
+	configured := ""
+	if opts.Config != nil {
+		configured = opts.Config.Dir.Entry
+	}
+	// a directory without a clear entry is not this rule's concern
+	entry, err := defaultDirEntry(tpl, names, buildAllowedExts(opts.Shared.ExtraExts), configured)
+	if err != nil || n.entry.pattern.MatchString(entry) || !opts.inScope(files[entry]) {
+		return
+	}
+	line := 0
+	if t := tpl.Lookup(entry); t != nil && t.Tree != nil && t.Tree.ParseName != entry {
+		line = treeLine(t.Tree)
+	}
+	result.Add(n.entry.issue(files[entry], line, fmt.Sprintf("entry template %s does not match %s", entry, n.entry.pattern)))
+}
+
+// treeLine returns the line a parsed template starts at.
+func treeLine(tree *parse.Tree) int {
+	loc, _ := tree.ErrorContext(tree.Root)
+	parts := strings.Split(loc, ":")
+	if len(parts) < 3 {
+		return 0
+	}
+	line, _ := strconv.Atoi(parts[len(parts)-2])
+	return line
+}
+
+// namingFiles maps the templates of tpl to the files under dir they were
+// parsed from.
+func namingFiles(tpl *template.Template, dir string) map[string]string {
+	files := map[string]string{}
+	for _, t := range tpl.Templates() {
+		if t.Tree != nil {
+			files[t.Name()] = filepath.Join(dir, t.Tree.ParseName)
+		}
+	}
+	return files
+}
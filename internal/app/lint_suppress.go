@@ -0,0 +1,149 @@
+package app
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kanopi/templr/pkg/lint"
+)
+
+// suppressionRe matches a lint suppression comment, whatever the
+// delimiters: {{/* templr:lint-disable <rule> [subject...] */}}.
+var suppressionRe = regexp.MustCompile(`/\*\s*templr:lint-disable\b([^*]*)\*/`)
+
+// lintSuppression is one templr:lint-disable comment. Without subjects it
+// silences its rule in the whole file; with subjects, only the issues of the
+// rule about one of them (a variable, a function name).
+type lintSuppression struct {
+	file     string
+	line     int
+	rule     string
+	subjects []string
+	used     bool
+}
+
+// lintSuppressions collects the suppression comments of the linted files.
+type lintSuppressions struct {
+	byFile map[string][]*lintSuppression
+	bad    []lint.Issue // comments without a rule
+}
+
+func newLintSuppressions() *lintSuppressions {
+	return &lintSuppressions{byFile: map[string][]*lintSuppression{}}
+}
+
+// collect records the suppression comments of the template source read
+// from path.
+func (s *lintSuppressions) collect(path string, content []byte) {
+	if s == nil {
+		return
+	}
+	src := string(content)
+	for _, m := range suppressionRe.FindAllStringSubmatchIndex(src, -1) {
+		line := strings.Count(src[:m[0]], "\n") + 1
+		fields := strings.Fields(src[m[2]:m[3]])
+		if len(fields) == 0 {
+			s.bad = append(s.bad, lint.Issue{
+				Rule:     "suppression",
+				Severity: lint.SeverityWarn,
+				Category: "suppression",
+				File:     path,
+				Line:     line,
+				Message:  "templr:lint-disable needs the rule to disable, e.g. templr:lint-disable undefined .optional.flag",
+			})
+			continue
+		}
+		s.byFile[path] = append(s.byFile[path], &lintSuppression{file: path, line: line, rule: fields[0], subjects: fields[1:]})
+	}
+}
+
+// matches reports whether the suppression silences the issue.
+func (p *lintSuppression) matches(is lint.Issue) bool {
+	if p.rule != is.RuleID() && p.rule != is.Category {
+		return false
+	}
+	if len(p.subjects) == 0 {
+		return true
+	}
+	for _, f := range strings.Fields(is.Message) {
+		f = strings.Trim(f, `"'():,;`)
+		for _, subject := range p.subjects {
+			if f == subject {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apply removes the suppressed issues from result and adds a warning for
+// every suppression that silenced nothing although its rule ran, so stale
+// ones do not pile up. Parse errors cannot be suppressed.
+func (s *lintSuppressions) apply(result *lint.Result, ran map[string]bool) {
+	if s == nil {
+		return
+	}
+	kept := &lint.Result{Issues: []lint.Issue{}}
+	for _, is := range result.Issues {
+		suppressed := false
+		if is.Category != "parse" {
+			for _, p := range s.byFile[is.File] {
+				if p.matches(is) {
+					p.used = true
+					suppressed = true
+				}
+			}
+		}
+		if !suppressed {
+			kept.Add(is)
+		}
+	}
+	kept.Add(s.bad...)
+	for _, file := range slices.Sorted(maps.Keys(s.byFile)) {
+		for _, p := range s.byFile[file] {
+			if p.used || !ran[p.rule] {
+				continue
+			}
+			what := p.rule
+			if len(p.subjects) > 0 {
+				what += " " + strings.Join(p.subjects, " ")
+			}
+			kept.Add(lint.Issue{
+				Rule:     "unused-suppression",
+				Severity: lint.SeverityWarn,
+				Category: "suppression",
+				File:     p.file,
+				Line:     p.line,
+				Message:  fmt.Sprintf("templr:lint-disable %s suppresses nothing; remove it", what),
+			})
+		}
+	}
+	*result = *kept
+}
+
+// ranRules returns the names and categories of the rules a lint run used,
+// those unused suppressions are reported for.
+func ranRules(values map[string]any, opts LintOptions) map[string]bool {
+	ran := map[string]bool{}
+	for _, r := range lintRules(values, opts) {
+		ran[r.Name()] = true
+	}
+	if ran["crypto-policy"] {
+		ran["function"] = true // its issues have the function category
+	}
+	if opts.Profile == lintProfileGHA {
+		ran["gha"], ran["gha-expression"] = true, true
+	}
+	for _, name := range opts.naming.ruleNames() {
+		ran[name], ran["naming"] = true, true
+	}
+	if opts.Values {
+		for _, name := range valuesRuleNames {
+			ran[name], ran["values"] = true, true
+		}
+	}
+	return ran
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/kanopi/templr/pkg/lint"
+	"gopkg.in/yaml.v3"
+)
+
+// Values lint rules, run by lint --values over the values files of the run.
+const (
+	valuesRuleDuplicate = "values-duplicate-key"
+	valuesRuleBool      = "values-yaml11-bool"
+	valuesRuleTab       = "values-tab-indent"
+	valuesRuleNumber    = "values-number-string"
+	valuesRuleParse     = "values-parse"
+)
+
+// valuesRuleNames are the rules lint --values runs.
+var valuesRuleNames = []string{valuesRuleDuplicate, valuesRuleBool, valuesRuleTab, valuesRuleNumber, valuesRuleParse}
+
+// yaml11Bools are the plain scalars YAML 1.1 tools (Helm, Ansible, PyYAML)
+// read as booleans while templr reads them as strings: the Norway problem.
+var yaml11Bools = map[string]bool{
+	"y": true, "yes": true, "n": true, "no": true, "on": true, "off": true,
+}
+
+// lossyNumber matches numbers whose text a decode does not keep: leading
+// zeros (a zip code, 0755) and trailing zeros after the point (a version).
+var lossyNumber = regexp.MustCompile(`^[-+]?(0[0-9_]+(\.[0-9]*)?|[0-9_]*\.[0-9]*0)$`)
+
+// lintValues lints the values files of the run: the default values.yaml,
+// --data and -f, as buildValues loads them from baseDir.
+func lintValues(baseDir string, opts LintOptions, result *lint.Result) {
+	for _, path := range valuesInputs(baseDir, opts.Shared) {
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			continue
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			continue // reported when the values are loaded
+		}
+		opts.suppress.collect(path, src)
+		lintValuesSource(path, src, ext == ".json", result)
+	}
+}
+
+// lintValuesSource lints one YAML (or JSON) values file. Only duplicate keys
+// are reported for JSON, whose strings are always quoted.
+func lintValuesSource(path string, src []byte, isJSON bool, result *lint.Result) {
+	issue := func(rule, severity string, line, col int, format string, args ...any) {
+		result.Add(lint.Issue{
+			Rule:     rule,
+			Severity: severity,
+			Category: "values",
+			File:     path,
+			Line:     line,
+			Column:   col,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(src)).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		// the usual cause is a tab in the indentation; tabs inside block
+		// scalars are content, so lines are only checked when parsing fails
+		tabs := false
+		for i, line := range bytes.Split(src, []byte("\n")) {
+			indent := line[:len(line)-len(bytes.TrimLeft(line, " \t"))]
+			if j := bytes.IndexByte(indent, '\t'); j >= 0 && len(bytes.TrimSpace(line)) > 0 && !isJSON {
+				issue(valuesRuleTab, lint.SeverityError, i+1, j+1, "tab in indentation; YAML only allows spaces")
+				tabs = true
+			}
+		}
+		if !tabs {
+			issue(valuesRuleParse, lint.SeverityError, 0, 0, "%v", err)
+		}
+		return
+	}
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		switch n.Kind {
+		case yaml.MappingNode:
+			seen := map[string]int{}
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k := n.Content[i]
+				if k.Kind == yaml.ScalarNode && k.Tag != "!!merge" {
+					if first, ok := seen[k.Value]; ok {
+						issue(valuesRuleDuplicate, lint.SeverityError, k.Line, k.Column,
+							"duplicate key %q, first defined on line %d", k.Value, first)
+					} else {
+						seen[k.Value] = k.Line
+					}
+				}
+				walk(n.Content[i+1])
+			}
+		case yaml.SequenceNode, yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c)
+			}
+		case yaml.ScalarNode:
+			if isJSON || n.Style != 0 {
+				return // quoted or block scalars keep their text
+			}
+			switch {
+			case n.ShortTag() == "!!str" && yaml11Bools[strings.ToLower(n.Value)]:
+				issue(valuesRuleBool, lint.SeverityWarn, n.Line, n.Column,
+					"unquoted %s is a string here but a boolean to YAML 1.1 tools such as Helm and Ansible; quote it or use true/false", n.Value)
+			case (n.ShortTag() == "!!int" || n.ShortTag() == "!!float") && lossyNumber.MatchString(n.Value):
+				issue(valuesRuleNumber, lint.SeverityWarn, n.Line, n.Column,
+					"%s is read as a number and loses its zeros; quote it to keep it as written", n.Value)
+			}
+		}
+	}
+	walk(&doc)
+}
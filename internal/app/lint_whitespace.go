@@ -0,0 +1,332 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"github.com/kanopi/templr/pkg/lint"
+)
+
+// wsIssue is an action that leaves stray whitespace in the rendered output.
+type wsIssue struct {
+	line, column int
+	message      string
+	fixAt        int    // byte offset where fix is inserted, -1 when there is no exact fix
+	fix          string // trim marker text to insert
+}
+
+// wsItem is a piece of a source line: text without newlines, or one action.
+type wsItem struct {
+	tok    fmtToken
+	offset int
+}
+
+// wsLine is one source line; newline is the offset of its "\n" (-1 for the last line).
+type wsLine struct {
+	items   []wsItem
+	start   int
+	newline int
+}
+
+// whitespaceRule reports actions that leave blank lines or trailing spaces in
+// the output, suggesting the {{- / -}} trim markers that avoid them.
+func whitespaceRule(ldelim, rdelim string) lint.Rule {
+	return lint.RuleFunc{RuleName: "whitespace", Fn: func(_ *parse.Tree, ctx *lint.Context) []lint.Issue {
+		if ctx.Source == nil {
+			return nil
+		}
+		found, err := whitespaceIssues(string(ctx.Source), ldelim, rdelim)
+		if err != nil {
+			return nil
+		}
+		issues := make([]lint.Issue, 0, len(found))
+		for _, w := range found {
+			issues = append(issues, lint.Issue{
+				Severity: lint.SeverityWarn,
+				Category: "whitespace",
+				File:     ctx.File,
+				Line:     w.line,
+				Column:   w.column,
+				Message:  w.message,
+			})
+		}
+		return issues
+	}}
+}
+
+// fixWhitespace applies the exact fixes of whitespaceIssues to src and
+// returns the result with the number of fixes applied.
+func fixWhitespace(src, ldelim, rdelim string) (string, int) {
+	found, err := whitespaceIssues(src, ldelim, rdelim)
+	if err != nil {
+		return src, 0
+	}
+	var fixes []wsIssue
+	for _, w := range found {
+		if w.fixAt >= 0 {
+			fixes = append(fixes, w)
+		}
+	}
+	sort.Slice(fixes, func(i, j int) bool { return fixes[i].fixAt > fixes[j].fixAt })
+	for _, f := range fixes {
+		src = src[:f.fixAt] + f.fix + src[f.fixAt:]
+	}
+	return src, len(fixes)
+}
+
+// lintFixFile applies the whitespace fixes to the template at path, rewriting
+// it when anything changed, and returns the (possibly fixed) content.
+func lintFixFile(path string, content []byte, opts LintOptions) ([]byte, error) {
+	fixed, n := fixWhitespace(string(content), opts.Shared.Ldelim, opts.Shared.Rdelim)
+	if n == 0 {
+		return content, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writeIfChanged(path, []byte(fixed), info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("write %s: %w", path, err)
+	}
+	fmt.Fprintf(sink.Stderr(), "fixed %d whitespace issue%s in %s\n", n, pluralize(n), path)
+	return []byte(fixed), nil
+}
+
+// whitespaceIssues finds lines whose actions produce no output but leave
+// whitespace behind: lines holding only such actions become blank lines, and
+// such actions at the end of a line leave the spaces before them. Lines whose
+// whitespace is already removed by a trim marker are not reported.
+func whitespaceIssues(src, ldelim, rdelim string) ([]wsIssue, error) {
+	tokens, err := fmtTokenize(src, ldelim, rdelim)
+	if err != nil {
+		return nil, err
+	}
+	lines := splitWsLines(tokens)
+	defines := defineEdges(lines)
+
+	var issues []wsIssue
+	for i, ln := range lines {
+		if ln.newline < 0 {
+			continue
+		}
+		content := ln.contentItems()
+		if len(content) == 0 {
+			continue
+		}
+		first, last := content[0], content[len(content)-1]
+		trailEaten := (last.tok.action && last.tok.rtrim) ||
+			(i+1 < len(lines) && lines[i+1].startsWithLeftTrim())
+
+		if ln.allSilent() {
+			leadEaten := (first.tok.ltrim) || (i > 0 && lines[i-1].endsWithRightTrim())
+			if leadEaten || trailEaten {
+				continue
+			}
+			w := wsIssue{line: strings.Count(src[:first.offset], "\n") + 1, column: first.offset - ln.start + 1, fixAt: -1}
+			// The whitespace before a define or after its end belongs to
+			// another template, so those lines are trimmed on the right
+			if i == 0 || defines[first.offset] || defines[last.offset] {
+				w.message = fmt.Sprintf("%s leaves a blank line in the output; use %s", last.tok.text, withRightTrim(last.tok, rdelim))
+				if next := ln.newline + 1; next < len(src) && !isSpaceByte(src[next]) {
+					w.fixAt, w.fix = rightTrimFix(src, last, rdelim)
+				}
+			} else {
+				w.message = fmt.Sprintf("%s leaves a blank line in the output; use %s", first.tok.text, withLeftTrim(first.tok, ldelim))
+				if len(lines[i-1].contentItems()) > 0 {
+					w.fixAt, w.fix = leftTrimFix(src, first, ldelim)
+				}
+			}
+			issues = append(issues, w)
+			continue
+		}
+
+		// Silent actions ending a line with content leave the spaces before them
+		if trailEaten || strings.Trim(ln.tail(), "\r") != "" {
+			continue
+		}
+		k := len(ln.items)
+		for k > 0 && (ln.items[k-1].tok.action && silentAction(ln.items[k-1].tok) || isBlankText(ln.items[k-1].tok)) {
+			k--
+		}
+		for k < len(ln.items) && !ln.items[k].tok.action {
+			k++
+		}
+		if k == 0 || k >= len(ln.items) || ln.items[k].tok.ltrim {
+			continue
+		}
+		before := ln.items[k-1].tok
+		if before.action || !strings.HasSuffix(before.text, " ") && !strings.HasSuffix(before.text, "\t") {
+			continue
+		}
+		act := ln.items[k]
+		fixAt, fix := leftTrimFix(src, act, ldelim)
+		issues = append(issues, wsIssue{
+			line:    strings.Count(src[:act.offset], "\n") + 1,
+			column:  act.offset - ln.start + 1,
+			message: fmt.Sprintf("trailing whitespace before %s in the output; use %s", act.tok.text, withLeftTrim(act.tok, ldelim)),
+			fixAt:   fixAt,
+			fix:     fix,
+		})
+	}
+	return issues, nil
+}
+
+// splitWsLines splits tokens at the newlines of their text.
+func splitWsLines(tokens []fmtToken) []wsLine {
+	var lines []wsLine
+	cur := wsLine{}
+	off := 0
+	for _, tok := range tokens {
+		if tok.action {
+			cur.items = append(cur.items, wsItem{tok: tok, offset: off})
+			off += len(tok.text)
+			continue
+		}
+		text := tok.text
+		for {
+			i := strings.IndexByte(text, '\n')
+			if i < 0 {
+				if text != "" {
+					cur.items = append(cur.items, wsItem{tok: fmtToken{text: text}, offset: off})
+				}
+				off += len(text)
+				break
+			}
+			if i > 0 {
+				cur.items = append(cur.items, wsItem{tok: fmtToken{text: text[:i]}, offset: off})
+			}
+			off += i
+			cur.newline = off
+			lines = append(lines, cur)
+			off++
+			text = text[i+1:]
+			cur = wsLine{start: off}
+		}
+	}
+	cur.newline = -1
+	return append(lines, cur)
+}
+
+// defineEdges returns the offsets of define actions and of the end actions
+// closing them.
+func defineEdges(lines []wsLine) map[int]bool {
+	edges := map[int]bool{}
+	var open []string
+	for _, ln := range lines {
+		for _, it := range ln.items {
+			if !it.tok.action || it.tok.comment {
+				continue
+			}
+			keyword := strings.Fields(it.tok.body + " ")[0]
+			switch keyword {
+			case "if", "range", "with", "block", "define":
+				open = append(open, keyword)
+				edges[it.offset] = keyword == "define"
+			case "end":
+				if len(open) > 0 {
+					edges[it.offset] = open[len(open)-1] == "define"
+					open = open[:len(open)-1]
+				}
+			}
+		}
+	}
+	return edges
+}
+
+// contentItems returns the actions and non-blank text of the line.
+func (l wsLine) contentItems() []wsItem {
+	var items []wsItem
+	for _, it := range l.items {
+		if it.tok.action || !isBlankText(it.tok) {
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+// allSilent reports whether the line holds only actions that produce no output.
+func (l wsLine) allSilent() bool {
+	for _, it := range l.contentItems() {
+		if !it.tok.action || !silentAction(it.tok) {
+			return false
+		}
+	}
+	return true
+}
+
+// tail returns the text after the last action of the line.
+func (l wsLine) tail() string {
+	var s string
+	for _, it := range l.items {
+		if it.tok.action {
+			s = ""
+		} else {
+			s += it.tok.text
+		}
+	}
+	return s
+}
+
+func (l wsLine) startsWithLeftTrim() bool {
+	c := l.contentItems()
+	return len(c) > 0 && c[0].tok.action && c[0].tok.ltrim
+}
+
+func (l wsLine) endsWithRightTrim() bool {
+	c := l.contentItems()
+	return len(c) > 0 && c[len(c)-1].tok.action && c[len(c)-1].tok.rtrim
+}
+
+func isBlankText(tok fmtToken) bool {
+	return !tok.action && strings.Trim(tok.text, " \t\r") == ""
+}
+
+// silentAction reports whether an action never writes output: comments,
+// control structures and variable declarations or assignments.
+func silentAction(tok fmtToken) bool {
+	if tok.comment {
+		return true
+	}
+	fields := strings.Fields(fmtActionBody(tok.body))
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "if", "else", "end", "range", "with", "define", "block", "break", "continue":
+		return true
+	}
+	return strings.HasPrefix(fields[0], "$") && len(fields) > 1 && (fields[1] == ":=" || fields[1] == "=")
+}
+
+// leftTrimFix returns where and what to insert to give an action a "{{- " marker.
+func leftTrimFix(src string, it wsItem, ldelim string) (int, string) {
+	at := it.offset + len(ldelim)
+	if at < len(src) && isSpaceByte(src[at]) {
+		return at, "-"
+	}
+	return at, "- "
+}
+
+// rightTrimFix returns where and what to insert to give an action a " -}}" marker.
+func rightTrimFix(src string, it wsItem, rdelim string) (int, string) {
+	at := it.offset + len(it.tok.text) - len(rdelim)
+	if at > 0 && isSpaceByte(src[at-1]) {
+		return at, "-"
+	}
+	return at, " -"
+}
+
+func withLeftTrim(tok fmtToken, ldelim string) string {
+	it := wsItem{tok: tok}
+	at, fix := leftTrimFix(tok.text, it, ldelim)
+	return tok.text[:at] + fix + tok.text[at:]
+}
+
+func withRightTrim(tok fmtToken, rdelim string) string {
+	it := wsItem{tok: tok}
+	at, fix := rightTrimFix(tok.text, it, rdelim)
+	return tok.text[:at] + fix + tok.text[at:]
+}
@@ -0,0 +1,222 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// missingProbeFunc is the function --explain-missing calls in place of the
+// field references of the templates it probes.
+const missingProbeFunc = "templrMissingProbe"
+
+// missingRef is an undefined value reference found by --explain-missing.
+type missingRef struct {
+	expr      string
+	templates map[string]bool
+	locations []string // "template:line", in discovery order
+}
+
+// missingRefs collects undefined references across the templates of one command.
+type missingRefs struct {
+	refs  map[string]*missingRef
+	order []string
+}
+
+func newMissingRefs() *missingRefs {
+	return &missingRefs{refs: map[string]*missingRef{}}
+}
+
+// collect executes template name once more to record every undefined
+// reference a non-strict render silently printed as a default. Each field
+// reference .a.b of the templates of tpl becomes (templrMissingProbe . n).a.b:
+// the probe records the first key the maps along a.b lack and returns dot,
+// so the reference evaluates as in the render. The rendered output is
+// discarded and the templates of tpl are restored.
+func (m *missingRefs) collect(tpl *template.Template, name string, values map[string]any, rootLabel string) {
+	p := &missingProber{}
+	for _, t := range tpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		tree := t.Tree
+		defer func() { t.Tree = tree }()
+		t.Tree = tree.Copy()
+		p.tree = t.Tree
+		p.node(t.Tree.Root)
+	}
+	tpl.Funcs(template.FuncMap{missingProbeFunc: func(dot any, id int) any {
+		ref := p.refs[id]
+		if lacksKey(dot, ref.path) {
+			tplName := ref.template
+			if tplName == "root" && rootLabel != "" {
+				tplName = rootLabel
+			}
+			m.add(ref.expr, tplName, ref.line)
+		}
+		return dot
+	}})
+	probe, _ := copyValues(values).(map[string]any)
+	_, _ = renderToBuffer(tpl, name, probe, SharedOptions{})
+}
+
+func (m *missingRefs) add(expr, tplName string, line int) {
+	ref, ok := m.refs[expr]
+	if !ok {
+		ref = &missingRef{expr: expr, templates: map[string]bool{}}
+		m.refs[expr] = ref
+		m.order = append(m.order, expr)
+	}
+	ref.templates[tplName] = true
+	loc := fmt.Sprintf("%s:%d", tplName, line)
+	for _, l := range ref.locations {
+		if l == loc {
+			return
+		}
+	}
+	ref.locations = append(ref.locations, loc)
+}
+
+// report prints one warning per undefined reference, most referenced first.
+func (m *missingRefs) report() {
+	refs := make([]*missingRef, 0, len(m.order))
+	for _, expr := range m.order {
+		refs = append(refs, m.refs[expr])
+	}
+	sort.SliceStable(refs, func(i, j int) bool { return len(refs[i].templates) > len(refs[j].templates) })
+	for _, ref := range refs {
+		noun := "templates"
+		if len(ref.templates) == 1 {
+			noun = "template"
+		}
+		warnf("missing", "%d %s referenced %s which is undefined (%s)", len(ref.templates), noun, ref.expr, strings.Join(ref.locations, ", "))
+	}
+	if len(refs) > 0 {
+		warnf("missing", "%d undefined reference(s); run with --strict to fail on them", len(refs))
+	}
+}
+
+// probedRef is a field reference replaced by a probe.
+type probedRef struct {
+	expr     string   // ".a.b" or "$.a.b"
+	path     []string // a, b
+	template string
+	line     int
+}
+
+// missingProber replaces the field references of parse trees with probes.
+type missingProber struct {
+	tree *parse.Tree // tree being probed, for the positions of references
+	refs []probedRef // indexed by the probe argument
+}
+
+func (p *missingProber) node(n parse.Node) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			p.node(c)
+		}
+	case *parse.ActionNode:
+		p.pipe(n.Pipe)
+	case *parse.IfNode:
+		p.branch(&n.BranchNode)
+	case *parse.RangeNode:
+		p.branch(&n.BranchNode)
+	case *parse.WithNode:
+		p.branch(&n.BranchNode)
+	case *parse.TemplateNode:
+		p.pipe(n.Pipe)
+	}
+}
+
+func (p *missingProber) branch(b *parse.BranchNode) {
+	p.pipe(b.Pipe)
+	p.node(b.List)
+	if b.ElseList != nil {
+		p.node(b.ElseList)
+	}
+}
+
+func (p *missingProber) pipe(pipe *parse.PipeNode) {
+	if pipe == nil {
+		return
+	}
+	for _, cmd := range pipe.Cmds {
+		for i, arg := range cmd.Args {
+			cmd.Args[i] = p.arg(arg)
+		}
+	}
+}
+
+// arg returns the probed form of a command argument: a field reference
+// becomes a chain on the probe, and nested pipelines are probed.
+func (p *missingProber) arg(arg parse.Node) parse.Node {
+	switch a := arg.(type) {
+	case *parse.FieldNode:
+		return p.probe(a, a.Ident, &parse.DotNode{NodeType: parse.NodeDot, Pos: a.Pos})
+	case *parse.VariableNode:
+		if len(a.Ident) > 1 && a.Ident[0] == "$" {
+			return p.probe(a, a.Ident[1:], &parse.VariableNode{NodeType: parse.NodeVariable, Pos: a.Pos, Ident: []string{"$"}})
+		}
+	case *parse.PipeNode:
+		p.pipe(a)
+	case *parse.ChainNode:
+		a.Node = p.arg(a.Node)
+	}
+	return arg
+}
+
+// probe returns (templrMissingProbe from n).path for the reference ref.
+func (p *missingProber) probe(ref parse.Node, path []string, from parse.Node) parse.Node {
+	loc, _ := p.tree.ErrorContext(ref) // "name:line:col"
+	r := probedRef{expr: ref.String(), path: path}
+	if i := strings.LastIndexByte(loc, ':'); i > 0 {
+		if j := strings.LastIndexByte(loc[:i], ':'); j > 0 {
+			r.template = loc[:j]
+			r.line, _ = strconv.Atoi(loc[j+1 : i])
+		}
+	}
+	id := len(p.refs)
+	p.refs = append(p.refs, r)
+
+	pos := ref.Position()
+	call := &parse.CommandNode{NodeType: parse.NodeCommand, Pos: pos, Args: []parse.Node{
+		parse.NewIdentifier(missingProbeFunc).SetPos(pos),
+		from,
+		&parse.NumberNode{NodeType: parse.NodeNumber, Pos: pos, IsInt: true, Int64: int64(id), Text: strconv.Itoa(id)},
+	}}
+	return &parse.ChainNode{
+		NodeType: parse.NodeChain,
+		Pos:      pos,
+		Node:     &parse.PipeNode{NodeType: parse.NodePipe, Pos: pos, Line: r.line, Cmds: []*parse.CommandNode{call}},
+		Field:    path,
+	}
+}
+
+// lacksKey reports whether a map reached through path from dot lacks the
+// next key of path. Values other than maps (nil, structs, methods) end the
+// check: the render reports or resolves them itself.
+func lacksKey(dot any, path []string) bool {
+	v := reflect.ValueOf(dot)
+	for _, key := range path {
+		for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) && !v.IsNil() {
+			v = v.Elem()
+		}
+		if !v.IsValid() || v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+			return false
+		}
+		next := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+		if !next.IsValid() {
+			return true
+		}
+		v = next
+	}
+	return false
+}
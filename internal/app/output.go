@@ -0,0 +1,102 @@
+package app
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// outputSink is the single writer for what templr prints while rendering:
+// status lines on stdout, warnings and errors on stderr. Every message is
+// written under one lock, and a messageGroup collects the messages about one
+// file and writes them together, so that lines of concurrent renders (or a
+// file's warnings and the next file's status line) never interleave.
+type outputSink struct {
+	mu     sync.Mutex
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var sink = &outputSink{stdout: os.Stdout, stderr: os.Stderr}
+
+// Stdout returns a writer for status lines. Each Write is atomic, so a
+// message must be written with a single call (fmt.Fprintf does).
+func (s *outputSink) Stdout() io.Writer { return sinkWriter{s: s} }
+
+// Stderr returns a writer for warnings and errors.
+func (s *outputSink) Stderr() io.Writer { return sinkWriter{s: s, stderr: true} }
+
+func (s *outputSink) write(stderr bool, p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stderr {
+		return s.stderr.Write(p)
+	}
+	return s.stdout.Write(p)
+}
+
+type sinkWriter struct {
+	s      *outputSink
+	stderr bool
+}
+
+func (w sinkWriter) Write(p []byte) (int, error) { return w.s.write(w.stderr, p) }
+
+// messageWriter is where the messages about an output go: the sink itself,
+// or the group of the file being rendered.
+type messageWriter interface {
+	Stdout() io.Writer
+	Stderr() io.Writer
+}
+
+// group starts collecting messages to be written together by flush.
+func (s *outputSink) group() *messageGroup {
+	return &messageGroup{s: s}
+}
+
+// messageGroup buffers the messages about one file in order. A group is
+// used by one goroutine.
+type messageGroup struct {
+	s    *outputSink
+	msgs []groupMessage
+}
+
+type groupMessage struct {
+	stderr bool
+	text   []byte
+}
+
+// Stdout returns a writer whose messages go to stdout on flush.
+func (g *messageGroup) Stdout() io.Writer { return groupWriter{g: g} }
+
+// Stderr returns a writer whose messages go to stderr on flush.
+func (g *messageGroup) Stderr() io.Writer { return groupWriter{g: g, stderr: true} }
+
+// flush writes the collected messages in order, without messages from
+// elsewhere in between.
+func (g *messageGroup) flush() {
+	if len(g.msgs) == 0 {
+		return
+	}
+	g.s.mu.Lock()
+	defer g.s.mu.Unlock()
+	for _, m := range g.msgs {
+		if m.stderr {
+			_, _ = g.s.stderr.Write(m.text)
+		} else {
+			_, _ = g.s.stdout.Write(m.text)
+		}
+	}
+	g.msgs = nil
+}
+
+type groupWriter struct {
+	g      *messageGroup
+	stderr bool
+}
+
+func (w groupWriter) Write(p []byte) (int, error) {
+	w.g.msgs = append(w.g.msgs, groupMessage{stderr: w.stderr, text: bytes.Clone(p)})
+	return len(p), nil
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Path styles for the output paths templr reports (--path-style).
+const (
+	pathStyleSlash  = "slash"  // forward slashes on every platform (default)
+	pathStyleNative = "native" // the platform separator, backslashes on Windows
+)
+
+// checkPathStyle rejects an unknown --path-style.
+func checkPathStyle(shared SharedOptions) error {
+	switch shared.PathStyle {
+	case "", pathStyleSlash, pathStyleNative:
+		return nil
+	}
+	return argsError(fmt.Errorf("unknown --path-style %q (want %s or %s)", shared.PathStyle, pathStyleSlash, pathStyleNative))
+}
+
+// displayPath formats a path for status lines, warnings and step summaries:
+// with forward slashes unless --path-style native, so the output of a run
+// on Windows compares equal to the same run elsewhere. Recorded paths
+// (provenance subjects, remote manifests, audit records) always use slashes.
+func displayPath(p string, shared SharedOptions) string {
+	if shared.PathStyle == pathStyleNative {
+		return p
+	}
+	return filepath.ToSlash(p)
+}
+
+// skipLink reports whether the template walk skips the entry at p: a
+// symbolic link or, on Windows, a junction, that points to a directory
+// (walks never descend into one, so links cannot make them loop) or to
+// nothing, which is warned about. Links to files are read through.
+func skipLink(p string, d fs.DirEntry) bool {
+	if d.Type()&(fs.ModeSymlink|fs.ModeIrregular) == 0 {
+		return false
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		warnf("link", "skip %s: broken link", filepath.ToSlash(p))
+		return true
+	}
+	return info.IsDir()
+}
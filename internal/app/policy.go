@@ -0,0 +1,330 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy modes accepted by --policy-mode.
+const (
+	PolicyEnforce = "enforce" // report violations as errors and do not write the file
+	PolicyWarn    = "warn"    // report violations as warnings and write the file
+)
+
+// policyRule is one rule of a templr policy file. A rule checks the outputs
+// matching Files with either an assertion or a JSON Schema.
+type policyRule struct {
+	ID      string         `yaml:"id"`
+	Files   []string       `yaml:"files"`   // output path globs; all outputs when empty
+	Assert  string         `yaml:"assert"`  // template expression that must render "true"
+	Schema  map[string]any `yaml:"schema"`  // JSON Schema the parsed output must satisfy
+	Message string         `yaml:"message"` // reported on violation
+
+	tpl    *template.Template
+	schema *jsonschema.Schema
+}
+
+// policySet holds the policies given with --policy: templr rule files, Rego
+// policies evaluated with the opa CLI and CUE constraints checked with the
+// cue CLI.
+type policySet struct {
+	mode  string
+	rules []*policyRule
+	rego  []string
+	cue   []string
+}
+
+// policyViolation is a violated rule of a policy.
+type policyViolation struct {
+	rule    string
+	message string
+}
+
+func (p *policySet) empty() bool {
+	return p == nil || len(p.rules)+len(p.rego)+len(p.cue) == 0
+}
+
+// loadPolicies loads the policy files and directories of --policy. In a
+// directory, .yaml/.yml/.json files are templr rule files, .rego files Rego
+// policies and .cue files CUE constraints; other files are ignored.
+func loadPolicies(shared SharedOptions) (*policySet, error) {
+	p := &policySet{mode: shared.PolicyMode}
+	if p.mode == "" {
+		p.mode = PolicyEnforce
+	}
+	if p.mode != PolicyEnforce && p.mode != PolicyWarn {
+		return nil, argsError(fmt.Errorf("invalid --policy-mode %q (want %s or %s)", p.mode, PolicyEnforce, PolicyWarn))
+	}
+
+	for _, root := range shared.Policies {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, argsError(fmt.Errorf("policy: %w", err))
+		}
+		files := []string{root}
+		if info.IsDir() {
+			files = nil
+			err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					files = append(files, path)
+				}
+				return err
+			})
+			if err != nil {
+				return nil, fmt.Errorf("read policies %s: %w", root, err)
+			}
+			sort.Strings(files)
+		}
+		for _, f := range files {
+			switch strings.ToLower(filepath.Ext(f)) {
+			case ".rego":
+				p.rego = append(p.rego, f)
+			case ".cue":
+				p.cue = append(p.cue, f)
+			case ".yaml", ".yml", ".json":
+				rules, err := loadPolicyFile(f, shared)
+				if err != nil {
+					return nil, argsError(err)
+				}
+				p.rules = append(p.rules, rules...)
+			default:
+				if !info.IsDir() {
+					return nil, argsError(fmt.Errorf("policy %s: unsupported file type (want .yaml, .json, .rego or .cue)", f))
+				}
+			}
+		}
+	}
+
+	if len(p.rego) > 0 {
+		if _, err := exec.LookPath("opa"); err != nil {
+			return nil, argsError(fmt.Errorf("rego policies need the opa CLI on PATH: %w", err))
+		}
+	}
+	if len(p.cue) > 0 {
+		if _, err := exec.LookPath("cue"); err != nil {
+			return nil, argsError(fmt.Errorf("cue policies need the cue CLI on PATH: %w", err))
+		}
+	}
+	return p, nil
+}
+
+// loadPolicyFile parses and compiles the rules of a templr policy file.
+func loadPolicyFile(path string, shared SharedOptions) ([]*policyRule, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	var doc struct {
+		Rules []*policyRule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("policy %s: %w", path, err)
+	}
+	for i, r := range doc.Rules {
+		if r.ID == "" {
+			return nil, fmt.Errorf("policy %s: rule %d has no id", path, i+1)
+		}
+		if (r.Assert == "") == (r.Schema == nil) {
+			return nil, fmt.Errorf("policy %s: rule %s needs either assert or schema", path, r.ID)
+		}
+		if r.Assert != "" {
+			if r.tpl, err = parseAssertion(r.Assert, shared); err != nil {
+				return nil, fmt.Errorf("policy %s: rule %s: %w", path, r.ID, err)
+			}
+			continue
+		}
+		compiler := jsonschema.NewCompiler()
+		if err := compiler.AddResource("policy.json", r.Schema); err != nil {
+			return nil, fmt.Errorf("policy %s: rule %s: %w", path, r.ID, err)
+		}
+		if r.schema, err = compiler.Compile("policy.json"); err != nil {
+			return nil, fmt.Errorf("policy %s: rule %s: %w", path, r.ID, err)
+		}
+	}
+	return doc.Rules, nil
+}
+
+// check evaluates the policies for one rendered file and reports each
+// violation to w. It returns the number of violations that block the file, which
+// is zero in warn mode.
+func (p *policySet) check(ctx assertContext, w io.Writer) int {
+	if p.empty() {
+		return 0
+	}
+	var violations []policyViolation
+	for _, r := range p.rules {
+		if len(r.Files) > 0 && !matchOutputPath(r.Files, ctx.Path) {
+			continue
+		}
+		if msg, ok := r.evaluate(ctx); !ok {
+			violations = append(violations, policyViolation{rule: r.ID, message: msg})
+		}
+	}
+	if len(p.rego) > 0 {
+		violations = append(violations, p.checkRego(ctx)...)
+	}
+	if len(p.cue) > 0 {
+		violations = append(violations, p.checkCUE(ctx)...)
+	}
+
+	for _, v := range violations {
+		if p.mode == PolicyWarn {
+			writeWarning(w, "policy", "%s: %s: %s", ctx.Path, v.rule, v.message)
+		} else {
+			writeCheckError(w, "policy", "%s: %s: %s", ctx.Path, v.rule, v.message)
+		}
+	}
+	if p.mode == PolicyWarn {
+		return 0
+	}
+	return len(violations)
+}
+
+// evaluate checks one output against a templr rule; the message describes a
+// violation.
+func (r *policyRule) evaluate(ctx assertContext) (string, bool) {
+	if r.tpl != nil {
+		holds, err := evalAssertion(r.tpl, ctx)
+		switch {
+		case err != nil:
+			return err.Error(), false
+		case !holds:
+			return r.describe("assertion failed: " + r.Assert), false
+		}
+		return "", true
+	}
+
+	if ctx.Data == nil {
+		return r.describe("output is not YAML or JSON"), false
+	}
+	if err := r.schema.Validate(ctx.Data); err != nil {
+		var ve *jsonschema.ValidationError
+		if errors.As(err, &ve) {
+			return r.describe(strings.Join(schemaViolations(ve, nil), "; ")), false
+		}
+		return r.describe(err.Error()), false
+	}
+	return "", true
+}
+
+// schemaViolations lists the leaf errors of a validation error as
+// "<path>: <message>".
+func schemaViolations(ve *jsonschema.ValidationError, out []string) []string {
+	if len(ve.Causes) == 0 {
+		msg := ve.ErrorKind.LocalizedString(message.NewPrinter(language.English))
+		return append(out, formatPathSegments(ve.InstanceLocation)+": "+msg)
+	}
+	for _, cause := range ve.Causes {
+		out = schemaViolations(cause, out)
+	}
+	return out
+}
+
+// describe prefixes detail with the rule's message, if it has one.
+func (r *policyRule) describe(detail string) string {
+	if r.Message == "" {
+		return detail
+	}
+	return r.Message + " (" + detail + ")"
+}
+
+// checkRego evaluates data.templr.deny of the Rego policies with the opa CLI.
+// The input document has the path, output and parsed data of the file; deny
+// holds message strings or objects with "id" and "msg".
+func (p *policySet) checkRego(ctx assertContext) []policyViolation {
+	input, err := json.Marshal(map[string]any{"path": ctx.Path, "output": ctx.Output, "data": ctx.Data})
+	if err != nil {
+		return []policyViolation{{rule: "rego", message: err.Error()}}
+	}
+	args := []string{"eval", "--format", "json", "--stdin-input"}
+	for _, f := range p.rego {
+		args = append(args, "--data", f)
+	}
+	args = append(args, "data.templr.deny")
+	cmd := exec.Command("opa", args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return []policyViolation{{rule: "rego", message: fmt.Sprintf("opa eval: %v: %s", err, strings.TrimSpace(stderr.String()))}}
+	}
+
+	var res struct {
+		Result []struct {
+			Expressions []struct {
+				Value []any `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		return []policyViolation{{rule: "rego", message: fmt.Sprintf("opa eval output: %v", err)}}
+	}
+	var violations []policyViolation
+	for _, r := range res.Result {
+		for _, e := range r.Expressions {
+			for _, v := range e.Value {
+				pv := policyViolation{rule: "templr.deny", message: fmt.Sprint(v)}
+				if m, ok := v.(map[string]any); ok {
+					if id, ok := m["id"].(string); ok {
+						pv.rule = id
+					}
+					if msg, ok := m["msg"].(string); ok {
+						pv.message = msg
+					}
+				}
+				violations = append(violations, pv)
+			}
+		}
+	}
+	return violations
+}
+
+// checkCUE validates the parsed output against the CUE constraints with
+// `cue vet -c`.
+func (p *policySet) checkCUE(ctx assertContext) []policyViolation {
+	if ctx.Data == nil {
+		return []policyViolation{{rule: "cue", message: "output is not YAML or JSON"}}
+	}
+	b, err := json.Marshal(ctx.Data)
+	if err != nil {
+		return []policyViolation{{rule: "cue", message: err.Error()}}
+	}
+	tmp, err := os.CreateTemp("", "templr-policy-*.json")
+	if err != nil {
+		return []policyViolation{{rule: "cue", message: err.Error()}}
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	_, werr := tmp.Write(b)
+	if cerr := tmp.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		return []policyViolation{{rule: "cue", message: werr.Error()}}
+	}
+
+	args := append(append([]string{"vet", "-c"}, p.cue...), tmp.Name())
+	out, err := exec.Command("cue", args...).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(strings.ReplaceAll(string(out), tmp.Name(), ctx.Path))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return []policyViolation{{rule: "cue", message: msg}}
+	}
+	return nil
+}
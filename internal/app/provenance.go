@@ -0,0 +1,436 @@
+package app
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+	"unsafe"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+// Provenance statements follow the in-toto attestation format with a SLSA
+// provenance predicate; signed ones are wrapped in a DSSE envelope.
+const (
+	inTotoStatementType   = "https://in-toto.io/Statement/v1"
+	slsaProvenanceType    = "https://slsa.dev/provenance/v1"
+	provenanceBuildType   = "https://github.com/kanopi/templr/walk/v1"
+	provenanceBuilderID   = "https://github.com/kanopi/templr"
+	provenancePayloadType = "application/vnd.in-toto+json"
+)
+
+// resourceDescriptor names a file and its digest. The "kind" annotation
+// tells templates (relative to src) from values files (relative to the
+// working directory).
+type resourceDescriptor struct {
+	Name        string            `json:"name"`
+	Digest      map[string]string `json:"digest"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// provenanceStatement is an in-toto statement whose subjects are the files
+// a walk produced.
+type provenanceStatement struct {
+	Type          string               `json:"_type"`
+	Subject       []resourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     provenancePredicate  `json:"predicate"`
+}
+
+type provenancePredicate struct {
+	BuildDefinition struct {
+		BuildType          string               `json:"buildType"`
+		ExternalParameters provenanceParameters `json:"externalParameters"`
+		// templates and values files the outputs were rendered from
+		ResolvedDependencies []resourceDescriptor `json:"resolvedDependencies"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID      string            `json:"id"`
+			Version map[string]string `json:"version"`
+		} `json:"builder"`
+		Metadata struct {
+			StartedOn  string `json:"startedOn"`
+			FinishedOn string `json:"finishedOn"`
+		} `json:"metadata"`
+	} `json:"runDetails"`
+}
+
+type provenanceParameters struct {
+	Src  string   `json:"src"`
+	Dst  string   `json:"dst"`
+	Sets []string `json:"sets,omitempty"`
+}
+
+// dsseEnvelope is a signed provenance statement.
+type dsseEnvelope struct {
+	PayloadType string          `json:"payloadType"`
+	Payload     string          `json:"payload"`
+	Signatures  []dsseSignature `json:"signatures"`
+}
+
+type dsseSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// VerifyOptions contains options for `templr verify`
+type VerifyOptions struct {
+	Provenance string // provenance file written by walk --provenance
+	Key        string // Ed25519 public (or private) key PEM checking the signature
+	Src        string // template directory (default: the recorded --src)
+	Dst        string // output directory (default: the recorded --dst)
+}
+
+// sha256Digest returns the in-toto digest set of b.
+func sha256Digest(b []byte) map[string]string {
+	sum := sha256.Sum256(b)
+	return map[string]string{"sha256": hex.EncodeToString(sum[:])}
+}
+
+// sha256StringDigest is sha256Digest of s, hashed in place rather than
+// copied into a []byte; the hash only reads it.
+func sha256StringDigest(s string) map[string]string {
+	return sha256Digest(unsafe.Slice(unsafe.StringData(s), len(s)))
+}
+
+func fileDigest(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return sha256Digest(b), nil
+}
+
+// valuesInputs lists the values files a run reads: the default values.yaml
+// of baseDir, --data and -f.
+func valuesInputs(baseDir string, shared SharedOptions) []string {
+	var files []string
+	for _, name := range []string{"values.yaml", "values.yml"} {
+		p := filepath.Join(baseDir, name)
+		if _, err := os.Stat(p); err == nil {
+			files = append(files, p)
+			break
+		}
+	}
+	if shared.Data != "" {
+		files = append(files, shared.Data)
+	}
+	return append(files, shared.Files...)
+}
+
+// walkInputs describes the templates and values files a walk reads, in
+// the order provenance statements list them.
+func walkInputs(opts WalkOptions, absSrc string, names []string) ([]resourceDescriptor, error) {
+	deps := []resourceDescriptor{}
+	for _, name := range names {
+		digest, err := fileDigest(filepath.Join(absSrc, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, resourceDescriptor{Name: name, Digest: digest, Annotations: map[string]string{"kind": "template"}})
+	}
+	for _, f := range valuesInputs(opts.Src, opts.Shared) {
+		digest, err := fileDigest(f)
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, resourceDescriptor{Name: filepath.ToSlash(f), Digest: digest, Annotations: map[string]string{"kind": "values"}})
+	}
+	return deps, nil
+}
+
+// checkProvenanceOptions fails before rendering if the provenance options
+// of a walk are unusable.
+func checkProvenanceOptions(opts WalkOptions) error {
+	if opts.ProvenanceKey == "" {
+		return nil
+	}
+	if opts.Provenance == "" {
+		return argsError(fmt.Errorf("--provenance-key requires --provenance"))
+	}
+	if _, err := readSigningKey(opts.ProvenanceKey); err != nil {
+		return argsError(fmt.Errorf("provenance key: %w", err))
+	}
+	return nil
+}
+
+// writeProvenance records the inputs and written outputs of a walk in
+// opts.Provenance, signed with opts.ProvenanceKey if set.
+func writeProvenance(opts WalkOptions, absSrc string, names []string, records []renderRecord, started time.Time) error {
+	st := provenanceStatement{Type: inTotoStatementType, PredicateType: slsaProvenanceType, Subject: []resourceDescriptor{}}
+	bd := &st.Predicate.BuildDefinition
+	bd.BuildType = provenanceBuildType
+	bd.ExternalParameters = provenanceParameters{Src: opts.Src, Dst: opts.Dst, Sets: opts.Shared.Sets}
+	deps, err := walkInputs(opts, absSrc, names)
+	if err != nil {
+		return fmt.Errorf("provenance: %w", err)
+	}
+	bd.ResolvedDependencies = deps
+
+	absDst, _ := filepath.Abs(opts.Dst)
+	for _, r := range records {
+		switch r.Status {
+		case "rendered", "rendered (empty)", "unchanged":
+		default:
+			continue
+		}
+		digest, err := fileDigest(r.Output)
+		if err != nil {
+			return fmt.Errorf("provenance: %w", err)
+		}
+		rel, _ := filepath.Rel(absDst, r.Output)
+		st.Subject = append(st.Subject, resourceDescriptor{Name: filepath.ToSlash(rel), Digest: digest})
+	}
+	sort.Slice(st.Subject, func(i, j int) bool { return st.Subject[i].Name < st.Subject[j].Name })
+
+	rd := &st.Predicate.RunDetails
+	rd.Builder.ID = provenanceBuilderID
+	rd.Builder.Version = builderVersion()
+	rd.Metadata.StartedOn = started.UTC().Format(time.RFC3339)
+	rd.Metadata.FinishedOn = time.Now().UTC().Format(time.RFC3339)
+
+	payload, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	out := payload
+	if opts.ProvenanceKey != "" {
+		key, err := readSigningKey(opts.ProvenanceKey)
+		if err != nil {
+			return argsError(fmt.Errorf("provenance key: %w", err))
+		}
+		env := dsseEnvelope{
+			PayloadType: provenancePayloadType,
+			Payload:     base64.StdEncoding.EncodeToString(payload),
+			Signatures: []dsseSignature{{
+				KeyID: keyID(key.Public().(ed25519.PublicKey)),
+				Sig:   base64.StdEncoding.EncodeToString(ed25519.Sign(key, dssePAE(provenancePayloadType, payload))),
+			}},
+		}
+		if out, err = json.MarshalIndent(env, "", "  "); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(opts.Provenance, append(out, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write provenance: %w", err)
+	}
+	return nil
+}
+
+// builderVersion fingerprints the running templr for the provenance
+// statement: its version and the hash of its template function set.
+func builderVersion() map[string]string {
+	return map[string]string{"templr": GetVersion(), "functions": templr.FuncSetHash()}
+}
+
+// checkBuilderVersion warns when the outputs recorded in st were rendered by
+// another templr version or function set, which may render them differently.
+func checkBuilderVersion(st *provenanceStatement) {
+	recorded, current := st.Predicate.RunDetails.Builder.Version, builderVersion()
+	if v := recorded["templr"]; v != "" && v != current["templr"] {
+		warnf("verify", "outputs were rendered by templr %s, this is templr %s; re-render to rule out differences between versions", v, current["templr"])
+	}
+	if h := recorded["functions"]; h != "" && h != current["functions"] {
+		warnf("verify", "outputs were rendered with a different template function set (%s, now %s); functions may have been added, removed or changed", shortHash(h), shortHash(current["functions"]))
+	}
+}
+
+// shortHash abbreviates a "sha256:..." hash for messages.
+func shortHash(h string) string {
+	if len(h) > len("sha256:")+12 {
+		return h[:len("sha256:")+12]
+	}
+	return h
+}
+
+// dssePAE is the DSSE pre-authentication encoding that is signed.
+func dssePAE(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// keyID identifies a public key by the SHA-256 of its PKIX encoding.
+func keyID(pub ed25519.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// readSigningKey reads a PKCS#8 Ed25519 private key PEM, as written by
+// `openssl genpkey -algorithm ed25519`.
+func readSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an Ed25519 key", path)
+	}
+	return priv, nil
+}
+
+// readVerifyKey reads an Ed25519 public key PEM; a private key works too.
+func readVerifyKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	var key crypto.PublicKey
+	if block.Type == "PRIVATE KEY" {
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := priv.(crypto.Signer); ok {
+			key = s.Public()
+		}
+	} else if key, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+		return nil, err
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an Ed25519 key", path)
+	}
+	return pub, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	return block, nil
+}
+
+// readProvenance reads a provenance file, checking the signature of a DSSE
+// envelope when a key is given.
+func readProvenance(path, keyPath string) (*provenanceStatement, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, argsError(fmt.Errorf("read provenance: %w", err))
+	}
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return nil, exitError(ExitDataError, "data", fmt.Errorf("parse provenance %s: %w", path, err))
+	}
+
+	payload := b
+	if _, signed := probe["payloadType"]; signed {
+		var env dsseEnvelope
+		if err := json.Unmarshal(b, &env); err != nil {
+			return nil, exitError(ExitDataError, "data", fmt.Errorf("parse provenance %s: %w", path, err))
+		}
+		if payload, err = base64.StdEncoding.DecodeString(env.Payload); err != nil {
+			return nil, exitError(ExitDataError, "data", fmt.Errorf("parse provenance %s: %w", path, err))
+		}
+		if keyPath == "" {
+			warnf("verify", "%s is signed; pass --key to check the signature", path)
+		} else if err := checkSignature(env, payload, keyPath); err != nil {
+			return nil, exitError(ExitVerifyFailed, "verify", err)
+		}
+	} else if keyPath != "" {
+		return nil, exitError(ExitVerifyFailed, "verify", fmt.Errorf("%s is not signed", path))
+	}
+
+	var st provenanceStatement
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return nil, exitError(ExitDataError, "data", fmt.Errorf("parse provenance %s: %w", path, err))
+	}
+	if st.Type != inTotoStatementType || st.PredicateType != slsaProvenanceType {
+		return nil, exitError(ExitDataError, "data", fmt.Errorf("%s is not a templr provenance statement", path))
+	}
+	return &st, nil
+}
+
+func checkSignature(env dsseEnvelope, payload []byte, keyPath string) error {
+	pub, err := readVerifyKey(keyPath)
+	if err != nil {
+		return fmt.Errorf("verify key: %w", err)
+	}
+	msg := dssePAE(env.PayloadType, payload)
+	for _, s := range env.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err == nil && ed25519.Verify(pub, msg, sig) {
+			return nil
+		}
+	}
+	return errors.New("provenance signature does not match the key")
+}
+
+// RunVerify checks that the outputs recorded in a provenance file are
+// unchanged and were rendered from the current templates and values files.
+func RunVerify(opts VerifyOptions) error {
+	if opts.Provenance == "" {
+		return argsError(fmt.Errorf("verify requires --provenance"))
+	}
+	st, err := readProvenance(opts.Provenance, opts.Key)
+	if err != nil {
+		return err
+	}
+	params := st.Predicate.BuildDefinition.ExternalParameters
+	src, dst := opts.Src, opts.Dst
+	if src == "" {
+		src = params.Src
+	}
+	if dst == "" {
+		dst = params.Dst
+	}
+
+	var problems int
+	check := func(kind, name, path string, want map[string]string) {
+		got, err := fileDigest(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			checkErrorf("verify", "%s %s is missing", kind, name)
+		case err != nil:
+			checkErrorf("verify", "%s %s: %v", kind, name, err)
+		case got["sha256"] != want["sha256"]:
+			checkErrorf("verify", "%s %s has changed", kind, name)
+		default:
+			return
+		}
+		problems++
+	}
+	for _, s := range st.Subject {
+		check("output", s.Name, filepath.Join(dst, filepath.FromSlash(s.Name)), s.Digest)
+	}
+	for _, d := range st.Predicate.BuildDefinition.ResolvedDependencies {
+		if d.Annotations["kind"] == "template" {
+			check("template", d.Name, filepath.Join(src, filepath.FromSlash(d.Name)), d.Digest)
+		} else {
+			check("values file", d.Name, filepath.FromSlash(d.Name), d.Digest)
+		}
+	}
+	checkBuilderVersion(st)
+	if problems > 0 {
+		return exitError(ExitVerifyFailed, "verify", fmt.Errorf("%d file%s changed since %s was written", problems, pluralize(problems), opts.Provenance))
+	}
+	fmt.Printf("verified %d output%s and %d input%s against %s\n",
+		len(st.Subject), pluralize(len(st.Subject)),
+		len(st.Predicate.BuildDefinition.ResolvedDependencies), pluralize(len(st.Predicate.BuildDefinition.ResolvedDependencies)),
+		opts.Provenance)
+	return nil
+}
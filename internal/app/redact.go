@@ -0,0 +1,175 @@
+package app
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redacted replaces the values of sensitive keys wherever templr shows
+// values: --debug dumps, values diff, the audit log and error messages.
+const redacted = "[redacted]"
+
+// sensitiveKeyWords mark a key as sensitive without configuration when its
+// lower-cased name, without "_" and "-", contains one of them.
+var sensitiveKeyWords = []string{"password", "passwd", "secret", "token", "apikey", "privatekey"}
+
+// minSecretLen is the shortest value scrubbed out of messages; shorter ones
+// ("1", "yes") would mangle unrelated text.
+const minSecretLen = 4
+
+// activeSecrets holds the values of the sensitive keys of the values built
+// for the running command, scrubbed out of error messages and the audit
+// log. Like activeAudit there is one per process.
+var activeSecrets struct {
+	mu     sync.Mutex
+	values []string // longest first
+}
+
+// sensitiveKey reports whether the value at the dotted path keys is
+// redacted: its last key contains a sensitive word, or a pattern matches the
+// end of the path. A pattern is a dotted path whose keys may be globs, so
+// "password" matches a password key at any depth, "*.token" a token key
+// under any key and "secrets.*" every key of a secrets map.
+func sensitiveKey(keys []string, patterns []string) bool {
+	last := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(keys[len(keys)-1]))
+	for _, w := range sensitiveKeyWords {
+		if strings.Contains(last, w) {
+			return true
+		}
+	}
+	for _, p := range patterns {
+		parts := strings.Split(strings.TrimPrefix(p, "."), ".")
+		if len(parts) > len(keys) {
+			continue
+		}
+		tail := keys[len(keys)-len(parts):]
+		matched := true
+		for i, part := range parts {
+			if ok, _ := path.Match(part, tail[i]); !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// sensitivePath reports whether the value at keys or one of its parents is
+// redacted.
+func sensitivePath(keys []string, patterns []string) bool {
+	for i := range keys {
+		if sensitiveKey(keys[:i+1], patterns) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactValues returns a copy of values with the value of every sensitive
+// key replaced by redacted.
+func redactValues(values map[string]any, patterns []string) map[string]any {
+	return redactTree(values, nil, patterns).(map[string]any)
+}
+
+func redactTree(v any, keys []string, patterns []string) any {
+	switch x := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(x))
+		for k, val := range x {
+			p := append(keys[:len(keys):len(keys)], k)
+			if sensitiveKey(p, patterns) {
+				out[k] = redacted
+				continue
+			}
+			out[k] = redactTree(val, p, patterns)
+		}
+		return out
+	case []any:
+		out := make([]any, len(x))
+		for i, val := range x {
+			out[i] = redactTree(val, keys, patterns)
+		}
+		return out
+	}
+	return v
+}
+
+// noteSecrets adds the scalar values under the sensitive keys of values to
+// those scrubSecrets replaces.
+func noteSecrets(values map[string]any, patterns []string) {
+	var found []string
+	var walk func(v any, keys []string, sensitive bool)
+	walk = func(v any, keys []string, sensitive bool) {
+		switch x := v.(type) {
+		case map[string]any:
+			for k, val := range x {
+				p := append(keys[:len(keys):len(keys)], k)
+				walk(val, p, sensitive || sensitiveKey(p, patterns))
+			}
+		case []any:
+			for _, val := range x {
+				walk(val, keys, sensitive)
+			}
+		case nil:
+		default:
+			if s := fmt.Sprint(x); sensitive && len(s) >= minSecretLen {
+				found = append(found, s)
+			}
+		}
+	}
+	walk(values, nil, false)
+
+	activeSecrets.mu.Lock()
+	defer activeSecrets.mu.Unlock()
+	for _, s := range found {
+		if !slices.Contains(activeSecrets.values, s) {
+			activeSecrets.values = append(activeSecrets.values, s)
+		}
+	}
+	// Longest first, so that a secret containing another is replaced whole
+	sort.SliceStable(activeSecrets.values, func(i, j int) bool {
+		return len(activeSecrets.values[i]) > len(activeSecrets.values[j])
+	})
+}
+
+// redactArgs returns a copy of the command line with the values of --set
+// overrides of sensitive keys, and any noted secret, redacted.
+func redactArgs(args []string, patterns []string) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case i > 0 && args[i-1] == "--set":
+			arg = redactSet(arg, patterns)
+		case strings.HasPrefix(arg, "--set="):
+			arg = "--set=" + redactSet(strings.TrimPrefix(arg, "--set="), patterns)
+		}
+		out[i] = scrubSecrets(arg)
+	}
+	return out
+}
+
+// redactSet redacts the value of a key=value override of a sensitive key.
+func redactSet(kv string, patterns []string) string {
+	key, _, ok := strings.Cut(kv, "=")
+	if ok && sensitiveKey(strings.Split(key, "."), patterns) {
+		return key + "=" + redacted
+	}
+	return kv
+}
+
+// scrubSecrets replaces the values noted by noteSecrets in s.
+func scrubSecrets(s string) string {
+	activeSecrets.mu.Lock()
+	defer activeSecrets.mu.Unlock()
+	for _, secret := range activeSecrets.values {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
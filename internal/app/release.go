@@ -0,0 +1,170 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed release/*.tpl
+var releaseTemplates embed.FS
+
+// releaseManifest is a packaging manifest rendered by `templr release manifest`.
+type releaseManifest struct {
+	template  string // built-in template under release/
+	file      string // output file name
+	checksums bool   // needs the archive checksums
+}
+
+var releaseManifests = map[string]releaseManifest{
+	"brew":  {template: "brew.rb.tpl", file: "templr.rb", checksums: true},
+	"scoop": {template: "scoop.json.tpl", file: "templr.json", checksums: true},
+	"deb":   {template: "deb-control.tpl", file: "control"},
+}
+
+// releaseFormats is the order manifests are rendered in.
+var releaseFormats = []string{"brew", "scoop", "deb"}
+
+// Project metadata of the manifests; it matches .goreleaser.yml.
+const (
+	releaseHomepage    = "https://github.com/kanopi/templr"
+	releaseDescription = "A powerful Go template rendering CLI tool with Sprig functions"
+	releaseLicense     = "MIT"
+	releaseMaintainer  = "Kanopi <info@kanopi.com>"
+)
+
+// ReleaseOptions contains options for `templr release manifest`
+type ReleaseOptions struct {
+	Formats   []string // brew, scoop, deb (default: all)
+	Version   string   // release version (default: the running binary's)
+	Checksums string   // SHA256SUMS file of the release archives
+	BaseURL   string   // download URL of the archives (default: the GitHub release)
+	Arch      string   // Debian architecture of the deb control file
+	OutputDir string   // write each manifest here instead of stdout
+}
+
+// RunReleaseManifest renders the packaging manifests of a release from the
+// built-in templates, using templr's own template engine.
+func RunReleaseManifest(opts ReleaseOptions) error {
+	formats := opts.Formats
+	if len(formats) == 0 {
+		formats = releaseFormats
+	}
+	for _, f := range formats {
+		if _, ok := releaseManifests[f]; !ok {
+			return argsError(fmt.Errorf("unknown manifest format %q (want brew, scoop or deb)", f))
+		}
+	}
+	if opts.OutputDir == "" && len(formats) > 1 {
+		return argsError(fmt.Errorf("rendering several manifests needs --output-dir (or pick one with --format)"))
+	}
+
+	version := strings.TrimPrefix(opts.Version, "v")
+	if version == "" {
+		version = strings.TrimPrefix(GetVersion(), "v")
+		if version == "dev" || strings.Contains(version, "-0.") || strings.HasPrefix(version, "0.0.0-") || strings.Contains(version, "+") {
+			return argsError(fmt.Errorf("this is a development build (%s); set the release version with --version", version))
+		}
+	}
+	baseURL := strings.TrimSuffix(opts.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = releaseHomepage + "/releases/download/v" + version
+	}
+	arch := opts.Arch
+	if arch == "" {
+		arch = "amd64"
+	}
+
+	sums := map[string]string{}
+	if opts.Checksums != "" {
+		var err error
+		if sums, err = readChecksums(opts.Checksums); err != nil {
+			return exitError(ExitDataError, "data", fmt.Errorf("read checksums: %w", err))
+		}
+	}
+
+	values := map[string]any{
+		"version":     version,
+		"baseURL":     baseURL,
+		"arch":        arch,
+		"homepage":    releaseHomepage,
+		"description": releaseDescription,
+		"license":     releaseLicense,
+		"maintainer":  releaseMaintainer,
+	}
+
+	for _, f := range formats {
+		m := releaseManifests[f]
+		if m.checksums && opts.Checksums == "" {
+			return argsError(fmt.Errorf("the %s manifest needs the archive checksums; pass --checksums SHA256SUMS", f))
+		}
+		out, err := renderReleaseManifest(m.template, values, sums)
+		if err != nil {
+			return err
+		}
+		if opts.OutputDir == "" {
+			fmt.Print(string(out))
+			continue
+		}
+		if err := os.MkdirAll(opts.OutputDir, 0o755); err != nil {
+			return fmt.Errorf("mkdir %s: %w", opts.OutputDir, err)
+		}
+		dst := filepath.Join(opts.OutputDir, m.file)
+		if err := os.WriteFile(dst, out, 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", dst, err)
+		}
+		fmt.Printf("wrote %s manifest -> %s\n", f, dst)
+	}
+	return nil
+}
+
+// renderReleaseManifest renders a built-in manifest template. The checksum
+// function returns the SHA-256 of a release archive and fails for archives
+// missing from the checksums file.
+func renderReleaseManifest(name string, values map[string]any, sums map[string]string) ([]byte, error) {
+	src, err := releaseTemplates.ReadFile("release/" + name)
+	if err != nil {
+		return nil, err
+	}
+	tpl := template.New(name).Option("missingkey=error")
+	funcs := buildFuncMap(&tpl)
+	funcs["checksum"] = func(archive string) (string, error) {
+		sum, ok := sums[archive]
+		if !ok {
+			return "", fmt.Errorf("no checksum for %s", archive)
+		}
+		return sum, nil
+	}
+	if _, err := tpl.Funcs(funcs).Parse(string(src)); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, values); err != nil {
+		return nil, exitError(ExitTemplateError, "template", fmt.Errorf("render %s: %w", name, err))
+	}
+	return buf.Bytes(), nil
+}
+
+// readChecksums parses a sha256sum style file ("<hash>  <file>", with "*"
+// marking binary mode) into a map from file name to hash.
+func readChecksums(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sums := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		sums[filepath.Base(strings.TrimPrefix(fields[1], "*"))] = strings.ToLower(fields[0])
+	}
+	return sums, sc.Err()
+}
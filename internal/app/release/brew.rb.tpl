@@ -0,0 +1,37 @@
+# Homebrew formula for templr {{ .version }}, generated by `templr release manifest`.
+class Templr < Formula
+  desc "{{ .description }}"
+  homepage "{{ .homepage }}"
+  version "{{ .version }}"
+  license "{{ .license }}"
+
+  on_macos do
+    on_intel do
+      url "{{ .baseURL }}/templr-darwin-amd64.tar.gz"
+      sha256 "{{ checksum "templr-darwin-amd64.tar.gz" }}"
+    end
+    on_arm do
+      url "{{ .baseURL }}/templr-darwin-arm64.tar.gz"
+      sha256 "{{ checksum "templr-darwin-arm64.tar.gz" }}"
+    end
+  end
+
+  on_linux do
+    on_intel do
+      url "{{ .baseURL }}/templr-linux-amd64.tar.gz"
+      sha256 "{{ checksum "templr-linux-amd64.tar.gz" }}"
+    end
+    on_arm do
+      url "{{ .baseURL }}/templr-linux-arm64.tar.gz"
+      sha256 "{{ checksum "templr-linux-arm64.tar.gz" }}"
+    end
+  end
+
+  def install
+    bin.install "templr"
+  end
+
+  test do
+    assert_match version.to_s, shell_output("#{bin}/templr version")
+  end
+end
@@ -0,0 +1,8 @@
+Package: templr
+Version: {{ .version }}
+Section: utils
+Priority: optional
+Architecture: {{ .arch }}
+Maintainer: {{ .maintainer }}
+Homepage: {{ .homepage }}
+Description: {{ .description }}
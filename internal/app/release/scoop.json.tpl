@@ -0,0 +1,26 @@
+{
+  "version": {{ .version | toJson }},
+  "description": {{ .description | toJson }},
+  "homepage": {{ .homepage | toJson }},
+  "license": {{ .license | toJson }},
+  "architecture": {
+    "64bit": {
+      "url": {{ printf "%s/templr-windows-amd64.zip" .baseURL | toJson }},
+      "hash": {{ checksum "templr-windows-amd64.zip" | toJson }}
+    }
+  },
+  "bin": "templr.exe",
+  "checkver": {
+    "github": {{ .homepage | toJson }}
+  },
+  "autoupdate": {
+    "architecture": {
+      "64bit": {
+        "url": {{ printf "%s/releases/download/v$version/templr-windows-amd64.zip" .homepage | toJson }}
+      }
+    },
+    "hash": {
+      "url": "$baseurl/SHA256SUMS"
+    }
+  }
+}
@@ -0,0 +1,247 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// remoteManifestName is the object under the destination prefix recording
+// the SHA-256 of every object a walk uploaded, so that the next walk uploads
+// only what changed without downloading or listing the objects.
+const remoteManifestName = ".templr-manifest.json"
+
+// remoteHTTPClient is used for object storage requests.
+var remoteHTTPClient = &http.Client{Timeout: 2 * time.Minute}
+
+// errObjectNotFound is returned by objectStore.get for a missing object.
+var errObjectNotFound = errors.New("object not found")
+
+// objectStore is a bucket of an object storage service.
+type objectStore interface {
+	get(key string) ([]byte, error)
+	put(key string, data []byte, contentType string) error
+}
+
+// remoteManifest maps output paths (relative to the prefix) to their digest.
+type remoteManifest struct {
+	Version int               `json:"version"`
+	Files   map[string]string `json:"files"` // "conf/app.yaml" -> "sha256:..."
+}
+
+// isRemoteDst reports whether a --dst is an object storage URL.
+func isRemoteDst(dst string) bool {
+	return strings.HasPrefix(dst, "s3://") || strings.HasPrefix(dst, "gs://")
+}
+
+// parseRemoteDst splits "s3://bucket/prefix" into scheme, bucket and prefix
+// (without surrounding slashes).
+func parseRemoteDst(dst string) (scheme, bucket, prefix string, err error) {
+	scheme, rest, _ := strings.Cut(dst, "://")
+	bucket, prefix, _ = strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", "", fmt.Errorf("%s: missing bucket name", dst)
+	}
+	return scheme, bucket, strings.Trim(prefix, "/"), nil
+}
+
+// checkRemoteOptions validates an object storage --dst before rendering.
+func checkRemoteOptions(opts WalkOptions) error {
+	if !isRemoteDst(opts.Dst) {
+		return nil
+	}
+	if _, _, _, err := parseRemoteDst(opts.Dst); err != nil {
+		return argsError(fmt.Errorf("invalid --dst: %w", err))
+	}
+	if opts.Provenance != "" {
+		return argsError(fmt.Errorf("--provenance cannot be used with an s3:// or gs:// --dst"))
+	}
+	return nil
+}
+
+// remoteTree uploads the outputs of a walk to object storage. Objects whose
+// content matches the manifest of the previous walk are not uploaded. The
+// manifest is rewritten by commit, or by discard for the uploads that
+// succeeded before a failure, so it never claims content an object lacks.
+type remoteTree struct {
+	dst      string // the --dst URL, for messages
+	prefix   string
+	store    objectStore
+	dryRun   bool
+	manifest remoteManifest
+	changed  bool // the manifest differs from the stored one
+}
+
+// newRemoteTree connects to the bucket of dst and reads the manifest of the
+// previous walk.
+func newRemoteTree(dst string, dryRun bool) (*remoteTree, error) {
+	scheme, bucket, prefix, err := parseRemoteDst(dst)
+	if err != nil {
+		return nil, argsError(fmt.Errorf("invalid --dst: %w", err))
+	}
+	var store objectStore
+	switch scheme {
+	case "s3":
+		store, err = newS3Store(bucket)
+	case "gs":
+		store, err = newGCSStore(bucket)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dst, err)
+	}
+	t := &remoteTree{dst: strings.TrimRight(dst, "/"), prefix: prefix, store: store, dryRun: dryRun}
+	t.manifest.Files = map[string]string{}
+	onExit(t.discard)
+	b, err := store.get(t.key(remoteManifestName))
+	switch {
+	case errors.Is(err, errObjectNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("read %s: %w", t.label(remoteManifestName), err)
+	default:
+		if err := json.Unmarshal(b, &t.manifest); err != nil {
+			warnf("remote", "ignoring unreadable %s: %v", t.label(remoteManifestName), err)
+		}
+		if t.manifest.Files == nil {
+			t.manifest.Files = map[string]string{}
+		}
+	}
+	return t, nil
+}
+
+// key returns the object key of an output path.
+func (t *remoteTree) key(relOut string) string {
+	return path.Join(t.prefix, filepath.ToSlash(relOut))
+}
+
+// label names an output in status lines: "s3://bucket/prefix/app.yaml".
+func (t *remoteTree) label(relOut string) string {
+	return t.dst + "/" + filepath.ToSlash(relOut)
+}
+
+// writeOutput uploads one rendered template unless the manifest shows the
+// same content is already there. As with --dst-archive, guards are not
+// checked: objects carry no guard to look for.
+func (t *remoteTree) writeOutput(w messageWriter, name, relOut string, outBytes []byte, keep bool, shared SharedOptions) (string, error) {
+	label := t.label(relOut)
+	if isEmpty(outBytes) && !keep {
+		if shared.DryRun {
+			fmt.Fprintf(w.Stdout(), "[dry-run] skip empty %s (no object created)\n", label)
+		}
+		return "skipped (empty)", nil
+	}
+	status := "rendered"
+	if isEmpty(outBytes) {
+		outBytes, status = nil, "rendered (empty)"
+	} else {
+		if shared.InjectGuard {
+			outBytes = injectGuardForExt(relOut, outBytes, shared)
+		}
+		var err error
+		if outBytes, err = encodeOutput(relOut, outBytes, shared); err != nil {
+			return "", fmt.Errorf("encode %s: %w", label, err)
+		}
+	}
+
+	rel := filepath.ToSlash(relOut)
+	sum := sha256.Sum256(outBytes)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if t.manifest.Files[rel] == digest {
+		if t.dryRun {
+			fmt.Fprintf(w.Stdout(), "[dry-run] would skip unchanged %s\n", label)
+		}
+		return "unchanged", nil
+	}
+	if t.dryRun {
+		fmt.Fprintf(w.Stdout(), "[dry-run] would render %s -> %s (changed)\n", name, label)
+		noteDryRunChange()
+		return "dry-run", nil
+	}
+	contentType := mime.TypeByExtension(path.Ext(rel))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	// a failed upload may leave the object in any state
+	delete(t.manifest.Files, rel)
+	t.changed = true
+	if err := t.store.put(t.key(rel), outBytes, contentType); err != nil {
+		return "", fmt.Errorf("upload %s: %w", label, err)
+	}
+	t.manifest.Files[rel] = digest
+	activeAudit.written(label, outBytes)
+	if status == "rendered (empty)" {
+		fmt.Fprintf(w.Stdout(), "rendered %s -> %s (empty)\n", name, label)
+	} else {
+		fmt.Fprintf(w.Stdout(), "rendered %s -> %s\n", name, label)
+	}
+	return status, nil
+}
+
+// commit records the uploaded objects in the manifest.
+func (t *remoteTree) commit() error {
+	if t.dryRun || !t.changed {
+		return nil
+	}
+	t.manifest.Version = 1
+	b, err := json.MarshalIndent(t.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := t.store.put(t.key(remoteManifestName), append(b, '\n'), "application/json"); err != nil {
+		return fmt.Errorf("write %s: %w", t.label(remoteManifestName), err)
+	}
+	t.changed = false
+	return nil
+}
+
+// discard records the uploads that succeeded before a walk failed. If the
+// manifest cannot be written either, the warning asks for the manifest to be
+// deleted: the stale one may list a changed object as unchanged.
+func (t *remoteTree) discard() {
+	if err := t.commit(); err != nil {
+		warnf("remote", "%v; delete %s to force a full upload next time", err, t.label(remoteManifestName))
+	}
+}
+
+// doRequest sends req and returns the response body, mapping 404 to
+// errObjectNotFound and other failures to an error with the service message.
+func doRequest(req *http.Request) ([]byte, error) {
+	resp, err := remoteHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errObjectNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > 300 {
+			msg = msg[:300] + "..."
+		}
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, msg)
+	}
+	return body, nil
+}
+
+// newBodyRequest builds a request with a byte body.
+func newBodyRequest(method, url string, body []byte) (*http.Request, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	return http.NewRequest(method, url, r)
+}
@@ -0,0 +1,249 @@
+package app
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gcsBaseURL  = "https://storage.googleapis.com"
+	gcsScope    = "https://www.googleapis.com/auth/devstorage.read_write"
+	gcsTokenURL = "https://oauth2.googleapis.com/token"
+)
+
+// gcsStore talks to the Google Cloud Storage JSON API, or to the emulator
+// set with STORAGE_EMULATOR_HOST (without authentication).
+type gcsStore struct {
+	bucket string
+	base   string
+	token  func() (string, error) // nil: no Authorization header
+
+	mu      sync.Mutex
+	cached  string
+	expires time.Time
+}
+
+func newGCSStore(bucket string) (*gcsStore, error) {
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		return &gcsStore{bucket: bucket, base: strings.TrimRight(host, "/")}, nil
+	}
+	token, err := gcsFindToken()
+	if err != nil {
+		return nil, err
+	}
+	return &gcsStore{bucket: bucket, base: gcsBaseURL, token: token}, nil
+}
+
+// gcsToken is an OAuth2 access token response.
+type gcsToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// gcsFindToken looks for credentials the way Google client libraries do:
+// an access token in GOOGLE_OAUTH_ACCESS_TOKEN, the application default
+// credentials file (GOOGLE_APPLICATION_CREDENTIALS, or the one written by
+// `gcloud auth application-default login`), then the metadata server. Of
+// the credentials file types, only service_account and authorized_user are
+// supported; workload identity federation (external_account) and
+// impersonated service accounts are reported as such.
+func gcsFindToken() (func() (string, error), error) {
+	if tok := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); tok != "" {
+		return func() (string, error) { return tok, nil }, nil
+	}
+	file := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if file == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			adc := filepath.Join(dir, "gcloud", "application_default_credentials.json")
+			if _, err := os.Stat(adc); err == nil {
+				file = adc
+			}
+		}
+	}
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read credentials: %w", err)
+		}
+		var cred struct {
+			Type         string `json:"type"`
+			ClientEmail  string `json:"client_email"`
+			PrivateKey   string `json:"private_key"`
+			TokenURI     string `json:"token_uri"`
+			ClientID     string `json:"client_id"`
+			ClientSecret string `json:"client_secret"`
+			RefreshToken string `json:"refresh_token"`
+		}
+		if err := json.Unmarshal(b, &cred); err != nil {
+			return nil, fmt.Errorf("parse credentials %s: %w", file, err)
+		}
+		if cred.TokenURI == "" {
+			cred.TokenURI = gcsTokenURL
+		}
+		switch cred.Type {
+		case "service_account":
+			key, err := parseRSAKey(cred.PrivateKey)
+			if err != nil {
+				return nil, fmt.Errorf("credentials %s: %w", file, err)
+			}
+			return func() (string, error) { return serviceAccountToken(cred.ClientEmail, key, cred.TokenURI) }, nil
+		case "authorized_user":
+			return func() (string, error) {
+				return fetchToken(cred.TokenURI, url.Values{
+					"grant_type":    {"refresh_token"},
+					"client_id":     {cred.ClientID},
+					"client_secret": {cred.ClientSecret},
+					"refresh_token": {cred.RefreshToken},
+				})
+			}, nil
+		}
+		return nil, fmt.Errorf("credentials %s: type %q is not supported (want service_account or authorized_user): set GOOGLE_OAUTH_ACCESS_TOKEN instead, e.g. to the output of `gcloud auth print-access-token`", file, cred.Type)
+	}
+	if _, err := metadataToken(); err == nil {
+		return metadataToken, nil
+	}
+	return nil, errors.New("no Google Cloud credentials found: set GOOGLE_APPLICATION_CREDENTIALS or run `gcloud auth application-default login`")
+}
+
+// serviceAccountToken exchanges a signed JWT for an access token.
+func serviceAccountToken(email string, key *rsa.PrivateKey, tokenURI string) (string, error) {
+	now := time.Now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]any{
+		"iss": email, "scope": gcsScope, "aud": tokenURI,
+		"iat": now.Unix(), "exp": now.Add(time.Hour).Unix(),
+	})
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return fetchToken(tokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)},
+	})
+}
+
+func fetchToken(tokenURI string, form url.Values) (string, error) {
+	resp, err := remoteHTTPClient.PostForm(tokenURI, form)
+	if err != nil {
+		return "", err
+	}
+	b, err := readSmallBody(resp)
+	if err != nil {
+		return "", fmt.Errorf("get access token: %w", err)
+	}
+	var tok gcsToken
+	if err := json.Unmarshal(b, &tok); err != nil || tok.AccessToken == "" {
+		return "", errors.New("get access token: no access_token in the response")
+	}
+	return tok.AccessToken, nil
+}
+
+// metadataToken gets the token of the default service account from the
+// metadata server of Compute Engine, GKE and Cloud Run.
+func metadataToken() (string, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	client := &http.Client{Timeout: 2 * time.Second}
+	req, _ := http.NewRequest(http.MethodGet, "http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	b, err := readSmallBody(resp)
+	if err != nil {
+		return "", err
+	}
+	var tok gcsToken
+	if err := json.Unmarshal(b, &tok); err != nil {
+		return "", err
+	}
+	return tok.AccessToken, nil
+}
+
+func parseRSAKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("private_key: no PEM data")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if rk, err2 := x509.ParsePKCS1PrivateKey(block.Bytes); err2 == nil {
+			return rk, nil
+		}
+		return nil, fmt.Errorf("private_key: %w", err)
+	}
+	rk, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private_key: not an RSA key")
+	}
+	return rk, nil
+}
+
+// authorize sets the bearer token, reusing it for 45 minutes (tokens are
+// valid for an hour).
+func (s *gcsStore) authorize(req *http.Request) error {
+	if s.token == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached == "" || time.Now().After(s.expires) {
+		tok, err := s.token()
+		if err != nil {
+			return err
+		}
+		s.cached, s.expires = tok, time.Now().Add(45*time.Minute)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.cached)
+	return nil
+}
+
+func (s *gcsStore) get(key string) ([]byte, error) {
+	u := s.base + "/storage/v1/b/" + url.PathEscape(s.bucket) + "/o/" + url.PathEscape(key) + "?alt=media"
+	req, err := newBodyRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(req); err != nil {
+		return nil, err
+	}
+	return doRequest(req)
+}
+
+func (s *gcsStore) put(key string, data []byte, contentType string) error {
+	u := s.base + "/upload/storage/v1/b/" + url.PathEscape(s.bucket) + "/o?" + url.Values{"uploadType": {"media"}, "name": {key}}.Encode()
+	req, err := newBodyRequest(http.MethodPost, u, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if err := s.authorize(req); err != nil {
+		return err
+	}
+	_, err = doRequest(req)
+	return err
+}
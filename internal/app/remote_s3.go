@@ -0,0 +1,376 @@
+package app
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Credentials are AWS access keys.
+type s3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// s3Store talks to S3, or an S3-compatible service set with
+// AWS_ENDPOINT_URL_S3 / AWS_ENDPOINT_URL (path-style addressing), with
+// requests signed with AWS Signature Version 4.
+type s3Store struct {
+	bucket   string
+	region   string
+	endpoint *url.URL // nil: https://<bucket>.s3.<region>.amazonaws.com
+	creds    s3Credentials
+}
+
+func newS3Store(bucket string) (*s3Store, error) {
+	s := &s3Store{bucket: bucket, region: firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	if ep := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"); ep != "" {
+		u, err := url.Parse(ep)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint %q", ep)
+		}
+		s.endpoint = u
+	}
+	creds, err := s3FindCredentials()
+	if err != nil {
+		return nil, err
+	}
+	s.creds = creds
+	return s, nil
+}
+
+// s3FindCredentials looks for credentials in the environment, the shared
+// credentials file, then the EC2 instance metadata service. The other
+// sources of the AWS SDKs (IRSA web identity tokens, ECS and EKS container
+// credentials, and SSO, assumed-role and credential_process profiles of
+// ~/.aws/config) are not supported: when one is configured, the error names
+// it rather than falling through to another identity.
+func s3FindCredentials() (s3Credentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return s3Credentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+	if os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "" {
+		return s3Credentials{}, s3Unsupported("web identity tokens (AWS_WEB_IDENTITY_TOKEN_FILE)")
+	}
+	creds, ok, err := s3SharedCredentials()
+	if ok {
+		return creds, nil
+	}
+	if source := s3ConfigSource(); source != "" {
+		return s3Credentials{}, s3Unsupported(source)
+	}
+	if err != nil {
+		return s3Credentials{}, err
+	}
+	if firstEnv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI") != "" {
+		return s3Credentials{}, s3Unsupported("container credentials (AWS_CONTAINER_CREDENTIALS_*)")
+	}
+	if !strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		if creds, err := s3InstanceCredentials(); err == nil {
+			return creds, nil
+		}
+	}
+	return s3Credentials{}, errors.New("no AWS credentials found: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or AWS_PROFILE for a profile of ~/.aws/credentials")
+}
+
+// s3Unsupported is the error for a credential source templr cannot use.
+func s3Unsupported(source string) error {
+	return fmt.Errorf("AWS credentials from %s are not supported: export keys instead, e.g. with `eval \"$(aws configure export-credentials --format env)\"`", source)
+}
+
+// s3ConfigSource returns the credential source of the AWS_PROFILE (or
+// "default") profile of ~/.aws/config when it is one templr cannot use, or "".
+func s3ConfigSource() string {
+	file := os.Getenv("AWS_CONFIG_FILE")
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		file = filepath.Join(home, ".aws", "config")
+	}
+	profile := s3Profile()
+	section := "profile " + profile
+	if profile == "default" {
+		section = "default"
+	}
+	settings, found, err := readAWSIni(file, section)
+	if err != nil || !found {
+		return ""
+	}
+	for _, key := range []string{"sso_session", "sso_start_url", "credential_process", "role_arn", "web_identity_token_file"} {
+		if settings[key] != "" {
+			return fmt.Sprintf("the %s of profile %q in %s", key, profile, file)
+		}
+	}
+	return ""
+}
+
+// s3Profile returns the AWS_PROFILE, or "default".
+func s3Profile() string {
+	if p := os.Getenv("AWS_PROFILE"); p != "" {
+		return p
+	}
+	return "default"
+}
+
+// s3SharedCredentials reads the AWS_PROFILE (or "default") section of the
+// shared credentials file.
+func s3SharedCredentials() (s3Credentials, bool, error) {
+	file := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return s3Credentials{}, false, nil
+		}
+		file = filepath.Join(home, ".aws", "credentials")
+	}
+	profile := s3Profile()
+	settings, found, err := readAWSIni(file, profile)
+	if err != nil {
+		return s3Credentials{}, false, err
+	}
+	if !found {
+		if os.Getenv("AWS_PROFILE") != "" {
+			return s3Credentials{}, false, fmt.Errorf("profile %q not found in %s", profile, file)
+		}
+		return s3Credentials{}, false, nil
+	}
+	creds := s3Credentials{
+		AccessKeyID:     settings["aws_access_key_id"],
+		SecretAccessKey: settings["aws_secret_access_key"],
+		SessionToken:    settings["aws_session_token"],
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return s3Credentials{}, false, fmt.Errorf("profile %q of %s has no aws_access_key_id and aws_secret_access_key", profile, file)
+	}
+	return creds, true, nil
+}
+
+// readAWSIni returns the settings of section of the AWS INI file, and
+// whether the section exists. A missing file has no sections.
+func readAWSIni(file, section string) (map[string]string, bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, false, nil
+	}
+	defer func() { _ = f.Close() }()
+
+	settings := map[string]string{}
+	var current string
+	found := false
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+			found = found || current == section
+			continue
+		}
+		if current != section {
+			continue
+		}
+		key, value, _ := strings.Cut(line, "=")
+		settings[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, false, fmt.Errorf("read %s: %w", file, err)
+	}
+	return settings, found, nil
+}
+
+// s3InstanceCredentials gets the credentials of the instance role from the
+// EC2 instance metadata service (IMDSv2).
+func s3InstanceCredentials() (s3Credentials, error) {
+	const imds = "http://169.254.169.254/latest"
+	client := &http.Client{Timeout: 2 * time.Second}
+	req, _ := http.NewRequest(http.MethodPut, imds+"/api/token", nil)
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	resp, err := client.Do(req)
+	if err != nil {
+		return s3Credentials{}, err
+	}
+	tokenBytes, err := readSmallBody(resp)
+	if err != nil {
+		return s3Credentials{}, err
+	}
+	get := func(p string) ([]byte, error) {
+		req, _ := http.NewRequest(http.MethodGet, imds+"/meta-data/iam/security-credentials/"+p, nil)
+		req.Header.Set("X-aws-ec2-metadata-token", string(tokenBytes))
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		return readSmallBody(resp)
+	}
+	role, err := get("")
+	if err != nil {
+		return s3Credentials{}, err
+	}
+	b, err := get(strings.TrimSpace(strings.SplitN(string(role), "\n", 2)[0]))
+	if err != nil {
+		return s3Credentials{}, err
+	}
+	var c struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		Token           string `json:"Token"`
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return s3Credentials{}, err
+	}
+	return s3Credentials{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.Token}, nil
+}
+
+// objectURL returns the URL of an object.
+func (s *s3Store) objectURL(key string) *url.URL {
+	escaped := escapeObjectKey(key)
+	if s.endpoint == nil {
+		return &url.URL{Scheme: "https", Host: s.bucket + ".s3." + s.region + ".amazonaws.com", Path: "/" + key, RawPath: "/" + escaped}
+	}
+	u := *s.endpoint
+	base := strings.TrimRight(u.Path, "/")
+	u.Path = base + "/" + s.bucket + "/" + key
+	u.RawPath = base + "/" + s.bucket + "/" + escaped
+	return &u
+}
+
+func (s *s3Store) get(key string) ([]byte, error) {
+	req, err := newBodyRequest(http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, nil, time.Now())
+	return doRequest(req)
+}
+
+func (s *s3Store) put(key string, data []byte, contentType string) error {
+	req, err := newBodyRequest(http.MethodPut, s.objectURL(key).String(), data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, data, time.Now())
+	_, err = doRequest(req)
+	return err
+}
+
+// sign adds the AWS Signature Version 4 headers to req.
+func (s *s3Store) sign(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.creds.SessionToken)
+	}
+
+	var names []string
+	for name := range req.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, name := range names {
+		canonHeaders.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+s.creds.SecretAccessKey), day)
+	for _, part := range []string{s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.creds.AccessKeyID, scope, signedHeaders, signature))
+	req.Header.Del("Host") // net/http sets it from req.Host
+}
+
+// escapeObjectKey percent-encodes an object key for a URL path the way
+// SigV4 canonical URIs require: every byte but the RFC 3986 unreserved
+// characters and the slashes between segments.
+func escapeObjectKey(key string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&15])
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// firstEnv returns the first non-empty environment variable of names.
+func firstEnv(names ...string) string {
+	for _, n := range names {
+		if v := os.Getenv(n); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// readSmallBody reads the body of a metadata or token response.
+func readSmallBody(resp *http.Response) ([]byte, error) {
+	defer func() { _ = resp.Body.Close() }()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s: %s", resp.Request.URL.Redacted(), resp.Status)
+	}
+	return b, nil
+}
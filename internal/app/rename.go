@@ -0,0 +1,63 @@
+package app
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RenameRule rewrites the output path of walk-mode templates.
+type RenameRule struct {
+	From string `yaml:"from"` // regexp matched against the whole template path relative to --src
+	To   string `yaml:"to"`   // output path relative to --dst; $1, ${name} expand groups of From
+
+	re *regexp.Regexp
+}
+
+// ParseRenameRule parses a --rename value of the form "FROM=>TO".
+func ParseRenameRule(s string) (RenameRule, error) {
+	from, to, ok := strings.Cut(s, "=>")
+	if !ok || from == "" || to == "" {
+		return RenameRule{}, fmt.Errorf("invalid --rename %q (want FROM=>TO)", s)
+	}
+	return RenameRule{From: from, To: to}, nil
+}
+
+// compileRenameRules compiles each rule's pattern, anchored to match the whole path.
+func compileRenameRules(rules []RenameRule) ([]RenameRule, error) {
+	out := make([]RenameRule, len(rules))
+	for i, r := range rules {
+		re, err := regexp.Compile(`^(?:` + r.From + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rename rule %q: %w", r.From, err)
+		}
+		r.re = re
+		out[i] = r
+	}
+	return out, nil
+}
+
+// outputRelPath returns the slash-separated output path, relative to the
+// destination, of template name. The first matching rename rule decides the
+// path; otherwise outputName trims the name and, with flatten, the
+// directories are dropped.
+func outputRelPath(name string, allowExts map[string]bool, shared SharedOptions, rules []RenameRule, flatten bool) (string, error) {
+	for _, r := range rules {
+		m := r.re.FindStringSubmatchIndex(name)
+		if m == nil {
+			continue
+		}
+		out := path.Clean(string(r.re.ExpandString(nil, r.To, name, m)))
+		if path.IsAbs(out) || filepath.IsAbs(out) || out == ".." || strings.HasPrefix(out, "../") {
+			return "", fmt.Errorf("rename rule %q maps %s outside the destination: %s", r.From, name, out)
+		}
+		return out, nil
+	}
+	out := outputName(name, allowExts, shared)
+	if flatten {
+		out = path.Base(out)
+	}
+	return out, nil
+}
@@ -39,7 +39,11 @@ func ValidateWithSchema(data map[string]interface{}, schemaPath, mode string) (*
 	if err != nil {
 		return nil, fmt.Errorf("read schema file: %w", err)
 	}
+	return validateSchemaBytes(data, schemaBytes, mode)
+}
 
+// validateSchemaBytes validates data against a YAML (or JSON) schema document.
+func validateSchemaBytes(data map[string]interface{}, schemaBytes []byte, mode string) (*SchemaValidationResult, error) {
 	// Parse schema YAML to map
 	var schemaMap map[string]interface{}
 	if err := yaml.Unmarshal(schemaBytes, &schemaMap); err != nil {
@@ -167,7 +171,10 @@ func buildSuggestion(err *jsonschema.ValidationError, _ string) string {
 
 // FindSchemaFile looks for schema file in order of precedence
 func FindSchemaFile(configSchemaPath string) string {
-	// 1. If explicit path provided in config, use that
+	// 1. If explicit path provided in config, use that; a URL is fetched later
+	if isSchemaURL(configSchemaPath) {
+		return configSchemaPath
+	}
 	if configSchemaPath != "" {
 		if _, err := os.Stat(configSchemaPath); err == nil {
 			return configSchemaPath
@@ -0,0 +1,220 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// valueSchema types the --set overrides and env-file values by the types a
+// schema declares, instead of guessing them with parseScalar.
+type valueSchema struct {
+	root map[string]any
+}
+
+// loadValueSchema reads the schema at path, a file or URL whose digest must
+// match pin when set; an empty path gives a nil schema.
+func loadValueSchema(path, pin string) (*valueSchema, error) {
+	if path == "" {
+		return nil, nil
+	}
+	local, err := resolveSchema(path, pin)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(local)
+	if err != nil {
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+	var root map[string]any
+	if err := yaml.Unmarshal(b, &root); err != nil {
+		return nil, fmt.Errorf("parse schema %s: %w", path, err)
+	}
+	return &valueSchema{root: root}, nil
+}
+
+// node returns the schema of the value at the dotted path, or nil when the
+// schema does not describe it.
+func (s *valueSchema) node(path []string) map[string]any {
+	if s == nil {
+		return nil
+	}
+	n := s.deref(s.root)
+	for _, seg := range path {
+		if props, ok := n["properties"].(map[string]any); ok {
+			if p, ok := props[seg].(map[string]any); ok {
+				n = s.deref(p)
+				continue
+			}
+		}
+		if ap, ok := n["additionalProperties"].(map[string]any); ok {
+			n = s.deref(ap)
+			continue
+		}
+		return nil
+	}
+	return n
+}
+
+// deref follows local "#/..." $refs.
+func (s *valueSchema) deref(n map[string]any) map[string]any {
+	for i := 0; i < 32; i++ {
+		ref, ok := n["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return n
+		}
+		var cur any = s.root
+		for _, seg := range strings.Split(ref[2:], "/") {
+			m, ok := cur.(map[string]any)
+			if !ok {
+				return n
+			}
+			cur = m[strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")]
+		}
+		next, ok := cur.(map[string]any)
+		if !ok {
+			return n
+		}
+		n = next
+	}
+	return n
+}
+
+// coerce converts the raw string given for the dotted key to the type the
+// schema declares there and checks it against the declared enum. Keys the
+// schema does not type keep untyped.
+func (s *valueSchema) coerce(key, raw string, untyped any) (any, error) {
+	n := s.node(strings.Split(key, "."))
+	types := schemaTypes(n)
+	v := untyped
+	if len(types) > 0 {
+		var ok bool
+		if v, ok = coerceToTypes(raw, types); !ok {
+			return nil, fmt.Errorf("%s=%s: expected %s", key, raw, strings.Join(types, " or "))
+		}
+	}
+	if enum, ok := n["enum"].([]any); ok && !inEnum(v, enum) {
+		allowed := make([]string, len(enum))
+		for i, e := range enum {
+			allowed[i] = fmt.Sprint(e)
+		}
+		return nil, fmt.Errorf("%s=%s: must be one of %s", key, raw, strings.Join(allowed, ", "))
+	}
+	return v, nil
+}
+
+// coerceStrings coerces the string leaves of m, an env file's values, in
+// place. Untyped values stay strings.
+func (s *valueSchema) coerceStrings(m map[string]any, prefix string) error {
+	for _, k := range sortedKeys(m) {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch x := m[k].(type) {
+		case map[string]any:
+			if err := s.coerceStrings(x, key); err != nil {
+				return err
+			}
+		case string:
+			c, err := s.coerce(key, x, x)
+			if err != nil {
+				return err
+			}
+			m[k] = c
+		}
+	}
+	return nil
+}
+
+// schemaTypes returns the types declared by a schema node.
+func schemaTypes(n map[string]any) []string {
+	switch t := n["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var out []string
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// coerceToTypes converts raw to the first of types it is valid for. A
+// "string" type only applies when nothing more specific does.
+func coerceToTypes(raw string, types []string) (any, bool) {
+	asString := false
+	for _, t := range types {
+		switch t {
+		case "integer":
+			if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				return i, true
+			}
+		case "number":
+			if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				return i, true
+			}
+			if f, err := strconv.ParseFloat(raw, 64); err == nil {
+				return f, true
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(raw); err == nil {
+				return b, true
+			}
+		case "null":
+			if raw == "" || raw == "null" {
+				return nil, true
+			}
+		case "array":
+			if v, ok := parseScalar(raw).([]any); ok {
+				return v, true
+			}
+		case "object":
+			if v, ok := parseScalar(raw).(map[string]any); ok {
+				return v, true
+			}
+		case "string":
+			asString = true
+		}
+	}
+	return raw, asString
+}
+
+// inEnum reports whether v is one of enum; numbers compare by value.
+func inEnum(v any, enum []any) bool {
+	for _, e := range enum {
+		if a, ok := toFloat(v); ok {
+			if b, ok := toFloat(e); ok && a == b {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(v, e) {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, !math.IsNaN(n)
+	}
+	return 0, false
+}
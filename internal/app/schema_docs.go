@@ -0,0 +1,368 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// schemaDocKeywords are the constraints listed for a key, in this order.
+var schemaDocKeywords = []string{
+	"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf",
+	"minLength", "maxLength", "pattern", "format",
+	"minItems", "maxItems", "uniqueItems", "minProperties", "maxProperties",
+}
+
+// schemaDocEntry documents the value at one key path of a schema.
+type schemaDocEntry struct {
+	Path        string // ".service.ports[].name"; "*" stands for any map key
+	Anchor      string
+	Types       []string
+	Title       string
+	Description string
+	Default     string
+	Enum        []string
+	Examples    []string
+	Constraints []string // "minimum: 1"
+	Required    bool
+	Deprecated  bool
+}
+
+// schemaDoc is a schema flattened for documentation.
+type schemaDoc struct {
+	Title       string
+	Description string
+	Entries     []schemaDocEntry
+}
+
+// RunSchemaDocs renders the schema into Markdown or HTML documentation.
+func RunSchemaDocs(opts SchemaOptions, config *Config) error {
+	schemaPath := opts.SchemaPath
+	if schemaPath == "" {
+		schemaPath = FindSchemaFile(config.Schema.Path)
+		if schemaPath == "" {
+			return fmt.Errorf("no schema file found (checked: %s, .templr.schema.yml, .templr/schema.yml)", config.Schema.Path)
+		}
+	}
+	pin := opts.SHA256
+	if pin == "" {
+		pin = config.Schema.SHA256
+	}
+	schema, err := loadValueSchema(schemaPath, pin)
+	if err != nil {
+		return exitError(ExitSchemaError, "schema", err)
+	}
+
+	doc := buildSchemaDoc(schema)
+	var out []byte
+	switch strings.ToLower(opts.Format) {
+	case "", "markdown", "md":
+		out = []byte(schemaDocMarkdown(doc))
+	case "html":
+		if out, err = schemaDocHTML(doc); err != nil {
+			return err
+		}
+	default:
+		return argsError(fmt.Errorf("--format %s: want markdown or html", opts.Format))
+	}
+
+	if opts.Output != "" {
+		if err := os.WriteFile(opts.Output, out, 0o644); err != nil {
+			return fmt.Errorf("write schema docs: %w", err)
+		}
+		fmt.Printf("Schema docs -> %s\n", opts.Output)
+		return nil
+	}
+	_, err = sink.Stdout().Write(out)
+	return err
+}
+
+// buildSchemaDoc flattens the schema into one entry per key path, depth first
+// with the keys of an object sorted.
+func buildSchemaDoc(s *valueSchema) schemaDoc {
+	root := s.deref(s.root)
+	doc := schemaDoc{Title: docString(root["title"]), Description: docString(root["description"])}
+	if doc.Title == "" {
+		doc.Title = "Values"
+	}
+	var walk func(raw map[string]any, path string, required bool, seen map[string]bool)
+	walk = func(raw map[string]any, path string, required bool, seen map[string]bool) {
+		n := s.deref(raw)
+		if path != "" {
+			doc.Entries = append(doc.Entries, schemaDocEntryOf(raw, n, path, required))
+		}
+		// A recursive $ref is listed but its keys are not expanded again
+		if ref, _ := raw["$ref"].(string); ref != "" {
+			if seen[ref] {
+				return
+			}
+			seen = copySeen(seen)
+			seen[ref] = true
+		}
+		req := map[string]bool{}
+		if list, ok := n["required"].([]any); ok {
+			for _, r := range list {
+				req[fmt.Sprint(r)] = true
+			}
+		}
+		if props, ok := n["properties"].(map[string]any); ok {
+			for _, k := range sortedKeys(props) {
+				if p, ok := props[k].(map[string]any); ok {
+					walk(p, path+"."+k, req[k], seen)
+				}
+			}
+		}
+		if ap, ok := n["additionalProperties"].(map[string]any); ok {
+			walk(ap, path+".*", false, seen)
+		}
+		if items, ok := n["items"].(map[string]any); ok {
+			walk(items, path+"[]", false, seen)
+		}
+	}
+	walk(s.root, "", false, map[string]bool{})
+	return doc
+}
+
+func copySeen(seen map[string]bool) map[string]bool {
+	c := make(map[string]bool, len(seen)+1)
+	for k := range seen {
+		c[k] = true
+	}
+	return c
+}
+
+// schemaDocEntryOf documents the node n (raw before following its $ref); the
+// description or default next to a $ref wins over the referenced one.
+func schemaDocEntryOf(raw, n map[string]any, path string, required bool) schemaDocEntry {
+	get := func(k string) any {
+		if v, ok := raw[k]; ok {
+			return v
+		}
+		return n[k]
+	}
+	e := schemaDocEntry{
+		Path:        path,
+		Anchor:      schemaDocAnchor(path),
+		Types:       schemaTypes(n),
+		Title:       docString(get("title")),
+		Description: docString(get("description")),
+		Required:    required,
+	}
+	if len(e.Types) == 0 {
+		e.Types = schemaDocCombinedTypes(n)
+	}
+	if d, ok := get("deprecated").(bool); ok {
+		e.Deprecated = d
+	}
+	if v, ok := raw["default"]; ok {
+		e.Default = docValue(v)
+	} else if v, ok := n["default"]; ok {
+		e.Default = docValue(v)
+	}
+	if enum, ok := get("enum").([]any); ok {
+		for _, v := range enum {
+			e.Enum = append(e.Enum, docValue(v))
+		}
+	}
+	if c, ok := n["const"]; ok {
+		e.Enum = []string{docValue(c)}
+	}
+	if ex, ok := get("examples").([]any); ok {
+		for _, v := range ex {
+			e.Examples = append(e.Examples, docValue(v))
+		}
+	}
+	for _, k := range schemaDocKeywords {
+		if v, ok := n[k]; ok {
+			e.Constraints = append(e.Constraints, k+": "+docValue(v))
+		}
+	}
+	return e
+}
+
+// schemaDocCombinedTypes lists the types of the alternatives of an
+// anyOf/oneOf node, as "string or integer".
+func schemaDocCombinedTypes(n map[string]any) []string {
+	var types []string
+	for _, k := range []string{"anyOf", "oneOf"} {
+		alts, _ := n[k].([]any)
+		for _, a := range alts {
+			if m, ok := a.(map[string]any); ok {
+				types = append(types, schemaTypes(m)...)
+			}
+		}
+	}
+	return types
+}
+
+var anchorUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// schemaDocAnchor returns the anchor of a key path: ".service.ports[].name"
+// is "service-ports-name".
+func schemaDocAnchor(path string) string {
+	a := anchorUnsafe.ReplaceAllString(strings.ToLower(strings.ReplaceAll(path, "*", "any")), "-")
+	return strings.Trim(a, "-")
+}
+
+func docString(v any) string {
+	s, _ := v.(string)
+	return strings.TrimSpace(s)
+}
+
+// docValue formats a default, enum or example value as compact JSON.
+func docValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func (e schemaDocEntry) typeText() string {
+	if len(e.Types) == 0 {
+		return "any"
+	}
+	return strings.Join(e.Types, " or ")
+}
+
+// schemaDocMarkdown renders the doc as Markdown: an index table of every key,
+// then one section per key with an anchor to link to.
+func schemaDocMarkdown(doc schemaDoc) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", doc.Title)
+	if doc.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", doc.Description)
+	}
+	if len(doc.Entries) == 0 {
+		b.WriteString("The schema describes no keys.\n")
+		return b.String()
+	}
+	b.WriteString("| Key | Type | Required | Default |\n|---|---|---|---|\n")
+	for _, e := range doc.Entries {
+		def := ""
+		if e.Default != "" {
+			def = "`" + mdCell(e.Default) + "`"
+		}
+		fmt.Fprintf(&b, "| [`%s`](#%s) | %s | %s | %s |\n", e.Path, e.Anchor, e.typeText(), yesNo(e.Required), def)
+	}
+	for _, e := range doc.Entries {
+		fmt.Fprintf(&b, "\n<a id=\"%s\"></a>\n### `%s`\n\n", e.Anchor, e.Path)
+		if e.Deprecated {
+			b.WriteString("**Deprecated.**\n\n")
+		}
+		if e.Title != "" {
+			fmt.Fprintf(&b, "**%s**\n\n", e.Title)
+		}
+		if e.Description != "" {
+			fmt.Fprintf(&b, "%s\n\n", e.Description)
+		}
+		fmt.Fprintf(&b, "- Type: `%s`\n", e.typeText())
+		fmt.Fprintf(&b, "- Required: %s\n", yesNo(e.Required))
+		if e.Default != "" {
+			fmt.Fprintf(&b, "- Default: `%s`\n", e.Default)
+		}
+		if len(e.Enum) > 0 {
+			fmt.Fprintf(&b, "- Allowed values: `%s`\n", strings.Join(e.Enum, "`, `"))
+		}
+		for _, c := range e.Constraints {
+			fmt.Fprintf(&b, "- %s\n", c)
+		}
+		if len(e.Examples) > 0 {
+			fmt.Fprintf(&b, "- Examples: `%s`\n", strings.Join(e.Examples, "`, `"))
+		}
+	}
+	return b.String()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+var schemaDocTemplate = template.Must(template.New("schema-docs").Funcs(template.FuncMap{
+	"yesNo": yesNo,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: .3rem .5rem; text-align: left; }
+section { margin-top: 2rem; }
+.deprecated { color: #a00; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+{{- with .Description }}
+<p>{{ . }}</p>
+{{- end }}
+<table>
+<thead><tr><th>Key</th><th>Type</th><th>Required</th><th>Default</th></tr></thead>
+<tbody>
+{{- range .Entries }}
+<tr><td><a href="#{{ .Anchor }}"><code>{{ .Path }}</code></a></td><td>{{ .TypeText }}</td><td>{{ yesNo .Required }}</td><td>{{ with .Default }}<code>{{ . }}</code>{{ end }}</td></tr>
+{{- end }}
+</tbody>
+</table>
+{{- range .Entries }}
+<section id="{{ .Anchor }}">
+<h3><a href="#{{ .Anchor }}"><code>{{ .Path }}</code></a></h3>
+{{- if .Deprecated }}
+<p class="deprecated"><strong>Deprecated.</strong></p>
+{{- end }}
+{{- with .Title }}
+<p><strong>{{ . }}</strong></p>
+{{- end }}
+{{- with .Description }}
+<p>{{ . }}</p>
+{{- end }}
+<ul>
+<li>Type: <code>{{ .TypeText }}</code></li>
+<li>Required: {{ yesNo .Required }}</li>
+{{- with .Default }}
+<li>Default: <code>{{ . }}</code></li>
+{{- end }}
+{{- with .Enum }}
+<li>Allowed values: {{ range $i, $v := . }}{{ if $i }}, {{ end }}<code>{{ $v }}</code>{{ end }}</li>
+{{- end }}
+{{- range .Constraints }}
+<li>{{ . }}</li>
+{{- end }}
+{{- with .Examples }}
+<li>Examples: {{ range $i, $v := . }}{{ if $i }}, {{ end }}<code>{{ $v }}</code>{{ end }}</li>
+{{- end }}
+</ul>
+</section>
+{{- end }}
+</body>
+</html>
+`))
+
+// schemaDocHTML renders the doc as a standalone HTML page.
+func schemaDocHTML(doc schemaDoc) ([]byte, error) {
+	type htmlEntry struct {
+		schemaDocEntry
+		TypeText string
+	}
+	data := struct {
+		Title, Description string
+		Entries            []htmlEntry
+	}{Title: doc.Title, Description: doc.Description}
+	for _, e := range doc.Entries {
+		data.Entries = append(data.Entries, htmlEntry{e, e.typeText()})
+	}
+	var buf bytes.Buffer
+	if err := schemaDocTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("render schema docs: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaDrift is what schema drift reports: the keys the values set to their
+// schema default, and the defaults the values leave to the schema.
+type schemaDrift struct {
+	Redundant []driftKey `json:"redundant"` // set to the default: candidates for deletion
+	Implicit  []driftKey `json:"implicit"`  // not set: the default is relied on
+}
+
+// driftKey is one key of a schemaDrift.
+type driftKey struct {
+	Key     string   `json:"key"`
+	Default any      `json:"default"`
+	Files   []string `json:"files,omitempty"` // the values files setting a redundant key
+}
+
+// RunSchemaDrift compares the merged values with the defaults of the schema
+// and prints the keys set to their default and the defaults relied on.
+func RunSchemaDrift(opts SchemaOptions, config *Config) error {
+	switch opts.Format {
+	case "", "text", "json":
+	default:
+		return argsError(fmt.Errorf("unknown format %q (want text or json)", opts.Format))
+	}
+	schemaPath := opts.SchemaPath
+	if schemaPath == "" {
+		schemaPath = FindSchemaFile(config.Schema.Path)
+		if schemaPath == "" {
+			return fmt.Errorf("no schema file found (checked: %s, .templr.schema.yml, .templr/schema.yml)", config.Schema.Path)
+		}
+	}
+	pin := opts.SHA256
+	if pin == "" {
+		pin = config.Schema.SHA256
+	}
+	local, err := resolveSchema(schemaPath, pin)
+	if err != nil {
+		return err
+	}
+	schema, err := loadValueSchema(local, "")
+	if err != nil {
+		return exitError(ExitSchemaError, "schema", err)
+	}
+
+	// Load and merge data, typed by the schema
+	opts.Shared.Schema = local
+	vals, err := buildValues(".", opts.Shared)
+	if err != nil {
+		return err
+	}
+	drift := schemaDrift{Redundant: []driftKey{}, Implicit: []driftKey{}}
+	schemaDriftWalk(schema, schema.root, "", vals, true, &drift, map[string]bool{})
+	if len(drift.Redundant) > 0 {
+		files := valuesFileMaps(valuesInputs(".", opts.Shared))
+		for i := range drift.Redundant {
+			for _, f := range files {
+				if _, ok := lookupValue(f.values, drift.Redundant[i].Key); ok {
+					drift.Redundant[i].Files = append(drift.Redundant[i].Files, f.path)
+				}
+			}
+		}
+	}
+
+	if opts.Format == "json" {
+		enc := json.NewEncoder(sink.Stdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(drift)
+	}
+	out := sink.Stdout()
+	if len(drift.Redundant) > 0 {
+		fmt.Fprintln(out, "set to the schema default (candidates for deletion):")
+		for _, k := range drift.Redundant {
+			where := ""
+			if len(k.Files) > 0 {
+				where = " in " + strings.Join(k.Files, ", ")
+			}
+			fmt.Fprintf(out, "  = %s: %s%s\n", k.Key, formatDiffValue(k.Default), where)
+		}
+	}
+	if len(drift.Implicit) > 0 {
+		fmt.Fprintln(out, "schema defaults relied on (not set):")
+		for _, k := range drift.Implicit {
+			fmt.Fprintf(out, "  ? %s: %s\n", k.Key, formatDiffValue(k.Default))
+		}
+	}
+	if len(drift.Redundant) == 0 && len(drift.Implicit) == 0 {
+		fmt.Fprintln(out, "✓ No schema defaults set or relied on")
+	}
+	return nil
+}
+
+// schemaDriftWalk compares the values v (present tells whether they are set)
+// with the defaults of the named properties of the schema node raw, depth
+// first with the keys of an object sorted. A key set to its default is not
+// looked into further.
+func schemaDriftWalk(s *valueSchema, raw map[string]any, key string, v any, present bool, drift *schemaDrift, seen map[string]bool) {
+	n := s.deref(raw)
+	if key != "" {
+		def, hasDef := raw["default"]
+		if !hasDef {
+			def, hasDef = n["default"]
+		}
+		if hasDef {
+			switch {
+			case !present:
+				drift.Implicit = append(drift.Implicit, driftKey{Key: key, Default: def})
+			case formatDiffValue(v) == formatDiffValue(def):
+				drift.Redundant = append(drift.Redundant, driftKey{Key: key, Default: def})
+				return
+			}
+		}
+	}
+	// A recursive $ref is not expanded again
+	if ref, _ := raw["$ref"].(string); ref != "" {
+		if seen[ref] {
+			return
+		}
+		seen = copySeen(seen)
+		seen[ref] = true
+	}
+	props, ok := n["properties"].(map[string]any)
+	if !ok {
+		return
+	}
+	m, isMap := v.(map[string]any)
+	if present && !isMap {
+		return
+	}
+	for _, k := range sortedKeys(props) {
+		p, ok := props[k].(map[string]any)
+		if !ok {
+			continue
+		}
+		child := k
+		if key != "" {
+			child = key + "." + k
+		}
+		cv, ok := m[k]
+		schemaDriftWalk(s, p, child, cv, ok, drift, seen)
+	}
+}
+
+// valuesFile is one values file, decoded on its own.
+type valuesFile struct {
+	path   string
+	values map[string]any
+}
+
+// valuesFileMaps decodes each YAML or JSON file of paths on its own, to tell
+// which files set a key; files that cannot be read are left out.
+func valuesFileMaps(paths []string) []valuesFile {
+	var files []valuesFile
+	for _, p := range paths {
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		var m map[string]any
+		if yaml.Unmarshal(b, &m) != nil {
+			continue
+		}
+		files = append(files, valuesFile{path: p, values: m})
+	}
+	return files
+}
+
+// lookupValue returns the value at the dotted key of nested maps, and
+// whether it is set.
+func lookupValue(m map[string]any, key string) (any, bool) {
+	var cur any = m
+	for _, k := range strings.Split(key, ".") {
+		mm, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = mm[k]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
@@ -0,0 +1,309 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaMapKeywords hold a map of subschemas; schemaKeywords hold a subschema
+// or a list of them. Only these are converted, so a property named "example"
+// or "nullable" is left alone.
+var (
+	schemaMapKeywords = []string{"properties", "patternProperties"}
+	schemaKeywords    = []string{"items", "additionalProperties", "allOf", "anyOf", "oneOf", "not"}
+)
+
+// openAPIOnlyKeywords have no JSON Schema equivalent and are dropped.
+var openAPIOnlyKeywords = []string{"discriminator", "xml", "externalDocs"}
+
+// readYAMLDocs reads every document of a YAML (or JSON) file.
+func readYAMLDocs(path string) ([]any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	var docs []any
+	for {
+		var doc any
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+// ImportOpenAPISchema converts the schema at pointer (e.g.
+// "#/components/schemas/Config") of an OpenAPI document into a templr schema.
+func ImportOpenAPISchema(path, pointer string) (map[string]any, error) {
+	docs, err := readYAMLDocs(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	if pointer == "" {
+		return nil, fmt.Errorf("--path is required with --openapi (e.g. '#/components/schemas/Config')")
+	}
+	return importSchema(docs[0], pointer)
+}
+
+// ImportCRDSchema converts the openAPIV3Schema of a CustomResourceDefinition
+// into a templr schema. version selects the CRD version (default: the storage
+// version); pointer optionally selects a part of it, e.g. "#/properties/spec".
+func ImportCRDSchema(path, version, pointer string) (map[string]any, error) {
+	docs, err := readYAMLDocs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		m, ok := doc.(map[string]any)
+		if !ok || m["kind"] != "CustomResourceDefinition" {
+			continue
+		}
+		root, err := crdVersionSchema(m, version)
+		if err != nil {
+			return nil, err
+		}
+		return importSchema(root, pointer)
+	}
+	return nil, fmt.Errorf("no CustomResourceDefinition found in %s", path)
+}
+
+// crdVersionSchema returns the openAPIV3Schema of a CRD version.
+func crdVersionSchema(crd map[string]any, version string) (any, error) {
+	spec, _ := crd["spec"].(map[string]any)
+	versions, _ := spec["versions"].([]any)
+	var chosen map[string]any
+	for _, v := range versions {
+		vm, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch {
+		case version != "" && vm["name"] == version:
+			chosen = vm
+		case version == "" && vm["storage"] == true:
+			chosen = vm
+		case version == "" && chosen == nil:
+			chosen = vm
+		}
+		if version != "" && chosen != nil {
+			break
+		}
+	}
+	// apiextensions.k8s.io/v1beta1 keeps one schema for all versions
+	var schema any
+	if chosen != nil {
+		if s, ok := chosen["schema"].(map[string]any); ok {
+			schema = s["openAPIV3Schema"]
+		}
+	}
+	if schema == nil && (chosen != nil || version == "") {
+		if v, ok := spec["validation"].(map[string]any); ok {
+			schema = v["openAPIV3Schema"]
+		}
+	}
+	if schema == nil {
+		if version != "" {
+			return nil, fmt.Errorf("CRD has no schema for version %q", version)
+		}
+		return nil, fmt.Errorf("CRD has no openAPIV3Schema")
+	}
+	return schema, nil
+}
+
+// schemaImporter converts OpenAPI schemas and collects the local $refs they
+// use as definitions.
+type schemaImporter struct {
+	doc   any
+	names map[string]string // $ref -> definition name
+	defs  map[string]any
+}
+
+func importSchema(doc any, pointer string) (map[string]any, error) {
+	node, err := resolvePointer(doc, pointer)
+	if err != nil {
+		return nil, err
+	}
+	imp := &schemaImporter{doc: doc, names: map[string]string{}, defs: map[string]any{}}
+	converted, err := imp.convert(node)
+	if err != nil {
+		return nil, err
+	}
+	out, ok := converted.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a schema object", pointer)
+	}
+	out["$schema"] = "http://json-schema.org/draft-07/schema#"
+	if len(imp.defs) > 0 {
+		out["definitions"] = imp.defs
+	}
+	return out, nil
+}
+
+// convert returns a JSON Schema copy of an OpenAPI schema node.
+func (imp *schemaImporter) convert(node any) (any, error) {
+	m, ok := node.(map[string]any)
+	if !ok {
+		return node, nil
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+
+	if ref, ok := out["$ref"].(string); ok {
+		name, err := imp.define(ref)
+		if err != nil {
+			return nil, err
+		}
+		out["$ref"] = "#/definitions/" + name
+	}
+
+	for _, k := range schemaMapKeywords {
+		props, ok := out[k].(map[string]any)
+		if !ok {
+			continue
+		}
+		conv := make(map[string]any, len(props))
+		for name, sub := range props {
+			c, err := imp.convert(sub)
+			if err != nil {
+				return nil, err
+			}
+			conv[name] = c
+		}
+		out[k] = conv
+	}
+	for _, k := range schemaKeywords {
+		switch sub := out[k].(type) {
+		case map[string]any:
+			c, err := imp.convert(sub)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = c
+		case []any:
+			list := make([]any, len(sub))
+			for i, s := range sub {
+				c, err := imp.convert(s)
+				if err != nil {
+					return nil, err
+				}
+				list[i] = c
+			}
+			out[k] = list
+		}
+	}
+
+	// OpenAPI 3.0 nullable -> type list with "null"
+	if nullable, _ := out["nullable"].(bool); nullable {
+		if t, ok := out["type"].(string); ok {
+			out["type"] = []any{t, "null"}
+		}
+	}
+	delete(out, "nullable")
+
+	if ex, ok := out["example"]; ok {
+		out["examples"] = []any{ex}
+		delete(out, "example")
+	}
+
+	// Kubernetes int-or-string fields accept both
+	if ios, _ := out["x-kubernetes-int-or-string"].(bool); ios {
+		out["anyOf"] = []any{map[string]any{"type": "integer"}, map[string]any{"type": "string"}}
+	}
+	for k := range out {
+		if strings.HasPrefix(k, "x-") {
+			delete(out, k)
+		}
+	}
+	for _, k := range openAPIOnlyKeywords {
+		delete(out, k)
+	}
+	return out, nil
+}
+
+// define converts the schema a local $ref points to into a definition once
+// and returns its name. Recursive references work since the name is taken
+// before the schema is converted.
+func (imp *schemaImporter) define(ref string) (string, error) {
+	if name, ok := imp.names[ref]; ok {
+		return name, nil
+	}
+	if !strings.HasPrefix(ref, "#") {
+		return "", fmt.Errorf("$ref %q: only references within the document are supported", ref)
+	}
+	target, err := resolvePointer(imp.doc, ref)
+	if err != nil {
+		return "", fmt.Errorf("$ref %q: %w", ref, err)
+	}
+
+	base := ref[strings.LastIndex(ref, "/")+1:]
+	name := base
+	for i := 2; imp.defs[name] != nil; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	imp.names[ref] = name
+	imp.defs[name] = map[string]any{} // reserve the name
+
+	converted, err := imp.convert(target)
+	if err != nil {
+		return "", err
+	}
+	imp.defs[name] = converted
+	return name, nil
+}
+
+// resolvePointer returns the node at a JSON pointer ("#/a/b" or "/a/b").
+// An empty pointer or "#" is the document itself.
+func resolvePointer(doc any, pointer string) (any, error) {
+	p := strings.TrimPrefix(pointer, "#")
+	if p == "" || p == "/" {
+		return doc, nil
+	}
+	node := doc
+	for _, part := range strings.Split(strings.TrimPrefix(p, "/"), "/") {
+		part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+		switch n := node.(type) {
+		case map[string]any:
+			next, ok := n[part]
+			if !ok {
+				return nil, fmt.Errorf("%s not found (no %q; have %s)", pointer, part, strings.Join(sortedKeys(n), ", "))
+			}
+			node = next
+		case []any:
+			var i int
+			if _, err := fmt.Sscanf(part, "%d", &i); err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("%s not found (bad index %q)", pointer, part)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("%s not found", pointer)
+		}
+	}
+	return node, nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,185 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// schemaHTTPClient fetches remote schemas; a schema registry that does not
+// answer in time falls back to the cached copy.
+var schemaHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// maxSchemaBytes bounds the size of a fetched schema.
+const maxSchemaBytes = 8 << 20
+
+// isSchemaURL reports whether a schema reference is an http(s) URL rather
+// than a file path.
+func isSchemaURL(ref string) bool {
+	return strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://")
+}
+
+// resolveSchema returns a local file holding the schema ref names: ref itself
+// for a file path, the cached copy for a URL. With pin, the schema must have
+// that sha256 digest (hex).
+func resolveSchema(ref, pin string) (string, error) {
+	if ref == "" {
+		return "", nil
+	}
+	pin = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(pin), "sha256:"))
+	path := ref
+	if isSchemaURL(ref) {
+		var err error
+		if path, err = fetchSchema(ref, pin); err != nil {
+			return "", err
+		}
+	}
+	if pin == "" {
+		return path, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read schema: %w", err)
+	}
+	if got := schemaDigest(b); got != pin {
+		return "", fmt.Errorf("schema %s: sha256 %s does not match the pinned %s", ref, got, pin)
+	}
+	return path, nil
+}
+
+// fetchSchema downloads the schema at rawURL into the user cache directory
+// and returns the cached file. The ETag of the last download is sent as
+// If-None-Match, so an unchanged schema is not transferred again; a cached
+// copy that already matches pin is used without a request. When the registry
+// cannot be reached, the cached copy is used with a warning.
+func fetchSchema(rawURL, pin string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("schema URL %s: %w", rawURL, err)
+	}
+	if u.Scheme != "https" && !isLoopbackHost(u.Hostname()) {
+		return "", fmt.Errorf("schema URL %s: only https is allowed (http only for localhost)", rawURL)
+	}
+
+	dir, err := schemaCacheDir()
+	if err != nil {
+		return "", err
+	}
+	key := schemaDigest([]byte(rawURL))
+	path := filepath.Join(dir, key+".schema")
+	etagPath := filepath.Join(dir, key+".etag")
+
+	cached, cacheErr := os.ReadFile(path)
+	haveCache := cacheErr == nil
+	if haveCache && pin != "" && schemaDigest(cached) == pin {
+		return path, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("schema URL %s: %w", rawURL, err)
+	}
+	req.Header.Set("Accept", "application/schema+json, application/yaml, application/json, */*")
+	if haveCache {
+		if etag, err := os.ReadFile(etagPath); err == nil && len(etag) > 0 {
+			req.Header.Set("If-None-Match", strings.TrimSpace(string(etag)))
+		}
+	}
+
+	resp, err := schemaHTTPClient.Do(req)
+	if err != nil {
+		if haveCache {
+			warnf("schema", "fetch %s: %v; using the cached copy", rawURL, err)
+			return path, nil
+		}
+		return "", fmt.Errorf("fetch schema %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && haveCache:
+		return path, nil
+	case resp.StatusCode != http.StatusOK:
+		if haveCache && resp.StatusCode >= 500 {
+			warnf("schema", "fetch %s: %s; using the cached copy", rawURL, resp.Status)
+			return path, nil
+		}
+		return "", fmt.Errorf("fetch schema %s: %s", rawURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSchemaBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("fetch schema %s: %w", rawURL, err)
+	}
+	if len(body) > maxSchemaBytes {
+		return "", fmt.Errorf("fetch schema %s: larger than %d bytes", rawURL, maxSchemaBytes)
+	}
+	if err := writeFileAtomic(path, body); err != nil {
+		return "", fmt.Errorf("cache schema: %w", err)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		err = writeFileAtomic(etagPath, []byte(etag))
+	} else {
+		err = os.Remove(etagPath)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("cache schema: %w", err)
+	}
+	return path, nil
+}
+
+// schemaCacheDir returns (and creates) the directory of cached remote schemas.
+func schemaCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("schema cache: %w", err)
+	}
+	dir := filepath.Join(base, "templr", "schemas")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("schema cache: %w", err)
+	}
+	return dir, nil
+}
+
+// writeFileAtomic writes b to path through a temporary file, so a concurrent
+// reader never sees a partial schema.
+func writeFileAtomic(path string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func schemaDigest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
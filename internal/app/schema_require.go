@@ -0,0 +1,43 @@
+package app
+
+import "fmt"
+
+// SchemaEnforceRender is the schema.enforce setting that makes render, dir
+// and walk validate the values before rendering, like --require-schema.
+const SchemaEnforceRender = "render"
+
+// checkSchemaEnforce validates schema.enforce: empty, off or render.
+func checkSchemaEnforce(shared SharedOptions) error {
+	switch shared.SchemaEnforce {
+	case "", "off", SchemaEnforceRender:
+		return nil
+	}
+	return argsError(fmt.Errorf("invalid schema.enforce %q: want render or off", shared.SchemaEnforce))
+}
+
+// requireSchema validates values against the schema of the run, with
+// --require-schema or schema.enforce: render, before anything is rendered:
+// values that do not match stop the run with ExitSchemaError, so that no
+// output tree is left half updated.
+func requireSchema(values map[string]any, shared SharedOptions) error {
+	if !shared.RequireSchema && shared.SchemaEnforce != SchemaEnforceRender {
+		return nil
+	}
+	if shared.Schema == "" {
+		return exitError(ExitSchemaError, "schema", fmt.Errorf("--require-schema: no schema file found (checked: schema.path, %s, .templr/schema.yml)", DefaultSchemaFile))
+	}
+	path, err := resolveSchema(shared.Schema, shared.SchemaSHA256)
+	if err != nil {
+		return exitError(ExitSchemaError, "schema", err)
+	}
+	result, err := ValidateWithSchema(values, path, "error")
+	if err != nil {
+		return exitError(ExitSchemaError, "schema", fmt.Errorf("schema validation failed: %w", err))
+	}
+	if !result.Passed {
+		fmt.Fprint(sink.Stderr(), FormatSchemaErrors(result, "error"))
+		return exitError(ExitSchemaError, "schema", fmt.Errorf("values do not match the schema %s; nothing was rendered", shared.Schema))
+	}
+	debugf(shared.Debug, "Values match the schema %s", shared.Schema)
+	return nil
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// scopeConfigFile is the file in a directory of a dir or walk tree whose
+// template section applies to the templates below that directory.
+const scopeConfigFile = ".templr.yaml"
+
+// TemplateScope overrides the delimiters and strictness of the templates
+// under a path of a dir or walk tree, so that a tree can mix templates that
+// must keep a literal {{ with normal ones.
+type TemplateScope struct {
+	Path           string `yaml:"path"`            // template path glob relative to the tree; a directory covers everything below it
+	LeftDelimiter  string `yaml:"left_delimiter"`  // default: inherited
+	RightDelimiter string `yaml:"right_delimiter"` // default: inherited
+	Strict         *bool  `yaml:"strict"`          // default: inherited
+}
+
+// scopeConfig is the part of a .templr.yaml inside a tree that is read.
+type scopeConfig struct {
+	Template struct {
+		LeftDelimiter  string `yaml:"left_delimiter"`
+		RightDelimiter string `yaml:"right_delimiter"`
+		Strict         *bool  `yaml:"strict"`
+	} `yaml:"template"`
+}
+
+// engineOptions are the parse and execution options of one template.
+type engineOptions struct {
+	ldelim, rdelim string
+	strict         bool
+}
+
+// missingKey returns the missingkey option of the template.
+func (o engineOptions) missingKey() string {
+	if o.strict {
+		return "missingkey=error"
+	}
+	return "missingkey=default"
+}
+
+// templateScopes resolves the engine options of the templates of a tree:
+// the command line options, overridden by the template.scopes rules of the
+// config in order and then by the .templr.yaml files of the tree's
+// subdirectories, deeper directories last.
+type templateScopes struct {
+	base  engineOptions
+	rules []TemplateScope
+}
+
+// loadTemplateScopes collects the scope rules for the tree at root.
+func loadTemplateScopes(root string, shared SharedOptions) (*templateScopes, error) {
+	s := &templateScopes{
+		base:  engineOptions{ldelim: shared.Ldelim, rdelim: shared.Rdelim, strict: shared.Strict},
+		rules: append([]TemplateScope(nil), shared.TemplateScopes...),
+	}
+	for _, r := range s.rules {
+		if _, err := path.Match(r.Path, ""); err != nil || r.Path == "" {
+			return nil, argsError(fmt.Errorf("template.scopes: invalid path %q", r.Path))
+		}
+	}
+	// WalkDir visits a directory before its subdirectories, so deeper files win
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || p == root {
+			return err
+		}
+		b, err := os.ReadFile(filepath.Join(p, scopeConfigFile))
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		var c scopeConfig
+		if err := yaml.Unmarshal(b, &c); err != nil {
+			return exitError(ExitGeneral, "config", fmt.Errorf("%s/%s: %w", rel, scopeConfigFile, err))
+		}
+		s.rules = append(s.rules, TemplateScope{
+			Path:           rel,
+			LeftDelimiter:  c.Template.LeftDelimiter,
+			RightDelimiter: c.Template.RightDelimiter,
+			Strict:         c.Template.Strict,
+		})
+		return nil
+	})
+	return s, err
+}
+
+// options returns the engine options of the template rel.
+func (s *templateScopes) options(rel string) engineOptions {
+	o := s.base
+	for _, r := range s.rules {
+		if !scopeMatches(r.Path, rel) {
+			continue
+		}
+		if r.LeftDelimiter != "" {
+			o.ldelim = r.LeftDelimiter
+		}
+		if r.RightDelimiter != "" {
+			o.rdelim = r.RightDelimiter
+		}
+		if r.Strict != nil {
+			o.strict = *r.Strict
+		}
+	}
+	return o
+}
+
+// prepare sets the missingkey option of the template set for rendering rel
+// and reports whether rel renders in strict mode. Options are shared by all
+// templates of a set, so the templates rel includes follow its strictness.
+func (s *templateScopes) prepare(tpl *template.Template, rel string) bool {
+	o := s.options(rel)
+	tpl.Option(o.missingKey())
+	return o.strict
+}
+
+// scopeMatches reports whether the glob pat matches the template path rel or
+// one of its directories.
+func scopeMatches(pat, rel string) bool {
+	pat = strings.TrimSuffix(pat, "/")
+	for p := rel; p != "." && p != "/"; p = path.Dir(p) {
+		if ok, _ := path.Match(pat, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isScopeConfig reports whether the tree file rel is the .templr.yaml of a
+// subdirectory rather than a template.
+func isScopeConfig(rel string) bool {
+	return strings.Contains(rel, "/") && path.Base(rel) == scopeConfigFile
+}
@@ -0,0 +1,189 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/kanopi/templr/pkg/lint"
+	"github.com/kanopi/templr/pkg/rpc/templrv1"
+	"github.com/kanopi/templr/pkg/templr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+)
+
+// ServeOptions holds options for serve mode
+type ServeOptions struct {
+	Shared         SharedOptions
+	GRPC           string  // address of the gRPC RenderService
+	AllowHostFuncs bool    // let requests call templr.HostFuncs
+	Config         *Config // configuration from file
+}
+
+// RunServe serves the RenderService on opts.GRPC until SIGINT or SIGTERM,
+// then lets the calls in progress finish.
+func RunServe(opts ServeOptions) error {
+	if opts.GRPC == "" {
+		return argsError(fmt.Errorf("serve requires --grpc <addr>"))
+	}
+	if err := checkCryptoPolicy(opts.Shared); err != nil {
+		return err
+	}
+	if err := checkMaxOutputSize(opts.Shared); err != nil {
+		return err
+	}
+	maxOutput, _ := maxOutputSize(opts.Shared)
+	lis, err := net.Listen("tcp", opts.GRPC)
+	if err != nil {
+		return exitError(ExitGeneral, "serve", fmt.Errorf("listen %s: %w", opts.GRPC, err))
+	}
+	srv := grpc.NewServer()
+	templrv1.RegisterRenderServiceServer(srv, &renderService{opts: opts, maxOutput: int(maxOutput)})
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	go func() {
+		<-sig
+		srv.GracefulStop()
+	}()
+
+	fmt.Fprintf(os.Stderr, "templr: gRPC RenderService listening on %s\n", lis.Addr())
+	return srv.Serve(lis)
+}
+
+// renderService implements templrv1.RenderServiceServer on top of the
+// in-memory engine; requests never read the server's filesystem.
+type renderService struct {
+	templrv1.UnimplementedRenderServiceServer
+	opts      ServeOptions
+	maxOutput int // --max-output-size in bytes, per output
+}
+
+// engineOptions maps request options onto the engine, with the functions
+// the server disables through its config and --crypto-policy, and the host
+// functions unless --allow-host-funcs is set.
+func (s *renderService) engineOptions(ro *templrv1.RenderOptions, warnings *[]string) templr.Options {
+	disabled := append([]string{}, s.opts.Shared.DisabledFuncs...)
+	if !s.opts.AllowHostFuncs {
+		disabled = append(disabled, templr.HostFuncs...)
+	}
+	opts := templr.Options{
+		ValuesYAML:      ro.GetValues(),
+		Helpers:         ro.GetHelpers(),
+		Strict:          ro.GetStrict(),
+		DefaultMissing:  ro.GetDefaultMissing(),
+		InjectGuard:     ro.GetInjectGuard(),
+		GuardMarker:     ro.GetGuardMarker(),
+		DisabledFuncs:   disabled,
+		IncludeCache:    s.opts.Shared.IncludeCache,
+		IncludeMaxDepth: s.opts.Shared.IncludeMaxDepth,
+		CryptoPolicy:    s.opts.Shared.CryptoPolicy,
+		MaxOutputSize:   s.maxOutput,
+		WarnFunc: func(msg string) {
+			*warnings = append(*warnings, msg)
+		},
+	}
+	if files := ro.GetFiles(); len(files) > 0 {
+		opts.Files = templr.FilesMap(files)
+	}
+	return opts
+}
+
+func (s *renderService) RenderSingle(ctx context.Context, req *templrv1.RenderSingleRequest) (*templrv1.RenderSingleResponse, error) {
+	var warnings []string
+	opts := s.engineOptions(req.GetOptions(), &warnings)
+	opts.Template = req.GetTemplate()
+	res, err := templr.RenderSingleContext(ctx, opts)
+	if err != nil {
+		if err := contextError(ctx); err != nil {
+			return nil, err
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &templrv1.RenderSingleResponse{Output: res.Output, Warnings: warnings}, nil
+}
+
+func (s *renderService) RenderTree(ctx context.Context, req *templrv1.RenderTreeRequest) (*templrv1.RenderTreeResponse, error) {
+	var warnings []string
+	outputs, err := templr.RenderTreeContext(ctx, templr.TreeOptions{
+		Options:   s.engineOptions(req.GetOptions(), &warnings),
+		Templates: req.GetTemplates(),
+	})
+	if err != nil {
+		if err := contextError(ctx); err != nil {
+			return nil, err
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &templrv1.RenderTreeResponse{Outputs: outputs, Warnings: warnings}, nil
+}
+
+func (s *renderService) Lint(ctx context.Context, req *templrv1.LintRequest) (*templrv1.LintResponse, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	if v := req.GetValues(); v != "" {
+		if err := yaml.Unmarshal([]byte(v), &values); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "values: %v", err)
+		}
+		if values == nil {
+			values = map[string]any{}
+		}
+	}
+	result := &lint.Result{Issues: []lint.Issue{}}
+	lintSource("template", []byte(req.GetTemplate()), values, LintOptions{Shared: s.opts.Shared, Config: s.opts.Config}, result)
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result.Issues, func(i, j int) bool { return result.Issues[i].Line < result.Issues[j].Line })
+
+	res := &templrv1.LintResponse{}
+	for _, is := range result.Issues {
+		res.Issues = append(res.Issues, &templrv1.LintIssue{
+			Rule:     is.RuleID(),
+			Severity: is.Severity,
+			Line:     int32(is.Line),
+			Message:  is.Message,
+		})
+	}
+	return res, nil
+}
+
+func (s *renderService) ValidateSchema(ctx context.Context, req *templrv1.ValidateSchemaRequest) (*templrv1.ValidateSchemaResponse, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+	values := map[string]any{}
+	if err := yaml.Unmarshal([]byte(req.GetValues()), &values); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "values: %v", err)
+	}
+	result, err := validateSchemaBytes(values, []byte(req.GetSchema()), "error")
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	res := &templrv1.ValidateSchemaResponse{Valid: result.Passed}
+	for _, e := range result.Errors {
+		res.Errors = append(res.Errors, &templrv1.SchemaError{Path: e.Path, Message: e.Message, Suggestion: e.Suggestion})
+	}
+	return res, nil
+}
+
+// contextError returns the CANCELLED or DEADLINE_EXCEEDED status of a call
+// whose client went away or ran out of time, or nil while ctx is live.
+func contextError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return nil
+}
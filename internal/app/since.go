@@ -0,0 +1,233 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// sinceChanges are the files that differ between a git ref and the working
+// tree, untracked files included (--since).
+type sinceChanges struct {
+	ref   string
+	root  string          // top level of the repository
+	files map[string]bool // absolute, symlink-resolved paths
+}
+
+// gitChangesSince asks git which files changed since ref.
+func gitChangesSince(ref string) (*sinceChanges, error) {
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("--since requires a git repository: %w", err)
+	}
+	if _, err := gitOutput("rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, argsError(fmt.Errorf("--since %s: not a commit of this repository", ref))
+	}
+	c := &sinceChanges{ref: ref, root: strings.TrimSpace(root), files: map[string]bool{}}
+	diff, err := gitOutput("diff", "--name-only", "-z", "--no-renames", ref)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitOutput("ls-files", "--others", "--exclude-standard", "-z", "--full-name", ":/")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Split(diff+"\x00"+untracked, "\x00") {
+		if name != "" {
+			c.files[realPath(filepath.Join(c.root, filepath.FromSlash(name)))] = true
+		}
+	}
+	return c, nil
+}
+
+// changed reports whether the file at path changed since the ref.
+func (c *sinceChanges) changed(path string) bool {
+	return c.files[realPath(path)]
+}
+
+// valuesChanged returns the values files among inputs that changed.
+func (c *sinceChanges) valuesChanged(inputs []string) []string {
+	var changed []string
+	for _, f := range inputs {
+		if f != "" && c.changed(f) {
+			changed = append(changed, f)
+		}
+	}
+	return changed
+}
+
+// show returns the content of path at the ref, or false when it did not
+// exist there.
+func (c *sinceChanges) show(path string) ([]byte, bool) {
+	rel, err := filepath.Rel(c.root, realPath(path))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil, false
+	}
+	out, err := gitOutput("show", c.ref+":"+filepath.ToSlash(rel))
+	if err != nil {
+		return nil, false
+	}
+	return []byte(out), true
+}
+
+// valuesAtRef merges the values of a run as they were at the ref: the
+// default values file of baseDir, --data and -f as of the ref (files that
+// did not exist there are left out), with the same --set and options.
+func (c *sinceChanges) valuesAtRef(baseDir string, shared SharedOptions) (map[string]any, error) {
+	tmp, err := os.MkdirTemp("", "templr-since-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	// old copies keep their file names, which decide how they are read
+	n := 0
+	materialize := func(path string) (string, error) {
+		b, ok := c.show(path)
+		if !ok {
+			return "", nil
+		}
+		n++
+		dir := filepath.Join(tmp, fmt.Sprint(n))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+		p := filepath.Join(dir, filepath.Base(path))
+		return p, os.WriteFile(p, b, 0o644)
+	}
+
+	base := filepath.Join(tmp, "base")
+	if err := os.MkdirAll(base, 0o755); err != nil {
+		return nil, err
+	}
+	for _, name := range []string{"values.yaml", "values.yml"} {
+		if b, ok := c.show(filepath.Join(baseDir, name)); ok {
+			if err := os.WriteFile(filepath.Join(base, name), b, 0o644); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if shared.Data != "" {
+		if shared.Data, err = materialize(shared.Data); err != nil {
+			return nil, err
+		}
+	}
+	var files []string
+	for _, f := range shared.Files {
+		p, err := materialize(f)
+		if err != nil {
+			return nil, err
+		}
+		if p != "" {
+			files = append(files, p)
+		}
+	}
+	shared.Files = files
+	shared.Debug = false
+	return buildValues(base, shared)
+}
+
+// valueKeys returns the values keys that changed since the ref, when a
+// values file of the run did: values is compared with valuesAtRef.
+func (c *sinceChanges) valueKeys(absSrc string, values map[string]any, shared SharedOptions) ([]string, error) {
+	if len(c.valuesChanged(valuesInputs(absSrc, shared))) == 0 {
+		return nil, nil
+	}
+	old, err := c.valuesAtRef(absSrc, shared)
+	if err != nil {
+		return nil, fmt.Errorf("--since %s: values: %w", c.ref, err)
+	}
+	var changes []valueChange
+	diffValues("", old, values, &changes)
+	keys := make([]string, len(changes))
+	for i, ch := range changes {
+		keys[i] = ch.Key
+	}
+	return keys, nil
+}
+
+// filesChanged reports whether a file under absSrc other than its templates
+// and values files changed, which templates reading .Files may depend on.
+func (c *sinceChanges) filesChanged(absSrc string, names []string, shared SharedOptions) bool {
+	known := map[string]bool{}
+	for _, name := range names {
+		known[realPath(filepath.Join(absSrc, filepath.FromSlash(name)))] = true
+	}
+	for _, f := range valuesInputs(absSrc, shared) {
+		known[realPath(f)] = true
+	}
+	prefix := realPath(absSrc) + string(filepath.Separator)
+	for f := range c.files {
+		if strings.HasPrefix(f, prefix) && !known[f] {
+			return true
+		}
+	}
+	return false
+}
+
+// changedDefs returns the templates of tpl parsed from a file under absSrc
+// that changed since the ref: the file's own template and its defines.
+func (c *sinceChanges) changedDefs(tpl *template.Template, absSrc string) map[string]bool {
+	defs := map[string]bool{}
+	for _, t := range tpl.Templates() {
+		if t.Tree != nil && c.changed(filepath.Join(absSrc, filepath.FromSlash(t.Tree.ParseName))) {
+			defs[t.Name()] = true
+		}
+	}
+	return defs
+}
+
+// affectedSince reports whether the template deps were collected for reads
+// a changed key or renders a template of a changed file.
+func affectedSince(d *templateDeps, defs map[string]bool, keys []string) bool {
+	if d.dynamic && len(defs) > 0 {
+		return true
+	}
+	for name := range d.templates {
+		if defs[name] {
+			return true
+		}
+	}
+	return d.affectedBy(keys)
+}
+
+// sinceScope returns the template files lint --since checks: those changed
+// since the ref and those that render a template of a changed file. When a
+// values file in use changed every template is in scope, so nil is returned.
+func sinceScope(opts LintOptions) (map[string]bool, error) {
+	c, err := gitChangesSince(opts.Since)
+	if err != nil {
+		return nil, err
+	}
+	base := lintBaseDir(opts)
+	valueFiles := append([]string{opts.Shared.Data, filepath.Join(base, "values.yaml"), filepath.Join(base, "values.yml")}, opts.Shared.Files...)
+	if vf := c.valuesChanged(valueFiles); len(vf) > 0 {
+		debugf(opts.Shared.Debug, "values file %s changed since %s: linting all templates", vf[0], opts.Since)
+		return nil, nil
+	}
+	scope := make(map[string]bool, len(c.files))
+	for f := range c.files {
+		scope[f] = true
+	}
+	root := firstNonEmpty(opts.Src, opts.Dir)
+	if root == "" {
+		return scope, nil
+	}
+	// a template that does not parse is linted for itself; nothing to expand
+	tpl, names, err := parseTree(root, opts.Shared)
+	if err != nil {
+		return scope, nil
+	}
+	absRoot, _ := filepath.Abs(root)
+	defs := c.changedDefs(tpl, absRoot)
+	deps := newValueDeps(tpl)
+	for _, name := range names {
+		if affectedSince(deps.of(name), defs, nil) {
+			scope[realPath(filepath.Join(absRoot, filepath.FromSlash(name)))] = true
+		}
+	}
+	return scope, nil
+}
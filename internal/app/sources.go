@@ -0,0 +1,28 @@
+package app
+
+// templateSources holds the source of each parsed template, used for the
+// context of error reports and the audit digests of templates. Sources are kept as the strings given to the
+// parser, which each parse.Tree retains as its text anyway: keeping them
+// costs no memory of their own, and an error is always shown against the
+// source that was parsed, never a file re-read after it changed.
+type templateSources struct {
+	src map[string]string
+}
+
+func newTemplateSources() *templateSources {
+	return &templateSources{src: map[string]string{}}
+}
+
+// set keeps src for the template name.
+func (s *templateSources) set(name, src string) {
+	s.src[name] = src
+}
+
+// get returns the source of the template name and whether it is known.
+func (s *templateSources) get(name string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	src, ok := s.src[name]
+	return src, ok
+}
@@ -0,0 +1,254 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SummarizeOptions contains options for `templr summarize`
+type SummarizeOptions struct {
+	Guard   string   // marker that identifies generated files
+	Redact  []string // dotted key patterns whose values are hidden
+	Base    string   // git ref the working tree is compared with
+	Paths   []string // limit the comparison to these paths
+	Output  string   // write the summary to this file instead of stdout
+	MaxKeys int      // key changes listed per file (0: default)
+}
+
+// defaultSummaryKeys is the number of key changes listed per file.
+const defaultSummaryKeys = 10
+
+// summaryFile is one generated file that differs from the base ref.
+type summaryFile struct {
+	Path    string
+	Op      string // "added", "changed" or "removed"
+	Changes []valueChange
+}
+
+// RunSummarize compares the generated files of the working tree with the
+// base ref and prints a Markdown summary for a merge request description.
+func RunSummarize(opts SummarizeOptions) error {
+	if opts.Base == "" {
+		return argsError(fmt.Errorf("--base is required"))
+	}
+	if opts.MaxKeys <= 0 {
+		opts.MaxKeys = defaultSummaryKeys
+	}
+	if _, err := gitOutput("rev-parse", "--verify", "--quiet", opts.Base+"^{commit}"); err != nil {
+		return argsError(fmt.Errorf("--base %s: not a commit of this repository", opts.Base))
+	}
+
+	files, err := summarizeFiles(opts)
+	if err != nil {
+		return err
+	}
+	md := summaryMarkdown(opts.Base, files, opts.MaxKeys)
+	if opts.Output != "" {
+		if err := os.WriteFile(opts.Output, []byte(md), 0o644); err != nil {
+			return fmt.Errorf("write summary: %w", err)
+		}
+		return nil
+	}
+	_, err = io.WriteString(sink.Stdout(), md)
+	return err
+}
+
+// summarizeFiles lists the generated files that differ between the base ref
+// and the working tree, untracked ones included, in path order. A file is
+// generated when either side of it carries the guard marker.
+func summarizeFiles(opts SummarizeOptions) ([]summaryFile, error) {
+	pathspec := append([]string{"--"}, opts.Paths...)
+	diff, err := gitOutput(append([]string{"diff", "--name-status", "-z", "--no-renames", "--relative", opts.Base}, pathspec...)...)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitOutput(append([]string{"ls-files", "--others", "--exclude-standard", "-z"}, pathspec...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := map[string]string{}
+	fields := strings.Split(strings.TrimRight(diff, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		switch fields[i] {
+		case "A":
+			ops[fields[i+1]] = "added"
+		case "D":
+			ops[fields[i+1]] = "removed"
+		default:
+			ops[fields[i+1]] = "changed"
+		}
+	}
+	for _, name := range strings.Split(untracked, "\x00") {
+		if name != "" {
+			ops[name] = "added"
+		}
+	}
+
+	names := make([]string, 0, len(ops))
+	for name := range ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var files []summaryFile
+	for _, name := range names {
+		op := ops[name]
+		var before, after []byte
+		if op != "added" {
+			// ./ makes the path relative to the current directory, as --relative does
+			if s, err := gitOutput("show", opts.Base+":./"+filepath.ToSlash(name)); err == nil {
+				before = []byte(s)
+			}
+		}
+		if op != "removed" {
+			if after, err = os.ReadFile(name); err != nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					return nil, err
+				}
+				op = "removed"
+			}
+		}
+		if !hasGuardFlexible(name, before, opts.Guard) && !hasGuardFlexible(name, after, opts.Guard) {
+			continue
+		}
+		f := summaryFile{Path: filepath.ToSlash(name), Op: op}
+		if op == "changed" {
+			f.Changes = structuredDiff(name, before, after)
+			for i := range f.Changes {
+				f.Changes[i].redact(opts.Redact)
+			}
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// structuredDiff returns the key changes between two versions of a YAML or
+// JSON file, or nil when the file is of another type or either side does
+// not parse. The documents of a multi-document file are keyed by index.
+func structuredDiff(name string, before, after []byte) []valueChange {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+	default:
+		return nil
+	}
+	a, aok := decodeDocs(before)
+	b, bok := decodeDocs(after)
+	if !aok || !bok {
+		return nil
+	}
+	var changes []valueChange
+	diffValues("", a, b, &changes)
+	return changes
+}
+
+// decodeDocs decodes the YAML documents of b into one map: the document
+// itself when there is a single map, else the documents by index.
+func decodeDocs(b []byte) (map[string]any, bool) {
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	var docs []any
+	for {
+		var doc any
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, false
+		}
+		if doc != nil {
+			docs = append(docs, normalizeYAML(doc))
+		}
+	}
+	if len(docs) == 1 {
+		if m, ok := docs[0].(map[string]any); ok {
+			return m, true
+		}
+	}
+	m := make(map[string]any, len(docs))
+	for i, d := range docs {
+		m[fmt.Sprintf("[%d]", i)] = d
+	}
+	return m, true
+}
+
+// normalizeYAML converts the map[any]any maps yaml may decode into
+// map[string]any, so they are compared key by key.
+func normalizeYAML(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, e := range x {
+			x[k] = normalizeYAML(e)
+		}
+		return x
+	case map[any]any:
+		m := make(map[string]any, len(x))
+		for k, e := range x {
+			m[fmt.Sprint(k)] = normalizeYAML(e)
+		}
+		return m
+	case []any:
+		for i, e := range x {
+			x[i] = normalizeYAML(e)
+		}
+	}
+	return v
+}
+
+// summaryMarkdown renders the summary of files compared with base.
+func summaryMarkdown(base string, files []summaryFile, maxKeys int) string {
+	var b strings.Builder
+	b.WriteString("### templr render summary\n\n")
+	if len(files) == 0 {
+		fmt.Fprintf(&b, "No generated files differ from `%s`.\n", base)
+		return b.String()
+	}
+	counts := map[string]int{}
+	for _, f := range files {
+		counts[f.Op]++
+	}
+	fmt.Fprintf(&b, "Compared with `%s`: %d added, %d changed, %d removed generated file%s.\n\n",
+		base, counts["added"], counts["changed"], counts["removed"], pluralize(len(files)))
+
+	b.WriteString("| File | Change |\n|---|---|\n")
+	for _, f := range files {
+		fmt.Fprintf(&b, "| `%s` | %s |\n", mdCell(f.Path), f.Op)
+	}
+
+	var notable []summaryFile
+	for _, f := range files {
+		if len(f.Changes) > 0 {
+			notable = append(notable, f)
+		}
+	}
+	if len(notable) == 0 {
+		return b.String()
+	}
+	b.WriteString("\n#### Key changes\n")
+	for _, f := range notable {
+		fmt.Fprintf(&b, "\n`%s`\n\n", f.Path)
+		for i, c := range f.Changes {
+			if i == maxKeys {
+				fmt.Fprintf(&b, "- ... and %d more\n", len(f.Changes)-maxKeys)
+				break
+			}
+			switch c.Op {
+			case "added":
+				fmt.Fprintf(&b, "- added `%s`: `%s`\n", c.Key, formatDiffValue(c.New))
+			case "removed":
+				fmt.Fprintf(&b, "- removed `%s` (was `%s`)\n", c.Key, formatDiffValue(c.Old))
+			default:
+				fmt.Fprintf(&b, "- `%s`: `%s` → `%s`\n", c.Key, formatDiffValue(c.Old), formatDiffValue(c.New))
+			}
+		}
+	}
+	return b.String()
+}
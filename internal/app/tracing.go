@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kanopi/templr/pkg/templr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// traceCtx carries the running command's root span. The CLI runs a single
+	// command per process, so helpers read it instead of threading a context.
+	traceCtx = context.Background()
+
+	// tracingShutdown flushes and stops the tracer provider, if one was installed.
+	tracingShutdown = func() {}
+)
+
+// SetupTracing installs an OpenTelemetry tracer provider when the standard
+// OTEL_* environment variables request one:
+//
+//	OTEL_TRACES_EXPORTER=otlp|console|none
+//	OTEL_EXPORTER_OTLP_ENDPOINT / OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+//	OTEL_SDK_DISABLED=true
+//
+// Without any of them tracing stays a no-op.
+func SetupTracing() error {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch exp := strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")); exp {
+	case "none":
+		return nil
+	case "console":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	case "otlp":
+		exporter, err = newOTLPExporter()
+	case "":
+		if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+			return nil
+		}
+		exporter, err = newOTLPExporter()
+	default:
+		return fmt.Errorf("unsupported OTEL_TRACES_EXPORTER %q (want otlp, console or none)", exp)
+	}
+	if err != nil {
+		return fmt.Errorf("create trace exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults.
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(attribute.String("service.name", "templr"), attribute.String("service.version", GetVersion())),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return fmt.Errorf("trace resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	tracingShutdown = func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tp.Shutdown(ctx); err != nil {
+			warnf("trace", "flush spans: %v", err)
+		}
+	}
+	return nil
+}
+
+// newOTLPExporter creates an OTLP/HTTP exporter configured from OTEL_EXPORTER_OTLP_* variables.
+func newOTLPExporter() (sdktrace.SpanExporter, error) {
+	proto := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+	if proto == "" {
+		proto = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+	if proto != "" && proto != "http/protobuf" {
+		return nil, fmt.Errorf("unsupported OTLP protocol %q (only http/protobuf)", proto)
+	}
+	return otlptracehttp.New(context.Background())
+}
+
+// ShutdownTracing flushes pending spans. Call it before the process exits.
+func ShutdownTracing() {
+	shutdown := tracingShutdown
+	tracingShutdown = func() {}
+	shutdown()
+}
+
+// startCommandSpan starts the root span for a command and makes it the parent
+// of the spans recorded by helpers.
+func startCommandSpan(name string, attrs ...attribute.KeyValue) trace.Span {
+	var span trace.Span
+	traceCtx, span = templr.StartSpan(context.Background(), name, attrs...)
+	return span
+}
+
+// startStepSpan starts a span for one pipeline step under the command span.
+func startStepSpan(name string, attrs ...attribute.KeyValue) trace.Span {
+	_, span := templr.StartSpan(traceCtx, name, attrs...)
+	return span
+}
@@ -0,0 +1,214 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// The update check looks up the latest release at most once a day on
+// interactive runs and prints a one-line notice when it is newer than the
+// running binary. The request carries nothing but a User-Agent naming the
+// templr version.
+const (
+	updateCheckEnv      = "TEMPLR_NO_UPDATE_CHECK"  // any value turns the check off
+	updateCheckURLEnv   = "TEMPLR_UPDATE_CHECK_URL" // release endpoint, for mirrors
+	updateCheckURL      = "https://api.github.com/repos/kanopi/templr/releases/latest"
+	updateCheckInterval = 24 * time.Hour
+	updateCheckTimeout  = 1500 * time.Millisecond
+)
+
+// ciEnvs are set by CI systems; any of them suppresses the update check.
+var ciEnvs = []string{
+	"CI", "CONTINUOUS_INTEGRATION", "BUILD_NUMBER", "RUN_ID", "GITHUB_ACTIONS", "GITLAB_CI",
+	"BUILDKITE", "CIRCLECI", "JENKINS_URL", "TEAMCITY_VERSION", "TF_BUILD", "TRAVIS",
+}
+
+// updateCache is the update-check.json file of the user cache directory.
+// Latest is the last release found, empty until a check succeeds.
+type updateCache struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest,omitempty"`
+}
+
+// updateCheck is the state of this run's update check: the latest release
+// known when the run started and, when that was a day old, the lookup in
+// flight, whose result is set before done is closed.
+var updateCheck struct {
+	latest  string
+	done    chan struct{}
+	fetched string
+}
+
+// StartUpdateCheck loads the result of the last update check, unless the
+// run is not interactive, runs in CI, or the check is turned off with
+// TEMPLR_NO_UPDATE_CHECK or output.no_update_check in the config. When that
+// result is a day old, the latest release is looked up in the background.
+// The attempt is recorded before the lookup, so that neither a failed
+// lookup nor a run that exits first is retried for a day. PrintUpdateNotice
+// reports the result.
+func StartUpdateCheck(configPath string) {
+	if !updateCheckEnabled(configPath) {
+		return
+	}
+	cachePath := updateCachePath()
+	c, _ := readUpdateCache(cachePath)
+	updateCheck.latest = c.Latest
+	if time.Since(c.CheckedAt) < updateCheckInterval {
+		return
+	}
+	writeUpdateCache(cachePath, updateCache{CheckedAt: time.Now(), Latest: c.Latest})
+	done := make(chan struct{})
+	updateCheck.done = done
+	go func() {
+		defer close(done)
+		latest, err := fetchLatestRelease()
+		if err != nil {
+			return // offline or rate limited: keep what the last check found
+		}
+		writeUpdateCache(cachePath, updateCache{CheckedAt: time.Now(), Latest: latest})
+		updateCheck.fetched = latest
+	}()
+}
+
+// PrintUpdateNotice prints a one-line notice on stderr when the update
+// check found a newer release. A lookup still in flight, at most once a
+// day, is given up to updateCheckTimeout to finish so that short runs
+// complete it.
+func PrintUpdateNotice() {
+	latest := updateCheck.latest
+	if done := updateCheck.done; done != nil {
+		select {
+		case <-done:
+			if updateCheck.fetched != "" {
+				latest = updateCheck.fetched
+			}
+		case <-time.After(updateCheckTimeout):
+		}
+	}
+	updateCheck.latest, updateCheck.done = "", nil
+	current := readBuildInfo().Version
+	if !newerRelease(latest, current) {
+		return
+	}
+	fmt.Fprintf(sink.Stderr(), "templr %s is available (you have %s): %s/releases/latest\n",
+		latest, current, releaseHomepage)
+}
+
+// updateCheckEnabled reports whether this run checks for updates.
+func updateCheckEnabled(configPath string) bool {
+	if os.Getenv(updateCheckEnv) != "" || logFormat == LogFormatJSON {
+		return false
+	}
+	for _, env := range ciEnvs {
+		if os.Getenv(env) != "" {
+			return false
+		}
+	}
+	if fi, err := os.Stderr.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	v, err := semver.NewVersion(readBuildInfo().Version)
+	if err != nil || v.Prerelease() != "" {
+		return false // dev builds, pseudo-versions and prereleases are not compared
+	}
+	config, err := LoadConfig(configPath)
+	return err == nil && !config.Output.NoUpdateCheck
+}
+
+// newerRelease reports whether the release latest is newer than current.
+func newerRelease(latest, current string) bool {
+	l, err := semver.NewVersion(latest)
+	if err != nil {
+		return false
+	}
+	c, err := semver.NewVersion(current)
+	return err == nil && l.GreaterThan(c)
+}
+
+// fetchLatestRelease returns the tag of the latest release.
+func fetchLatestRelease() (string, error) {
+	url := updateCheckURL
+	if u := os.Getenv(updateCheckURLEnv); u != "" {
+		url = u
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "templr/"+readBuildInfo().Version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", err
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("GET %s: no tag_name", url)
+	}
+	return release.TagName, nil
+}
+
+// updateCachePath returns the path of the update check cache, or "" when
+// the user has no cache directory.
+func updateCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "templr", "update-check.json")
+}
+
+func readUpdateCache(path string) (updateCache, bool) {
+	var c updateCache
+	if path == "" {
+		return c, false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(b, &c) != nil {
+		return updateCache{}, false
+	}
+	c.Latest = strings.TrimSpace(c.Latest)
+	return c, !c.CheckedAt.IsZero()
+}
+
+// writeUpdateCache records a check through a rename, so that a run exiting
+// mid-write leaves the previous record; failing to is not worth reporting,
+// the next run checks again.
+func writeUpdateCache(path string, c updateCache) {
+	if path == "" {
+		return
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	tmp := fmt.Sprintf("%s.%d", path, os.Getpid())
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+	}
+}
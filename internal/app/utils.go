@@ -5,7 +5,9 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -14,6 +16,9 @@ import (
 	"text/template"
 	"unicode"
 
+	"github.com/kanopi/templr/pkg/templr"
+	toml "github.com/pelletier/go-toml/v2"
+	"go.opentelemetry.io/otel/attribute"
 	"gopkg.in/yaml.v3"
 )
 
@@ -30,140 +35,69 @@ const (
 // errf prints a standardized error line and exits with the given code.
 // Format: [templr:error:<kind>] message
 func errf(code int, kind, format string, a ...any) {
-	fmt.Fprintf(os.Stderr, "[templr:error:%s] %s\n", kind, fmt.Sprintf(format, a...))
-	os.Exit(code)
+	if logFormat == LogFormatJSON {
+		writeLogRecord(sink.Stderr(), logRecord{Level: "error", Kind: kind, Message: fmt.Sprintf(format, a...)})
+	} else {
+		fmt.Fprintf(sink.Stderr(), "[templr:error:%s] %s\n", kind, fmt.Sprintf(format, a...))
+	}
+	Exit(code)
+}
+
+// fatalErr prints err like errf, followed by its template context, and exits
+// with the given code.
+func fatalErr(code int, kind string, err error, noColor bool) {
+	writeError(sink.Stderr(), "[templr:error:"+kind+"] ", kind, err, noColor)
+	Exit(code)
 }
 
 // warnf prints a standardized warning (does not exit).
 // Format: [templr:warn:<kind>] message
 func warnf(kind, format string, a ...any) {
-	fmt.Fprintf(os.Stderr, "[templr:warn:%s] %s\n", kind, fmt.Sprintf(format, a...))
-}
-
-// strictErrf prints an enhanced strict mode error with context and exits with ExitStrictError.
-func strictErrf(err error, sources map[string][]byte, noColor bool) {
-	fmt.Fprint(os.Stderr, formatStrictError(err, sources, noColor))
-	os.Exit(ExitStrictError)
+	writeWarning(sink.Stderr(), kind, format, a...)
 }
 
-// formatStrictError enhances strict mode errors with colors, context lines, and helpful hints.
-func formatStrictError(err error, templateSources map[string][]byte, noColor bool) string {
-	if err == nil {
-		return ""
-	}
-
-	errMsg := err.Error()
-
-	// Helper to optionally colorize text
-	colorize := func(color, text string) string {
-		if noColor {
-			return text
-		}
-		return color + text + colorReset
-	}
-
-	var tplName string
-	var lineNum int
-	var expr string
-	var missingKey string
-
-	// Try to parse template name and line number
-	if strings.HasPrefix(errMsg, "template: ") {
-		rest := errMsg[10:]
-		if idx := strings.Index(rest, ":"); idx > 0 {
-			tplName = rest[:idx]
-			rest = rest[idx+1:]
-			if idx2 := strings.Index(rest, ":"); idx2 > 0 {
-				if ln, e := strconv.Atoi(rest[:idx2]); e == nil {
-					lineNum = ln
-				}
-			}
-		}
+// writeWarning writes a warning as warnf does, to w.
+func writeWarning(w io.Writer, kind, format string, a ...any) {
+	if logFormat == LogFormatJSON {
+		writeLogRecord(w, logRecord{Level: "warn", Kind: kind, Message: fmt.Sprintf(format, a...)})
+		return
 	}
+	fmt.Fprintf(w, "[templr:warn:%s] %s\n", kind, fmt.Sprintf(format, a...))
+}
 
-	// Extract the expression that failed
-	if start := strings.Index(errMsg, "at <"); start >= 0 {
-		start += 4
-		if end := strings.Index(errMsg[start:], ">"); end >= 0 {
-			expr = errMsg[start : start+end]
-		}
+// strictErrf prints an enhanced strict mode error with context and exits with ExitStrictError.
+// rootLabel names the template parsed as "root", as in newTemplateError.
+func strictErrf(err error, sources *templateSources, rootLabel string, noColor bool) {
+	te := newTemplateError("strict", err, sources, rootLabel)
+	if logFormat == LogFormatJSON {
+		writeLogRecord(sink.Stderr(), te.logRecord("error"))
+	} else {
+		fmt.Fprint(sink.Stderr(), formatStrictError(te, noColor))
 	}
+	Exit(ExitStrictError)
+}
 
-	// Extract missing key
-	if strings.Contains(errMsg, "map has no entry for key") {
-		if start := strings.Index(errMsg, `key "`); start >= 0 {
-			start += 5
-			if end := strings.Index(errMsg[start:], `"`); end >= 0 {
-				missingKey = errMsg[start : start+end]
-			}
-		}
-	}
+// formatStrictError enhances strict mode errors with colors, context lines, and helpful hints.
+func formatStrictError(te *TemplateError, noColor bool) string {
+	colorize := colorizer(noColor)
 
 	var buf bytes.Buffer
-	buf.WriteString(colorize(colorRed+colorBold, "✗ Strict Mode Error") + "\n")
-
-	if tplName != "" && lineNum > 0 {
-		buf.WriteString(colorize(colorCyan, fmt.Sprintf("  %s:%d", tplName, lineNum)) + "\n\n")
+	buf.WriteString(colorize(colorRed+colorBold, tr("✗ Strict Mode Error")) + "\n")
+	te.writeLocation(&buf, colorize)
 
-		if src, ok := templateSources[tplName]; ok {
-			lines := bytes.Split(src, []byte("\n"))
-			if lineNum > 0 && lineNum <= len(lines) {
-				start := lineNum - 2
-				if start < 0 {
-					start = 0
-				}
-				end := lineNum + 1
-				if end > len(lines) {
-					end = len(lines)
-				}
-
-				for i := start; i < end; i++ {
-					lineNumStr := fmt.Sprintf("%4d", i+1)
-					if i+1 == lineNum {
-						buf.WriteString(colorize(colorGray, lineNumStr) + " | ")
-						buf.WriteString(colorize(colorRed, string(lines[i])) + "\n")
-						buf.WriteString(colorize(colorGray, "     | "))
-						buf.WriteString(colorize(colorRed, "^ Error occurred here") + "\n")
-					} else {
-						buf.WriteString(colorize(colorGray, lineNumStr) + " | ")
-						buf.WriteString(string(lines[i]) + "\n")
-					}
-				}
-				buf.WriteString("\n")
-			}
-		}
+	if te.Expr != "" {
+		buf.WriteString(colorize(colorRed, "  "+tr("Missing: ")) + te.Expr + "\n")
 	}
-
-	if expr != "" {
-		buf.WriteString(colorize(colorRed, "  Missing: ") + expr + "\n")
-	}
-	if missingKey != "" {
-		buf.WriteString(colorize(colorRed, "  Key: ") + missingKey + "\n")
+	if te.Key != "" {
+		buf.WriteString(colorize(colorRed, "  "+tr("Key: ")) + te.Key + "\n")
 	}
 
 	buf.WriteString("\n")
-	buf.WriteString(colorize(colorGray, "  Details: "+errMsg) + "\n\n")
-
-	buf.WriteString(colorize(colorYellow, "  💡 Tip: "))
-	if missingKey != "" {
-		buf.WriteString(fmt.Sprintf("Define '%s' in your values file, or run without --strict to use defaults.\n", missingKey))
-	} else if expr != "" {
-		buf.WriteString(fmt.Sprintf("Define '%s' in your values file, or run without --strict to use defaults.\n", expr))
-	} else {
-		buf.WriteString("Check your values file to ensure all required keys are defined, or run without --strict.\n")
-	}
-
+	buf.WriteString(colorize(colorGray, "  "+tr("Details: ")+te.Error()) + "\n\n")
+	te.writeHint(&buf, colorize)
 	return buf.String()
 }
 
-type stringSlice []string
-
-func (s *stringSlice) String() string { return strings.Join(*s, ",") }
-func (s *stringSlice) Set(v string) error {
-	*s = append(*s, v)
-	return nil
-}
-
 // FilesAPI provides a Helm-like .Files facade anchored at a directory.
 type FilesAPI struct {
 	Root string
@@ -389,15 +323,43 @@ func loadData(path string) (map[string]any, error) {
 
 	var m map[string]any
 	ext := strings.ToLower(filepath.Ext(path))
-	switch ext {
-	case ".yaml", ".yml":
+	switch {
+	case isEnvFile(path):
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		if m, err = parseEnvFile(b); err != nil {
+			return nil, fmt.Errorf("env decode: %w", err)
+		}
+	case ext == ".yaml" || ext == ".yml":
 		if err := yaml.NewDecoder(f).Decode(&m); err != nil {
 			return nil, fmt.Errorf("yaml decode: %w", err)
 		}
-	case ".json":
+	case ext == ".json":
 		if err := json.NewDecoder(f).Decode(&m); err != nil {
 			return nil, fmt.Errorf("json decode: %w", err)
 		}
+	case ext == ".toml":
+		if err := toml.NewDecoder(f).Decode(&m); err != nil {
+			return nil, fmt.Errorf("toml decode: %w", err)
+		}
+	case ext == ".tfvars":
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		if m, err = templr.ParseTfvars(b); err != nil {
+			return nil, fmt.Errorf("tfvars decode: %w", err)
+		}
+	case ext == ".jsonc" || ext == ".json5":
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(templr.StandardizeJSONC(b), &m); err != nil {
+			return nil, fmt.Errorf("jsonc decode: %w", err)
+		}
 	default:
 		if err := yaml.NewDecoder(f).Decode(&m); err != nil {
 			if _, e := f.Seek(0, 0); e != nil {
@@ -481,38 +443,79 @@ func setByDottedKey(m map[string]any, dotted string, val any) {
 func buildAllowedExts(extra []string) map[string]bool {
 	m := map[string]bool{".tpl": true}
 	for _, e := range extra {
-		e = strings.TrimSpace(strings.ToLower(e))
-		if e == "" {
-			continue
-		}
-		if !strings.HasPrefix(e, ".") {
-			e = "." + e
+		if e = dottedSuffix(e); e != "" {
+			m[e] = true
 		}
-		m[e] = true
 	}
 	return m
 }
 
-// trimAnyExt removes the first matching extension from name based on allowExts.
-func trimAnyExt(name string, allowExts map[string]bool) string {
+// dottedSuffix returns the file name suffix s lowercased with a leading dot:
+// "Conf.tpl" is ".conf.tpl". It returns "" for a blank s.
+func dottedSuffix(s string) string {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s != "" && !strings.HasPrefix(s, ".") {
+		s = "." + s
+	}
+	return s
+}
+
+// matchExt returns the longest extension of allowExts that name ends with,
+// so that an extension may have several dots (".conf.tpl", ".yaml.gotmpl"),
+// or "" when name is not a template.
+func matchExt(name string, allowExts map[string]bool) string {
 	lower := strings.ToLower(name)
-	var exts []string
+	match := ""
 	for e := range allowExts {
-		exts = append(exts, e)
+		if allowExts[e] && len(e) > len(match) && strings.HasSuffix(lower, e) {
+			match = e
+		}
 	}
-	sort.Slice(exts, func(i, j int) bool { return len(exts[i]) > len(exts[j]) })
-	for _, e := range exts {
-		if strings.HasSuffix(lower, e) {
-			return name[:len(name)-len(e)]
+	return match
+}
+
+// trimAnyExt removes the longest matching extension of allowExts from name.
+func trimAnyExt(name string, allowExts map[string]bool) string {
+	return name[:len(name)-len(matchExt(name, allowExts))]
+}
+
+// outputName returns the output name of template name: the suffix of the
+// longest matching files.output_extensions entry is replaced by its
+// extension, or else the longest matching files.strip_suffixes entry is
+// removed, or else the template extension is trimmed.
+func outputName(name string, allowExts map[string]bool, shared SharedOptions) string {
+	lower := strings.ToLower(name)
+	match := ""
+	for suffix := range shared.OutputExts {
+		if len(suffix) > len(match) && strings.HasSuffix(lower, suffix) {
+			match = suffix
 		}
 	}
-	return name
+	if match != "" {
+		return name[:len(name)-len(match)] + shared.OutputExts[match]
+	}
+	for _, suffix := range shared.StripSuffixes {
+		if len(suffix) > len(match) && strings.HasSuffix(lower, suffix) {
+			match = suffix
+		}
+	}
+	if match != "" {
+		return name[:len(name)-len(match)]
+	}
+	return trimAnyExt(name, allowExts)
 }
 
-// readAllTplsIntoSet parses every allowed template file under root into the given template set.
-func readAllTplsIntoSet(tpl *template.Template, root string, allowExts map[string]bool) (*template.Template, []string, map[string][]byte, error) {
+// readAllTplsIntoSet parses every allowed template file under root into the given template set,
+// each with the delimiters of its scope.
+// Unless allowDuplicates is set, two files whose names differ only in case, or
+// a template name defined by more than one file, are reported as an error
+// instead of silently overriding each other.
+func readAllTplsIntoSet(tpl *template.Template, root string, allowExts map[string]bool, allowDuplicates bool, scopes *templateScopes) (*template.Template, []string, *templateSources, error) {
+	span := startStepSpan("templr.parse", attribute.String("templr.dir", root))
 	var names []string
-	sources := make(map[string][]byte)
+	sources := newTemplateSources()
+	definedIn := make(map[string]string) // template name -> file that defines it
+	byLower := make(map[string]string)   // lowercased file name -> file name
 	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -520,8 +523,7 @@ func readAllTplsIntoSet(tpl *template.Template, root string, allowExts map[strin
 		if d.IsDir() {
 			return nil
 		}
-		ext := strings.ToLower(filepath.Ext(d.Name()))
-		if !allowExts[ext] {
+		if matchExt(d.Name(), allowExts) == "" || skipLink(p, d) {
 			return nil
 		}
 		rel, err := filepath.Rel(root, p)
@@ -529,21 +531,71 @@ func readAllTplsIntoSet(tpl *template.Template, root string, allowExts map[strin
 			return err
 		}
 		rel = filepath.ToSlash(rel)
+		if isScopeConfig(rel) {
+			return nil
+		}
 		src, err := os.ReadFile(p)
 		if err != nil {
 			return err
 		}
-		sources[rel] = src
-		_, err = tpl.New(rel).Parse(string(src))
+		if skipBinary(rel, src) {
+			return nil
+		}
+		text := string(src) // shared with the text of the parsed tree
+		sources.set(rel, text)
+		if !allowDuplicates {
+			if prev, ok := byLower[strings.ToLower(rel)]; ok {
+				return fmt.Errorf("template files %s and %s differ only in case and collide on case-insensitive filesystems (use --allow-duplicate-templates to allow)", prev, rel)
+			}
+			byLower[strings.ToLower(rel)] = rel
+			if prev, ok := definedIn[rel]; ok {
+				return duplicateTemplateError(rel, prev, rel)
+			}
+		}
+		o := scopes.options(rel)
+		expanded, err := templr.ExpandRawBlocks(rel, text, o.ldelim, o.rdelim)
+		if err != nil {
+			return err
+		}
+		_, err = tpl.New(rel).Delims(o.ldelim, o.rdelim).Parse(expanded)
 		if err != nil {
 			return fmt.Errorf("parse %s: %w", rel, err)
 		}
+		if !allowDuplicates {
+			for _, t := range tpl.Templates() {
+				if t.Tree == nil || t.Tree.ParseName != rel {
+					continue
+				}
+				if prev, ok := definedIn[t.Name()]; ok && prev != rel {
+					return duplicateTemplateError(t.Name(), prev, rel)
+				}
+				definedIn[t.Name()] = rel
+			}
+		}
 		names = append(names, rel)
 		return nil
 	})
+	// Render in a documented order, by full slash-separated path, rather than
+	// in directory traversal order ("a.tpl" before "a/b.tpl").
+	sort.Strings(names)
+	span.SetAttributes(attribute.Int("templr.templates", len(names)))
+	templr.EndSpan(span, err)
 	return tpl, names, sources, err
 }
 
+// parseRaw parses src into t after expanding its {{ raw }} blocks.
+func parseRaw(t *template.Template, src string, shared SharedOptions) (*template.Template, error) {
+	expanded, err := templr.ExpandRawBlocks(t.Name(), src, shared.Ldelim, shared.Rdelim)
+	if err != nil {
+		return nil, err
+	}
+	return t.Parse(expanded)
+}
+
+func duplicateTemplateError(name, first, second string) error {
+	return fmt.Errorf("duplicate template name %q: defined in %s and %s (use --allow-duplicate-templates to let the later one win)", name, first, second)
+}
+
 // shouldRender returns false for "partials" (files whose base name starts with "_").
 func shouldRender(rel string) bool {
 	base := filepath.Base(rel)
@@ -565,20 +617,55 @@ func isEmpty(b []byte) bool {
 }
 
 // renderToBuffer executes a template into an in-memory buffer.
-func renderToBuffer(tpl *template.Template, name string, values map[string]any) ([]byte, error) {
-	var buf bytes.Buffer
+func renderToBuffer(tpl *template.Template, name string, values map[string]any, shared SharedOptions) (out []byte, err error) {
+	span := startStepSpan("templr.execute", attribute.String("templr.template", name))
+	defer func() { templr.EndSpan(span, err) }()
+
+	limit, _ := maxOutputSize(shared) // validated by checkMaxOutputSize
+	buf := &limitedBuffer{max: limit}
 	if name == "" {
-		if err := tpl.Execute(&buf, values); err != nil {
-			return nil, err
-		}
+		err = tpl.Execute(buf, values)
 	} else {
-		if err := tpl.ExecuteTemplate(&buf, name, values); err != nil {
-			return nil, err
-		}
+		err = tpl.ExecuteTemplate(buf, name, values)
+	}
+	if errors.Is(err, errOutputTooLarge) {
+		return nil, outputTooLargeError(tpl, name, shared)
+	}
+	if err != nil {
+		return nil, err
 	}
 	return buf.Bytes(), nil
 }
 
+// copyValues deep-copies the maps and slices of a values tree.
+func copyValues(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(x))
+		for k, val := range x {
+			out[k] = copyValues(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(x))
+		for i, val := range x {
+			out[i] = copyValues(val)
+		}
+		return out
+	}
+	return v
+}
+
+// firstNonEmpty returns the first of ss that is not empty.
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
 // applyDefaultMissing replaces the engine's "<no value>" placeholder with a configured string.
 func applyDefaultMissing(out []byte, replacement string) []byte {
 	if replacement == "" || replacement == "<no value>" {
@@ -629,7 +716,13 @@ func fastEqual(path string, newBytes []byte) (bool, error) {
 }
 
 // writeIfChanged writes newBytes to path only if content differs from existing file.
-func writeIfChanged(path string, newBytes []byte, mode os.FileMode) (bool, error) {
+func writeIfChanged(path string, newBytes []byte, mode os.FileMode) (changed bool, err error) {
+	span := startStepSpan("templr.write", attribute.String("templr.path", path))
+	defer func() {
+		span.SetAttributes(attribute.Bool("templr.changed", changed))
+		templr.EndSpan(span, err)
+	}()
+
 	same, err := fastEqual(path, newBytes)
 	if err != nil {
 		return false, err
@@ -671,6 +764,7 @@ func writeIfChanged(path string, newBytes []byte, mode os.FileMode) (bool, error
 	if err := os.Rename(tmp, path); err != nil {
 		return false, err
 	}
+	activeAudit.written(path, newBytes)
 
 	return true, nil
 }
@@ -693,12 +787,10 @@ func loadDefaultValues(baseDir string) (map[string]any, error) {
 	return out, nil
 }
 
-// normalize strips UTF-8 BOM and converts CRLF to LF for consistent processing.
+// normalize strips the BOM (decoding UTF-16 files) and converts CRLF to LF
+// for consistent processing.
 func normalize(content []byte) []byte {
-	if len(content) >= 3 && content[0] == 0xEF && content[1] == 0xBB && content[2] == 0xBF {
-		content = content[3:]
-	}
-	return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(decodeText(content), []byte("\r\n"), []byte("\n"))
 }
 
 // hasGuardFlexible checks if content contains the guard marker.
@@ -750,103 +842,29 @@ func isShebang(content []byte) bool {
 	return content[0] == '#' && content[1] == '!'
 }
 
-// injectGuardForExt injects guard into content using a style determined by file path.
-func injectGuardForExt(path string, content []byte, guard string) []byte {
+// injectGuardForExt injects the guard into content using a comment style and
+// position determined by the guard options and the file path.
+func injectGuardForExt(path string, content []byte, shared SharedOptions) []byte {
+	guard := shared.Guard
 	if len(guard) == 0 || hasGuardFlexible(path, content, guard) {
 		return content
 	}
-
-	base := strings.ToLower(filepath.Base(path))
-	ext := strings.ToLower(filepath.Ext(path))
-
-	if ext == ".json" {
-		return content
-	}
-
-	if base == "dockerfile" {
-		return []byte("# " + guard + "\n" + string(content))
-	}
-
-	addLineTop := func(prefix string) []byte {
-		return []byte(prefix + guard + "\n" + string(content))
-	}
-	addBlockTop := func(open, closeToken string) []byte {
-		return []byte(open + " " + guard + " " + closeToken + "\n" + string(content))
-	}
-	addAfterShebang := func(prefix string) []byte {
-		idx := bytes.IndexByte(content, '\n')
-		if idx == -1 {
-			return append(append(content, []byte("\n"+prefix+guard+"\n")...), []byte{}...)
-		}
-		she := content[:idx+1]
-		rest := content[idx+1:]
-		return append(append(she, []byte(prefix+guard+"\n")...), rest...)
-	}
-
-	hashCommentExts := map[string]bool{
-		".sh": true, ".bash": true, ".zsh": true, ".env": true,
-		".yml": true, ".yaml": true, ".toml": true, ".ini": true, ".conf": true,
-		".py": true, ".rb": true,
-	}
-	if hashCommentExts[ext] {
-		if isShebang(content) {
-			return addAfterShebang("# ")
-		}
-		return addLineTop("# ")
-	}
-
-	if ext == ".php" || ext == ".phtml" {
-		trimmed := bytes.TrimLeft(content, "\ufeff")
-		if bytes.HasPrefix(trimmed, []byte("<?php")) {
-			idx := bytes.IndexByte(trimmed, '\n')
-			if idx == -1 {
-				return append(trimmed, []byte("\n// "+guard+"\n")...)
-			}
-			head := trimmed[:idx+1]
-			rest := trimmed[idx+1:]
-			var buf bytes.Buffer
-			buf.Write(head)
-			buf.WriteString("// " + guard + "\n")
-			buf.Write(rest)
-			if !bytes.HasPrefix(content, []byte("<?php")) {
-				return append([]byte("\ufeff"), buf.Bytes()...)
-			}
-			return buf.Bytes()
-		}
-		return []byte("<?php // " + guard + " ?>\n" + string(content))
-	}
-
-	markupExts := map[string]bool{".html": true, ".htm": true, ".xml": true, ".md": true}
-	if markupExts[ext] {
-		return addBlockTop("<!--", "-->")
-	}
-
-	if ext == ".css" || ext == ".scss" {
-		return addBlockTop("/*", "*/")
-	}
-
-	slashSlashExts := map[string]bool{
-		".js": true, ".ts": true, ".mjs": true, ".cjs": true,
-		".go": true, ".java": true, ".kt": true, ".kts": true,
-		".c": true, ".h": true, ".cpp": true, ".hpp": true, ".cc": true, ".hh": true,
-		".rs": true, ".swift": true,
-	}
-	if slashSlashExts[ext] {
-		return addLineTop("// ")
-	}
-
-	return addLineTop("# ")
+	style, pos := guardPlacement(path, content, shared)
+	return injectGuardStyle(content, style, guard, pos)
 }
 
 // computeHelperVars executes an optional helper template named "templr.vars".
-func computeHelperVars(tpl *template.Template, values map[string]any) error {
+func computeHelperVars(tpl *template.Template, values map[string]any) (err error) {
 	if tpl == nil {
 		return nil
 	}
 	if tpl.Lookup("templr.vars") == nil {
 		return nil
 	}
-	out, err := renderToBuffer(tpl, "templr.vars", values)
+	span := startStepSpan("templr.helper_vars")
+	defer func() { templr.EndSpan(span, err) }()
+
+	out, err := renderToBuffer(tpl, "templr.vars", values, SharedOptions{})
 	if err != nil {
 		return fmt.Errorf("templr.vars execute: %w", err)
 	}
@@ -0,0 +1,223 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ValidateRule selects a built-in validator for the outputs matching Files
+// (render.validate in the config).
+type ValidateRule struct {
+	Files    []string `yaml:"files"`    // output path globs; patterns without a slash match the base name
+	Validate string   `yaml:"validate"` // validator name, see validators
+}
+
+// validators are the built-in syntax checks of render.validate. They run on
+// the rendered output before it is written and return the first problem.
+var validators = map[string]func(name string, out []byte) error{
+	"systemd-unit": validateSystemdUnit,
+	"nginx":        validateNginx,
+}
+
+func validatorNames() string {
+	names := make([]string, 0, len(validators))
+	for n := range validators {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// checkValidateRules rejects rules with an unknown validator or no files.
+func checkValidateRules(rules []ValidateRule) error {
+	for _, r := range rules {
+		if _, ok := validators[r.Validate]; !ok {
+			return argsError(fmt.Errorf("render.validate: unknown validator %q (want one of %s)", r.Validate, validatorNames()))
+		}
+		if len(r.Files) == 0 {
+			return argsError(fmt.Errorf("render.validate: the %s rule has no files", r.Validate))
+		}
+	}
+	return nil
+}
+
+// validateOutput runs the validators whose files match relOut and returns
+// the problems found. Output printed to stdout is matched by the template
+// path without its template extension.
+func validateOutput(rules []ValidateRule, template, relOut string, out []byte) []string {
+	target := relOut
+	if relOut == "stdout" {
+		target = strings.TrimSuffix(template, path.Ext(template))
+	}
+	var problems []string
+	for _, r := range rules {
+		if !matchOutputPath(r.Files, target) {
+			continue
+		}
+		if err := validators[r.Validate](filepath.Base(target), out); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", r.Validate, err))
+		}
+	}
+	return problems
+}
+
+// systemdSections are the sections systemd unit files may contain; X-
+// sections are for other programs and are not checked.
+var systemdSections = map[string]bool{
+	"Unit": true, "Install": true, "Service": true, "Socket": true, "Timer": true, "Mount": true,
+	"Automount": true, "Swap": true, "Path": true, "Slice": true, "Scope": true,
+	"Network": true, "Match": true, "Link": true, "NetDev": true, "Address": true, "Route": true,
+}
+
+// validateSystemdUnit checks the syntax of a systemd unit file: known
+// [Section] headers, Key=Value lines inside a section, and an ExecStart= in
+// a .service whose Type is not oneshot.
+func validateSystemdUnit(name string, out []byte) error {
+	section := ""
+	sections := map[string]map[string]bool{}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(make([]byte, 0, 64*1024), len(out)+1)
+	n := 0
+	for sc.Scan() {
+		n++
+		line := strings.TrimSpace(sc.Text())
+		// continuation lines belong to the previous setting
+		for strings.HasSuffix(line, `\`) && sc.Scan() {
+			n++
+			line = strings.TrimSuffix(line, `\`) + " " + strings.TrimSpace(sc.Text())
+		}
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+			continue
+		case line[0] == '[':
+			if !strings.HasSuffix(line, "]") {
+				return fmt.Errorf("line %d: invalid section header %s", n, line)
+			}
+			section = line[1 : len(line)-1]
+			if !systemdSections[section] && !strings.HasPrefix(section, "X-") {
+				return fmt.Errorf("line %d: unknown section [%s]", n, section)
+			}
+			if sections[section] == nil {
+				sections[section] = map[string]bool{}
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		switch {
+		case !ok:
+			return fmt.Errorf("line %d: %q is not a Key=Value setting", n, line)
+		case key == "" || strings.ContainsAny(key, " \t"):
+			return fmt.Errorf("line %d: invalid setting name %q", n, key)
+		case section == "":
+			return fmt.Errorf("line %d: %s= is outside of a section", n, key)
+		}
+		if strings.TrimSpace(value) != "" {
+			sections[section][key] = true
+		}
+		if section == "Service" && key == "Type" {
+			sections[section]["Type="+strings.TrimSpace(value)] = true
+		}
+	}
+	if len(sections) == 0 {
+		return fmt.Errorf("no [Unit] or other section")
+	}
+	if strings.HasSuffix(name, ".service") {
+		svc := sections["Service"]
+		if svc != nil && !svc["Type=oneshot"] && !svc["ExecStart"] {
+			return fmt.Errorf("[Service] has no ExecStart=")
+		}
+	}
+	return nil
+}
+
+// validateNginx checks the syntax of an nginx configuration or snippet:
+// balanced braces and quotes, and directives terminated by ";".
+func validateNginx(_ string, out []byte) error {
+	type block struct {
+		name string
+		line int
+	}
+	var stack []block
+	line := 1
+	var words []string // words of the directive being read
+	wordLine := 0
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch {
+		case c == '\n':
+			line++
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '#':
+			for i < len(out) && out[i] != '\n' {
+				i++
+			}
+			i-- // count the newline
+		case c == ';':
+			if len(words) == 0 {
+				return fmt.Errorf("line %d: unexpected \";\"", line)
+			}
+			words = nil
+		case c == '{':
+			if len(words) == 0 {
+				return fmt.Errorf("line %d: unexpected \"{\"", line)
+			}
+			stack = append(stack, block{words[0], line})
+			words = nil
+		case c == '}':
+			if len(words) > 0 {
+				return fmt.Errorf("line %d: directive %q is not terminated by \";\"", wordLine, words[0])
+			}
+			if len(stack) == 0 {
+				return fmt.Errorf("line %d: unexpected \"}\"", line)
+			}
+			stack = stack[:len(stack)-1]
+		case c == '"' || c == '\'':
+			start := line
+			for i++; i < len(out) && out[i] != c; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+				if i < len(out) && out[i] == '\n' {
+					line++
+				}
+			}
+			if i >= len(out) {
+				return fmt.Errorf("line %d: unterminated %c string", start, c)
+			}
+			if len(words) == 0 {
+				wordLine = start
+			}
+			words = append(words, "string")
+		default:
+			start := i
+			for i < len(out) && !bytes.ContainsRune([]byte(" \t\r\n;{}"), rune(out[i])) {
+				// ${var} keeps its braces
+				if out[i] == '$' && i+1 < len(out) && out[i+1] == '{' {
+					if end := bytes.IndexByte(out[i:], '}'); end > 0 {
+						i += end
+					}
+				}
+				i++
+			}
+			if len(words) == 0 {
+				wordLine = line
+			}
+			words = append(words, string(out[start:i]))
+			i--
+		}
+	}
+	if len(words) > 0 {
+		return fmt.Errorf("line %d: directive %q is not terminated by \";\"", wordLine, words[0])
+	}
+	if len(stack) > 0 {
+		b := stack[len(stack)-1]
+		return fmt.Errorf("line %d: %q block is not closed", b.line, b.name)
+	}
+	return nil
+}
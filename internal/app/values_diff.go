@@ -0,0 +1,271 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/kanopi/templr/pkg/lint"
+)
+
+// ValuesDiffOptions contains options for `templr values diff`
+type ValuesDiffOptions struct {
+	Shared SharedOptions // Files holds the old and the new value set
+	Src    string        // only report keys the templates of this tree reference
+	Format string        // text, json or files (the templates of Src affected)
+}
+
+// valueChange is one difference between two value sets.
+type valueChange struct {
+	Op      string `json:"op"` // "added", "removed" or "changed"
+	Key     string `json:"key"`
+	Old     any    `json:"old,omitempty"`
+	OldType string `json:"old_type,omitempty"`
+	New     any    `json:"new,omitempty"`
+	NewType string `json:"new_type,omitempty"`
+}
+
+// RunValuesDiff compares two value sets, each merged like the -f files of a
+// render (a set may list several files separated by commas), and prints the
+// keys added, removed and changed by the second.
+func RunValuesDiff(opts ValuesDiffOptions) error {
+	if len(opts.Shared.Files) != 2 {
+		return argsError(fmt.Errorf("values diff needs two -f value sets, the old and the new one (got %d)", len(opts.Shared.Files)))
+	}
+	switch opts.Format {
+	case "", "text", "json":
+	case "files":
+		if opts.Src == "" {
+			return argsError(fmt.Errorf("--format files needs --src"))
+		}
+	default:
+		return argsError(fmt.Errorf("unknown format %q (want text, json or files)", opts.Format))
+	}
+
+	sides := make([]map[string]any, 2)
+	for i, set := range opts.Shared.Files {
+		shared := opts.Shared
+		shared.Data, shared.Files = "", strings.Split(set, ",")
+		values, err := buildValues(opts.Src, shared)
+		if err != nil {
+			return err
+		}
+		sides[i] = values
+	}
+
+	var changes []valueChange
+	diffValues("", sides[0], sides[1], &changes)
+	var affected []string
+	if opts.Src != "" {
+		tpl, names, err := parseTree(opts.Src, opts.Shared)
+		if err != nil {
+			return err
+		}
+		refs := referencedKeys(tpl)
+		kept := changes[:0]
+		var keys []string
+		for _, c := range changes {
+			if keyReferenced(c.Key, refs) {
+				kept = append(kept, c)
+				keys = append(keys, c.Key)
+			}
+		}
+		changes = kept
+		affected = affectedTemplates(tpl, names, keys)
+	}
+	if opts.Format == "files" {
+		for _, name := range affected {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	for i := range changes {
+		changes[i].redact(opts.Shared.Redact)
+	}
+
+	if opts.Format == "json" {
+		if changes == nil {
+			changes = []valueChange{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	}
+	for _, c := range changes {
+		switch c.Op {
+		case "added":
+			fmt.Printf("+ %s: %s (%s)\n", c.Key, formatDiffValue(c.New), c.NewType)
+		case "removed":
+			fmt.Printf("- %s: %s (%s)\n", c.Key, formatDiffValue(c.Old), c.OldType)
+		default:
+			fmt.Printf("~ %s: %s (%s) -> %s (%s)\n", c.Key, formatDiffValue(c.Old), c.OldType, formatDiffValue(c.New), c.NewType)
+		}
+	}
+	if len(changes) > 0 && opts.Src != "" {
+		keys := make([]string, len(changes))
+		for i, c := range changes {
+			keys[i] = c.Key
+		}
+		fmt.Printf("\nfiles affected by changed keys %s:\n", strings.Join(keys, ", "))
+		for _, name := range affected {
+			fmt.Printf("  %s\n", name)
+		}
+		if len(affected) == 0 {
+			fmt.Println("  (none)")
+		}
+	}
+	return nil
+}
+
+// redact hides the old and new values of a sensitive key, and the sensitive
+// keys inside added, removed or changed maps; the types stay visible.
+func (c *valueChange) redact(patterns []string) {
+	keys := strings.Split(c.Key, ".")
+	if sensitivePath(keys, patterns) {
+		if c.Op != "added" {
+			c.Old = redacted
+		}
+		if c.Op != "removed" {
+			c.New = redacted
+		}
+		return
+	}
+	c.Old, c.New = redactTree(c.Old, keys, patterns), redactTree(c.New, keys, patterns)
+}
+
+// diffValues appends the differences between the maps a and b under prefix,
+// in key order. Maps present on both sides are compared key by key; any
+// other value, lists included, is compared as a whole.
+func diffValues(prefix string, a, b map[string]any, changes *[]valueChange) {
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		av, inA := a[k]
+		bv, inB := b[k]
+		switch {
+		case !inA:
+			*changes = append(*changes, valueChange{Op: "added", Key: key, New: bv, NewType: valueTypeName(bv)})
+		case !inB:
+			*changes = append(*changes, valueChange{Op: "removed", Key: key, Old: av, OldType: valueTypeName(av)})
+		default:
+			am, aok := av.(map[string]any)
+			bm, bok := bv.(map[string]any)
+			if aok && bok {
+				diffValues(key, am, bm, changes)
+				continue
+			}
+			if !reflect.DeepEqual(av, bv) {
+				*changes = append(*changes, valueChange{Op: "changed", Key: key,
+					Old: av, OldType: valueTypeName(av), New: bv, NewType: valueTypeName(bv)})
+			}
+		}
+	}
+}
+
+// valueTypeName names the type of a decoded value the way values files
+// spell it.
+func valueTypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case int, int64, uint64:
+		return "int"
+	case float64:
+		return "float"
+	case map[string]any:
+		return "map"
+	case []any:
+		return "list"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// formatDiffValue prints a value on one line, as JSON.
+func formatDiffValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// referencedKeys returns the dotted keys (".a.b" without the dot) the
+// templates of tpl reference. References inside range and with blocks are
+// relative to their dot and count as top-level keys, so the scope errs on
+// the side of showing a change.
+func referencedKeys(tpl *template.Template) []string {
+	var refs []string
+	for _, t := range tpl.Templates() {
+		for _, v := range lint.ExtractVariables(t.Tree) {
+			refs = append(refs, strings.TrimPrefix(strings.TrimPrefix(v, "."), "Values."))
+		}
+	}
+	return refs
+}
+
+// parseTree parses the templates under src as walk does and returns the set
+// with the names of its templates.
+func parseTree(src string, shared SharedOptions) (*template.Template, []string, error) {
+	absSrc, _ := filepath.Abs(src)
+	scopes, err := loadTemplateScopes(absSrc, shared)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tpl *template.Template
+	tpl = template.New("root").Funcs(buildFuncMapWithOptions(&tpl, shared)).Delims(shared.Ldelim, shared.Rdelim)
+	tpl, names, sources, err := readAllTplsIntoSet(tpl, absSrc, buildAllowedExts(shared.ExtraExts), true, scopes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse %s: %w", src, newTemplateError("parse", err, sources, ""))
+	}
+	return tpl, names, nil
+}
+
+// affectedTemplates returns the templates of the tree walk would render
+// that read one of keys.
+func affectedTemplates(tpl *template.Template, names, keys []string) []string {
+	deps := newValueDeps(tpl)
+	var affected []string
+	for _, name := range names {
+		if shouldRender(name) && deps.of(name).affectedBy(keys) {
+			affected = append(affected, name)
+		}
+	}
+	return affected
+}
+
+// keyReferenced reports whether a template reads key, one of its parents or
+// one of its children.
+func keyReferenced(key string, refs []string) bool {
+	for _, r := range refs {
+		if r == key || strings.HasPrefix(key, r+".") || strings.HasPrefix(r, key+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package app
+
+// valuesNamespaceKey is where --values-namespace exposes the values, the
+// key Helm charts read them from.
+const valuesNamespaceKey = "Values"
+
+// addValuesNamespace exposes the merged values under .Values as well as at
+// the top level when --values-namespace or template.values_namespace is set,
+// so that templates written for Helm charts render unchanged. It runs before
+// .Files and .Templr are added, which stay out of .Values. A top-level Values
+// key of the values themselves wins, with a warning.
+func addValuesNamespace(values map[string]any, shared SharedOptions) {
+	if !shared.ValuesNamespace {
+		return
+	}
+	if _, ok := values[valuesNamespaceKey]; ok {
+		warnf("values-namespace", "the values have a top-level %s key; .%s is that key rather than all the values", valuesNamespaceKey, valuesNamespaceKey)
+		return
+	}
+	ns := make(map[string]any, len(values))
+	for k, v := range values {
+		ns[k] = v
+	}
+	values[valuesNamespaceKey] = ns
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// valueRefRe matches ${.dotted.key} references in values; $${ is a literal ${.
+var valueRefRe = regexp.MustCompile(`\$?\$\{\s*\.([^}]*?)\s*\}`)
+
+// resolveValueRefs replaces ${.dotted.key} references in the string values of
+// values with the values they name. A string that is a single reference takes
+// the type of the referenced value (a number, a list, a map); references
+// embedded in longer strings must name scalars. References are resolved
+// recursively, and a reference that depends on itself is an error.
+func resolveValueRefs(values map[string]any) error {
+	r := &refResolver{root: values, active: map[string]bool{}, done: map[string]bool{}}
+	_, err := r.resolve(nil, values)
+	return err
+}
+
+type refResolver struct {
+	root   map[string]any
+	active map[string]bool // paths being resolved, for cycle detection
+	done   map[string]bool // paths already resolved in place
+	chain  []string        // paths being resolved, in order, for the cycle report
+}
+
+// resolve returns node with its references replaced; maps and lists are
+// updated in place.
+func (r *refResolver) resolve(path []string, node any) (any, error) {
+	key := strings.Join(path, ".")
+	if r.done[key] {
+		return node, nil
+	}
+	if r.active[key] {
+		cycle := append(append([]string(nil), r.chain[indexOf(r.chain, key):]...), key)
+		return nil, fmt.Errorf("values reference cycle: %s", strings.Join(cycleLabels(cycle), " -> "))
+	}
+	r.active[key] = true
+	r.chain = append(r.chain, key)
+	defer func() {
+		delete(r.active, key)
+		r.chain = r.chain[:len(r.chain)-1]
+	}()
+
+	var err error
+	switch n := node.(type) {
+	case map[string]any:
+		for _, k := range sortedKeys(n) {
+			if n[k], err = r.resolve(childPath(path, k), n[k]); err != nil {
+				return nil, err
+			}
+		}
+	case []any:
+		for i, v := range n {
+			if n[i], err = r.resolve(childPath(path, strconv.Itoa(i)), v); err != nil {
+				return nil, err
+			}
+		}
+	case string:
+		if node, err = r.interpolate(key, n); err != nil {
+			return nil, err
+		}
+	}
+	r.done[key] = true
+	return node, nil
+}
+
+// interpolate resolves the references in the string s found at key.
+func (r *refResolver) interpolate(key, s string) (any, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	if m := valueRefRe.FindStringSubmatchIndex(s); m != nil && m[0] == 0 && m[1] == len(s) && !strings.HasPrefix(s, "$$") {
+		return r.lookup(key, s[m[2]:m[3]])
+	}
+
+	var err error
+	out := valueRefRe.ReplaceAllStringFunc(s, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		target := valueRefRe.FindStringSubmatch(ref)[1]
+		var v any
+		if v, err = r.lookup(key, target); err != nil {
+			return ref
+		}
+		switch v.(type) {
+		case map[string]any, []any:
+			err = fmt.Errorf("%s: ${.%s} is a %s and cannot be embedded in a string", refLabel(key), target, typeName(v))
+			return ref
+		case nil:
+			return ""
+		}
+		return fmt.Sprint(v)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// lookup returns the resolved value at the dotted path target, referenced
+// from key.
+func (r *refResolver) lookup(key, target string) (any, error) {
+	if target == "" {
+		return nil, fmt.Errorf("%s: ${.} cannot reference all values", refLabel(key))
+	}
+	path := strings.Split(target, ".")
+	var node any = r.root
+	for i, seg := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			v, ok := n[seg]
+			if !ok {
+				return nil, fmt.Errorf("%s: ${.%s} is not defined", refLabel(key), target)
+			}
+			node = v
+		case []any:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(n) {
+				return nil, fmt.Errorf("%s: ${.%s}: %s has no element %q", refLabel(key), target, strings.Join(path[:i], "."), seg)
+			}
+			node = n[idx]
+		default:
+			return nil, fmt.Errorf("%s: ${.%s} is not defined", refLabel(key), target)
+		}
+	}
+	v, err := r.resolve(path, node)
+	if err != nil {
+		return nil, err
+	}
+	setAtPath(r.root, path, v)
+	return v, nil
+}
+
+// setAtPath stores v at path in root; every parent along path exists.
+func setAtPath(root map[string]any, path []string, v any) {
+	var node any = root
+	for i, seg := range path {
+		last := i == len(path)-1
+		switch n := node.(type) {
+		case map[string]any:
+			if last {
+				n[seg] = v
+				return
+			}
+			node = n[seg]
+		case []any:
+			idx, _ := strconv.Atoi(seg)
+			if last {
+				n[idx] = v
+				return
+			}
+			node = n[idx]
+		}
+	}
+}
+
+func childPath(path []string, seg string) []string {
+	return append(append([]string(nil), path...), seg)
+}
+
+func indexOf(ss []string, s string) int {
+	for i, v := range ss {
+		if v == s {
+			return i
+		}
+	}
+	return 0
+}
+
+func cycleLabels(keys []string) []string {
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		labels[i] = "." + k
+	}
+	return labels
+}
+
+func refLabel(key string) string {
+	return "value ." + key
+}
+
+func typeName(v any) string {
+	if _, ok := v.(map[string]any); ok {
+		return "map"
+	}
+	return "list"
+}
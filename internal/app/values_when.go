@@ -0,0 +1,98 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/kanopi/templr/pkg/lint"
+)
+
+// valuesWhenKey is the top-level key of a values file that decides whether
+// the file is merged at all.
+const valuesWhenKey = "_when"
+
+// setOverride is a parsed --set key=value.
+type setOverride struct {
+	key string
+	val any
+}
+
+// parseSets parses the --set key=value overrides, typing the values by
+// schema when there is one.
+func parseSets(sets []string, schema *valueSchema) ([]setOverride, error) {
+	out := make([]setOverride, 0, len(sets))
+	for _, kv := range sets {
+		idx := strings.Index(kv, "=")
+		if idx <= 0 {
+			return nil, argsError(fmt.Errorf("--set expects key=value, got: %s", kv))
+		}
+		key, raw := kv[:idx], kv[idx+1:]
+		if schema == nil {
+			out = append(out, setOverride{key: key, val: parseScalar(raw)})
+			continue
+		}
+		val, err := schema.coerce(key, raw, parseScalar(raw))
+		if err != nil {
+			return nil, exitError(ExitSchemaError, "schema", fmt.Errorf("--set %w", err))
+		}
+		out = append(out, setOverride{key: key, val: val})
+	}
+	return out, nil
+}
+
+// valuesFileApplies removes the _when guard from the values file add loaded
+// from path and reports whether the file is to be merged. The guard is a bool
+// or an expression like --assert ("eq .env \"prod\"", ".gpu.enabled") that
+// holds when it renders "true". It is evaluated against the values merged so
+// far with the --set overrides applied, so -f gpu.yaml can turn itself on
+// with --set gpu.enabled=true. A path whose parent is undefined, like
+// .gpu.enabled without gpu, is false rather than an error.
+func valuesFileApplies(path string, add, values map[string]any, sets []setOverride, shared SharedOptions) (bool, error) {
+	guard, ok := add[valuesWhenKey]
+	if !ok {
+		return true, nil
+	}
+	delete(add, valuesWhenKey)
+
+	switch g := guard.(type) {
+	case bool:
+		return g, nil
+	case string:
+		tpl, err := parseAssertion(g, shared)
+		if err != nil {
+			return false, fmt.Errorf("%s: invalid %s %q: %w", path, valuesWhenKey, g, err)
+		}
+		ctx, _ := copyValues(values).(map[string]any)
+		for _, s := range sets {
+			setByDottedKey(ctx, s.key, s.val)
+		}
+		defineGuardParents(ctx, lint.ExtractVariables(tpl.Tree))
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, ctx); err != nil {
+			return false, fmt.Errorf("%s: %s %q: %w", path, valuesWhenKey, g, err)
+		}
+		return strings.TrimSpace(buf.String()) == "true", nil
+	}
+	return false, fmt.Errorf("%s: %s must be a bool or an expression, got %v", path, valuesWhenKey, guard)
+}
+
+// defineGuardParents defines the undefined parents of the field paths of a
+// guard as empty maps in ctx, so that ".gpu.enabled" evaluates to no value
+// instead of failing on a nil gpu. Defined values are left alone.
+func defineGuardParents(ctx map[string]any, paths []string) {
+	for _, p := range paths {
+		parts := strings.Split(strings.TrimPrefix(p, "."), ".")
+		m := ctx
+		for _, key := range parts[:len(parts)-1] {
+			if m[key] == nil {
+				m[key] = map[string]any{}
+			}
+			next, ok := m[key].(map[string]any)
+			if !ok {
+				break
+			}
+			m = next
+		}
+	}
+}
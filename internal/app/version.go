@@ -0,0 +1,107 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+// Build metadata, set at build time via -ldflags (see main.go).
+var (
+	Version string
+	Commit  string
+	Date    string
+)
+
+// BuildInfo describes the running templr binary.
+type BuildInfo struct {
+	Version   string          `json:"version"`
+	Commit    string          `json:"commit"`
+	Date      string          `json:"date"`
+	GoVersion string          `json:"go_version"`
+	Platform  string          `json:"platform"`
+	Features  map[string]bool `json:"features"`
+}
+
+// buildFeatures reports the optional capabilities compiled into the binary.
+func buildFeatures() map[string]bool {
+	return map[string]bool{
+		"wasm":              runtime.GOARCH == "wasm",
+		"network_functions": true,  // getHostByName and the other network helpers
+		"sops":              false, // no SOPS-encrypted values support yet
+		"fips":              templr.FIPSBuild,
+	}
+}
+
+// readBuildInfo fills the fields the -ldflags left empty from the module and
+// VCS information the Go toolchain embeds (go install, go build in a checkout).
+// It runs once, after main set the ldflags values.
+var readBuildInfo = sync.OnceValue(func() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		var modified bool
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = s.Value
+				}
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+		if modified && Commit == "" && info.Commit != "" {
+			info.Commit += "-dirty"
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+})
+
+// GetBuildInfo returns the build information of the running binary. It is
+// safe for concurrent use; each call gets its own Features map.
+func GetBuildInfo() BuildInfo {
+	info := readBuildInfo()
+	info.Features = buildFeatures()
+	return info
+}
+
+// GetVersion returns a human-friendly version string.
+func GetVersion() string {
+	return readBuildInfo().Version
+}
+
+// PrintVersion prints the version as plain text or, with format "json", the
+// full build information.
+func PrintVersion(format string) error {
+	switch format {
+	case "", "text":
+		fmt.Println(GetVersion())
+		return nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(GetBuildInfo())
+	}
+	return argsError(fmt.Errorf("invalid --format %q (want text or json)", format))
+}
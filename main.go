@@ -12,41 +12,89 @@ import (
 // Build-time variables (overridable via -ldflags)
 var (
 	Version string // preferred explicit version (e.g., a tag)
+	Commit  string // VCS revision
+	Date    string // build date
 )
 
 // Shared flag variables
 var (
-	flagConfig         string
-	flagData           string
-	flagFiles          []string
-	flagSets           []string
-	flagStrict         bool
-	flagDryRun         bool
-	flagGuard          string
-	flagInjectGuard    bool
-	flagDefaultMissing string
-	flagNoColor        bool
-	flagDebug          bool
-	flagLdelim         string
-	flagRdelim         string
-	flagExtraExts      []string
+	flagConfig          string
+	flagData            string
+	flagFiles           []string
+	flagEnvKey          string
+	flagResolveRefs     bool
+	flagAsserts         []string
+	flagPolicies        []string
+	flagPolicyMode      string
+	flagIncludeCache    int
+	flagIncludeMaxDepth int
+	flagMaxOutputSize   string
+	flagAuditLog        string
+	flagCryptoPolicy    string
+	flagValueTemplates  bool
+	flagRequireSchema   bool
+	flagFeatures        []string
+	flagFeatureSets     []string
+	flagValuesNamespace bool
+	flagSets            []string
+	flagStrict          bool
+	flagExplainMissing  bool
+	flagKeepEmpty       bool
+	flagQuietEmpty      bool
+	flagEncoding        string
+	flagPreserveEnc     bool
+	flagDryRun          bool
+	flagExitCode        bool
+	flagPathStyle       string
+	flagGuard           string
+	flagInjectGuard     bool
+	flagGuardStyle      string
+	flagGuardPosition   string
+	flagDefaultMissing  string
+	flagNoColor         bool
+	flagLogFormat       string
+	flagLang            string
+	flagNoLegacy        bool
+	flagExitZero        bool
+	flagDebug           bool
+	flagLdelim          string
+	flagRdelim          string
+	flagExtraExts       []string
 )
 
 // Command-specific flag variables
 var (
 	// render command
-	flagRenderIn      string
-	flagRenderOut     string
-	flagRenderHelpers string
+	flagRenderIn         string
+	flagRenderOut        string
+	flagRenderHelpers    string
+	flagRenderHelpersDir string
+	flagRenderEngine     string
 
 	// dir command
-	flagDirPath string
-	flagDirIn   string
-	flagDirOut  string
+	flagDirPath      string
+	flagDirIn        []string
+	flagDirOut       string
+	flagDirOutputDir string
+	flagDirAllowDups bool
+	flagDirIsolate   bool
 
 	// walk command
-	flagWalkSrc string
-	flagWalkDst string
+	flagWalkSrc        string
+	flagWalkDst        string
+	flagWalkDstArchive string
+	flagWalkHelmChart  string
+	flagWalkGHASummary bool
+	flagWalkRename     []string
+	flagWalkFlatten    bool
+	flagWalkCollision  string
+	flagWalkAllowDups  bool
+	flagWalkIsolate    bool
+	flagWalkProvenance string
+	flagWalkProvKey    string
+	flagWalkFrozen     bool
+	flagWalkAffectedBy string
+	flagWalkSince      string
 
 	// lint command
 	flagLintIn           string
@@ -54,14 +102,91 @@ var (
 	flagLintSrc          string
 	flagLintFailOnWarn   bool
 	flagLintFormat       string
+	flagLintOutput       string
+	flagLintGHASummary   bool
+	flagLintGHA          bool
+	flagLintPrintMatcher bool
+	flagLintStaged       bool
+	flagLintSince        string
 	flagLintNoUndefCheck bool
+	flagLintWhitespace   bool
+	flagLintFix          bool
+	flagLintProfile      string
+	flagLintValues       bool
+
+	// fmt command
+	flagFmtCheck bool
+
+	// funcs command
+	flagFuncsCategory  string
+	flagFuncsNamespace string
+	flagFuncsFormat    string
+
+	// hook command
+	flagHookPreCommit bool
+	flagHookLefthook  bool
+	flagHookForce     bool
+	flagHookVerify    string
 
 	// schema command
 	flagSchemaPath            string
+	flagSchemaSHA256          string
 	flagSchemaMode            string
 	flagSchemaOutput          string
 	flagSchemaRequired        string
 	flagSchemaAdditionalProps bool
+	flagSchemaOpenAPI         string
+	flagSchemaCRD             string
+	flagSchemaPointer         string
+	flagSchemaCRDVersion      string
+	flagSchemaDocsFormat      string
+	flagSchemaDriftFormat     string
+
+	// version command
+	flagVersionFormat string
+
+	// release command
+	flagReleaseFormats   []string
+	flagReleaseVersion   string
+	flagReleaseChecksums string
+	flagReleaseBaseURL   string
+	flagReleaseArch      string
+	flagReleaseOutputDir string
+
+	// verify command
+	flagVerifyProvenance string
+	flagVerifyKey        string
+	flagVerifySrc        string
+	flagVerifyDst        string
+
+	// values command
+	flagValuesDiffSrc    string
+	flagValuesDiffFormat string
+
+	// k8s command
+	flagK8sSrc        string
+	flagK8sConfigMap  string
+	flagK8sSecret     string
+	flagK8sNamespace  string
+	flagK8sLabels     []string
+	flagK8sOut        string
+	flagK8sApply      bool
+	flagK8sKubeconfig string
+	flagK8sContext    string
+	flagK8sRename     []string
+
+	// serve command
+	flagServeGRPC           string
+	flagServeAllowHostFuncs bool
+
+	// summarize command
+	flagSummarizeBase    string
+	flagSummarizeOut     string
+	flagSummarizeMaxKeys int
+
+	// batch command
+	flagBatchWorkers int
+	flagBatchHelpers string
 )
 
 var rootCmd = &cobra.Command{
@@ -76,6 +201,13 @@ SUBCOMMANDS:
   dir       Render templates from a directory
   walk      Recursively render template directory trees
   lint      Validate template syntax and detect issues
+  fmt       Format templates in canonical style
+  funcs     List available template functions
+  hook      Install git pre-commit hooks
+  release   Generate packaging manifests for a release
+  batch     Render a stream of JSON jobs from stdin
+  serve     Serve the render API over gRPC
+  summarize Summarize generated changes against a git ref as Markdown
   version   Print version information
 
 EXAMPLES:
@@ -101,6 +233,17 @@ For detailed help on a specific command:
   templr help <command>`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		app.SetExitZero(flagExitZero)
+		if err := app.SetLang(flagLang); err != nil {
+			return err
+		}
+		if err := app.SetLogFormat(flagLogFormat); err != nil {
+			return err
+		}
+		app.StartUpdateCheck(flagConfig)
+		return nil
+	},
 }
 
 var renderCmd = &cobra.Command{
@@ -120,24 +263,59 @@ Examples:
 	RunE: func(_ *cobra.Command, _ []string) error {
 		opts := app.RenderOptions{
 			Shared: app.SharedOptions{
-				Data:           flagData,
-				Files:          flagFiles,
-				Sets:           flagSets,
-				Strict:         flagStrict,
-				DryRun:         flagDryRun,
-				Guard:          flagGuard,
-				InjectGuard:    flagInjectGuard,
-				DefaultMissing: flagDefaultMissing,
-				NoColor:        flagNoColor,
-				Debug:          flagDebug,
-				Ldelim:         flagLdelim,
-				Rdelim:         flagRdelim,
-				ExtraExts:      flagExtraExts,
+				Data:             flagData,
+				Files:            flagFiles,
+				EnvKey:           flagEnvKey,
+				ResolveRefs:      flagResolveRefs,
+				Sets:             flagSets,
+				Features:         flagFeatures,
+				FeatureSets:      flagFeatureSets,
+				ValuesNamespace:  flagValuesNamespace,
+				Strict:           flagStrict,
+				DryRun:           flagDryRun,
+				DryRunExitCode:   flagExitCode,
+				PathStyle:        flagPathStyle,
+				Guard:            flagGuard,
+				InjectGuard:      flagInjectGuard,
+				GuardStyle:       flagGuardStyle,
+				GuardPosition:    flagGuardPosition,
+				DefaultMissing:   flagDefaultMissing,
+				NoColor:          flagNoColor,
+				Debug:            flagDebug,
+				Ldelim:           flagLdelim,
+				Rdelim:           flagRdelim,
+				ExtraExts:        flagExtraExts,
+				ExplainMissing:   flagExplainMissing,
+				KeepEmpty:        flagKeepEmpty,
+				QuietEmpty:       flagQuietEmpty,
+				Encoding:         flagEncoding,
+				PreserveEncoding: flagPreserveEnc,
+				Asserts:          flagAsserts,
+				Policies:         flagPolicies,
+				PolicyMode:       flagPolicyMode,
+				IncludeCache:     flagIncludeCache,
+				IncludeMaxDepth:  flagIncludeMaxDepth,
+				MaxOutputSize:    flagMaxOutputSize,
+				AuditLog:         flagAuditLog,
+				CryptoPolicy:     flagCryptoPolicy,
+				ValueTemplates:   flagValueTemplates,
+				RequireSchema:    flagRequireSchema,
 			},
-			In:      flagRenderIn,
-			Out:     flagRenderOut,
-			Helpers: flagRenderHelpers,
+			In:         flagRenderIn,
+			Out:        flagRenderOut,
+			Helpers:    flagRenderHelpers,
+			HelpersDir: flagRenderHelpersDir,
+			Engine:     flagRenderEngine,
 		}
+
+		// Apply config-driven function restrictions
+		config, err := app.LoadConfig(flagConfig)
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		app.ApplyFunctionsConfig(&opts.Shared, config)
+		app.ApplyRenderConfig(&opts.Shared, config)
+
 		return app.RunRenderMode(opts)
 	},
 }
@@ -153,29 +331,71 @@ Examples:
   # Render using an entry template
   templr dir --dir templates/ -in main.tpl -data values.yaml -out output.txt
 
-  # Render with auto-detected entry (looks for "root" template)
-  templr dir --dir templates/ -data values.yaml -out output.txt`,
+  # Render with auto-detected entry (index.tpl, main.tpl or a "root" template)
+  templr dir --dir templates/ -data values.yaml -out output.txt
+
+  # Render several entries, each to its own file under out/
+  templr dir --dir templates/ -i 'configs/*.tpl' -i main.tpl --output-dir out/`,
 	RunE: func(_ *cobra.Command, _ []string) error {
 		opts := app.DirOptions{
 			Shared: app.SharedOptions{
-				Data:           flagData,
-				Files:          flagFiles,
-				Sets:           flagSets,
-				Strict:         flagStrict,
-				DryRun:         flagDryRun,
-				Guard:          flagGuard,
-				InjectGuard:    flagInjectGuard,
-				DefaultMissing: flagDefaultMissing,
-				NoColor:        flagNoColor,
-				Debug:          flagDebug,
-				Ldelim:         flagLdelim,
-				Rdelim:         flagRdelim,
-				ExtraExts:      flagExtraExts,
+				Data:             flagData,
+				Files:            flagFiles,
+				EnvKey:           flagEnvKey,
+				ResolveRefs:      flagResolveRefs,
+				Sets:             flagSets,
+				Features:         flagFeatures,
+				FeatureSets:      flagFeatureSets,
+				ValuesNamespace:  flagValuesNamespace,
+				Strict:           flagStrict,
+				DryRun:           flagDryRun,
+				DryRunExitCode:   flagExitCode,
+				PathStyle:        flagPathStyle,
+				Guard:            flagGuard,
+				InjectGuard:      flagInjectGuard,
+				GuardStyle:       flagGuardStyle,
+				GuardPosition:    flagGuardPosition,
+				DefaultMissing:   flagDefaultMissing,
+				NoColor:          flagNoColor,
+				Debug:            flagDebug,
+				Ldelim:           flagLdelim,
+				Rdelim:           flagRdelim,
+				ExtraExts:        flagExtraExts,
+				ExplainMissing:   flagExplainMissing,
+				KeepEmpty:        flagKeepEmpty,
+				QuietEmpty:       flagQuietEmpty,
+				Encoding:         flagEncoding,
+				PreserveEncoding: flagPreserveEnc,
+				Asserts:          flagAsserts,
+				Policies:         flagPolicies,
+				PolicyMode:       flagPolicyMode,
+				IncludeCache:     flagIncludeCache,
+				IncludeMaxDepth:  flagIncludeMaxDepth,
+				MaxOutputSize:    flagMaxOutputSize,
+				AuditLog:         flagAuditLog,
+				CryptoPolicy:     flagCryptoPolicy,
+				ValueTemplates:   flagValueTemplates,
+				RequireSchema:    flagRequireSchema,
+				AllowDuplicates:  flagDirAllowDups,
+				IsolateValues:    flagDirIsolate,
 			},
-			Dir: flagDirPath,
-			In:  flagDirIn,
-			Out: flagDirOut,
+			Dir:       flagDirPath,
+			Out:       flagDirOut,
+			OutputDir: flagDirOutputDir,
+		}
+		if len(flagDirIn) > 0 {
+			opts.In, opts.Entries = flagDirIn[0], flagDirIn[1:]
+		}
+
+		// Apply config-driven function restrictions
+		config, err := app.LoadConfig(flagConfig)
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
 		}
+		app.ApplyFunctionsConfig(&opts.Shared, config)
+		app.ApplyRenderConfig(&opts.Shared, config)
+		app.ApplyDirConfig(&opts, config)
+
 		return app.RunDirMode(opts)
 	},
 }
@@ -197,27 +417,101 @@ Examples:
   templr walk --src templates/ --dst output/ --ext md --ext txt
 
   # Dry-run to preview changes
-  templr walk --src templates/ --dst output/ --dry-run`,
+  templr walk --src templates/ --dst output/ --dry-run
+
+  # Write the generated tree into an archive instead of a directory
+  templr walk --src templates/ --dst-archive output.tar.gz
+
+  # Write the outputs as the templates of a Helm chart
+  templr walk --src templates/ --as-helm-chart mychart/
+
+  # Rewrite output paths
+  templr walk --src templates/ --dst output/ --rename 'services/(.*)/config.tpl=>$1.conf'
+
+  # Only re-render what the changes since the main branch affect
+  templr walk --src templates/ --dst output/ --since origin/main
+
+  # Only re-render the templates that read a key changed since old.yaml
+  templr walk --src templates/ --dst output/ -f values.yaml --affected-by-values-diff old.yaml
+
+  # Record a signed provenance statement of the generated tree
+  templr walk --src templates/ --dst output/ --provenance output.intoto.json --provenance-key key.pem
+
+  # In CI: render, but fail on outputs the statement does not account for
+  templr walk --src templates/ --dst output/ --provenance output.intoto.json --frozen`,
 	RunE: func(_ *cobra.Command, _ []string) error {
 		opts := app.WalkOptions{
 			Shared: app.SharedOptions{
-				Data:           flagData,
-				Files:          flagFiles,
-				Sets:           flagSets,
-				Strict:         flagStrict,
-				DryRun:         flagDryRun,
-				Guard:          flagGuard,
-				InjectGuard:    flagInjectGuard,
-				DefaultMissing: flagDefaultMissing,
-				NoColor:        flagNoColor,
-				Debug:          flagDebug,
-				Ldelim:         flagLdelim,
-				Rdelim:         flagRdelim,
-				ExtraExts:      flagExtraExts,
+				Data:             flagData,
+				Files:            flagFiles,
+				EnvKey:           flagEnvKey,
+				ResolveRefs:      flagResolveRefs,
+				Sets:             flagSets,
+				Features:         flagFeatures,
+				FeatureSets:      flagFeatureSets,
+				ValuesNamespace:  flagValuesNamespace,
+				Strict:           flagStrict,
+				DryRun:           flagDryRun,
+				DryRunExitCode:   flagExitCode,
+				PathStyle:        flagPathStyle,
+				Guard:            flagGuard,
+				InjectGuard:      flagInjectGuard,
+				GuardStyle:       flagGuardStyle,
+				GuardPosition:    flagGuardPosition,
+				DefaultMissing:   flagDefaultMissing,
+				NoColor:          flagNoColor,
+				Debug:            flagDebug,
+				Ldelim:           flagLdelim,
+				Rdelim:           flagRdelim,
+				ExtraExts:        flagExtraExts,
+				ExplainMissing:   flagExplainMissing,
+				KeepEmpty:        flagKeepEmpty,
+				QuietEmpty:       flagQuietEmpty,
+				Encoding:         flagEncoding,
+				PreserveEncoding: flagPreserveEnc,
+				Asserts:          flagAsserts,
+				Policies:         flagPolicies,
+				PolicyMode:       flagPolicyMode,
+				IncludeCache:     flagIncludeCache,
+				IncludeMaxDepth:  flagIncludeMaxDepth,
+				MaxOutputSize:    flagMaxOutputSize,
+				AuditLog:         flagAuditLog,
+				CryptoPolicy:     flagCryptoPolicy,
+				ValueTemplates:   flagValueTemplates,
+				RequireSchema:    flagRequireSchema,
+				AllowDuplicates:  flagWalkAllowDups,
+				IsolateValues:    flagWalkIsolate,
 			},
-			Src: flagWalkSrc,
-			Dst: flagWalkDst,
+			Src:           flagWalkSrc,
+			Dst:           flagWalkDst,
+			DstArchive:    flagWalkDstArchive,
+			HelmChart:     flagWalkHelmChart,
+			GHASummary:    flagWalkGHASummary,
+			Flatten:       flagWalkFlatten,
+			OnCollision:   flagWalkCollision,
+			Provenance:    flagWalkProvenance,
+			ProvenanceKey: flagWalkProvKey,
+			Frozen:        flagWalkFrozen,
+			AffectedBy:    flagWalkAffectedBy,
+			Since:         flagWalkSince,
+		}
+		for _, r := range flagWalkRename {
+			rule, err := app.ParseRenameRule(r)
+			if err != nil {
+				return err
+			}
+			opts.Rename = append(opts.Rename, rule)
 		}
+
+		// Apply config-driven function restrictions and output layout
+		config, err := app.LoadConfig(flagConfig)
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		app.ApplyFunctionsConfig(&opts.Shared, config)
+		app.ApplyRenderConfig(&opts.Shared, config)
+		app.ApplyWalkConfig(&opts, config)
+
 		return app.RunWalkMode(opts)
 	},
 }
@@ -246,8 +540,19 @@ Examples:
   templr lint --src templates/ -d values.yaml --fail-on-warn
 
   # Skip undefined variable checking (syntax only)
-  templr lint --src templates/ --no-undefined-check`,
+  templr lint --src templates/ --no-undefined-check
+
+  # Find and fix stray blank lines left by {{ if }}/{{ end }} lines
+  templr lint --src templates/ --fix
+
+  # Annotate GitHub Actions logs via a problem matcher
+  templr lint --print-problem-matcher > "$RUNNER_TEMP/templr-matcher.json"
+  echo "::add-matcher::$RUNNER_TEMP/templr-matcher.json"`,
 	RunE: func(_ *cobra.Command, _ []string) error {
+		if flagLintPrintMatcher {
+			return app.PrintProblemMatcher(os.Stdout)
+		}
+
 		// Load configuration
 		config, err := app.LoadConfig(flagConfig)
 		if err != nil {
@@ -256,26 +561,42 @@ Examples:
 
 		opts := app.LintOptions{
 			Shared: app.SharedOptions{
-				Data:           flagData,
-				Files:          flagFiles,
-				Sets:           flagSets,
-				Strict:         flagStrict,
-				DryRun:         flagDryRun,
-				Guard:          flagGuard,
-				InjectGuard:    flagInjectGuard,
-				DefaultMissing: flagDefaultMissing,
-				NoColor:        flagNoColor,
-				Debug:          flagDebug,
-				Ldelim:         flagLdelim,
-				Rdelim:         flagRdelim,
-				ExtraExts:      flagExtraExts,
+				Data:            flagData,
+				Files:           flagFiles,
+				EnvKey:          flagEnvKey,
+				ResolveRefs:     flagResolveRefs,
+				Sets:            flagSets,
+				Features:        flagFeatures,
+				FeatureSets:     flagFeatureSets,
+				ValuesNamespace: flagValuesNamespace,
+				Strict:          flagStrict,
+				DryRun:          flagDryRun,
+				Guard:           flagGuard,
+				InjectGuard:     flagInjectGuard,
+				DefaultMissing:  flagDefaultMissing,
+				NoColor:         flagNoColor,
+				Debug:           flagDebug,
+				Ldelim:          flagLdelim,
+				Rdelim:          flagRdelim,
+				ExtraExts:       flagExtraExts,
+				CryptoPolicy:    flagCryptoPolicy,
+				ValueTemplates:  flagValueTemplates,
 			},
 			In:           flagLintIn,
 			Dir:          flagLintDir,
 			Src:          flagLintSrc,
 			FailOnWarn:   flagLintFailOnWarn,
 			Format:       flagLintFormat,
+			Output:       flagLintOutput,
+			GHASummary:   flagLintGHASummary,
+			GHA:          flagLintGHA,
+			Staged:       flagLintStaged,
+			Since:        flagLintSince,
 			NoUndefCheck: flagLintNoUndefCheck,
+			Whitespace:   flagLintWhitespace,
+			Fix:          flagLintFix,
+			Profile:      flagLintProfile,
+			Values:       flagLintValues,
 		}
 
 		// Apply config to options (CLI flags take precedence)
@@ -285,6 +606,118 @@ Examples:
 	},
 }
 
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [path...]",
+	Short: "Format templates in canonical style",
+	Long: `Rewrite templates in a canonical style, like gofmt does for Go code:
+
+  - actions get one space inside the delimiters ("{{ .x }}", "{{- .x -}}")
+  - spaces inside single-line actions are collapsed, pipes written " | "
+  - control structures nested in if/range/with/define/block are indented two
+    spaces deeper than their opener, with else/end aligned to it
+
+Only whitespace removed by trim markers is re-indented, so formatting never
+changes what a template renders. Directories are searched for templates (.tpl
+and --ext extensions); with no path, or "-", stdin is formatted to stdout.
+The paths of reformatted files are printed.
+
+With --check nothing is written: unformatted templates are listed and the
+command exits with code 13.
+
+Examples:
+  # Format every template under templates/
+  templr fmt templates/
+
+  # Fail CI when a template is not formatted
+  templr fmt --check templates/
+
+  # Format an editor buffer
+  templr fmt < page.tpl`,
+	RunE: func(_ *cobra.Command, args []string) error {
+		return app.RunFmt(app.FmtOptions{
+			Shared: app.SharedOptions{
+				Ldelim:    flagLdelim,
+				Rdelim:    flagRdelim,
+				ExtraExts: flagExtraExts,
+			},
+			Paths: args,
+			Check: flagFmtCheck,
+		})
+	},
+}
+
+var funcsCmd = &cobra.Command{
+	Use:   "funcs",
+	Short: "List available template functions",
+	Long: `List the template functions available to templates, grouped by category.
+
+Each function shows its namespace: "sprig" for functions from the Sprig library
+and "templr" for functions added by templr. Deprecated functions show the
+replacement to use; calling them while rendering prints a warning.
+
+Examples:
+  # List all functions
+  templr funcs
+
+  # Only network helpers
+  templr funcs --category network
+
+  # Machine-readable output
+  templr funcs --namespace templr --format json`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return app.RunFuncs(app.FuncsOptions{
+			Category:  flagFuncsCategory,
+			Namespace: flagFuncsNamespace,
+			Format:    flagFuncsFormat,
+		})
+	},
+}
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage git pre-commit hooks",
+	Long: `Manage git pre-commit hooks that lint staged templates.
+
+Subcommands:
+  install  Install a pre-commit hook`,
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install a pre-commit hook that lints staged templates",
+	Long: `Install a pre-commit hook that runs 'templr lint --staged' and, with
+--verify, 'templr verify' against a provenance statement.
+
+By default a plain git hook is written to .git/hooks/pre-commit. Use
+--pre-commit or --lefthook to write the config for those hook managers instead.
+
+Examples:
+  # Install a plain git hook
+  templr hook install
+
+  # Add a local hook to .pre-commit-config.yaml
+  templr hook install --pre-commit
+
+  # Add a command to lefthook.yml, linting .md templates too
+  templr hook install --lefthook --ext md
+
+  # Also check the generated files against the provenance statement
+  templr hook install --verify out.intoto.json`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		opts := app.HookOptions{
+			Shared: app.SharedOptions{
+				Guard:     flagGuard,
+				ExtraExts: flagExtraExts,
+			},
+			PreCommit: flagHookPreCommit,
+			Lefthook:  flagHookLefthook,
+			Force:     flagHookForce,
+			Verify:    flagHookVerify,
+		}
+		return app.RunHookInstall(opts)
+	},
+}
+
 var schemaCmd = &cobra.Command{
 	Use:   "schema",
 	Short: "Schema validation and generation commands",
@@ -292,7 +725,10 @@ var schemaCmd = &cobra.Command{
 
 Subcommands:
   validate  Validate data files against a schema
-  generate  Generate a schema from data files`,
+  generate  Generate a schema from data files
+  import    Convert an OpenAPI or CRD schema into a templr schema
+  docs      Render the schema as Markdown or HTML documentation
+  drift     Report values set to their schema default and defaults relied on`,
 }
 
 var schemaValidateCmd = &cobra.Command{
@@ -305,6 +741,9 @@ The schema file can be specified via:
   2. schema.path in .templr.yaml config
   3. Auto-discovery: .templr.schema.yml or .templr/schema.yml
 
+A schema given as an https URL is cached in the user cache directory and
+revalidated with its ETag; --schema-sha256 pins its content.
+
 Examples:
   # Validate using auto-discovered schema
   templr schema validate
@@ -315,6 +754,10 @@ Examples:
   # Validate with specific data files
   templr schema validate -data values.yaml -schema schema.yml
 
+  # Validate against a central schema, pinned to one version
+  templr schema validate --schema https://schemas.example.com/app.yml \
+    --schema-sha256 3f2a...
+
   # Fail on errors (vs warnings)
   templr schema validate --schema-mode error`,
 	RunE: func(_ *cobra.Command, _ []string) error {
@@ -322,13 +765,15 @@ Examples:
 		config, err := app.LoadConfig(flagConfig)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "[templr:error] load config: %v\n", err)
-			os.Exit(app.ExitGeneral)
+			app.Exit(app.ExitGeneral)
 		}
 
 		opts := app.SchemaOptions{
 			Shared: app.SharedOptions{
 				Data:           flagData,
 				Files:          flagFiles,
+				EnvKey:         flagEnvKey,
+				ResolveRefs:    flagResolveRefs,
 				Sets:           flagSets,
 				Strict:         flagStrict,
 				DryRun:         flagDryRun,
@@ -342,12 +787,13 @@ Examples:
 				ExtraExts:      flagExtraExts,
 			},
 			SchemaPath: flagSchemaPath,
+			SHA256:     flagSchemaSHA256,
 			Mode:       flagSchemaMode,
 		}
 
 		if err := app.RunSchemaValidate(opts, config); err != nil {
 			fmt.Fprintf(os.Stderr, "[templr:error] %v\n", err)
-			os.Exit(app.ExitSchemaError)
+			app.Exit(app.ExitSchemaError)
 		}
 		return nil
 	},
@@ -377,13 +823,15 @@ Examples:
 		config, err := app.LoadConfig(flagConfig)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "[templr:error] load config: %v\n", err)
-			os.Exit(app.ExitGeneral)
+			app.Exit(app.ExitGeneral)
 		}
 
 		opts := app.SchemaOptions{
 			Shared: app.SharedOptions{
 				Data:           flagData,
 				Files:          flagFiles,
+				EnvKey:         flagEnvKey,
+				ResolveRefs:    flagResolveRefs,
 				Sets:           flagSets,
 				Strict:         flagStrict,
 				DryRun:         flagDryRun,
@@ -403,32 +851,571 @@ Examples:
 
 		if err := app.RunSchemaGenerate(opts, config); err != nil {
 			fmt.Fprintf(os.Stderr, "[templr:error] %v\n", err)
-			os.Exit(app.ExitGeneral)
+			app.Exit(app.ExitGeneral)
+		}
+		return nil
+	},
+}
+
+var schemaDocsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Render the schema as Markdown or HTML documentation",
+	Long: `Render the schema into human-readable documentation: one entry per key
+path with its type, description, default, required flag, allowed values and
+constraints, and an anchor to link to.
+
+The schema is found as for schema validate: --schema, schema.path in the
+config, then .templr.schema.yml or .templr/schema.yml.
+
+Examples:
+  # Markdown to stdout
+  templr schema docs
+
+  # A standalone HTML page
+  templr schema docs --schema schema.yml --format html -o values.html`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		config, err := app.LoadConfig(flagConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[templr:error] load config: %v\n", err)
+			app.Exit(app.ExitGeneral)
+		}
+
+		opts := app.SchemaOptions{
+			SchemaPath: flagSchemaPath,
+			SHA256:     flagSchemaSHA256,
+			Output:     flagSchemaOutput,
+			Format:     flagSchemaDocsFormat,
+		}
+
+		if err := app.RunSchemaDocs(opts, config); err != nil {
+			fmt.Fprintf(os.Stderr, "[templr:error] %v\n", err)
+			app.Exit(app.ExitCode(err))
+		}
+		return nil
+	},
+}
+
+var schemaDriftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Report values set to their schema default and defaults relied on",
+	Long: `Compare the merged values with the defaults of the schema and report the
+keys the values set to their default, candidates for deletion, with the
+values files setting them, and the defaults the values leave to the schema.
+Keeping environment overlays to the keys they actually change makes them
+easier to review.
+
+The values are merged as for render: values.yaml, --data, -f and --set. The
+schema is found as for schema validate.
+
+Examples:
+  # Review an environment overlay
+  templr schema drift -f values-prod.yaml
+
+  # Machine-readable output
+  templr schema drift -f values-prod.yaml --format json`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		config, err := app.LoadConfig(flagConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[templr:error] load config: %v\n", err)
+			app.Exit(app.ExitGeneral)
+		}
+
+		opts := app.SchemaOptions{
+			Shared: app.SharedOptions{
+				Data:        flagData,
+				Files:       flagFiles,
+				EnvKey:      flagEnvKey,
+				ResolveRefs: flagResolveRefs,
+				Sets:        flagSets,
+				Debug:       flagDebug,
+				Ldelim:      flagLdelim,
+				Rdelim:      flagRdelim,
+				ExtraExts:   flagExtraExts,
+			},
+			SchemaPath: flagSchemaPath,
+			SHA256:     flagSchemaSHA256,
+			Format:     flagSchemaDriftFormat,
+		}
+
+		if err := app.RunSchemaDrift(opts, config); err != nil {
+			fmt.Fprintf(os.Stderr, "[templr:error] %v\n", err)
+			app.Exit(app.ExitCode(err))
 		}
 		return nil
 	},
 }
 
+var schemaImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Convert an OpenAPI or CRD schema into a templr schema",
+	Long: `Convert a schema from an OpenAPI document or a Kubernetes
+CustomResourceDefinition into the templr schema format (JSON Schema draft-07
+in YAML), so existing API schemas need no hand-maintained copy.
+
+Local $refs become definitions of the imported schema, OpenAPI 3.0 nullable
+and example become a "null" type and examples, and x- extensions are dropped.
+
+Examples:
+  # Import a component schema of an OpenAPI document
+  templr schema import --openapi api.yaml --path '#/components/schemas/Config' -o .templr.schema.yml
+
+  # Import the spec of a CRD's storage version
+  templr schema import --crd crd.yaml --path '#/properties/spec' -o .templr.schema.yml
+
+  # Import a specific CRD version
+  templr schema import --crd crd.yaml --crd-version v1beta1`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		opts := app.SchemaOptions{
+			Output:     flagSchemaOutput,
+			OpenAPI:    flagSchemaOpenAPI,
+			CRD:        flagSchemaCRD,
+			Pointer:    flagSchemaPointer,
+			CRDVersion: flagSchemaCRDVersion,
+		}
+
+		if err := app.RunSchemaImport(opts); err != nil {
+			fmt.Fprintf(os.Stderr, "[templr:error] %v\n", err)
+			app.Exit(app.ExitCode(err))
+		}
+		return nil
+	},
+}
+
+var releaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Release tooling",
+	Long: `Release tooling for templr itself.
+
+Subcommands:
+  manifest  Render packaging manifests (brew, scoop, deb) for a release`,
+}
+
+var releaseManifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Render packaging manifests for a release",
+	Long: `Render the Homebrew formula (templr.rb), Scoop manifest (templr.json)
+and Debian control file (control) of a release from built-in templates,
+using templr's own template engine.
+
+The brew and scoop manifests need the SHA-256 checksums of the release
+archives, read from the SHA256SUMS file of the release.
+
+Examples:
+  # Write all manifests for v1.4.0
+  templr release manifest --version 1.4.0 --checksums dist/SHA256SUMS --output-dir dist/manifests
+
+  # Print the Homebrew formula
+  templr release manifest --format brew --version 1.4.0 --checksums dist/SHA256SUMS
+
+  # Debian control file for arm64
+  templr release manifest --format deb --version 1.4.0 --arch arm64`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return app.RunReleaseManifest(app.ReleaseOptions{
+			Formats:   flagReleaseFormats,
+			Version:   flagReleaseVersion,
+			Checksums: flagReleaseChecksums,
+			BaseURL:   flagReleaseBaseURL,
+			Arch:      flagReleaseArch,
+			OutputDir: flagReleaseOutputDir,
+		})
+	},
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify generated files against a provenance statement",
+	Long: `Check the provenance statement written by walk --provenance: every
+recorded output must be unchanged, and the templates and values files it was
+rendered from must still match. With --key, the signature of a signed
+statement is checked too.
+
+Exits with code 11 when a file differs or the signature does not match.
+
+Examples:
+  # Verify the generated tree
+  templr verify --provenance output.intoto.json
+
+  # Also check the signature
+  templr verify --provenance output.intoto.json --key key.pub.pem`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return app.RunVerify(app.VerifyOptions{
+			Provenance: flagVerifyProvenance,
+			Key:        flagVerifyKey,
+			Src:        flagVerifySrc,
+			Dst:        flagVerifyDst,
+		})
+	},
+}
+
+var valuesCmd = &cobra.Command{
+	Use:   "values",
+	Short: "Inspect value sets",
+}
+
+var valuesDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show the keys two value sets add, remove and change",
+	Long: `Compare two value sets, given as two -f flags: the old one first, the new
+one second. Each is merged like the -f files of a render, with the
+values.yaml of --src (or the current directory) as its base and --set on
+top; list several files separated by commas to compare merged sets.
+
+Added (+), removed (-) and changed (~) keys are printed with their types.
+Maps are compared key by key, lists as a whole. With --src, only keys the
+templates of the tree reference are shown, followed by the templates that
+read them; --format files prints only those templates, for hooks.
+
+Examples:
+  # Review a promotion from staging to production
+  templr values diff -f values/staging.yaml -f values/prod.yaml
+
+  # Compare merged sets, limited to what the templates read
+  templr values diff -f common.yaml,staging.yaml -f common.yaml,prod.yaml --src templates/
+
+  # The templates a change affects, one per line
+  templr values diff -f old.yaml -f new.yaml --src templates/ --format files
+
+  # Machine-readable output
+  templr values diff -f old.yaml -f new.yaml --format json`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		config, err := app.LoadConfig(flagConfig)
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		return app.RunValuesDiff(app.ValuesDiffOptions{
+			Shared: app.SharedOptions{
+				Files:       flagFiles,
+				EnvKey:      flagEnvKey,
+				ResolveRefs: flagResolveRefs,
+				Sets:        flagSets,
+				Debug:       flagDebug,
+				Ldelim:      flagLdelim,
+				Rdelim:      flagRdelim,
+				ExtraExts:   flagExtraExts,
+				Redact:      config.Debug.Redact,
+			},
+			Src:    flagValuesDiffSrc,
+			Format: flagValuesDiffFormat,
+		})
+	},
+}
+
+var k8sCmd = &cobra.Command{
+	Use:   "k8s",
+	Short: "Kubernetes tooling",
+	Long: `Render templates into Kubernetes objects.
+
+Subcommands:
+  apply  Package rendered files into a ConfigMap or Secret`,
+}
+
+var k8sApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Package rendered files into a ConfigMap or Secret",
+	Long: `Render a template tree like walk and package the outputs into a ConfigMap
+(--configmap) or Secret (--secret) manifest instead of writing files. Each
+output becomes a key named after its path with "/" replaced by "_".
+
+The manifest carries a checksum/config annotation with the SHA-256 of its
+data; copy it into the pod template of a Deployment to roll the pods when
+the configuration changes.
+
+The manifest is printed to stdout (or written to --out). With --apply it is
+piped to "kubectl apply -f -" instead, using --kubeconfig and --context when
+given; --dry-run then asks kubectl for a client-side dry run.
+
+Examples:
+  # Print a ConfigMap manifest
+  templr k8s apply --src templates/ --configmap app-config --namespace prod -d values.yaml
+
+  # Apply a Secret to the current cluster
+  templr k8s apply --src secrets/ --secret app-secrets --namespace prod --apply`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		opts := app.K8sOptions{
+			Walk: app.WalkOptions{
+				Shared: app.SharedOptions{
+					Data:             flagData,
+					Files:            flagFiles,
+					EnvKey:           flagEnvKey,
+					ResolveRefs:      flagResolveRefs,
+					Sets:             flagSets,
+					Features:         flagFeatures,
+					FeatureSets:      flagFeatureSets,
+					ValuesNamespace:  flagValuesNamespace,
+					Strict:           flagStrict,
+					DryRun:           flagDryRun,
+					DefaultMissing:   flagDefaultMissing,
+					NoColor:          flagNoColor,
+					Debug:            flagDebug,
+					Ldelim:           flagLdelim,
+					Rdelim:           flagRdelim,
+					ExtraExts:        flagExtraExts,
+					ExplainMissing:   flagExplainMissing,
+					KeepEmpty:        flagKeepEmpty,
+					QuietEmpty:       flagQuietEmpty,
+					Encoding:         flagEncoding,
+					PreserveEncoding: flagPreserveEnc,
+					Asserts:          flagAsserts,
+					Policies:         flagPolicies,
+					PolicyMode:       flagPolicyMode,
+					IncludeCache:     flagIncludeCache,
+					IncludeMaxDepth:  flagIncludeMaxDepth,
+					MaxOutputSize:    flagMaxOutputSize,
+					AuditLog:         flagAuditLog,
+					CryptoPolicy:     flagCryptoPolicy,
+					ValueTemplates:   flagValueTemplates,
+					RequireSchema:    flagRequireSchema,
+				},
+				Src: flagK8sSrc,
+			},
+			ConfigMap:  flagK8sConfigMap,
+			Secret:     flagK8sSecret,
+			Namespace:  flagK8sNamespace,
+			Labels:     flagK8sLabels,
+			Out:        flagK8sOut,
+			Apply:      flagK8sApply,
+			Kubeconfig: flagK8sKubeconfig,
+			Context:    flagK8sContext,
+		}
+		for _, r := range flagK8sRename {
+			rule, err := app.ParseRenameRule(r)
+			if err != nil {
+				return err
+			}
+			opts.Walk.Rename = append(opts.Walk.Rename, rule)
+		}
+
+		config, err := app.LoadConfig(flagConfig)
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		app.ApplyFunctionsConfig(&opts.Walk.Shared, config)
+		app.ApplyRenderConfig(&opts.Walk.Shared, config)
+
+		return app.RunK8sApply(opts)
+	},
+}
+
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "Render a stream of JSON jobs from stdin",
+	Long: `Read render jobs from stdin, one JSON object per line, render them with a
+pool of --workers goroutines and print one JSON result per line, in the order
+the jobs finish. Orchestrators can stream thousands of small renders through
+one process instead of starting one per file.
+
+A job names a template file (template) or carries its source (inline), values
+merged over the shared -d/-f/--set values, an output file (out) and an id
+echoed in its result:
+
+  {"id": "web", "template": "nginx.conf.tpl", "values": {"port": 8080}, "out": "out/web.conf"}
+  {"id": "motd", "inline": "Hello {{ .name }}", "values": {"name": "ops"}}
+
+A result carries the input line, the id and out of its job, a status
+(rendered, unchanged, changed with --dry-run, empty, skipped when the guard is
+missing, or error), the output when the job has no out, and the error:
+
+  {"line": 2, "id": "motd", "status": "rendered", "output": "Hello ops"}
+
+A failed job does not stop the others; templr exits with 1 once every job
+ran if any failed.
+
+Examples:
+  # Render the jobs of a file with 8 workers
+  templr batch --workers 8 -d defaults.yaml < jobs.jsonl
+
+  # Collect the failures
+  generate-jobs | templr batch | jq -c 'select(.status == "error")'`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		opts := app.BatchOptions{
+			Shared: app.SharedOptions{
+				Data:             flagData,
+				Files:            flagFiles,
+				EnvKey:           flagEnvKey,
+				ResolveRefs:      flagResolveRefs,
+				Sets:             flagSets,
+				Features:         flagFeatures,
+				FeatureSets:      flagFeatureSets,
+				ValuesNamespace:  flagValuesNamespace,
+				Strict:           flagStrict,
+				DryRun:           flagDryRun,
+				PathStyle:        flagPathStyle,
+				Guard:            flagGuard,
+				InjectGuard:      flagInjectGuard,
+				GuardStyle:       flagGuardStyle,
+				GuardPosition:    flagGuardPosition,
+				DefaultMissing:   flagDefaultMissing,
+				NoColor:          flagNoColor,
+				Debug:            flagDebug,
+				Ldelim:           flagLdelim,
+				Rdelim:           flagRdelim,
+				ExtraExts:        flagExtraExts,
+				Encoding:         flagEncoding,
+				PreserveEncoding: flagPreserveEnc,
+				IncludeCache:     flagIncludeCache,
+				IncludeMaxDepth:  flagIncludeMaxDepth,
+				MaxOutputSize:    flagMaxOutputSize,
+				CryptoPolicy:     flagCryptoPolicy,
+				ValueTemplates:   flagValueTemplates,
+			},
+			Workers: flagBatchWorkers,
+			Helpers: flagBatchHelpers,
+		}
+
+		config, err := app.LoadConfig(flagConfig)
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		app.ApplyFunctionsConfig(&opts.Shared, config)
+		app.ApplyRenderConfig(&opts.Shared, config)
+
+		return app.RunBatchMode(opts, os.Stdin)
+	},
+}
+
+var summarizeCmd = &cobra.Command{
+	Use:   "summarize",
+	Short: "Summarize generated changes against a git ref as Markdown",
+	Long: `Compare the generated files of the working tree with --base and print a
+Markdown summary for a merge request description: the generated files added,
+changed and removed, and for changed YAML and JSON files the keys whose
+values differ.
+
+A file counts as generated when it carries the guard marker (--guard) in the
+working tree or at --base. Untracked files are included; values of sensitive
+keys (see debug.redact) are hidden.
+
+Examples:
+  # Summarize a branch against main after rendering
+  templr walk --src templates/ --dst deploy/
+  templr summarize --base origin/main deploy/
+
+  # Post the summary from CI
+  templr summarize --base "$CI_MERGE_REQUEST_DIFF_BASE_SHA" -o summary.md`,
+	RunE: func(_ *cobra.Command, args []string) error {
+		config, err := app.LoadConfig(flagConfig)
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		return app.RunSummarize(app.SummarizeOptions{
+			Guard:   flagGuard,
+			Redact:  config.Debug.Redact,
+			Base:    flagSummarizeBase,
+			Paths:   args,
+			Output:  flagSummarizeOut,
+			MaxKeys: flagSummarizeMaxKeys,
+		})
+	},
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the render API over gRPC",
+	Long: `Serve the templr.v1.RenderService gRPC API (proto/templr/v1/render.proto)
+on --grpc, for platforms that render templates over the network instead of
+running the CLI:
+
+  RenderSingle    render one template
+  RenderTree      render a set of templates sharing their defines
+  Lint            check a template for parse errors and missing values
+  ValidateSchema  validate values against a JSON Schema
+
+Templates, values and .Files contents travel in the requests; the server
+never reads its own filesystem for them. Functions disabled by the config
+or --crypto-policy stay disabled, and --max-output-size applies to every
+output. env, expandenv and getHostByName, which read the server's
+environment or resolve host names, are disabled unless --allow-host-funcs is
+given. Render errors are returned with the INVALID_ARGUMENT status; a call
+cancelled by its client or past its deadline stops rendering.
+
+The server has no authentication or TLS: bind it to a loopback or private
+address, or put it behind a proxy that provides them.
+
+SIGINT and SIGTERM stop the server once the calls in progress finish.
+
+Examples:
+  # Serve on the loopback interface
+  templr serve --grpc 127.0.0.1:9090
+
+  # Try it with grpcurl
+  grpcurl -plaintext -import-path proto -proto templr/v1/render.proto \
+    -d '{"template": "Hello {{ .name }}", "options": {"values": "name: World"}}' \
+    127.0.0.1:9090 templr.v1.RenderService/RenderSingle`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		config, err := app.LoadConfig(flagConfig)
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		opts := app.ServeOptions{
+			Shared: app.SharedOptions{
+				IncludeCache:    flagIncludeCache,
+				IncludeMaxDepth: flagIncludeMaxDepth,
+				MaxOutputSize:   flagMaxOutputSize,
+				CryptoPolicy:    flagCryptoPolicy,
+			},
+			GRPC:           flagServeGRPC,
+			AllowHostFuncs: flagServeAllowHostFuncs,
+			Config:         config,
+		}
+		app.ApplyFunctionsConfig(&opts.Shared, config)
+		app.ApplyRenderConfig(&opts.Shared, config)
+		return app.RunServe(opts)
+	},
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print version information",
-	Run: func(_ *cobra.Command, _ []string) {
-		fmt.Println(app.GetVersion())
+	Long: `Print the templr version.
+
+With --format json, print the version, commit, build date, Go version,
+platform and compiled-in features. Fields not set at build time are read from
+the build information the Go toolchain embeds.`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return app.PrintVersion(flagVersionFormat)
 	},
 }
 
 func init() {
 	// Add persistent (global) flags to root command
 	rootCmd.PersistentFlags().StringVar(&flagConfig, "config", "", "Path to config file (default: .templr.yaml or ~/.config/templr/config.yaml)")
-	rootCmd.PersistentFlags().StringVarP(&flagData, "data", "d", "", "Path to base JSON or YAML data file")
-	rootCmd.PersistentFlags().StringArrayVarP(&flagFiles, "f", "f", nil, "Additional values files (YAML/JSON). Repeatable.")
+	rootCmd.PersistentFlags().StringVarP(&flagData, "data", "d", "", "Path to base data file (YAML, JSON, JSONC, TOML or .env)")
+	rootCmd.PersistentFlags().StringArrayVarP(&flagFiles, "f", "f", nil, "Additional values files (YAML, JSON, JSONC, TOML or .env). Repeatable.")
+	rootCmd.PersistentFlags().StringVar(&flagEnvKey, "env-key", "", "Dotted key to nest the values of .env files under (default: top level)")
+	rootCmd.PersistentFlags().BoolVar(&flagResolveRefs, "resolve-refs", false, "Resolve ${.dotted.key} references to other values inside values files and --set")
 	rootCmd.PersistentFlags().StringArrayVar(&flagSets, "set", nil, "key=value overrides. Repeatable. Supports dotted keys.")
 	rootCmd.PersistentFlags().BoolVar(&flagStrict, "strict", false, "Fail on missing keys")
+	rootCmd.PersistentFlags().BoolVar(&flagExplainMissing, "explain-missing", false, "After a non-strict render, list every undefined value reference")
+	rootCmd.PersistentFlags().BoolVar(&flagKeepEmpty, "keep-empty", false, "Create empty output files instead of skipping empty renders")
+	rootCmd.PersistentFlags().BoolVar(&flagQuietEmpty, "quiet-empty", false, "Do not report skipped empty renders")
+	rootCmd.PersistentFlags().StringVar(&flagEncoding, "encoding", "", "Output encoding: utf-8, utf-8-bom or utf-16le (default: as rendered)")
+	rootCmd.PersistentFlags().StringArrayVar(&flagAsserts, "assert", nil, `Expression every rendered file must satisfy, e.g. 'eq .Data.server.port 8080'. Repeatable.`)
+	rootCmd.PersistentFlags().StringArrayVar(&flagPolicies, "policy", nil, "Policy file or directory (templr rules .yaml/.json, Rego .rego, CUE .cue) checked against every rendered file. Repeatable.")
+	rootCmd.PersistentFlags().StringVar(&flagPolicyMode, "policy-mode", "", "How policy violations are handled: enforce (fail and skip the file, default) or warn")
+	rootCmd.PersistentFlags().IntVar(&flagIncludeMaxDepth, "include-max-depth", 0, "Fail include calls nested deeper than N, e.g. a template including itself (0: 1000)")
+	rootCmd.PersistentFlags().IntVar(&flagIncludeCache, "include-cache", 0, "Memoize include renders by template name and data, keeping up to N results (0: off; includeCached always memoizes)")
+	rootCmd.PersistentFlags().StringArrayVar(&flagFeatures, "feature", nil, "Enable a feature flag for templates, as name or name=value (repeatable); see .Templr.Features and hasFeature")
+	rootCmd.PersistentFlags().StringArrayVar(&flagFeatureSets, "feature-set", nil, "Enable the feature flags of a features.sets entry of the config (repeatable)")
+	rootCmd.PersistentFlags().BoolVar(&flagValuesNamespace, "values-namespace", false, "Expose the values under .Values as well as at the top level, as Helm charts read them")
+	rootCmd.PersistentFlags().BoolVar(&flagRequireSchema, "require-schema", false, "Validate the values against the schema before render, dir or walk write anything, and fail on errors")
+	rootCmd.PersistentFlags().BoolVar(&flagValueTemplates, "allow-value-templates", false, "Let renderValueTemplate render template snippets stored in values, without env, include or value changes")
+	rootCmd.PersistentFlags().StringVar(&flagCryptoPolicy, "crypto-policy", "", "Crypto helper policy: default, or fips to reject non-approved helpers such as sha1sum and bcrypt")
+	rootCmd.PersistentFlags().StringVar(&flagMaxOutputSize, "max-output-size", "", "Abort a render whose output exceeds this size, e.g. 10MiB (default 100MiB, 0 disables)")
+	rootCmd.PersistentFlags().StringVar(&flagAuditLog, "audit-log", "", "Append a JSON record of each non-dry-run render (user, host, argv, input hashes, changed outputs) to this file, or to the system log with 'syslog'")
+	rootCmd.PersistentFlags().StringVar(&flagPathStyle, "path-style", "slash", "How output paths are reported: slash (forward slashes on every platform) or native")
+	rootCmd.PersistentFlags().BoolVar(&flagPreserveEnc, "preserve-encoding", false, "Keep the BOM and line endings of existing output files")
 	rootCmd.PersistentFlags().BoolVar(&flagDryRun, "dry-run", false, "Preview which files would be rendered (no writes)")
+	rootCmd.PersistentFlags().BoolVar(&flagExitCode, "exit-code", false, "With --dry-run, exit with code 14 when an output would be created or changed (0 when none would)")
 	rootCmd.PersistentFlags().StringVar(&flagGuard, "guard", "#templr generated", "Guard string required in existing files to allow overwrite")
 	rootCmd.PersistentFlags().BoolVar(&flagInjectGuard, "inject-guard", true, "Automatically insert the guard as a comment into written files")
+	rootCmd.PersistentFlags().StringVar(&flagGuardStyle, "guard-style", "", `Comment style for the injected guard, e.g. "-- %s" (overrides the file type)`)
+	rootCmd.PersistentFlags().StringVar(&flagGuardPosition, "guard-position", "", "Where to inject the guard: top, after-shebang, after-directives or bottom (overrides the file type)")
 	rootCmd.PersistentFlags().StringVar(&flagDefaultMissing, "default-missing", "<no value>", "String to render when a variable/key is missing")
 	rootCmd.PersistentFlags().BoolVar(&flagNoColor, "no-color", false, "Disable colored output (useful for CI/non-ANSI terminals)")
+	rootCmd.PersistentFlags().StringVar(&flagLogFormat, "log-format", "text", "Format of errors and warnings on stderr: text or json")
+	rootCmd.PersistentFlags().StringVar(&flagLang, "lang", "", "Language of error messages, strict mode tips and lint output: en, de, es or ja (default $TEMPLR_LANG, else en)")
+	rootCmd.PersistentFlags().BoolVar(&flagExitZero, "exit-zero", false, "Report errors but always exit with status 0 (report-only runs)")
+	rootCmd.PersistentFlags().BoolVar(&flagNoLegacy, "no-legacy", false, "Reject the deprecated flag-only syntax (templr -walk ...) instead of translating it")
 	rootCmd.PersistentFlags().BoolVar(&flagDebug, "debug", false, "Enable debug output (shows variable context and render evaluation flow)")
 	rootCmd.PersistentFlags().StringVar(&flagLdelim, "ldelim", "{{", "Left delimiter")
 	rootCmd.PersistentFlags().StringVar(&flagRdelim, "rdelim", "}}", "Right delimiter")
@@ -438,29 +1425,74 @@ func init() {
 	renderCmd.Flags().StringVarP(&flagRenderIn, "in", "i", "", "Template file (omit for stdin)")
 	renderCmd.Flags().StringVarP(&flagRenderOut, "out", "o", "", "Output file (omit for stdout)")
 	renderCmd.Flags().StringVar(&flagRenderHelpers, "helpers", "_helpers*.tpl", "Glob pattern of helper templates to load. Set empty to skip.")
+	renderCmd.Flags().StringVar(&flagRenderHelpersDir, "helpers-dir", "", "Directory to load --helpers from (default: the template's directory; needed with stdin)")
+	renderCmd.Flags().StringVar(&flagRenderEngine, "engine", "", "Template engine: go or jinja (default: by extension, jinja for .j2, .jinja and .jinja2)")
 
 	// Dir command flags
 	dirCmd.Flags().StringVar(&flagDirPath, "dir", "", "Directory containing templates (required)")
-	dirCmd.Flags().StringVarP(&flagDirIn, "in", "i", "", "Entry template name (default: 'root' or first template)")
+	dirCmd.Flags().StringArrayVarP(&flagDirIn, "in", "i", nil, "Entry template name or glob (default: dir.entry, a root template, index.tpl or main.tpl, or the only template), or - for stdin. Repeatable.")
 	dirCmd.Flags().StringVarP(&flagDirOut, "out", "o", "", "Output file (omit for stdout)")
+	dirCmd.Flags().BoolVar(&flagDirIsolate, "isolate-values", false, "Give each entry its own copy of the values so mutations cannot leak between entries")
+	dirCmd.Flags().BoolVar(&flagDirAllowDups, "allow-duplicate-templates", false, "Let a later file override a template name already defined by another file")
+	dirCmd.Flags().StringVar(&flagDirOutputDir, "output-dir", "", "Render each entry to a file named after it in this directory")
 	_ = dirCmd.MarkFlagRequired("dir")
 
 	// Walk command flags
 	walkCmd.Flags().StringVar(&flagWalkSrc, "src", "", "Source template directory (required)")
-	walkCmd.Flags().StringVar(&flagWalkDst, "dst", "", "Destination output directory (required)")
+	walkCmd.Flags().StringVar(&flagWalkDst, "dst", "", "Destination output directory (required unless --dst-archive or --as-helm-chart is set)")
+	walkCmd.Flags().StringVar(&flagWalkDstArchive, "dst-archive", "", "Write the outputs into this .tar, .tar.gz/.tgz or .zip file instead of a directory")
+	walkCmd.Flags().StringVar(&flagWalkHelmChart, "as-helm-chart", "", "Write the outputs as the templates of a Helm chart in this directory, with a generated Chart.yaml")
+	walkCmd.Flags().BoolVar(&flagWalkGHASummary, "gha-summary", false, "Append a Markdown summary of rendered files to $GITHUB_STEP_SUMMARY")
+	walkCmd.Flags().StringArrayVar(&flagWalkRename, "rename", nil, "Output path rewrite rule 'REGEX=>PATH' (template path relative to --src => output relative to --dst). Repeatable.")
+	walkCmd.Flags().BoolVar(&flagWalkIsolate, "isolate-values", false, "Give each template its own copy of the values so mutations cannot leak between templates")
+	walkCmd.Flags().BoolVar(&flagWalkAllowDups, "allow-duplicate-templates", false, "Let a later file override a template name already defined by another file")
+	walkCmd.Flags().BoolVar(&flagWalkFlatten, "flatten", false, "Write every output directly under --dst instead of mirroring source directories")
+	walkCmd.Flags().StringVar(&flagWalkCollision, "on-collision", "", "When templates render to the same output: error (default), first or last (keep the output of the first or last template in name order)")
+	walkCmd.Flags().StringVar(&flagWalkProvenance, "provenance", "", "Write an in-toto/SLSA provenance statement of the inputs and outputs to this file")
+	walkCmd.Flags().StringVar(&flagWalkProvKey, "provenance-key", "", "Ed25519 private key (PKCS#8 PEM) signing the provenance statement")
+	walkCmd.Flags().StringVar(&flagWalkSince, "since", "", "Only render templates changed since this git ref, those including them and those reading a values key changed since")
+	walkCmd.Flags().StringVar(&flagWalkAffectedBy, "affected-by-values-diff", "", "Only render templates that read a values key changed since these old values files (comma-separated)")
+	walkCmd.Flags().BoolVar(&flagWalkFrozen, "frozen", false, "Fail if an output is not listed in the --provenance statement or changes while the inputs it records did not")
 	_ = walkCmd.MarkFlagRequired("src")
-	_ = walkCmd.MarkFlagRequired("dst")
+	walkCmd.MarkFlagsOneRequired("dst", "dst-archive", "as-helm-chart")
+	walkCmd.MarkFlagsMutuallyExclusive("dst", "dst-archive", "as-helm-chart")
 
 	// Lint command flags
-	lintCmd.Flags().StringVarP(&flagLintIn, "in", "i", "", "Single template file to lint")
+	lintCmd.Flags().StringVarP(&flagLintIn, "in", "i", "", "Single template file to lint, or - for stdin")
 	lintCmd.Flags().StringVar(&flagLintDir, "dir", "", "Directory of templates to lint")
 	lintCmd.Flags().StringVar(&flagLintSrc, "src", "", "Source directory tree to walk and lint")
 	lintCmd.Flags().BoolVar(&flagLintFailOnWarn, "fail-on-warn", false, "Exit with code 1 on warnings (default: errors only)")
-	lintCmd.Flags().StringVar(&flagLintFormat, "format", "text", "Output format: text, json, github-actions")
+	lintCmd.Flags().StringVar(&flagLintFormat, "format", "text", "Output format: text, json, github-actions, gitlab, checkstyle, sarif")
+	lintCmd.Flags().StringVar(&flagLintOutput, "output", "", "Write the lint report to a file instead of stdout")
+	lintCmd.Flags().BoolVar(&flagLintGHASummary, "gha-summary", false, "Append a Markdown summary of lint results to $GITHUB_STEP_SUMMARY")
+	lintCmd.Flags().BoolVar(&flagLintGHA, "gha", false, "In GitHub Actions: annotate issues (on stderr), append the step summary and set the errors, warnings and report step outputs")
+	lintCmd.Flags().BoolVar(&flagLintPrintMatcher, "print-problem-matcher", false, "Print the GitHub Actions problem matcher for the text format and exit")
+	lintCmd.Flags().BoolVar(&flagLintStaged, "staged", false, "Only lint templates staged in git (all templates if a values file is staged)")
+	lintCmd.Flags().StringVar(&flagLintSince, "since", "", "Only lint templates changed since this git ref and those including them (all templates if a values file changed)")
 	lintCmd.Flags().BoolVar(&flagLintNoUndefCheck, "no-undefined-check", false, "Skip undefined variable detection")
+	lintCmd.Flags().BoolVar(&flagLintWhitespace, "whitespace", false, "Report actions that leave blank lines or trailing spaces in the output")
+	lintCmd.Flags().StringVar(&flagLintProfile, "profile", "", "Add the rules of a profile: gha (GitHub Actions workflows)")
+	lintCmd.Flags().BoolVar(&flagLintValues, "values", false, "Also lint the values files (values.yaml, --data, -f) for duplicate keys, tab indentation and ambiguous scalars")
+	lintCmd.Flags().BoolVar(&flagLintFix, "fix", false, "Add the trim markers suggested by --whitespace to the templates (implies --whitespace)")
+
+	// Fmt command flags
+	fmtCmd.Flags().BoolVar(&flagFmtCheck, "check", false, "List unformatted templates and exit with code 13 instead of rewriting them")
+
+	// Funcs command flags
+	funcsCmd.Flags().StringVar(&flagFuncsCategory, "category", "", "Only list functions in this category")
+	funcsCmd.Flags().StringVar(&flagFuncsNamespace, "namespace", "", "Only list functions in this namespace: sprig, templr")
+	funcsCmd.Flags().StringVar(&flagFuncsFormat, "format", "text", "Output format: text, json")
+
+	// Hook install command flags
+	hookInstallCmd.Flags().BoolVar(&flagHookPreCommit, "pre-commit", false, "Write a local hook into .pre-commit-config.yaml")
+	hookInstallCmd.Flags().BoolVar(&flagHookLefthook, "lefthook", false, "Write a command into lefthook.yml")
+	hookInstallCmd.Flags().BoolVar(&flagHookForce, "force", false, "Overwrite an existing hook or config file")
+	hookInstallCmd.Flags().StringVar(&flagHookVerify, "verify", "", "Also run templr verify against this provenance statement")
+	hookCmd.AddCommand(hookInstallCmd)
 
 	// Schema validate command flags
-	schemaValidateCmd.Flags().StringVar(&flagSchemaPath, "schema", "", "Path to schema file (default: auto-discover)")
+	schemaValidateCmd.Flags().StringVar(&flagSchemaPath, "schema", "", "Path or https URL of the schema (default: auto-discover)")
+	schemaValidateCmd.Flags().StringVar(&flagSchemaSHA256, "schema-sha256", "", "Fail unless the schema has this sha256 digest (hex)")
 	schemaValidateCmd.Flags().StringVar(&flagSchemaMode, "schema-mode", "", "Validation mode: warn|error|strict (default from config or warn)")
 
 	// Schema generate command flags
@@ -468,18 +1500,96 @@ func init() {
 	schemaGenerateCmd.Flags().StringVar(&flagSchemaRequired, "required", "", "Mark fields as required: all|none|auto (default from config or auto)")
 	schemaGenerateCmd.Flags().BoolVar(&flagSchemaAdditionalProps, "additional-props", true, "Allow additional properties in schema")
 
+	// Schema import command flags
+	schemaImportCmd.Flags().StringVarP(&flagSchemaOutput, "output", "o", "", "Output schema file (default: stdout)")
+	schemaImportCmd.Flags().StringVar(&flagSchemaOpenAPI, "openapi", "", "OpenAPI document (YAML or JSON) to import from")
+	schemaImportCmd.Flags().StringVar(&flagSchemaCRD, "crd", "", "CustomResourceDefinition manifest to import from")
+	schemaImportCmd.Flags().StringVar(&flagSchemaPointer, "path", "", "JSON pointer of the schema to import, e.g. '#/components/schemas/Config'")
+	schemaImportCmd.Flags().StringVar(&flagSchemaCRDVersion, "crd-version", "", "CRD version to import (default: the storage version)")
+
+	// Version command flags
+	versionCmd.Flags().StringVar(&flagVersionFormat, "format", "text", "Output format: text or json")
+
+	// Release manifest command flags
+	releaseManifestCmd.Flags().StringArrayVar(&flagReleaseFormats, "format", nil, "Manifest to render: brew, scoop or deb. Repeatable (default: all)")
+	releaseManifestCmd.Flags().StringVar(&flagReleaseVersion, "version", "", "Release version (default: the version of this binary)")
+	releaseManifestCmd.Flags().StringVar(&flagReleaseChecksums, "checksums", "", "SHA256SUMS file of the release archives (needed for brew and scoop)")
+	releaseManifestCmd.Flags().StringVar(&flagReleaseBaseURL, "base-url", "", "Download URL of the release archives (default: the GitHub release)")
+	releaseManifestCmd.Flags().StringVar(&flagReleaseArch, "arch", "amd64", "Debian architecture of the deb control file")
+	releaseManifestCmd.Flags().StringVar(&flagReleaseOutputDir, "output-dir", "", "Write the manifests to this directory (default: stdout, one format only)")
+
+	// Verify command flags
+	verifyCmd.Flags().StringVar(&flagVerifyProvenance, "provenance", "", "Provenance statement written by walk --provenance (required)")
+	verifyCmd.Flags().StringVar(&flagVerifyKey, "key", "", "Ed25519 public key PEM to check the signature with")
+	verifyCmd.Flags().StringVar(&flagVerifySrc, "src", "", "Template directory (default: the recorded --src)")
+	verifyCmd.Flags().StringVar(&flagVerifyDst, "dst", "", "Output directory (default: the recorded --dst)")
+	_ = verifyCmd.MarkFlagRequired("provenance")
+	releaseCmd.AddCommand(releaseManifestCmd)
+
+	// Values diff command flags
+	valuesDiffCmd.Flags().StringVar(&flagValuesDiffSrc, "src", "", "Only show keys referenced by the templates of this directory")
+	valuesDiffCmd.Flags().StringVar(&flagValuesDiffFormat, "format", "text", "Output format: text, json or files (the templates of --src affected by the changes)")
+	valuesCmd.AddCommand(valuesDiffCmd)
+
+	// K8s apply command flags
+	k8sApplyCmd.Flags().StringVar(&flagK8sSrc, "src", "", "Template directory to render (required)")
+	k8sApplyCmd.Flags().StringVar(&flagK8sConfigMap, "configmap", "", "Name of the ConfigMap to generate")
+	k8sApplyCmd.Flags().StringVar(&flagK8sSecret, "secret", "", "Name of the Secret to generate")
+	k8sApplyCmd.Flags().StringVarP(&flagK8sNamespace, "namespace", "n", "", "Namespace of the object")
+	k8sApplyCmd.Flags().StringArrayVar(&flagK8sLabels, "label", nil, "Extra label key=value. Repeatable")
+	k8sApplyCmd.Flags().StringVarP(&flagK8sOut, "out", "o", "", "Write the manifest to this file (default: stdout)")
+	k8sApplyCmd.Flags().BoolVar(&flagK8sApply, "apply", false, "Apply the manifest with kubectl instead of printing it")
+	k8sApplyCmd.Flags().StringVar(&flagK8sKubeconfig, "kubeconfig", "", "kubeconfig file passed to kubectl")
+	k8sApplyCmd.Flags().StringVar(&flagK8sContext, "context", "", "kubeconfig context passed to kubectl")
+	k8sApplyCmd.Flags().StringArrayVar(&flagK8sRename, "rename", nil, "Rewrite output paths (before they become keys), like walk --rename. Repeatable")
+	_ = k8sApplyCmd.MarkFlagRequired("src")
+	k8sApplyCmd.MarkFlagsMutuallyExclusive("configmap", "secret")
+	k8sApplyCmd.MarkFlagsOneRequired("configmap", "secret")
+	k8sApplyCmd.MarkFlagsMutuallyExclusive("out", "apply")
+	k8sCmd.AddCommand(k8sApplyCmd)
+
+	// Batch command flags
+	batchCmd.Flags().IntVar(&flagBatchWorkers, "workers", 0, "Jobs rendered at once (default: number of CPUs)")
+	batchCmd.Flags().StringVar(&flagBatchHelpers, "helpers", "_helpers*.tpl", "Glob pattern of helper templates to load next to each template file. Set empty to skip.")
+
+	// Serve command flags
+	summarizeCmd.Flags().StringVar(&flagSummarizeBase, "base", "", "Git ref the working tree is compared with (required)")
+	summarizeCmd.Flags().StringVarP(&flagSummarizeOut, "out", "o", "", "Write the summary to this file (default: stdout)")
+	summarizeCmd.Flags().IntVar(&flagSummarizeMaxKeys, "max-keys", 10, "Key changes listed per file")
+	_ = summarizeCmd.MarkFlagRequired("base")
+
+	serveCmd.Flags().StringVar(&flagServeGRPC, "grpc", "", "Address of the gRPC RenderService, e.g. 127.0.0.1:9090 (required)")
+	serveCmd.Flags().BoolVar(&flagServeAllowHostFuncs, "allow-host-funcs", false, "Let requests call env, expandenv and getHostByName")
+	_ = serveCmd.MarkFlagRequired("grpc")
+
 	// Add schema subcommands
-	schemaCmd.AddCommand(schemaValidateCmd, schemaGenerateCmd)
+	schemaDocsCmd.Flags().StringVar(&flagSchemaPath, "schema", "", "Path or https URL of the schema (default: auto-discover)")
+	schemaDocsCmd.Flags().StringVar(&flagSchemaSHA256, "schema-sha256", "", "Fail unless the schema has this sha256 digest (hex)")
+	schemaDocsCmd.Flags().StringVar(&flagSchemaDocsFormat, "format", "markdown", "Output format: markdown or html")
+	schemaDocsCmd.Flags().StringVarP(&flagSchemaOutput, "output", "o", "", "Output file (default: stdout)")
+
+	schemaDriftCmd.Flags().StringVar(&flagSchemaPath, "schema", "", "Path or https URL of the schema (default: auto-discover)")
+	schemaDriftCmd.Flags().StringVar(&flagSchemaSHA256, "schema-sha256", "", "Fail unless the schema has this sha256 digest (hex)")
+	schemaDriftCmd.Flags().StringVar(&flagSchemaDriftFormat, "format", "text", "Output format: text or json")
+
+	schemaCmd.AddCommand(schemaValidateCmd, schemaGenerateCmd, schemaImportCmd, schemaDocsCmd, schemaDriftCmd)
 
 	// Add subcommands
-	rootCmd.AddCommand(renderCmd, dirCmd, walkCmd, lintCmd, schemaCmd, versionCmd)
+	rootCmd.AddCommand(renderCmd, dirCmd, walkCmd, lintCmd, fmtCmd, funcsCmd, hookCmd, schemaCmd, releaseCmd, verifyCmd, valuesCmd, k8sCmd, batchCmd, serveCmd, summarizeCmd, versionCmd)
 }
 
 func main() {
 	// Set version in app package for build-time injection
-	app.Version = Version
+	app.Version, app.Commit, app.Date = Version, Commit, Date
+
+	// Tracing is opt-in through the standard OTEL_* environment variables
+	if err := app.SetupTracing(); err != nil {
+		fmt.Fprintf(os.Stderr, "[templr:warn:trace] %v\n", err)
+	}
+	defer app.ShutdownTracing()
 
 	// Check for legacy flag syntax (backward compatibility)
+	legacy := false
 	if len(os.Args) > 1 {
 		firstArg := os.Args[1]
 
@@ -501,38 +1611,48 @@ func main() {
 			"dir":        true,
 			"walk":       true,
 			"lint":       true,
+			"fmt":        true,
+			"funcs":      true,
+			"hook":       true,
 			"schema":     true,
+			"release":    true,
+			"verify":     true,
+			"k8s":        true,
+			"values":     true,
+			"batch":      true,
+			"serve":      true,
+			"summarize":  true,
 			"version":    true,
 			"help":       true,
 			"completion": true,
 		}
 
-		// If first arg is NOT a known subcommand, use legacy mode
+		// If first arg is NOT a known subcommand, translate the legacy syntax
 		if !knownSubcommands[firstArg] {
 			// This handles cases like:
 			// - templr -in file.tpl
 			// - templr --walk --src ... --dst ...
 			// - templr --dir templates/
-			app.RunLegacyMode()
-			return
+			args, err := app.TranslateLegacyArgs(os.Args[1:])
+			if err != nil {
+				app.PrintError(err, false)
+				app.Exit(app.ExitCode(err))
+			}
+			rootCmd.SetArgs(args)
+			legacy = true
 		}
 	}
 
 	// Execute cobra command (will show help if no args)
 	if err := rootCmd.Execute(); err != nil {
-		// Map errors to appropriate exit codes
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-
-		// Try to determine error type from message
-		errMsg := err.Error()
-		if app.Contains(errMsg, "parse") || app.Contains(errMsg, "template") {
-			os.Exit(app.ExitTemplateError)
-		} else if app.Contains(errMsg, "data") || app.Contains(errMsg, "load") {
-			os.Exit(app.ExitDataError)
-		} else if app.Contains(errMsg, "guard") {
-			os.Exit(app.ExitGuardSkipped)
+		if legacy {
+			app.ExitLegacyError(err, flagNoColor)
 		}
 
-		os.Exit(app.ExitGeneral)
+		// The exit code comes from the error's type (app.ExitError, app.TemplateError)
+		app.PrintError(err, flagNoColor)
+		app.Exit(app.ExitCode(err))
 	}
+	app.PrintLegacyNotice()
+	app.PrintUpdateNotice()
 }
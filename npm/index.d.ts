@@ -0,0 +1,28 @@
+import type { FuncInfo, LintIssue, RenderRequest } from './types';
+
+export type { FuncInfo, LintIssue, RenderRequest, RenderResponse, LintResponse } from './types';
+
+export interface RenderResult {
+  output: string;
+  warnings: string[];
+}
+
+/** Error thrown when templr reports a render or lint error. */
+export class TemplrError extends Error {
+  warnings: string[];
+}
+
+/**
+ * Renders opts.template with opts.values (YAML or JSON), opts.helpers and
+ * opts.files (the .Files API).
+ */
+export function render(opts: RenderRequest): Promise<RenderResult>;
+
+/**
+ * Lints opts.template (with opts.helpers, and opts.values to report
+ * undefined references).
+ */
+export function lint(opts: Pick<RenderRequest, 'template' | 'helpers' | 'values'>): Promise<LintIssue[]>;
+
+/** The template functions of the build. */
+export function funcs(): Promise<FuncInfo[]>;
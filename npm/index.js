@@ -0,0 +1,97 @@
+'use strict';
+
+// Node.js bindings of the templr WebAssembly build (make npm). The module is
+// instantiated once, on the first call; every call then runs synchronously
+// inside it, so calls never interleave.
+
+const fs = require('fs');
+const path = require('path');
+
+// The globals wasm_exec.js expects outside a browser, as Go's own
+// wasm_exec_node.js sets them.
+globalThis.require ??= require;
+globalThis.fs ??= fs;
+globalThis.path ??= path;
+globalThis.TextEncoder ??= require('util').TextEncoder;
+globalThis.TextDecoder ??= require('util').TextDecoder;
+globalThis.performance ??= require('perf_hooks').performance;
+globalThis.crypto ??= require('crypto').webcrypto;
+
+require('./wasm_exec.js');
+
+/** Error thrown when templr reports a render or lint error. */
+class TemplrError extends Error {
+  constructor(message, warnings) {
+    super(message);
+    this.name = 'TemplrError';
+    this.warnings = warnings || [];
+  }
+}
+
+let loading;
+
+function load() {
+  if (!loading) {
+    loading = (async () => {
+      const go = new globalThis.Go();
+      const wasm = await fs.promises.readFile(path.join(__dirname, 'templr.wasm'));
+      const { instance } = await WebAssembly.instantiate(wasm, go.importObject);
+      go.run(instance); // returns once main blocks, with the functions set
+      return globalThis;
+    })();
+  }
+  return loading;
+}
+
+function call(fn, ...args) {
+  return JSON.parse(fn(...args));
+}
+
+// setInputs replaces the inputs of the module's session with those of opts.
+function setInputs(g, opts) {
+  call(g.templrReset);
+  const inputs = Object.assign({}, opts.files, {
+    template: opts.template || '',
+    helpers: opts.helpers || '',
+    values: opts.values || '',
+  });
+  for (const [name, content] of Object.entries(inputs)) {
+    const res = call(g.templrSetFile, name, content);
+    if (res.error) throw new TemplrError(res.error);
+  }
+}
+
+/**
+ * Renders opts.template with opts.values (YAML or JSON), opts.helpers and
+ * opts.files (the .Files API). Resolves to the output and the warnings;
+ * rejects with a TemplrError.
+ */
+async function render(opts) {
+  const g = await load();
+  setInputs(g, opts);
+  const { defaultMissing, strict, injectGuard, guardMarker } = opts;
+  const res = call(g.templrRenderSession, JSON.stringify({ defaultMissing, strict, injectGuard, guardMarker }));
+  if (res.error) throw new TemplrError(res.error, res.warnings);
+  return { output: res.output || '', warnings: res.warnings || [] };
+}
+
+/**
+ * Lints opts.template (with opts.helpers, and opts.values to report
+ * undefined references). Resolves to the issues found; rejects with a
+ * TemplrError when the values cannot be read.
+ */
+async function lint(opts) {
+  const g = await load();
+  setInputs(g, opts);
+  const res = call(g.templrLint);
+  if (res.error) throw new TemplrError(res.error);
+  return res.issues;
+}
+
+/** Resolves to the template functions of the build. */
+async function funcs() {
+  const g = await load();
+  return call(g.templrFuncs);
+}
+
+module.exports = { render, lint, funcs, TemplrError };
@@ -0,0 +1,31 @@
+'use strict';
+
+// Run with `make npm-test`, which builds templr.wasm first.
+
+const test = require('node:test');
+const assert = require('node:assert');
+const templr = require('./index.js');
+
+test('render', async () => {
+  const res = await templr.render({
+    template: '{{ include "greet" . }} {{ call .Files.Get "motd" }}',
+    helpers: '{{ define "greet" }}hello {{ .name | upper }}{{ end }}',
+    values: 'name: web\n',
+    files: { motd: 'ok' },
+  });
+  assert.strictEqual(res.output, 'hello WEB ok');
+});
+
+test('render error', async () => {
+  await assert.rejects(templr.render({ template: '{{ .missing.key }}', strict: true }), templr.TemplrError);
+});
+
+test('lint', async () => {
+  const issues = await templr.lint({ template: '{{ .name }} {{ .port }}', values: 'name: web\n' });
+  assert.deepStrictEqual(issues.map((i) => i.message), ['variable .port is undefined']);
+});
+
+test('funcs', async () => {
+  const list = await templr.funcs();
+  assert.ok(list.some((f) => f.name === 'toYaml'));
+});
@@ -0,0 +1,39 @@
+// Code generated by tsgen from wasm/api; DO NOT EDIT.
+
+export interface RenderRequest {
+  template?: string;
+  values?: string;
+  helpers?: string;
+  defaultMissing?: string;
+  strict?: boolean;
+  files?: Record<string, string>;
+  injectGuard?: boolean;
+  guardMarker?: string;
+}
+
+export interface RenderResponse {
+  output?: string;
+  error?: string;
+  warnings?: string[];
+}
+
+export interface LintIssue {
+  rule: string;
+  severity: string;
+  line?: number;
+  message: string;
+}
+
+export interface LintResponse {
+  issues: LintIssue[];
+  error?: string;
+}
+
+export interface FuncInfo {
+  name: string;
+  namespace: string;
+  category: string;
+  deprecated_since?: string;
+  replacement?: string;
+  overrides_sprig?: boolean;
+}
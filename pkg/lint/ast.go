@@ -0,0 +1,132 @@
+package lint
+
+import (
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// WalkPipes visits every pipeline in the tree rooted at node, descending into
+// if/range/with bodies and else branches. Nested templates invoked with
+// {{ template }} are not followed.
+func WalkPipes(node parse.Node, fn func(pipe *parse.PipeNode)) {
+	if node == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *parse.ActionNode:
+		fn(n.Pipe)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.ListNode:
+		walkList(n, fn)
+	case *parse.TemplateNode:
+		if n.Pipe != nil {
+			fn(n.Pipe)
+		}
+	}
+}
+
+func walkBranch(b *parse.BranchNode, fn func(pipe *parse.PipeNode)) {
+	fn(b.Pipe)
+	walkList(b.List, fn)
+	if b.ElseList != nil {
+		walkList(b.ElseList, fn)
+	}
+}
+
+// walkList walks all nodes in a list
+func walkList(list *parse.ListNode, fn func(pipe *parse.PipeNode)) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		WalkPipes(node, fn)
+	}
+}
+
+// ExtractVariables extracts all field references (e.g. ".a.b") from a template AST.
+// The result is sorted for stable reporting.
+func ExtractVariables(tree *parse.Tree) []string {
+	if tree == nil {
+		return nil
+	}
+	vars := make(map[string]bool)
+	WalkPipes(tree.Root, func(pipe *parse.PipeNode) {
+		extractFromPipe(pipe, vars)
+	})
+	return sortedKeys(vars)
+}
+
+// extractFromPipe extracts variable references from a pipe
+func extractFromPipe(pipe *parse.PipeNode, vars map[string]bool) {
+	if pipe == nil {
+		return
+	}
+
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			extractFromArg(arg, vars)
+		}
+	}
+}
+
+// extractFromArg extracts variable references from an argument
+func extractFromArg(arg parse.Node, vars map[string]bool) {
+	switch a := arg.(type) {
+	case *parse.FieldNode:
+		// This is a field access like .field or .nested.field
+		path := "." + strings.Join(a.Ident, ".")
+		vars[path] = true
+	case *parse.ChainNode:
+		// This is a method chain
+		if a.Node != nil {
+			extractFromArg(a.Node, vars)
+		}
+	case *parse.PipeNode:
+		extractFromPipe(a, vars)
+	}
+}
+
+// ExtractFunctionCalls extracts the names of all functions called in a template AST.
+// The result is sorted for stable reporting.
+func ExtractFunctionCalls(tree *parse.Tree) []string {
+	if tree == nil {
+		return nil
+	}
+	funcs := make(map[string]bool)
+	WalkPipes(tree.Root, func(pipe *parse.PipeNode) {
+		extractFuncsFromPipe(pipe, funcs)
+	})
+	return sortedKeys(funcs)
+}
+
+// extractFuncsFromPipe extracts function names from a pipe
+func extractFuncsFromPipe(pipe *parse.PipeNode, funcs map[string]bool) {
+	if pipe == nil {
+		return
+	}
+
+	for _, cmd := range pipe.Cmds {
+		if len(cmd.Args) > 0 {
+			// First arg might be a function identifier
+			if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+				funcs[ident.Ident] = true
+			}
+		}
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,277 @@
+// Package lint provides the template linting engine used by `templr lint`.
+// It exposes the issue types, AST helpers for extracting variable references
+// and function calls, and a Rule interface so organizations can register
+// in-house checks when embedding templr.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template/parse"
+)
+
+// Severity levels reported by rules.
+const (
+	SeverityError = "error"
+	SeverityWarn  = "warn"
+)
+
+// Issue represents a single linting issue.
+type Issue struct {
+	Rule     string // id of the rule that produced the issue
+	Severity string // "error", "warn"
+	Category string // "parse", "undefined", "function", "guard"
+	File     string // file path
+	Line     int    // line number (0 if unknown)
+	Column   int    // column number (0 if unknown)
+	Message  string // human-readable message
+}
+
+// Result contains the results of a lint operation.
+type Result struct {
+	Issues []Issue
+	Errors int
+	Warns  int
+}
+
+// Add appends issues to the result and updates the error/warning counters.
+func (r *Result) Add(issues ...Issue) {
+	for _, is := range issues {
+		r.Issues = append(r.Issues, is)
+		if is.Severity == SeverityError {
+			r.Errors++
+		} else {
+			r.Warns++
+		}
+	}
+}
+
+// RuleID returns the issue's rule id, falling back to its category for
+// issues that were not produced by a Rule (e.g. parse errors).
+func (i Issue) RuleID() string {
+	if i.Rule != "" {
+		return i.Rule
+	}
+	return i.Category
+}
+
+// Context carries per-template information passed to rules.
+type Context struct {
+	File   string         // path of the template being checked
+	Name   string         // template name within the parsed set
+	Source []byte         // raw template source (may be nil)
+	Values map[string]any // merged values (nil when no data was provided)
+}
+
+// Rule is a single lint check run against a parsed template tree.
+type Rule interface {
+	Name() string
+	Check(tree *parse.Tree, ctx *Context) []Issue
+}
+
+// RuleFunc adapts a plain function into a named Rule.
+type RuleFunc struct {
+	RuleName string
+	Fn       func(tree *parse.Tree, ctx *Context) []Issue
+}
+
+// Name returns the rule name.
+func (r RuleFunc) Name() string { return r.RuleName }
+
+// Check runs the wrapped function.
+func (r RuleFunc) Check(tree *parse.Tree, ctx *Context) []Issue {
+	if r.Fn == nil {
+		return nil
+	}
+	return r.Fn(tree, ctx)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Rule{}
+)
+
+// Register adds a custom rule to the global registry. Registered rules run
+// for every template checked by `templr lint`. Registering a rule with a name
+// that already exists replaces the previous rule.
+func Register(r Rule) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[r.Name()] = r
+}
+
+// Unregister removes a rule from the global registry.
+func Unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, name)
+}
+
+// RegisteredRules returns all registered rules sorted by name.
+func RegisteredRules() []Rule {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	rules := make([]Rule, 0, len(registry))
+	for _, r := range registry {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name() < rules[j].Name() })
+	return rules
+}
+
+// Run executes the given rules against tree and returns all issues found.
+// Issues without a file are attributed to ctx.File, and issues without a rule
+// id or category inherit the rule's name.
+func Run(tree *parse.Tree, ctx *Context, rules []Rule) []Issue {
+	if tree == nil {
+		return nil
+	}
+	var issues []Issue
+	for _, r := range rules {
+		for _, is := range r.Check(tree, ctx) {
+			if is.File == "" {
+				is.File = ctx.File
+			}
+			if is.Rule == "" {
+				is.Rule = r.Name()
+			}
+			if is.Category == "" {
+				is.Category = r.Name()
+			}
+			if is.Severity == "" {
+				is.Severity = SeverityWarn
+			}
+			issues = append(issues, is)
+		}
+	}
+	return issues
+}
+
+// UndefinedRule reports variable references that are missing from ctx.Values.
+// It does nothing when ctx.Values is nil.
+type UndefinedRule struct {
+	Severity string
+}
+
+// Name returns the rule name.
+func (UndefinedRule) Name() string { return "undefined" }
+
+// Check reports every field reference not present in the values.
+func (r UndefinedRule) Check(tree *parse.Tree, ctx *Context) []Issue {
+	if ctx.Values == nil {
+		return nil
+	}
+	severity := r.Severity
+	if severity == "" {
+		severity = SeverityWarn
+	}
+	var issues []Issue
+	for _, varPath := range ExtractVariables(tree) {
+		if !VariableExists(varPath, ctx.Values) {
+			issues = append(issues, Issue{
+				Severity: severity,
+				Category: "undefined",
+				File:     ctx.File,
+				Message:  fmt.Sprintf("variable %s is undefined", varPath),
+			})
+		}
+	}
+	return issues
+}
+
+// DisallowedFunctionsRule reports calls to any function in Functions.
+type DisallowedFunctionsRule struct {
+	Functions []string
+}
+
+// Name returns the rule name.
+func (DisallowedFunctionsRule) Name() string { return "function" }
+
+// Check reports every disallowed function used in the tree.
+func (r DisallowedFunctionsRule) Check(tree *parse.Tree, ctx *Context) []Issue {
+	if len(r.Functions) == 0 {
+		return nil
+	}
+	disallowMap := make(map[string]bool)
+	for _, fn := range r.Functions {
+		disallowMap[fn] = true
+	}
+	var issues []Issue
+	for _, fn := range ExtractFunctionCalls(tree) {
+		if disallowMap[fn] {
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Category: "function",
+				File:     ctx.File,
+				Message:  fmt.Sprintf("disallowed function %q is used", fn),
+			})
+		}
+	}
+	return issues
+}
+
+// VariableExists checks if a dotted variable path (e.g. ".a.b") exists in values.
+func VariableExists(varPath string, values map[string]any) bool {
+	// Remove leading dot
+	varPath = strings.TrimPrefix(varPath, ".")
+
+	// Handle special cases; feature flags are only known at render time
+	if varPath == "" || varPath == "Files" || varPath == "Values" || varPath == "Templr" || strings.HasPrefix(varPath, "Templr.") {
+		return true
+	}
+
+	// Split the path and traverse the values
+	parts := strings.Split(varPath, ".")
+	current := values
+
+	for i, part := range parts {
+		val, ok := current[part]
+		if !ok {
+			return false
+		}
+
+		// If this is the last part, we found it
+		if i == len(parts)-1 {
+			return true
+		}
+
+		// Otherwise, traverse deeper
+		switch v := val.(type) {
+		case map[string]any:
+			current = v
+		case map[any]any:
+			// Convert to map[string]any
+			m := make(map[string]any)
+			for k, v := range v {
+				if ks, ok := k.(string); ok {
+					m[ks] = v
+				}
+			}
+			current = m
+		default:
+			// Can't traverse further
+			return false
+		}
+	}
+
+	return false
+}
+
+// ExtractLineNumber tries to extract a line number from a template error message.
+func ExtractLineNumber(errMsg string) int {
+	// Go template errors often include "line X" in the message
+	// Example: "template: file.tpl:12: unexpected {{end}}"
+	parts := strings.Split(errMsg, ":")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if i > 0 && i < len(parts)-1 {
+			var line int
+			if _, err := fmt.Sscanf(part, "%d", &line); err == nil {
+				return line
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,811 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: templr/v1/render.proto
+
+// Package templr.v1 is the gRPC API of `templr serve --grpc`: rendering,
+// linting and schema validation of sources sent in the request, with no
+// access to the server's filesystem.
+
+package templrv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// RenderOptions are the inputs shared by every template of a request.
+type RenderOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Values as a YAML (or JSON) document.
+	Values string `protobuf:"bytes,1,opt,name=values,proto3" json:"values,omitempty"`
+	// Helper templates parsed before the template, for their defines.
+	Helpers string `protobuf:"bytes,2,opt,name=helpers,proto3" json:"helpers,omitempty"`
+	// Files of the .Files API, by path.
+	Files map[string]string `protobuf:"bytes,3,rep,name=files,proto3" json:"files,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Fail on references missing from the values.
+	Strict bool `protobuf:"varint,4,opt,name=strict,proto3" json:"strict,omitempty"`
+	// Text rendered for missing references (default "<no value>").
+	DefaultMissing string `protobuf:"bytes,5,opt,name=default_missing,json=defaultMissing,proto3" json:"default_missing,omitempty"`
+	// Prepend the do-not-edit guard to the outputs.
+	InjectGuard bool `protobuf:"varint,6,opt,name=inject_guard,json=injectGuard,proto3" json:"inject_guard,omitempty"`
+	// Guard text (default "#templr generated").
+	GuardMarker   string `protobuf:"bytes,7,opt,name=guard_marker,json=guardMarker,proto3" json:"guard_marker,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenderOptions) Reset() {
+	*x = RenderOptions{}
+	mi := &file_templr_v1_render_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenderOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderOptions) ProtoMessage() {}
+
+func (x *RenderOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_templr_v1_render_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderOptions.ProtoReflect.Descriptor instead.
+func (*RenderOptions) Descriptor() ([]byte, []int) {
+	return file_templr_v1_render_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RenderOptions) GetValues() string {
+	if x != nil {
+		return x.Values
+	}
+	return ""
+}
+
+func (x *RenderOptions) GetHelpers() string {
+	if x != nil {
+		return x.Helpers
+	}
+	return ""
+}
+
+func (x *RenderOptions) GetFiles() map[string]string {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *RenderOptions) GetStrict() bool {
+	if x != nil {
+		return x.Strict
+	}
+	return false
+}
+
+func (x *RenderOptions) GetDefaultMissing() string {
+	if x != nil {
+		return x.DefaultMissing
+	}
+	return ""
+}
+
+func (x *RenderOptions) GetInjectGuard() bool {
+	if x != nil {
+		return x.InjectGuard
+	}
+	return false
+}
+
+func (x *RenderOptions) GetGuardMarker() string {
+	if x != nil {
+		return x.GuardMarker
+	}
+	return ""
+}
+
+type RenderSingleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Template      string                 `protobuf:"bytes,1,opt,name=template,proto3" json:"template,omitempty"`
+	Options       *RenderOptions         `protobuf:"bytes,2,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenderSingleRequest) Reset() {
+	*x = RenderSingleRequest{}
+	mi := &file_templr_v1_render_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenderSingleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderSingleRequest) ProtoMessage() {}
+
+func (x *RenderSingleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_templr_v1_render_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderSingleRequest.ProtoReflect.Descriptor instead.
+func (*RenderSingleRequest) Descriptor() ([]byte, []int) {
+	return file_templr_v1_render_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RenderSingleRequest) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *RenderSingleRequest) GetOptions() *RenderOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type RenderSingleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Output        string                 `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
+	Warnings      []string               `protobuf:"bytes,2,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenderSingleResponse) Reset() {
+	*x = RenderSingleResponse{}
+	mi := &file_templr_v1_render_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenderSingleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderSingleResponse) ProtoMessage() {}
+
+func (x *RenderSingleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_templr_v1_render_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderSingleResponse.ProtoReflect.Descriptor instead.
+func (*RenderSingleResponse) Descriptor() ([]byte, []int) {
+	return file_templr_v1_render_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RenderSingleResponse) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *RenderSingleResponse) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+type RenderTreeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Templates by path; names starting with "_" are partials and render
+	// nothing, and outputs are keyed by path with ".tpl" removed.
+	Templates     map[string]string `protobuf:"bytes,1,rep,name=templates,proto3" json:"templates,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Options       *RenderOptions    `protobuf:"bytes,2,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenderTreeRequest) Reset() {
+	*x = RenderTreeRequest{}
+	mi := &file_templr_v1_render_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenderTreeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderTreeRequest) ProtoMessage() {}
+
+func (x *RenderTreeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_templr_v1_render_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderTreeRequest.ProtoReflect.Descriptor instead.
+func (*RenderTreeRequest) Descriptor() ([]byte, []int) {
+	return file_templr_v1_render_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RenderTreeRequest) GetTemplates() map[string]string {
+	if x != nil {
+		return x.Templates
+	}
+	return nil
+}
+
+func (x *RenderTreeRequest) GetOptions() *RenderOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type RenderTreeResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Outputs by path; templates rendering only whitespace are omitted.
+	Outputs       map[string]string `protobuf:"bytes,1,rep,name=outputs,proto3" json:"outputs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Warnings      []string          `protobuf:"bytes,2,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenderTreeResponse) Reset() {
+	*x = RenderTreeResponse{}
+	mi := &file_templr_v1_render_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenderTreeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderTreeResponse) ProtoMessage() {}
+
+func (x *RenderTreeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_templr_v1_render_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderTreeResponse.ProtoReflect.Descriptor instead.
+func (*RenderTreeResponse) Descriptor() ([]byte, []int) {
+	return file_templr_v1_render_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RenderTreeResponse) GetOutputs() map[string]string {
+	if x != nil {
+		return x.Outputs
+	}
+	return nil
+}
+
+func (x *RenderTreeResponse) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+type LintRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Template string                 `protobuf:"bytes,1,opt,name=template,proto3" json:"template,omitempty"`
+	// Values as a YAML document; references missing from them are reported
+	// only when set.
+	Values        string `protobuf:"bytes,2,opt,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LintRequest) Reset() {
+	*x = LintRequest{}
+	mi := &file_templr_v1_render_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LintRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LintRequest) ProtoMessage() {}
+
+func (x *LintRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_templr_v1_render_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LintRequest.ProtoReflect.Descriptor instead.
+func (*LintRequest) Descriptor() ([]byte, []int) {
+	return file_templr_v1_render_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *LintRequest) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *LintRequest) GetValues() string {
+	if x != nil {
+		return x.Values
+	}
+	return ""
+}
+
+type LintIssue struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Rule  string                 `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	// "error" or "warn".
+	Severity      string `protobuf:"bytes,2,opt,name=severity,proto3" json:"severity,omitempty"`
+	Line          int32  `protobuf:"varint,3,opt,name=line,proto3" json:"line,omitempty"`
+	Message       string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LintIssue) Reset() {
+	*x = LintIssue{}
+	mi := &file_templr_v1_render_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LintIssue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LintIssue) ProtoMessage() {}
+
+func (x *LintIssue) ProtoReflect() protoreflect.Message {
+	mi := &file_templr_v1_render_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LintIssue.ProtoReflect.Descriptor instead.
+func (*LintIssue) Descriptor() ([]byte, []int) {
+	return file_templr_v1_render_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LintIssue) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *LintIssue) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *LintIssue) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *LintIssue) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type LintResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Issues        []*LintIssue           `protobuf:"bytes,1,rep,name=issues,proto3" json:"issues,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LintResponse) Reset() {
+	*x = LintResponse{}
+	mi := &file_templr_v1_render_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LintResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LintResponse) ProtoMessage() {}
+
+func (x *LintResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_templr_v1_render_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LintResponse.ProtoReflect.Descriptor instead.
+func (*LintResponse) Descriptor() ([]byte, []int) {
+	return file_templr_v1_render_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LintResponse) GetIssues() []*LintIssue {
+	if x != nil {
+		return x.Issues
+	}
+	return nil
+}
+
+type ValidateSchemaRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// JSON Schema as a YAML or JSON document.
+	Schema string `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	// Values as a YAML or JSON document.
+	Values        string `protobuf:"bytes,2,opt,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateSchemaRequest) Reset() {
+	*x = ValidateSchemaRequest{}
+	mi := &file_templr_v1_render_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateSchemaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateSchemaRequest) ProtoMessage() {}
+
+func (x *ValidateSchemaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_templr_v1_render_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateSchemaRequest.ProtoReflect.Descriptor instead.
+func (*ValidateSchemaRequest) Descriptor() ([]byte, []int) {
+	return file_templr_v1_render_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ValidateSchemaRequest) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *ValidateSchemaRequest) GetValues() string {
+	if x != nil {
+		return x.Values
+	}
+	return ""
+}
+
+type SchemaError struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path of the value, such as ".service.replicas".
+	Path          string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Suggestion    string `protobuf:"bytes,3,opt,name=suggestion,proto3" json:"suggestion,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SchemaError) Reset() {
+	*x = SchemaError{}
+	mi := &file_templr_v1_render_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SchemaError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SchemaError) ProtoMessage() {}
+
+func (x *SchemaError) ProtoReflect() protoreflect.Message {
+	mi := &file_templr_v1_render_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SchemaError.ProtoReflect.Descriptor instead.
+func (*SchemaError) Descriptor() ([]byte, []int) {
+	return file_templr_v1_render_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SchemaError) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SchemaError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *SchemaError) GetSuggestion() string {
+	if x != nil {
+		return x.Suggestion
+	}
+	return ""
+}
+
+type ValidateSchemaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Valid         bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	Errors        []*SchemaError         `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateSchemaResponse) Reset() {
+	*x = ValidateSchemaResponse{}
+	mi := &file_templr_v1_render_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateSchemaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateSchemaResponse) ProtoMessage() {}
+
+func (x *ValidateSchemaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_templr_v1_render_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateSchemaResponse.ProtoReflect.Descriptor instead.
+func (*ValidateSchemaResponse) Descriptor() ([]byte, []int) {
+	return file_templr_v1_render_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ValidateSchemaResponse) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+func (x *ValidateSchemaResponse) GetErrors() []*SchemaError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+var File_templr_v1_render_proto protoreflect.FileDescriptor
+
+const file_templr_v1_render_proto_rawDesc = "" +
+	"\n" +
+	"\x16templr/v1/render.proto\x12\ttemplr.v1\"\xbd\x02\n" +
+	"\rRenderOptions\x12\x16\n" +
+	"\x06values\x18\x01 \x01(\tR\x06values\x12\x18\n" +
+	"\ahelpers\x18\x02 \x01(\tR\ahelpers\x129\n" +
+	"\x05files\x18\x03 \x03(\v2#.templr.v1.RenderOptions.FilesEntryR\x05files\x12\x16\n" +
+	"\x06strict\x18\x04 \x01(\bR\x06strict\x12'\n" +
+	"\x0fdefault_missing\x18\x05 \x01(\tR\x0edefaultMissing\x12!\n" +
+	"\finject_guard\x18\x06 \x01(\bR\vinjectGuard\x12!\n" +
+	"\fguard_marker\x18\a \x01(\tR\vguardMarker\x1a8\n" +
+	"\n" +
+	"FilesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"e\n" +
+	"\x13RenderSingleRequest\x12\x1a\n" +
+	"\btemplate\x18\x01 \x01(\tR\btemplate\x122\n" +
+	"\aoptions\x18\x02 \x01(\v2\x18.templr.v1.RenderOptionsR\aoptions\"J\n" +
+	"\x14RenderSingleResponse\x12\x16\n" +
+	"\x06output\x18\x01 \x01(\tR\x06output\x12\x1a\n" +
+	"\bwarnings\x18\x02 \x03(\tR\bwarnings\"\xd0\x01\n" +
+	"\x11RenderTreeRequest\x12I\n" +
+	"\ttemplates\x18\x01 \x03(\v2+.templr.v1.RenderTreeRequest.TemplatesEntryR\ttemplates\x122\n" +
+	"\aoptions\x18\x02 \x01(\v2\x18.templr.v1.RenderOptionsR\aoptions\x1a<\n" +
+	"\x0eTemplatesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb2\x01\n" +
+	"\x12RenderTreeResponse\x12D\n" +
+	"\aoutputs\x18\x01 \x03(\v2*.templr.v1.RenderTreeResponse.OutputsEntryR\aoutputs\x12\x1a\n" +
+	"\bwarnings\x18\x02 \x03(\tR\bwarnings\x1a:\n" +
+	"\fOutputsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"A\n" +
+	"\vLintRequest\x12\x1a\n" +
+	"\btemplate\x18\x01 \x01(\tR\btemplate\x12\x16\n" +
+	"\x06values\x18\x02 \x01(\tR\x06values\"i\n" +
+	"\tLintIssue\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12\x1a\n" +
+	"\bseverity\x18\x02 \x01(\tR\bseverity\x12\x12\n" +
+	"\x04line\x18\x03 \x01(\x05R\x04line\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\"<\n" +
+	"\fLintResponse\x12,\n" +
+	"\x06issues\x18\x01 \x03(\v2\x14.templr.v1.LintIssueR\x06issues\"G\n" +
+	"\x15ValidateSchemaRequest\x12\x16\n" +
+	"\x06schema\x18\x01 \x01(\tR\x06schema\x12\x16\n" +
+	"\x06values\x18\x02 \x01(\tR\x06values\"[\n" +
+	"\vSchemaError\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"suggestion\x18\x03 \x01(\tR\n" +
+	"suggestion\"^\n" +
+	"\x16ValidateSchemaResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12.\n" +
+	"\x06errors\x18\x02 \x03(\v2\x16.templr.v1.SchemaErrorR\x06errors2\xbb\x02\n" +
+	"\rRenderService\x12O\n" +
+	"\fRenderSingle\x12\x1e.templr.v1.RenderSingleRequest\x1a\x1f.templr.v1.RenderSingleResponse\x12I\n" +
+	"\n" +
+	"RenderTree\x12\x1c.templr.v1.RenderTreeRequest\x1a\x1d.templr.v1.RenderTreeResponse\x127\n" +
+	"\x04Lint\x12\x16.templr.v1.LintRequest\x1a\x17.templr.v1.LintResponse\x12U\n" +
+	"\x0eValidateSchema\x12 .templr.v1.ValidateSchemaRequest\x1a!.templr.v1.ValidateSchemaResponseB4Z2github.com/kanopi/templr/pkg/rpc/templrv1;templrv1b\x06proto3"
+
+var (
+	file_templr_v1_render_proto_rawDescOnce sync.Once
+	file_templr_v1_render_proto_rawDescData []byte
+)
+
+func file_templr_v1_render_proto_rawDescGZIP() []byte {
+	file_templr_v1_render_proto_rawDescOnce.Do(func() {
+		file_templr_v1_render_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_templr_v1_render_proto_rawDesc), len(file_templr_v1_render_proto_rawDesc)))
+	})
+	return file_templr_v1_render_proto_rawDescData
+}
+
+var file_templr_v1_render_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_templr_v1_render_proto_goTypes = []any{
+	(*RenderOptions)(nil),          // 0: templr.v1.RenderOptions
+	(*RenderSingleRequest)(nil),    // 1: templr.v1.RenderSingleRequest
+	(*RenderSingleResponse)(nil),   // 2: templr.v1.RenderSingleResponse
+	(*RenderTreeRequest)(nil),      // 3: templr.v1.RenderTreeRequest
+	(*RenderTreeResponse)(nil),     // 4: templr.v1.RenderTreeResponse
+	(*LintRequest)(nil),            // 5: templr.v1.LintRequest
+	(*LintIssue)(nil),              // 6: templr.v1.LintIssue
+	(*LintResponse)(nil),           // 7: templr.v1.LintResponse
+	(*ValidateSchemaRequest)(nil),  // 8: templr.v1.ValidateSchemaRequest
+	(*SchemaError)(nil),            // 9: templr.v1.SchemaError
+	(*ValidateSchemaResponse)(nil), // 10: templr.v1.ValidateSchemaResponse
+	nil,                            // 11: templr.v1.RenderOptions.FilesEntry
+	nil,                            // 12: templr.v1.RenderTreeRequest.TemplatesEntry
+	nil,                            // 13: templr.v1.RenderTreeResponse.OutputsEntry
+}
+var file_templr_v1_render_proto_depIdxs = []int32{
+	11, // 0: templr.v1.RenderOptions.files:type_name -> templr.v1.RenderOptions.FilesEntry
+	0,  // 1: templr.v1.RenderSingleRequest.options:type_name -> templr.v1.RenderOptions
+	12, // 2: templr.v1.RenderTreeRequest.templates:type_name -> templr.v1.RenderTreeRequest.TemplatesEntry
+	0,  // 3: templr.v1.RenderTreeRequest.options:type_name -> templr.v1.RenderOptions
+	13, // 4: templr.v1.RenderTreeResponse.outputs:type_name -> templr.v1.RenderTreeResponse.OutputsEntry
+	6,  // 5: templr.v1.LintResponse.issues:type_name -> templr.v1.LintIssue
+	9,  // 6: templr.v1.ValidateSchemaResponse.errors:type_name -> templr.v1.SchemaError
+	1,  // 7: templr.v1.RenderService.RenderSingle:input_type -> templr.v1.RenderSingleRequest
+	3,  // 8: templr.v1.RenderService.RenderTree:input_type -> templr.v1.RenderTreeRequest
+	5,  // 9: templr.v1.RenderService.Lint:input_type -> templr.v1.LintRequest
+	8,  // 10: templr.v1.RenderService.ValidateSchema:input_type -> templr.v1.ValidateSchemaRequest
+	2,  // 11: templr.v1.RenderService.RenderSingle:output_type -> templr.v1.RenderSingleResponse
+	4,  // 12: templr.v1.RenderService.RenderTree:output_type -> templr.v1.RenderTreeResponse
+	7,  // 13: templr.v1.RenderService.Lint:output_type -> templr.v1.LintResponse
+	10, // 14: templr.v1.RenderService.ValidateSchema:output_type -> templr.v1.ValidateSchemaResponse
+	11, // [11:15] is the sub-list for method output_type
+	7,  // [7:11] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_templr_v1_render_proto_init() }
+func file_templr_v1_render_proto_init() {
+	if File_templr_v1_render_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_templr_v1_render_proto_rawDesc), len(file_templr_v1_render_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_templr_v1_render_proto_goTypes,
+		DependencyIndexes: file_templr_v1_render_proto_depIdxs,
+		MessageInfos:      file_templr_v1_render_proto_msgTypes,
+	}.Build()
+	File_templr_v1_render_proto = out.File
+	file_templr_v1_render_proto_goTypes = nil
+	file_templr_v1_render_proto_depIdxs = nil
+}
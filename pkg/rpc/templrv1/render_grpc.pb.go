@@ -0,0 +1,253 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: templr/v1/render.proto
+
+// Package templr.v1 is the gRPC API of `templr serve --grpc`: rendering,
+// linting and schema validation of sources sent in the request, with no
+// access to the server's filesystem.
+
+package templrv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RenderService_RenderSingle_FullMethodName   = "/templr.v1.RenderService/RenderSingle"
+	RenderService_RenderTree_FullMethodName     = "/templr.v1.RenderService/RenderTree"
+	RenderService_Lint_FullMethodName           = "/templr.v1.RenderService/Lint"
+	RenderService_ValidateSchema_FullMethodName = "/templr.v1.RenderService/ValidateSchema"
+)
+
+// RenderServiceClient is the client API for RenderService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RenderServiceClient interface {
+	// RenderSingle renders one template.
+	RenderSingle(ctx context.Context, in *RenderSingleRequest, opts ...grpc.CallOption) (*RenderSingleResponse, error)
+	// RenderTree renders a set of templates sharing their defines, as walk
+	// mode does with a source tree.
+	RenderTree(ctx context.Context, in *RenderTreeRequest, opts ...grpc.CallOption) (*RenderTreeResponse, error)
+	// Lint checks a template for parse errors, references missing from the
+	// values and the registered rules.
+	Lint(ctx context.Context, in *LintRequest, opts ...grpc.CallOption) (*LintResponse, error)
+	// ValidateSchema validates values against a JSON Schema written in YAML
+	// or JSON, as --schema does.
+	ValidateSchema(ctx context.Context, in *ValidateSchemaRequest, opts ...grpc.CallOption) (*ValidateSchemaResponse, error)
+}
+
+type renderServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRenderServiceClient(cc grpc.ClientConnInterface) RenderServiceClient {
+	return &renderServiceClient{cc}
+}
+
+func (c *renderServiceClient) RenderSingle(ctx context.Context, in *RenderSingleRequest, opts ...grpc.CallOption) (*RenderSingleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenderSingleResponse)
+	err := c.cc.Invoke(ctx, RenderService_RenderSingle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *renderServiceClient) RenderTree(ctx context.Context, in *RenderTreeRequest, opts ...grpc.CallOption) (*RenderTreeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenderTreeResponse)
+	err := c.cc.Invoke(ctx, RenderService_RenderTree_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *renderServiceClient) Lint(ctx context.Context, in *LintRequest, opts ...grpc.CallOption) (*LintResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LintResponse)
+	err := c.cc.Invoke(ctx, RenderService_Lint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *renderServiceClient) ValidateSchema(ctx context.Context, in *ValidateSchemaRequest, opts ...grpc.CallOption) (*ValidateSchemaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateSchemaResponse)
+	err := c.cc.Invoke(ctx, RenderService_ValidateSchema_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RenderServiceServer is the server API for RenderService service.
+// All implementations must embed UnimplementedRenderServiceServer
+// for forward compatibility.
+type RenderServiceServer interface {
+	// RenderSingle renders one template.
+	RenderSingle(context.Context, *RenderSingleRequest) (*RenderSingleResponse, error)
+	// RenderTree renders a set of templates sharing their defines, as walk
+	// mode does with a source tree.
+	RenderTree(context.Context, *RenderTreeRequest) (*RenderTreeResponse, error)
+	// Lint checks a template for parse errors, references missing from the
+	// values and the registered rules.
+	Lint(context.Context, *LintRequest) (*LintResponse, error)
+	// ValidateSchema validates values against a JSON Schema written in YAML
+	// or JSON, as --schema does.
+	ValidateSchema(context.Context, *ValidateSchemaRequest) (*ValidateSchemaResponse, error)
+	mustEmbedUnimplementedRenderServiceServer()
+}
+
+// UnimplementedRenderServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRenderServiceServer struct{}
+
+func (UnimplementedRenderServiceServer) RenderSingle(context.Context, *RenderSingleRequest) (*RenderSingleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenderSingle not implemented")
+}
+func (UnimplementedRenderServiceServer) RenderTree(context.Context, *RenderTreeRequest) (*RenderTreeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenderTree not implemented")
+}
+func (UnimplementedRenderServiceServer) Lint(context.Context, *LintRequest) (*LintResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Lint not implemented")
+}
+func (UnimplementedRenderServiceServer) ValidateSchema(context.Context, *ValidateSchemaRequest) (*ValidateSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateSchema not implemented")
+}
+func (UnimplementedRenderServiceServer) mustEmbedUnimplementedRenderServiceServer() {}
+func (UnimplementedRenderServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeRenderServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RenderServiceServer will
+// result in compilation errors.
+type UnsafeRenderServiceServer interface {
+	mustEmbedUnimplementedRenderServiceServer()
+}
+
+func RegisterRenderServiceServer(s grpc.ServiceRegistrar, srv RenderServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRenderServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RenderService_ServiceDesc, srv)
+}
+
+func _RenderService_RenderSingle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenderSingleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RenderServiceServer).RenderSingle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RenderService_RenderSingle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RenderServiceServer).RenderSingle(ctx, req.(*RenderSingleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RenderService_RenderTree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenderTreeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RenderServiceServer).RenderTree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RenderService_RenderTree_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RenderServiceServer).RenderTree(ctx, req.(*RenderTreeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RenderService_Lint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LintRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RenderServiceServer).Lint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RenderService_Lint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RenderServiceServer).Lint(ctx, req.(*LintRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RenderService_ValidateSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RenderServiceServer).ValidateSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RenderService_ValidateSchema_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RenderServiceServer).ValidateSchema(ctx, req.(*ValidateSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RenderService_ServiceDesc is the grpc.ServiceDesc for RenderService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RenderService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "templr.v1.RenderService",
+	HandlerType: (*RenderServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RenderSingle",
+			Handler:    _RenderService_RenderSingle_Handler,
+		},
+		{
+			MethodName: "RenderTree",
+			Handler:    _RenderService_RenderTree_Handler,
+		},
+		{
+			MethodName: "Lint",
+			Handler:    _RenderService_Lint_Handler,
+		},
+		{
+			MethodName: "ValidateSchema",
+			Handler:    _RenderService_ValidateSchema_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "templr/v1/render.proto",
+}
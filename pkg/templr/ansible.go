@@ -0,0 +1,488 @@
+package templr
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ansibleInventory is an Ansible inventory being read: groups with their
+// direct hosts, child groups and variables, and per-host variables.
+type ansibleInventory struct {
+	groups    map[string]*ansibleGroup
+	hostVars  map[string]map[string]any
+	hostOrder []string
+}
+
+type ansibleGroup struct {
+	hosts    []string
+	children []string
+	vars     map[string]any
+}
+
+func newAnsibleInventory() *ansibleInventory {
+	inv := &ansibleInventory{groups: map[string]*ansibleGroup{}, hostVars: map[string]map[string]any{}}
+	inv.group("all")
+	inv.group("ungrouped")
+	return inv
+}
+
+func (inv *ansibleInventory) group(name string) *ansibleGroup {
+	g, ok := inv.groups[name]
+	if !ok {
+		g = &ansibleGroup{vars: map[string]any{}}
+		inv.groups[name] = g
+	}
+	return g
+}
+
+// addHost adds host to group, merging vars over the ones it already has.
+func (inv *ansibleInventory) addHost(group, host string, vars map[string]any) {
+	g := inv.group(group)
+	if !containsString(g.hosts, host) {
+		g.hosts = append(g.hosts, host)
+	}
+	hv, ok := inv.hostVars[host]
+	if !ok {
+		hv = map[string]any{}
+		inv.hostVars[host] = hv
+		inv.hostOrder = append(inv.hostOrder, host)
+	}
+	for k, v := range vars {
+		hv[k] = v
+	}
+}
+
+func (inv *ansibleInventory) addChild(parent, child string) {
+	inv.group(child)
+	g := inv.group(parent)
+	if !containsString(g.children, child) {
+		g.children = append(g.children, child)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAnsibleInventory parses an Ansible inventory in the INI or YAML form.
+//
+// The result has three maps:
+//   - "hosts": host name -> {"name", "groups" (every group the host is in,
+//     parents included), "vars" (the effective variables: all, then groups
+//     from the least to the most specific, then the host's own)}
+//   - "groups": group name -> {"hosts" (direct and through children),
+//     "children", "vars"}
+//   - "vars": the variables of the "all" group
+func ParseAnsibleInventory(src string) (map[string]any, error) {
+	inv := newAnsibleInventory()
+	var doc map[string]any
+	if err := yaml.Unmarshal([]byte(src), &doc); err == nil && isAnsibleYAML(doc) {
+		for name, body := range doc {
+			if err := inv.readYAMLGroup(name, body); err != nil {
+				return nil, err
+			}
+		}
+	} else if err := inv.readINI(src); err != nil {
+		return nil, err
+	}
+	return inv.result()
+}
+
+// isAnsibleYAML reports whether a YAML document looks like an inventory:
+// a map of groups, each a map (or empty).
+func isAnsibleYAML(doc map[string]any) bool {
+	if len(doc) == 0 {
+		return false
+	}
+	for _, v := range doc {
+		if _, ok := v.(map[string]any); !ok && v != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (inv *ansibleInventory) readYAMLGroup(name string, body any) error {
+	inv.group(name)
+	if body == nil {
+		return nil
+	}
+	m, ok := body.(map[string]any)
+	if !ok {
+		return fmt.Errorf("inventory group %s: want a map, got %T", name, body)
+	}
+	for key, v := range m {
+		switch key {
+		case "hosts":
+			hosts, ok := v.(map[string]any)
+			if !ok && v != nil {
+				return fmt.Errorf("inventory group %s: hosts must be a map", name)
+			}
+			for pattern, hv := range hosts {
+				vars, ok := hv.(map[string]any)
+				if !ok && hv != nil {
+					return fmt.Errorf("inventory host %s: vars must be a map", pattern)
+				}
+				names, err := expandHostPattern(pattern)
+				if err != nil {
+					return err
+				}
+				for _, h := range names {
+					inv.addHost(name, h, vars)
+				}
+			}
+		case "vars":
+			vars, ok := v.(map[string]any)
+			if !ok && v != nil {
+				return fmt.Errorf("inventory group %s: vars must be a map", name)
+			}
+			for k, val := range vars {
+				inv.group(name).vars[k] = val
+			}
+		case "children":
+			children, ok := v.(map[string]any)
+			if !ok && v != nil {
+				return fmt.Errorf("inventory group %s: children must be a map", name)
+			}
+			for child, cb := range children {
+				inv.addChild(name, child)
+				if err := inv.readYAMLGroup(child, cb); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("inventory group %s: unknown key %q (want hosts, vars or children)", name, key)
+		}
+	}
+	return nil
+}
+
+// readINI reads the INI form: host lines, [group], [group:vars] and
+// [group:children] sections. Host line values are typed like YAML scalars
+// (numbers, booleans); values in :vars sections stay strings, as in Ansible.
+func (inv *ansibleInventory) readINI(src string) error {
+	group, kind := "ungrouped", "hosts"
+	for i, raw := range strings.Split(src, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return fmt.Errorf("inventory line %d: invalid section %s", i+1, line)
+			}
+			name := line[1 : len(line)-1]
+			group, kind = name, "hosts"
+			if g, k, ok := strings.Cut(name, ":"); ok {
+				group, kind = g, k
+			}
+			if kind != "hosts" && kind != "vars" && kind != "children" {
+				return fmt.Errorf("inventory line %d: unknown section type %q", i+1, kind)
+			}
+			inv.group(group)
+			continue
+		}
+		switch kind {
+		case "hosts":
+			fields, err := splitInventoryLine(line)
+			if err != nil {
+				return fmt.Errorf("inventory line %d: %w", i+1, err)
+			}
+			vars := map[string]any{}
+			for _, f := range fields[1:] {
+				k, v, ok := strings.Cut(f, "=")
+				if !ok {
+					return fmt.Errorf("inventory line %d: %q is not key=value", i+1, f)
+				}
+				vars[k] = inventoryScalar(v)
+			}
+			names, err := expandHostPattern(fields[0])
+			if err != nil {
+				return fmt.Errorf("inventory line %d: %w", i+1, err)
+			}
+			for _, h := range names {
+				inv.addHost(group, h, vars)
+			}
+		case "vars":
+			k, v, ok := strings.Cut(line, "=")
+			if !ok {
+				return fmt.Errorf("inventory line %d: %q is not key=value", i+1, line)
+			}
+			inv.group(group).vars[strings.TrimSpace(k)] = unquoteInventory(strings.TrimSpace(v))
+		case "children":
+			inv.addChild(group, line)
+		}
+	}
+	return nil
+}
+
+// splitInventoryLine splits a host line on blanks, keeping quoted values
+// (key="a b") together and dropping the quotes and a trailing comment.
+func splitInventoryLine(line string) ([]string, error) {
+	var fields []string
+	var cur strings.Builder
+	var quote byte
+	inField := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				cur.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote, inField = c, true
+		case c == ' ' || c == '\t':
+			if inField {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				inField = false
+			}
+		case c == '#' && !inField:
+			i = len(line)
+		default:
+			cur.WriteByte(c)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inField {
+		fields = append(fields, cur.String())
+	}
+	return fields, nil
+}
+
+func unquoteInventory(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// inventoryScalar types a host line value: integers, floats, true/false
+// (any case) and None; anything else is a string.
+func inventoryScalar(v string) any {
+	if n, err := strconv.Atoi(v); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil && strings.ContainsAny(v, ".eE") {
+		return f
+	}
+	switch strings.ToLower(v) {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "none":
+		return nil
+	}
+	return v
+}
+
+// expandHostPattern expands the ranges of an inventory host pattern:
+// "web[01:03].example.com" gives web01, web02 and web03; "db-[a:c]" gives
+// db-a, db-b and db-c; "[1:9:2]" steps by 2.
+func expandHostPattern(pattern string) ([]string, error) {
+	open := strings.IndexByte(pattern, '[')
+	if open < 0 {
+		return []string{pattern}, nil
+	}
+	end := strings.IndexByte(pattern[open:], ']')
+	if end < 0 {
+		return nil, fmt.Errorf("host pattern %s: missing ]", pattern)
+	}
+	end += open
+	parts := strings.Split(pattern[open+1:end], ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("host pattern %s: want [start:end] or [start:end:step]", pattern)
+	}
+	step := 1
+	if len(parts) == 3 {
+		s, err := strconv.Atoi(parts[2])
+		if err != nil || s <= 0 {
+			return nil, fmt.Errorf("host pattern %s: invalid step %q", pattern, parts[2])
+		}
+		step = s
+	}
+	rest, err := expandHostPattern(pattern[end+1:])
+	if err != nil {
+		return nil, err
+	}
+	var items []string
+	lo, hi := parts[0], parts[1]
+	if a, errA := strconv.Atoi(lo); errA == nil {
+		b, errB := strconv.Atoi(hi)
+		if errB != nil || b < a {
+			return nil, fmt.Errorf("host pattern %s: invalid range [%s:%s]", pattern, lo, hi)
+		}
+		width := 0
+		if len(lo) > 1 && lo[0] == '0' {
+			width = len(lo)
+		}
+		for n := a; n <= b; n += step {
+			items = append(items, fmt.Sprintf("%0*d", width, n))
+		}
+	} else {
+		if len(lo) != 1 || len(hi) != 1 || lo[0] > hi[0] {
+			return nil, fmt.Errorf("host pattern %s: invalid range [%s:%s]", pattern, lo, hi)
+		}
+		for c := int(lo[0]); c <= int(hi[0]); c += step {
+			items = append(items, string(rune(c)))
+		}
+	}
+	var out []string
+	for _, item := range items {
+		for _, r := range rest {
+			out = append(out, pattern[:open]+item+r)
+		}
+	}
+	return out, nil
+}
+
+// result builds the returned maps; see ParseAnsibleInventory.
+func (inv *ansibleInventory) result() (map[string]any, error) {
+	// every group is under "all"; hosts in no other group are "ungrouped"
+	parents := map[string][]string{}
+	for name, g := range inv.groups {
+		for _, c := range g.children {
+			parents[c] = append(parents[c], name)
+		}
+	}
+	for name := range inv.groups {
+		if name != "all" && len(parents[name]) == 0 {
+			inv.addChild("all", name)
+			parents[name] = []string{"all"}
+		}
+	}
+
+	depth := map[string]int{}
+	var depthOf func(name string, seen map[string]bool) (int, error)
+	depthOf = func(name string, seen map[string]bool) (int, error) {
+		if d, ok := depth[name]; ok {
+			return d, nil
+		}
+		if seen[name] {
+			return 0, fmt.Errorf("inventory: group %s is its own ancestor", name)
+		}
+		seen[name] = true
+		d := 0
+		for _, p := range parents[name] {
+			pd, err := depthOf(p, seen)
+			if err != nil {
+				return 0, err
+			}
+			d = max(d, pd+1)
+		}
+		depth[name] = d
+		return d, nil
+	}
+	for name := range inv.groups {
+		if _, err := depthOf(name, map[string]bool{}); err != nil {
+			return nil, err
+		}
+	}
+
+	// hosts in no group but all and ungrouped are ungrouped, as in Ansible
+	grouped := map[string]bool{}
+	for name, g := range inv.groups {
+		if name != "all" && name != "ungrouped" {
+			for _, h := range g.hosts {
+				grouped[h] = true
+			}
+		}
+	}
+	ungrouped := inv.groups["ungrouped"]
+	ungrouped.hosts = nil
+	for _, h := range inv.hostOrder {
+		if !grouped[h] {
+			ungrouped.hosts = append(ungrouped.hosts, h)
+		}
+	}
+
+	// groups of each host, with their ancestors
+	hostGroups := map[string]map[string]bool{}
+	for _, h := range inv.hostOrder {
+		hostGroups[h] = map[string]bool{}
+	}
+	var addAncestors func(host, group string)
+	addAncestors = func(host, group string) {
+		if hostGroups[host][group] {
+			return
+		}
+		hostGroups[host][group] = true
+		for _, p := range parents[group] {
+			addAncestors(host, p)
+		}
+	}
+	for name, g := range inv.groups {
+		for _, h := range g.hosts {
+			addAncestors(h, name)
+		}
+	}
+
+	hosts := map[string]any{}
+	for _, h := range inv.hostOrder {
+		names := make([]string, 0, len(hostGroups[h]))
+		for g := range hostGroups[h] {
+			names = append(names, g)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if depth[names[i]] != depth[names[j]] {
+				return depth[names[i]] < depth[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		vars := map[string]any{}
+		for _, g := range names {
+			for k, v := range inv.groups[g].vars {
+				vars[k] = v
+			}
+		}
+		for k, v := range inv.hostVars[h] {
+			vars[k] = v
+		}
+		groupList := make([]any, 0, len(names))
+		for _, g := range names {
+			if g != "all" {
+				groupList = append(groupList, g)
+			}
+		}
+		hosts[h] = map[string]any{"name": h, "groups": groupList, "vars": vars}
+	}
+
+	groups := map[string]any{}
+	for name, g := range inv.groups {
+		members := []string{}
+		for _, h := range inv.hostOrder {
+			if hostGroups[h][name] {
+				members = append(members, h)
+			}
+		}
+		sort.Strings(members)
+		children := append([]string(nil), g.children...)
+		sort.Strings(children)
+		groups[name] = map[string]any{"hosts": toAnyList(members), "children": toAnyList(children), "vars": g.vars}
+	}
+	return map[string]any{"hosts": hosts, "groups": groups, "vars": inv.groups["all"].vars}, nil
+}
+
+func toAnyList(ss []string) []any {
+	out := make([]any, len(ss))
+	for i, s := range ss {
+		out[i] = s
+	}
+	return out
+}
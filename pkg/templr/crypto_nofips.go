@@ -0,0 +1,7 @@
+//go:build !fips
+
+package templr
+
+// FIPSBuild reports whether templr was built with the fips tag, which
+// enforces the fips crypto policy regardless of configuration.
+const FIPSBuild = false
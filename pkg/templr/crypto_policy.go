@@ -0,0 +1,72 @@
+package templr
+
+import (
+	"fmt"
+	"text/template"
+)
+
+// Crypto policies accepted by FuncMapOptions.CryptoPolicy.
+const (
+	CryptoPolicyDefault = "default"
+	CryptoPolicyFIPS    = "fips"
+)
+
+// nonApprovedCrypto lists the crypto helpers the fips policy rejects, with
+// the reason reported to the user.
+var nonApprovedCrypto = map[string]string{
+	"adler32sum":     "Adler-32 is a checksum, not an approved hash; use sha256sum",
+	"bcrypt":         "bcrypt is not an approved password hash, and Sprig runs it at a fixed cost of 10",
+	"derivePassword": "derivePassword is built on scrypt, which is not an approved key derivation function",
+	"htpasswd":       "htpasswd hashes with bcrypt, which is not an approved password hash",
+	"sha1sum":        "SHA-1 is not approved for new digests; use sha256sum or sha512sum",
+}
+
+// dsaNotApproved is the reason genPrivateKey "dsa" is rejected.
+const dsaNotApproved = "DSA signatures are no longer approved (FIPS 186-5); use rsa or ecdsa"
+
+// NonApprovedCryptoFuncs returns the crypto helpers rejected by the fips
+// policy, mapped to the reason. genPrivateKey is not listed: only
+// its "dsa" key type is rejected (see NonApprovedKeyType).
+func NonApprovedCryptoFuncs() map[string]string {
+	out := make(map[string]string, len(nonApprovedCrypto))
+	for name, reason := range nonApprovedCrypto {
+		out[name] = reason
+	}
+	return out
+}
+
+// NonApprovedKeyType returns why the fips policy rejects genPrivateKey with
+// the key type typ, or "" when it is allowed.
+func NonApprovedKeyType(typ string) string {
+	if typ == "dsa" {
+		return dsaNotApproved
+	}
+	return ""
+}
+
+// CryptoPolicyEnforced reports whether policy (or the fips build tag)
+// restricts the crypto helpers.
+func CryptoPolicyEnforced(policy string) bool {
+	return FIPSBuild || policy == CryptoPolicyFIPS
+}
+
+// applyCryptoPolicy replaces the non-approved helpers with functions that
+// fail with the reason, so a template that still calls one stops with a
+// clear error instead of "function not defined".
+func applyCryptoPolicy(funcs template.FuncMap) {
+	for name, reason := range nonApprovedCrypto {
+		if _, ok := funcs[name]; !ok {
+			continue
+		}
+		err := fmt.Errorf("%s is not allowed by the fips crypto policy: %s", name, reason)
+		funcs[name] = func(...any) (string, error) { return "", err }
+	}
+	if gen, ok := funcs["genPrivateKey"].(func(string) string); ok {
+		funcs["genPrivateKey"] = func(typ string) (string, error) {
+			if reason := NonApprovedKeyType(typ); reason != "" {
+				return "", fmt.Errorf("genPrivateKey %q is not allowed by the fips crypto policy: %s", typ, reason)
+			}
+			return gen(typ), nil
+		}
+	}
+}
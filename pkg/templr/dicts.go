@@ -0,0 +1,126 @@
+package templr
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// toPairs returns the entries of m as a list of key/value dicts sorted by
+// key, e.g. [{key: a, value: 1}], for range to iterate in order and
+// fromPairs to turn back into a dict.
+func toPairs(m map[string]any) []any {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]any, len(keys))
+	for i, k := range keys {
+		out[i] = map[string]any{"key": k, "value": m[k]}
+	}
+	return out
+}
+
+// fromPairs builds a dict from a list of pairs, each a two-item list
+// (list "a" 1) or a key/value dict as toPairs returns. A later pair with the
+// same key wins.
+func fromPairs(pairs any) (map[string]any, error) {
+	var list []any
+	switch x := pairs.(type) {
+	case []any:
+		list = x
+	case []map[string]any:
+		for _, p := range x {
+			list = append(list, p)
+		}
+	default:
+		return nil, argError(pairs, "a list of pairs")
+	}
+	out := make(map[string]any, len(list))
+	for i, p := range list {
+		var key, val any
+		switch x := p.(type) {
+		case []any:
+			if len(x) != 2 {
+				return nil, fmt.Errorf("fromPairs: pair %d has %d items, want 2", i, len(x))
+			}
+			key, val = x[0], x[1]
+		case map[string]any:
+			k, ok := x["key"]
+			if !ok {
+				return nil, fmt.Errorf("fromPairs: pair %d has no key", i)
+			}
+			key, val = k, x["value"]
+		default:
+			return nil, fmt.Errorf("fromPairs: pair %d: %w", i, argError(p, "a two-item list or a key/value dict"))
+		}
+		s, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("fromPairs: pair %d: %w", i, argError(key, "a key"))
+		}
+		out[s] = val
+	}
+	return out, nil
+}
+
+// renameKey returns a copy of m with the key from renamed to to, replacing
+// any value at to. m is returned copied but unchanged when it has no from.
+func renameKey(m map[string]any, from, to string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	if v, ok := out[from]; ok && from != to {
+		delete(out, from)
+		out[to] = v
+	}
+	return out
+}
+
+// pickRegex returns a copy of m with only the keys matching the regular
+// expression pattern, like pick with a pattern instead of key names.
+func pickRegex(m map[string]any, pattern string) (map[string]any, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("pickRegex: %w", err)
+	}
+	out := map[string]any{}
+	for k, v := range m {
+		if re.MatchString(k) {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// deepGet returns the value at the dotted path of nested dicts in m, the
+// reverse of setd, or nil when a key of the path is missing.
+func deepGet(m map[string]any, dotted string) any {
+	v, _ := lookupDottedOK(m, dotted)
+	return v
+}
+
+// deepHas reports whether m has a value at the dotted path, even a nil or
+// empty one.
+func deepHas(m map[string]any, dotted string) bool {
+	_, ok := lookupDottedOK(m, dotted)
+	return ok
+}
+
+// lookupDottedOK returns the value at a dotted path of nested maps and
+// whether every key of the path is present.
+func lookupDottedOK(m map[string]any, dotted string) (any, bool) {
+	var cur any = m
+	for _, key := range strings.Split(dotted, ".") {
+		mm, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = mm[key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
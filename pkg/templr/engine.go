@@ -5,10 +5,15 @@ package templr
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"path"
+	"sort"
+	"strings"
 	"text/template"
 
+	"go.opentelemetry.io/otel/attribute"
 	"gopkg.in/yaml.v3"
 )
 
@@ -32,18 +37,28 @@ func (m FilesMap) Get(name string) (string, error) {
 // Options configures a single in-memory template render.
 // Set Template/Helpers to the text to parse; provide ValuesYAML or ValuesJSON
 // for data. Strict toggles missingkey=error. DefaultMissing replaces "<no value>"
-// in the final output. Files can provide a `.Files` API. InjectGuard/GuardMarker
-// optionally prepend a guard header to the output.
+// in the final output. Files can provide a `.Files` API. ExtraFuncs adds
+// domain-specific helpers and DisabledFuncs removes built-in ones.
+// InjectGuard/GuardMarker optionally prepend a guard header to the output.
 type Options struct {
-	Template       string
-	Helpers        string
-	ValuesYAML     string
-	ValuesJSON     string
-	Strict         bool
-	DefaultMissing string
-	Files          FilesAPI
-	FuncMap        template.FuncMap
-	WarnFunc       func(string) // Function to call for warnings
+	Template        string
+	Helpers         string
+	ValuesYAML      string
+	ValuesJSON      string
+	Strict          bool
+	DefaultMissing  string
+	Files           FilesAPI
+	ExtraFuncs      template.FuncMap
+	DisabledFuncs   []string
+	WarnFunc        func(string) // Function to call for warnings
+	IncludeCache    int          // memoize include with up to this many renders
+	IncludeMaxDepth int          // fail include calls nested deeper than this (0: DefaultIncludeMaxDepth)
+	CryptoPolicy    string       // "fips" rejects the non-approved crypto helpers
+	MaxOutputSize   int          // abort a render whose output exceeds this many bytes (0: no limit)
+	Engine          string       // template engine of Template: "" or EngineGo, or EngineJinja
+
+	// Deprecated: use ExtraFuncs. FuncMap is merged before ExtraFuncs.
+	FuncMap template.FuncMap
 
 	InjectGuard bool
 	GuardMarker string
@@ -54,11 +69,23 @@ type Options struct {
 type Result struct{ Output string }
 
 // defaultFuncMapWithOptions creates function map with options (for RenderSingle)
-func defaultFuncMapWithOptions(tpl **template.Template, strict bool, defaultMissing string, warnFunc func(string)) template.FuncMap {
+func defaultFuncMapWithOptions(tpl **template.Template, o Options) template.FuncMap {
+	extra := template.FuncMap{}
+	for k, v := range o.FuncMap {
+		extra[k] = v
+	}
+	for k, v := range o.ExtraFuncs {
+		extra[k] = v
+	}
 	return BuildFuncMapWithOptions(tpl, &FuncMapOptions{
-		Strict:         strict,
-		DefaultMissing: defaultMissing,
-		WarnFunc:       warnFunc,
+		Strict:          o.Strict,
+		DefaultMissing:  o.DefaultMissing,
+		WarnFunc:        o.WarnFunc,
+		ExtraFuncs:      extra,
+		DisabledFuncs:   o.DisabledFuncs,
+		IncludeCache:    o.IncludeCache,
+		IncludeMaxDepth: o.IncludeMaxDepth,
+		CryptoPolicy:    o.CryptoPolicy,
 	})
 }
 
@@ -105,7 +132,19 @@ func injectGuard(marker string, content []byte) []byte {
 // It supports Sprig, the `safe` helper, optional `.Files`, strict mode, and
 // default-missing replacement. Helpers (if provided) are parsed before Template.
 func RenderSingle(opts Options) (Result, error) {
+	return RenderSingleContext(context.Background(), opts)
+}
+
+// RenderSingleContext is RenderSingle with a context used as the parent of the
+// OpenTelemetry spans recorded for each render stage. The render stops with
+// the context's error once ctx is done.
+func RenderSingleContext(ctx context.Context, opts Options) (res Result, err error) {
+	ctx, span := StartSpan(ctx, "templr.render", attribute.Bool("templr.strict", opts.Strict))
+	defer func() { EndSpan(span, err) }()
+
+	_, loadSpan := StartSpan(ctx, "templr.load_values")
 	values, err := loadValues(opts)
+	EndSpan(loadSpan, err)
 	if err != nil {
 		return Result{}, err
 	}
@@ -114,6 +153,26 @@ func RenderSingle(opts Options) (Result, error) {
 		values["Files"] = map[string]any{"Get": opts.Files.Get}
 	}
 
+	if opts.Engine != "" && opts.Engine != EngineGo {
+		engine, err := NewEngine(opts.Engine, opts)
+		if err != nil {
+			return Result{}, err
+		}
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		_, execSpan := StartSpan(ctx, "templr.execute")
+		out, err := engine.Render("root", opts.Template, values)
+		EndSpan(execSpan, err)
+		if err != nil {
+			return Result{}, err
+		}
+		if opts.InjectGuard && opts.GuardMarker != "" {
+			out = string(injectGuard(opts.GuardMarker, []byte(out)))
+		}
+		return Result{Output: out}, nil
+	}
+
 	// Create template first
 	root := template.New("root").Option("missingkey=default")
 	if opts.Strict {
@@ -121,24 +180,20 @@ func RenderSingle(opts Options) (Result, error) {
 	}
 
 	// Build funcmap with reference to root template for include function
-	funcs := defaultFuncMapWithOptions(&root, opts.Strict, opts.DefaultMissing, opts.WarnFunc)
-	for k, v := range opts.FuncMap {
-		funcs[k] = v
-	}
-	root = root.Funcs(funcs)
+	root = root.Funcs(defaultFuncMapWithOptions(&root, opts))
 
-	if opts.Helpers != "" {
-		if _, err := root.Parse(opts.Helpers); err != nil {
-			return Result{}, fmt.Errorf("helpers parse: %w", err)
-		}
-	}
-	t, err := root.Parse(opts.Template)
+	_, parseSpan := StartSpan(ctx, "templr.parse")
+	t, err := parseSingle(root, opts)
+	EndSpan(parseSpan, err)
 	if err != nil {
-		return Result{}, fmt.Errorf("template parse: %w", err)
+		return Result{}, err
 	}
 
+	_, execSpan := StartSpan(ctx, "templr.execute")
 	var buf bytes.Buffer
-	if err := t.Execute(&buf, values); err != nil {
+	err = t.Execute(&limitedBuffer{ctx: ctx, buf: &buf, limit: opts.MaxOutputSize}, values)
+	EndSpan(execSpan, err)
+	if err != nil {
 		return Result{}, fmt.Errorf("render: %w", err)
 	}
 
@@ -148,3 +203,127 @@ func RenderSingle(opts Options) (Result, error) {
 	}
 	return Result{Output: string(out)}, nil
 }
+
+// TreeOptions configures a render of several in-memory templates that share
+// the helpers, values and functions of Options, like a walk over a
+// directory. Options.Template is not used.
+type TreeOptions struct {
+	Options
+	Templates map[string]string // slash-separated path -> template text
+}
+
+// RenderTree parses every template of opts.Templates into one set, so each
+// can include the others, and renders those whose file name does not start
+// with "_" (partials). The outputs are keyed by path without the .tpl
+// extension; as in a walk, empty outputs are left out.
+func RenderTree(opts TreeOptions) (map[string]string, error) {
+	return RenderTreeContext(context.Background(), opts)
+}
+
+// RenderTreeContext is RenderTree stopping with the context's error once ctx
+// is done.
+func RenderTreeContext(ctx context.Context, opts TreeOptions) (map[string]string, error) {
+	values, err := loadValues(opts.Options)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Files != nil {
+		values["Files"] = map[string]any{"Get": opts.Files.Get}
+	}
+
+	root := template.New("root").Option("missingkey=default")
+	if opts.Strict {
+		root = root.Option("missingkey=error")
+	}
+	root = root.Funcs(defaultFuncMapWithOptions(&root, opts.Options))
+	if opts.Helpers != "" {
+		helpers, err := ExpandRawBlocks("helpers", opts.Helpers, "{{", "}}")
+		if err == nil {
+			_, err = root.New("helpers").Parse(helpers)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("helpers parse: %w", err)
+		}
+	}
+
+	names := make([]string, 0, len(opts.Templates))
+	for name := range opts.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		src, err := ExpandRawBlocks(name, opts.Templates[name], "{{", "}}")
+		if err == nil {
+			_, err = root.New(name).Parse(src)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", name, err)
+		}
+	}
+
+	outputs := map[string]string{}
+	for _, name := range names {
+		if strings.HasPrefix(path.Base(name), "_") {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := root.ExecuteTemplate(&limitedBuffer{ctx: ctx, buf: &buf, limit: opts.MaxOutputSize}, name, values); err != nil {
+			return nil, fmt.Errorf("render %s: %w", name, err)
+		}
+		out := applyDefaultMissing(buf.Bytes(), opts.DefaultMissing)
+		if len(bytes.TrimSpace(out)) == 0 {
+			continue
+		}
+		if opts.InjectGuard && opts.GuardMarker != "" {
+			out = injectGuard(opts.GuardMarker, out)
+		}
+		outputs[strings.TrimSuffix(name, ".tpl")] = string(out)
+	}
+	return outputs, nil
+}
+
+// limitedBuffer fails writes that would grow buf beyond limit bytes, or that
+// come after ctx is done, which stops the template execution. A limit of 0
+// means no limit; a nil ctx is never done.
+type limitedBuffer struct {
+	ctx   context.Context
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.ctx != nil {
+		if err := b.ctx.Err(); err != nil {
+			return 0, err
+		}
+	}
+	if b.limit > 0 && b.buf.Len()+len(p) > b.limit {
+		return 0, fmt.Errorf("output exceeds %d bytes", b.limit)
+	}
+	return b.buf.Write(p)
+}
+
+// parseSingle parses the helpers (if any) and the main template into root.
+func parseSingle(root *template.Template, opts Options) (*template.Template, error) {
+	if opts.Helpers != "" {
+		helpers, err := ExpandRawBlocks("helpers", opts.Helpers, "{{", "}}")
+		if err == nil {
+			_, err = root.Parse(helpers)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("helpers parse: %w", err)
+		}
+	}
+	src, err := ExpandRawBlocks(root.Name(), opts.Template, "{{", "}}")
+	if err != nil {
+		return nil, fmt.Errorf("template parse: %w", err)
+	}
+	t, err := root.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("template parse: %w", err)
+	}
+	return t, nil
+}
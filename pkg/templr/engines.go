@@ -0,0 +1,94 @@
+package templr
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// Template engines. Go (text/template with the templr functions) is the
+// default; Jinja renders the subset of Jinja2 described on JinjaEngine, so
+// templates migrated from Ansible can be rendered next to Go templates.
+const (
+	EngineGo    = "go"
+	EngineJinja = "jinja"
+)
+
+// DefaultEngineExts maps the file extensions rendered by an engine other
+// than Go to that engine.
+var DefaultEngineExts = map[string]string{
+	".j2":     EngineJinja,
+	".jinja":  EngineJinja,
+	".jinja2": EngineJinja,
+}
+
+// Engine renders template text with a set of values.
+type Engine interface {
+	// Name returns the engine name used in configuration, such as "jinja".
+	Name() string
+	// Render renders src, the template called name, with values.
+	Render(name, src string, values map[string]any) (string, error)
+}
+
+// NewEngine returns the engine called name ("" is Go) configured from
+// opts. Options.Template and the values options are not used.
+func NewEngine(name string, opts Options) (Engine, error) {
+	switch name {
+	case "", EngineGo:
+		return goEngine{opts: opts}, nil
+	case EngineJinja:
+		return &JinjaEngine{Strict: opts.Strict, MaxOutputSize: opts.MaxOutputSize}, nil
+	}
+	return nil, fmt.Errorf("unknown template engine %q (want %s)", name, strings.Join(Engines(), ", "))
+}
+
+// Engines returns the names of the engines.
+func Engines() []string {
+	return []string{EngineGo, EngineJinja}
+}
+
+// EngineFor returns the engine that renders the template file name: that of
+// its extension in exts, then in DefaultEngineExts, else Go. Extensions are
+// matched case-insensitively, with or without their leading dot.
+func EngineFor(name string, exts map[string]string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if ext == "" {
+		return EngineGo
+	}
+	for k, engine := range exts {
+		if "."+strings.TrimPrefix(strings.ToLower(k), ".") == ext {
+			return engine
+		}
+	}
+	if engine, ok := DefaultEngineExts[ext]; ok {
+		return engine
+	}
+	return EngineGo
+}
+
+// goEngine renders text/template templates with the templr functions, the
+// helpers of its options parsed first.
+type goEngine struct{ opts Options }
+
+func (goEngine) Name() string { return EngineGo }
+
+func (e goEngine) Render(name, src string, values map[string]any) (string, error) {
+	o := e.opts
+	o.Template = src
+	root := template.New(name).Option("missingkey=default")
+	if o.Strict {
+		root = root.Option("missingkey=error")
+	}
+	root = root.Funcs(defaultFuncMapWithOptions(&root, o))
+	t, err := parseSingle(root, o)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&limitedBuffer{buf: &buf, limit: o.MaxOutputSize}, values); err != nil {
+		return "", fmt.Errorf("render: %w", err)
+	}
+	return string(applyDefaultMissing(buf.Bytes(), o.DefaultMissing)), nil
+}
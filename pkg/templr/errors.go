@@ -0,0 +1,75 @@
+package templr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FuncError is returned when one of templr's own template functions fails,
+// so the error always names the function regardless of how it was built.
+type FuncError struct {
+	Func string
+	Err  error
+}
+
+func (e *FuncError) Error() string {
+	msg := e.Err.Error()
+	if strings.HasPrefix(msg, e.Func+":") {
+		return msg
+	}
+	return e.Func + ": " + msg
+}
+
+func (e *FuncError) Unwrap() error { return e.Err }
+
+// passthroughFuncs return user-supplied or nested-template errors that must
+// reach the user unchanged.
+var passthroughFuncs = map[string]bool{"include": true, "includeCached": true, "required": true, "fail": true}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// wrapFuncErrors makes every registered templr function that can fail return
+// its error as a *FuncError.
+func wrapFuncErrors(funcs map[string]any) {
+	for _, info := range templrFuncs {
+		name := info.Name
+		if passthroughFuncs[name] {
+			continue
+		}
+		fn, ok := funcs[name]
+		if !ok {
+			continue
+		}
+		v := reflect.ValueOf(fn)
+		t := v.Type()
+		if t.Kind() != reflect.Func || t.NumOut() == 0 || t.Out(t.NumOut()-1) != errorType {
+			continue
+		}
+		funcs[name] = reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+			var out []reflect.Value
+			if t.IsVariadic() {
+				out = v.CallSlice(args)
+			} else {
+				out = v.Call(args)
+			}
+			last := out[len(out)-1]
+			if err, _ := last.Interface().(error); err != nil {
+				var fe *FuncError
+				if !errors.As(err, &fe) {
+					out[len(out)-1] = reflect.ValueOf(error(&FuncError{Func: name, Err: err}))
+				}
+			}
+			return out
+		}).Interface()
+	}
+}
+
+// argError describes a template function argument that has the wrong type.
+func argError(val any, want string) error {
+	if s, ok := val.(string); ok {
+		return fmt.Errorf("cannot use %q as %s", s, want)
+	}
+	return fmt.Errorf("cannot use %v (%T) as %s", val, val, want)
+}
@@ -1,6 +1,8 @@
 package templr
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
@@ -11,6 +13,10 @@ func PruneEmptyDirs(root string) error {
 	var dirs []string
 	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
 		if err != nil {
+			// nothing was written (every file skipped or empty)
+			if p == root && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
 			return err
 		}
 		if d.IsDir() {
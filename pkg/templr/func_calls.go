@@ -0,0 +1,86 @@
+package templr
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// FuncCalls counts the calls of template functions and the time spent in
+// them, to find the function that makes a render slow. The time of a
+// function includes that of the functions it calls, e.g. include.
+type FuncCalls struct {
+	mu    sync.Mutex
+	stats map[string]*FuncCallStat
+}
+
+// FuncCallStat holds the calls of one template function.
+type FuncCallStat struct {
+	Name  string
+	Calls int
+	Time  time.Duration
+}
+
+// NewFuncCalls returns an empty FuncCalls.
+func NewFuncCalls() *FuncCalls {
+	return &FuncCalls{stats: map[string]*FuncCallStat{}}
+}
+
+// Wrap replaces every function of funcs with one recording its calls in c.
+// The wrappers have the signature of the functions they wrap.
+func (c *FuncCalls) Wrap(funcs template.FuncMap) {
+	for name, fn := range funcs {
+		v := reflect.ValueOf(fn)
+		if v.Kind() != reflect.Func {
+			continue
+		}
+		funcs[name] = c.wrap(name, v).Interface()
+	}
+}
+
+func (c *FuncCalls) wrap(name string, fn reflect.Value) reflect.Value {
+	typ := fn.Type()
+	return reflect.MakeFunc(typ, func(args []reflect.Value) []reflect.Value {
+		start := time.Now()
+		defer func() { c.record(name, time.Since(start)) }() // also when fn panics
+		if typ.IsVariadic() {
+			return fn.CallSlice(args)
+		}
+		return fn.Call(args)
+	})
+}
+
+func (c *FuncCalls) record(name string, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.stats[name]
+	if !ok {
+		s = &FuncCallStat{Name: name}
+		c.stats[name] = s
+	}
+	s.Calls++
+	s.Time += d
+}
+
+// Top returns the n functions with the most time spent in them (all called
+// functions when n <= 0), slowest first.
+func (c *FuncCalls) Top(n int) []FuncCallStat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]FuncCallStat, 0, len(c.stats))
+	for _, s := range c.stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Time != out[j].Time {
+			return out[i].Time > out[j].Time
+		}
+		return out[i].Name < out[j].Name
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
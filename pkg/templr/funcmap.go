@@ -6,15 +6,19 @@ import (
 	"encoding/base32"
 	"encoding/base64"
 	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"math"
 	"math/big"
 	"net"
 	"net/mail"
 	"net/url"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
@@ -22,9 +26,7 @@ import (
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/araddon/dateparse"
-	"github.com/beevik/etree"
 	"github.com/dustin/go-humanize"
-	"github.com/montanaflynn/stats"
 	toml "github.com/pelletier/go-toml/v2"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -33,9 +35,17 @@ import (
 
 // FuncMapOptions configures the behavior of template functions
 type FuncMapOptions struct {
-	Strict         bool
-	DefaultMissing string
-	WarnFunc       func(string) // Function to call for warnings (e.g., missing templates)
+	Strict          bool
+	DefaultMissing  string
+	WarnFunc        func(string)     // Function to call for warnings (e.g., missing templates)
+	ExtraFuncs      template.FuncMap // Added on top of the built-in functions (overriding same-named ones)
+	DisabledFuncs   []string         // Removed from the final map, e.g. to strip env or file access
+	IncludeCache    int              // Memoize include with up to this many renders (0: only includeCached memoizes)
+	IncludeMaxDepth int              // Fail include calls nested deeper than this (0: DefaultIncludeMaxDepth)
+	CryptoPolicy    string           // "fips" rejects the non-approved crypto helpers (always on in fips builds)
+	ValueTemplates  bool             // let renderValueTemplate render templates stored in values
+	Calls           *FuncCalls       // record the calls of every function, e.g. for --debug statistics
+	Features        map[string]any   // feature flags of the render, read by hasFeature
 }
 
 // BuildFuncMap creates the template function map with Sprig and custom functions.
@@ -81,6 +91,17 @@ func BuildFuncMapWithOptions(tpl **template.Template, opts *FuncMapOptions) temp
 			return m, nil
 		}
 	}
+	// Deterministic variants: keys in byte order, and a chosen indent
+	funcs["toYamlSorted"] = func(v any) (string, error) {
+		return toYamlSorted(v, 4)
+	}
+	funcs["toYamlPretty"] = func(v any, indent int) (string, error) {
+		out, err := toYamlSorted(v, indent)
+		if err != nil {
+			return "", fmt.Errorf("toYamlPretty: %w", err)
+		}
+		return out, nil
+	}
 	if _, ok := funcs["mustToYaml"]; !ok {
 		funcs["mustToYaml"] = func(v any) string {
 			b, err := yaml.Marshal(v)
@@ -101,16 +122,19 @@ func BuildFuncMapWithOptions(tpl **template.Template, opts *FuncMapOptions) temp
 	}
 
 	// Helm-like helpers
-	funcs["include"] = func(name string, data any) (string, error) {
+	// render executes an include; found is false when the template is missing
+	// and the non-strict placeholder is returned.
+	stack := newIncludeStack(opts.IncludeMaxDepth)
+	render := func(name string, data any) (out string, found bool, err error) {
 		var b bytes.Buffer
 		if tpl == nil || *tpl == nil {
 			if opts.Strict {
-				return "", fmt.Errorf("template not initialized")
+				return "", false, fmt.Errorf("template not initialized")
 			}
 			if opts.WarnFunc != nil {
 				opts.WarnFunc(fmt.Sprintf("include: template not initialized for %q", name))
 			}
-			return opts.DefaultMissing, nil
+			return opts.DefaultMissing, false, nil
 		}
 
 		// Check if template exists
@@ -118,40 +142,73 @@ func BuildFuncMapWithOptions(tpl **template.Template, opts *FuncMapOptions) temp
 		if tmpl == nil {
 			// Template doesn't exist
 			if opts.Strict {
-				return "", fmt.Errorf("template %q not found", name)
+				return "", false, fmt.Errorf("template %q not found", name)
 			}
 			if opts.WarnFunc != nil {
 				opts.WarnFunc(fmt.Sprintf("include: template %q not found", name))
 			}
-			return opts.DefaultMissing, nil
+			return opts.DefaultMissing, false, nil
 		}
 
+		if err := stack.push(name); err != nil {
+			return "", true, err
+		}
+		defer stack.pop()
 		if err := (*tpl).ExecuteTemplate(&b, name, data); err != nil {
+			// The depth error surfaces once, not wrapped by every level
+			var depthErr *IncludeDepthError
+			if errors.As(err, &depthErr) {
+				return "", true, depthErr
+			}
 			// Execution error - always fail (even in non-strict mode)
-			return "", err
+			return "", true, err
+		}
+		return b.String(), true, nil
+	}
+	// cached renders a template once per (name, data); missing templates and
+	// errors are not cached so that they are reported every time.
+	cache := newIncludeCache(opts.IncludeCache)
+	cached := func(name string, data any) (string, error) {
+		key, ok := includeKey(name, data)
+		if ok {
+			if out, hit := cache.get(key); hit {
+				return out, nil
+			}
 		}
-		return b.String(), nil
+		out, found, err := render(name, data)
+		if ok && found && err == nil {
+			cache.put(key, out)
+		}
+		return out, err
 	}
+	funcs["include"] = func(name string, data any) (string, error) {
+		if opts.IncludeCache > 0 {
+			return cached(name, data)
+		}
+		out, _, err := render(name, data)
+		return out, err
+	}
+	funcs["includeCached"] = cached
 	funcs["required"] = func(msg string, v any) (any, error) {
-		switch x := v.(type) {
-		case nil:
+		if isBlankValue(v) {
 			return nil, errors.New(msg)
-		case string:
-			if strings.TrimSpace(x) == "" {
-				return nil, errors.New(msg)
-			}
-		case []any:
-			if len(x) == 0 {
-				return nil, errors.New(msg)
-			}
-		case map[string]any:
-			if len(x) == 0 {
-				return nil, errors.New(msg)
-			}
 		}
 		return v, nil
 	}
+	// requiredFields / requiredAll: check several dotted paths at once and
+	// report every missing one in a single error
+	funcs["requiredFields"] = func(data any, paths any) (string, error) {
+		list, err := pathList(paths)
+		if err != nil {
+			return "", err
+		}
+		return "", checkRequiredFields(data, list)
+	}
+	funcs["requiredAll"] = func(data any, paths ...string) (string, error) {
+		return "", checkRequiredFields(data, paths)
+	}
 	funcs["fail"] = func(msg string) (string, error) { return "", errors.New(msg) }
+	funcs["hasFeature"] = func(name string) bool { return featureOn(opts.Features[name]) }
 
 	// set: mutate a map with key=value and return it (useful for introducing new vars)
 	funcs["set"] = func(m map[string]any, key string, val any) (map[string]any, error) {
@@ -177,6 +234,13 @@ func BuildFuncMapWithOptions(tpl **template.Template, opts *FuncMapOptions) temp
 		}
 		return deepMerge(out, b)
 	}
+	// pairs, key renaming, regex picking and dotted lookups mirroring setd
+	funcs["toPairs"] = toPairs
+	funcs["fromPairs"] = fromPairs
+	funcs["renameKey"] = renameKey
+	funcs["pickRegex"] = pickRegex
+	funcs["deepGet"] = deepGet
+	funcs["deepHas"] = deepHas
 	// safe: render value or fallback when missing/empty
 	funcs["safe"] = func(v any, def string) string {
 		if v == nil {
@@ -241,6 +305,10 @@ func BuildFuncMapWithOptions(tpl **template.Template, opts *FuncMapOptions) temp
 		}
 	}
 
+	funcs["formatNumber"] = formatLocaleNumber
+	funcs["formatCurrency"] = formatLocaleCurrency
+	funcs["formatPercent"] = formatLocalePercent
+
 	funcs["ordinal"] = func(num any) string {
 		var n int
 		switch v := num.(type) {
@@ -273,6 +341,29 @@ func BuildFuncMapWithOptions(tpl **template.Template, opts *FuncMapOptions) temp
 		return m, nil
 	}
 
+	// JSONC: JSON with comments and trailing commas
+	funcs["fromJsonc"] = func(s string) (any, error) {
+		var v any
+		if err := json.Unmarshal(StandardizeJSONC([]byte(s)), &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+
+	// JSON with a chosen indent, sorted keys, or strict parsing
+	funcs["toJsonIndent"] = toJSONIndent
+	funcs["toJsonSortedKeys"] = toJSONSortedKeys
+	funcs["fromJsonStrict"] = fromJSONStrict
+
+	// Terraform variable definitions (terraform.tfvars)
+	funcs["toTfvars"] = MarshalTfvars
+	funcs["fromTfvars"] = func(s string) (map[string]any, error) {
+		return ParseTfvars([]byte(s))
+	}
+
+	// Ansible inventories (INI or YAML)
+	funcs["fromAnsibleInventory"] = ParseAnsibleInventory
+
 	// Path functions
 	funcs["pathExt"] = func(path string) string {
 		return filepath.Ext(path)
@@ -320,6 +411,44 @@ func BuildFuncMapWithOptions(tpl **template.Template, opts *FuncMapOptions) temp
 		return uuidRegex.MatchString(uuid)
 	}
 
+	// Stable hashing functions: the same input maps to the same value on
+	// every render and platform
+	funcs["hashFNV"] = func(s string) int {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(s))
+		return int(h.Sum32())
+	}
+
+	funcs["consistentShard"] = func(key string, buckets any) (int, error) {
+		n, err := toInt(buckets)
+		if err != nil {
+			return 0, err
+		}
+		if n <= 0 {
+			return 0, fmt.Errorf("bucket count must be positive, got %d", n)
+		}
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		return jumpHash(h.Sum64(), n), nil
+	}
+
+	funcs["colorFromString"] = func(s string) string {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(s))
+		return hslToHex(float64(h.Sum32()%360), 0.65, 0.5)
+	}
+
+	// Identifiers: uuidv5 and ulidFrom are stable across renders, unlike uuidv4
+	funcs["uuidv5"] = UUIDv5
+	funcs["ulid"] = func() (string, error) { return NewULID(time.Now()) }
+	funcs["ulidFrom"] = func(t any, name string) (string, error) {
+		ts, err := toTime(t)
+		if err != nil {
+			return "", err
+		}
+		return DeterministicULID(ts, name), nil
+	}
+
 	// Advanced Base64 & Encoding functions
 	funcs["base64url"] = func(data string) string {
 		return base64.URLEncoding.EncodeToString([]byte(data))
@@ -543,58 +672,7 @@ func BuildFuncMapWithOptions(tpl **template.Template, opts *FuncMapOptions) temp
 	}
 
 	// Math and Statistics functions
-	funcs["sum"] = func(numbers any) (float64, error) {
-		floats, err := toFloat64Slice(numbers)
-		if err != nil {
-			return 0, err
-		}
-		return stats.Sum(floats)
-	}
-
-	funcs["avg"] = func(numbers any) (float64, error) {
-		floats, err := toFloat64Slice(numbers)
-		if err != nil {
-			return 0, err
-		}
-		return stats.Mean(floats)
-	}
-
-	funcs["median"] = func(numbers any) (float64, error) {
-		floats, err := toFloat64Slice(numbers)
-		if err != nil {
-			return 0, err
-		}
-		return stats.Median(floats)
-	}
-
-	funcs["stddev"] = func(numbers any) (float64, error) {
-		floats, err := toFloat64Slice(numbers)
-		if err != nil {
-			return 0, err
-		}
-		return stats.StandardDeviation(floats)
-	}
-
-	funcs["percentile"] = func(numbers, p any) (float64, error) {
-		floats, err := toFloat64Slice(numbers)
-		if err != nil {
-			return 0, err
-		}
-
-		var percentile float64
-		switch v := p.(type) {
-		case int:
-			percentile = float64(v)
-		case int64:
-			percentile = float64(v)
-		case float64:
-			percentile = v
-		default:
-			return 0, fmt.Errorf("percentile must be numeric, got %T", p)
-		}
-
-		return stats.Percentile(floats, percentile)
-	}
+	addStatsFuncs(funcs)
 
 	funcs["clamp"] = func(value, minValue, maxValue any) (float64, error) {
 		v, err := toFloat64(value)
@@ -635,6 +713,119 @@ func BuildFuncMapWithOptions(tpl **template.Template, opts *FuncMapOptions) temp
 		return math.Round(v*multiplier) / multiplier, nil
 	}
 
+	// Sequences: seqList takes the arguments of Sprig's seq, which returns a
+	// string and is left as is, but returns a list that range can iterate
+	funcs["seqList"] = func(params ...any) (intSeq, error) {
+		ints := make([]int, len(params))
+		for i, p := range params {
+			n, err := toInt(p)
+			if err != nil {
+				return nil, err
+			}
+			ints[i] = n
+		}
+		switch len(ints) {
+		case 0:
+			return intSeq{}, nil
+		case 1:
+			return inclusiveSeq(1, ints[0], seqDirection(1, ints[0])), nil
+		case 2:
+			return inclusiveSeq(ints[0], ints[1], seqDirection(ints[0], ints[1])), nil
+		case 3:
+			return inclusiveSeq(ints[0], ints[2], ints[1]), nil
+		default:
+			return nil, fmt.Errorf("takes 1 to 3 arguments, got %d", len(ints))
+		}
+	}
+
+	funcs["seqStep"] = func(start, end, step any) (intSeq, error) {
+		from, err := toInt(start)
+		if err != nil {
+			return nil, err
+		}
+		to, err := toInt(end)
+		if err != nil {
+			return nil, err
+		}
+		by, err := toInt(step)
+		if err != nil {
+			return nil, err
+		}
+		if by == 0 {
+			return nil, fmt.Errorf("step must not be 0")
+		}
+		return inclusiveSeq(from, to, by), nil
+	}
+
+	// chunk accepts Sprig's "chunk SIZE LIST" and "chunk LIST SIZE"
+	funcs["chunk"] = func(a, b any) ([][]any, error) {
+		size, list := a, b
+		if _, err := toInt(a); err != nil {
+			size, list = b, a
+		}
+		n, err := toInt(size)
+		if err != nil {
+			return nil, err
+		}
+		if n <= 0 {
+			return nil, fmt.Errorf("size must be positive, got %d", n)
+		}
+		v := reflect.ValueOf(list)
+		if list == nil || (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) {
+			return nil, argError(list, "a list")
+		}
+		chunks := make([][]any, 0, (v.Len()+n-1)/n)
+		for i := 0; i < v.Len(); i += n {
+			end := min(i+n, v.Len())
+			c := make([]any, 0, end-i)
+			for j := i; j < end; j++ {
+				c = append(c, v.Index(j).Interface())
+			}
+			chunks = append(chunks, c)
+		}
+		return chunks, nil
+	}
+
+	// matrix: cartesian product of a dict of lists, as one dict per
+	// combination; keys vary in sorted order, the last one fastest
+	funcs["matrix"] = func(axes map[string]any) ([]map[string]any, error) {
+		if len(axes) == 0 {
+			return []map[string]any{}, nil
+		}
+		keys := make([]string, 0, len(axes))
+		for k := range axes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		combos := []map[string]any{{}}
+		for _, k := range keys {
+			values := []any{axes[k]}
+			if v := reflect.ValueOf(axes[k]); v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+				values = make([]any, v.Len())
+				for i := range values {
+					values[i] = v.Index(i).Interface()
+				}
+			}
+			if len(combos)*len(values) > maxMatrixSize {
+				return nil, fmt.Errorf("more than %d combinations", maxMatrixSize)
+			}
+			next := make([]map[string]any, 0, len(combos)*len(values))
+			for _, combo := range combos {
+				for _, v := range values {
+					m := make(map[string]any, len(combo)+1)
+					for ck, cv := range combo {
+						m[ck] = cv
+					}
+					m[k] = v
+					next = append(next, m)
+				}
+			}
+			combos = next
+		}
+		return combos, nil
+	}
+
 	// Enhanced JSON Querying functions
 	funcs["jsonPath"] = func(jsonData, path string) (any, error) {
 		result := gjson.Get(jsonData, path)
@@ -725,34 +916,23 @@ func BuildFuncMapWithOptions(tpl **template.Template, opts *FuncMapOptions) temp
 	}
 
 	// XML Support functions
-	funcs["toXml"] = func(data any) (string, error) {
-		doc := etree.NewDocument()
-		doc.Indent(2)
+	addXMLFuncs(funcs)
 
-		if err := buildXMLElement(doc.CreateElement("root"), data); err != nil {
-			return "", err
-		}
+	wrapFuncErrors(funcs)
+	wrapDeprecated(funcs, opts.WarnFunc)
 
-		var buf bytes.Buffer
-		if _, err := doc.WriteTo(&buf); err != nil {
-			return "", err
-		}
-		return buf.String(), nil
+	for name, fn := range opts.ExtraFuncs {
+		funcs[name] = fn
 	}
-
-	funcs["fromXml"] = func(xmlData string) (map[string]any, error) {
-		doc := etree.NewDocument()
-		if err := doc.ReadFromString(xmlData); err != nil {
-			return nil, err
-		}
-
-		root := doc.Root()
-		if root == nil {
-			return map[string]any{}, nil
-		}
-
-		result := parseXMLElement(root)
-		return map[string]any{root.Tag: result}, nil
+	if CryptoPolicyEnforced(opts.CryptoPolicy) {
+		applyCryptoPolicy(funcs)
+	}
+	addValueTemplates(funcs, opts)
+	for _, name := range opts.DisabledFuncs {
+		delete(funcs, name)
+	}
+	if opts.Calls != nil {
+		opts.Calls.Wrap(funcs)
 	}
 
 	return funcs
@@ -780,10 +960,101 @@ func toFloat64(val any) (float64, error) {
 	case float64:
 		return v, nil
 	case string:
-		return strconv.ParseFloat(v, 64)
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, argError(val, "a number")
+		}
+		return f, nil
+	default:
+		return 0, argError(val, "a number")
+	}
+}
+
+// jumpHash maps key to a bucket in [0, buckets) with Jump Consistent Hash
+// (Lamping & Veach): growing the bucket count from n to n+1 only moves 1/(n+1)
+// of the keys.
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// hslToHex converts a hue (degrees), saturation and lightness (0-1) to a
+// "#rrggbb" color.
+func hslToHex(h, s, l float64) string {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
 	default:
-		return 0, fmt.Errorf("cannot convert %T to float64", val)
+		r, g, b = c, 0, x
+	}
+	to8 := func(v float64) int { return int(math.Round((v + m) * 255)) }
+	return fmt.Sprintf("#%02x%02x%02x", to8(r), to8(g), to8(b))
+}
+
+// maxMatrixSize bounds the number of combinations matrix generates.
+const maxMatrixSize = 100000
+
+// toInt converts an integral number (or numeric string) to int
+func toInt(val any) (int, error) {
+	f, err := toFloat64(val)
+	if err != nil || f != math.Trunc(f) {
+		return 0, argError(val, "an integer")
+	}
+	return int(f), nil
+}
+
+// intSeq is a list of integers that prints space-separated, like the string
+// Sprig's seq returns.
+type intSeq []int
+
+func (s intSeq) String() string {
+	parts := make([]string, len(s))
+	for i, n := range s {
+		parts[i] = strconv.Itoa(n)
 	}
+	return strings.Join(parts, " ")
+}
+
+// seqDirection returns 1 when counting up from start to end, -1 otherwise.
+func seqDirection(start, end int) int {
+	if end < start {
+		return -1
+	}
+	return 1
+}
+
+// inclusiveSeq returns start, start+step, ... up to and including end. A step
+// that points away from end gives an empty list, as Sprig's untilStep does.
+func inclusiveSeq(start, end, step int) intSeq {
+	s := intSeq{}
+	switch {
+	case step > 0 && start <= end:
+		for i := start; i <= end; i += step {
+			s = append(s, i)
+		}
+	case step < 0 && start >= end:
+		for i := start; i >= end; i += step {
+			s = append(s, i)
+		}
+	}
+	return s
 }
 
 // toFloat64Slice converts slice/array to []float64
@@ -808,13 +1079,13 @@ func toFloat64Slice(val any) ([]float64, error) {
 		for i, item := range v {
 			f, err := toFloat64(item)
 			if err != nil {
-				return nil, err
+				return nil, fmt.Errorf("item %d: %w", i, err)
 			}
 			result[i] = f
 		}
 		return result, nil
 	default:
-		return nil, fmt.Errorf("cannot convert %T to []float64", val)
+		return nil, argError(val, "a list of numbers")
 	}
 }
 
@@ -870,93 +1141,105 @@ func parseDurationString(s string) (time.Duration, error) {
 	return total, nil
 }
 
-// buildXMLElement builds XML element from Go data
-func buildXMLElement(elem *etree.Element, data any) error {
-	switch v := data.(type) {
-	case map[string]any:
-		for key, val := range v {
-			child := elem.CreateElement(key)
-			if err := buildXMLElement(child, val); err != nil {
-				return err
-			}
-		}
-	case []any:
-		for i, item := range v {
-			child := elem.CreateElement(fmt.Sprintf("item%d", i))
-			if err := buildXMLElement(child, item); err != nil {
-				return err
+// deepMerge performs deep merge of two maps (right wins)
+func deepMerge(dst, src map[string]any) map[string]any {
+	if dst == nil {
+		dst = map[string]any{}
+	}
+	for k, v := range src {
+		if dm, ok := dst[k].(map[string]any); ok {
+			if sm, ok := v.(map[string]any); ok {
+				dst[k] = deepMerge(dm, sm)
+				continue
 			}
 		}
-	case string:
-		elem.SetText(v)
-	case int, int64, float64, bool:
-		elem.SetText(fmt.Sprintf("%v", v))
-	case nil:
-		// Empty element
-	default:
-		elem.SetText(fmt.Sprintf("%v", v))
+		dst[k] = v
 	}
-	return nil
+	return dst
 }
 
-// parseXMLElement parses XML element to Go data
-func parseXMLElement(elem *etree.Element) any {
-	// If element has no children, return text content
-	if len(elem.ChildElements()) == 0 {
-		text := elem.Text()
-		if text == "" {
-			return nil
-		}
-		return text
+// setByDottedKey assigns val into m using a dotted path (e.g., "a.b.c")
+// isBlankValue reports whether required treats v as missing: nil, a blank
+// string or an empty list or map.
+func isBlankValue(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(x) == ""
+	case []any:
+		return len(x) == 0
+	case map[string]any:
+		return len(x) == 0
 	}
+	return false
+}
 
-	// If all children have the same tag, treat as array
-	children := elem.ChildElements()
-	if len(children) > 0 {
-		firstTag := children[0].Tag
-		allSame := true
-		for _, child := range children {
-			if child.Tag != firstTag {
-				allSame = false
-				break
-			}
-		}
-
-		if allSame {
-			var arr []any
-			for _, child := range children {
-				arr = append(arr, parseXMLElement(child))
-			}
-			return arr
+// checkRequiredFields fails with every dotted path of data that is absent
+// or blank, in the order given.
+func checkRequiredFields(data any, paths []string) error {
+	var missing []string
+	for _, p := range paths {
+		p = strings.TrimPrefix(p, ".")
+		if isBlankValue(lookupDotted(data, p)) {
+			missing = append(missing, "."+p)
 		}
 	}
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("missing required value %s", missing[0])
+	}
+	return fmt.Errorf("missing %d required values: %s", len(missing), strings.Join(missing, ", "))
+}
 
-	// Otherwise, treat as map
-	result := make(map[string]any)
-	for _, child := range children {
-		result[child.Tag] = parseXMLElement(child)
+// featureOn reports whether a feature flag value enables its feature: true,
+// or any value but false and the empty string. A missing flag is off.
+func featureOn(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case string:
+		return x != ""
 	}
-	return result
+	return true
 }
 
-// deepMerge performs deep merge of two maps (right wins)
-func deepMerge(dst, src map[string]any) map[string]any {
-	if dst == nil {
-		dst = map[string]any{}
+// lookupDotted returns the value at a dotted path of nested maps, or nil.
+func lookupDotted(data any, dotted string) any {
+	cur := data
+	for _, key := range strings.Split(dotted, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[key]
 	}
-	for k, v := range src {
-		if dm, ok := dst[k].(map[string]any); ok {
-			if sm, ok := v.(map[string]any); ok {
-				dst[k] = deepMerge(dm, sm)
-				continue
+	return cur
+}
+
+// pathList converts the list argument of requiredFields to strings.
+func pathList(v any) ([]string, error) {
+	switch x := v.(type) {
+	case []string:
+		return x, nil
+	case []any:
+		out := make([]string, len(x))
+		for i, p := range x {
+			s, ok := p.(string)
+			if !ok {
+				return nil, argError(p, "a dotted path")
 			}
+			out[i] = s
 		}
-		dst[k] = v
+		return out, nil
 	}
-	return dst
+	return nil, argError(v, "a list of dotted paths")
 }
 
-// setByDottedKey assigns val into m using a dotted path (e.g., "a.b.c")
 func setByDottedKey(m map[string]any, dotted string, val any) {
 	parts := strings.Split(dotted, ".")
 	cur := m
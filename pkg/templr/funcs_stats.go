@@ -0,0 +1,66 @@
+//go:build !wasm_lite
+
+package templr
+
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/montanaflynn/stats"
+)
+
+// addStatsFuncs adds the statistics functions, left out of wasm_lite builds.
+func addStatsFuncs(funcs template.FuncMap) {
+	funcs["sum"] = func(numbers any) (float64, error) {
+		floats, err := toFloat64Slice(numbers)
+		if err != nil {
+			return 0, err
+		}
+		return stats.Sum(floats)
+	}
+
+	funcs["avg"] = func(numbers any) (float64, error) {
+		floats, err := toFloat64Slice(numbers)
+		if err != nil {
+			return 0, err
+		}
+		return stats.Mean(floats)
+	}
+
+	funcs["median"] = func(numbers any) (float64, error) {
+		floats, err := toFloat64Slice(numbers)
+		if err != nil {
+			return 0, err
+		}
+		return stats.Median(floats)
+	}
+
+	funcs["stddev"] = func(numbers any) (float64, error) {
+		floats, err := toFloat64Slice(numbers)
+		if err != nil {
+			return 0, err
+		}
+		return stats.StandardDeviation(floats)
+	}
+
+	funcs["percentile"] = func(numbers, p any) (float64, error) {
+		floats, err := toFloat64Slice(numbers)
+		if err != nil {
+			return 0, err
+		}
+
+		var percentile float64
+		switch v := p.(type) {
+		case int:
+			percentile = float64(v)
+		case int64:
+			percentile = float64(v)
+		case float64:
+			percentile = v
+		default:
+			return 0, fmt.Errorf("percentile must be numeric, got %T", p)
+		}
+
+		return stats.Percentile(floats, percentile)
+	}
+}
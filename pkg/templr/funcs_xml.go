@@ -0,0 +1,113 @@
+//go:build !wasm_lite
+
+package templr
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/beevik/etree"
+)
+
+// addXMLFuncs adds toXml and fromXml, left out of wasm_lite builds.
+func addXMLFuncs(funcs template.FuncMap) {
+	funcs["toXml"] = func(data any) (string, error) {
+		doc := etree.NewDocument()
+		doc.Indent(2)
+
+		if err := buildXMLElement(doc.CreateElement("root"), data); err != nil {
+			return "", err
+		}
+
+		var buf bytes.Buffer
+		if _, err := doc.WriteTo(&buf); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	funcs["fromXml"] = func(xmlData string) (map[string]any, error) {
+		doc := etree.NewDocument()
+		if err := doc.ReadFromString(xmlData); err != nil {
+			return nil, err
+		}
+
+		root := doc.Root()
+		if root == nil {
+			return map[string]any{}, nil
+		}
+
+		result := parseXMLElement(root)
+		return map[string]any{root.Tag: result}, nil
+	}
+}
+
+// buildXMLElement builds XML element from Go data
+func buildXMLElement(elem *etree.Element, data any) error {
+	switch v := data.(type) {
+	case map[string]any:
+		for key, val := range v {
+			child := elem.CreateElement(key)
+			if err := buildXMLElement(child, val); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, item := range v {
+			child := elem.CreateElement(fmt.Sprintf("item%d", i))
+			if err := buildXMLElement(child, item); err != nil {
+				return err
+			}
+		}
+	case string:
+		elem.SetText(v)
+	case int, int64, float64, bool:
+		elem.SetText(fmt.Sprintf("%v", v))
+	case nil:
+		// Empty element
+	default:
+		elem.SetText(fmt.Sprintf("%v", v))
+	}
+	return nil
+}
+
+// parseXMLElement parses XML element to Go data
+func parseXMLElement(elem *etree.Element) any {
+	// If element has no children, return text content
+	if len(elem.ChildElements()) == 0 {
+		text := elem.Text()
+		if text == "" {
+			return nil
+		}
+		return text
+	}
+
+	// If all children have the same tag, treat as array
+	children := elem.ChildElements()
+	if len(children) > 0 {
+		firstTag := children[0].Tag
+		allSame := true
+		for _, child := range children {
+			if child.Tag != firstTag {
+				allSame = false
+				break
+			}
+		}
+
+		if allSame {
+			var arr []any
+			for _, child := range children {
+				arr = append(arr, parseXMLElement(child))
+			}
+			return arr
+		}
+	}
+
+	// Otherwise, treat as map
+	result := make(map[string]any)
+	for _, child := range children {
+		result[child.Tag] = parseXMLElement(child)
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package templr
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"github.com/google/uuid"
+)
+
+// uuidNamespaces are the predefined UUIDv5 namespaces of RFC 4122.
+var uuidNamespaces = map[string]uuid.UUID{
+	"dns":  uuid.NameSpaceDNS,
+	"url":  uuid.NameSpaceURL,
+	"oid":  uuid.NameSpaceOID,
+	"x500": uuid.NameSpaceX500,
+}
+
+// UUIDv5 returns the name-based (SHA-1) UUID of name in namespace, which is
+// "dns", "url", "oid", "x500" or a UUID. The result only depends on the inputs.
+func UUIDv5(namespace, name string) (string, error) {
+	ns, ok := uuidNamespaces[strings.ToLower(namespace)]
+	if !ok {
+		var err error
+		if ns, err = uuid.Parse(namespace); err != nil {
+			return "", fmt.Errorf("namespace %q is not dns, url, oid, x500 or a UUID", namespace)
+		}
+	}
+	return uuid.NewSHA1(ns, []byte(name)).String(), nil
+}
+
+// crockford is the Base32 alphabet of ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a ULID for t with random entropy.
+func NewULID(t time.Time) (string, error) {
+	var entropy [10]byte
+	if _, err := rand.Read(entropy[:]); err != nil {
+		return "", err
+	}
+	return encodeULID(t, entropy), nil
+}
+
+// DeterministicULID returns the ULID for t whose entropy is derived from
+// name, so the same time and name always give the same ULID.
+func DeterministicULID(t time.Time, name string) string {
+	sum := sha256.Sum256([]byte(name))
+	var entropy [10]byte
+	copy(entropy[:], sum[:])
+	return encodeULID(t, entropy)
+}
+
+// encodeULID encodes the 48-bit millisecond timestamp of t and 80 bits of
+// entropy as 26 Crockford Base32 characters.
+func encodeULID(t time.Time, entropy [10]byte) string {
+	var id [16]byte
+	ms := uint64(t.UnixMilli())
+	for i := 0; i < 6; i++ {
+		id[i] = byte(ms >> (8 * (5 - i)))
+	}
+	copy(id[6:], entropy[:])
+
+	// 26 characters hold 130 bits: two zero bits, then the 128 bits of the ID
+	var b strings.Builder
+	b.Grow(26)
+	var acc uint32
+	bits := 2
+	for _, c := range id {
+		acc = acc<<8 | uint32(c)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			b.WriteByte(crockford[(acc>>bits)&0x1f])
+		}
+		acc &= 1<<bits - 1
+	}
+	return b.String()
+}
+
+// toTime converts a time.Time or a date string to a time.Time.
+func toTime(v any) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case *time.Time:
+		if t != nil {
+			return *t, nil
+		}
+	case string:
+		if parsed, err := dateparse.ParseAny(t); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, argError(v, "a time or date")
+}
@@ -0,0 +1,71 @@
+package templr
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+)
+
+// DefaultIncludeCacheSize is the number of renders includeCached keeps when
+// no size is configured.
+const DefaultIncludeCacheSize = 1024
+
+// includeCache memoizes include renders keyed by template name and a hash
+// of the data, evicting the least recently used entry beyond size.
+type includeCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // front: most recently used
+	entries map[string]*list.Element
+}
+
+type includeEntry struct {
+	key string
+	out string
+}
+
+func newIncludeCache(size int) *includeCache {
+	if size <= 0 {
+		size = DefaultIncludeCacheSize
+	}
+	return &includeCache{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// includeKey returns the cache key of a render, or false when data cannot be
+// hashed (it holds functions or channels) and the render is not cached.
+func includeKey(name string, data any) (string, bool) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(b)
+	return name + "\x00" + string(sum[:]), true
+}
+
+func (c *includeCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*includeEntry).out, true
+}
+
+func (c *includeCache) put(key, out string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*includeEntry).out = out
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&includeEntry{key: key, out: out})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*includeEntry).key)
+	}
+}
@@ -0,0 +1,63 @@
+package templr
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultIncludeMaxDepth is how deep include calls may nest when no limit is
+// configured: far beyond any real recursion, as in Helm, and low enough to
+// fail before a template that includes itself exhausts the stack.
+const DefaultIncludeMaxDepth = 1000
+
+// IncludeDepthError reports include calls nested beyond the depth limit.
+type IncludeDepthError struct {
+	Limit int
+	Stack []string // included templates, outermost first
+}
+
+func (e *IncludeDepthError) Error() string {
+	return fmt.Sprintf("include depth limit %d exceeded: %s", e.Limit, e.Cycle())
+}
+
+// Cycle returns the include chain up to the first template entered twice,
+// such as "page -> a -> b -> a", or the whole stack when none repeats.
+func (e *IncludeDepthError) Cycle() string {
+	seen := map[string]bool{}
+	for i, name := range e.Stack {
+		if seen[name] {
+			return strings.Join(e.Stack[:i+1], " -> ")
+		}
+		seen[name] = true
+	}
+	return strings.Join(e.Stack, " -> ")
+}
+
+// includeStack tracks the templates include is rendering, innermost last.
+// A func map renders one template at a time, like the template set it
+// belongs to, so the stack needs no lock.
+type includeStack struct {
+	max   int
+	names []string
+}
+
+func newIncludeStack(max int) *includeStack {
+	if max <= 0 {
+		max = DefaultIncludeMaxDepth
+	}
+	return &includeStack{max: max}
+}
+
+// push enters name, failing when that nests deeper than the limit.
+func (s *includeStack) push(name string) error {
+	s.names = append(s.names, name)
+	if len(s.names) > s.max {
+		err := &IncludeDepthError{Limit: s.max, Stack: slices.Clone(s.names)}
+		s.names = s.names[:len(s.names)-1]
+		return err
+	}
+	return nil
+}
+
+func (s *includeStack) pop() { s.names = s.names[:len(s.names)-1] }
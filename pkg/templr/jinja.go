@@ -0,0 +1,986 @@
+package templr
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// JinjaEngine renders a subset of Jinja2, the template language of Ansible:
+//
+//   - {{ expr }} output, {# comments #}, {% raw %}...{% endraw %}
+//   - {% if %}/{% elif %}/{% else %}/{% endif %}
+//   - {% for x in xs [if cond] %}/{% else %}/{% endfor %} with loop.index,
+//     loop.index0, loop.first, loop.last and loop.length; for k, v in d.items()
+//   - {% set x = expr %} and {% include "name" [ignore missing] %}
+//   - {% macro name(arg, arg=default) %}...{% endmacro %}, called as name(...)
+//   - literals, attribute and index access, arithmetic, comparisons, and,
+//     or, not, in, is tests, inline if/else, ~ concatenation, % formatting
+//   - the common Jinja and Ansible filters (see jinjaFilters)
+//
+// The values are the top-level variables. As in Ansible, the first newline
+// after a block tag is removed (trim_blocks), and {%- -%} trim whitespace.
+// Blocks, template inheritance, imports and {% call %} are not supported.
+//
+// pongo2, the Go implementation of Django templates, was not used: its
+// filter arguments (default:"x"), loop variables (forloop) and missing
+// inline if and ~ make it unable to render Ansible templates unchanged.
+type JinjaEngine struct {
+	Strict        bool                              // using an undefined variable is an error
+	Loader        func(name string) (string, error) // reads the templates of {% include %}
+	MaxOutputSize int                               // fail renders whose output exceeds this many bytes (0: no limit)
+}
+
+func (e *JinjaEngine) Name() string { return EngineJinja }
+
+// Render renders src, the Jinja template called name, with values.
+func (e *JinjaEngine) Render(name, src string, values map[string]any) (string, error) {
+	body, err := parseJinja(name, src)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	r := &jinjaRenderer{
+		engine: e,
+		out:    &limitedBuffer{buf: &buf, limit: e.MaxOutputSize},
+		scopes: []map[string]any{values, {}},
+		stack:  []string{name},
+	}
+	if err := r.render(body); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// jinjaMaxIncludeDepth bounds {% include %} nesting, stopping templates that
+// include themselves.
+const jinjaMaxIncludeDepth = 100
+
+// Template-level tokens.
+const (
+	jinjaText = iota
+	jinjaVar
+	jinjaStmt
+	jinjaComment
+)
+
+type jinjaTok struct {
+	kind         int
+	text         string // text, or the inside of a tag without its trim markers
+	line         int
+	ltrim, rtrim bool
+}
+
+var (
+	jinjaTagClose = map[string]string{"{{": "}}", "{%": "%}", "{#": "#}"}
+	jinjaTagKind  = map[string]int{"{{": jinjaVar, "{%": jinjaStmt, "{#": jinjaComment}
+	jinjaEndRaw   = regexp.MustCompile(`\{%(-?)\s*endraw\s*(-?)%\}`)
+)
+
+// lexJinja splits src into text and tags and applies the whitespace control
+// of the tags to the text around them.
+func lexJinja(name, src string) ([]jinjaTok, error) {
+	var toks []jinjaTok
+	line := 1
+	addText := func(s string) {
+		if s != "" {
+			toks = append(toks, jinjaTok{kind: jinjaText, text: s, line: line})
+			line += strings.Count(s, "\n")
+		}
+	}
+	for i := 0; i < len(src); {
+		j := jinjaNextTag(src, i)
+		if j < 0 {
+			addText(src[i:])
+			break
+		}
+		addText(src[i:j])
+		open := src[j : j+2]
+		end := jinjaFindClose(src, j+2, jinjaTagClose[open], open != "{#")
+		if end < 0 {
+			return nil, fmt.Errorf("%s:%d: unclosed %s", name, line, open)
+		}
+		tok := jinjaTok{kind: jinjaTagKind[open], line: line}
+		inner := src[j+2 : end]
+		if strings.HasPrefix(inner, "-") {
+			tok.ltrim, inner = true, inner[1:]
+		}
+		if strings.HasSuffix(inner, "-") {
+			tok.rtrim, inner = true, inner[:len(inner)-1]
+		}
+		tok.text = strings.TrimSpace(inner)
+		toks = append(toks, tok)
+		line += strings.Count(src[j:end+2], "\n")
+		i = end + 2
+
+		// the content of a raw block is text
+		if tok.kind == jinjaStmt && tok.text == "raw" {
+			m := jinjaEndRaw.FindStringSubmatchIndex(src[i:])
+			if m == nil {
+				return nil, fmt.Errorf("%s:%d: raw block is not closed with endraw", name, tok.line)
+			}
+			addText(src[i : i+m[0]])
+			toks = append(toks, jinjaTok{kind: jinjaStmt, text: "endraw", line: line, ltrim: m[3] > m[2], rtrim: m[5] > m[4]})
+			i += m[1]
+		}
+	}
+
+	for k, t := range toks {
+		if t.kind == jinjaText {
+			continue
+		}
+		if t.ltrim && k > 0 && toks[k-1].kind == jinjaText {
+			toks[k-1].text = strings.TrimRight(toks[k-1].text, " \t\r\n")
+		}
+		if k+1 < len(toks) && toks[k+1].kind == jinjaText {
+			next := &toks[k+1]
+			switch {
+			case t.rtrim:
+				next.text = strings.TrimLeft(next.text, " \t\r\n")
+			case t.kind != jinjaVar: // trim_blocks
+				if strings.HasPrefix(next.text, "\r\n") {
+					next.text = next.text[2:]
+				} else {
+					next.text = strings.TrimPrefix(next.text, "\n")
+				}
+			}
+		}
+	}
+	return toks, nil
+}
+
+// jinjaNextTag returns the offset of the next tag opening at or after i, or -1.
+func jinjaNextTag(src string, i int) int {
+	for {
+		k := strings.IndexByte(src[i:], '{')
+		if k < 0 || i+k+1 >= len(src) {
+			return -1
+		}
+		i += k
+		switch src[i+1] {
+		case '{', '%', '#':
+			return i
+		}
+		i++
+	}
+}
+
+// jinjaFindClose returns the offset of the closing delimiter after i,
+// skipping quoted strings inside expressions, or -1.
+func jinjaFindClose(src string, i int, closing string, quotes bool) int {
+	var quote byte
+	for ; i+len(closing) <= len(src); i++ {
+		c := src[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case quotes && (c == '\'' || c == '"'):
+			quote = c
+		case strings.HasPrefix(src[i:], closing):
+			return i
+		}
+	}
+	return -1
+}
+
+// Template nodes.
+type (
+	jinjaNode any
+
+	jinjaOutput struct {
+		expr jinjaExpr
+		line int
+	}
+	jinjaIf struct {
+		conds  []jinjaExpr
+		lines  []int
+		bodies [][]jinjaNode
+		els    []jinjaNode
+	}
+	jinjaFor struct {
+		vars      []string
+		iter      jinjaExpr
+		cond      jinjaExpr // nil: every item
+		body, els []jinjaNode
+		line      int
+	}
+	jinjaSet struct {
+		names []string
+		expr  jinjaExpr
+		line  int
+	}
+	jinjaInclude struct {
+		name          jinjaExpr
+		ignoreMissing bool
+		line          int
+	}
+	jinjaMacro struct {
+		name     string
+		params   []string
+		defaults map[string]jinjaExpr
+		body     []jinjaNode
+		line     int
+	}
+)
+
+type jinjaParser struct {
+	name string
+	toks []jinjaTok
+	pos  int
+}
+
+// parseJinja parses the template src called name.
+func parseJinja(name, src string) ([]jinjaNode, error) {
+	toks, err := lexJinja(name, src)
+	if err != nil {
+		return nil, err
+	}
+	p := &jinjaParser{name: name, toks: toks}
+	body, end, err := p.body()
+	if err != nil {
+		return nil, err
+	}
+	if end != nil {
+		return nil, p.errorf(end.line, "unexpected {%% %s %%}", end.text)
+	}
+	return body, nil
+}
+
+func (p *jinjaParser) errorf(line int, format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", p.name, line, fmt.Sprintf(format, args...))
+}
+
+// body parses nodes up to the end of the template or a tag ending a block
+// (endif, else, ...), which it returns.
+func (p *jinjaParser) body() ([]jinjaNode, *jinjaTok, error) {
+	var nodes []jinjaNode
+	for p.pos < len(p.toks) {
+		t := &p.toks[p.pos]
+		p.pos++
+		switch t.kind {
+		case jinjaText:
+			nodes = append(nodes, t.text)
+		case jinjaVar:
+			e, err := p.expr(t, t.text)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, &jinjaOutput{expr: e, line: t.line})
+		case jinjaStmt:
+			keyword, rest, _ := strings.Cut(t.text, " ")
+			rest = strings.TrimSpace(rest)
+			var n jinjaNode
+			var err error
+			switch keyword {
+			case "if":
+				n, err = p.ifStmt(t, rest)
+			case "for":
+				n, err = p.forStmt(t, rest)
+			case "set":
+				n, err = p.setStmt(t, rest)
+			case "include":
+				n, err = p.includeStmt(t, rest)
+			case "macro":
+				n, err = p.macroStmt(t, rest)
+			case "raw", "endraw":
+				continue
+			case "elif", "else", "endif", "endfor", "endmacro":
+				return nodes, t, nil
+			case "block", "extends", "import", "from", "call", "filter", "with", "macro_call":
+				return nil, nil, p.errorf(t.line, "{%% %s %%} is not supported by the jinja engine", keyword)
+			default:
+				return nil, nil, p.errorf(t.line, "unknown tag {%% %s %%}", keyword)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes, nil, nil
+}
+
+// expect parses a body that must end with one of the tags ends.
+func (p *jinjaParser) expect(open *jinjaTok, ends ...string) ([]jinjaNode, *jinjaTok, error) {
+	body, end, err := p.body()
+	if err != nil {
+		return nil, nil, err
+	}
+	if end == nil {
+		return nil, nil, p.errorf(open.line, "{%% %s %%} is not closed with {%% %s %%}", open.text, ends[len(ends)-1])
+	}
+	keyword, _, _ := strings.Cut(end.text, " ")
+	for _, e := range ends {
+		if keyword == e {
+			return body, end, nil
+		}
+	}
+	return nil, nil, p.errorf(end.line, "unexpected {%% %s %%}", end.text)
+}
+
+func (p *jinjaParser) ifStmt(t *jinjaTok, cond string) (jinjaNode, error) {
+	n := &jinjaIf{}
+	line := t.line
+	for {
+		c, err := p.expr(&jinjaTok{line: line}, cond)
+		if err != nil {
+			return nil, err
+		}
+		body, end, err := p.expect(t, "elif", "else", "endif")
+		if err != nil {
+			return nil, err
+		}
+		n.conds = append(n.conds, c)
+		n.lines = append(n.lines, line)
+		n.bodies = append(n.bodies, body)
+		keyword, rest, _ := strings.Cut(end.text, " ")
+		switch keyword {
+		case "elif":
+			cond, line = strings.TrimSpace(rest), end.line
+			continue
+		case "else":
+			if n.els, _, err = p.expect(t, "endif"); err != nil {
+				return nil, err
+			}
+		}
+		return n, nil
+	}
+}
+
+func (p *jinjaParser) forStmt(t *jinjaTok, spec string) (jinjaNode, error) {
+	x, err := newJinjaExprParser(spec)
+	if err != nil {
+		return nil, p.errorf(t.line, "%v", err)
+	}
+	n := &jinjaFor{line: t.line}
+	for {
+		name := x.next()
+		if name.kind != 'n' {
+			return nil, p.errorf(t.line, "for: expected a loop variable, got %q", name.s)
+		}
+		n.vars = append(n.vars, name.s)
+		if !x.accept(",") {
+			break
+		}
+	}
+	if !x.acceptName("in") {
+		return nil, p.errorf(t.line, "for: expected in after %s", strings.Join(n.vars, ", "))
+	}
+	if n.iter, err = x.or(); err == nil && x.acceptName("if") {
+		n.cond, err = x.or()
+	}
+	if err == nil {
+		err = x.end()
+	}
+	if err != nil {
+		return nil, p.errorf(t.line, "%v", err)
+	}
+	body, end, err := p.expect(t, "else", "endfor")
+	if err != nil {
+		return nil, err
+	}
+	n.body = body
+	if end.text == "else" {
+		if n.els, _, err = p.expect(t, "endfor"); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func (p *jinjaParser) setStmt(t *jinjaTok, spec string) (jinjaNode, error) {
+	lhs, rhs, ok := strings.Cut(spec, "=")
+	if !ok {
+		return nil, p.errorf(t.line, "set: expected name = value (block set is not supported)")
+	}
+	n := &jinjaSet{line: t.line}
+	for _, name := range strings.Split(lhs, ",") {
+		name = strings.TrimSpace(name)
+		if !jinjaIdent.MatchString(name) {
+			return nil, p.errorf(t.line, "set: %q is not a variable name", name)
+		}
+		n.names = append(n.names, name)
+	}
+	var err error
+	if n.expr, err = p.expr(t, rhs); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+var jinjaIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (p *jinjaParser) includeStmt(t *jinjaTok, spec string) (jinjaNode, error) {
+	n := &jinjaInclude{line: t.line}
+	for _, suffix := range []string{"with context", "without context"} {
+		spec = strings.TrimSpace(strings.TrimSuffix(spec, suffix))
+	}
+	if rest, ok := strings.CutSuffix(spec, "ignore missing"); ok {
+		n.ignoreMissing, spec = true, strings.TrimSpace(rest)
+	}
+	var err error
+	if n.name, err = p.expr(t, spec); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// macroStmt parses {% macro name(param, param=default) %} and its body.
+func (p *jinjaParser) macroStmt(t *jinjaTok, spec string) (jinjaNode, error) {
+	x, err := newJinjaExprParser(spec)
+	if err != nil {
+		return nil, p.errorf(t.line, "%v", err)
+	}
+	name := x.next()
+	if name.kind != 'n' {
+		return nil, p.errorf(t.line, "macro: expected a name, got %q", name.s)
+	}
+	n := &jinjaMacro{name: name.s, defaults: map[string]jinjaExpr{}, line: t.line}
+	if err := x.expectOp("("); err != nil {
+		return nil, p.errorf(t.line, "macro %s: %v", n.name, err)
+	}
+	for !x.accept(")") {
+		if len(n.params) > 0 {
+			if err := x.expectOp(","); err != nil {
+				return nil, p.errorf(t.line, "macro %s: %v", n.name, err)
+			}
+		}
+		param := x.next()
+		if param.kind != 'n' {
+			return nil, p.errorf(t.line, "macro %s: expected a parameter name, got %q", n.name, param.s)
+		}
+		n.params = append(n.params, param.s)
+		if x.accept("=") {
+			if n.defaults[param.s], err = x.expr(); err != nil {
+				return nil, p.errorf(t.line, "macro %s: %v", n.name, err)
+			}
+		} else if len(n.defaults) > 0 {
+			return nil, p.errorf(t.line, "macro %s: parameter %s without a default follows one with a default", n.name, param.s)
+		}
+	}
+	if err := x.end(); err != nil {
+		return nil, p.errorf(t.line, "macro %s: %v", n.name, err)
+	}
+	if n.body, _, err = p.expect(t, "endmacro"); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// expr parses the whole of src as an expression.
+func (p *jinjaParser) expr(t *jinjaTok, src string) (jinjaExpr, error) {
+	x, err := newJinjaExprParser(src)
+	if err == nil {
+		var e jinjaExpr
+		if e, err = x.expr(); err == nil {
+			if err = x.end(); err == nil {
+				return e, nil
+			}
+		}
+	}
+	return nil, p.errorf(t.line, "%v", err)
+}
+
+// Expression tokens: kind is 'n' (name), '0' (number), 's' (string), 'o'
+// (operator or punctuation) or 0 at the end.
+type jinjaExprTok struct {
+	kind byte
+	s    string
+	v    any // value of numbers and strings
+}
+
+var jinjaOps = []string{"==", "!=", "<=", ">=", "//", "**", "+", "-", "*", "/", "%", "~", "<", ">", "=", "(", ")", "[", "]", "{", "}", ",", ".", "|", ":"}
+
+func lexJinjaExpr(src string) ([]jinjaExprTok, error) {
+	var toks []jinjaExprTok
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || src[j] >= 'a' && src[j] <= 'z' || src[j] >= 'A' && src[j] <= 'Z' || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			toks = append(toks, jinjaExprTok{kind: 'n', s: src[i:j]})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i
+			float := false
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '_' ||
+				src[j] == '.' && !float && j+1 < len(src) && src[j+1] >= '0' && src[j+1] <= '9') {
+				float = float || src[j] == '.'
+				j++
+			}
+			text := strings.ReplaceAll(src[i:j], "_", "")
+			tok := jinjaExprTok{kind: '0', s: src[i:j]}
+			if float {
+				tok.v, _ = strconv.ParseFloat(text, 64)
+			} else {
+				n, err := strconv.ParseInt(text, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("bad number %s", src[i:j])
+				}
+				tok.v = int(n)
+			}
+			toks = append(toks, tok)
+			i = j
+		case c == '\'' || c == '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+					switch src[j] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					case 'r':
+						b.WriteByte('\r')
+					default:
+						b.WriteByte(src[j])
+					}
+					continue
+				}
+				b.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string %s", src[i:])
+			}
+			toks = append(toks, jinjaExprTok{kind: 's', s: src[i : j+1], v: b.String()})
+			i = j + 1
+		default:
+			op := ""
+			for _, o := range jinjaOps {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			toks = append(toks, jinjaExprTok{kind: 'o', s: op})
+			i += len(op)
+		}
+	}
+	return toks, nil
+}
+
+// jinjaExprParser parses expressions, from the loosest binding (inline
+// if/else) to the tightest (attribute access), as Jinja does.
+type jinjaExprParser struct {
+	toks []jinjaExprTok
+	pos  int
+}
+
+func newJinjaExprParser(src string) (*jinjaExprParser, error) {
+	toks, err := lexJinjaExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	return &jinjaExprParser{toks: toks}, nil
+}
+
+func (x *jinjaExprParser) peek() jinjaExprTok {
+	if x.pos < len(x.toks) {
+		return x.toks[x.pos]
+	}
+	return jinjaExprTok{}
+}
+
+func (x *jinjaExprParser) next() jinjaExprTok {
+	t := x.peek()
+	if x.pos < len(x.toks) {
+		x.pos++
+	}
+	return t
+}
+
+// accept consumes the operator op if it comes next.
+func (x *jinjaExprParser) accept(op string) bool {
+	if t := x.peek(); t.kind == 'o' && t.s == op {
+		x.pos++
+		return true
+	}
+	return false
+}
+
+// acceptName consumes the keyword name if it comes next.
+func (x *jinjaExprParser) acceptName(name string) bool {
+	if t := x.peek(); t.kind == 'n' && t.s == name {
+		x.pos++
+		return true
+	}
+	return false
+}
+
+func (x *jinjaExprParser) expectOp(op string) error {
+	if !x.accept(op) {
+		return fmt.Errorf("expected %s, got %s", op, x.describe())
+	}
+	return nil
+}
+
+func (x *jinjaExprParser) describe() string {
+	if t := x.peek(); t.kind != 0 {
+		return strconv.Quote(t.s)
+	}
+	return "end of expression"
+}
+
+func (x *jinjaExprParser) end() error {
+	if x.pos < len(x.toks) {
+		return fmt.Errorf("unexpected %s", x.describe())
+	}
+	return nil
+}
+
+func (x *jinjaExprParser) expr() (jinjaExpr, error) {
+	e, err := x.or()
+	if err != nil || !x.acceptName("if") {
+		return e, err
+	}
+	cond, err := x.or()
+	if err != nil {
+		return nil, err
+	}
+	var els jinjaExpr = jinjaLit{jinjaUndefined{"else"}} // as in Jinja
+	if x.acceptName("else") {
+		if els, err = x.expr(); err != nil {
+			return nil, err
+		}
+	}
+	return jinjaCond{cond: cond, then: e, els: els}, nil
+}
+
+func (x *jinjaExprParser) or() (jinjaExpr, error) {
+	e, err := x.and()
+	for err == nil && x.acceptName("or") {
+		var r jinjaExpr
+		if r, err = x.and(); err == nil {
+			e = jinjaBinary{op: "or", l: e, r: r}
+		}
+	}
+	return e, err
+}
+
+func (x *jinjaExprParser) and() (jinjaExpr, error) {
+	e, err := x.not()
+	for err == nil && x.acceptName("and") {
+		var r jinjaExpr
+		if r, err = x.not(); err == nil {
+			e = jinjaBinary{op: "and", l: e, r: r}
+		}
+	}
+	return e, err
+}
+
+func (x *jinjaExprParser) not() (jinjaExpr, error) {
+	if x.acceptName("not") {
+		e, err := x.not()
+		return jinjaUnary{op: "not", x: e}, err
+	}
+	return x.compare()
+}
+
+func (x *jinjaExprParser) compare() (jinjaExpr, error) {
+	e, err := x.concat()
+	for err == nil {
+		t := x.peek()
+		var op string
+		switch {
+		case t.kind == 'o' && (t.s == "==" || t.s == "!=" || t.s == "<" || t.s == "<=" || t.s == ">" || t.s == ">="):
+			op = t.s
+			x.pos++
+		case t.kind == 'n' && t.s == "in":
+			op = "in"
+			x.pos++
+		case t.kind == 'n' && t.s == "not" && x.pos+1 < len(x.toks) && x.toks[x.pos+1].kind == 'n' && x.toks[x.pos+1].s == "in":
+			op = "not in"
+			x.pos += 2
+		case t.kind == 'n' && t.s == "is":
+			x.pos++
+			e, err = x.test(e)
+			continue
+		default:
+			return e, nil
+		}
+		var r jinjaExpr
+		if r, err = x.concat(); err == nil {
+			e = jinjaBinary{op: op, l: e, r: r}
+		}
+	}
+	return e, err
+}
+
+// test parses the test after "is": [not] name [args].
+func (x *jinjaExprParser) test(subject jinjaExpr) (jinjaExpr, error) {
+	t := jinjaTest{x: subject, negate: x.acceptName("not")}
+	name := x.next()
+	if name.kind != 'n' {
+		return nil, fmt.Errorf("expected a test name after is, got %q", name.s)
+	}
+	t.name = name.s
+	if _, ok := jinjaTests[t.name]; !ok {
+		return nil, fmt.Errorf("unknown test %q", t.name)
+	}
+	switch next := x.peek(); {
+	case next.kind == 'o' && next.s == "(":
+		x.pos++
+		args, _, err := x.args()
+		if err != nil {
+			return nil, err
+		}
+		t.args = args
+	case next.kind == '0' || next.kind == 's' || next.kind == 'n' && next.s != "and" && next.s != "or" && next.s != "else" && next.s != "if":
+		// a single argument without parentheses: x is divisibleby 3
+		arg, err := x.postfix()
+		if err != nil {
+			return nil, err
+		}
+		t.args = []jinjaExpr{arg}
+	}
+	return t, nil
+}
+
+func (x *jinjaExprParser) concat() (jinjaExpr, error) {
+	e, err := x.additive()
+	for err == nil && x.accept("~") {
+		var r jinjaExpr
+		if r, err = x.additive(); err == nil {
+			e = jinjaBinary{op: "~", l: e, r: r}
+		}
+	}
+	return e, err
+}
+
+func (x *jinjaExprParser) additive() (jinjaExpr, error) {
+	e, err := x.multiplicative()
+	for err == nil {
+		t := x.peek()
+		if t.kind != 'o' || (t.s != "+" && t.s != "-") {
+			break
+		}
+		x.pos++
+		var r jinjaExpr
+		if r, err = x.multiplicative(); err == nil {
+			e = jinjaBinary{op: t.s, l: e, r: r}
+		}
+	}
+	return e, err
+}
+
+func (x *jinjaExprParser) multiplicative() (jinjaExpr, error) {
+	e, err := x.unary()
+	for err == nil {
+		t := x.peek()
+		if t.kind != 'o' || (t.s != "*" && t.s != "/" && t.s != "//" && t.s != "%" && t.s != "**") {
+			break
+		}
+		x.pos++
+		var r jinjaExpr
+		if r, err = x.unary(); err == nil {
+			e = jinjaBinary{op: t.s, l: e, r: r}
+		}
+	}
+	return e, err
+}
+
+func (x *jinjaExprParser) unary() (jinjaExpr, error) {
+	if x.accept("-") {
+		e, err := x.unary()
+		return jinjaUnary{op: "-", x: e}, err
+	}
+	if x.accept("+") {
+		return x.unary()
+	}
+	return x.filtered()
+}
+
+// filtered parses a value followed by | filters.
+func (x *jinjaExprParser) filtered() (jinjaExpr, error) {
+	e, err := x.postfix()
+	for err == nil && x.accept("|") {
+		name := x.next()
+		if name.kind != 'n' {
+			return nil, fmt.Errorf("expected a filter name after |, got %q", name.s)
+		}
+		if _, ok := jinjaFilters[name.s]; !ok {
+			return nil, fmt.Errorf("unknown filter %q", name.s)
+		}
+		f := jinjaFilter{x: e, name: name.s}
+		if x.accept("(") {
+			if f.args, f.kwargs, err = x.args(); err != nil {
+				return nil, err
+			}
+		}
+		e = f
+	}
+	return e, err
+}
+
+// args parses call arguments up to the closing parenthesis, the opening one
+// consumed: positional ones first, then name=value ones.
+func (x *jinjaExprParser) args() ([]jinjaExpr, map[string]jinjaExpr, error) {
+	var args []jinjaExpr
+	var kwargs map[string]jinjaExpr
+	for !x.accept(")") {
+		if len(args) > 0 || len(kwargs) > 0 {
+			if err := x.expectOp(","); err != nil {
+				return nil, nil, err
+			}
+			if x.accept(")") {
+				break
+			}
+		}
+		if t := x.peek(); t.kind == 'n' && x.pos+1 < len(x.toks) && x.toks[x.pos+1].kind == 'o' && x.toks[x.pos+1].s == "=" {
+			x.pos += 2
+			v, err := x.expr()
+			if err != nil {
+				return nil, nil, err
+			}
+			if kwargs == nil {
+				kwargs = map[string]jinjaExpr{}
+			}
+			kwargs[t.s] = v
+			continue
+		}
+		if len(kwargs) > 0 {
+			return nil, nil, fmt.Errorf("positional argument after a keyword argument")
+		}
+		v, err := x.expr()
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, v)
+	}
+	return args, kwargs, nil
+}
+
+func (x *jinjaExprParser) postfix() (jinjaExpr, error) {
+	e, err := x.primary()
+	for err == nil {
+		switch {
+		case x.accept("."):
+			t := x.next()
+			if t.kind != 'n' && t.kind != '0' {
+				return nil, fmt.Errorf("expected an attribute name after ., got %q", t.s)
+			}
+			e = jinjaAttr{x: e, name: t.s}
+		case x.accept("["):
+			var idx jinjaExpr
+			if idx, err = x.expr(); err == nil {
+				err = x.expectOp("]")
+			}
+			e = jinjaIndex{x: e, index: idx}
+		case x.accept("("):
+			call := jinjaCall{fn: e}
+			call.args, call.kwargs, err = x.args()
+			e = call
+		default:
+			return e, nil
+		}
+	}
+	return e, err
+}
+
+func (x *jinjaExprParser) primary() (jinjaExpr, error) {
+	t := x.next()
+	switch t.kind {
+	case '0', 's':
+		return jinjaLit{t.v}, nil
+	case 'n':
+		switch t.s {
+		case "true", "True":
+			return jinjaLit{true}, nil
+		case "false", "False":
+			return jinjaLit{false}, nil
+		case "none", "None":
+			return jinjaLit{nil}, nil
+		}
+		return jinjaName{t.s}, nil
+	case 'o':
+		switch t.s {
+		case "(":
+			// a parenthesized expression, or a tuple, which is a list here
+			e, err := x.expr()
+			if err != nil || !x.accept(",") {
+				if err == nil {
+					err = x.expectOp(")")
+				}
+				return e, err
+			}
+			tuple := jinjaList{e}
+			for !x.accept(")") {
+				if len(tuple) > 1 {
+					if err := x.expectOp(","); err != nil {
+						return nil, err
+					}
+					if x.accept(")") {
+						break
+					}
+				}
+				if e, err = x.expr(); err != nil {
+					return nil, err
+				}
+				tuple = append(tuple, e)
+			}
+			return tuple, nil
+		case "[":
+			var list jinjaList
+			for !x.accept("]") {
+				if len(list) > 0 {
+					if err := x.expectOp(","); err != nil {
+						return nil, err
+					}
+					if x.accept("]") {
+						break
+					}
+				}
+				e, err := x.expr()
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, e)
+			}
+			return list, nil
+		case "{":
+			var d jinjaDict
+			for !x.accept("}") {
+				if len(d.keys) > 0 {
+					if err := x.expectOp(","); err != nil {
+						return nil, err
+					}
+					if x.accept("}") {
+						break
+					}
+				}
+				k, err := x.expr()
+				if err == nil {
+					err = x.expectOp(":")
+				}
+				var v jinjaExpr
+				if err == nil {
+					v, err = x.expr()
+				}
+				if err != nil {
+					return nil, err
+				}
+				d.keys, d.vals = append(d.keys, k), append(d.vals, v)
+			}
+			return d, nil
+		}
+	case 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q", t.s)
+}
@@ -0,0 +1,1064 @@
+package templr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jinjaUndefined is the value of a variable, attribute or key that does not
+// exist. It prints as nothing and is false, unless the engine is strict.
+type jinjaUndefined struct{ name string }
+
+// Expression nodes.
+type (
+	jinjaExpr interface{}
+
+	jinjaLit  struct{ v any }
+	jinjaName struct{ name string }
+	jinjaAttr struct {
+		x    jinjaExpr
+		name string
+	}
+	jinjaIndex struct{ x, index jinjaExpr }
+	jinjaCall  struct {
+		fn     jinjaExpr
+		args   []jinjaExpr
+		kwargs map[string]jinjaExpr
+	}
+	jinjaFilter struct {
+		x      jinjaExpr
+		name   string
+		args   []jinjaExpr
+		kwargs map[string]jinjaExpr
+	}
+	jinjaTest struct {
+		x      jinjaExpr
+		name   string
+		args   []jinjaExpr
+		negate bool
+	}
+	jinjaBinary struct {
+		op   string
+		l, r jinjaExpr
+	}
+	jinjaUnary struct {
+		op string
+		x  jinjaExpr
+	}
+	jinjaCond struct{ cond, then, els jinjaExpr }
+	jinjaList []jinjaExpr
+	jinjaDict struct{ keys, vals []jinjaExpr }
+)
+
+// jinjaRenderer executes parsed templates; scopes holds the variables,
+// innermost last.
+type jinjaRenderer struct {
+	engine *JinjaEngine
+	out    io.Writer
+	scopes []map[string]any
+	stack  []string // templates being rendered, for errors and includes
+	line   int      // line of the node being rendered
+	calls  int      // macro calls being rendered
+}
+
+// jinjaMacroValue is a macro defined by {% macro %}, with the scopes it was
+// defined in.
+type jinjaMacroValue struct {
+	*jinjaMacro
+	scopes []map[string]any
+}
+
+func (r *jinjaRenderer) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", r.stack[len(r.stack)-1], r.line, fmt.Sprintf(format, args...))
+}
+
+func (r *jinjaRenderer) render(nodes []jinjaNode) error {
+	for _, n := range nodes {
+		if err := r.node(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *jinjaRenderer) node(n jinjaNode) error {
+	switch n := n.(type) {
+	case string:
+		_, err := io.WriteString(r.out, n)
+		return err
+	case *jinjaOutput:
+		r.line = n.line
+		v, err := r.eval(n.expr)
+		if err != nil {
+			return err
+		}
+		if err := r.defined(v); err != nil {
+			return err
+		}
+		_, err = io.WriteString(r.out, jinjaString(v))
+		return err
+	case *jinjaIf:
+		for i, c := range n.conds {
+			r.line = n.lines[i]
+			v, err := r.eval(c)
+			if err != nil {
+				return err
+			}
+			if err := r.defined(v); err != nil {
+				return err
+			}
+			if jinjaTruth(v) {
+				return r.render(n.bodies[i])
+			}
+		}
+		return r.render(n.els)
+	case *jinjaFor:
+		return r.forLoop(n)
+	case *jinjaSet:
+		r.line = n.line
+		v, err := r.eval(n.expr)
+		if err != nil {
+			return err
+		}
+		return r.assign(n.names, v, r.scopes[len(r.scopes)-1])
+	case *jinjaInclude:
+		return r.include(n)
+	case *jinjaMacro:
+		scopes := append([]map[string]any{}, r.scopes...)
+		r.scopes[len(r.scopes)-1][n.name] = &jinjaMacroValue{jinjaMacro: n, scopes: scopes}
+	}
+	return nil
+}
+
+// assign sets names to v in scope, unpacking v when there are several.
+func (r *jinjaRenderer) assign(names []string, v any, scope map[string]any) error {
+	if len(names) == 1 {
+		scope[names[0]] = v
+		return nil
+	}
+	items, ok := jinjaSeq(v)
+	if !ok || len(items) != len(names) {
+		return r.errorf("cannot unpack %s into %d variables", jinjaRepr(v), len(names))
+	}
+	for i, name := range names {
+		scope[name] = items[i]
+	}
+	return nil
+}
+
+func (r *jinjaRenderer) forLoop(n *jinjaFor) error {
+	r.line = n.line
+	v, err := r.eval(n.iter)
+	if err != nil {
+		return err
+	}
+	if err := r.defined(v); err != nil {
+		return err
+	}
+	items, err := jinjaIterate(v)
+	if err != nil {
+		return r.errorf("for: %v", err)
+	}
+	scope := map[string]any{}
+	r.scopes = append(r.scopes, scope)
+	defer func() { r.scopes = r.scopes[:len(r.scopes)-1] }()
+
+	if n.cond != nil {
+		var kept []any
+		for _, item := range items {
+			if err := r.assign(n.vars, item, scope); err != nil {
+				return err
+			}
+			c, err := r.eval(n.cond)
+			if err != nil {
+				return err
+			}
+			if jinjaTruth(c) {
+				kept = append(kept, item)
+			}
+		}
+		items = kept
+	}
+	if len(items) == 0 {
+		return r.render(n.els)
+	}
+	for i, item := range items {
+		clear(scope)
+		if err := r.assign(n.vars, item, scope); err != nil {
+			return err
+		}
+		scope["loop"] = map[string]any{
+			"index":     i + 1,
+			"index0":    i,
+			"revindex":  len(items) - i,
+			"revindex0": len(items) - i - 1,
+			"first":     i == 0,
+			"last":      i == len(items)-1,
+			"length":    len(items),
+		}
+		if err := r.render(n.body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *jinjaRenderer) include(n *jinjaInclude) error {
+	r.line = n.line
+	v, err := r.eval(n.name)
+	if err != nil {
+		return err
+	}
+	name, ok := v.(string)
+	if !ok {
+		return r.errorf("include: template name must be a string, got %s", jinjaRepr(v))
+	}
+	if len(r.stack) > jinjaMaxIncludeDepth {
+		return r.errorf("include depth limit %d exceeded: %s", jinjaMaxIncludeDepth, strings.Join(r.stack, " -> "))
+	}
+	if r.engine.Loader == nil {
+		return r.errorf("include %s: templates cannot be included here", name)
+	}
+	src, err := r.engine.Loader(name)
+	if err != nil {
+		if n.ignoreMissing {
+			return nil
+		}
+		return r.errorf("include %s: %v", name, err)
+	}
+	body, err := parseJinja(name, src)
+	if err != nil {
+		return err
+	}
+	// the included template sees the variables but its own sets stay inside
+	line := r.line
+	r.stack = append(r.stack, name)
+	r.scopes = append(r.scopes, map[string]any{})
+	r.line = 1
+	err = r.render(body)
+	r.stack, r.scopes, r.line = r.stack[:len(r.stack)-1], r.scopes[:len(r.scopes)-1], line
+	return err
+}
+
+// defined fails on an undefined value when the engine is strict.
+func (r *jinjaRenderer) defined(v any) error {
+	if u, ok := v.(jinjaUndefined); ok && r.engine.Strict {
+		return r.errorf("%q is undefined", u.name)
+	}
+	return nil
+}
+
+func (r *jinjaRenderer) lookup(name string) any {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if v, ok := r.scopes[i][name]; ok {
+			return v
+		}
+	}
+	return jinjaUndefined{name}
+}
+
+func (r *jinjaRenderer) eval(e jinjaExpr) (any, error) {
+	switch e := e.(type) {
+	case jinjaLit:
+		return e.v, nil
+	case jinjaName:
+		return r.lookup(e.name), nil
+	case jinjaAttr:
+		x, err := r.eval(e.x)
+		if err != nil {
+			return nil, err
+		}
+		return jinjaGet(x, e.name, jinjaExprName(e)), nil
+	case jinjaIndex:
+		x, err := r.eval(e.x)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := r.eval(e.index)
+		if err != nil {
+			return nil, err
+		}
+		return jinjaGet(x, idx, jinjaExprName(e)), nil
+	case jinjaCall:
+		return r.call(e)
+	case jinjaFilter:
+		return r.filter(e)
+	case jinjaTest:
+		x, err := r.eval(e.x)
+		if err != nil {
+			return nil, err
+		}
+		args, err := r.evalAll(e.args)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := jinjaTests[e.name](x, args)
+		if err != nil {
+			return nil, r.errorf("test %s: %v", e.name, err)
+		}
+		return ok != e.negate, nil
+	case jinjaCond:
+		c, err := r.eval(e.cond)
+		if err != nil {
+			return nil, err
+		}
+		if jinjaTruth(c) {
+			return r.eval(e.then)
+		}
+		return r.eval(e.els)
+	case jinjaUnary:
+		x, err := r.eval(e.x)
+		if err != nil {
+			return nil, err
+		}
+		if e.op == "not" {
+			return !jinjaTruth(x), nil
+		}
+		if err := r.defined(x); err != nil {
+			return nil, err
+		}
+		v, err := jinjaArith("-", 0, x)
+		if err != nil {
+			return nil, r.errorf("%v", err)
+		}
+		return v, nil
+	case jinjaBinary:
+		return r.binary(e)
+	case jinjaList:
+		list, err := r.evalAll(e)
+		if list == nil && err == nil {
+			list = []any{}
+		}
+		return list, err
+	case jinjaDict:
+		m := make(map[string]any, len(e.keys))
+		for i := range e.keys {
+			k, err := r.eval(e.keys[i])
+			if err != nil {
+				return nil, err
+			}
+			v, err := r.eval(e.vals[i])
+			if err != nil {
+				return nil, err
+			}
+			m[jinjaString(k)] = v
+		}
+		return m, nil
+	}
+	return nil, r.errorf("cannot evaluate %T", e)
+}
+
+func (r *jinjaRenderer) evalAll(exprs []jinjaExpr) ([]any, error) {
+	var vals []any
+	for _, e := range exprs {
+		v, err := r.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+func (r *jinjaRenderer) evalKw(kwargs map[string]jinjaExpr) (map[string]any, error) {
+	kw := make(map[string]any, len(kwargs))
+	for k, e := range kwargs {
+		v, err := r.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		kw[k] = v
+	}
+	return kw, nil
+}
+
+func (r *jinjaRenderer) binary(e jinjaBinary) (any, error) {
+	l, err := r.eval(e.l)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "and":
+		if !jinjaTruth(l) {
+			return l, nil
+		}
+		return r.eval(e.r)
+	case "or":
+		if jinjaTruth(l) {
+			return l, nil
+		}
+		return r.eval(e.r)
+	}
+	rv, err := r.eval(e.r)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.defined(l); err != nil {
+		return nil, err
+	}
+	if err := r.defined(rv); err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "~":
+		return jinjaString(l) + jinjaString(rv), nil
+	case "==":
+		return jinjaEqual(l, rv), nil
+	case "!=":
+		return !jinjaEqual(l, rv), nil
+	case "<", "<=", ">", ">=":
+		c, err := jinjaCompare(l, rv)
+		if err != nil {
+			return nil, r.errorf("%v", err)
+		}
+		switch e.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	case "in", "not in":
+		in, err := jinjaContains(rv, l)
+		if err != nil {
+			return nil, r.errorf("%v", err)
+		}
+		return in == (e.op == "in"), nil
+	}
+	v, err := jinjaArith(e.op, l, rv)
+	if err != nil {
+		return nil, r.errorf("%v", err)
+	}
+	return v, nil
+}
+
+// call evaluates the global functions and the methods of values Ansible
+// templates use, such as d.items() and s.split(",").
+func (r *jinjaRenderer) call(e jinjaCall) (any, error) {
+	args, err := r.evalAll(e.args)
+	if err != nil {
+		return nil, err
+	}
+	kw, err := r.evalKw(e.kwargs)
+	if err != nil {
+		return nil, err
+	}
+	switch fn := e.fn.(type) {
+	case jinjaName:
+		switch fn.name {
+		case "range":
+			return jinjaRange(args)
+		case "dict":
+			return kw, nil
+		}
+		if m, ok := r.lookup(fn.name).(*jinjaMacroValue); ok {
+			return r.callMacro(m, args, kw)
+		}
+		return nil, r.errorf("unknown function %s()", fn.name)
+	case jinjaAttr:
+		recv, err := r.eval(fn.x)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.defined(recv); err != nil {
+			return nil, err
+		}
+		v, err := jinjaMethod(recv, fn.name, args)
+		if err != nil {
+			return nil, r.errorf("%s(): %v", fn.name, err)
+		}
+		return v, nil
+	}
+	return nil, r.errorf("%s is not callable", jinjaExprName(e.fn))
+}
+
+// callMacro renders the body of macro m, in the scopes it was defined in
+// with its parameters bound to args and kw, and returns the output.
+// Parameters without a value or a default are undefined.
+func (r *jinjaRenderer) callMacro(m *jinjaMacroValue, args []any, kw map[string]any) (any, error) {
+	if len(args) > len(m.params) {
+		return nil, r.errorf("macro %s takes %d arguments, got %d", m.name, len(m.params), len(args))
+	}
+	if r.calls >= jinjaMaxIncludeDepth {
+		return nil, r.errorf("macro %s: call depth limit %d exceeded", m.name, jinjaMaxIncludeDepth)
+	}
+	scope := map[string]any{}
+	for name, v := range kw {
+		if !slices.Contains(m.params, name) {
+			return nil, r.errorf("macro %s has no parameter %s", m.name, name)
+		}
+		scope[name] = v
+	}
+	for i, v := range args {
+		if _, ok := kw[m.params[i]]; ok {
+			return nil, r.errorf("macro %s got %s twice", m.name, m.params[i])
+		}
+		scope[m.params[i]] = v
+	}
+
+	var buf bytes.Buffer
+	scopes, out, line := r.scopes, r.out, r.line
+	r.scopes = append(m.scopes[:len(m.scopes):len(m.scopes)], scope)
+	r.out = &limitedBuffer{buf: &buf, limit: r.engine.MaxOutputSize}
+	r.calls++
+	defer func() { r.scopes, r.out, r.line, r.calls = scopes, out, line, r.calls-1 }()
+
+	r.line = m.line
+	for _, name := range m.params {
+		if _, ok := scope[name]; ok {
+			continue
+		}
+		if def, ok := m.defaults[name]; ok {
+			v, err := r.eval(def)
+			if err != nil {
+				return nil, err
+			}
+			scope[name] = v
+		} else {
+			scope[name] = jinjaUndefined{name}
+		}
+	}
+	if err := r.render(m.body); err != nil {
+		return nil, err
+	}
+	return buf.String(), nil
+}
+
+func (r *jinjaRenderer) filter(e jinjaFilter) (any, error) {
+	x, err := r.eval(e.x)
+	if err != nil {
+		return nil, err
+	}
+	args, err := r.evalAll(e.args)
+	if err != nil {
+		return nil, err
+	}
+	kw, err := r.evalKw(e.kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if !jinjaUndefinedOK[e.name] {
+		if err := r.defined(x); err != nil {
+			return nil, err
+		}
+	}
+	v, err := jinjaFilters[e.name](x, args, kw)
+	if err != nil {
+		return nil, r.errorf("filter %s: %v", e.name, err)
+	}
+	return v, nil
+}
+
+// jinjaExprName describes a variable expression for undefined errors.
+func jinjaExprName(e jinjaExpr) string {
+	switch e := e.(type) {
+	case jinjaName:
+		return e.name
+	case jinjaAttr:
+		return jinjaExprName(e.x) + "." + e.name
+	case jinjaIndex:
+		if lit, ok := e.index.(jinjaLit); ok {
+			return jinjaExprName(e.x) + "[" + jinjaRepr(lit.v) + "]"
+		}
+		return jinjaExprName(e.x) + "[...]"
+	}
+	return "value"
+}
+
+// jinjaGet returns the attribute or item key of x, undefined when it has none.
+func jinjaGet(x, key any, name string) any {
+	switch v := x.(type) {
+	case jinjaUndefined:
+		return jinjaUndefined{name}
+	case map[string]any:
+		if val, ok := v[jinjaString(key)]; ok {
+			return val
+		}
+		return jinjaUndefined{name}
+	}
+	rv := reflect.ValueOf(x)
+	switch rv.Kind() {
+	case reflect.Map:
+		k := reflect.ValueOf(key)
+		if s, ok := key.(string); ok && rv.Type().Key().Kind() == reflect.String {
+			k = reflect.ValueOf(s).Convert(rv.Type().Key())
+		}
+		if k.IsValid() && k.Type().AssignableTo(rv.Type().Key()) {
+			if val := rv.MapIndex(k); val.IsValid() {
+				return val.Interface()
+			}
+		}
+	case reflect.Slice, reflect.Array, reflect.String:
+		i, ok := jinjaInt(key)
+		if !ok {
+			if s, isStr := key.(string); isStr {
+				n, err := strconv.Atoi(s)
+				i, ok = n, err == nil
+			}
+		}
+		if ok {
+			if i < 0 {
+				i += rv.Len()
+			}
+			if i >= 0 && i < rv.Len() {
+				if rv.Kind() == reflect.String {
+					return string(rv.String()[i])
+				}
+				return rv.Index(i).Interface()
+			}
+		}
+	}
+	return jinjaUndefined{name}
+}
+
+// jinjaMethod calls a Python method on a value.
+func jinjaMethod(recv any, name string, args []any) (any, error) {
+	arg := func(i int) string {
+		if i < len(args) {
+			return jinjaString(args[i])
+		}
+		return ""
+	}
+	if m, ok := jinjaMap(recv); ok {
+		switch name {
+		case "items":
+			items := make([]any, 0, len(m))
+			for _, k := range jinjaSortedKeys(m) {
+				items = append(items, []any{k, m[k]})
+			}
+			return items, nil
+		case "keys":
+			keys := make([]any, 0, len(m))
+			for _, k := range jinjaSortedKeys(m) {
+				keys = append(keys, k)
+			}
+			return keys, nil
+		case "values":
+			vals := make([]any, 0, len(m))
+			for _, k := range jinjaSortedKeys(m) {
+				vals = append(vals, m[k])
+			}
+			return vals, nil
+		case "get":
+			if v, ok := m[arg(0)]; ok {
+				return v, nil
+			}
+			if len(args) > 1 {
+				return args[1], nil
+			}
+			return nil, nil
+		}
+	}
+	if s, ok := recv.(string); ok {
+		switch name {
+		case "upper":
+			return strings.ToUpper(s), nil
+		case "lower":
+			return strings.ToLower(s), nil
+		case "strip":
+			if len(args) > 0 {
+				return strings.Trim(s, arg(0)), nil
+			}
+			return strings.TrimSpace(s), nil
+		case "startswith":
+			return strings.HasPrefix(s, arg(0)), nil
+		case "endswith":
+			return strings.HasSuffix(s, arg(0)), nil
+		case "replace":
+			return strings.ReplaceAll(s, arg(0), arg(1)), nil
+		case "split":
+			var parts []string
+			if len(args) == 0 {
+				parts = strings.Fields(s)
+			} else {
+				parts = strings.Split(s, arg(0))
+			}
+			return jinjaStrings(parts), nil
+		case "format":
+			return fmt.Sprintf(strings.ReplaceAll(s, "%s", "%v"), args...), nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no method %s", jinjaTypeName(recv), name)
+}
+
+func jinjaRange(args []any) (any, error) {
+	ints := make([]int, len(args))
+	for i, a := range args {
+		n, ok := jinjaInt(a)
+		if !ok {
+			return nil, fmt.Errorf("range: %s is not an integer", jinjaRepr(a))
+		}
+		ints[i] = n
+	}
+	start, stop, step := 0, 0, 1
+	switch len(ints) {
+	case 1:
+		stop = ints[0]
+	case 2:
+		start, stop = ints[0], ints[1]
+	case 3:
+		start, stop, step = ints[0], ints[1], ints[2]
+	default:
+		return nil, fmt.Errorf("range takes 1 to 3 arguments")
+	}
+	if step == 0 {
+		return nil, fmt.Errorf("range: step must not be zero")
+	}
+	list := []any{}
+	for i := start; step > 0 && i < stop || step < 0 && i > stop; i += step {
+		list = append(list, i)
+	}
+	return list, nil
+}
+
+// jinjaTruth reports whether v is true in a condition, as in Python.
+func jinjaTruth(v any) bool {
+	switch x := v.(type) {
+	case nil, jinjaUndefined:
+		return false
+	case bool:
+		return x
+	case string:
+		return x != ""
+	}
+	if f, ok := jinjaNumber(v); ok {
+		return f != 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return rv.Len() > 0
+	}
+	return true
+}
+
+// jinjaString formats v for output, as Python's str does.
+func jinjaString(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case jinjaUndefined:
+		return ""
+	}
+	return jinjaRepr(v)
+}
+
+// jinjaRepr formats v as Python's repr does: strings are quoted.
+func jinjaRepr(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "None"
+	case jinjaUndefined:
+		return "Undefined"
+	case bool:
+		if x {
+			return "True"
+		}
+		return "False"
+	case string:
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`).Replace(x) + "'"
+	case float32, float64:
+		f, _ := jinjaNumber(x)
+		s := strconv.FormatFloat(f, 'f', -1, 64)
+		if !strings.ContainsAny(s, ".eIN") {
+			s += ".0"
+		}
+		return s
+	}
+	if n, ok := jinjaInt(v); ok {
+		return strconv.Itoa(n)
+	}
+	if m, ok := jinjaMap(v); ok {
+		parts := make([]string, 0, len(m))
+		for _, k := range jinjaSortedKeys(m) {
+			parts = append(parts, jinjaRepr(k)+": "+jinjaRepr(m[k]))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+	if items, ok := jinjaSeq(v); ok {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = jinjaRepr(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+func jinjaTypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "None"
+	case jinjaUndefined:
+		return "undefined"
+	case string:
+		return "str"
+	case bool:
+		return "bool"
+	case *jinjaMacroValue:
+		return "macro"
+	}
+	if _, ok := jinjaInt(v); ok {
+		return "int"
+	}
+	if _, ok := jinjaNumber(v); ok {
+		return "float"
+	}
+	if _, ok := jinjaMap(v); ok {
+		return "dict"
+	}
+	if _, ok := jinjaSeq(v); ok {
+		return "list"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// jinjaInt returns v as an int when it is an integer.
+func jinjaInt(v any) (int, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(rv.Uint()), true
+	}
+	return 0, false
+}
+
+// jinjaNumber returns v as a float64 when it is a number.
+func jinjaNumber(v any) (float64, bool) {
+	if _, ok := v.(bool); ok {
+		return 0, false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	if n, ok := jinjaInt(v); ok {
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// jinjaMap returns v as a map with string keys when it is a map.
+func jinjaMap(v any) (map[string]any, bool) {
+	if m, ok := v.(map[string]any); ok {
+		return m, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map {
+		return nil, false
+	}
+	m := make(map[string]any, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		m[fmt.Sprint(iter.Key().Interface())] = iter.Value().Interface()
+	}
+	return m, true
+}
+
+// jinjaSeq returns v as a list when it is a slice or array.
+func jinjaSeq(v any) ([]any, bool) {
+	if s, ok := v.([]any); ok {
+		return s, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	s := make([]any, rv.Len())
+	for i := range s {
+		s[i] = rv.Index(i).Interface()
+	}
+	return s, true
+}
+
+// jinjaIterate returns the items a for loop visits: the items of a list,
+// the sorted keys of a map or the characters of a string.
+func jinjaIterate(v any) ([]any, error) {
+	switch x := v.(type) {
+	case nil, jinjaUndefined:
+		return nil, nil
+	case string:
+		items := make([]any, 0, len(x))
+		for _, c := range x {
+			items = append(items, string(c))
+		}
+		return items, nil
+	}
+	if items, ok := jinjaSeq(v); ok {
+		return items, nil
+	}
+	if m, ok := jinjaMap(v); ok {
+		keys := make([]any, 0, len(m))
+		for _, k := range jinjaSortedKeys(m) {
+			keys = append(keys, k)
+		}
+		return keys, nil
+	}
+	return nil, fmt.Errorf("%s is not iterable", jinjaTypeName(v))
+}
+
+func jinjaSortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func jinjaStrings(ss []string) []any {
+	list := make([]any, len(ss))
+	for i, s := range ss {
+		list[i] = s
+	}
+	return list
+}
+
+// jinjaEqual compares values as Python's == does: numbers by value.
+func jinjaEqual(a, b any) bool {
+	if _, ok := a.(jinjaUndefined); ok {
+		a = nil
+	}
+	if _, ok := b.(jinjaUndefined); ok {
+		b = nil
+	}
+	fa, aok := jinjaNumber(a)
+	fb, bok := jinjaNumber(b)
+	if aok && bok {
+		return fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// jinjaCompare orders two numbers or two strings.
+func jinjaCompare(a, b any) (int, error) {
+	fa, aok := jinjaNumber(a)
+	fb, bok := jinjaNumber(b)
+	if aok && bok {
+		switch {
+		case fa < fb:
+			return -1, nil
+		case fa > fb:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	sa, aok := a.(string)
+	sb, bok := b.(string)
+	if aok && bok {
+		return strings.Compare(sa, sb), nil
+	}
+	return 0, fmt.Errorf("cannot compare %s with %s", jinjaTypeName(a), jinjaTypeName(b))
+}
+
+// jinjaContains implements "item in container".
+func jinjaContains(container, item any) (bool, error) {
+	switch c := container.(type) {
+	case string:
+		return strings.Contains(c, jinjaString(item)), nil
+	case nil, jinjaUndefined:
+		return false, nil
+	}
+	if m, ok := jinjaMap(container); ok {
+		_, found := m[jinjaString(item)]
+		return found, nil
+	}
+	if items, ok := jinjaSeq(container); ok {
+		for _, x := range items {
+			if jinjaEqual(x, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("%s is not a container", jinjaTypeName(container))
+}
+
+// jinjaArith applies an arithmetic operator. + also joins strings and lists,
+// and % formats a string with b: the items of a list, as a tuple in Python,
+// the keys of a dict, or a single value.
+func jinjaArith(op string, a, b any) (any, error) {
+	if sa, ok := a.(string); ok && op == "%" {
+		if m, ok := jinjaMap(b); ok {
+			return jinjaPercent(sa, []any{b}, m)
+		}
+		if items, ok := jinjaSeq(b); ok {
+			return jinjaPercent(sa, items, nil)
+		}
+		return jinjaPercent(sa, []any{b}, nil)
+	}
+	if op == "+" {
+		if sa, ok := a.(string); ok {
+			if sb, ok := b.(string); ok {
+				return sa + sb, nil
+			}
+		}
+		if la, ok := jinjaSeq(a); ok {
+			if lb, ok := jinjaSeq(b); ok {
+				return append(append([]any{}, la...), lb...), nil
+			}
+		}
+	}
+	ia, aInt := jinjaInt(a)
+	ib, bInt := jinjaInt(b)
+	fa, aok := jinjaNumber(a)
+	fb, bok := jinjaNumber(b)
+	if !aok || !bok {
+		return nil, fmt.Errorf("unsupported operand types for %s: %s and %s", op, jinjaTypeName(a), jinjaTypeName(b))
+	}
+	ints := aInt && bInt
+	switch op {
+	case "+":
+		if ints {
+			return ia + ib, nil
+		}
+		return fa + fb, nil
+	case "-":
+		if ints {
+			return ia - ib, nil
+		}
+		return fa - fb, nil
+	case "*":
+		if ints {
+			return ia * ib, nil
+		}
+		return fa * fb, nil
+	case "**":
+		p := math.Pow(fa, fb)
+		if ints && ib >= 0 {
+			return int(p), nil
+		}
+		return p, nil
+	}
+	if fb == 0 {
+		return nil, errors.New("division by zero")
+	}
+	switch op {
+	case "/":
+		return fa / fb, nil
+	case "//":
+		if ints {
+			return int(math.Floor(fa / fb)), nil
+		}
+		return math.Floor(fa / fb), nil
+	case "%":
+		if ints {
+			return ((ia % ib) + ib) % ib, nil
+		}
+		return fa - fb*math.Floor(fa/fb), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", op)
+}
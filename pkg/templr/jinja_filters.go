@@ -0,0 +1,1107 @@
+package templr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jinjaFilterFunc applies a filter to v with the filter's arguments.
+type jinjaFilterFunc func(v any, args []any, kw map[string]any) (any, error)
+
+// jinjaUndefinedOK lists the filters that accept an undefined value in a
+// strict render.
+var jinjaUndefinedOK = map[string]bool{"default": true, "d": true, "mandatory": true}
+
+// jinjaFilters are the filters of the jinja engine: the Jinja built-ins
+// templates use most and the Ansible ones for data formats and regexps.
+var jinjaFilters map[string]jinjaFilterFunc
+
+func init() {
+	jinjaFilters = map[string]jinjaFilterFunc{
+		"default":    jinjaDefault,
+		"d":          jinjaDefault,
+		"mandatory":  jinjaMandatory,
+		"upper":      jinjaStringFilter(strings.ToUpper),
+		"lower":      jinjaStringFilter(strings.ToLower),
+		"capitalize": jinjaStringFilter(jinjaCapitalize),
+		"title":      jinjaStringFilter(jinjaTitle),
+		"trim":       jinjaStringFilter(strings.TrimSpace),
+		"string":     func(v any, _ []any, _ map[string]any) (any, error) { return jinjaString(v), nil },
+		"length":     jinjaLength,
+		"count":      jinjaLength,
+		"join":       jinjaJoin,
+		"replace":    jinjaReplace,
+		"format":     jinjaFormat,
+		"int":        jinjaToInt,
+		"float":      jinjaToFloat,
+		"bool":       jinjaToBool,
+		"abs":        jinjaAbs,
+		"round":      jinjaRound,
+		"list":       jinjaToList,
+		"first":      jinjaFirst,
+		"last":       jinjaLast,
+		"sort":       jinjaSort,
+		"unique":     jinjaUnique,
+		"reverse":    jinjaReverse,
+		"min":        jinjaMinMax(-1),
+		"max":        jinjaMinMax(1),
+		"sum":        jinjaSum,
+		"split":      jinjaSplit,
+		"indent":     jinjaIndent,
+		"quote":      jinjaStringFilter(jinjaShellQuote),
+		"basename":   jinjaStringFilter(path.Base),
+		"dirname":    jinjaStringFilter(path.Dir),
+		"b64encode": jinjaStringFilter(func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		}),
+		"b64decode":      jinjaB64Decode,
+		"regex_replace":  jinjaRegexReplace,
+		"regex_search":   jinjaRegexSearch,
+		"to_json":        jinjaToJSON(false),
+		"tojson":         jinjaToJSON(false),
+		"to_nice_json":   jinjaToJSON(true),
+		"to_yaml":        jinjaToYAML,
+		"to_nice_yaml":   jinjaToYAML,
+		"from_json":      jinjaFromJSON,
+		"from_yaml":      jinjaFromYAML,
+		"dict2items":     jinjaDict2Items,
+		"items2dict":     jinjaItems2Dict,
+		"combine":        jinjaCombine,
+		"map":            jinjaMapFilter,
+		"select":         jinjaSelect(true),
+		"reject":         jinjaSelect(false),
+		"selectattr":     jinjaSelectAttr(true),
+		"rejectattr":     jinjaSelectAttr(false),
+		"ternary":        jinjaTernary,
+		"items":          jinjaItems,
+		"dictsort":       jinjaItems,
+		"center":         jinjaCenter,
+		"wordcount":      jinjaWordCount,
+		"truncate":       jinjaTruncate,
+		"batch":          jinjaBatch,
+		"flatten":        jinjaFlatten,
+		"zip":            jinjaZip,
+		"difference":     jinjaSetOp("difference"),
+		"intersect":      jinjaSetOp("intersect"),
+		"union":          jinjaSetOp("union"),
+		"symmetric_diff": jinjaSetOp("symmetric_difference"),
+	}
+}
+
+func jinjaArg(args []any, kw map[string]any, i int, name string, def any) any {
+	if v, ok := kw[name]; ok {
+		return v
+	}
+	if i < len(args) {
+		return args[i]
+	}
+	return def
+}
+
+func jinjaStringFilter(fn func(string) string) jinjaFilterFunc {
+	return func(v any, _ []any, _ map[string]any) (any, error) {
+		return fn(jinjaString(v)), nil
+	}
+}
+
+// jinjaDefault is default(value, boolean=false): value (an empty string when
+// not given) when v is undefined, or with boolean when v is false.
+func jinjaDefault(v any, args []any, kw map[string]any) (any, error) {
+	_, undefined := v.(jinjaUndefined)
+	if undefined || jinjaTruth(jinjaArg(args, kw, 1, "boolean", false)) && !jinjaTruth(v) {
+		return jinjaArg(args, kw, 0, "default_value", ""), nil
+	}
+	return v, nil
+}
+
+func jinjaMandatory(v any, args []any, kw map[string]any) (any, error) {
+	if u, ok := v.(jinjaUndefined); ok {
+		if msg := jinjaArg(args, kw, 0, "msg", nil); msg != nil {
+			return nil, fmt.Errorf("%s", jinjaString(msg))
+		}
+		return nil, fmt.Errorf("mandatory variable %q not defined", u.name)
+	}
+	return v, nil
+}
+
+func jinjaCapitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(strings.ToLower(s))
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func jinjaTitle(s string) string {
+	r := []rune(s)
+	start := true
+	for i, c := range r {
+		switch {
+		case unicode.IsLetter(c) || unicode.IsDigit(c):
+			if start {
+				r[i] = unicode.ToUpper(c)
+			} else {
+				r[i] = unicode.ToLower(c)
+			}
+			start = false
+		default:
+			start = true
+		}
+	}
+	return string(r)
+}
+
+func jinjaLength(v any, _ []any, _ map[string]any) (any, error) {
+	switch x := v.(type) {
+	case string:
+		return len([]rune(x)), nil
+	case nil, jinjaUndefined:
+		return 0, nil
+	}
+	if m, ok := jinjaMap(v); ok {
+		return len(m), nil
+	}
+	if s, ok := jinjaSeq(v); ok {
+		return len(s), nil
+	}
+	return nil, fmt.Errorf("%s has no length", jinjaTypeName(v))
+}
+
+func jinjaJoin(v any, args []any, kw map[string]any) (any, error) {
+	items, err := jinjaIterate(v)
+	if err != nil {
+		return nil, err
+	}
+	sep := jinjaString(jinjaArg(args, kw, 0, "d", ""))
+	parts := make([]string, len(items))
+	for i, item := range items {
+		if attr, ok := kw["attribute"]; ok {
+			item = jinjaGet(item, attr, "")
+		}
+		parts[i] = jinjaString(item)
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// jinjaFormat formats the arguments, or the keyword arguments of %(name)s
+// conversions, with v as a printf-style format.
+func jinjaFormat(v any, args []any, kw map[string]any) (any, error) {
+	if len(kw) > 0 {
+		return jinjaPercent(jinjaString(v), nil, kw)
+	}
+	return jinjaPercent(jinjaString(v), args, nil)
+}
+
+// jinjaPercent formats args, or the values of named for %(name)s
+// conversions, as Python's % operator does with format: %s, %r, %d, %i,
+// %f, %e, %g, %x, %o, %c and %%, with flags, width and precision.
+func jinjaPercent(format string, args []any, named map[string]any) (string, error) {
+	var b strings.Builder
+	used := 0
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		j := i + 1
+		var arg any
+		haveArg := false
+		if j < len(format) && format[j] == '(' {
+			end := strings.IndexByte(format[j:], ')')
+			if end < 0 {
+				return "", fmt.Errorf("incomplete format key")
+			}
+			key := format[j+1 : j+end]
+			v, ok := named[key]
+			if !ok {
+				return "", fmt.Errorf("format key %q is not defined", key)
+			}
+			arg, haveArg = v, true
+			j += end + 1
+		}
+		spec := j
+		for j < len(format) && strings.IndexByte("-+ 0#", format[j]) >= 0 {
+			j++
+		}
+		for j < len(format) && (format[j] >= '0' && format[j] <= '9' || format[j] == '.') {
+			j++
+		}
+		if j >= len(format) {
+			return "", fmt.Errorf("incomplete format")
+		}
+		verb := format[j]
+		i = j
+		if verb == '%' {
+			b.WriteByte('%')
+			continue
+		}
+		if !haveArg {
+			if used >= len(args) {
+				return "", fmt.Errorf("not enough arguments for format string")
+			}
+			arg = args[used]
+			used++
+		}
+		flags := "%" + format[spec:j]
+		switch verb {
+		case 's':
+			fmt.Fprintf(&b, flags+"s", jinjaString(arg))
+		case 'r':
+			fmt.Fprintf(&b, flags+"s", jinjaRepr(arg))
+		case 'c':
+			if n, ok := jinjaInt(arg); ok {
+				arg = string(rune(n))
+			}
+			fmt.Fprintf(&b, flags+"s", jinjaString(arg))
+		case 'd', 'i', 'x', 'X', 'o':
+			f, ok := jinjaNumber(arg)
+			if !ok {
+				return "", fmt.Errorf("%%%c format: a number is required, not %s", verb, jinjaTypeName(arg))
+			}
+			if verb == 'i' {
+				verb = 'd'
+			}
+			fmt.Fprintf(&b, flags+string(verb), int(f))
+		case 'f', 'F', 'e', 'E', 'g', 'G':
+			f, ok := jinjaNumber(arg)
+			if !ok {
+				return "", fmt.Errorf("%%%c format: a number is required, not %s", verb, jinjaTypeName(arg))
+			}
+			if !strings.Contains(flags, ".") && (verb == 'f' || verb == 'F' || verb == 'e' || verb == 'E') {
+				flags += ".6"
+			}
+			fmt.Fprintf(&b, flags+string(verb), f)
+		default:
+			return "", fmt.Errorf("unsupported format character %q", verb)
+		}
+	}
+	if named == nil && used < len(args) {
+		return "", fmt.Errorf("not all arguments converted during string formatting")
+	}
+	return b.String(), nil
+}
+
+func jinjaReplace(v any, args []any, kw map[string]any) (any, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("replace takes the old and new strings")
+	}
+	n := -1
+	if c, ok := jinjaInt(jinjaArg(args, kw, 2, "count", nil)); ok {
+		n = c
+	}
+	return strings.Replace(jinjaString(v), jinjaString(args[0]), jinjaString(args[1]), n), nil
+}
+
+func jinjaToInt(v any, args []any, kw map[string]any) (any, error) {
+	def := jinjaArg(args, kw, 0, "default", 0)
+	if n, ok := jinjaInt(v); ok {
+		return n, nil
+	}
+	if f, ok := jinjaNumber(v); ok {
+		return int(f), nil
+	}
+	switch x := v.(type) {
+	case bool:
+		if x {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		s := strings.TrimSpace(x)
+		if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return int(n), nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return int(f), nil
+		}
+	}
+	return def, nil
+}
+
+func jinjaToFloat(v any, args []any, kw map[string]any) (any, error) {
+	if f, ok := jinjaNumber(v); ok {
+		return f, nil
+	}
+	if s, ok := v.(string); ok {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			return f, nil
+		}
+	}
+	return jinjaArg(args, kw, 0, "default", 0.0), nil
+}
+
+// jinjaToBool converts as Ansible's bool filter does: "yes", "on", "true"
+// and "1" are true.
+func jinjaToBool(v any, _ []any, _ map[string]any) (any, error) {
+	if s, ok := v.(string); ok {
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "yes", "on", "true", "1", "y", "t":
+			return true, nil
+		}
+		return false, nil
+	}
+	return jinjaTruth(v), nil
+}
+
+func jinjaAbs(v any, _ []any, _ map[string]any) (any, error) {
+	if n, ok := jinjaInt(v); ok {
+		if n < 0 {
+			return -n, nil
+		}
+		return n, nil
+	}
+	if f, ok := jinjaNumber(v); ok {
+		return math.Abs(f), nil
+	}
+	return nil, fmt.Errorf("%s is not a number", jinjaTypeName(v))
+}
+
+func jinjaRound(v any, args []any, kw map[string]any) (any, error) {
+	f, ok := jinjaNumber(v)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a number", jinjaTypeName(v))
+	}
+	precision, _ := jinjaInt(jinjaArg(args, kw, 0, "precision", 0))
+	p := math.Pow(10, float64(precision))
+	switch jinjaString(jinjaArg(args, kw, 1, "method", "common")) {
+	case "ceil":
+		return math.Ceil(f*p) / p, nil
+	case "floor":
+		return math.Floor(f*p) / p, nil
+	}
+	return math.Round(f*p) / p, nil
+}
+
+func jinjaToList(v any, _ []any, _ map[string]any) (any, error) {
+	items, err := jinjaIterate(v)
+	if items == nil && err == nil {
+		items = []any{}
+	}
+	return items, err
+}
+
+func jinjaFirst(v any, _ []any, _ map[string]any) (any, error) {
+	items, err := jinjaIterate(v)
+	if err != nil || len(items) == 0 {
+		return jinjaUndefined{"first"}, err
+	}
+	return items[0], nil
+}
+
+func jinjaLast(v any, _ []any, _ map[string]any) (any, error) {
+	items, err := jinjaIterate(v)
+	if err != nil || len(items) == 0 {
+		return jinjaUndefined{"last"}, err
+	}
+	return items[len(items)-1], nil
+}
+
+func jinjaSort(v any, args []any, kw map[string]any) (any, error) {
+	items, err := jinjaIterate(v)
+	if err != nil {
+		return nil, err
+	}
+	sorted := append([]any{}, items...)
+	reverse := jinjaTruth(jinjaArg(args, kw, 0, "reverse", false))
+	attr, hasAttr := kw["attribute"]
+	var cmpErr error
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if hasAttr {
+			a, b = jinjaGet(a, attr, ""), jinjaGet(b, attr, "")
+		}
+		c, err := jinjaCompare(a, b)
+		if err != nil {
+			cmpErr = err
+		}
+		if reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+	return sorted, cmpErr
+}
+
+func jinjaUnique(v any, _ []any, _ map[string]any) (any, error) {
+	items, err := jinjaIterate(v)
+	if err != nil {
+		return nil, err
+	}
+	out := []any{}
+	for _, item := range items {
+		if in, _ := jinjaContains(out, item); !in {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+func jinjaReverse(v any, _ []any, _ map[string]any) (any, error) {
+	if s, ok := v.(string); ok {
+		r := []rune(s)
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+		return string(r), nil
+	}
+	items, err := jinjaIterate(v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, len(items))
+	for i, item := range items {
+		out[len(items)-1-i] = item
+	}
+	return out, nil
+}
+
+func jinjaMinMax(sign int) jinjaFilterFunc {
+	return func(v any, _ []any, _ map[string]any) (any, error) {
+		items, err := jinjaIterate(v)
+		if err != nil || len(items) == 0 {
+			return jinjaUndefined{"value"}, err
+		}
+		best := items[0]
+		for _, item := range items[1:] {
+			c, err := jinjaCompare(item, best)
+			if err != nil {
+				return nil, err
+			}
+			if c*sign > 0 {
+				best = item
+			}
+		}
+		return best, nil
+	}
+}
+
+func jinjaSum(v any, args []any, kw map[string]any) (any, error) {
+	items, err := jinjaIterate(v)
+	if err != nil {
+		return nil, err
+	}
+	total := jinjaArg(args, kw, 1, "start", 0)
+	for _, item := range items {
+		if attr, ok := kw["attribute"]; ok {
+			item = jinjaGet(item, attr, "")
+		}
+		if total, err = jinjaArith("+", total, item); err != nil {
+			return nil, err
+		}
+	}
+	return total, nil
+}
+
+func jinjaSplit(v any, args []any, kw map[string]any) (any, error) {
+	s := jinjaString(v)
+	sep := jinjaArg(args, kw, 0, "sep", nil)
+	if sep == nil {
+		return jinjaStrings(strings.Fields(s)), nil
+	}
+	return jinjaStrings(strings.Split(s, jinjaString(sep))), nil
+}
+
+// jinjaIndent is indent(width=4, first=false, blank=false).
+func jinjaIndent(v any, args []any, kw map[string]any) (any, error) {
+	width := jinjaArg(args, kw, 0, "width", 4)
+	pad, ok := width.(string)
+	if !ok {
+		n, _ := jinjaInt(width)
+		pad = strings.Repeat(" ", n)
+	}
+	first := jinjaTruth(jinjaArg(args, kw, 1, "first", false))
+	blank := jinjaTruth(jinjaArg(args, kw, 2, "blank", false))
+	lines := strings.Split(jinjaString(v), "\n")
+	for i, line := range lines {
+		if (i > 0 || first) && (blank || line != "") {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+var jinjaShellSafe = regexp.MustCompile(`^[A-Za-z0-9@%+=:,./_-]+$`)
+
+// jinjaShellQuote quotes s for a POSIX shell, as Ansible's quote does.
+func jinjaShellQuote(s string) string {
+	if jinjaShellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+func jinjaB64Decode(v any, _ []any, _ map[string]any) (any, error) {
+	b, err := base64.StdEncoding.DecodeString(jinjaString(v))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// jinjaRegexp compiles a pattern of the Ansible regex filters with their
+// ignorecase and multiline options.
+func jinjaRegexp(pattern string, ignoreCase, multiline bool) (*regexp.Regexp, error) {
+	flags := ""
+	if ignoreCase {
+		flags += "i"
+	}
+	if multiline {
+		flags += "m"
+	}
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// jinjaBackref matches the Python group references \1 and \g<name>.
+var jinjaBackref = regexp.MustCompile(`\\(\d+)|\\g<(\w+)>`)
+
+func jinjaRegexReplace(v any, args []any, kw map[string]any) (any, error) {
+	re, err := jinjaRegexp(jinjaString(jinjaArg(args, kw, 0, "pattern", "")),
+		jinjaTruth(jinjaArg(args, kw, 2, "ignorecase", false)), jinjaTruth(jinjaArg(args, kw, 3, "multiline", false)))
+	if err != nil {
+		return nil, err
+	}
+	repl := strings.ReplaceAll(jinjaString(jinjaArg(args, kw, 1, "replacement", "")), "$", "$$")
+	repl = jinjaBackref.ReplaceAllString(repl, "$${$1$2}")
+	return re.ReplaceAllString(jinjaString(v), repl), nil
+}
+
+func jinjaRegexSearch(v any, args []any, kw map[string]any) (any, error) {
+	re, err := jinjaRegexp(jinjaString(jinjaArg(args, kw, 0, "pattern", "")),
+		jinjaTruth(kw["ignorecase"]), jinjaTruth(kw["multiline"]))
+	if err != nil {
+		return nil, err
+	}
+	m := re.FindStringSubmatch(jinjaString(v))
+	if m == nil {
+		return nil, nil
+	}
+	if len(args) < 2 {
+		return m[0], nil
+	}
+	// regex_search(pattern, '\\1', '\\g<name>') returns those groups
+	var groups []any
+	for _, a := range args[1:] {
+		ref := jinjaBackref.FindStringSubmatch(jinjaString(a))
+		if ref == nil {
+			return nil, fmt.Errorf("%s is not a group reference", jinjaString(a))
+		}
+		i, err := strconv.Atoi(ref[1])
+		if err != nil {
+			i = re.SubexpIndex(ref[2])
+		}
+		if i < 0 || i >= len(m) {
+			return nil, fmt.Errorf("no group %s", jinjaString(a))
+		}
+		groups = append(groups, m[i])
+	}
+	return groups, nil
+}
+
+func jinjaToJSON(nice bool) jinjaFilterFunc {
+	return func(v any, args []any, kw map[string]any) (any, error) {
+		var b []byte
+		var err error
+		if nice {
+			indent, _ := jinjaInt(jinjaArg(args, kw, 0, "indent", 4))
+			b, err = json.MarshalIndent(jinjaPlain(v), "", strings.Repeat(" ", indent))
+		} else {
+			b, err = json.Marshal(jinjaPlain(v))
+		}
+		return string(b), err
+	}
+}
+
+func jinjaToYAML(v any, args []any, kw map[string]any) (any, error) {
+	indent, _ := jinjaInt(jinjaArg(args, kw, 0, "indent", 2))
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(jinjaPlain(v)); err != nil {
+		return nil, err
+	}
+	return buf.String(), enc.Close()
+}
+
+func jinjaFromJSON(v any, _ []any, _ map[string]any) (any, error) {
+	var out any
+	err := json.Unmarshal([]byte(jinjaString(v)), &out)
+	return out, err
+}
+
+func jinjaFromYAML(v any, _ []any, _ map[string]any) (any, error) {
+	var out any
+	err := yaml.Unmarshal([]byte(jinjaString(v)), &out)
+	return out, err
+}
+
+// jinjaPlain replaces undefined values before encoding.
+func jinjaPlain(v any) any {
+	switch x := v.(type) {
+	case jinjaUndefined:
+		return nil
+	case map[string]any:
+		m := make(map[string]any, len(x))
+		for k, e := range x {
+			m[k] = jinjaPlain(e)
+		}
+		return m
+	case []any:
+		s := make([]any, len(x))
+		for i, e := range x {
+			s[i] = jinjaPlain(e)
+		}
+		return s
+	}
+	return v
+}
+
+func jinjaDict2Items(v any, args []any, kw map[string]any) (any, error) {
+	m, ok := jinjaMap(v)
+	if !ok {
+		return nil, fmt.Errorf("dict2items requires a dict, got %s", jinjaTypeName(v))
+	}
+	keyName := jinjaString(jinjaArg(args, kw, 0, "key_name", "key"))
+	valueName := jinjaString(jinjaArg(args, kw, 1, "value_name", "value"))
+	items := make([]any, 0, len(m))
+	for _, k := range jinjaSortedKeys(m) {
+		items = append(items, map[string]any{keyName: k, valueName: m[k]})
+	}
+	return items, nil
+}
+
+func jinjaItems2Dict(v any, args []any, kw map[string]any) (any, error) {
+	items, ok := jinjaSeq(v)
+	if !ok {
+		return nil, fmt.Errorf("items2dict requires a list, got %s", jinjaTypeName(v))
+	}
+	keyName := jinjaArg(args, kw, 0, "key_name", "key")
+	valueName := jinjaArg(args, kw, 1, "value_name", "value")
+	m := make(map[string]any, len(items))
+	for _, item := range items {
+		m[jinjaString(jinjaGet(item, keyName, ""))] = jinjaGet(item, valueName, "")
+	}
+	return m, nil
+}
+
+// jinjaCombine merges dicts into v, later ones winning; recursive=true
+// merges nested dicts too.
+func jinjaCombine(v any, args []any, kw map[string]any) (any, error) {
+	base, ok := jinjaMap(v)
+	if !ok {
+		return nil, fmt.Errorf("combine requires a dict, got %s", jinjaTypeName(v))
+	}
+	out := copyMap(base)
+	for _, a := range args {
+		m, ok := jinjaMap(a)
+		if !ok {
+			return nil, fmt.Errorf("combine: %s is not a dict", jinjaTypeName(a))
+		}
+		if jinjaTruth(kw["recursive"]) {
+			out = deepMerge(out, copyMap(m))
+			continue
+		}
+		for k, e := range m {
+			out[k] = e
+		}
+	}
+	return out, nil
+}
+
+func copyMap(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if nested, ok := v.(map[string]any); ok {
+			v = copyMap(nested)
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// jinjaMapFilter is map(attribute='x') or map('filter', args...).
+func jinjaMapFilter(v any, args []any, kw map[string]any) (any, error) {
+	items, err := jinjaIterate(v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, len(items))
+	if attr, ok := kw["attribute"]; ok {
+		for i, item := range items {
+			out[i] = jinjaGet(item, attr, jinjaString(attr))
+			if _, undefined := out[i].(jinjaUndefined); undefined {
+				if def, ok := kw["default"]; ok {
+					out[i] = def
+				}
+			}
+		}
+		return out, nil
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("map takes a filter name or attribute=")
+	}
+	name := jinjaString(args[0])
+	f, ok := jinjaFilters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown filter %q", name)
+	}
+	for i, item := range items {
+		if out[i], err = f(item, args[1:], nil); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// jinjaSelect is select('test', args...) and reject: the items passing (or
+// failing) a test, or truthy ones without a test.
+func jinjaSelect(keep bool) jinjaFilterFunc {
+	return func(v any, args []any, _ map[string]any) (any, error) {
+		items, err := jinjaIterate(v)
+		if err != nil {
+			return nil, err
+		}
+		out := []any{}
+		for _, item := range items {
+			ok, err := jinjaApplyTest(item, args)
+			if err != nil {
+				return nil, err
+			}
+			if ok == keep {
+				out = append(out, item)
+			}
+		}
+		return out, nil
+	}
+}
+
+// jinjaSelectAttr is selectattr('attr', 'test', args...) and rejectattr.
+func jinjaSelectAttr(keep bool) jinjaFilterFunc {
+	return func(v any, args []any, _ map[string]any) (any, error) {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("takes an attribute name")
+		}
+		items, err := jinjaIterate(v)
+		if err != nil {
+			return nil, err
+		}
+		out := []any{}
+		for _, item := range items {
+			ok, err := jinjaApplyTest(jinjaGet(item, args[0], jinjaString(args[0])), args[1:])
+			if err != nil {
+				return nil, err
+			}
+			if ok == keep {
+				out = append(out, item)
+			}
+		}
+		return out, nil
+	}
+}
+
+// jinjaApplyTest runs the test named by args[0] with the rest of args, or
+// checks truthiness without a test.
+func jinjaApplyTest(v any, args []any) (bool, error) {
+	if len(args) == 0 {
+		return jinjaTruth(v), nil
+	}
+	name := jinjaString(args[0])
+	t, ok := jinjaTests[name]
+	if !ok {
+		return false, fmt.Errorf("unknown test %q", name)
+	}
+	return t(v, args[1:])
+}
+
+func jinjaTernary(v any, args []any, kw map[string]any) (any, error) {
+	if v == nil && len(args) > 2 {
+		return args[2], nil
+	}
+	if jinjaTruth(v) {
+		return jinjaArg(args, kw, 0, "true_val", nil), nil
+	}
+	return jinjaArg(args, kw, 1, "false_val", nil), nil
+}
+
+// jinjaItems lists the key, value pairs of a dict sorted by key.
+func jinjaItems(v any, _ []any, _ map[string]any) (any, error) {
+	if _, ok := jinjaMap(v); !ok {
+		return nil, fmt.Errorf("requires a dict, got %s", jinjaTypeName(v))
+	}
+	return jinjaMethod(v, "items", nil)
+}
+
+func jinjaCenter(v any, args []any, kw map[string]any) (any, error) {
+	s := jinjaString(v)
+	width, _ := jinjaInt(jinjaArg(args, kw, 0, "width", 80))
+	n := len([]rune(s))
+	if n >= width {
+		return s, nil
+	}
+	left := (width - n) / 2
+	return strings.Repeat(" ", left) + s + strings.Repeat(" ", width-n-left), nil
+}
+
+func jinjaWordCount(v any, _ []any, _ map[string]any) (any, error) {
+	return len(strings.Fields(jinjaString(v))), nil
+}
+
+func jinjaTruncate(v any, args []any, kw map[string]any) (any, error) {
+	s := []rune(jinjaString(v))
+	length, _ := jinjaInt(jinjaArg(args, kw, 0, "length", 255))
+	end := jinjaString(jinjaArg(args, kw, 2, "end", "..."))
+	if len(s) <= length {
+		return string(s), nil
+	}
+	cut := length - len([]rune(end))
+	if cut < 0 {
+		cut = 0
+	}
+	if !jinjaTruth(jinjaArg(args, kw, 1, "killwords", false)) {
+		if i := strings.LastIndexByte(string(s[:cut]), ' '); i > 0 {
+			return string(s[:cut])[:i] + end, nil
+		}
+	}
+	return string(s[:cut]) + end, nil
+}
+
+func jinjaBatch(v any, args []any, kw map[string]any) (any, error) {
+	items, err := jinjaIterate(v)
+	if err != nil {
+		return nil, err
+	}
+	size, ok := jinjaInt(jinjaArg(args, kw, 0, "linecount", 0))
+	if !ok || size <= 0 {
+		return nil, fmt.Errorf("batch takes a positive size")
+	}
+	fill, hasFill := kw["fill_with"]
+	if len(args) > 1 {
+		fill, hasFill = args[1], true
+	}
+	out := []any{}
+	for i := 0; i < len(items); i += size {
+		end := min(i+size, len(items))
+		batch := append([]any{}, items[i:end]...)
+		for hasFill && len(batch) < size {
+			batch = append(batch, fill)
+		}
+		out = append(out, batch)
+	}
+	return out, nil
+}
+
+func jinjaFlatten(v any, args []any, kw map[string]any) (any, error) {
+	levels, limited := jinjaInt(jinjaArg(args, kw, 0, "levels", nil))
+	var flat func(items []any, depth int) []any
+	flat = func(items []any, depth int) []any {
+		out := []any{}
+		for _, item := range items {
+			if inner, ok := jinjaSeq(item); ok && (!limited || depth < levels) {
+				out = append(out, flat(inner, depth+1)...)
+				continue
+			}
+			out = append(out, item)
+		}
+		return out
+	}
+	items, err := jinjaIterate(v)
+	if err != nil {
+		return nil, err
+	}
+	return flat(items, 0), nil
+}
+
+func jinjaZip(v any, args []any, _ map[string]any) (any, error) {
+	lists := [][]any{}
+	for _, l := range append([]any{v}, args...) {
+		items, err := jinjaIterate(l)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, items)
+	}
+	n := len(lists[0])
+	for _, l := range lists[1:] {
+		n = min(n, len(l))
+	}
+	out := make([]any, n)
+	for i := range out {
+		tuple := make([]any, len(lists))
+		for j, l := range lists {
+			tuple[j] = l[i]
+		}
+		out[i] = tuple
+	}
+	return out, nil
+}
+
+// jinjaSetOp implements the Ansible set theory filters, keeping the order
+// of the items.
+func jinjaSetOp(op string) jinjaFilterFunc {
+	return func(v any, args []any, _ map[string]any) (any, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("takes one list")
+		}
+		a, err := jinjaIterate(v)
+		if err != nil {
+			return nil, err
+		}
+		b, err := jinjaIterate(args[0])
+		if err != nil {
+			return nil, err
+		}
+		has := func(list []any, x any) bool { in, _ := jinjaContains(list, x); return in }
+		out := []any{}
+		add := func(x any) {
+			if !has(out, x) {
+				out = append(out, x)
+			}
+		}
+		for _, x := range a {
+			switch op {
+			case "difference", "symmetric_difference":
+				if !has(b, x) {
+					add(x)
+				}
+			case "intersect":
+				if has(b, x) {
+					add(x)
+				}
+			case "union":
+				add(x)
+			}
+		}
+		for _, x := range b {
+			if op == "union" || op == "symmetric_difference" && !has(a, x) {
+				add(x)
+			}
+		}
+		return out, nil
+	}
+}
+
+// jinjaTestFunc reports whether v passes a test with the test's arguments.
+type jinjaTestFunc func(v any, args []any) (bool, error)
+
+// jinjaTests are the tests of "x is name".
+var jinjaTests map[string]jinjaTestFunc
+
+func init() {
+	compare := func(op string) jinjaTestFunc {
+		return func(v any, args []any) (bool, error) {
+			if len(args) != 1 {
+				return false, fmt.Errorf("takes one argument")
+			}
+			if op == "==" {
+				return jinjaEqual(v, args[0]), nil
+			}
+			if op == "!=" {
+				return !jinjaEqual(v, args[0]), nil
+			}
+			c, err := jinjaCompare(v, args[0])
+			switch op {
+			case "<":
+				return c < 0, err
+			case "<=":
+				return c <= 0, err
+			case ">":
+				return c > 0, err
+			}
+			return c >= 0, err
+		}
+	}
+	regex := func(anchored bool) jinjaTestFunc {
+		return func(v any, args []any) (bool, error) {
+			if len(args) == 0 {
+				return false, fmt.Errorf("takes a pattern")
+			}
+			pattern := jinjaString(args[0])
+			if anchored {
+				pattern = "^(?:" + pattern + ")"
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return false, err
+			}
+			return re.MatchString(jinjaString(v)), nil
+		}
+	}
+	jinjaTests = map[string]jinjaTestFunc{
+		"defined": func(v any, _ []any) (bool, error) {
+			_, undefined := v.(jinjaUndefined)
+			return !undefined, nil
+		},
+		"undefined": func(v any, _ []any) (bool, error) {
+			_, undefined := v.(jinjaUndefined)
+			return undefined, nil
+		},
+		"none":   func(v any, _ []any) (bool, error) { return v == nil, nil },
+		"string": func(v any, _ []any) (bool, error) { _, ok := v.(string); return ok, nil },
+		"number": func(v any, _ []any) (bool, error) { _, ok := jinjaNumber(v); return ok, nil },
+		"integer": func(v any, _ []any) (bool, error) {
+			_, ok := jinjaInt(v)
+			return ok, nil
+		},
+		"float": func(v any, _ []any) (bool, error) {
+			_, isInt := jinjaInt(v)
+			_, isNum := jinjaNumber(v)
+			return isNum && !isInt, nil
+		},
+		"boolean": func(v any, _ []any) (bool, error) { _, ok := v.(bool); return ok, nil },
+		"true":    func(v any, _ []any) (bool, error) { return v == true, nil },
+		"false":   func(v any, _ []any) (bool, error) { return v == false, nil },
+		"mapping": func(v any, _ []any) (bool, error) { _, ok := jinjaMap(v); return ok, nil },
+		"sequence": func(v any, _ []any) (bool, error) {
+			_, isSeq := jinjaSeq(v)
+			_, isStr := v.(string)
+			return isSeq || isStr, nil
+		},
+		"iterable": func(v any, _ []any) (bool, error) {
+			_, err := jinjaIterate(v)
+			_, undefined := v.(jinjaUndefined)
+			return err == nil && v != nil && !undefined, nil
+		},
+		"even": func(v any, _ []any) (bool, error) { n, ok := jinjaInt(v); return ok && n%2 == 0, nil },
+		"odd":  func(v any, _ []any) (bool, error) { n, ok := jinjaInt(v); return ok && n%2 != 0, nil },
+		"divisibleby": func(v any, args []any) (bool, error) {
+			n, ok := jinjaInt(v)
+			d, dok := 0, false
+			if len(args) == 1 {
+				d, dok = jinjaInt(args[0])
+			}
+			if !ok || !dok || d == 0 {
+				return false, fmt.Errorf("takes a non-zero integer")
+			}
+			return n%d == 0, nil
+		},
+		"in": func(v any, args []any) (bool, error) {
+			if len(args) != 1 {
+				return false, fmt.Errorf("takes one argument")
+			}
+			return jinjaContains(args[0], v)
+		},
+		"eq": compare("=="), "equalto": compare("=="), "==": compare("=="),
+		"ne": compare("!="), "!=": compare("!="),
+		"lt": compare("<"), "lessthan": compare("<"), "<": compare("<"),
+		"le": compare("<="), "<=": compare("<="),
+		"gt": compare(">"), "greaterthan": compare(">"), ">": compare(">"),
+		"ge": compare(">="), ">=": compare(">="),
+		"match":  regex(true),
+		"search": regex(false),
+	}
+}
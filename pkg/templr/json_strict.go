@@ -0,0 +1,160 @@
+package templr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// toJSONIndent marshals v as JSON indented by indent spaces per level, or on
+// one line when indent is 0. Unlike toJson and toPrettyJson, <, > and & are
+// written as they are rather than as \u escapes.
+func toJSONIndent(v any, indent int) (string, error) {
+	if indent < 0 || indent > 8 {
+		return "", fmt.Errorf("indent must be between 0 and 8, got %d", indent)
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", strings.Repeat(" ", indent))
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// toJSONSortedKeys marshals v like toJSONIndent with the keys of every object
+// in byte order, whatever the map or struct type: maps with non-string keys
+// (as decoded from some YAML) are converted, and struct fields are sorted
+// too. Numbers keep their text.
+func toJSONSortedKeys(v any, indent ...int) (string, error) {
+	if len(indent) > 1 {
+		return "", fmt.Errorf("toJsonSortedKeys takes at most one indent, got %d", len(indent))
+	}
+	b, err := json.Marshal(jsonStringKeys(reflect.ValueOf(v)))
+	if err != nil {
+		return "", err
+	}
+	// a generic decode turns structs into maps, which encoding/json sorts
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return "", err
+	}
+	n := 0
+	if len(indent) == 1 {
+		n = indent[0]
+	}
+	return toJSONIndent(generic, n)
+}
+
+// jsonStringKeys returns v with every map keyed by strings, so that it can
+// be marshalled as JSON.
+func jsonStringKeys(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return jsonStringKeys(v.Elem())
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = jsonStringKeys(iter.Value())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v.Interface() // null, or []byte as base64 like encoding/json
+		}
+		s := make([]any, v.Len())
+		for i := range s {
+			s[i] = jsonStringKeys(v.Index(i))
+		}
+		return s
+	}
+	return v.Interface()
+}
+
+// fromJSONStrict parses s as a single JSON value like fromJson, but fails on
+// an object with the same key twice and on anything but whitespace after
+// the value, where fromJson keeps the last key and ignores the rest.
+func fromJSONStrict(s string) (any, error) {
+	if err := checkJSONStrict([]byte(s)); err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// checkJSONStrict reports the first duplicate key of src, or data after its
+// first value.
+func checkJSONStrict(src []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	if err := checkJSONValue(dec, "$"); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("unexpected end of JSON input")
+		}
+		return err
+	}
+	off := dec.InputOffset()
+	if rest := bytes.TrimLeft(src[off:], " \t\r\n"); len(rest) > 0 {
+		return fmt.Errorf("trailing data after the JSON value at offset %d", len(src)-len(rest))
+	}
+	return nil
+}
+
+// checkJSONValue reads the next value of dec, at path, checking the keys of
+// its objects.
+func checkJSONValue(dec *json.Decoder, path string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		seen := map[string]bool{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := tok.(string)
+			if seen[key] {
+				return fmt.Errorf("duplicate key %q in the object at %s", key, path)
+			}
+			seen[key] = true
+			if err := checkJSONValue(dec, path+"."+key); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			if err := checkJSONValue(dec, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package templr
+
+// StandardizeJSONC turns JSONC (JSON with // and /* */ comments and trailing
+// commas, as used by .jsonc and .json5 files) into plain JSON. Comments are
+// replaced by spaces, keeping line breaks, so decode errors point at the
+// original line; strings are copied verbatim.
+func StandardizeJSONC(src []byte) []byte {
+	out := make([]byte, len(src))
+	copy(out, src)
+	n := len(out)
+	for i := 0; i < n; i++ {
+		switch {
+		case out[i] == '"':
+			// skip the string, honoring escapes
+			for i++; i < n && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case out[i] == '/' && i+1 < n && out[i+1] == '/':
+			for ; i < n && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case out[i] == '/' && i+1 < n && out[i+1] == '*':
+			out[i], out[i+1] = ' ', ' '
+			for i += 2; i < n && !(out[i] == '*' && i+1 < n && out[i+1] == '/'); i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			if i < n {
+				out[i], out[i+1] = ' ', ' '
+				i++
+			}
+		}
+	}
+
+	// drop commas followed only by whitespace and a closing bracket
+	for i := 0; i < n; i++ {
+		switch out[i] {
+		case '"':
+			for i++; i < n && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case ',':
+			j := i + 1
+			for j < n && (out[j] == ' ' || out[j] == '\t' || out[j] == '\n' || out[j] == '\r') {
+				j++
+			}
+			if j < n && (out[j] == '}' || out[j] == ']') {
+				out[i] = ' '
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,17 @@
+//go:build wasm_lite
+
+package templr
+
+import "text/template"
+
+// LiteBuild reports whether templr was built with the wasm_lite tag, which
+// leaves out the functions with heavy dependencies to keep the WebAssembly
+// playground small.
+const LiteBuild = true
+
+// liteOmittedFuncs lists the functions a wasm_lite build leaves out.
+var liteOmittedFuncs = []string{"toXml", "fromXml", "sum", "avg", "median", "stddev", "percentile"}
+
+func addStatsFuncs(template.FuncMap) {}
+
+func addXMLFuncs(template.FuncMap) {}
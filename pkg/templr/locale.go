@@ -0,0 +1,114 @@
+package templr
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// localePrinter returns the printer of a BCP 47 locale such as "de-DE" or
+// "fr_FR".
+func localePrinter(fn, locale string) (*message.Printer, language.Tag, error) {
+	tag, err := language.Parse(strings.ReplaceAll(locale, "_", "-"))
+	if err != nil {
+		return nil, language.Und, fmt.Errorf("%s: unknown locale %q", fn, locale)
+	}
+	return message.NewPrinter(tag), tag, nil
+}
+
+// formatLocaleNumber formats n with the separators of locale, with a fixed
+// number of fraction digits when given (up to 3 otherwise).
+func formatLocaleNumber(n any, locale string, digits ...int) (string, error) {
+	v, err := toFloat64(n)
+	if err != nil {
+		return "", fmt.Errorf("formatNumber: %w", err)
+	}
+	p, _, err := localePrinter("formatNumber", locale)
+	if err != nil {
+		return "", err
+	}
+	if len(digits) > 0 {
+		return p.Sprint(number.Decimal(v, number.Scale(digits[0]))), nil
+	}
+	return p.Sprint(number.Decimal(v)), nil
+}
+
+// formatLocalePercent formats the fraction n (0.25 for 25%) as a percentage
+// of locale, with no fraction digits unless given.
+func formatLocalePercent(n any, locale string, digits ...int) (string, error) {
+	v, err := toFloat64(n)
+	if err != nil {
+		return "", fmt.Errorf("formatPercent: %w", err)
+	}
+	p, _, err := localePrinter("formatPercent", locale)
+	if err != nil {
+		return "", err
+	}
+	if len(digits) > 0 {
+		return p.Sprint(number.Percent(v, number.Scale(digits[0]))), nil
+	}
+	return p.Sprint(number.Percent(v)), nil
+}
+
+// symbolAfterAmount lists the languages that write the currency symbol
+// after the amount, separated by a no-break space ("1.234,50 €"), and the
+// regions of those languages that write it first.
+var symbolAfterAmount = map[string][]string{
+	"bg": nil, "ca": nil, "cs": nil, "da": nil, "de": {"AT", "CH", "LI"}, "el": nil,
+	"es": {"419", "AR", "CL", "CO", "MX", "PR", "US"}, "et": nil, "fi": nil, "fr": nil,
+	"hr": nil, "hu": nil, "it": {"CH"}, "lt": nil, "lv": nil, "nb": nil, "no": nil,
+	"pl": nil, "pt": {"BR"}, "ro": nil, "ru": nil, "sk": nil, "sl": nil, "sv": nil, "uk": nil,
+}
+
+// formatLocaleCurrency formats amount in the ISO 4217 currency code for
+// locale: the locale's symbol for the currency, its separators and the
+// currency's usual fraction digits (none for JPY).
+func formatLocaleCurrency(amount any, code, locale string) (string, error) {
+	v, err := toFloat64(amount)
+	if err != nil {
+		return "", fmt.Errorf("formatCurrency: %w", err)
+	}
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return "", fmt.Errorf("formatCurrency: unknown currency %q", code)
+	}
+	p, tag, err := localePrinter("formatCurrency", locale)
+	if err != nil {
+		return "", err
+	}
+	scale, _ := currency.Standard.Rounding(unit)
+	num := p.Sprint(number.Decimal(v, number.Scale(scale)))
+	symbol := p.Sprint(currency.Symbol(unit))
+
+	base, _ := tag.Base()
+	region, _ := tag.Region()
+	if exceptions, ok := symbolAfterAmount[base.String()]; ok {
+		after := true
+		for _, r := range exceptions {
+			if region.String() == r {
+				after = false
+			}
+		}
+		if after {
+			return num + "\u00a0" + symbol, nil
+		}
+	}
+	// Letter symbols ("CHF") are set apart from the amount; "€" and "$" are not
+	if sign, rest, negative := strings.Cut(num, "-"); negative && sign == "" {
+		return "-" + joinSymbol(symbol, rest), nil
+	}
+	return joinSymbol(symbol, num), nil
+}
+
+// joinSymbol puts a symbol written before the amount in front of num.
+func joinSymbol(symbol, num string) string {
+	last := symbol[len(symbol)-1]
+	if last >= 'A' && last <= 'Z' {
+		return symbol + "\u00a0" + num
+	}
+	return symbol + num
+}
@@ -0,0 +1,11 @@
+//go:build !wasm_lite
+
+package templr
+
+// LiteBuild reports whether templr was built with the wasm_lite tag, which
+// leaves out the functions with heavy dependencies to keep the WebAssembly
+// playground small.
+const LiteBuild = false
+
+// liteOmittedFuncs lists the functions a wasm_lite build leaves out.
+var liteOmittedFuncs []string
@@ -0,0 +1,191 @@
+package templr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RawBlock is one {{ raw }}...{{ endraw }} block of a template source.
+type RawBlock struct {
+	Start, End   int    // byte offsets of the whole block, tags included
+	ContentStart int    // byte offset of Content
+	Content      string // text between the tags, passed through untouched
+	LTrim, RTrim bool   // "{{- raw" and "endraw -}}" trim the text around the block
+	InnerLTrim   bool   // "raw -}}" trims the start of Content
+	InnerRTrim   bool   // "{{- endraw" trims the end of Content
+}
+
+// rawTags returns the patterns of the opening and closing tags of raw
+// blocks for the delimiters. The opening one only matches at the start of
+// its input.
+func rawTags(ldelim, rdelim string) (open, end *regexp.Regexp) {
+	tag := func(word string) string {
+		return regexp.QuoteMeta(ldelim) + `(-\s)?\s*` + word + `\s*(\s-)?` + regexp.QuoteMeta(rdelim)
+	}
+	return regexp.MustCompile(`^` + tag("raw")), regexp.MustCompile(tag("endraw"))
+}
+
+// FindRawBlocks returns the raw blocks of the template name's source src, in
+// order. Only tags in the text of the template open a block: a raw tag in a
+// comment or a string literal of an action is part of that action. Raw
+// blocks do not nest: a block ends at the first {{ endraw }}. Empty
+// delimiters are the default ones, as for text/template.
+func FindRawBlocks(name, src, ldelim, rdelim string) ([]RawBlock, error) {
+	ldelim, rdelim = defaultDelims(ldelim, rdelim)
+	open, end := rawTags(ldelim, rdelim)
+	var blocks []RawBlock
+	for pos := 0; ; {
+		i := strings.Index(src[pos:], ldelim)
+		if i < 0 {
+			return blocks, nil
+		}
+		i += pos
+		o := open.FindStringSubmatchIndex(src[i:])
+		if o == nil {
+			if pos = actionEnd(src, i+len(ldelim), rdelim); pos < 0 {
+				return blocks, nil // the parser reports the unclosed action
+			}
+			continue
+		}
+		contentStart := i + o[1]
+		e := end.FindStringSubmatchIndex(src[contentStart:])
+		if e == nil {
+			line := 1 + strings.Count(src[:i], "\n")
+			return nil, fmt.Errorf("template: %s:%d: unterminated raw block (missing %s endraw %s)", name, line, ldelim, rdelim)
+		}
+		blocks = append(blocks, RawBlock{
+			Start:        i,
+			End:          contentStart + e[1],
+			ContentStart: contentStart,
+			Content:      src[contentStart : contentStart+e[0]],
+			LTrim:        o[2] >= 0,
+			InnerLTrim:   o[4] >= 0,
+			InnerRTrim:   e[2] >= 0,
+			RTrim:        e[4] >= 0,
+		})
+		pos = contentStart + e[1]
+	}
+}
+
+// defaultDelims returns the delimiters, "{{" and "}}" for empty ones.
+func defaultDelims(ldelim, rdelim string) (string, string) {
+	if ldelim == "" {
+		ldelim = "{{"
+	}
+	if rdelim == "" {
+		rdelim = "}}"
+	}
+	return ldelim, rdelim
+}
+
+// actionEnd returns the offset just past the rdelim closing the action or
+// comment whose text starts at i, skipping the comment and string literals,
+// or -1 when it is not closed.
+func actionEnd(src string, i int, rdelim string) int {
+	body := i
+	if strings.HasPrefix(src[body:], "- ") {
+		body += 2
+	}
+	if strings.HasPrefix(src[body:], "/*") {
+		c := strings.Index(src[body+2:], "*/")
+		if c < 0 {
+			return -1
+		}
+		i = body + 2 + c + 2
+	}
+	for i < len(src) {
+		if strings.HasPrefix(src[i:], rdelim) {
+			return i + len(rdelim)
+		}
+		switch q := src[i]; q {
+		case '"', '\'':
+			for i++; i < len(src) && src[i] != q; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+		case '`':
+			c := strings.IndexByte(src[i+1:], '`')
+			if c < 0 {
+				return -1
+			}
+			i += 1 + c
+		}
+		i++
+	}
+	return -1
+}
+
+// ExpandRawBlocks rewrites the {{ raw }}...{{ endraw }} blocks of the
+// template name's source src into actions that print their content as a
+// string literal, so that delimiters inside a block ({{ .Values.x }},
+// ${{ github.sha }}) are output as written. Trim markers work as on other
+// actions, and the lines of the rest of the template keep their numbers.
+func ExpandRawBlocks(name, src, ldelim, rdelim string) (string, error) {
+	ldelim, rdelim = defaultDelims(ldelim, rdelim)
+	blocks, err := FindRawBlocks(name, src, ldelim, rdelim)
+	if err != nil || len(blocks) == 0 {
+		return src, err
+	}
+	var b strings.Builder
+	last := 0
+	for _, blk := range blocks {
+		b.WriteString(src[last:blk.Start])
+		content, lead, trail := blk.Content, "", ""
+		if blk.InnerLTrim {
+			trimmed := strings.TrimLeft(content, " \t\r\n")
+			lead, content = content[:len(content)-len(trimmed)], trimmed
+		}
+		if blk.InnerRTrim {
+			trimmed := strings.TrimRight(content, " \t\r\n")
+			trail, content = content[len(trimmed):], trimmed
+		}
+		b.WriteString(ldelim)
+		if blk.LTrim {
+			b.WriteString("- ")
+		}
+		// the trimmed whitespace stays inside the action, keeping line numbers
+		b.WriteString(" ")
+		if parts := rawLiteral(content); len(parts) == 1 {
+			b.WriteString(lead + parts[0] + trail)
+		} else {
+			b.WriteString("print " + lead + strings.Join(parts, " ") + trail)
+		}
+		b.WriteString(" ")
+		if blk.RTrim {
+			b.WriteString(" -")
+		}
+		b.WriteString(rdelim)
+		last = blk.End
+	}
+	b.WriteString(src[last:])
+	return b.String(), nil
+}
+
+// rawLiteral returns template string operands for s: raw `...` strings,
+// which keep newlines, with backticks and carriage returns (which raw
+// strings cannot hold) as quoted strings in between.
+func rawLiteral(s string) []string {
+	if s == "" {
+		return []string{`""`}
+	}
+	var parts []string
+	for s != "" {
+		i := strings.IndexAny(s, "`\r")
+		if i < 0 {
+			parts = append(parts, "`"+s+"`")
+			break
+		}
+		if i > 0 {
+			parts = append(parts, "`"+s[:i]+"`")
+		}
+		if s[i] == '`' {
+			parts = append(parts, "\"`\"")
+		} else {
+			parts = append(parts, `"\r"`)
+		}
+		s = s[i+1:]
+	}
+	return parts
+}
@@ -0,0 +1,337 @@
+package templr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// Function namespaces.
+const (
+	NamespaceSprig  = "sprig"
+	NamespaceTemplr = "templr"
+)
+
+// FuncInfo describes a template function available in BuildFuncMap.
+type FuncInfo struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"` // NamespaceSprig or NamespaceTemplr
+	Category        string `json:"category"`
+	DeprecatedSince string `json:"deprecated_since,omitempty"`
+	Replacement     string `json:"replacement,omitempty"`
+	OverridesSprig  bool   `json:"overrides_sprig,omitempty"` // templr intentionally replaces the Sprig function
+}
+
+// Deprecated reports whether the function is deprecated.
+func (f FuncInfo) Deprecated() bool { return f.DeprecatedSince != "" }
+
+// templrFuncs registers every function templr adds on top of Sprig. New
+// functions in BuildFuncMapWithOptions must be listed here.
+var templrFuncs = []FuncInfo{
+	// templates
+	{Name: "include", Category: "templates"},
+	{Name: "includeCached", Category: "templates"},
+	{Name: "renderValueTemplate", Category: "templates"},
+	{Name: "required", Category: "templates"},
+	{Name: "requiredFields", Category: "templates"},
+	{Name: "requiredAll", Category: "templates"},
+	{Name: "fail", Category: "templates", OverridesSprig: true},
+	{Name: "hasFeature", Category: "templates"},
+	{Name: "safe", Category: "templates"},
+
+	// dicts
+	{Name: "set", Category: "dicts", OverridesSprig: true},
+	{Name: "setd", Category: "dicts"},
+	{Name: "mergeDeep", Category: "dicts"},
+	{Name: "toPairs", Category: "dicts"},
+	{Name: "fromPairs", Category: "dicts"},
+	{Name: "renameKey", Category: "dicts"},
+	{Name: "pickRegex", Category: "dicts"},
+	{Name: "deepGet", Category: "dicts"},
+	{Name: "deepHas", Category: "dicts"},
+
+	// encoding
+	{Name: "toYaml", Category: "encoding"},
+	{Name: "fromYaml", Category: "encoding"},
+	{Name: "mustToYaml", Category: "encoding"},
+	{Name: "toYamlSorted", Category: "encoding"},
+	{Name: "toYamlPretty", Category: "encoding"},
+	{Name: "mustFromYaml", Category: "encoding"},
+	{Name: "toToml", Category: "encoding"},
+	{Name: "fromToml", Category: "encoding"},
+	{Name: "fromJsonc", Category: "encoding"},
+	{Name: "toJsonIndent", Category: "encoding"},
+	{Name: "toJsonSortedKeys", Category: "encoding"},
+	{Name: "fromJsonStrict", Category: "encoding"},
+	{Name: "toTfvars", Category: "encoding"},
+	{Name: "fromTfvars", Category: "encoding"},
+	{Name: "fromAnsibleInventory", Category: "encoding"},
+	{Name: "base32", Category: "encoding"},
+	{Name: "base32Decode", Category: "encoding"},
+	{Name: "base64url", Category: "encoding"},
+	{Name: "base64urlDecode", Category: "encoding"},
+	{Name: "toCsv", Category: "encoding"},
+	{Name: "fromCsv", Category: "encoding"},
+	{Name: "csvColumn", Category: "encoding"},
+	{Name: "toXml", Category: "encoding"},
+	{Name: "fromXml", Category: "encoding"},
+
+	// humanize
+	{Name: "humanizeBytes", Category: "humanize"},
+	{Name: "humanizeNumber", Category: "humanize"},
+	{Name: "humanizeTime", Category: "humanize"},
+	{Name: "ordinal", Category: "humanize"},
+	{Name: "formatNumber", Category: "humanize"},
+	{Name: "formatCurrency", Category: "humanize"},
+	{Name: "formatPercent", Category: "humanize"},
+
+	// paths
+	{Name: "pathExt", Category: "paths"},
+	{Name: "pathStem", Category: "paths"},
+	{Name: "pathNormalize", Category: "paths"},
+	{Name: "mimeType", Category: "paths"},
+
+	// validation
+	{Name: "isEmail", Category: "validation"},
+	{Name: "isURL", Category: "validation"},
+	{Name: "isIPv4", Category: "validation"},
+	{Name: "isIPv6", Category: "validation"},
+	{Name: "isUUID", Category: "validation"},
+
+	// network
+	{Name: "cidrContains", Category: "network"},
+	{Name: "cidrHosts", Category: "network"},
+	{Name: "ipAdd", Category: "network"},
+	{Name: "ipPrivate", Category: "network"},
+	{Name: "ipVersion", Category: "network"},
+
+	// math
+	{Name: "sum", Category: "math"},
+	{Name: "avg", Category: "math"},
+	{Name: "median", Category: "math"},
+	{Name: "percentile", Category: "math"},
+	{Name: "stddev", Category: "math"},
+	{Name: "clamp", Category: "math"},
+	{Name: "roundTo", Category: "math"},
+	{Name: "seqList", Category: "math"},
+	{Name: "seqStep", Category: "math"},
+
+	// lists
+	{Name: "chunk", Category: "lists", OverridesSprig: true},
+	{Name: "matrix", Category: "lists"},
+
+	// uuid
+	{Name: "uuidv5", Category: "uuid"},
+	{Name: "ulid", Category: "uuid"},
+	{Name: "ulidFrom", Category: "uuid"},
+
+	// hashing
+	{Name: "hashFNV", Category: "hashing"},
+	{Name: "consistentShard", Category: "hashing"},
+	{Name: "colorFromString", Category: "hashing"},
+
+	// json
+	{Name: "jsonPath", Category: "json"},
+	{Name: "jsonQuery", Category: "json"},
+	{Name: "jsonSet", Category: "json"},
+
+	// dates
+	{Name: "dateParse", Category: "dates"},
+	{Name: "dateAdd", Category: "dates"},
+	{Name: "dateRange", Category: "dates"},
+	{Name: "workdays", Category: "dates"},
+}
+
+// sprigCategories groups Sprig functions following the Sprig documentation.
+// Functions added by future Sprig releases show up in the "other" category.
+var sprigCategories = map[string][]string{
+	"strings": {
+		"abbrev", "abbrevboth", "camelcase", "cat", "contains", "hasPrefix", "hasSuffix", "hello",
+		"indent", "initials", "join", "kebabcase", "lower", "nindent", "nospace", "plural", "quote",
+		"randAlpha", "randAlphaNum", "randAscii", "randNumeric", "repeat", "replace", "shuffle",
+		"snakecase", "sortAlpha", "split", "splitList", "splitn", "squote", "substr", "swapcase",
+		"title", "toStrings", "trim", "trimAll", "trimPrefix", "trimSuffix", "trimall", "trunc",
+		"untitle", "upper", "wrap", "wrapWith",
+	},
+	"regex": {
+		"regexFind", "regexFindAll", "regexMatch", "regexQuoteMeta", "regexReplaceAll",
+		"regexReplaceAllLiteral", "regexSplit", "mustRegexFind", "mustRegexFindAll", "mustRegexMatch",
+		"mustRegexReplaceAll", "mustRegexReplaceAllLiteral", "mustRegexSplit",
+	},
+	"math": {
+		"add", "add1", "add1f", "addf", "biggest", "ceil", "div", "divf", "floor", "max", "maxf",
+		"min", "minf", "mod", "mul", "mulf", "randInt", "round", "seq", "sub", "subf", "until", "untilStep",
+	},
+	"dates": {
+		"ago", "date", "dateInZone", "dateModify", "date_in_zone", "date_modify", "duration",
+		"durationRound", "htmlDate", "htmlDateInZone", "mustDateModify", "mustToDate",
+		"must_date_modify", "now", "toDate", "unixEpoch",
+	},
+	"defaults": {"all", "any", "coalesce", "default", "empty", "ternary"},
+	"encoding": {
+		"b32dec", "b32enc", "b64dec", "b64enc", "fromJson", "mustFromJson", "mustToJson",
+		"mustToPrettyJson", "mustToRawJson", "toJson", "toPrettyJson", "toRawJson",
+	},
+	"lists": {
+		"append", "chunk", "compact", "concat", "first", "has", "initial", "last", "list",
+		"mustAppend", "mustChunk", "mustCompact", "mustFirst", "mustHas", "mustInitial", "mustLast",
+		"mustPrepend", "mustPush", "mustRest", "mustReverse", "mustSlice", "mustUniq", "mustWithout",
+		"prepend", "push", "rest", "reverse", "slice", "tuple", "uniq", "without",
+	},
+	"dicts": {
+		"deepCopy", "dict", "dig", "get", "hasKey", "keys", "merge", "mergeOverwrite", "mustDeepCopy",
+		"mustMerge", "mustMergeOverwrite", "omit", "pick", "pluck", "set", "unset", "values",
+	},
+	"conversion": {"atoi", "float64", "int", "int64", "toDecimal", "toString"},
+	"paths":      {"base", "clean", "dir", "ext", "isAbs", "osBase", "osClean", "osDir", "osExt", "osIsAbs"},
+	"templates":  {"fail"},
+	"uuid":       {"uuidv4"},
+	"os":         {"env", "expandenv"},
+	"semver":     {"semver", "semverCompare"},
+	"reflection": {"deepEqual", "kindIs", "kindOf", "typeIs", "typeIsLike", "typeOf"},
+	"crypto": {
+		"adler32sum", "bcrypt", "buildCustomCert", "decryptAES", "derivePassword", "encryptAES",
+		"genCA", "genCAWithKey", "genPrivateKey", "genSelfSignedCert", "genSelfSignedCertWithKey",
+		"genSignedCert", "genSignedCertWithKey", "htpasswd", "randBytes", "sha1sum", "sha256sum",
+		"sha512sum",
+	},
+	"network": {"getHostByName"},
+	"url":     {"urlJoin", "urlParse"},
+}
+
+// deprecation marks a function as deprecated in favor of another.
+type deprecation struct {
+	since       string
+	replacement string
+}
+
+// deprecatedFuncs lists functions that emit a warning when called.
+var deprecatedFuncs = map[string]deprecation{
+	"trimall":          {since: "sprig v3.0.0", replacement: "trimAll"},
+	"date_in_zone":     {since: "sprig v3.0.0", replacement: "dateInZone"},
+	"date_modify":      {since: "sprig v3.0.0", replacement: "dateModify"},
+	"must_date_modify": {since: "sprig v3.0.0", replacement: "mustDateModify"},
+}
+
+// Funcs returns metadata for every built-in template function, sorted by
+// category and name. A templr function that replaces a Sprig function is
+// listed once, under the templr namespace; functions a wasm_lite build
+// leaves out are not listed.
+func Funcs() []FuncInfo {
+	sprigCategory := map[string]string{}
+	for cat, names := range sprigCategories {
+		for _, n := range names {
+			sprigCategory[n] = cat
+		}
+	}
+
+	byName := map[string]FuncInfo{}
+	for name := range sprig.TxtFuncMap() {
+		cat, ok := sprigCategory[name]
+		if !ok {
+			cat = "other"
+		}
+		byName[name] = FuncInfo{Name: name, Namespace: NamespaceSprig, Category: cat}
+	}
+	for _, f := range templrFuncs {
+		if slices.Contains(liteOmittedFuncs, f.Name) {
+			continue
+		}
+		f.Namespace = NamespaceTemplr
+		byName[f.Name] = f
+	}
+
+	out := make([]FuncInfo, 0, len(byName))
+	for _, f := range byName {
+		if d, ok := deprecatedFuncs[f.Name]; ok {
+			f.DeprecatedSince, f.Replacement = d.since, d.replacement
+		}
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Category != out[j].Category {
+			return out[i].Category < out[j].Category
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// LookupFunc returns the metadata of a built-in function.
+func LookupFunc(name string) (FuncInfo, bool) {
+	for _, f := range Funcs() {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return FuncInfo{}, false
+}
+
+// FuncCollisions returns the templr functions that shadow a Sprig function
+// without being marked as an intentional override. A non-empty result after
+// upgrading Sprig means a new Sprig function is hidden by a templr one.
+func FuncCollisions() []string {
+	sprigFuncs := sprig.TxtFuncMap()
+	var out []string
+	for _, f := range templrFuncs {
+		if _, ok := sprigFuncs[f.Name]; ok && !f.OverridesSprig {
+			out = append(out, f.Name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// FuncSetHash fingerprints the built-in function set: the namespace, name
+// and signature of every function in BuildFuncMap. Binaries with different
+// hashes render with different functions; equal hashes do not guarantee
+// identical behavior, only identical names and signatures.
+func FuncSetHash() string {
+	var tpl *template.Template
+	funcs := BuildFuncMap(&tpl)
+	var b strings.Builder
+	for _, f := range Funcs() {
+		fn, ok := funcs[f.Name]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "%s.%s %s\n", f.Namespace, f.Name, reflect.TypeOf(fn))
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// wrapDeprecated replaces deprecated functions in funcs with wrappers that
+// report the deprecation through warn the first time they are called.
+func wrapDeprecated(funcs map[string]any, warn func(string)) {
+	if warn == nil {
+		return
+	}
+	for name, d := range deprecatedFuncs {
+		fn, ok := funcs[name]
+		if !ok {
+			continue
+		}
+		v := reflect.ValueOf(fn)
+		if v.Kind() != reflect.Func {
+			continue
+		}
+		var once sync.Once
+		msg := fmt.Sprintf("deprecated: function %q is deprecated since %s; use %q instead", name, d.since, d.replacement)
+		funcs[name] = reflect.MakeFunc(v.Type(), func(args []reflect.Value) []reflect.Value {
+			once.Do(func() { warn(msg) })
+			if v.Type().IsVariadic() {
+				return v.CallSlice(args)
+			}
+			return v.Call(args)
+		}).Interface()
+	}
+}
@@ -0,0 +1,595 @@
+package templr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseTfvars parses a Terraform variable definitions file (terraform.tfvars)
+// into a map. It supports what tfvars files may contain: attributes whose
+// values are literals (strings, heredocs, numbers, bools, null), lists and
+// objects, with #, // and /* */ comments. Expressions such as function calls
+// or "${...}" interpolation are rejected.
+func ParseTfvars(src []byte) (map[string]any, error) {
+	p := &tfvarsParser{src: src}
+	out := map[string]any{}
+	for {
+		p.skip(true)
+		if p.eof() {
+			return out, nil
+		}
+		key, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		p.skip(false)
+		if p.peek() != '=' {
+			return nil, p.errorf("expected '=' after %s", key)
+		}
+		p.pos++
+		p.skip(false)
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := out[key]; dup {
+			return nil, p.errorf("duplicate variable %s", key)
+		}
+		out[key] = v
+		p.skip(false)
+		if !p.eof() && p.peek() != '\n' {
+			return nil, p.errorf("expected a newline after the value of %s", key)
+		}
+	}
+}
+
+type tfvarsParser struct {
+	src []byte
+	pos int
+}
+
+func (p *tfvarsParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *tfvarsParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *tfvarsParser) errorf(format string, args ...any) error {
+	line := 1 + strings.Count(string(p.src[:min(p.pos, len(p.src))]), "\n")
+	return fmt.Errorf("tfvars line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skip skips blanks and comments, and newlines too when newlines is set. A
+// line comment stops before its newline.
+func (p *tfvarsParser) skip(newlines bool) {
+	for !p.eof() {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '\n' && newlines:
+			p.pos++
+		case c == '#' || (c == '/' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '/'):
+			for !p.eof() && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == '/' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '*':
+			end := strings.Index(string(p.src[p.pos+2:]), "*/")
+			if end < 0 {
+				p.pos = len(p.src)
+				return
+			}
+			p.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+func isIdentByte(c byte, first bool) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') ||
+		(!first && (c == '-' || ('0' <= c && c <= '9')))
+}
+
+func (p *tfvarsParser) ident() (string, error) {
+	start := p.pos
+	for !p.eof() && isIdentByte(p.src[p.pos], p.pos == start) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("unexpected %q, expected a variable name", p.peek())
+	}
+	return string(p.src[start:p.pos]), nil
+}
+
+func (p *tfvarsParser) value() (any, error) {
+	c := p.peek()
+	switch {
+	case p.eof():
+		return nil, p.errorf("unexpected end of file, expected a value")
+	case c == '"':
+		return p.quoted()
+	case c == '<' && strings.HasPrefix(string(p.src[p.pos:]), "<<"):
+		return p.heredoc()
+	case c == '[':
+		return p.list()
+	case c == '{':
+		return p.object()
+	case c == '-' || ('0' <= c && c <= '9'):
+		return p.number()
+	case isIdentByte(c, true):
+		start := p.pos
+		word, _ := p.ident()
+		switch word {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		p.pos = start
+		return nil, p.errorf("unsupported expression %s: tfvars values must be literals", word)
+	}
+	return nil, p.errorf("unexpected %q, expected a value", c)
+}
+
+func (p *tfvarsParser) quoted() (string, error) {
+	p.pos++ // opening quote
+	var b strings.Builder
+	for {
+		if p.eof() || p.src[p.pos] == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.src[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return b.String(), nil
+		case c == '\\':
+			if p.pos+1 >= len(p.src) {
+				return "", p.errorf("unterminated string")
+			}
+			e := p.src[p.pos+1]
+			p.pos += 2
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\':
+				b.WriteByte(e)
+			case 'u', 'U':
+				n := 4
+				if e == 'U' {
+					n = 8
+				}
+				if p.pos+n > len(p.src) {
+					return "", p.errorf("invalid \\%c escape", e)
+				}
+				r, err := strconv.ParseUint(string(p.src[p.pos:p.pos+n]), 16, 32)
+				if err != nil || !utf8.ValidRune(rune(r)) {
+					return "", p.errorf("invalid \\%c escape", e)
+				}
+				b.WriteRune(rune(r))
+				p.pos += n
+			default:
+				return "", p.errorf("invalid escape \\%c", e)
+			}
+		case (c == '$' || c == '%') && p.pos+2 < len(p.src) && p.src[p.pos+1] == c && p.src[p.pos+2] == '{':
+			b.WriteByte(c) // $${ and %%{ are literal ${ and %{
+			b.WriteByte('{')
+			p.pos += 3
+		case (c == '$' || c == '%') && p.pos+1 < len(p.src) && p.src[p.pos+1] == '{':
+			return "", p.errorf("template sequence %c{ is not allowed in tfvars (write %c%c{ for a literal)", c, c, c)
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// heredoc parses <<EOT and the indented <<-EOT forms.
+func (p *tfvarsParser) heredoc() (string, error) {
+	p.pos += 2
+	indented := p.peek() == '-'
+	if indented {
+		p.pos++
+	}
+	marker, err := p.ident()
+	if err != nil {
+		return "", err
+	}
+	p.skip(false)
+	if p.peek() != '\n' {
+		return "", p.errorf("expected a newline after <<%s", marker)
+	}
+	p.pos++
+	var lines []string
+	for {
+		if p.eof() {
+			return "", p.errorf("heredoc %s is not terminated", marker)
+		}
+		end := strings.IndexByte(string(p.src[p.pos:]), '\n')
+		var line string
+		if end < 0 {
+			line, p.pos = string(p.src[p.pos:]), len(p.src)
+		} else {
+			line, p.pos = string(p.src[p.pos:p.pos+end]), p.pos+end+1
+		}
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == marker {
+			if p.pos > 0 && p.src[p.pos-1] == '\n' {
+				p.pos-- // leave the newline ending the attribute
+			}
+			break
+		}
+		lines = append(lines, line)
+	}
+	if indented {
+		trim := -1
+		for _, l := range lines {
+			if strings.TrimSpace(l) == "" {
+				continue
+			}
+			if n := len(l) - len(strings.TrimLeft(l, " \t")); trim < 0 || n < trim {
+				trim = n
+			}
+		}
+		for i, l := range lines {
+			if len(l) >= trim && trim > 0 {
+				lines[i] = l[trim:]
+			} else if strings.TrimSpace(l) == "" {
+				lines[i] = ""
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	text := strings.Join(lines, "\n") + "\n"
+	for _, c := range []string{"$", "%"} {
+		if strings.Contains(strings.ReplaceAll(text, c+c+"{", ""), c+"{") {
+			return "", p.errorf("template sequence %s{ is not allowed in tfvars (write %s%s{ for a literal)", c, c, c)
+		}
+		text = strings.ReplaceAll(text, c+c+"{", c+"{")
+	}
+	return text, nil
+}
+
+func (p *tfvarsParser) number() (any, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	isFloat := false
+scan:
+	for !p.eof() {
+		c := p.src[p.pos]
+		switch {
+		case '0' <= c && c <= '9':
+		case c == '.' || c == 'e' || c == 'E':
+			isFloat = true
+		case (c == '+' || c == '-') && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E'):
+		default:
+			break scan
+		}
+		p.pos++
+	}
+	text := string(p.src[start:p.pos])
+	if !isFloat {
+		if n, err := strconv.Atoi(text); err == nil {
+			return n, nil
+		}
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %s", text)
+	}
+	return f, nil
+}
+
+func (p *tfvarsParser) list() ([]any, error) {
+	p.pos++ // [
+	out := []any{}
+	for {
+		p.skip(true)
+		if p.peek() == ']' {
+			p.pos++
+			return out, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+		p.skip(true)
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("expected ',' or ']' in list")
+		}
+	}
+}
+
+func (p *tfvarsParser) object() (map[string]any, error) {
+	p.pos++ // {
+	out := map[string]any{}
+	for {
+		p.skip(true)
+		if p.peek() == '}' {
+			p.pos++
+			return out, nil
+		}
+		var key string
+		var err error
+		if p.peek() == '"' {
+			key, err = p.quoted()
+		} else {
+			key, err = p.ident()
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.skip(false)
+		if c := p.peek(); c != '=' && c != ':' {
+			return nil, p.errorf("expected '=' after %s", key)
+		}
+		p.pos++
+		p.skip(false)
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		out[key] = v
+		p.skip(false)
+		switch p.peek() {
+		case ',', '\n':
+			p.pos++
+		case '}':
+		default:
+			return nil, p.errorf("expected ',', a newline or '}' after %s", key)
+		}
+	}
+}
+
+// MarshalTfvars renders a map as a Terraform variable definitions file, with
+// sorted keys and the equals signs aligned the way `terraform fmt` does.
+func MarshalTfvars(v any) (string, error) {
+	norm, err := tfvarsNormalize(v)
+	if err != nil {
+		return "", err
+	}
+	m, ok := norm.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("toTfvars: want a map of variables, got %T", v)
+	}
+	for k := range m {
+		if !isTfvarsIdent(k) {
+			return "", fmt.Errorf("toTfvars: %q is not a valid variable name", k)
+		}
+	}
+	var b strings.Builder
+	if err := writeTfvarsAttrs(&b, m, 0); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func isTfvarsIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isIdentByte(s[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// tfvarsNormalize turns v into maps with string keys, slices and scalars;
+// other types (structs, typed maps) go through JSON.
+func tfvarsNormalize(v any) (any, error) {
+	switch t := v.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v, nil
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, e := range t {
+			n, err := tfvarsNormalize(e)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = n
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(t))
+		for i, e := range t {
+			n, err := tfvarsNormalize(e)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = n
+		}
+		return out, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		out := make([]any, rv.Len())
+		for i := range out {
+			n, err := tfvarsNormalize(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			out[i] = n
+		}
+		return out, nil
+	case reflect.Map:
+		out := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			n, err := tfvarsNormalize(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(iter.Key().Interface())] = n
+		}
+		return out, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("toTfvars: cannot encode %T: %w", v, err)
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return tfvarsNormalize(out)
+}
+
+// writeTfvarsAttrs writes "key = value" lines at the given depth. Equals
+// signs are aligned within runs of attributes; a multi-line value ends a run.
+func writeTfvarsAttrs(b *strings.Builder, m map[string]any, depth int) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	names := make([]string, len(keys))
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k
+		if !isTfvarsIdent(k) {
+			names[i] = strconv.Quote(k)
+		}
+		var vb strings.Builder
+		if err := writeTfvarsValue(&vb, m[k], depth); err != nil {
+			return err
+		}
+		values[i] = vb.String()
+	}
+
+	indent := strings.Repeat("  ", depth)
+	for start := 0; start < len(keys); {
+		end := start
+		for end < len(keys)-1 && !strings.Contains(values[end], "\n") {
+			end++
+		}
+		width := 0
+		for i := start; i <= end; i++ {
+			width = max(width, len(names[i]))
+		}
+		for i := start; i <= end; i++ {
+			fmt.Fprintf(b, "%s%-*s = %s\n", indent, width, names[i], values[i])
+		}
+		start = end + 1
+	}
+	return nil
+}
+
+func writeTfvarsValue(b *strings.Builder, v any, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	switch t := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case string:
+		b.WriteString(quoteTfvars(t))
+	case bool:
+		b.WriteString(strconv.FormatBool(t))
+	case float32:
+		return writeTfvarsValue(b, float64(t), depth)
+	case float64:
+		if math.IsNaN(t) || math.IsInf(t, 0) {
+			return fmt.Errorf("toTfvars: %v is not a valid number", t)
+		}
+		b.WriteString(strconv.FormatFloat(t, 'f', -1, 64))
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		fmt.Fprint(b, t)
+	case []any:
+		if len(t) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		items := make([]string, len(t))
+		multiline := false
+		for i, e := range t {
+			var eb strings.Builder
+			if err := writeTfvarsValue(&eb, e, depth+1); err != nil {
+				return err
+			}
+			items[i] = eb.String()
+			switch e.(type) {
+			case map[string]any, []any:
+				multiline = true
+			}
+		}
+		if !multiline {
+			b.WriteString("[" + strings.Join(items, ", ") + "]")
+			return nil
+		}
+		b.WriteString("[\n")
+		for _, item := range items {
+			b.WriteString(indent + "  " + item + ",\n")
+		}
+		b.WriteString(indent + "]")
+	case map[string]any:
+		if len(t) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		b.WriteString("{\n")
+		if err := writeTfvarsAttrs(b, t, depth+1); err != nil {
+			return err
+		}
+		b.WriteString(indent + "}")
+	default:
+		return fmt.Errorf("toTfvars: cannot encode %T", v)
+	}
+	return nil
+}
+
+// quoteTfvars quotes a string for HCL, escaping the ${ and %{ template
+// sequences so they stay literal.
+func quoteTfvars(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case (r == '$' || r == '%') && i+1 < len(s) && s[i+1] == '{':
+			b.WriteRune(r)
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,23 @@
+//go:build wasm_lite
+
+package templr
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// StartSpan returns a no-op span: a wasm_lite build has no tracer provider
+// to export spans to, and leaving out the global one keeps net/http out of
+// the binary.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return noop.Tracer{}.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan ends span.
+func EndSpan(span trace.Span, _ error) {
+	span.End()
+}
@@ -0,0 +1,72 @@
+package templr
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// HostFuncs are the functions that read the environment of the process or
+// resolve host names. Templates from outside the host, such as value
+// templates and RenderService requests, should not get them.
+var HostFuncs = []string{"env", "expandenv", "getHostByName"}
+
+// valueTemplateDenied lists the functions a template stored in values may not
+// call: values are often maintained outside the template repository, so a
+// value template cannot read the environment, resolve hosts, reach the
+// templates of the render, change the values or render further value
+// templates.
+var valueTemplateDenied = append(append([]string{}, HostFuncs...),
+	"include", "includeCached",
+	"set", "setd", "unset",
+	"renderValueTemplate",
+)
+
+// addValueTemplates registers renderValueTemplate in the final funcs. Value
+// templates get the same functions as the render, minus disabled ones and
+// valueTemplateDenied, and always use the default delimiters. Unless
+// opts.ValueTemplates is set every call fails.
+func addValueTemplates(funcs template.FuncMap, opts *FuncMapOptions) {
+	sandbox := template.FuncMap{}
+	for name, fn := range funcs {
+		sandbox[name] = fn
+	}
+	for _, name := range opts.DisabledFuncs {
+		delete(sandbox, name)
+	}
+	for _, name := range valueTemplateDenied {
+		sandbox[name] = func(...any) (any, error) {
+			return nil, fmt.Errorf("%s is not available in value templates", name)
+		}
+	}
+
+	funcs["renderValueTemplate"] = func(src any, data any) (string, error) {
+		if !opts.ValueTemplates {
+			return "", fmt.Errorf("renderValueTemplate: value templates are not enabled (--allow-value-templates)")
+		}
+		var text string
+		switch s := src.(type) {
+		case string:
+			text = s
+		case nil:
+			if opts.Strict {
+				return "", fmt.Errorf("renderValueTemplate: the value template is missing")
+			}
+			return "", nil
+		default:
+			return "", fmt.Errorf("renderValueTemplate: value template must be a string, got %T", src)
+		}
+		t := template.New("value template").Funcs(sandbox)
+		if opts.Strict {
+			t = t.Option("missingkey=error")
+		}
+		if _, err := t.Parse(text); err != nil {
+			return "", err
+		}
+		var b bytes.Buffer
+		if err := t.Execute(&b, data); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+}
@@ -0,0 +1,51 @@
+package templr
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// toYamlSorted marshals v like toYaml but with the keys of every mapping in
+// byte order ("a10" before "a2"), whatever the map type, and indent spaces
+// per level.
+func toYamlSorted(v any, indent int) (string, error) {
+	if indent < 2 || indent > 9 {
+		return "", fmt.Errorf("indent must be between 2 and 9, got %d", indent)
+	}
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return "", err
+	}
+	sortYAMLKeys(&node)
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(indent)
+	if err := enc.Encode(&node); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// sortYAMLKeys sorts the key/value pairs of every mapping under n by key.
+func sortYAMLKeys(n *yaml.Node) {
+	if n.Kind == yaml.MappingNode {
+		pairs := make([][2]*yaml.Node, 0, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			pairs = append(pairs, [2]*yaml.Node{n.Content[i], n.Content[i+1]})
+		}
+		sort.SliceStable(pairs, func(i, j int) bool { return pairs[i][0].Value < pairs[j][0].Value })
+		for i, p := range pairs {
+			n.Content[2*i], n.Content[2*i+1] = p[0], p[1]
+		}
+	}
+	for _, c := range n.Content {
+		sortYAMLKeys(c)
+	}
+}
@@ -0,0 +1,91 @@
+"""Render templr templates in-process through its C-shared library.
+
+Build the library with ``make build-cshared`` and point ``TEMPLR_LIB`` at
+it (``.bin/libtemplr.so``, or ``.dylib`` on macOS), or place it next to this
+package.
+"""
+
+import ctypes
+import json
+import os
+import sys
+
+__all__ = ["TemplrError", "render", "render_tree", "version"]
+
+
+class TemplrError(Exception):
+    """A render error reported by templr, with the warnings raised before it."""
+
+    def __init__(self, message, warnings=None):
+        super().__init__(message)
+        self.warnings = warnings or []
+
+
+def _load():
+    path = os.environ.get("TEMPLR_LIB")
+    if not path:
+        ext = "dylib" if sys.platform == "darwin" else "dll" if os.name == "nt" else "so"
+        path = os.path.join(os.path.dirname(__file__), "libtemplr." + ext)
+    lib = ctypes.CDLL(path)
+    # Returned strings are allocated by the library: keep them as void
+    # pointers so they can be handed back to TemplrFree.
+    for name in ("TemplrRenderSingle", "TemplrRenderTree"):
+        fn = getattr(lib, name)
+        fn.argtypes = [ctypes.c_char_p]
+        fn.restype = ctypes.c_void_p
+    lib.TemplrVersion.argtypes = []
+    lib.TemplrVersion.restype = ctypes.c_void_p
+    lib.TemplrFree.argtypes = [ctypes.c_void_p]
+    lib.TemplrFree.restype = None
+    return lib
+
+
+_lib = None
+
+
+def _call(name, *args):
+    global _lib
+    if _lib is None:
+        _lib = _load()
+    ptr = getattr(_lib, name)(*args)
+    try:
+        return ctypes.string_at(ptr).decode("utf-8")
+    finally:
+        _lib.TemplrFree(ptr)
+
+
+def _request(fn, req):
+    res = json.loads(_call(fn, json.dumps(req).encode("utf-8")))
+    if res.get("error"):
+        raise TemplrError(res["error"], res.get("warnings"))
+    return res
+
+
+def _options(values, helpers, files, strict, default_missing):
+    req = {"helpers": helpers, "strict": strict, "defaultMissing": default_missing}
+    if values is not None:
+        req["values"] = values if isinstance(values, str) else json.dumps(values)
+    if files:
+        req["files"] = files
+    return req
+
+
+def render(template, values=None, helpers="", files=None, strict=False, default_missing=""):
+    """Render one template. values is a dict, or YAML or JSON text."""
+    req = _options(values, helpers, files, strict, default_missing)
+    req["template"] = template
+    return _request("TemplrRenderSingle", req).get("output", "")
+
+
+def render_tree(templates, values=None, helpers="", files=None, strict=False, default_missing=""):
+    """Render a dict of path -> template text whose templates can include
+    each other. Returns path (without .tpl) -> output; partials (``_*``)
+    and empty outputs are left out."""
+    req = _options(values, helpers, files, strict, default_missing)
+    req["templates"] = templates
+    return _request("TemplrRenderTree", req).get("outputs", {})
+
+
+def version():
+    """The version of the loaded library."""
+    return _call("TemplrVersion")
@@ -0,0 +1,33 @@
+"""Run with `make cshared-test`, which builds the library first."""
+
+import unittest
+
+import templr
+
+
+class TemplrTest(unittest.TestCase):
+    def test_render(self):
+        out = templr.render("hello {{ .name | upper }}", values={"name": "web"})
+        self.assertEqual(out, "hello WEB")
+
+    def test_render_error(self):
+        with self.assertRaises(templr.TemplrError):
+            templr.render("{{ .missing.key }}", strict=True)
+
+    def test_render_tree(self):
+        outputs = templr.render_tree(
+            {
+                "_helpers.tpl": '{{ define "port" }}{{ .port | default 8080 }}{{ end }}',
+                "app.conf.tpl": 'listen {{ include "port" . }}\n',
+                "conf/empty.txt.tpl": "{{ if false }}x{{ end }}",
+            },
+            values="port: 9000\n",
+        )
+        self.assertEqual(outputs, {"app.conf": "listen 9000\n"})
+
+    def test_version(self):
+        self.assertTrue(templr.version())
+
+
+if __name__ == "__main__":
+    unittest.main()
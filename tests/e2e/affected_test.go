@@ -0,0 +1,76 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAffectedByValuesDiff(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "templates")
+	write := func(path, content string) string {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write(filepath.Join(src, "_helpers.tpl"), `{{ define "app.name" }}{{ .app.name }}{{ end }}`)
+	write(filepath.Join(src, "db.yaml.tpl"), "host: {{ .db.host }}\n")
+	write(filepath.Join(src, "app.yaml.tpl"), "name: {{ include \"app.name\" . }}\n")
+	write(filepath.Join(src, "all.txt.tpl"), "{{ len . }}\n")
+	write(filepath.Join(src, "static.txt.tpl"), "static\n")
+	old := write(filepath.Join(td, "old.yaml"), "db: {host: a}\napp: {name: x}\n")
+	dbChange := write(filepath.Join(td, "db.yaml"), "db: {host: b}\napp: {name: x}\n")
+	appChange := write(filepath.Join(td, "app.yaml"), "db: {host: a}\napp: {name: y}\n")
+
+	t.Run("walk", func(t *testing.T) {
+		dst := filepath.Join(td, "out")
+		stdout, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "-f", dbChange, "--affected-by-values-diff", old)
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "2 of 4 templates read the 1 changed key: db.host") {
+			t.Fatalf("expected a summary line, got:\n%s", stdout)
+		}
+		for name, want := range map[string]bool{"db.yaml": true, "all.txt": true, "app.yaml": false, "static.txt": false} {
+			if _, err := os.Stat(filepath.Join(dst, name)); (err == nil) != want {
+				t.Errorf("%s rendered = %v, want %v", name, err == nil, want)
+			}
+		}
+	})
+
+	t.Run("values_diff_files", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "values", "diff", "-f", old, "-f", appChange, "--src", src, "--format", "files")
+		if err != nil {
+			t.Fatalf("values diff failed: %v\n%s", err, stderr)
+		}
+		if want := "all.txt.tpl\napp.yaml.tpl\n"; stdout != want {
+			t.Fatalf("expected %q, got %q", want, stdout)
+		}
+	})
+
+	t.Run("values_diff_text", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "values", "diff", "-f", old, "-f", dbChange, "--src", src)
+		if err != nil {
+			t.Fatalf("values diff failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "files affected by changed keys db.host:\n  all.txt.tpl\n  db.yaml.tpl\n") {
+			t.Fatalf("expected the affected files, got:\n%s", stdout)
+		}
+	})
+
+	t.Run("archive_rejected", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "walk", "--src", src, "--dst-archive", filepath.Join(td, "out.tar"), "--affected-by-values-diff", old)
+		if code := getExitCode(err); code == 0 || !strings.Contains(stderr, "cannot be combined with --dst-archive") {
+			t.Fatalf("expected a usage error, got %d\n%s", code, stderr)
+		}
+	})
+}
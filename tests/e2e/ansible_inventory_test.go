@@ -0,0 +1,111 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFromAnsibleInventory(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	files := map[string]string{
+		"hosts.ini": `mail.example.com
+
+[web]
+web[01:02].example.com http_port=8080 ansible_user="deploy user"
+
+[db]
+db-[a:b].example.com
+
+[prod:children]
+web
+db
+
+[prod:vars]
+env=production
+
+[all:vars]
+env=default
+retries=3
+`,
+		"hosts.yaml": `all:
+  vars:
+    env: default
+  hosts:
+    mail.example.com:
+  children:
+    prod:
+      vars:
+        env: production
+      children:
+        web:
+          hosts:
+            web[01:02].example.com:
+              http_port: 8080
+`,
+		// one line per host: name, groups, effective env and port
+		"hosts.tpl": `{{- $inv := fromAnsibleInventory (.Files.Get .inventory) -}}
+{{- range $name, $h := $inv.hosts }}
+{{ $name }} [{{ join "," $h.groups }}] env={{ $h.vars.env }} port={{ $h.vars.http_port | default "-" }}
+{{- end }}
+web: {{ join "," (index $inv.groups "web").hosts }}
+all vars: {{ $inv.vars.env }}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(td, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tpl := filepath.Join(td, "hosts.tpl")
+
+	t.Run("ini", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "-i", tpl, "--set", "inventory=hosts.ini")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		want := `
+db-a.example.com [prod,db] env=production port=-
+db-b.example.com [prod,db] env=production port=-
+mail.example.com [ungrouped] env=default port=-
+web01.example.com [prod,web] env=production port=8080
+web02.example.com [prod,web] env=production port=8080
+web: web01.example.com,web02.example.com
+all vars: default
+`
+		if strings.TrimSpace(stdout) != strings.TrimSpace(want) {
+			t.Errorf("got:\n%s\nwant:\n%s", stdout, want)
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "-i", tpl, "--set", "inventory=hosts.yaml")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		want := `
+mail.example.com [ungrouped] env=default port=-
+web01.example.com [prod,web] env=production port=8080
+web02.example.com [prod,web] env=production port=8080
+web: web01.example.com,web02.example.com
+all vars: default
+`
+		if strings.TrimSpace(stdout) != strings.TrimSpace(want) {
+			t.Errorf("got:\n%s\nwant:\n%s", stdout, want)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		bad := filepath.Join(td, "bad.tpl")
+		if err := os.WriteFile(bad, []byte(`{{ fromAnsibleInventory "[web]\nweb[3:1]" }}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err := run(t, bin, "render", "-i", bad)
+		if err == nil || !strings.Contains(stderr, "invalid range [3:1]") {
+			t.Errorf("expected a range error, got %v\n%s", err, stderr)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAssertions(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"app.yaml.tpl":  "server:\n  port: {{ .port }}\n",
+		"app.json.tpl":  `{"server": {"port": {{ .port }}}}` + "\n",
+		"notes.txt.tpl": "port is {{ .port }}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// plain text has no .Data, so the check is limited to structured files
+	portCheck := `or (not .Data) (eq .Data.server.port 8080)`
+
+	t.Run("walk_pass", func(t *testing.T) {
+		dst := filepath.Join(td, "pass")
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--set", "port=8080", "--assert", portCheck, "--assert", `contains "port" .Output`)
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		for _, f := range []string{"app.yaml", "app.json", "notes.txt"} {
+			if _, err := os.Stat(filepath.Join(dst, f)); err != nil {
+				t.Errorf("expected %s to be written: %v", f, err)
+			}
+		}
+	})
+
+	t.Run("walk_fail", func(t *testing.T) {
+		dst := filepath.Join(td, "fail")
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--set", "port=9090", "--assert", portCheck)
+		if code := getExitCode(err); code != 9 {
+			t.Fatalf("expected exit code 9, got %d\n%s", code, stderr)
+		}
+		for _, f := range []string{"app.yaml", "app.json"} {
+			if !strings.Contains(stderr, "[templr:error:assert] "+f+": assertion failed") {
+				t.Errorf("expected violation for %s, got:\n%s", f, stderr)
+			}
+			if _, err := os.Stat(filepath.Join(dst, f)); !os.IsNotExist(err) {
+				t.Errorf("expected %s not to be written", f)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dst, "notes.txt")); err != nil {
+			t.Errorf("expected passing notes.txt to be written: %v", err)
+		}
+		if !strings.Contains(stderr, "2 assertions failed") {
+			t.Errorf("expected summary, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("render_template_syntax", func(t *testing.T) {
+		tpl := filepath.Join(src, "app.yaml.tpl")
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "--set", "port=8080", "--assert", `{{ eq .Path "stdout" }}`)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "port: 8080") {
+			t.Errorf("unexpected output:\n%s", stdout)
+		}
+
+		_, stderr, err = run(t, bin, "render", "--no-color", "-i", tpl, "--set", "port=8080", "--assert", `eq .Values.port`)
+		if code := getExitCode(err); code != 9 || !strings.Contains(stderr, "stdout: assertion") {
+			t.Errorf("expected evaluation error to fail with 9, got %d\n%s", code, stderr)
+		}
+	})
+
+	t.Run("invalid_expression", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "render", "--no-color", "-i", filepath.Join(src, "notes.txt.tpl"), "--assert", `eq (`)
+		if code := getExitCode(err); code != 1 || !strings.Contains(stderr, "invalid --assert") {
+			t.Errorf("expected invalid --assert to fail with 1, got %d\n%s", code, stderr)
+		}
+	})
+}
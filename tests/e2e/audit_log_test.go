@@ -0,0 +1,87 @@
+package e2e
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "app.conf.tpl"), []byte("name={{ .name }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "values.yaml"), []byte("name: web\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(td, "out")
+	logPath := filepath.Join(td, "logs", "audit.jsonl")
+
+	type file struct {
+		Name, Kind, SHA256 string
+	}
+	type record struct {
+		User, Host, Command, Result, Error string
+		Argv                               []string
+		Inputs, Outputs                    []file
+	}
+	var records []record
+	readLog := func() {
+		t.Helper()
+		b, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = records[:0]
+		for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+			var r record
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				t.Fatalf("bad record %q: %v", line, err)
+			}
+			records = append(records, r)
+		}
+	}
+
+	walk := []string{"walk", "--no-color", "--src", src, "--dst", dst, "--audit-log", logPath}
+	for _, extra := range [][]string{nil, nil, {"--dry-run"}} {
+		if _, stderr, err := run(t, bin, append(walk, extra...)...); err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+	}
+	readLog()
+	if len(records) != 2 {
+		t.Fatalf("expected a record per non-dry-run walk, got %d", len(records))
+	}
+	first := records[0]
+	if first.Command != "walk" || first.Result != "ok" || first.Host == "" || !strings.Contains(strings.Join(first.Argv, " "), "--audit-log") {
+		t.Fatalf("unexpected record: %+v", first)
+	}
+	if len(first.Inputs) != 2 || first.Inputs[0].Kind != "values" || first.Inputs[1].Name != "app.conf.tpl" || len(first.Inputs[1].SHA256) != 64 {
+		t.Fatalf("expected the values file and template as inputs, got %+v", first.Inputs)
+	}
+	if len(first.Outputs) != 1 || !strings.HasSuffix(first.Outputs[0].Name, "app.conf") {
+		t.Fatalf("expected the written output, got %+v", first.Outputs)
+	}
+	if len(records[1].Outputs) != 0 {
+		t.Fatalf("expected unchanged outputs to be left out, got %+v", records[1].Outputs)
+	}
+
+	_, _, err := run(t, bin, "render", "--no-color", "-i", filepath.Join(td, "missing.tpl"), "--audit-log", logPath)
+	if err == nil {
+		t.Fatal("expected the render to fail")
+	}
+	readLog()
+	last := records[len(records)-1]
+	if last.Command != "render" || last.Result != "error" || !strings.Contains(last.Error, "missing.tpl") {
+		t.Fatalf("expected the failed render to be recorded, got %+v", last)
+	}
+}
@@ -0,0 +1,119 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type batchResult struct {
+	Line   int    `json:"line"`
+	ID     string `json:"id"`
+	Out    string `json:"out"`
+	Status string `json:"status"`
+	Output string `json:"output"`
+	Error  string `json:"error"`
+}
+
+// runBatch runs templr batch in dir with jobs on stdin and returns the
+// results by id.
+func runBatch(t *testing.T, bin, dir, jobs string, args ...string) (map[string]batchResult, string, error) {
+	t.Helper()
+	cmd := exec.Command(bin, append([]string{"batch"}, args...)...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(jobs)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	results := map[string]batchResult{}
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var r batchResult
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("bad result line %q: %v", line, err)
+		}
+		results[r.ID] = r
+	}
+	return results, stderr.String(), err
+}
+
+func TestBatch(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(td, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("app.tpl", `{{ include "name" . }}:{{ .port }}`)
+	write("_helpers.tpl", `{{ define "name" }}{{ .env }}-app{{ end }}`)
+	write("values.yaml", "env: prod\nport: 80\n")
+
+	var jobs strings.Builder
+	for i := range 20 {
+		fmt.Fprintf(&jobs, `{"id":"f%d","template":"app.tpl","values":{"port":%d},"out":"out/app%d.conf"}`+"\n", i, 8000+i, i)
+	}
+	jobs.WriteString(`{"id":"inline","inline":"Hello {{ .env }}"}` + "\n")
+	jobs.WriteString(`{"id":"empty","inline":"{{ if false }}x{{ end }}","out":"out/empty.txt"}` + "\n")
+	jobs.WriteString(`{"id":"bad","inline":"{{ .missing.key }}"}` + "\n")
+	jobs.WriteString("not json\n")
+
+	results, stderr, err := runBatch(t, bin, td, jobs.String(), "-d", "values.yaml", "--workers", "4", "--strict")
+	if getExitCode(err) != 1 || !strings.Contains(stderr, "2 of 24 jobs failed") {
+		t.Fatalf("expected exit 1 with 2 failed jobs, got %v\n%s", err, stderr)
+	}
+	if len(results) != 24 {
+		t.Fatalf("expected 24 results, got %d", len(results))
+	}
+
+	for i := range 20 {
+		r := results[fmt.Sprintf("f%d", i)]
+		if r.Status != "rendered" || r.Out != fmt.Sprintf("out/app%d.conf", i) || r.Line != i+1 {
+			t.Fatalf("unexpected result %+v", r)
+		}
+		got, err := os.ReadFile(filepath.Join(td, "out", fmt.Sprintf("app%d.conf", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("prod-app:%d", 8000+i); normalizeOut(string(got)) != "#templr generated\n"+want {
+			t.Fatalf("app%d.conf = %q, want %q", i, got, want)
+		}
+	}
+	if r := results["inline"]; r.Status != "rendered" || r.Output != "Hello prod" {
+		t.Fatalf("unexpected inline result %+v", r)
+	}
+	if r := results["empty"]; r.Status != "empty" {
+		t.Fatalf("unexpected empty result %+v", r)
+	}
+	if _, err := os.Stat(filepath.Join(td, "out", "empty.txt")); !os.IsNotExist(err) {
+		t.Fatalf("empty output should not be written: %v", err)
+	}
+	if r := results["bad"]; r.Status != "error" || !strings.Contains(r.Error, "missing") {
+		t.Fatalf("unexpected bad result %+v", r)
+	}
+	if r := results[""]; r.Status != "error" || r.Line != 24 || !strings.Contains(r.Error, "bad job") {
+		t.Fatalf("unexpected result for the invalid line %+v", r)
+	}
+
+	// A second run leaves the files alone; --dry-run reports what would change
+	results, _, err = runBatch(t, bin, td, `{"id":"same","template":"app.tpl","values":{"port":8000},"out":"out/app0.conf"}`+"\n"+
+		`{"id":"new","template":"app.tpl","out":"out/new.conf"}`+"\n", "-d", "values.yaml", "--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results["same"].Status != "unchanged" || results["new"].Status != "changed" {
+		t.Fatalf("unexpected dry-run results %+v", results)
+	}
+	if _, err := os.Stat(filepath.Join(td, "out", "new.conf")); !os.IsNotExist(err) {
+		t.Fatalf("--dry-run should not write: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBinaryTemplates(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"app.conf.tpl": "name={{ .name }}\n",
+		"blob.tpl":     "\x00\x01{{ .name",
+		"latin1.tpl":   "caf\xe9 {{ .name }}\n",
+		"logo.png":     "{{ .name }}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("walk_skips", func(t *testing.T) {
+		dst := filepath.Join(td, "out")
+		_, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--ext", "png", "--set", "name=x")
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		for _, want := range []string{
+			"[templr:warn:binary] skip blob.tpl: NUL byte at offset 0, not a template",
+			"[templr:warn:binary] skip latin1.tpl: not valid UTF-8, not a template",
+			"[templr:warn:binary] skip logo.png: .png files are binary, not a template",
+		} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("expected %q in stderr, got:\n%s", want, stderr)
+			}
+		}
+		entries, _ := os.ReadDir(dst)
+		if len(entries) != 1 || entries[0].Name() != "app.conf" {
+			t.Errorf("expected only app.conf to be rendered, got %v", entries)
+		}
+	})
+
+	t.Run("lint_warns", func(t *testing.T) {
+		stdout, _, err := run(t, bin, "lint", "--no-color", "--src", src)
+		if err != nil {
+			t.Fatalf("expected warnings only: %v\n%s", err, stdout)
+		}
+		if !strings.Contains(stdout, "[lint:warn:binary] "+filepath.Join(src, "blob.tpl")+": NUL byte at offset 0") {
+			t.Errorf("expected a binary warning, got:\n%s", stdout)
+		}
+	})
+
+	t.Run("render_refuses", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "render", "-i", filepath.Join(src, "blob.tpl"))
+		if code := getExitCode(err); code != 2 || !strings.Contains(stderr, "NUL byte at offset 0, not a template") {
+			t.Fatalf("expected a template error, got %d\n%s", code, stderr)
+		}
+	})
+}
@@ -358,3 +358,48 @@ undefined: {{ .notdefined }}`
 		t.Fatal("expected lint to fail because CLI --fail-on-warn overrides config")
 	}
 }
+
+// TestConfigDisableFunctions tests that functions.disable removes functions at
+// render time and is reported by lint
+func TestConfigDisableFunctions(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	config := "functions:\n  disable:\n    - env\n"
+	if err := os.WriteFile(filepath.Join(td, ".templr.yaml"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tplPath := filepath.Join(td, "test.tpl")
+	if err := os.WriteFile(tplPath, []byte(`home: {{ env "HOME" }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	okPath := filepath.Join(td, "ok.tpl")
+	if err := os.WriteFile(okPath, []byte(`{{ upper "ok" }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, err := runIn(t, td, bin, "render", "-i", tplPath)
+	if err == nil {
+		t.Fatal("expected render to fail with env disabled")
+	}
+	if !strings.Contains(stderr, `function "env" not defined`) {
+		t.Fatalf("unexpected error: %s", stderr)
+	}
+
+	stdout, stderr, err := runIn(t, td, bin, "render", "-i", okPath)
+	if err != nil {
+		t.Fatalf("render failed: %v\n%s", err, stderr)
+	}
+	if strings.TrimSpace(stdout) != "OK" {
+		t.Fatalf("unexpected output: %q", stdout)
+	}
+
+	stdout, _, err = runIn(t, td, bin, "lint", "-i", tplPath, "--no-color")
+	if err == nil {
+		t.Fatal("expected lint to fail on disabled function")
+	}
+	if !strings.Contains(stdout, `disallowed function "env"`) {
+		t.Fatalf("expected disallowed function issue, got: %s", stdout)
+	}
+}
@@ -0,0 +1,85 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCryptoPolicyFIPS(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	legacy := filepath.Join(td, "legacy.tpl")
+	sha1 := filepath.Join(td, "sha1.tpl")
+	approved := filepath.Join(td, "approved.tpl")
+	files := map[string]string{
+		sha1:     "digest: {{ sha1sum \"a\" }}\n",
+		legacy:   "digest: {{ sha1sum \"a\" }}\nkey: |\n{{ genPrivateKey \"dsa\" | indent 2 }}\n",
+		approved: "digest: {{ sha256sum \"a\" }}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("default_policy_allows", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", sha1)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "86f7e437faa5a7fce15d1ddcb9eaeaea377667b8") {
+			t.Errorf("expected the sha1 digest, got:\n%s", stdout)
+		}
+	})
+
+	t.Run("render_rejects", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "render", "--no-color", "--crypto-policy", "fips", "-i", legacy)
+		if code := getExitCode(err); code != 2 {
+			t.Fatalf("expected exit code 2, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "sha1sum is not allowed by the fips crypto policy") {
+			t.Errorf("expected crypto policy error, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("render_allows_approved", func(t *testing.T) {
+		if _, stderr, err := run(t, bin, "render", "--no-color", "--crypto-policy", "fips", "-i", approved); err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+	})
+
+	t.Run("lint_reports", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "lint", "--no-color", "--crypto-policy", "fips", "-i", legacy)
+		if code := getExitCode(err); code != 7 {
+			t.Fatalf("expected exit code 7, got %d\n%s%s", code, stdout, stderr)
+		}
+		out := stdout + stderr
+		for _, want := range []string{"legacy.tpl:1: sha1sum is not allowed", `legacy.tpl:3: genPrivateKey "dsa" is not allowed`, "2 error(s)"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected %q in lint output, got:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("config", func(t *testing.T) {
+		cfg := filepath.Join(td, "templr.yaml")
+		if err := os.WriteFile(cfg, []byte("functions:\n  crypto_policy: fips\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err := run(t, bin, "render", "--no-color", "--config", cfg, "-i", legacy)
+		if code := getExitCode(err); code != 2 || !strings.Contains(stderr, "fips crypto policy") {
+			t.Errorf("expected crypto policy error from config, got %d\n%s", code, stderr)
+		}
+	})
+
+	t.Run("invalid_policy", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "render", "--no-color", "--crypto-policy", "strict", "-i", approved)
+		if code := getExitCode(err); code == 0 || !strings.Contains(stderr, `invalid --crypto-policy "strict"`) {
+			t.Errorf("expected usage error, got %d\n%s", code, stderr)
+		}
+	})
+}
@@ -290,3 +290,41 @@ func TestDebugOutputToStderr(t *testing.T) {
 		t.Errorf("Output file should not contain debug info, got: %s", string(result))
 	}
 }
+
+func TestDebugFunctionCalls(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	tmpDir := t.TempDir()
+	tplFile := filepath.Join(tmpDir, "calls.tpl")
+	template := `{{ define "item" }}{{ upper . }}{{ end }}{{ range until 30 }}{{ include "item" "x" }}{{ end }}
+{{ lower "Y" }}`
+	if err := os.WriteFile(tplFile, []byte(template), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := filepath.Join(tmpDir, "templr.yaml")
+	if err := os.WriteFile(cfg, []byte("debug:\n  top_functions: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, err := run(t, bin, "render", "-i", tplFile, "--debug")
+	if err != nil {
+		t.Fatalf("render failed: %v\n%s", err, stderr)
+	}
+	for _, want := range []string{"[DEBUG] Template Function Calls", "include ", "30 calls", "until ", "1 call "} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("expected %q in the debug output, got:\n%s", want, stderr)
+		}
+	}
+
+	_, stderr, _ = run(t, bin, "render", "-i", tplFile, "--debug", "--config", cfg)
+	section := stderr[strings.Index(stderr, "Template Function Calls"):]
+	if n := strings.Count(section, " call"); n != 2 {
+		t.Errorf("expected debug.top_functions to list 2 functions, got %d:\n%s", n, section)
+	}
+
+	_, stderr, _ = run(t, bin, "render", "-i", tplFile)
+	if strings.Contains(stderr, "Template Function Calls") {
+		t.Errorf("expected no function statistics without --debug, got:\n%s", stderr)
+	}
+}
@@ -0,0 +1,61 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDictHelpers(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	values := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(values, []byte("env: {B: 2, A: 1}\ndb_host: h\ndb_port: 5432\nname: app\ningress: {tls: null, host: x.example}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	render := func(src string) (string, string, error) {
+		tpl := filepath.Join(td, "t.tpl")
+		if err := os.WriteFile(tpl, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return run(t, bin, "render", "--no-color", "-i", tpl, "-d", values)
+	}
+
+	cases := []struct {
+		name, src, want string
+	}{
+		{"toPairs", `{{ range toPairs .env }}{{ .key }}={{ .value }};{{ end }}`, "A=1;B=2;"},
+		{"fromPairs", `{{ $m := fromPairs (list (list "a" 1) (dict "key" "b" "value" 2) (list "a" 3)) }}{{ $m.a }} {{ $m.b }}`, "3 2"},
+		{"roundTrip", `{{ toJson (fromPairs (toPairs .env)) }}`, `{"A":1,"B":2}`},
+		{"renameKey", `{{ $m := renameKey .env "A" "C" }}{{ keys $m | sortAlpha | join "," }} {{ keys .env | sortAlpha | join "," }}`, "B,C A,B"},
+		{"pickRegex", `{{ keys (pickRegex . "^db_") | sortAlpha | join "," }}`, "db_host,db_port"},
+		{"deepGet", `{{ deepGet . "ingress.host" }} {{ deepGet . "ingress.missing.x" | default "none" }}`, "x.example none"},
+		{"deepHas", `{{ deepHas . "ingress.tls" }} {{ deepHas . "ingress.port" }} {{ deepHas . "name.x" }}`, "true false false"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr, err := render(tc.src)
+			if err != nil {
+				t.Fatalf("render failed: %v\n%s", err, stderr)
+			}
+			if stdout != tc.want {
+				t.Fatalf("got %q, want %q", stdout, tc.want)
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		for src, want := range map[string]string{
+			`{{ fromPairs (list (list "a")) }}`: "pair 0 has 1 items, want 2",
+			`{{ pickRegex . "(" }}`:             "pickRegex: error parsing regexp",
+		} {
+			_, stderr, err := render(src)
+			if err == nil || !strings.Contains(stderr, want) {
+				t.Errorf("%s: expected an error with %q, got %v\n%s", src, want, err, stderr)
+			}
+		}
+	})
+}
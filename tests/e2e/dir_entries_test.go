@@ -0,0 +1,77 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirMultipleEntries(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	dir := filepath.Join(td, "tpl")
+	if err := os.MkdirAll(filepath.Join(dir, "configs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"_helpers.tpl":     `{{ define "greet" }}hello {{ .name }}{{ end }}`,
+		"configs/a.tpl":    "a: {{ include \"greet\" . }}\n",
+		"configs/b.tpl":    "b: {{ .name }}\n",
+		"configs/_p.tpl":   "partial\n",
+		"main.tpl":         "main\n",
+		"configs/note.txt": "not a template\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := filepath.Join(td, "out")
+
+	stdout, stderr, err := run(t, bin, "dir", "--dir", dir, "-i", "configs/*.tpl", "-i", filepath.Join(dir, "main.tpl"),
+		"--output-dir", out, "--set", "name=x")
+	if err != nil {
+		t.Fatalf("dir failed: %v\n%s", err, stderr)
+	}
+	for name, want := range map[string]string{"configs/a": "a: hello x", "configs/b": "b: x", "main": "main"} {
+		b, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatalf("missing output %s: %v\nstdout:\n%s", name, err, stdout)
+		}
+		if !strings.Contains(string(b), want) || !strings.Contains(string(b), "#templr generated") {
+			t.Errorf("%s: unexpected content:\n%s", name, b)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "configs", "_p")); !os.IsNotExist(err) {
+		t.Errorf("partial rendered by glob")
+	}
+
+	// Files without the guard are left alone
+	if err := os.WriteFile(filepath.Join(out, "main"), []byte("hand edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, err = run(t, bin, "dir", "--dir", dir, "-i", "main.tpl", "--output-dir", out)
+	if err != nil {
+		t.Fatalf("dir failed: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stderr, "skip (guard missing)") {
+		t.Errorf("expected guard warning, got:\n%s", stderr)
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-i", "configs/*.tpl"}, "require --output-dir"},
+		{[]string{"-i", "nope/*.tpl", "--output-dir", out}, "matched no templates"},
+		{[]string{"-i", "main.tpl", "-i", "configs/a.tpl", "--output-dir", out, "-o", "x"}, "--out cannot be combined"},
+	} {
+		_, stderr, err := run(t, bin, append([]string{"dir", "--dir", dir}, tc.args...)...)
+		if err == nil || !strings.Contains(stderr, tc.want) {
+			t.Errorf("%v: expected %q, got err=%v\n%s", tc.args, tc.want, err, stderr)
+		}
+	}
+}
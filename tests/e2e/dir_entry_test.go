@@ -0,0 +1,97 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirDefaultEntry(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	setup := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range files {
+			p := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		config  string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "index_convention",
+			files: map[string]string{"a.tpl": "a\n", "index.tpl": "index\n", "sub/main.tpl": "sub\n"},
+			want:  "index",
+		},
+		{
+			name:  "only_template",
+			files: map[string]string{"_helpers.tpl": `{{ define "x" }}x{{ end }}`, "page.tpl": "page {{ template \"x\" }}\n"},
+			want:  "page x",
+		},
+		{
+			name:  "root_define",
+			files: map[string]string{"a.tpl": `{{ define "root" }}root{{ end }}`, "main.tpl": "main\n"},
+			want:  "root",
+		},
+		{
+			name:   "config_entry",
+			files:  map[string]string{"a.tpl": "a\n", "b/c.tpl": "c\n"},
+			config: "dir:\n  entry: b/c.tpl\n",
+			want:   "c",
+		},
+		{
+			name:    "ambiguous",
+			files:   map[string]string{"a.tpl": "a\n", "b.tpl": "b\n"},
+			wantErr: "cannot choose the entry template among a.tpl, b.tpl",
+		},
+		{
+			name:    "both_conventions",
+			files:   map[string]string{"a.tpl": "a\n", "index.tpl": "index\n", "main.tpl": "main\n"},
+			wantErr: "among index.tpl, main.tpl",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := setup(t, tc.files)
+			args := []string{"dir", "--no-color", "--dir", dir}
+			if tc.config != "" {
+				cfg := filepath.Join(t.TempDir(), "templr.yaml")
+				if err := os.WriteFile(cfg, []byte(tc.config), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				args = append(args, "--config", cfg)
+			}
+			stdout, stderr, err := run(t, bin, args...)
+			if tc.wantErr != "" {
+				if code := getExitCode(err); code != 1 {
+					t.Fatalf("expected exit code 1, got %d\n%s", code, stderr)
+				}
+				if !strings.Contains(stderr, tc.wantErr) {
+					t.Fatalf("expected %q, got:\n%s", tc.wantErr, stderr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("dir failed: %v\n%s", err, stderr)
+			}
+			if strings.TrimSpace(stdout) != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDockerfileLabels(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(src, "Dockerfile.tpl"):     "FROM golang AS build\nRUN make\nFROM alpine:{{ .tag }}\nCOPY --from=build /app /app",
+		filepath.Join(src, "app.dockerfile.tpl"): "# no stages\nRUN true\n",
+		filepath.Join(src, "app.yaml.tpl"):       "FROM: {{ .tag }}\n",
+		filepath.Join(src, "values.yaml"):        "tag: \"3.20\"\n",
+		filepath.Join(td, "labels.yaml"):         "render:\n  dockerfile_labels: true\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := filepath.Join(td, "labels.yaml")
+	digestRe := regexp.MustCompile(`templr\.values\.digest="(sha256:[0-9a-f]{64})"`)
+
+	var digest string
+	t.Run("walk", func(t *testing.T) {
+		dst := filepath.Join(td, "out")
+		if _, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--config", cfg); err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		b, err := os.ReadFile(filepath.Join(dst, "Dockerfile"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := string(b)
+		if !strings.Contains(got, "COPY --from=build /app /app\nLABEL templr.version=") {
+			t.Errorf("LABEL not appended after the final stage:\n%s", got)
+		}
+		if !strings.Contains(got, `templr.template="Dockerfile.tpl"`) {
+			t.Errorf("template label missing:\n%s", got)
+		}
+		m := digestRe.FindStringSubmatch(got)
+		if m == nil {
+			t.Fatalf("values digest label missing:\n%s", got)
+		}
+		digest = m[1]
+
+		for _, name := range []string{"app.dockerfile", "app.yaml"} {
+			b, err := os.ReadFile(filepath.Join(dst, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(b), "LABEL") {
+				t.Errorf("%s should not be labelled:\n%s", name, b)
+			}
+		}
+	})
+
+	t.Run("render_same_digest", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "-i", filepath.Join(src, "Dockerfile.tpl"), "-d", filepath.Join(src, "values.yaml"), "--config", cfg)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		m := digestRe.FindStringSubmatch(stdout)
+		if m == nil || m[1] != digest {
+			t.Errorf("render digest differs from walk (%s):\n%s", digest, stdout)
+		}
+	})
+
+	t.Run("digest_follows_values", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "-i", filepath.Join(src, "Dockerfile.tpl"), "-d", filepath.Join(src, "values.yaml"), "--set", "tag=edge", "--config", cfg)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if m := digestRe.FindStringSubmatch(stdout); m == nil || m[1] == digest {
+			t.Errorf("digest did not change with --set:\n%s", stdout)
+		}
+	})
+
+	t.Run("off_by_default", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "-i", filepath.Join(src, "Dockerfile.tpl"), "-d", filepath.Join(src, "values.yaml"))
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if strings.Contains(stdout, "LABEL") {
+			t.Errorf("labels added without dockerfile_labels:\n%s", stdout)
+		}
+	})
+}
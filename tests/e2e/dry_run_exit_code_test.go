@@ -0,0 +1,80 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRunExitCode(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "app.conf.tpl"), []byte("name={{ .name }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(td, "out")
+	walk := []string{"walk", "--no-color", "--src", src, "--dst", dst, "--set", "name=web"}
+	plan := append(walk, "--dry-run", "--exit-code")
+
+	t.Run("new_output", func(t *testing.T) {
+		_, stderr, err := run(t, bin, plan...)
+		if code := getExitCode(err); code != 14 {
+			t.Fatalf("expected exit code 14, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "1 output would change") {
+			t.Fatalf("expected the change count, stderr=%s", stderr)
+		}
+		if _, err := os.Stat(filepath.Join(dst, "app.conf")); !os.IsNotExist(err) {
+			t.Fatal("expected the dry run to write nothing")
+		}
+	})
+
+	if _, stderr, err := run(t, bin, walk...); err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+
+	t.Run("no_changes", func(t *testing.T) {
+		if _, stderr, err := run(t, bin, plan...); err != nil {
+			t.Fatalf("expected exit code 0, got %d\n%s", getExitCode(err), stderr)
+		}
+	})
+
+	t.Run("changed_value", func(t *testing.T) {
+		_, stderr, err := run(t, bin, append(plan, "--set", "name=api")...)
+		if code := getExitCode(err); code != 14 {
+			t.Fatalf("expected exit code 14, got %d\n%s", code, stderr)
+		}
+	})
+
+	t.Run("without_exit_code", func(t *testing.T) {
+		if _, stderr, err := run(t, bin, append(walk, "--dry-run", "--set", "name=api")...); err != nil {
+			t.Fatalf("expected a plain dry run to exit 0: %v\n%s", err, stderr)
+		}
+	})
+
+	t.Run("render_out", func(t *testing.T) {
+		in := filepath.Join(src, "app.conf.tpl")
+		out := filepath.Join(dst, "app.conf")
+		if _, stderr, err := run(t, bin, "render", "--no-color", "-i", in, "-o", out, "--set", "name=web", "--dry-run", "--exit-code"); err != nil {
+			t.Fatalf("expected exit code 0, got %d\n%s", getExitCode(err), stderr)
+		}
+		_, stderr, err := run(t, bin, "render", "--no-color", "-i", in, "-o", out, "--set", "name=db", "--dry-run", "--exit-code")
+		if code := getExitCode(err); code != 14 {
+			t.Fatalf("expected exit code 14, got %d\n%s", code, stderr)
+		}
+	})
+
+	t.Run("requires_dry_run", func(t *testing.T) {
+		_, stderr, err := run(t, bin, append(walk, "--exit-code")...)
+		if code := getExitCode(err); code != 1 || !strings.Contains(stderr, "--exit-code requires --dry-run") {
+			t.Fatalf("expected an args error, got %d\n%s", code, stderr)
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package e2e
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWalkDstArchive(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(filepath.Join(src, "conf", "nested"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(src, "app.yaml.tpl"):                "name: {{ .name }}\n",
+		filepath.Join(src, "conf", "nested", "b.txt.tpl"): "b\n",
+		filepath.Join(src, "conf", "empty.txt.tpl"):       "{{- /* nothing */ -}}",
+		filepath.Join(src, "values.yaml"):                 "name: demo\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("tar_gz", func(t *testing.T) {
+		archive := filepath.Join(td, "out", "site.tar.gz")
+		stdout, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst-archive", archive, "--keep-empty")
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "rendered app.yaml.tpl -> "+archive+":app.yaml") {
+			t.Errorf("unexpected status lines:\n%s", stdout)
+		}
+		f, err := os.Open(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(gz)
+		entries := map[string]string{}
+		modes := map[string]int64{}
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := io.ReadAll(tr)
+			entries[hdr.Name] = string(b)
+			modes[hdr.Name] = hdr.Mode
+		}
+		if got := sortedKeys(entries); got != "app.yaml,conf/,conf/empty.txt,conf/nested/,conf/nested/b.txt" {
+			t.Errorf("unexpected entries: %s", got)
+		}
+		if !strings.Contains(entries["app.yaml"], "name: demo") || entries["conf/empty.txt"] != "" {
+			t.Errorf("unexpected content: %q", entries)
+		}
+		if modes["app.yaml"] != 0o644 || modes["conf/"] != 0o755 {
+			t.Errorf("unexpected modes: %v", modes)
+		}
+		if _, err := os.Stat(filepath.Join(td, "out", "app.yaml")); !os.IsNotExist(err) {
+			t.Errorf("outputs must not be written next to the archive")
+		}
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		archive := filepath.Join(td, "site.zip")
+		if _, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst-archive", archive); err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		zr, err := zip.OpenReader(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		entries := map[string]string{}
+		for _, f := range zr.File {
+			entries[f.Name] = f.Mode().String()
+		}
+		if got := sortedKeys(entries); got != "app.yaml,conf/,conf/nested/,conf/nested/b.txt" {
+			t.Errorf("unexpected entries: %s", got)
+		}
+		if entries["app.yaml"] != "-rw-r--r--" {
+			t.Errorf("unexpected mode of app.yaml: %s", entries["app.yaml"])
+		}
+	})
+
+	t.Run("failed_run_keeps_archive", func(t *testing.T) {
+		archive := filepath.Join(td, "keep.tar")
+		broken := filepath.Join(td, "broken")
+		if err := os.MkdirAll(broken, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(broken, "a.tpl"), []byte("{{ .missing.key }}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(archive, []byte("previous"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := run(t, bin, "walk", "--no-color", "--strict", "--src", broken, "--dst-archive", archive); err == nil {
+			t.Fatal("expected the strict walk to fail")
+		}
+		if b, _ := os.ReadFile(archive); string(b) != "previous" {
+			t.Errorf("a failed walk replaced the archive")
+		}
+		if leftovers, _ := filepath.Glob(filepath.Join(td, ".templr-*")); len(leftovers) > 0 {
+			t.Errorf("temporary files left behind: %v", leftovers)
+		}
+	})
+
+	t.Run("usage_errors", func(t *testing.T) {
+		cases := map[string][]string{
+			"want a .tar":       {"--dst-archive", filepath.Join(td, "x.rar")},
+			"none of the other": {"--dst-archive", filepath.Join(td, "x.tar"), "--dst", filepath.Join(td, "x")},
+			"--provenance":      {"--dst-archive", filepath.Join(td, "x.tar"), "--provenance", filepath.Join(td, "p.json")},
+		}
+		for want, args := range cases {
+			_, stderr, err := run(t, bin, append([]string{"walk", "--no-color", "--src", src}, args...)...)
+			if getExitCode(err) == 0 || !strings.Contains(stderr, want) {
+				t.Errorf("expected an error containing %q, got %v\n%s", want, err, stderr)
+			}
+		}
+	})
+}
+
+func sortedKeys(m map[string]string) string {
+	names := make([]string, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,74 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateTemplateNames(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	write := func(dir string, files map[string]string) {
+		t.Helper()
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	td := t.TempDir()
+
+	// Two helpers defining the same name
+	helpers := filepath.Join(td, "helpers")
+	write(helpers, map[string]string{
+		"_a.tpl":   `{{ define "greet" }}A{{ end }}`,
+		"_b.tpl":   `{{ define "greet" }}B{{ end }}`,
+		"main.tpl": "{{ template \"greet\" }}\n",
+	})
+	_, stderr, err := run(t, bin, "walk", "--src", helpers, "--dst", filepath.Join(td, "out"))
+	if code := getExitCode(err); code != 2 {
+		t.Fatalf("expected exit code 2, got %d\n%s", code, stderr)
+	}
+	if !strings.Contains(stderr, `duplicate template name "greet": defined in _a.tpl and _b.tpl`) {
+		t.Errorf("unexpected error:\n%s", stderr)
+	}
+
+	_, stderr, err = run(t, bin, "walk", "--src", helpers, "--dst", filepath.Join(td, "out"), "--allow-duplicate-templates")
+	if err != nil {
+		t.Fatalf("walk with --allow-duplicate-templates failed: %v\n%s", err, stderr)
+	}
+	b, _ := os.ReadFile(filepath.Join(td, "out", "main"))
+	if !strings.Contains(string(b), "B") {
+		t.Errorf("expected the later definition to win, got:\n%s", b)
+	}
+
+	// A helper defining the name of a template file
+	shadow := filepath.Join(td, "shadow")
+	write(shadow, map[string]string{
+		"_helpers.tpl": `{{ define "main.tpl" }}shadowed{{ end }}`,
+		"main.tpl":     "real\n",
+	})
+	_, stderr, err = run(t, bin, "dir", "--dir", shadow, "-i", "main.tpl")
+	if err == nil || !strings.Contains(stderr, `duplicate template name "main.tpl": defined in _helpers.tpl and main.tpl`) {
+		t.Errorf("expected duplicate error, got err=%v\n%s", err, stderr)
+	}
+
+	// File names differing only in case
+	caseDir := filepath.Join(td, "case")
+	write(caseDir, map[string]string{"App.tpl": "a\n"})
+	if err := os.WriteFile(filepath.Join(caseDir, "app.tpl"), []byte("b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(caseDir); len(entries) == 2 { // case-sensitive filesystem
+		_, stderr, err = run(t, bin, "walk", "--src", caseDir, "--dst", filepath.Join(td, "caseout"))
+		if err == nil || !strings.Contains(stderr, "differ only in case") {
+			t.Errorf("expected case collision error, got err=%v\n%s", err, stderr)
+		}
+	}
+}
@@ -301,3 +301,69 @@ enabled: true
 		})
 	}
 }
+
+func TestEmptyOutputKeepEmpty(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	dst := filepath.Join(td, "dst")
+	if err := os.MkdirAll(filepath.Join(src, "logs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"logs/.gitkeep.tpl": "{{/* placeholder */}}",
+		"empty.txt.tpl":     "  \n",
+		"content.txt.tpl":   "Hello",
+	} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// render.keep_empty_paths keeps matching empty renders only
+	cfg := filepath.Join(td, "templr.yaml")
+	if err := os.WriteFile(cfg, []byte("render:\n  keep_empty_paths: [\".gitkeep\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--config", cfg); err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "logs", ".gitkeep")); err != nil || info.Size() != 0 {
+		t.Errorf("expected empty logs/.gitkeep, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "empty.txt")); err == nil {
+		t.Errorf("expected empty.txt to be skipped")
+	}
+
+	// --keep-empty keeps every empty render
+	if _, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--keep-empty"); err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "empty.txt")); err != nil || info.Size() != 0 {
+		t.Errorf("expected empty empty.txt, got %v", err)
+	}
+
+	// The skip notice can be silenced or logged as JSON
+	in := filepath.Join(src, "empty.txt.tpl")
+	out := filepath.Join(td, "single.txt")
+	_, stderr, err := run(t, bin, "render", "-i", in, "-o", out, "--quiet-empty")
+	if err != nil || stderr != "" {
+		t.Errorf("expected no notice with --quiet-empty, got %v: %q", err, stderr)
+	}
+	_, stderr, err = run(t, bin, "render", "-i", in, "-o", out, "--log-format", "json")
+	if err != nil || !strings.Contains(stderr, `"level":"info"`) || !strings.Contains(stderr, "skipping empty render") {
+		t.Errorf("expected JSON notice, got %v: %q", err, stderr)
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Errorf("expected %s to be skipped", out)
+	}
+
+	if _, stderr, err = run(t, bin, "render", "-i", in, "-o", out, "--keep-empty"); err != nil {
+		t.Fatalf("render failed: %v\n%s", err, stderr)
+	}
+	if info, err := os.Stat(out); err != nil || info.Size() != 0 {
+		t.Errorf("expected empty %s, got %v", out, err)
+	}
+}
@@ -0,0 +1,62 @@
+package e2e
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputEncoding(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	in := filepath.Join(td, "app.yaml.tpl")
+	if err := os.WriteFile(in, []byte("a: 1\nb: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// --encoding utf-16le writes a BOM and UTF-16 code units
+	out := filepath.Join(td, "app.yaml")
+	if _, stderr, err := run(t, bin, "render", "-i", in, "-o", out, "--encoding", "utf-16le"); err != nil {
+		t.Fatalf("render failed: %v\n%s", err, stderr)
+	}
+	got, _ := os.ReadFile(out)
+	if !bytes.HasPrefix(got, []byte{0xFF, 0xFE}) || !bytes.Contains(got, []byte("a\x00:\x00 \x001\x00\n\x00")) {
+		t.Fatalf("expected UTF-16LE output, got %q", got)
+	}
+
+	// The guard is found in the UTF-16 file, and unchanged output is not rewritten
+	stdout, stderr, err := run(t, bin, "render", "-i", in, "-o", out, "--encoding", "utf-16le")
+	if err != nil || strings.Contains(stdout, "rendered") || strings.Contains(stderr, "guard missing") {
+		t.Errorf("expected an unchanged UTF-16 file, got %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	// --preserve-encoding keeps the BOM and CRLF line endings of an existing file
+	existing := filepath.Join(td, "win.yaml")
+	if err := os.WriteFile(existing, []byte("\xEF\xBB\xBF# #templr generated\r\nold: true\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := run(t, bin, "render", "-i", in, "-o", existing, "--preserve-encoding"); err != nil {
+		t.Fatalf("render failed: %v\n%s", err, stderr)
+	}
+	got, _ = os.ReadFile(existing)
+	if !bytes.HasPrefix(got, []byte("\xEF\xBB\xBF")) || !bytes.Contains(got, []byte("a: 1\r\nb: 2\r\n")) {
+		t.Errorf("expected BOM and CRLF to be preserved, got %q", got)
+	}
+
+	// Without the options the rendered bytes are written as is
+	if _, stderr, err := run(t, bin, "render", "-i", in, "-o", existing); err != nil {
+		t.Fatalf("render failed: %v\n%s", err, stderr)
+	}
+	got, _ = os.ReadFile(existing)
+	if bytes.HasPrefix(got, []byte("\xEF\xBB\xBF")) || bytes.Contains(got, []byte("\r\n")) {
+		t.Errorf("expected plain UTF-8 with LF, got %q", got)
+	}
+
+	if _, stderr, err := run(t, bin, "render", "-i", in, "--encoding", "latin1"); err == nil || !strings.Contains(stderr, "invalid --encoding") {
+		t.Errorf("expected invalid --encoding error, got %v\n%s", err, stderr)
+	}
+}
@@ -0,0 +1,93 @@
+package e2e
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJinjaEngine(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(filepath.Join(src, "etc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"values.yaml":       "name: web\nports: [80, 443]\ntags: {env: prod}\n",
+		"app.txt.tpl":       "app {{ .name }}\n",
+		"etc/nginx.conf.j2": "server {{ name | upper }}\n{% for p in ports %}\nlisten {{ p }};{{ ' # last' if loop.last else '' }}\n{% endfor %}\n{% include \"_foot.j2\" %}\n",
+		"_foot.j2":          "# env={{ tags.env | default('dev') }}\n",
+		"notes.tmpl":        "{{ name ~ '!' }}\n",
+		"motd.j2":           "{# banner #}\nwelcome to {{ name }}\n{% include \"_foot.j2\" %}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nginx := "server WEB\nlisten 80;\nlisten 443; # last\n# env=prod\n"
+
+	t.Run("render", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "-i", filepath.Join(src, "motd.j2"), "-d", filepath.Join(src, "values.yaml"))
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if stdout != "welcome to web\n# env=prod\n" {
+			t.Fatalf("unexpected output:\n%q", stdout)
+		}
+	})
+
+	t.Run("walk_mixed", func(t *testing.T) {
+		dst := filepath.Join(td, "out")
+		cfg := filepath.Join(td, "templr.yaml")
+		if err := os.WriteFile(cfg, []byte("render:\n  engines:\n    .tmpl: jinja\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		stdout, stderr, err := run(t, bin, "walk", "--config", cfg, "--src", src, "--dst", dst, "--inject-guard=false", "--ext", "tmpl")
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s%s", err, stdout, stderr)
+		}
+		want := map[string]string{
+			"app.txt":        "app web\n",
+			"etc/nginx.conf": nginx,
+			"motd":           "welcome to web\n# env=prod\n",
+			"notes":          "web!\n",
+		}
+		for name, content := range want {
+			b, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != content {
+				t.Errorf("%s: got %q, want %q", name, b, content)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dst, "_foot")); !os.IsNotExist(err) {
+			t.Errorf("expected the partial _foot.j2 not to be rendered")
+		}
+	})
+
+	t.Run("strict_undefined", func(t *testing.T) {
+		cmd := exec.Command(bin, "render", "--engine", "jinja", "--strict", "--set", "name=x")
+		cmd.Stdin = strings.NewReader("{{ name }}\n{{ missing.key }}\n")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		if code := getExitCode(err); code != 2 || !strings.Contains(stderr.String(), `stdin:2: "missing.key" is undefined`) {
+			t.Fatalf("expected a template error naming the line, got %d\n%s", code, stderr.String())
+		}
+	})
+
+	t.Run("unknown_engine", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "render", "-i", filepath.Join(src, "app.txt.tpl"), "--engine", "mustache")
+		if code := getExitCode(err); code != 1 || !strings.Contains(stderr, `unknown template engine "mustache"`) {
+			t.Fatalf("expected a usage error, got %d\n%s", code, stderr)
+		}
+	})
+}
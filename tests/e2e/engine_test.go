@@ -0,0 +1,166 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+func TestRenderSingleExtraFuncs(t *testing.T) {
+	res, err := templr.RenderSingle(templr.Options{
+		Template:   `{{ shout .name }} {{ upper "x" }}`,
+		ValuesYAML: "name: world\n",
+		ExtraFuncs: template.FuncMap{
+			"shout": func(s string) string { return strings.ToUpper(s) + "!" },
+			// Overrides the built-in
+			"upper": func(s string) string { return "<" + s + ">" },
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "WORLD! <x>" {
+		t.Fatalf("unexpected output: %q", res.Output)
+	}
+}
+
+func TestRenderSingleDisabledFuncs(t *testing.T) {
+	_, err := templr.RenderSingle(templr.Options{
+		Template:      `{{ env "HOME" }}`,
+		DisabledFuncs: []string{"env", "expandenv"},
+	})
+	if err == nil || !strings.Contains(err.Error(), `function "env" not defined`) {
+		t.Fatalf("expected env to be disabled, got: %v", err)
+	}
+
+	res, err := templr.RenderSingle(templr.Options{
+		Template:      `{{ lower "OK" }}`,
+		DisabledFuncs: []string{"env"},
+	})
+	if err != nil || res.Output != "ok" {
+		t.Fatalf("unexpected result %q, %v", res.Output, err)
+	}
+}
+
+func TestIncludeMemoization(t *testing.T) {
+	src := `{{ define "banner" }}[{{ tick }} {{ .title }}]{{ end }}` +
+		`{{ range .items }}{{ FN "banner" $.page }}{{ end }}|{{ FN "banner" (dict "title" "other") }}`
+	cases := []struct {
+		name  string
+		fn    string
+		cache int
+		want  string
+		calls int
+	}{
+		{"include_uncached", "include", 0, "[1 home][2 home][3 home]|[4 other]", 4},
+		{"include_cached", "include", 16, "[1 home][1 home][1 home]|[2 other]", 2},
+		{"includeCached", "includeCached", 0, "[1 home][1 home][1 home]|[2 other]", 2},
+		{"size_limit", "includeCached", 1, "[1 home][1 home][1 home]|[2 other]", 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			res, err := templr.RenderSingle(templr.Options{
+				Template:     strings.ReplaceAll(src, "FN", tc.fn),
+				ValuesYAML:   "page: {title: home}\nitems: [a, b, c]\n",
+				IncludeCache: tc.cache,
+				ExtraFuncs:   template.FuncMap{"tick": func() int { calls++; return calls }},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Output != tc.want || calls != tc.calls {
+				t.Errorf("got %q with %d renders, want %q with %d", res.Output, calls, tc.want, tc.calls)
+			}
+		})
+	}
+}
+
+func TestRenderSingleMaxOutputSize(t *testing.T) {
+	_, err := templr.RenderSingle(templr.Options{
+		Template:      `{{ range until 1000 }}0123456789{{ end }}`,
+		MaxOutputSize: 1024,
+	})
+	if err == nil || !strings.Contains(err.Error(), "output exceeds 1024 bytes") {
+		t.Fatalf("expected the output limit to stop the render, got: %v", err)
+	}
+
+	res, err := templr.RenderSingle(templr.Options{
+		Template:      `{{ range until 10 }}0123456789{{ end }}`,
+		MaxOutputSize: 1024,
+	})
+	if err != nil || len(res.Output) != 100 {
+		t.Fatalf("expected a render within the limit, got %d bytes, err=%v", len(res.Output), err)
+	}
+}
+
+func TestRenderTree(t *testing.T) {
+	outputs, err := templr.RenderTree(templr.TreeOptions{
+		Options: templr.Options{ValuesYAML: "name: web\n", Helpers: `{{ define "greet" }}hello{{ end }}`},
+		Templates: map[string]string{
+			"_port.tpl":          `{{ define "port" }}{{ .port | default 8080 }}{{ end }}`,
+			"app.conf.tpl":       `{{ include "greet" . }} {{ .name }}:{{ include "port" . }}`,
+			"conf/empty.txt.tpl": `{{ if false }}x{{ end }}`,
+			"README.md":          "static",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"app.conf": "hello web:8080", "README.md": "static"}
+	if len(outputs) != len(want) {
+		t.Fatalf("unexpected outputs: %v", outputs)
+	}
+	for k, v := range want {
+		if outputs[k] != v {
+			t.Fatalf("output %s = %q, want %q", k, outputs[k], v)
+		}
+	}
+
+	_, err = templr.RenderTree(templr.TreeOptions{Templates: map[string]string{"bad.tpl": "{{ fail \"boom\" }}"}})
+	if err == nil || !strings.Contains(err.Error(), "render bad.tpl") || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected the failing template to be named, got: %v", err)
+	}
+}
+
+func TestRenderContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := templr.RenderSingleContext(ctx, templr.Options{Template: `{{ range until 1000 }}x{{ end }}`})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected RenderSingleContext to stop, got: %v", err)
+	}
+	_, err = templr.RenderTreeContext(ctx, templr.TreeOptions{Templates: map[string]string{"a.tpl": "a"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected RenderTreeContext to stop, got: %v", err)
+	}
+}
+
+func TestRenderSingleIncludeCycle(t *testing.T) {
+	_, err := templr.RenderSingle(templr.Options{
+		Template: `{{ define "a" }}{{ include "b" . }}{{ end }}{{ define "b" }}{{ include "a" . }}{{ end }}` +
+			`{{ define "page" }}{{ include "a" . }}{{ end }}{{ include "page" . }}`,
+	})
+	var depthErr *templr.IncludeDepthError
+	if !errors.As(err, &depthErr) || depthErr.Limit != templr.DefaultIncludeMaxDepth {
+		t.Fatalf("expected an include depth error, got: %v", err)
+	}
+	if got := depthErr.Cycle(); got != "page -> a -> b -> a" {
+		t.Fatalf("unexpected cycle %q", got)
+	}
+
+	// Recursion that ends within the limit renders
+	countdown := `{{ define "n" }}{{ if gt . 0 }}{{ . }}{{ include "n" (sub . 1) }}{{ end }}{{ end }}{{ include "n" 3 }}`
+	res, err := templr.RenderSingle(templr.Options{Template: countdown, IncludeMaxDepth: 4})
+	if err != nil || res.Output != "321" {
+		t.Fatalf("unexpected result %q, %v", res.Output, err)
+	}
+	_, err = templr.RenderSingle(templr.Options{Template: countdown, IncludeMaxDepth: 3})
+	if err == nil || !strings.Contains(err.Error(), "include depth limit 3 exceeded: n -> n") {
+		t.Fatalf("expected the limit of 3 to stop the render, got: %v", err)
+	}
+}
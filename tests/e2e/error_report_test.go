@@ -0,0 +1,155 @@
+package e2e
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestErrorReportSnippet checks that parse and include errors show the
+// offending line of the innermost template and a hint.
+func TestErrorReportSnippet(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "app.tpl"), []byte("name: x\nport: {{ tolower .port }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, err := run(t, bin, "walk", "--src", src, "--dst", filepath.Join(td, "out"), "--no-color")
+	if code := getExitCode(err); code != 2 {
+		t.Fatalf("expected exit code 2, got %d\n%s", code, stderr)
+	}
+	for _, want := range []string{
+		`function "tolower" not defined`,
+		"  app.tpl:2\n",
+		"   2 | port: {{ tolower .port }}",
+		"Tip: \"tolower\" is not a template function; run `templr funcs`",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("missing %q in:\n%s", want, stderr)
+		}
+	}
+	if strings.Contains(stderr, "\033[") {
+		t.Errorf("ANSI codes with --no-color:\n%s", stderr)
+	}
+
+	// Errors inside an included template point at the partial
+	dir := filepath.Join(td, "dir")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "_p.tpl"), []byte("{{ define \"p\" }}\n  v: {{ .a.b }}\n{{ end }}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.tpl"), []byte("top\n{{ include \"p\" . }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, err = run(t, bin, "dir", "--dir", dir, "-i", "main.tpl", "--set", "a=3", "--no-color")
+	if code := getExitCode(err); code != 2 {
+		t.Fatalf("expected exit code 2, got %d\n%s", code, stderr)
+	}
+	for _, want := range []string{
+		"  _p.tpl:2:10\n",
+		"   2 |   v: {{ .a.b }}\n     |           ^ Error occurred here",
+		`Tip: Field "b" was looked up on a value that is not a map`,
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("missing %q in:\n%s", want, stderr)
+		}
+	}
+}
+
+func TestErrorReportJSON(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	tplPath := filepath.Join(td, "app.tpl")
+	if err := os.WriteFile(tplPath, []byte("a\n{{ if .x }}\nb\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, err := run(t, bin, "render", "-i", tplPath, "--log-format", "json")
+	if code := getExitCode(err); code != 2 {
+		t.Fatalf("expected exit code 2, got %d\n%s", code, stderr)
+	}
+	var rec struct {
+		Level    string `json:"level"`
+		Kind     string `json:"kind"`
+		Message  string `json:"message"`
+		Template string `json:"template"`
+		Line     int    `json:"line"`
+		Hint     string `json:"hint"`
+	}
+	if err := json.Unmarshal([]byte(stderr), &rec); err != nil {
+		t.Fatalf("stderr is not a JSON object: %v\n%s", err, stderr)
+	}
+	if rec.Level != "error" || rec.Kind != "parse" || rec.Template != tplPath || rec.Line != 4 {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+	if !strings.Contains(rec.Message, "unexpected EOF") || !strings.Contains(rec.Hint, "{{ end }}") {
+		t.Errorf("unexpected message or hint: %+v", rec)
+	}
+
+	// Strict errors use the same record
+	if err := os.WriteFile(tplPath, []byte("k: {{ .missing }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, err = run(t, bin, "render", "-i", tplPath, "--strict", "--log-format", "json")
+	if code := getExitCode(err); code != 4 {
+		t.Fatalf("expected exit code 4, got %d\n%s", code, stderr)
+	}
+	if err := json.Unmarshal([]byte(stderr), &rec); err != nil {
+		t.Fatalf("stderr is not a JSON object: %v\n%s", err, stderr)
+	}
+	if rec.Kind != "strict" || !strings.Contains(rec.Hint, "'missing'") {
+		t.Errorf("unexpected strict record: %+v", rec)
+	}
+
+	_, stderr, err = run(t, bin, "render", "-i", tplPath, "--log-format", "yaml")
+	if err == nil || !strings.Contains(stderr, "invalid --log-format") {
+		t.Errorf("expected invalid --log-format error, got: %v\n%s", err, stderr)
+	}
+}
+
+// TestErrorReportParsedSource checks that the context of a render error is
+// the source that was parsed, even when the file changed on disk since: in
+// place, m.tpl.tpl overwrites m.tpl before n.tpl runs the template it
+// defines.
+func TestErrorReportParsedSource(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	for name, content := range map[string]string{
+		"m.tpl":     "{{/* #templr generated */}}{{ define \"boom\" }}\nparsed {{ fail \"boom failed\" }}\n{{ end }}",
+		"m.tpl.tpl": "line one\nchanged on disk\n",
+		"n.tpl":     "{{ template \"boom\" . }}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(td, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, stderr, err := run(t, bin, "walk", "--src", td, "--dst", td, "--inject-guard=false", "--no-color")
+	if code := getExitCode(err); code != 2 {
+		t.Fatalf("expected exit code 2, got %d\n%s", code, stderr)
+	}
+	if b, _ := os.ReadFile(filepath.Join(td, "m.tpl")); string(b) != "line one\nchanged on disk\n" {
+		t.Fatalf("m.tpl was not overwritten before the error:\n%s\n%s", b, stderr)
+	}
+	if want := "   2 | parsed {{ fail \"boom failed\" }}\n     |           ^ Error occurred here"; !strings.Contains(stderr, want) {
+		t.Errorf("missing %q in:\n%s", want, stderr)
+	}
+	if strings.Contains(stderr, "changed on disk") {
+		t.Errorf("error context shows the file on disk:\n%s", stderr)
+	}
+}
@@ -0,0 +1,59 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWalkExisting(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tpl := "{{- if not .Existing.Exists }}# first render\n{{ end -}}\n" +
+		"db:\n  host: {{ .host }}\n  password: {{ .Existing.Get \"db.password\" | default (randAlphaNum 24) }}\n"
+	if err := os.WriteFile(filepath.Join(src, "config.yaml.tpl"), []byte(tpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(td, "out")
+	out := filepath.Join(dst, "config.yaml")
+
+	password := func(content string) string {
+		t.Helper()
+		for _, line := range strings.Split(content, "\n") {
+			if p, ok := strings.CutPrefix(strings.TrimSpace(line), "password: "); ok {
+				return p
+			}
+		}
+		t.Fatalf("no password in output:\n%s", content)
+		return ""
+	}
+
+	if _, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--set", "host=a"); err != nil {
+		t.Fatalf("first walk failed: %v\n%s", err, stderr)
+	}
+	first, _ := os.ReadFile(out)
+	if !strings.Contains(string(first), "# first render") {
+		t.Fatalf("expected .Existing.Exists to be false on the first render:\n%s", first)
+	}
+
+	if _, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--set", "host=b"); err != nil {
+		t.Fatalf("second walk failed: %v\n%s", err, stderr)
+	}
+	second, _ := os.ReadFile(out)
+	if strings.Contains(string(second), "# first render") {
+		t.Fatalf("expected .Existing.Exists to be true on the second render:\n%s", second)
+	}
+	if !strings.Contains(string(second), "host: b") {
+		t.Fatalf("expected the new host:\n%s", second)
+	}
+	if p1, p2 := password(string(first)), password(string(second)); p1 != p2 || len(p1) != 24 {
+		t.Fatalf("expected the password to be kept, got %q then %q", p1, p2)
+	}
+}
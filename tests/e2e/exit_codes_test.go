@@ -429,3 +429,42 @@ func TestExitCodes_ErrorMessageFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestExitCodes_TypedErrors(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	in := filepath.Join(td, "in.tpl")
+	if err := os.WriteFile(in, []byte(`{{ fail "could not load data for the guard" }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	badCfg := filepath.Join(td, "bad.yaml")
+	if err := os.WriteFile(badCfg, []byte("render: [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		// The words in the rendered message no longer decide the exit code
+		{"render error mentioning data", []string{"render", "-i", in}, 2},
+		{"invalid guard style", []string{"render", "-i", in, "--guard-style", "x"}, 1},
+		{"unreadable config", []string{"render", "-i", in, "--config", badCfg}, 1},
+		{"missing data file", []string{"render", "-i", in, "-d", filepath.Join(td, "none.yaml")}, 3},
+		{"exit-zero", []string{"render", "-i", in, "--exit-zero"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, err := run(t, bin, tt.args...)
+			if code := getExitCode(err); code != tt.code {
+				t.Errorf("expected exit code %d, got %d\n%s", tt.code, code, stderr)
+			}
+			if !strings.Contains(stderr, "Error: ") {
+				t.Errorf("expected the error to be reported, got:\n%s", stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplainMissing(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"api.tpl":    "host: {{ .db.host }}\nport: {{ .db.port }}\n",
+		"worker.tpl": "{{ range .users }}{{ .email }}\n{{ end }}port: {{ .db.port }}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	args := []string{"walk", "--src", src, "--dst", filepath.Join(td, "out"), "--set", "db.host=h", "--set", `users=[{"email":"a"},{"name":"b"}]`}
+
+	// Without the flag nothing is reported
+	_, stderr, err := run(t, bin, args...)
+	if err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	if strings.Contains(stderr, "undefined") {
+		t.Fatalf("unexpected report:\n%s", stderr)
+	}
+
+	_, stderr, err = run(t, bin, append(args, "--explain-missing")...)
+	if err != nil {
+		t.Fatalf("walk --explain-missing failed: %v\n%s", err, stderr)
+	}
+	for _, want := range []string{
+		"[templr:warn:missing] 2 templates referenced .db.port which is undefined (api.tpl:2, worker.tpl:2)",
+		"[templr:warn:missing] 1 template referenced .email which is undefined (worker.tpl:1)",
+		"2 undefined reference(s)",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("missing %q in:\n%s", want, stderr)
+		}
+	}
+
+	// Output is the same as a plain non-strict render
+	b, err := os.ReadFile(filepath.Join(td, "out", "api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "port: <no value>") {
+		t.Errorf("unexpected output:\n%s", b)
+	}
+}
+
+// TestExplainMissingMany checks that every undefined reference is found,
+// however many a template has, including under an undefined parent.
+func TestExplainMissingMany(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	var b strings.Builder
+	for i := range 600 {
+		fmt.Fprintf(&b, "{{ .k%d }}\n", i)
+	}
+	b.WriteString("{{ .db.tls.cert }} {{ .db.tls.cert | default \"none\" }}\n")
+	tpl := filepath.Join(t.TempDir(), "many.tpl")
+	if err := os.WriteFile(tpl, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "--set", "db.host=h", "--explain-missing")
+	if err != nil {
+		t.Fatalf("render --explain-missing failed: %v\n%s", err, stderr)
+	}
+	for _, want := range []string{
+		"referenced .k599 which is undefined (" + tpl + ":600)",
+		"referenced .db.tls.cert which is undefined (" + tpl + ":601)",
+		"601 undefined reference(s)",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("missing %q in:\n%s", want, stderr)
+		}
+	}
+}
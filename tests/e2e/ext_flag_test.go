@@ -30,3 +30,61 @@ func TestExtFlagInWalkMode(t *testing.T) {
 		t.Fatalf("expected rendered README without extension: %v", err)
 	}
 }
+
+func TestExtMultiDotSuffixes(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	work := t.TempDir()
+	src := filepath.Join(work, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"app.yaml.gotmpl": "app: {{ .name }}\n",
+		"notes.gotmpl":    "not a template\n",
+		"nginx.conf.tpl":  "server {}\n",
+		"db.yml.j2":       "db: {{ name }}\n",
+		"values.yaml":     "name: demo\n",
+	} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exists := func(t *testing.T, dst string, want, notWant []string) {
+		t.Helper()
+		for _, name := range want {
+			if _, err := os.Stat(filepath.Join(dst, name)); err != nil {
+				t.Errorf("expected %s: %v", name, err)
+			}
+		}
+		for _, name := range notWant {
+			if _, err := os.Stat(filepath.Join(dst, name)); err == nil {
+				t.Errorf("did not expect %s", name)
+			}
+		}
+	}
+
+	t.Run("full suffix", func(t *testing.T) {
+		dst := filepath.Join(work, "out")
+		_, stderr, err := runIn(t, work, bin, "walk", "--src", src, "--dst", dst, "--ext", "yaml.gotmpl")
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		exists(t, dst, []string{"app", "nginx.conf", "db.yml"}, []string{"notes", "app.yaml"})
+	})
+
+	t.Run("configured suffixes", func(t *testing.T) {
+		config := "files:\n  strip_suffixes: [.gotmpl, tpl]\n  output_extensions:\n    .YML.j2: yaml\n"
+		if err := os.WriteFile(filepath.Join(work, ".templr.yaml"), []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(filepath.Join(work, ".templr.yaml"))
+		dst := filepath.Join(work, "out-config")
+		_, stderr, err := runIn(t, work, bin, "walk", "--src", src, "--dst", dst, "--ext", "yaml.gotmpl")
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		exists(t, dst, []string{"app.yaml", "nginx.conf", "db.yaml"}, []string{"app", "db.yml"})
+	})
+}
@@ -0,0 +1,104 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	files := map[string]string{
+		"app.tpl": `{{ if hasFeature "proxy" }}proxy{{ else }}direct{{ end }} {{ .Templr.Features.canary | default "none" }} {{ hasFeature "off" }}`,
+		".templr.yaml": `features:
+  enabled: [proxy]
+  sets:
+    staging: [canary=10, proxy=false]
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(td, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"config_enabled", nil, "proxy none false"},
+		{"set", []string{"--feature-set", "staging"}, "direct 10 false"},
+		{"flag_wins", []string{"--feature-set", "staging", "--feature", "proxy", "--feature", "off=false"}, "proxy 10 false"},
+		{"strict_hasFeature", []string{"--strict", "--feature", "canary=1"}, "proxy 1 false"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := append([]string{"render", "-i", "app.tpl"}, tc.args...)
+			stdout, stderr, err := runIn(t, td, bin, args...)
+			if err != nil {
+				t.Fatalf("render failed: %v\n%s", err, stderr)
+			}
+			if stdout != tc.want {
+				t.Fatalf("got %q, want %q", stdout, tc.want)
+			}
+		})
+	}
+
+	t.Run("walk", func(t *testing.T) {
+		src := filepath.Join(td, "src")
+		if err := os.MkdirAll(src, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(src, "a.conf.tpl"), []byte(`{{ if .Templr.Features.beta }}beta{{ end }}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		dst := filepath.Join(td, "out")
+		if _, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--feature", "beta"); err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		if b, _ := os.ReadFile(filepath.Join(dst, "a.conf")); !strings.HasSuffix(string(b), "\nbeta") {
+			t.Fatalf("got %q, want the beta block", b)
+		}
+	})
+
+	t.Run("lint", func(t *testing.T) {
+		stdout, stderr, err := runIn(t, td, bin, "lint", "--no-color", "-i", "app.tpl")
+		if err != nil || strings.Contains(stdout, "undefined") {
+			t.Fatalf("expected .Templr.Features to be known to lint: %v\n%s%s", err, stdout, stderr)
+		}
+	})
+
+	t.Run("not_configured", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "app.tpl"), []byte(`{{ toJson . }} {{ hasFeature "x" }}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "values.yaml"), []byte("Templr: mine\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		stdout, stderr, err := runIn(t, dir, bin, "render", "-i", "app.tpl", "--set", "name=web")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if want := `{"Files":{"Root":"` + dir + `"},"Templr":"mine","name":"web"} false`; stdout != want {
+			t.Fatalf("got %q, want %q", stdout, want)
+		}
+
+		_, stderr, err = runIn(t, dir, bin, "render", "-i", "app.tpl", "--feature", "x")
+		if err != nil || !strings.Contains(stderr, "[templr:warn:features]") {
+			t.Fatalf("expected a warning about the Templr key, got %v\n%s", err, stderr)
+		}
+	})
+
+	t.Run("unknown_set", func(t *testing.T) {
+		_, stderr, err := runIn(t, td, bin, "render", "-i", "app.tpl", "--feature-set", "prod")
+		if getExitCode(err) != 1 || !strings.Contains(stderr, `--feature-set "prod": no such set in features.sets`) {
+			t.Fatalf("expected a usage error, got %v\n%s", err, stderr)
+		}
+	})
+}
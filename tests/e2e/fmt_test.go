@@ -0,0 +1,108 @@
+package e2e
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFmt(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	messy := `{{- define "item" -}}
+{{- if .a}}
+{{- range $i,$v:=.items }}
+{{-   $v|upper|quote   -}}
+{{- end}}
+{{- end }}
+{{- end -}}
+spec:
+  {{- if .x }}
+  foo: {{ .y|default "a  |  b" }}
+  {{- end }}
+{{/* keep   this */}}
+`
+	want := `{{- define "item" -}}
+  {{- if .a }}
+    {{- range $i, $v := .items }}
+      {{- $v | upper | quote -}}
+    {{- end }}
+  {{- end }}
+{{- end -}}
+spec:
+  {{- if .x }}
+  foo: {{ .y | default "a  |  b" }}
+  {{- end }}
+{{/* keep   this */}}
+`
+
+	td := t.TempDir()
+	tpl := filepath.Join(td, "app.yaml.tpl")
+	if err := os.WriteFile(tpl, []byte(messy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(td, "notes.txt"), []byte("{{x}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("check_reports_unformatted", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "fmt", "--check", td)
+		if code := getExitCode(err); code != 13 {
+			t.Fatalf("expected exit code 13, got %d\n%s", code, stderr)
+		}
+		if strings.TrimSpace(stdout) != tpl {
+			t.Errorf("expected only %s to be listed, got:\n%s", tpl, stdout)
+		}
+		if b, _ := os.ReadFile(tpl); string(b) != messy {
+			t.Errorf("--check must not rewrite files")
+		}
+	})
+
+	t.Run("rewrites_in_place", func(t *testing.T) {
+		values := filepath.Join(td, "values.yaml")
+		if err := os.WriteFile(values, []byte("x: true\ny: 1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		before, _, err := run(t, bin, "render", "-i", tpl, "-d", values, "--inject-guard=false")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, stderr, err := run(t, bin, "fmt", td); err != nil {
+			t.Fatalf("fmt failed: %v\n%s", err, stderr)
+		}
+		b, _ := os.ReadFile(tpl)
+		if string(b) != want {
+			t.Errorf("unexpected formatting:\n%s", b)
+		}
+		after, _, err := run(t, bin, "render", "-i", tpl, "-d", values, "--inject-guard=false")
+		if err != nil || after != before {
+			t.Errorf("formatting changed the output: %q -> %q (%v)", before, after, err)
+		}
+		if _, stderr, err := run(t, bin, "fmt", "--check", td); err != nil {
+			t.Errorf("expected formatted tree to pass --check: %v\n%s", err, stderr)
+		}
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		cmd := exec.Command(bin, "fmt")
+		cmd.Stdin = strings.NewReader("  {{.name}}\n")
+		out, err := cmd.Output()
+		if err != nil || string(out) != "  {{ .name }}\n" {
+			t.Errorf("unexpected stdin output %q (%v)", out, err)
+		}
+	})
+
+	t.Run("parse_error", func(t *testing.T) {
+		bad := filepath.Join(t.TempDir(), "bad.tpl")
+		if err := os.WriteFile(bad, []byte("{{ if .x }}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err := run(t, bin, "fmt", bad)
+		if code := getExitCode(err); code != 2 || !strings.Contains(stderr, "[templr:error:fmt]") {
+			t.Errorf("expected a template error, got %d\n%s", code, stderr)
+		}
+	})
+}
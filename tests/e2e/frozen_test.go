@@ -0,0 +1,95 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWalkFrozen(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("app.conf.tpl", "name={{ .name }}\n")
+	write("host.txt.tpl", "host={{ env \"TEMPLR_FROZEN_HOST\" }}\n")
+	write("values.yaml", "name: web\n")
+	dst := filepath.Join(td, "out")
+	prov := filepath.Join(td, "provenance.json")
+
+	walk := []string{"walk", "--no-color", "--src", src, "--dst", dst, "--provenance", prov}
+	t.Setenv("TEMPLR_FROZEN_HOST", "a")
+	if _, stderr, err := run(t, bin, walk...); err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	recorded, err := os.ReadFile(prov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frozen := append(walk, "--frozen")
+
+	t.Run("unchanged", func(t *testing.T) {
+		if _, stderr, err := run(t, bin, frozen...); err != nil {
+			t.Fatalf("expected the frozen walk to pass: %v\n%s", err, stderr)
+		}
+		if b, _ := os.ReadFile(prov); string(b) != string(recorded) {
+			t.Fatal("expected the statement not to be rewritten")
+		}
+	})
+
+	t.Run("changed_set", func(t *testing.T) {
+		if _, stderr, err := run(t, bin, append(frozen, "--set", "name=api")...); err != nil {
+			t.Fatalf("expected a change of inputs to be allowed: %v\n%s", err, stderr)
+		}
+		if _, stderr, err := run(t, bin, frozen...); err != nil {
+			t.Fatalf("restore failed: %v\n%s", err, stderr)
+		}
+	})
+
+	t.Run("not_deterministic", func(t *testing.T) {
+		t.Setenv("TEMPLR_FROZEN_HOST", "b")
+		_, stderr, err := run(t, bin, frozen...)
+		if code := getExitCode(err); code != 11 {
+			t.Fatalf("expected exit code 11, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "host.txt would change although its inputs match") {
+			t.Fatalf("expected the changed output to be reported, got:\n%s", stderr)
+		}
+		if b, _ := os.ReadFile(filepath.Join(dst, "host.txt")); !strings.HasSuffix(string(b), "host=a\n") {
+			t.Fatalf("expected host.txt to be left alone, got %q", b)
+		}
+	})
+
+	t.Run("new_output", func(t *testing.T) {
+		write("extra.txt.tpl", "extra\n")
+		defer os.Remove(filepath.Join(src, "extra.txt.tpl"))
+		_, stderr, err := run(t, bin, frozen...)
+		if code := getExitCode(err); code != 11 {
+			t.Fatalf("expected exit code 11, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "extra.txt is not listed in") {
+			t.Fatalf("expected the new output to be reported, got:\n%s", stderr)
+		}
+		if _, err := os.Stat(filepath.Join(dst, "extra.txt")); !os.IsNotExist(err) {
+			t.Fatal("expected extra.txt not to be written")
+		}
+	})
+
+	t.Run("requires_provenance", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--frozen")
+		if code := getExitCode(err); code != 1 {
+			t.Fatalf("expected exit code 1, got %d\n%s", code, stderr)
+		}
+	})
+}
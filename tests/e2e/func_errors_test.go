@@ -0,0 +1,75 @@
+package e2e
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+// TestFunctionErrorPosition checks that function errors name the function
+// once and point at the template file and line in every mode.
+func TestFunctionErrorPosition(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tplPath := filepath.Join(src, "limits.tpl")
+	if err := os.WriteFile(tplPath, []byte("first\nvalue: {{ clamp .v 1 10 }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		args     []string
+		location string
+	}{
+		{"render", []string{"render", "-i", tplPath, "--set", "v=abc"}, tplPath + ":2:"},
+		{"walk", []string{"walk", "--src", src, "--dst", filepath.Join(td, "out"), "--set", "v=abc"}, "limits.tpl:2:"},
+		{"dir", []string{"dir", "--dir", src, "--set", "v=abc"}, "limits.tpl:2:"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, stderr, err := run(t, bin, append(tc.args, "--no-color")...)
+			if code := getExitCode(err); code != 2 {
+				t.Fatalf("expected exit code 2, got %d\n%s", code, stderr)
+			}
+			for _, want := range []string{
+				tc.location,
+				`error calling clamp: cannot use "abc" as a number`,
+				"   2 | value: {{ clamp .v 1 10 }}",
+				"Tip: Check the arguments passed to clamp.",
+			} {
+				if !strings.Contains(stderr, want) {
+					t.Errorf("missing %q in:\n%s", want, stderr)
+				}
+			}
+			if strings.Contains(stderr, "clamp: clamp:") {
+				t.Errorf("function name repeated:\n%s", stderr)
+			}
+		})
+	}
+}
+
+func TestFuncErrorType(t *testing.T) {
+	_, err := templr.RenderSingle(templr.Options{
+		Template: `{{ cidrContains "10.0.0.1" "not-a-cidr" }}`,
+	})
+	var fe *templr.FuncError
+	if !errors.As(err, &fe) || fe.Func != "cidrContains" {
+		t.Fatalf("expected a FuncError for cidrContains, got: %v", err)
+	}
+
+	// User-supplied messages from fail/required are not prefixed
+	_, err = templr.RenderSingle(templr.Options{Template: `{{ fail "stop here" }}`})
+	if err == nil || !strings.HasSuffix(err.Error(), "error calling fail: stop here") {
+		t.Fatalf("unexpected fail error: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package e2e
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+// TestFuncRegistryComplete checks that every built-in function has registry
+// metadata and that no templr function silently shadows a Sprig one.
+func TestFuncRegistryComplete(t *testing.T) {
+	var tpl *template.Template
+	funcs := templr.BuildFuncMap(&tpl)
+
+	registered := map[string]templr.FuncInfo{}
+	for _, f := range templr.Funcs() {
+		registered[f.Name] = f
+	}
+	for name := range funcs {
+		if _, ok := registered[name]; !ok {
+			t.Errorf("function %q is missing from the registry", name)
+		}
+	}
+	for name, f := range registered {
+		if _, ok := funcs[name]; !ok {
+			t.Errorf("registered function %q is not in the func map", name)
+		}
+		if f.Category == "" {
+			t.Errorf("function %q has no category", name)
+		}
+	}
+	if c := templr.FuncCollisions(); len(c) > 0 {
+		t.Errorf("templr functions shadow Sprig functions: %v", c)
+	}
+
+	if f, ok := templr.LookupFunc("trimall"); !ok || !f.Deprecated() || f.Replacement != "trimAll" {
+		t.Errorf("unexpected trimall metadata: %+v", f)
+	}
+	if f, _ := templr.LookupFunc("set"); f.Namespace != templr.NamespaceTemplr || !f.OverridesSprig {
+		t.Errorf("unexpected set metadata: %+v", f)
+	}
+}
+
+func TestFuncsCommand(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	stdout, stderr, err := run(t, bin, "funcs", "--category", "network", "--format", "json")
+	if err != nil {
+		t.Fatalf("funcs failed: %v\n%s", err, stderr)
+	}
+	var funcs []templr.FuncInfo
+	if err := json.Unmarshal([]byte(stdout), &funcs); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	names := map[string]string{}
+	for _, f := range funcs {
+		if f.Category != "network" {
+			t.Fatalf("unexpected category in %+v", f)
+		}
+		names[f.Name] = f.Namespace
+	}
+	if names["cidrContains"] != "templr" || names["getHostByName"] != "sprig" {
+		t.Fatalf("unexpected functions: %v", names)
+	}
+
+	stdout, _, err = run(t, bin, "funcs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, "network:") || !strings.Contains(stdout, "use trimAll") {
+		t.Fatalf("unexpected text output:\n%s", stdout)
+	}
+}
+
+func TestDeprecatedFunctionWarning(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	tpl := filepath.Join(td, "t.tpl")
+	if err := os.WriteFile(tpl, []byte(`{{ trimall "$" "$5$" }} {{ trimall "-" "-x-" }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := run(t, bin, "render", "-i", tpl)
+	if err != nil {
+		t.Fatalf("render failed: %v\n%s", err, stderr)
+	}
+	if strings.TrimSpace(stdout) != "5 x" {
+		t.Fatalf("unexpected output: %q", stdout)
+	}
+	if strings.Count(stderr, "[templr:warn:deprecated]") != 1 || !strings.Contains(stderr, `use "trimAll"`) {
+		t.Fatalf("expected a single deprecation warning, got: %s", stderr)
+	}
+}
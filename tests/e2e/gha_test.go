@@ -0,0 +1,200 @@
+package e2e
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestLintProblemMatcher checks that the printed problem matcher matches the
+// lint text output, both with and without color.
+func TestLintProblemMatcher(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	stdout, stderr, err := run(t, bin, "lint", "--print-problem-matcher")
+	if err != nil {
+		t.Fatalf("print-problem-matcher failed: %v\n%s", err, stderr)
+	}
+	var matcher struct {
+		ProblemMatcher []struct {
+			Owner    string `json:"owner"`
+			Severity string `json:"severity"`
+			Pattern  []struct {
+				Regexp  string `json:"regexp"`
+				File    int    `json:"file"`
+				Line    int    `json:"line"`
+				Message int    `json:"message"`
+			} `json:"pattern"`
+		} `json:"problemMatcher"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &matcher); err != nil {
+		t.Fatalf("invalid matcher JSON: %v\n%s", err, stdout)
+	}
+	if len(matcher.ProblemMatcher) != 2 {
+		t.Fatalf("expected error and warning matchers, got %d", len(matcher.ProblemMatcher))
+	}
+
+	td := t.TempDir()
+	tpl := filepath.Join(td, "broken.tpl")
+	if err := os.WriteFile(tpl, []byte("ok\n{{ if .x }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	errRe := regexp.MustCompile(matcher.ProblemMatcher[0].Pattern[0].Regexp)
+	for _, args := range [][]string{
+		{"lint", "-i", tpl, "--no-color"},
+		{"lint", "-i", tpl},
+	} {
+		out, _, _ := run(t, bin, args...)
+		line := strings.SplitN(out, "\n", 2)[0]
+		m := errRe.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("error matcher did not match %q", line)
+		}
+		p := matcher.ProblemMatcher[0].Pattern[0]
+		if m[p.File] != tpl || m[p.Line] == "" || m[p.Message] == "" {
+			t.Fatalf("unexpected captures %q", m)
+		}
+	}
+}
+
+// TestLintGHASummary checks the Markdown table appended to $GITHUB_STEP_SUMMARY.
+func TestLintGHASummary(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	tpl := filepath.Join(td, "app.tpl")
+	values := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(tpl, []byte("{{ .missing }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(values, []byte("name: x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	summary := filepath.Join(td, "summary.md")
+	if err := os.WriteFile(summary, []byte("existing\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+
+	if _, stderr, err := run(t, bin, "lint", "-i", tpl, "-d", values, "--gha-summary"); err != nil {
+		t.Fatalf("lint failed: %v\n%s", err, stderr)
+	}
+
+	b, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(b)
+	for _, want := range []string{"existing\n", "### templr lint", "| Severity | Rule |", "variable .missing is undefined"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("summary missing %q:\n%s", want, got)
+		}
+	}
+}
+
+// TestLintGHA checks that --gha annotates, summarizes and sets the step
+// outputs alongside the chosen report format.
+func TestLintGHA(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	tpl := filepath.Join(td, "app.tpl")
+	if err := os.WriteFile(tpl, []byte("{{ .missing }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	values := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(values, []byte("name: x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	summary := filepath.Join(td, "summary.md")
+	outputs := filepath.Join(td, "outputs")
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+	t.Setenv("GITHUB_OUTPUT", outputs)
+
+	report := filepath.Join(td, "report.json")
+	stdout, stderr, err := run(t, bin, "lint", "-i", tpl, "-d", values, "--format", "json", "--output", report, "--gha")
+	if err != nil {
+		t.Fatalf("lint failed: %v\n%s", err, stderr)
+	}
+	if stdout != "" {
+		t.Errorf("expected nothing on stdout, got:\n%s", stdout)
+	}
+	if !strings.Contains(stderr, "::warning file="+tpl+"::variable .missing is undefined") {
+		t.Errorf("expected an annotation on stderr, got:\n%s", stderr)
+	}
+	var parsed map[string]any
+	if b, err := os.ReadFile(report); err != nil || json.Unmarshal(b, &parsed) != nil {
+		t.Fatalf("expected a JSON report: %v", err)
+	}
+	if b, _ := os.ReadFile(summary); !strings.Contains(string(b), "### templr lint") {
+		t.Errorf("expected a step summary, got:\n%s", b)
+	}
+	b, _ := os.ReadFile(outputs)
+	if want := "errors=0\nwarnings=1\nreport=" + report + "\n"; string(b) != want {
+		t.Errorf("got step outputs %q, want %q", b, want)
+	}
+}
+
+// TestWalkGHASummary checks the rendered-files table written by walk.
+func TestWalkGHASummary(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	dst := filepath.Join(td, "dst")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a.tpl"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "empty.tpl"), []byte("{{/* nothing */}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	summary := filepath.Join(td, "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+
+	if _, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--gha-summary"); err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+
+	b, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(b)
+	for _, want := range []string{"### templr walk", "| a.tpl |", "| rendered |", "| empty.tpl |", "skipped (empty)"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("summary missing %q:\n%s", want, got)
+		}
+	}
+
+	// a dry run reports unchanged outputs as unchanged, not as dry-run
+	if err := os.WriteFile(filepath.Join(src, "b.tpl"), []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(summary); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--gha-summary", "--dry-run"); err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	b, err = os.ReadFile(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = string(b)
+	for _, want := range []string{"| a.tpl | " + filepath.Join(dst, "a") + " | unchanged |", "| b.tpl | " + filepath.Join(dst, "b") + " | dry-run |"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("summary missing %q:\n%s", want, got)
+		}
+	}
+}
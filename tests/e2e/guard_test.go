@@ -42,3 +42,126 @@ func TestGuardOverwriteBehavior(t *testing.T) {
 		t.Fatalf("expected overwrite when guard marker present; got=%q", string(got2))
 	}
 }
+
+func TestGuardCommentStyles(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	dst := filepath.Join(td, "dst")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"schema.sql.tpl":  "CREATE TABLE t (id int);\n",
+		"App.vue.tpl":     "<template></template>\n",
+		"Jenkinsfile.tpl": "pipeline {}\n",
+		"run.tpl":         "#!/usr/bin/env -S node --no-warnings\nconsole.log(1)\n",
+		"data.json.tpl":   "{}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := filepath.Join(td, "templr.yaml")
+	if err := os.WriteFile(cfg, []byte(`guard:
+  comment_styles:
+    ".sql": "-- %s"
+    ".VUE": "<!-- %s -->"
+    "Jenkinsfile": "// %s"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--config", cfg); err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	for name, want := range map[string]string{
+		"schema.sql":  "-- #templr generated\nCREATE TABLE",
+		"App.vue":     "<!-- #templr generated -->\n<template>",
+		"Jenkinsfile": "// #templr generated\npipeline",
+		// No known extension: the shebang interpreter decides, below the shebang
+		"run": "#!/usr/bin/env -S node --no-warnings\n// #templr generated\n",
+	} {
+		got, _ := os.ReadFile(filepath.Join(dst, name))
+		if !strings.HasPrefix(string(got), want) {
+			t.Errorf("%s: expected prefix %q, got %q", name, want, got)
+		}
+	}
+
+	// --guard-style overrides every file type
+	tpl := filepath.Join(src, "data.json.tpl")
+	out := filepath.Join(td, "x.yaml")
+	if _, stderr, err := run(t, bin, "render", "-i", tpl, "-o", out, "--guard-style", "/* %s */"); err != nil {
+		t.Fatalf("render failed: %v\n%s", err, stderr)
+	}
+	if got, _ := os.ReadFile(out); string(got) != "/* #templr generated */\n{}\n" {
+		t.Errorf("unexpected --guard-style output %q", got)
+	}
+
+	_, stderr, err := run(t, bin, "render", "-i", tpl, "-o", out, "--guard-style", "-- guard")
+	if err == nil || !strings.Contains(stderr, "must contain %s") {
+		t.Errorf("expected invalid --guard-style error, got %v\n%s", err, stderr)
+	}
+}
+
+func TestGuardPosition(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	dst := filepath.Join(td, "dst")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"feed.xml.tpl":    "<?xml version=\"1.0\"?>\n<feed/>\n",
+		"Dockerfile.tpl":  "# syntax=docker/dockerfile:1\nFROM alpine\n",
+		"strict.php.tpl":  "<?php\ndeclare(strict_types=1);\necho 1;\n",
+		"script.py.tpl":   "#!/usr/bin/env python3\n# -*- coding: utf-8 -*-\nprint(1)\n",
+		"notes.ini.tpl":   "[a]\nb = 1",
+		"manifest.sh.tpl": "echo 1\n",
+	} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := filepath.Join(td, "templr.yaml")
+	if err := os.WriteFile(cfg, []byte("guard:\n  positions:\n    \".ini\": bottom\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--config", cfg); err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	for name, want := range map[string]string{
+		"feed.xml":    "<?xml version=\"1.0\"?>\n<!-- #templr generated -->\n<feed/>\n",
+		"Dockerfile":  "# syntax=docker/dockerfile:1\n# #templr generated\nFROM alpine\n",
+		"strict.php":  "<?php\ndeclare(strict_types=1);\n// #templr generated\necho 1;\n",
+		"script.py":   "#!/usr/bin/env python3\n# -*- coding: utf-8 -*-\n# #templr generated\nprint(1)\n",
+		"notes.ini":   "[a]\nb = 1\n# #templr generated\n",
+		"manifest.sh": "# #templr generated\necho 1\n",
+	} {
+		got, _ := os.ReadFile(filepath.Join(dst, name))
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+
+	// --guard-position overrides the file type
+	tpl := filepath.Join(src, "feed.xml.tpl")
+	out := filepath.Join(td, "top.xml")
+	if _, stderr, err := run(t, bin, "render", "-i", tpl, "-o", out, "--guard-position", "top"); err != nil {
+		t.Fatalf("render failed: %v\n%s", err, stderr)
+	}
+	if got, _ := os.ReadFile(out); !strings.HasPrefix(string(got), "<!-- #templr generated -->\n<?xml") {
+		t.Errorf("unexpected --guard-position top output %q", got)
+	}
+
+	_, stderr, err := run(t, bin, "render", "-i", tpl, "-o", out, "--guard-position", "middle")
+	if err == nil || !strings.Contains(stderr, "invalid --guard-position") {
+		t.Errorf("expected invalid --guard-position error, got %v\n%s", err, stderr)
+	}
+}
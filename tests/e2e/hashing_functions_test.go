@@ -0,0 +1,72 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHashingFunctions(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	render := func(t *testing.T, tpl string) string {
+		t.Helper()
+		path := filepath.Join(td, "hash.tpl")
+		if err := os.WriteFile(path, []byte(tpl), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", path)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		return strings.TrimSuffix(stdout, "\n")
+	}
+
+	t.Run("hashFNV", func(t *testing.T) {
+		// FNV-1a 32-bit
+		if got := render(t, `{{ hashFNV "hello" }} {{ hashFNV "" }}`); got != "1335831723 2166136261" {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("consistentShard", func(t *testing.T) {
+		got := render(t, `{{ range seqList 1 200 }}{{ $k := printf "host-%d" . }}{{ consistentShard $k 8 }}:{{ consistentShard $k 9 }} {{ end }}`)
+		used := map[string]bool{}
+		for _, pair := range strings.Fields(got) {
+			from, to, _ := strings.Cut(pair, ":")
+			used[from] = true
+			// Adding a bucket only moves keys into the new bucket
+			if from != to && to != "8" {
+				t.Fatalf("key moved from bucket %s to %s", from, to)
+			}
+		}
+		if len(used) != 8 {
+			t.Errorf("expected keys in all 8 buckets, got %v", used)
+		}
+		if again := render(t, `{{ range seqList 1 200 }}{{ $k := printf "host-%d" . }}{{ consistentShard $k 8 }}:{{ consistentShard $k 9 }} {{ end }}`); again != got {
+			t.Errorf("consistentShard is not stable across renders")
+		}
+	})
+
+	t.Run("colorFromString", func(t *testing.T) {
+		got := render(t, `{{ colorFromString "api" }} {{ colorFromString "api" }} {{ colorFromString "web" }}`)
+		colors := strings.Fields(got)
+		if len(colors) != 3 || colors[0] != colors[1] || colors[0] == colors[2] || len(colors[0]) != 7 || colors[0][0] != '#' {
+			t.Errorf("unexpected colors %q", got)
+		}
+	})
+
+	t.Run("invalid_bucket_count", func(t *testing.T) {
+		path := filepath.Join(td, "bad.tpl")
+		if err := os.WriteFile(path, []byte(`{{ consistentShard "a" 0 }}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err := run(t, bin, "render", "--no-color", "-i", path)
+		if code := getExitCode(err); code != 2 || !strings.Contains(stderr, "consistentShard: bucket count must be positive") {
+			t.Errorf("expected bucket count error, got %d\n%s", code, stderr)
+		}
+	})
+}
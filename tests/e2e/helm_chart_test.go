@@ -0,0 +1,78 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWalkAsHelmChart(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(filepath.Join(src, "k8s"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"values.yaml":          "chart:\n  name: web\n  appVersion: \"2.1\"\n  description: Web app\n  keywords: [web]\nreplicas: 2\n",
+		"k8s/deploy.yaml.tpl":  "kind: Deployment\nspec:\n  replicas: {{ .replicas }}\n",
+		"k8s/config.yaml.tpl":  "kind: ConfigMap\ndata:\n  tpl: \"{{ raw }}{{ .Values.x }}{{ endraw }}\"\n",
+		"k8s/_helpers.tpl":     `{{ define "unused" }}{{ end }}`,
+		"k8s/skipped.yaml.tpl": "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chart := filepath.Join(td, "charts", "mychart")
+
+	t.Run("chart", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--inject-guard=false", "--src", src, "--as-helm-chart", chart)
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		meta, err := os.ReadFile(filepath.Join(chart, "Chart.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "apiVersion: v2\nname: web\ndescription: Web app\ntype: application\nversion: 0.1.0\nappVersion: \"2.1\"\nkeywords:\n  - web\n"
+		if string(meta) != want {
+			t.Fatalf("expected Chart.yaml %q, got %q", want, meta)
+		}
+		deploy, _ := os.ReadFile(filepath.Join(chart, "templates", "k8s", "deploy.yaml"))
+		if !strings.Contains(string(deploy), "replicas: 2") {
+			t.Fatalf("expected the rendered deployment, got:\n%s", deploy)
+		}
+		config, _ := os.ReadFile(filepath.Join(chart, "templates", "k8s", "config.yaml"))
+		if want := `tpl: "{{ "{{" }} .Values.x }}"`; !strings.Contains(string(config), want) {
+			t.Fatalf("expected the escaped action %q, got:\n%s", want, config)
+		}
+		if _, err := os.Stat(filepath.Join(chart, "templates", "k8s", "skipped.yaml")); !os.IsNotExist(err) {
+			t.Fatalf("expected empty output to be skipped, got err=%v", err)
+		}
+	})
+
+	t.Run("invalid_version", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--as-helm-chart", filepath.Join(td, "bad"), "--set", "chart.version=latest")
+		if code := getExitCode(err); code != 3 {
+			t.Fatalf("expected exit code 3, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "chart version latest: not a semantic version") {
+			t.Fatalf("expected the version to be rejected, got:\n%s", stderr)
+		}
+		if _, err := os.Stat(filepath.Join(td, "bad")); !os.IsNotExist(err) {
+			t.Fatalf("expected nothing to be written, got err=%v", err)
+		}
+	})
+
+	t.Run("with_dst", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--as-helm-chart", chart, "--dst", filepath.Join(td, "out"))
+		if err == nil || !strings.Contains(stderr, "as-helm-chart") {
+			t.Fatalf("expected --dst to be rejected, got:\n%s", stderr)
+		}
+	})
+}
@@ -0,0 +1,235 @@
+package e2e
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// initGitRepo creates a git repository in a temp dir and returns its path.
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	gitIn(t, dir, "init", "-q")
+	return dir
+}
+
+func gitIn(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// runIn runs the binary with dir as working directory.
+func runIn(t *testing.T, dir, bin string, args ...string) (string, string, error) {
+	t.Helper()
+	cmd := exec.Command(bin, args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
+
+func TestLintStaged(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	repo := initGitRepo(t)
+	if err := os.WriteFile(filepath.Join(repo, "broken.tpl"), []byte("{{ if .x }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "ok.tpl"), []byte("{{ .name }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the valid template is staged: the broken one is out of scope
+	gitIn(t, repo, "add", "ok.tpl")
+	if stdout, stderr, err := runIn(t, repo, bin, "lint", "--staged", "--no-color"); err != nil {
+		t.Fatalf("expected staged lint to pass: %v\n%s%s", err, stdout, stderr)
+	}
+
+	// Staging the broken template makes lint fail
+	gitIn(t, repo, "add", "broken.tpl")
+	stdout, _, err := runIn(t, repo, bin, "lint", "--staged", "--no-color")
+	if err == nil {
+		t.Fatal("expected staged lint to fail")
+	}
+	if !strings.Contains(stdout, "broken.tpl") || strings.Contains(stdout, "ok.tpl") {
+		t.Fatalf("unexpected output:\n%s", stdout)
+	}
+}
+
+// TestLintStagedTemplateDirValues checks that staging the values.yaml of
+// the template directory, not of the working directory, widens the scope.
+func TestLintStagedTemplateDirValues(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	repo := initGitRepo(t)
+	tpls := filepath.Join(repo, "templates")
+	if err := os.MkdirAll(tpls, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tpls, "broken.tpl"), []byte("{{ if .x }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tpls, "values.yaml"), []byte("x: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gitIn(t, repo, "add", "templates/values.yaml")
+	stdout, _, err := runIn(t, repo, bin, "lint", "--staged", "--src", "templates", "--no-color")
+	if err == nil {
+		t.Fatal("expected the staged values.yaml to bring every template into scope")
+	}
+	if !strings.Contains(stdout, "broken.tpl") {
+		t.Fatalf("unexpected output:\n%s", stdout)
+	}
+}
+
+func TestLintStagedOutsideGit(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	dir := t.TempDir()
+	_, stderr, err := runIn(t, dir, bin, "lint", "--staged")
+	if err == nil {
+		t.Fatal("expected --staged to fail outside a git repository")
+	}
+	if !strings.Contains(stderr, "git repository") {
+		t.Fatalf("unexpected error: %s", stderr)
+	}
+}
+
+func TestHookInstall(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	repo := initGitRepo(t)
+
+	// Plain git hook
+	if _, stderr, err := runIn(t, repo, bin, "hook", "install", "--ext", "md"); err != nil {
+		t.Fatalf("hook install failed: %v\n%s", err, stderr)
+	}
+	hookPath := filepath.Join(repo, ".git", "hooks", "pre-commit")
+	info, err := os.Stat(hookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&0o100 == 0 {
+		t.Fatalf("hook is not executable: %v", info.Mode())
+	}
+	b, _ := os.ReadFile(hookPath)
+	if !strings.Contains(string(b), "templr lint --staged --ext md") {
+		t.Fatalf("unexpected hook:\n%s", b)
+	}
+
+	// Re-installing over our own hook is allowed; a foreign hook is not
+	if _, stderr, err := runIn(t, repo, bin, "hook", "install"); err != nil {
+		t.Fatalf("reinstall failed: %v\n%s", err, stderr)
+	}
+	if err := os.WriteFile(hookPath, []byte("#!/bin/sh\nmake check\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := runIn(t, repo, bin, "hook", "install"); err == nil {
+		t.Fatal("expected install to refuse to overwrite a foreign hook")
+	}
+
+	// pre-commit framework
+	if _, stderr, err := runIn(t, repo, bin, "hook", "install", "--pre-commit"); err != nil {
+		t.Fatalf("pre-commit install failed: %v\n%s", err, stderr)
+	}
+	b, err = os.ReadFile(filepath.Join(repo, ".pre-commit-config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "entry: templr lint --staged") || !strings.Contains(string(b), "pass_filenames: false") {
+		t.Fatalf("unexpected pre-commit config:\n%s", b)
+	}
+
+	// lefthook
+	if _, stderr, err := runIn(t, repo, bin, "hook", "install", "--lefthook"); err != nil {
+		t.Fatalf("lefthook install failed: %v\n%s", err, stderr)
+	}
+	b, err = os.ReadFile(filepath.Join(repo, "lefthook.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "run: templr lint --staged") {
+		t.Fatalf("unexpected lefthook config:\n%s", b)
+	}
+}
+
+// TestHookInstallVerify checks that --verify adds a templr verify step that
+// fails the hook when a generated file no longer matches the provenance
+// statement.
+func TestHookInstallVerify(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+	binDir := t.TempDir()
+	if err := os.Symlink(bin, filepath.Join(binDir, "templr")); err != nil {
+		t.Skipf("symlink: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	repo := initGitRepo(t)
+	if err := os.MkdirAll(filepath.Join(repo, "tpl"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "tpl", "app.yaml.tpl"), []byte("name: app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := runIn(t, repo, bin, "walk", "--src", "tpl", "--dst", "out", "--provenance", "out.intoto.json"); err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+
+	if _, _, err := runIn(t, repo, bin, "hook", "install", "--verify", "../elsewhere.json"); err == nil {
+		t.Fatal("expected a provenance statement outside the repository to be refused")
+	}
+	if _, stderr, err := runIn(t, repo, bin, "hook", "install", "--verify", "out.intoto.json"); err != nil {
+		t.Fatalf("hook install failed: %v\n%s", err, stderr)
+	}
+	hookPath := filepath.Join(repo, ".git", "hooks", "pre-commit")
+	b, _ := os.ReadFile(hookPath)
+	if !strings.Contains(string(b), "templr lint --staged || exit $?\nexec templr verify --provenance out.intoto.json\n") {
+		t.Fatalf("unexpected hook:\n%s", b)
+	}
+
+	if _, stderr, err := runIn(t, repo, hookPath); err != nil {
+		t.Fatalf("hook failed on unchanged outputs: %v\n%s", err, stderr)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "out", "app.yaml"), []byte("name: edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, err := runIn(t, repo, hookPath)
+	if code := getExitCode(err); code != 11 || !strings.Contains(stderr, "output app.yaml has changed") {
+		t.Fatalf("expected the hook to fail verify with code 11, got %d:\n%s", code, stderr)
+	}
+
+	// Hook managers get a separate step that runs on every commit
+	if _, stderr, err := runIn(t, repo, bin, "hook", "install", "--pre-commit", "--verify", "out.intoto.json"); err != nil {
+		t.Fatalf("pre-commit install failed: %v\n%s", err, stderr)
+	}
+	b, _ = os.ReadFile(filepath.Join(repo, ".pre-commit-config.yaml"))
+	if !strings.Contains(string(b), "id: templr-verify\n        name: templr verify\n        entry: templr verify --provenance out.intoto.json\n        language: system\n        always_run: true") {
+		t.Fatalf("unexpected pre-commit config:\n%s", b)
+	}
+	if _, stderr, err := runIn(t, repo, bin, "hook", "install", "--lefthook", "--verify", "out.intoto.json"); err != nil {
+		t.Fatalf("lefthook install failed: %v\n%s", err, stderr)
+	}
+	b, _ = os.ReadFile(filepath.Join(repo, "lefthook.yml"))
+	if !strings.Contains(string(b), "templr-verify:\n      run: templr verify --provenance out.intoto.json\n") {
+		t.Fatalf("unexpected lefthook config:\n%s", b)
+	}
+}
@@ -0,0 +1,69 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIDFunctions(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	render := func(t *testing.T, tpl string) (string, string, error) {
+		t.Helper()
+		path := filepath.Join(td, "ids.tpl")
+		if err := os.WriteFile(path, []byte(tpl), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", path)
+		return strings.TrimSuffix(stdout, "\n"), stderr, err
+	}
+
+	t.Run("uuidv5", func(t *testing.T) {
+		// RFC 4122 namespaces given by name or as a UUID
+		got, stderr, err := render(t, `{{ uuidv5 "dns" "example.com" }} {{ uuidv5 "6ba7b810-9dad-11d1-80b4-00c04fd430c8" "example.com" }}`)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if got != "cfbff0d1-9375-5685-968c-48ce8b15ae17 cfbff0d1-9375-5685-968c-48ce8b15ae17" {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("uuidv5_bad_namespace", func(t *testing.T) {
+		_, stderr, err := render(t, `{{ uuidv5 "nope" "x" }}`)
+		if err == nil || !strings.Contains(stderr, "uuidv5") {
+			t.Errorf("expected an error for an unknown namespace, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("ulidFrom_is_stable", func(t *testing.T) {
+		tpl := `{{ ulidFrom "2024-01-02T03:04:05Z" "web" }} {{ ulidFrom "2024-01-02T03:04:05Z" "api" }}`
+		first, stderr, err := render(t, tpl)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		second, _, _ := render(t, tpl)
+		if first != second || first != "01HK421P489DF5FXQB5X1BJ0WV "+strings.Fields(first)[1] {
+			t.Errorf("expected stable IDs, got %q and %q", first, second)
+		}
+		ids := strings.Fields(first)
+		if ids[0][:10] != ids[1][:10] || ids[0] == ids[1] {
+			t.Errorf("expected a shared time prefix and different names, got %q", first)
+		}
+	})
+
+	t.Run("ulid", func(t *testing.T) {
+		got, stderr, err := render(t, `{{ ulid }} {{ ulid }}`)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		ids := strings.Fields(got)
+		if len(ids) != 2 || len(ids[0]) != 26 || ids[0] == ids[1] {
+			t.Errorf("expected two distinct 26-character ULIDs, got %q", got)
+		}
+	})
+}
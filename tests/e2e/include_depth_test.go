@@ -0,0 +1,43 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIncludeMaxDepth(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	loop := filepath.Join(td, "loop.tpl")
+	src := `{{ define "a" }}{{ include "b" . }}{{ end }}{{ define "b" }}{{ include "a" . }}{{ end }}{{ include "a" . }}`
+	if err := os.WriteFile(loop, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, err := run(t, bin, "render", "--in", loop, "--no-color")
+	if getExitCode(err) != 2 || !strings.Contains(stderr, "include depth limit 1000 exceeded: a -> b -> a") {
+		t.Fatalf("expected exit 2 with the cycle, got %v\n%s", err, stderr)
+	}
+
+	tree := filepath.Join(td, "tree.tpl")
+	src = `{{ define "node" }}{{ .name }}{{ range .children }}({{ include "node" . }}){{ end }}{{ end }}{{ include "node" .root }}`
+	if err := os.WriteFile(tree, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	vals := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(vals, []byte("root:\n  name: a\n  children:\n    - name: b\n      children:\n        - name: c\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, err := run(t, bin, "render", "--in", tree, "-d", vals, "--include-max-depth", "3")
+	if err != nil || stdout != "a(b(c))" {
+		t.Fatalf("expected the tree to render, got %q, %v\n%s", stdout, err, stderr)
+	}
+	_, stderr, err = run(t, bin, "render", "--in", tree, "-d", vals, "--include-max-depth", "2", "--no-color")
+	if getExitCode(err) != 2 || !strings.Contains(stderr, "include depth limit 2 exceeded: node -> node") {
+		t.Fatalf("expected --include-max-depth 2 to fail, got %v\n%s", err, stderr)
+	}
+}
@@ -0,0 +1,126 @@
+package e2e
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+// TestJinjaGrammar renders the constructs of the Jinja engine directly.
+func TestJinjaGrammar(t *testing.T) {
+	values := map[string]any{
+		"name":  "web",
+		"ports": []any{80, 443},
+		"tags":  map[string]any{"env": "prod", "team": "ops"},
+		"users": []any{
+			map[string]any{"name": "ann", "admin": true},
+			map[string]any{"name": "bob", "admin": false},
+		},
+		"n":     7,
+		"ratio": 0.5,
+		"empty": []any{},
+	}
+	partials := map[string]string{
+		"_foot.j2": "# {{ name }}{% set local = 1 %}",
+		"_loop.j2": "{% include '_loop.j2' %}",
+	}
+	engine := &templr.JinjaEngine{Loader: func(name string) (string, error) {
+		if src, ok := partials[name]; ok {
+			return src, nil
+		}
+		return "", fmt.Errorf("%s not found", name)
+	}}
+
+	tests := []struct {
+		name, src, want string
+	}{
+		{"text", "plain text\n", "plain text\n"},
+		{"output", "{{ name }} {{ tags.env }} {{ tags['team'] }} {{ ports[1] }} {{ ports[-1] }}", "web prod ops 443 443"},
+		{"comment", "a{# {{ name }} #}b", "ab"},
+		{"raw", "{% raw %}{{ name }}{% endraw %}", "{{ name }}"},
+		{"undefined", "[{{ missing }}][{{ missing.key }}]", "[][]"},
+		{"literals", "{{ 'it\\'s' }} {{ 1_000 }} {{ 2.5 }} {{ true }} {{ none }} {{ [1, 'a'] }} {{ {'k': 1} }}", "it's 1000 2.5 True None [1, 'a'] {'k': 1}"},
+		{"arithmetic", "{{ 1 + 2 * 3 }} {{ (1 + 2) * 3 }} {{ 7 // 2 }} {{ 7 / 2 }} {{ -7 % 3 }} {{ 2 ** 3 }} {{ -n }}", "7 9 3 3.5 2 8 -7"},
+		{"concat", "{{ name ~ '-' ~ n }} {{ 'a' + 'b' }} {{ [1] + [2] }}", "web-7 ab [1, 2]"},
+		{"compare", "{{ n > 5 }} {{ n == 7.0 }} {{ 'a' < 'b' }} {{ n != 7 }}", "True True True False"},
+		{"logic", "{{ true and not false }} {{ none or 'x' }} {{ 0 and 1 }}", "True x 0"},
+		{"in", "{{ 80 in ports }} {{ 'env' in tags }} {{ 'eb' in name }} {{ 22 not in ports }}", "True True True True"},
+		{"tests", "{{ name is defined }} {{ missing is undefined }} {{ n is odd }} {{ n is divisibleby 7 }} {{ name is not string }}", "True True True True False"},
+		{"inline_if", "{{ 'yes' if n > 5 else 'no' }}|{{ 'x' if false }}|", "yes||"},
+		{"if", "{% if n < 5 %}small{% elif n < 10 %}medium{% else %}large{% endif %}", "medium"},
+		{"for", "{% for p in ports %}{{ loop.index }}/{{ loop.length }}:{{ p }}{{ ',' if not loop.last }}{% endfor %}", "1/2:80,2/2:443"},
+		{"for_items", "{% for k, v in tags.items() %}{{ k }}={{ v }};{% endfor %}", "env=prod;team=ops;"},
+		{"for_filter", "{% for u in users if u.admin %}{{ u.name }}{% endfor %}", "ann"},
+		{"for_else", "{% for x in empty %}{{ x }}{% else %}none{% endfor %}", "none"},
+		{"for_scope", "{% for p in ports %}{% set last = p %}{% endfor %}[{{ last }}][{{ p }}]", "[][]"},
+		{"set", "{% set a, b = [1, 2] %}{% set s = name | upper %}{{ a + b }} {{ s }}", "3 WEB"},
+		{"include", "{% include '_foot.j2' %}[{{ local }}]{% include 'nope.j2' ignore missing %}", "# web[]"},
+		{"trim_blocks", "{% if true %}\nyes\n{% endif %}\n", "yes\n"},
+		{"whitespace_control", "a  {%- if true -%}  b  {%- endif -%}  c", "abc"},
+		{"filters", "{{ name | upper }} {{ missing | default('dev') }} {{ ports | join(', ') }} {{ users | map(attribute='name') | list }}", "WEB dev 80, 443 ['ann', 'bob']"},
+		{"filter_kwargs", "{{ 'a b c' | replace(' ', '-', count=1) }} {{ users | selectattr('admin') | map(attribute='name') | join }}", "a-b c ann"},
+		{"format_filter", "{{ '%s:%d' | format(name, n) }} {{ '%05.1f' | format(3.14159) }} {{ '%-4s|' | format('a') }} {{ '%(x)s' | format(x=1) }}", "web:7 003.1 a   | 1"},
+		{"percent", "{{ '%s=%s' % ('a', 1) }} {{ 'v%d' % n }} {{ '%(env)s' % tags }} {{ '%x %r' % (255, name) }}", "a=1 v7 prod ff 'web'"},
+		{"methods", "{{ 'a,b'.split(',') }} {{ name.upper() }} {{ tags.get('x', 'none') }} {{ ' s '.strip() }}", "['a', 'b'] WEB none s"},
+		{"functions", "{{ range(3) | list }} {{ dict(a=1) }}", "[0, 1, 2] {'a': 1}"},
+		{"macro", "{% macro port(p, proto='tcp') -%}\nlisten {{ p }}/{{ proto }};\n{%- endmacro %}\n{{ port(80) }}\n{{ port(53, proto='udp') }}\n", "listen 80/tcp;\nlisten 53/udp;\n"},
+		{"macro_scope", "{% macro greet(who) %}{{ name }} greets {{ who }}{% set inner = 1 %}{% endmacro %}{{ greet('you') }}[{{ inner }}][{{ who }}]", "web greets you[][]"},
+		{"macro_recursive", "{% macro down(i) %}{{ i }}{% if i > 0 %}{{ down(i - 1) }}{% endif %}{% endmacro %}{{ down(3) }}", "3210"},
+		{"macro_filter", "{% macro b(x) %}[{{ x }}]{% endmacro %}{{ b(name) | upper }}", "[WEB]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := engine.Render("t.j2", tt.src, values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestJinjaGrammarErrors checks that malformed and unsupported templates
+// fail with the template, the line and the cause.
+func TestJinjaGrammarErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+		strict          bool
+	}{
+		{"unclosed_tag", "a\n{{ name", "t.j2:2: unclosed {{", false},
+		{"unclosed_block", "{% if x %}\nx", "t.j2:1: {% if x %} is not closed with {% endif %}", false},
+		{"unexpected_end", "x\n{% endfor %}", "t.j2:2: unexpected {% endfor %}", false},
+		{"unclosed_raw", "{% raw %}x", "raw block is not closed with endraw", false},
+		{"unknown_tag", "{% frobnicate %}", "unknown tag {% frobnicate %}", false},
+		{"unsupported_tag", "{% extends 'base.j2' %}", "{% extends %} is not supported by the jinja engine", false},
+		{"unknown_filter", "{{ x | frobnicate }}", `unknown filter "frobnicate"`, false},
+		{"unknown_test", "{{ x is frobnicated }}", `unknown test "frobnicated"`, false},
+		{"bad_expression", "{{ 1 + }}", "unexpected end of expression", false},
+		{"trailing_tokens", "{{ a b }}", `unexpected "b"`, false},
+		{"unknown_function", "{{ nope() }}", "unknown function nope()", false},
+		{"format_arity", "{{ '%s %s' | format(1) }}", "not enough arguments for format string", false},
+		{"percent_extra", "{{ '%s' % (1, 2) }}", "not all arguments converted", false},
+		{"percent_type", "{{ '%d' % 'x' }}", "%d format: a number is required, not str", false},
+		{"macro_arity", "{% macro m(a) %}{% endmacro %}{{ m(1, 2) }}", "macro m takes 1 arguments, got 2", false},
+		{"macro_kwarg", "{% macro m(a) %}{% endmacro %}{{ m(b=1) }}", "macro m has no parameter b", false},
+		{"macro_defaults", "{% macro m(a=1, b) %}{% endmacro %}", "parameter b without a default follows one with a default", false},
+		{"macro_unclosed", "{% macro m() %}x", "is not closed with {% endmacro %}", false},
+		{"macro_depth", "{% macro m() %}{{ m() }}{% endmacro %}{{ m() }}", "macro m: call depth limit 100 exceeded", false},
+		{"include_depth", "{% include '_loop.j2' %}", "include depth limit 100 exceeded", false},
+		{"strict_undefined", "{{ name }}\n{{ missing.key }}", `t.j2:2: "missing.key" is undefined`, true},
+		{"strict_macro_param", "{% macro m(a) %}{{ a }}{% endmacro %}{{ m() }}", `"a" is undefined`, true},
+	}
+	loader := func(name string) (string, error) { return "{% include '_loop.j2' %}", nil }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &templr.JinjaEngine{Strict: tt.strict, Loader: loader}
+			_, err := engine.Render("t.j2", tt.src, map[string]any{"name": "web"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
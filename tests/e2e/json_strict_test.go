@@ -0,0 +1,63 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONHelpers(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	values := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(values, []byte("cfg:\n  url: http://x/?a=1&b=<2>\n  b: [1, 2.50]\n  a: {z: 1, 10: y}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	render := func(src string) (string, string, error) {
+		tpl := filepath.Join(td, "t.tpl")
+		if err := os.WriteFile(tpl, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return run(t, bin, "render", "--no-color", "-i", tpl, "-d", values)
+	}
+
+	t.Run("toJsonIndent", func(t *testing.T) {
+		stdout, stderr, err := render("{{ toJsonIndent .cfg.b 4 }}\n{{ toJsonIndent .cfg.url 0 }}")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if want := "[\n    1,\n    2.5\n]\n\"http://x/?a=1&b=<2>\""; stdout != want {
+			t.Fatalf("got %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("toJsonSortedKeys", func(t *testing.T) {
+		stdout, stderr, err := render("{{ toJsonSortedKeys .cfg.a }}\n{{ toJsonSortedKeys (dict \"b\" 1 \"a\" 2) 2 }}")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if want := "{\"10\":\"y\",\"z\":1}\n{\n  \"a\": 2,\n  \"b\": 1\n}"; stdout != want {
+			t.Fatalf("got %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("fromJsonStrict", func(t *testing.T) {
+		stdout, stderr, err := render(`{{ (fromJsonStrict "{\"a\": {\"b\": [1, 2]}}").a.b | len }}`)
+		if err != nil || stdout != "2" {
+			t.Fatalf("expected a valid document to parse, got %q: %v\n%s", stdout, err, stderr)
+		}
+		for src, want := range map[string]string{
+			`{{ fromJsonStrict "{\"s\": {\"port\": 80, \"port\": 8080}}" }}`: `duplicate key "port" in the object at $.s`,
+			`{{ fromJsonStrict "{\"a\": 1} {\"b\": 2}" }}`:                   "trailing data after the JSON value at offset 9",
+			`{{ fromJsonStrict "[1, 2" }}`:                                   "unexpected end of JSON input",
+		} {
+			_, stderr, err := render(src)
+			if code := getExitCode(err); code != 2 || !strings.Contains(stderr, want) {
+				t.Errorf("%s: expected %q, got %d:\n%s", src, want, code, stderr)
+			}
+		}
+	})
+}
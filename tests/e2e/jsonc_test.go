@@ -0,0 +1,83 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONCValues(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	values := `{
+  // who to greet
+  "name": "templr", /* inline */
+  "url": "https://example.com/*not-a-comment*/",
+  "tags": [
+    "a",
+    "b",
+  ],
+}
+`
+	for _, ext := range []string{".jsonc", ".json5"} {
+		t.Run(ext, func(t *testing.T) {
+			td := t.TempDir()
+			vals := filepath.Join(td, "values"+ext)
+			tpl := filepath.Join(td, "in.tpl")
+			if err := os.WriteFile(vals, []byte(values), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(tpl, []byte("{{ .name }} {{ .url }} {{ join \",\" .tags }}\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "-d", vals)
+			if err != nil {
+				t.Fatalf("render failed: %v\n%s", err, stderr)
+			}
+			if want := "templr https://example.com/*not-a-comment*/ a,b"; !strings.Contains(stdout, want) {
+				t.Fatalf("expected %q, got:\n%s", want, stdout)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		td := t.TempDir()
+		vals := filepath.Join(td, "values.jsonc")
+		if err := os.WriteFile(vals, []byte("{\n  // comment\n  \"name\": ,\n}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err := run(t, bin, "render", "--no-color", "-i", vals, "-d", vals)
+		if code := getExitCode(err); code != 3 {
+			t.Fatalf("expected exit code 3, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "jsonc decode") {
+			t.Fatalf("expected jsonc decode error, got:\n%s", stderr)
+		}
+	})
+}
+
+func TestFromJsonc(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	tpl := filepath.Join(td, "in.tpl")
+	template := `{{- $src := "{\n  // service\n  \"name\": \"a//b\", /* inline */\n  \"ports\": [80, 443,],\n}" }}
+{{- $data := fromJsonc $src }}
+name: {{ $data.name }}
+ports: {{ len $data.ports }}`
+	if err := os.WriteFile(tpl, []byte(template), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl)
+	if err != nil {
+		t.Fatalf("render failed: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, "name: a//b") || !strings.Contains(stdout, "ports: 2") {
+		t.Fatalf("unexpected output:\n%s", stdout)
+	}
+}
@@ -0,0 +1,180 @@
+package e2e
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+type k8sObject struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name        string            `yaml:"name"`
+		Namespace   string            `yaml:"namespace"`
+		Labels      map[string]string `yaml:"labels"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+	Type string            `yaml:"type"`
+	Data map[string]string `yaml:"data"`
+}
+
+func TestK8sApply(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(filepath.Join(src, "conf"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(src, "app.yaml.tpl"):     "name: {{ .name }}\n",
+		filepath.Join(src, "conf", "env.tpl"):  "NAME={{ .name }}\n",
+		filepath.Join(src, "_helpers.tpl"):     `{{ define "x" }}x{{ end }}`,
+		filepath.Join(src, "empty.txt.tpl"):    "{{- /* nothing */ -}}",
+		filepath.Join(src, "values.yaml"):      "name: demo\n",
+		filepath.Join(td, "other-values.yaml"): "name: other\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	parse := func(t *testing.T, manifest string) k8sObject {
+		t.Helper()
+		var obj k8sObject
+		if err := yaml.Unmarshal([]byte(manifest), &obj); err != nil {
+			t.Fatalf("invalid manifest: %v\n%s", err, manifest)
+		}
+		return obj
+	}
+
+	var checksum string
+	t.Run("configmap", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "k8s", "apply", "--src", src, "--configmap", "app-config", "--namespace", "prod", "--label", "team=web")
+		if err != nil {
+			t.Fatalf("k8s apply failed: %v\n%s", err, stderr)
+		}
+		obj := parse(t, stdout)
+		if obj.Kind != "ConfigMap" || obj.Metadata.Name != "app-config" || obj.Metadata.Namespace != "prod" {
+			t.Errorf("unexpected object:\n%s", stdout)
+		}
+		want := map[string]string{"app.yaml": "name: demo\n", "conf_env": "NAME=demo\n"}
+		if len(obj.Data) != len(want) {
+			t.Errorf("data = %v, want %v", obj.Data, want)
+		}
+		for k, v := range want {
+			if obj.Data[k] != v {
+				t.Errorf("data[%s] = %q, want %q", k, obj.Data[k], v)
+			}
+		}
+		if obj.Metadata.Labels["app.kubernetes.io/managed-by"] != "templr" || obj.Metadata.Labels["team"] != "web" {
+			t.Errorf("labels = %v", obj.Metadata.Labels)
+		}
+		checksum = obj.Metadata.Annotations["checksum/config"]
+		if len(checksum) != 64 {
+			t.Errorf("checksum/config = %q", checksum)
+		}
+	})
+
+	t.Run("checksum_follows_data", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "k8s", "apply", "--src", src, "--configmap", "app-config", "-d", filepath.Join(td, "other-values.yaml"))
+		if err != nil {
+			t.Fatalf("k8s apply failed: %v\n%s", err, stderr)
+		}
+		if got := parse(t, stdout).Metadata.Annotations["checksum/config"]; got == checksum {
+			t.Errorf("checksum did not change with the data: %s", got)
+		}
+		stdout, _, _ = run(t, bin, "k8s", "apply", "--src", src, "--configmap", "app-config", "--namespace", "other")
+		if got := parse(t, stdout).Metadata.Annotations["checksum/config"]; got != checksum {
+			t.Errorf("checksum changed with the metadata: %s != %s", got, checksum)
+		}
+	})
+
+	t.Run("secret", func(t *testing.T) {
+		out := filepath.Join(td, "secret.yaml")
+		stdout, stderr, err := run(t, bin, "k8s", "apply", "--src", src, "--secret", "app-secret", "--out", out)
+		if err != nil {
+			t.Fatalf("k8s apply failed: %v\n%s", err, stderr)
+		}
+		if stdout != "" {
+			t.Errorf("unexpected stdout with --out:\n%s", stdout)
+		}
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		obj := parse(t, string(b))
+		if obj.Kind != "Secret" || obj.Type != "Opaque" {
+			t.Errorf("unexpected object:\n%s", b)
+		}
+		got, err := base64.StdEncoding.DecodeString(obj.Data["app.yaml"])
+		if err != nil || string(got) != "name: demo\n" {
+			t.Errorf("data[app.yaml] = %q (%v)", got, err)
+		}
+	})
+
+	t.Run("invalid_name", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "k8s", "apply", "--src", src, "--configmap", "App_Config")
+		if code := getExitCode(err); code != 1 {
+			t.Fatalf("exit code = %d, want 1\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "invalid name") {
+			t.Errorf("unexpected error:\n%s", stderr)
+		}
+	})
+
+	t.Run("key_collision", func(t *testing.T) {
+		dup := filepath.Join(td, "dup")
+		if err := os.MkdirAll(filepath.Join(dup, "a"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{filepath.Join(dup, "a", "b.tpl"), filepath.Join(dup, "a_b.tpl")} {
+			if err := os.WriteFile(name, []byte("x\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, stderr, err := run(t, bin, "k8s", "apply", "--src", dup, "--configmap", "dup")
+		if err == nil || !strings.Contains(stderr, `both map to key "a_b"`) {
+			t.Errorf("expected a key collision error, got %v\n%s", err, stderr)
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("fake kubectl is a shell script")
+		}
+		binDir := filepath.Join(td, "bin")
+		if err := os.MkdirAll(binDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		captured := filepath.Join(td, "kubectl-stdin")
+		args := filepath.Join(td, "kubectl-args")
+		script := "#!/bin/sh\necho \"$@\" > " + args + "\ncat > " + captured + "\necho configmap/app-config configured\n"
+		if err := os.WriteFile(filepath.Join(binDir, "kubectl"), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+		stdout, stderr, err := run(t, bin, "k8s", "apply", "--src", src, "--configmap", "app-config", "--apply", "--context", "staging", "--dry-run")
+		if err != nil {
+			t.Fatalf("k8s apply --apply failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "configmap/app-config configured") {
+			t.Errorf("kubectl output not passed through:\n%s", stdout)
+		}
+		gotArgs, _ := os.ReadFile(args)
+		if strings.TrimSpace(string(gotArgs)) != "apply -f - --context staging --dry-run=client" {
+			t.Errorf("kubectl args = %q", gotArgs)
+		}
+		manifest, _ := os.ReadFile(captured)
+		if obj := parse(t, string(manifest)); obj.Data["app.yaml"] != "name: demo\n" {
+			t.Errorf("unexpected manifest on kubectl stdin:\n%s", manifest)
+		}
+	})
+}
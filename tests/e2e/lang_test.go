@@ -0,0 +1,75 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLang(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	tpl := filepath.Join(td, "app.tpl")
+	if err := os.WriteFile(tpl, []byte("name: {{ .missing.name }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	values := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(values, []byte("replicas: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("strict report", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "render", "-i", tpl, "-d", values, "--strict", "--no-color", "--lang", "de")
+		if getExitCode(err) == 0 {
+			t.Fatal("expected the strict render to fail")
+		}
+		for _, want := range []string{"✗ Fehler im Strict-Modus", "Schlüssel: missing", "💡 Tipp: Definieren Sie 'missing'"} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("expected %q in:\n%s", want, stderr)
+			}
+		}
+	})
+
+	t.Run("lint", func(t *testing.T) {
+		stdout, _, err := run(t, bin, "lint", "-i", tpl, "-d", values, "--no-color", "--lang", "ja")
+		if err != nil {
+			t.Fatalf("lint failed: %v", err)
+		}
+		for _, want := range []string{"[lint:warn:undefined]", "変数 .missing.name は定義されていません", "1 件の警告が見つかりました"} {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("expected %q in:\n%s", want, stdout)
+			}
+		}
+	})
+
+	t.Run("machine formats stay English", func(t *testing.T) {
+		stdout, _, err := run(t, bin, "lint", "-i", tpl, "-d", values, "--format", "json", "--lang", "es")
+		if err != nil {
+			t.Fatalf("lint failed: %v", err)
+		}
+		if !strings.Contains(stdout, "variable .missing.name is undefined") {
+			t.Errorf("expected the English message in:\n%s", stdout)
+		}
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("TEMPLR_LANG", "es_ES.UTF-8")
+		stdout, _, err := run(t, bin, "lint", "-i", tpl, "-d", values, "--no-color")
+		if err != nil {
+			t.Fatalf("lint failed: %v", err)
+		}
+		if !strings.Contains(stdout, "la variable .missing.name no está definida") {
+			t.Errorf("expected the Spanish message in:\n%s", stdout)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "lint", "-i", tpl, "--lang", "fr")
+		if getExitCode(err) != 1 || !strings.Contains(stderr, `invalid --lang "fr" (want en, de, es or ja)`) {
+			t.Fatalf("expected a usage error, got %v\n%s", err, stderr)
+		}
+	})
+}
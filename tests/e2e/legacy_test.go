@@ -0,0 +1,51 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLegacyTranslation(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	dst := filepath.Join(td, "dst")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "a.txt.tpl"), []byte("x={{ .x }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flags of the new CLI work with the legacy syntax
+	_, stderr, err := run(t, bin, "-walk", "-src", src, "-dst", dst, "-set", "x=hello", "--flatten", "-helpers", "ignored")
+	if err != nil {
+		t.Fatalf("legacy walk failed: %v\n%s", err, stderr)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dst, "a.txt")); !strings.Contains(string(got), "x=hello") {
+		t.Errorf("expected flattened output with x=hello, got %q", got)
+	}
+	want := "[templr:warn:deprecated] legacy flag syntax is deprecated and will be removed; use: templr walk --src " + src + " --dst " + dst + " --set x=hello --flatten\n"
+	if !strings.HasSuffix(stderr, want) {
+		t.Errorf("expected deprecation notice %q, got:\n%s", want, stderr)
+	}
+
+	// --no-legacy rejects the old syntax and names the new invocation
+	in := filepath.Join(src, "sub", "a.txt.tpl")
+	_, stderr, err = run(t, bin, "--no-legacy", "-in", in, "-dry-run")
+	if code := getExitCode(err); code != 1 {
+		t.Fatalf("expected exit code 1, got %d\n%s", code, stderr)
+	}
+	if !strings.Contains(stderr, "disabled by --no-legacy; use: templr render --no-legacy --in "+in+" --dry-run") {
+		t.Errorf("unexpected --no-legacy error:\n%s", stderr)
+	}
+
+	// The flag is accepted by the subcommands
+	if _, stderr, err = run(t, bin, "render", "--no-legacy", "-i", in); err != nil {
+		t.Errorf("render --no-legacy failed: %v\n%s", err, stderr)
+	}
+}
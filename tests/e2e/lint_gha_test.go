@@ -0,0 +1,84 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintProfileGHA(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	workflows := filepath.Join(td, ".github", "workflows")
+	if err := os.MkdirAll(workflows, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) string {
+		p := filepath.Join(workflows, name)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	values := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(values, []byte("trigger: push\njob: build\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		tpl := write("ok.yml.tpl", "on: [{{ .trigger }}, workflow_dispatch]\njobs:\n  {{ .job }}:\n    steps:\n      - run: echo {{ \"${{\" }} github.sha }}\n{{ raw }}      - run: echo ${{ github.ref }}\n{{ endraw }}")
+		stdout, stderr, err := run(t, bin, "lint", "--no-color", "-i", tpl, "-d", values, "--profile", "gha")
+		if err != nil {
+			t.Fatalf("expected no issues, got: %v\n%s%s", err, stdout, stderr)
+		}
+	})
+
+	t.Run("expression", func(t *testing.T) {
+		tpl := write("expr.yml.tpl", "on: push\njobs:\n  a:\n    steps:\n      - run: echo ${{ github.sha }}\n")
+		stdout, _, err := run(t, bin, "lint", "--no-color", "-i", tpl, "--profile", "gha")
+		if code := getExitCode(err); code != 7 {
+			t.Fatalf("expected exit code 7, got %d\n%s", code, stdout)
+		}
+		if !strings.Contains(stdout, "expr.yml.tpl:5: ${{ github.sha }} is a GitHub expression") {
+			t.Fatalf("expected the expression to be reported, got:\n%s", stdout)
+		}
+	})
+
+	t.Run("rendered", func(t *testing.T) {
+		tpl := write("bad.yml.tpl", "on:\n  {{ .trigger }}:\n  pull_reqest:\njobs:\n  {{ .job }}:\n    x: 1\n  build:\n    x: 2\n  2nd:\n    x: 3\n")
+		stdout, _, err := run(t, bin, "lint", "--no-color", "-i", tpl, "-d", values, "--profile", "gha")
+		if code := getExitCode(err); code != 7 {
+			t.Fatalf("expected exit code 7, got %d\n%s", code, stdout)
+		}
+		for _, want := range []string{
+			`rendered line 3: unknown trigger "pull_reqest"`,
+			`rendered line 7: job id "build" is already used on line 5`,
+			`rendered line 9: invalid job id "2nd"`,
+		} {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("expected %q, got:\n%s", want, stdout)
+			}
+		}
+	})
+
+	t.Run("other_templates", func(t *testing.T) {
+		tpl := filepath.Join(td, "notes.yml.tpl")
+		if err := os.WriteFile(tpl, []byte("jobs: ${{ x }}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		stdout, _, _ := run(t, bin, "lint", "--no-color", "-i", tpl, "--profile", "gha")
+		if strings.Contains(stdout, "[lint:error:gha]") {
+			t.Fatalf("expected only workflow templates to be checked, got:\n%s", stdout)
+		}
+	})
+
+	t.Run("unknown_profile", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "lint", "--no-color", "-i", values, "--profile", "gitlab")
+		if code := getExitCode(err); code != 1 || !strings.Contains(stderr, `unknown lint profile "gitlab"`) {
+			t.Fatalf("expected a usage error, got %d\n%s", code, stderr)
+		}
+	})
+}
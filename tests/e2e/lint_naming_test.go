@@ -0,0 +1,89 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintNaming(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	dir := filepath.Join(td, "tpl")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"helpers.tpl":     "{{ define \"MyHelper\" }}x{{ end }}\n{{ define \"app.name\" }}y{{ end }}\n",
+		"index.tpl":       "{{ template \"app.name\" . }}\n",
+		"_Other_File.tpl": "{{ define \"other\" }}z{{ end }}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig := func(t *testing.T, body string) string {
+		cfg := filepath.Join(t.TempDir(), "templr.yaml")
+		if err := os.WriteFile(cfg, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+
+	t.Run("off_by_default", func(t *testing.T) {
+		stdout, _, err := run(t, bin, "lint", "--no-color", "--dir", dir)
+		if err != nil || strings.Contains(stdout, "naming") {
+			t.Fatalf("expected no naming issues without lint.naming: %v\n%s", err, stdout)
+		}
+	})
+
+	t.Run("rules", func(t *testing.T) {
+		cfg := writeConfig(t, `lint:
+  naming:
+    partials: {severity: error}
+    defines: {severity: warn}
+    files: {severity: warn}
+    entry: {severity: warn}
+`)
+		stdout, stderr, err := run(t, bin, "lint", "--no-color", "--config", cfg, "--dir", dir)
+		if code := getExitCode(err); code != 7 {
+			t.Fatalf("expected exit code 7, got %d\n%s%s", code, stdout, stderr)
+		}
+		for _, want := range []string{
+			"[lint:error:naming] " + filepath.Join(dir, "helpers.tpl") + ": helpers.tpl only holds defines, so it is a partial",
+			`helpers.tpl:1: define "MyHelper" does not match ^[a-z0-9.]+$`,
+			"_Other_File.tpl: file name _Other_File.tpl does not match",
+			"index.tpl: entry template index.tpl does not match ^root$",
+		} {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("expected %q in output, got:\n%s", want, stdout)
+			}
+		}
+		if strings.Contains(stdout, `"app.name"`) || strings.Contains(stdout, `"other"`) {
+			t.Errorf("expected conforming defines to pass, got:\n%s", stdout)
+		}
+	})
+
+	t.Run("custom_pattern", func(t *testing.T) {
+		cfg := writeConfig(t, `lint:
+  naming:
+    defines: {severity: error, pattern: '^[A-Za-z.]+$'}
+`)
+		stdout, stderr, err := run(t, bin, "lint", "--no-color", "--config", cfg, "--src", dir)
+		if err != nil {
+			t.Fatalf("expected the custom pattern to accept every define: %v\n%s%s", err, stdout, stderr)
+		}
+	})
+
+	t.Run("bad_severity", func(t *testing.T) {
+		cfg := writeConfig(t, "lint:\n  naming:\n    files: {severity: fatal}\n")
+		_, stderr, err := run(t, bin, "lint", "--no-color", "--config", cfg, "--dir", dir)
+		if code := getExitCode(err); code != 1 || !strings.Contains(stderr, "lint.naming.files.severity") {
+			t.Fatalf("expected a usage error, got %d\n%s", code, stderr)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/kanopi/templr/pkg/lint"
+)
+
+// TestLintPackageExtractors checks the exported AST helpers.
+func TestLintPackageExtractors(t *testing.T) {
+	tpl := template.Must(template.New("t").Funcs(template.FuncMap{"upper": strings.ToUpper}).Parse(
+		`{{ .name | upper }}{{ if .flags.debug }}{{ range .items }}{{ .x }}{{ end }}{{ end }}`))
+
+	vars := lint.ExtractVariables(tpl.Tree)
+	want := []string{".flags.debug", ".items", ".name", ".x"}
+	if strings.Join(vars, ",") != strings.Join(want, ",") {
+		t.Fatalf("ExtractVariables = %v, want %v", vars, want)
+	}
+
+	funcs := lint.ExtractFunctionCalls(tpl.Tree)
+	if len(funcs) != 1 || funcs[0] != "upper" {
+		t.Fatalf("ExtractFunctionCalls = %v, want [upper]", funcs)
+	}
+
+	values := map[string]any{"flags": map[string]any{"debug": true}}
+	if !lint.VariableExists(".flags.debug", values) {
+		t.Fatal("expected .flags.debug to exist")
+	}
+	if lint.VariableExists(".flags.trace", values) {
+		t.Fatal("expected .flags.trace to be missing")
+	}
+}
+
+// TestLintPackageCustomRule registers a rule and runs it through lint.Run.
+func TestLintPackageCustomRule(t *testing.T) {
+	rule := lint.RuleFunc{
+		RuleName: "no-todo",
+		Fn: func(_ *parse.Tree, ctx *lint.Context) []lint.Issue {
+			if strings.Contains(string(ctx.Source), "TODO") {
+				return []lint.Issue{{Message: "TODO left in template"}}
+			}
+			return nil
+		},
+	}
+	lint.Register(rule)
+	defer lint.Unregister("no-todo")
+
+	found := false
+	for _, r := range lint.RegisteredRules() {
+		if r.Name() == "no-todo" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("registered rule not returned by RegisteredRules")
+	}
+
+	src := "hello {{ .name }} TODO"
+	tpl := template.Must(template.New("t").Parse(src))
+	ctx := &lint.Context{File: "t.tpl", Source: []byte(src)}
+	issues := lint.Run(tpl.Tree, ctx, lint.RegisteredRules())
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %d", len(issues))
+	}
+	is := issues[0]
+	if is.File != "t.tpl" || is.Category != "no-todo" || is.Severity != lint.SeverityWarn {
+		t.Fatalf("unexpected issue defaults: %+v", is)
+	}
+
+	var res lint.Result
+	res.Add(is, lint.Issue{Severity: lint.SeverityError})
+	if res.Errors != 1 || res.Warns != 1 {
+		t.Fatalf("Result.Add counters: errors=%d warns=%d", res.Errors, res.Warns)
+	}
+}
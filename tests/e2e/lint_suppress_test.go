@@ -0,0 +1,62 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintSuppression(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	values := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(values, []byte("a: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) string {
+		p := filepath.Join(td, name)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	t.Run("scoped", func(t *testing.T) {
+		tpl := write("scoped.tpl", "{{/* templr:lint-disable undefined .optional.flag */}}\n{{ .a }} {{ .optional.flag }} {{ .other }}\n")
+		stdout, stderr, err := run(t, bin, "lint", "--no-color", "-i", tpl, "-d", values)
+		if err != nil {
+			t.Fatalf("lint failed: %v\n%s", err, stderr)
+		}
+		out := stdout + stderr
+		if strings.Contains(out, ".optional.flag is undefined") || !strings.Contains(out, ".other is undefined") {
+			t.Fatalf("expected only .optional.flag to be suppressed, got:\n%s", out)
+		}
+	})
+
+	t.Run("file_wide", func(t *testing.T) {
+		tpl := write("file.tpl", "{{- /* templr:lint-disable undefined */ -}}\n{{ .x }} {{ .y }}\n")
+		stdout, stderr, err := run(t, bin, "lint", "--no-color", "-i", tpl, "-d", values, "--fail-on-warn")
+		if err != nil {
+			t.Fatalf("expected every undefined issue to be suppressed: %v\n%s%s", err, stdout, stderr)
+		}
+	})
+
+	t.Run("unused", func(t *testing.T) {
+		tpl := write("unused.tpl", "{{ .a }}\n{{/* templr:lint-disable undefined .gone */}}\n")
+		stdout, stderr, err := run(t, bin, "lint", "--no-color", "-i", tpl, "-d", values, "--fail-on-warn")
+		if code := getExitCode(err); code != 6 {
+			t.Fatalf("expected exit code 6, got %d\n%s%s", code, stdout, stderr)
+		}
+		if !strings.Contains(stdout+stderr, "unused.tpl:2: templr:lint-disable undefined .gone suppresses nothing") {
+			t.Fatalf("expected the unused suppression to be reported, got:\n%s%s", stdout, stderr)
+		}
+
+		// Without values the undefined rule does not run: nothing to report
+		if _, stderr, err := run(t, bin, "lint", "--no-color", "-i", tpl, "--fail-on-warn"); err != nil {
+			t.Fatalf("expected no report when the rule did not run: %v\n%s", err, stderr)
+		}
+	})
+}
@@ -405,3 +405,184 @@ env: {{ .environment }}`
 		t.Fatalf("expected no issues with --set values, got: %s", stdout+stderr)
 	}
 }
+
+// TestLintGitLabOutput tests the GitLab Code Quality output format
+func TestLintGitLabOutput(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	tplPath := filepath.Join(td, "test.tpl")
+	if err := os.WriteFile(tplPath, []byte("{{ if .enabled }}\n  active\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _, err := run(t, bin, "lint", "-i", tplPath, "--format", "gitlab")
+	if err == nil {
+		t.Fatal("expected lint to fail")
+	}
+
+	var issues []struct {
+		Description string `json:"description"`
+		CheckName   string `json:"check_name"`
+		Fingerprint string `json:"fingerprint"`
+		Severity    string `json:"severity"`
+		Location    struct {
+			Path  string `json:"path"`
+			Lines struct {
+				Begin int `json:"begin"`
+			} `json:"lines"`
+		} `json:"location"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &issues); err != nil {
+		t.Fatalf("invalid GitLab JSON: %v\n%s", err, stdout)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %d", len(issues))
+	}
+	is := issues[0]
+	if is.CheckName != "templr/parse" || is.Severity != "major" || is.Fingerprint == "" || is.Location.Path != tplPath {
+		t.Fatalf("unexpected GitLab issue: %+v", is)
+	}
+}
+
+// TestLintCheckstyleOutput tests the Checkstyle XML output format
+func TestLintCheckstyleOutput(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	tplPath := filepath.Join(td, "test.tpl")
+	valPath := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(tplPath, []byte("{{ .missing }}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(valPath, []byte("name: x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := run(t, bin, "lint", "-i", tplPath, "-d", valPath, "--format", "checkstyle")
+	if err != nil {
+		t.Fatalf("lint failed: %v, stderr=%s", err, stderr)
+	}
+	if !strings.HasPrefix(stdout, "<?xml") || !strings.Contains(stdout, `<checkstyle version="4.3">`) {
+		t.Fatalf("expected checkstyle XML, got: %s", stdout)
+	}
+	if !strings.Contains(stdout, `severity="warning"`) || !strings.Contains(stdout, `source="templr.undefined"`) {
+		t.Fatalf("expected undefined warning entry, got: %s", stdout)
+	}
+}
+
+// TestLintSARIFOutput tests the SARIF 2.1.0 output format
+func TestLintSARIFOutput(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	tplPath := filepath.Join(td, "test.tpl")
+	valPath := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(tplPath, []byte("{{ .missing }}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(valPath, []byte("name: x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := run(t, bin, "lint", "-i", tplPath, "-d", valPath, "--format", "sarif")
+	if err != nil {
+		t.Fatalf("lint failed: %v, stderr=%s", err, stderr)
+	}
+	var report struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("invalid SARIF: %v\n%s", err, stdout)
+	}
+	if report.Version != "2.1.0" || len(report.Runs) != 1 || report.Runs[0].Tool.Driver.Name != "templr" {
+		t.Fatalf("unexpected SARIF log: %s", stdout)
+	}
+	run0 := report.Runs[0]
+	if len(run0.Tool.Driver.Rules) != 1 || run0.Tool.Driver.Rules[0].ID != "undefined" {
+		t.Fatalf("expected the undefined rule, got %+v", run0.Tool.Driver.Rules)
+	}
+	if len(run0.Results) != 1 {
+		t.Fatalf("expected one result, got %+v", run0.Results)
+	}
+	r := run0.Results[0]
+	if r.RuleID != "undefined" || r.Level != "warning" || len(r.Locations) != 1 ||
+		r.Locations[0].PhysicalLocation.ArtifactLocation.URI != filepath.ToSlash(tplPath) {
+		t.Fatalf("unexpected result %+v", r)
+	}
+}
+
+// TestLintJSONOutputFile tests the stable JSON schema written via --output
+func TestLintJSONOutputFile(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	tplPath := filepath.Join(td, "broken.tpl")
+	// The parse error message contains quotes and braces
+	if err := os.WriteFile(tplPath, []byte("line1\n{{ .name \"}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report := filepath.Join(td, "report.json")
+
+	stdout, _, err := run(t, bin, "lint", "-i", tplPath, "--format", "json", "--output", report)
+	if err == nil {
+		t.Fatal("expected lint to fail")
+	}
+	if strings.TrimSpace(stdout) != "" {
+		t.Fatalf("expected nothing on stdout with --output, got: %s", stdout)
+	}
+
+	b, rerr := os.ReadFile(report)
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	var result struct {
+		Version int `json:"version"`
+		Errors  int `json:"errors"`
+		Issues  []struct {
+			Rule     string `json:"rule"`
+			Severity string `json:"severity"`
+			Line     int    `json:"line"`
+			Column   *int   `json:"column"`
+			Message  string `json:"message"`
+		} `json:"issues"`
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		t.Fatalf("invalid JSON report: %v\n%s", err, b)
+	}
+	if result.Version != 1 || result.Errors != 1 || len(result.Issues) != 1 {
+		t.Fatalf("unexpected report: %s", b)
+	}
+	is := result.Issues[0]
+	if is.Rule != "parse" || is.Severity != "error" || is.Line != 2 || is.Column == nil {
+		t.Fatalf("unexpected issue: %+v", is)
+	}
+	if !strings.Contains(is.Message, "broken.tpl") {
+		t.Fatalf("message lost its content: %q", is.Message)
+	}
+}
@@ -0,0 +1,66 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintValues(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	files := map[string]string{
+		"bad.yaml":   "a: 1\nb:\n  c: x\n  country: no\n  zip: 01234\n  version: 1.10\n  c: y\n",
+		"tabs.yaml":  "a:\n\tb: 1\n",
+		"dup.json":   `{"a": 1, "a": 2}`,
+		"clean.yaml": "enabled: true\ncountry: \"no\"\nzip: \"01234\"\nscript: |\n  if [ x ]; then\n  fi\n",
+		"app.tpl":    "{{ .a }}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(td, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("reports", func(t *testing.T) {
+		stdout, _, err := runIn(t, td, bin, "lint", "--values", "--no-color", "-f", "bad.yaml", "-f", "tabs.yaml", "-f", "dup.json")
+		if code := getExitCode(err); code != 7 {
+			t.Fatalf("expected exit 7, got %d\n%s", code, stdout)
+		}
+		for _, want := range []string{
+			`[lint:error:values] bad.yaml:7: duplicate key "c", first defined on line 3`,
+			"[lint:warn:values] bad.yaml:4: unquoted no is a string here but a boolean to YAML 1.1 tools",
+			"[lint:warn:values] bad.yaml:5: 01234 is read as a number and loses its zeros",
+			"[lint:warn:values] bad.yaml:6: 1.10 is read as a number and loses its zeros",
+			"[lint:error:values] tabs.yaml:2: tab in indentation",
+			`[lint:error:values] dup.json:1: duplicate key "a"`,
+		} {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("expected %q in output, got:\n%s", want, stdout)
+			}
+		}
+	})
+
+	t.Run("clean", func(t *testing.T) {
+		stdout, stderr, err := runIn(t, td, bin, "lint", "--values", "--no-color", "-f", "clean.yaml")
+		if err != nil {
+			t.Fatalf("expected a clean lint: %v\n%s%s", err, stdout, stderr)
+		}
+		if strings.Contains(stdout, "[lint:") {
+			t.Errorf("expected no issues, got:\n%s", stdout)
+		}
+	})
+
+	t.Run("off_by_default", func(t *testing.T) {
+		stdout, stderr, err := runIn(t, td, bin, "lint", "--no-color", "-i", "app.tpl", "-f", "clean.yaml", "-f", "bad.yaml")
+		if err != nil {
+			t.Fatalf("expected values to be left alone: %v\n%s%s", err, stdout, stderr)
+		}
+		if strings.Contains(stdout, "lint:warn:values") || strings.Contains(stdout, "lint:error:values") {
+			t.Errorf("expected no values issues without --values, got:\n%s", stdout)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintWhitespace(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	src := `{{ define "greeting" }}
+hello
+{{ end }}
+items:
+  {{ range .items }}
+  - {{ . }}
+  {{ end }}
+name: {{ $n := .name }}
+
+{{ $y := 1 }}
+tail
+`
+	td := t.TempDir()
+	tpl := filepath.Join(td, "app.yaml.tpl")
+	if err := os.WriteFile(tpl, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("off_by_default", func(t *testing.T) {
+		stdout, _, err := run(t, bin, "lint", "--no-color", "-i", tpl)
+		if err != nil || strings.Contains(stdout, "whitespace") {
+			t.Fatalf("expected no whitespace issues without --whitespace: %v\n%s", err, stdout)
+		}
+	})
+
+	t.Run("reports_suggestions", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "lint", "--no-color", "--whitespace", "--fail-on-warn", "-i", tpl)
+		if code := getExitCode(err); code != 6 {
+			t.Fatalf("expected exit code 6, got %d\n%s%s", code, stdout, stderr)
+		}
+		for _, want := range []string{
+			`app.yaml.tpl:1: {{ define "greeting" }} leaves a blank line in the output; use {{ define "greeting" -}}`,
+			`app.yaml.tpl:3: {{ end }} leaves a blank line in the output; use {{ end -}}`,
+			`app.yaml.tpl:5: {{ range .items }} leaves a blank line in the output; use {{- range .items }}`,
+			`app.yaml.tpl:7: {{ end }} leaves a blank line in the output; use {{- end }}`,
+			`app.yaml.tpl:8: trailing whitespace before {{ $n := .name }} in the output; use {{- $n := .name }}`,
+			`app.yaml.tpl:10: {{ $y := 1 }} leaves a blank line in the output; use {{- $y := 1 }}`,
+		} {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("expected %q in output, got:\n%s", want, stdout)
+			}
+		}
+	})
+
+	t.Run("fix", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "lint", "--no-color", "--fix", "-i", tpl)
+		if err != nil {
+			t.Fatalf("lint --fix failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stderr, "fixed 5 whitespace issues in "+tpl) {
+			t.Errorf("expected fix notice, got:\n%s", stderr)
+		}
+		want := `{{ define "greeting" -}}
+hello
+{{ end -}}
+items:
+  {{- range .items }}
+  - {{ . }}
+  {{- end }}
+name: {{- $n := .name }}
+
+{{ $y := 1 }}
+tail
+`
+		if b, _ := os.ReadFile(tpl); string(b) != want {
+			t.Errorf("unexpected fixed template:\n%s", b)
+		}
+
+		values := filepath.Join(td, "values.yaml")
+		if err := os.WriteFile(values, []byte("items: [a, b]\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		stdout, _, err := run(t, bin, "render", "-i", tpl, "-d", values, "--inject-guard=false")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(stdout, "items:\n  - a\n  - b\nname:\n") {
+			t.Errorf("expected no stray whitespace, got %q", stdout)
+		}
+	})
+}
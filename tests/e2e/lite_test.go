@@ -0,0 +1,30 @@
+//go:build wasm_lite
+
+package e2e
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+// Run with: go test -tags wasm_lite ./tests/e2e -run TestLiteBuild
+func TestLiteBuild(t *testing.T) {
+	if !templr.LiteBuild {
+		t.Fatal("expected LiteBuild with the wasm_lite tag")
+	}
+	var tpl *template.Template
+	funcs := templr.BuildFuncMap(&tpl)
+	for _, name := range []string{"toXml", "fromXml", "sum", "avg", "median", "stddev", "percentile"} {
+		if _, ok := funcs[name]; ok {
+			t.Errorf("expected %s to be left out", name)
+		}
+		if _, ok := templr.LookupFunc(name); ok {
+			t.Errorf("expected %s not to be listed", name)
+		}
+	}
+	if _, ok := funcs["toYaml"]; !ok {
+		t.Error("expected toYaml to remain")
+	}
+}
@@ -0,0 +1,50 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocaleFormatting(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	const nbsp = "\u00a0"
+	tests := []struct {
+		tpl, want string
+	}{
+		{`{{ formatNumber 1234567.891 "de-DE" }}`, "1.234.567,891"},
+		{`{{ formatNumber 1234.5 "en-US" 2 }}`, "1,234.50"},
+		{`{{ formatCurrency 1234.5 "EUR" "de-DE" }}`, "1.234,50" + nbsp + "€"},
+		{`{{ formatCurrency -1234.5 "USD" "en-US" }}`, "-$1,234.50"},
+		{`{{ formatCurrency 1234 "JPY" "en-US" }}`, "¥1,234"},
+		{`{{ formatCurrency 1234.5 "BRL" "pt-BR" }}`, "R$1.234,50"},
+		{`{{ formatPercent 0.256 "en-US" }}`, "26%"},
+		{`{{ formatPercent 0.256 "de-DE" 1 }}`, "25,6" + nbsp + "%"},
+	}
+	td := t.TempDir()
+	for i, tc := range tests {
+		tpl := filepath.Join(td, "t.tpl")
+		if err := os.WriteFile(tpl, []byte(tc.tpl), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl)
+		if err != nil {
+			t.Fatalf("%d: render failed: %v\n%s", i, err, stderr)
+		}
+		if strings.TrimSpace(stdout) != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.tpl, stdout, tc.want)
+		}
+	}
+
+	tpl := filepath.Join(td, "bad.tpl")
+	if err := os.WriteFile(tpl, []byte(`{{ formatCurrency 1 "EURO" "de-DE" }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl)
+	if code := getExitCode(err); code != 2 || !strings.Contains(stderr, `unknown currency "EURO"`) {
+		t.Fatalf("expected an unknown currency error, got %d:\n%s", code, stderr)
+	}
+}
@@ -0,0 +1,69 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatrixFunction(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	values := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(values, []byte("envs: [dev, prod]\nregions: [us, eu]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name, tpl, want string
+	}{
+		{
+			"product_in_key_order",
+			`{{ range matrix (dict "region" .regions "env" .envs) }}{{ .env }}-{{ .region }} {{ end }}`,
+			"dev-us dev-eu prod-us prod-eu ",
+		},
+		{
+			"scalar_axis",
+			`{{ range matrix (dict "env" .envs "tier" "web") }}{{ .env }}/{{ .tier }} {{ end }}`,
+			"dev/web prod/web ",
+		},
+		{
+			"empty",
+			`{{ len (matrix dict) }} {{ len (matrix (dict "env" .envs "region" list)) }}`,
+			"0 0",
+		},
+		{
+			"yaml",
+			`{{ matrix (dict "env" .envs "size" (list 1)) | toYaml }}`,
+			"- env: dev\n  size: 1\n- env: prod\n  size: 1",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tpl := filepath.Join(td, tc.name+".tpl")
+			if err := os.WriteFile(tpl, []byte(tc.tpl), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			stdout, stderr, err := run(t, bin, "render", "-i", tpl, "-d", values)
+			if err != nil {
+				t.Fatalf("render failed: %v\n%s", err, stderr)
+			}
+			if got := strings.TrimRight(stdout, "\n"); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("too_large", func(t *testing.T) {
+		tpl := filepath.Join(td, "large.tpl")
+		if err := os.WriteFile(tpl, []byte(`{{ $n := until 100 }}{{ len (matrix (dict "a" $n "b" $n "c" $n)) }}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl)
+		if code := getExitCode(err); code != 2 || !strings.Contains(stderr, "matrix: more than 100000 combinations") {
+			t.Errorf("expected size error, got %d\n%s", code, stderr)
+		}
+	})
+}
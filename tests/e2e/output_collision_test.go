@@ -0,0 +1,72 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWalkOutputCollision(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	for name, content := range map[string]string{
+		"a/app.conf.tpl": "from a\n",
+		"b/app.conf.tpl": "from b\n",
+		"b/other.tpl":    "other\n",
+	} {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("error", func(t *testing.T) {
+		dst := filepath.Join(td, "out-error")
+		_, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--flatten")
+		if getExitCode(err) != 1 {
+			t.Fatalf("expected exit 1, got %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stderr, "templates a/app.conf.tpl and b/app.conf.tpl both render to app.conf") {
+			t.Errorf("expected both templates to be named, got:\n%s", stderr)
+		}
+		if _, err := os.Stat(dst); !os.IsNotExist(err) {
+			t.Errorf("expected nothing to be written, got %v", err)
+		}
+	})
+
+	for _, tc := range []struct{ policy, want, dropped string }{
+		{"first", "from a\n", "b/app.conf.tpl"},
+		{"last", "from b\n", "a/app.conf.tpl"},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			dst := filepath.Join(td, "out-"+tc.policy)
+			_, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--flatten", "--on-collision", tc.policy, "--inject-guard=false")
+			if err != nil {
+				t.Fatalf("walk failed: %v\n%s", err, stderr)
+			}
+			if !strings.Contains(stderr, "[templr:warn:collision] "+tc.dropped+" renders to app.conf") {
+				t.Errorf("expected a collision warning for %s, got:\n%s", tc.dropped, stderr)
+			}
+			if b, _ := os.ReadFile(filepath.Join(dst, "app.conf")); string(b) != tc.want {
+				t.Errorf("got %q, want %q", b, tc.want)
+			}
+			if _, err := os.Stat(filepath.Join(dst, "other")); err != nil {
+				t.Errorf("expected other to be rendered: %v", err)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "walk", "--src", src, "--dst", filepath.Join(td, "x"), "--on-collision", "merge")
+		if getExitCode(err) != 1 || !strings.Contains(stderr, `invalid --on-collision "merge"`) {
+			t.Fatalf("expected a usage error, got %v\n%s", err, stderr)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaxOutputSize(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	loop := "header\n{{ range $i := until (int .n) }}line {{ $i }}\n{{ end }}"
+	if err := os.WriteFile(filepath.Join(src, "big.txt.tpl"), []byte(loop), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("walk_stops_runaway_loop", func(t *testing.T) {
+		dst := filepath.Join(td, "out")
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--set", "n=100000", "--max-output-size", "10KB")
+		if code := getExitCode(err); code != 2 {
+			t.Fatalf("expected exit code 2, got %d\n%s", code, stderr)
+		}
+		for _, want := range []string{"output of big.txt.tpl exceeds --max-output-size 10KB", "big.txt.tpl:2:9 {{range $i := until (int .n)}}"} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("expected %q in stderr, got:\n%s", want, stderr)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dst, "big.txt")); !os.IsNotExist(err) {
+			t.Errorf("expected no output file")
+		}
+	})
+
+	t.Run("render_under_limit_and_disabled", func(t *testing.T) {
+		tpl := filepath.Join(src, "big.txt.tpl")
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "--set", "n=3", "--max-output-size", "1KiB")
+		if err != nil || !strings.Contains(stdout, "line 2") {
+			t.Fatalf("render failed: %v\n%s%s", err, stdout, stderr)
+		}
+		stdout, stderr, err = run(t, bin, "render", "--no-color", "-i", tpl, "--set", "n=1000", "--max-output-size", "0")
+		if err != nil || !strings.Contains(stdout, "line 999") {
+			t.Fatalf("expected 0 to disable the limit: %v\n%s", err, stderr)
+		}
+	})
+
+	t.Run("strict_and_invalid", func(t *testing.T) {
+		tpl := filepath.Join(src, "big.txt.tpl")
+		_, stderr, err := run(t, bin, "render", "--no-color", "--strict", "-i", tpl, "--set", "n=100000", "--max-output-size", "1KB")
+		if code := getExitCode(err); code != 2 || !strings.Contains(stderr, "exceeds --max-output-size 1KB") {
+			t.Errorf("expected size error in strict mode, got %d\n%s", code, stderr)
+		}
+		_, stderr, err = run(t, bin, "render", "--no-color", "-i", tpl, "--set", "n=1", "--max-output-size", "lots")
+		if code := getExitCode(err); code != 1 || !strings.Contains(stderr, `invalid --max-output-size "lots"`) {
+			t.Errorf("expected usage error, got %d\n%s", code, stderr)
+		}
+	})
+}
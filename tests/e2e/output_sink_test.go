@@ -0,0 +1,72 @@
+package e2e
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWalkMessagesGrouped checks that the warnings about each file of a walk
+// and its status line come out together, in file order, when stdout and
+// stderr go to the same place.
+func TestWalkMessagesGrouped(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	dst := filepath.Join(td, "out")
+	for _, d := range []string{src, dst} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(src, "a.txt.tpl"): "image app:latest\n",
+		filepath.Join(src, "b.txt.tpl"): "image app:1.2\n",
+		filepath.Join(src, "c.txt.tpl"): "image app:latest\n",
+		filepath.Join(src, "d.txt.tpl"): "image app:latest\n",
+		filepath.Join(dst, "d.txt"):     "hand written\n",
+		filepath.Join(td, "policy.yaml"): `rules:
+  - id: no-latest
+    assert: 'not (contains ":latest" .Output)'
+    message: images must be pinned
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(bin, "walk", "--no-color", "--src", src, "--dst", dst,
+		"--policy", filepath.Join(td, "policy.yaml"), "--policy-mode", "warn")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, out.String())
+	}
+
+	want := []string{
+		"[templr:warn:policy] a.txt: no-latest: images must be pinned",
+		"rendered a.txt.tpl -> ",
+		"rendered b.txt.tpl -> ",
+		"[templr:warn:policy] c.txt: no-latest: images must be pinned",
+		"rendered c.txt.tpl -> ",
+		"[templr:warn:policy] d.txt: no-latest: images must be pinned",
+		"[templr:warn:guard] skip (guard missing) ",
+	}
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got:\n%s", len(want), out.String())
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d: expected %q, got %q", i+1, prefix, lines[i])
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWalkPathStyle(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(filepath.Join(src, "conf"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "conf", "app.conf.tpl"), []byte("name=web\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(td, "out")
+
+	t.Run("slash", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--dry-run")
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		if want := filepath.ToSlash(filepath.Join(dst, "conf", "app.conf")); !strings.Contains(stdout, want) {
+			t.Fatalf("expected %s in the status lines:\n%s", want, stdout)
+		}
+		if strings.Contains(stdout, `\`) {
+			t.Fatalf("expected no backslashes:\n%s", stdout)
+		}
+	})
+
+	t.Run("native", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--dry-run", "--path-style", "native")
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		if want := filepath.Join(dst, "conf", "app.conf"); !strings.Contains(stdout, want) {
+			t.Fatalf("expected %s in the status lines:\n%s", want, stdout)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--path-style", "dos")
+		if code := getExitCode(err); code != 1 || !strings.Contains(stderr, `unknown --path-style "dos"`) {
+			t.Fatalf("expected an args error, got %d\n%s", code, stderr)
+		}
+	})
+}
+
+func TestWalkLinks(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	shared := filepath.Join(td, "shared")
+	for _, d := range []string{src, filepath.Join(shared, "more.tpl")} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(shared, "app.conf.tpl"), []byte("name={{ .name }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"app.conf.tpl": filepath.Join(shared, "app.conf.tpl"), // a file: read through
+		"more.tpl":     filepath.Join(shared, "more.tpl"),     // a directory: skipped
+		"gone.tpl":     filepath.Join(shared, "gone.tpl"),     // broken: skipped with a warning
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(src, name)); err != nil {
+			t.Skipf("cannot create symbolic links here: %v", err)
+		}
+	}
+	dst := filepath.Join(td, "out")
+
+	_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--set", "name=web")
+	if err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	if b, err := os.ReadFile(filepath.Join(dst, "app.conf")); err != nil || !strings.Contains(string(b), "name=web") {
+		t.Fatalf("expected the linked template to render: %v\n%s", err, b)
+	}
+	if !strings.Contains(stderr, "gone.tpl: broken link") {
+		t.Fatalf("expected a warning for the broken link, stderr=%s", stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "more")); !os.IsNotExist(err) {
+		t.Fatal("expected the linked directory not to be rendered")
+	}
+}
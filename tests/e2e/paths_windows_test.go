@@ -0,0 +1,49 @@
+//go:build windows
+
+package e2e
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWalkJunctionWindows(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "app.conf.tpl"), []byte("name=web\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// a junction with a template extension pointing back at the tree
+	if out, err := exec.Command("cmd", "/c", "mklink", "/J", filepath.Join(src, "loop.tpl"), src).CombinedOutput(); err != nil {
+		t.Skipf("cannot create a junction: %v\n%s", err, out)
+	}
+	dst := filepath.Join(td, "out")
+
+	stdout, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst)
+	if err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	if strings.Contains(stdout, `\`) {
+		t.Fatalf("expected forward slashes by default:\n%s", stdout)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "loop")); !os.IsNotExist(err) {
+		t.Fatal("expected the junction to be skipped")
+	}
+
+	stdout, stderr, err = run(t, bin, "walk", "--src", src, "--dst", dst, "--dry-run", "--path-style", "native")
+	if err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, filepath.Join(dst, "app.conf")) {
+		t.Fatalf("expected native paths with --path-style native:\n%s", stdout)
+	}
+}
@@ -0,0 +1,133 @@
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPolicies(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	policies := filepath.Join(td, "policies")
+	for _, d := range []string{src, policies} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(src, "deploy.yaml.tpl"): "replicas: {{ .replicas }}\nimage: {{ .image }}\n",
+		filepath.Join(src, "notes.txt.tpl"):   "image {{ .image }}\n",
+		filepath.Join(policies, "k8s.yaml"): `rules:
+  - id: no-latest
+    assert: 'not (contains ":latest" .Output)'
+    message: images must be pinned
+  - id: replicas
+    files: ["*.yaml"]
+    schema:
+      type: object
+      required: [replicas]
+      properties:
+        replicas: {type: integer, minimum: 2}
+`,
+		filepath.Join(policies, "README.md"): "ignored\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("pass", func(t *testing.T) {
+		dst := filepath.Join(td, "pass")
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "-d", valuesFile(t, td, 3), "--set", "image=app:1.2", "--policy", policies)
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		for _, f := range []string{"deploy.yaml", "notes.txt"} {
+			if _, err := os.Stat(filepath.Join(dst, f)); err != nil {
+				t.Errorf("expected %s to be written: %v", f, err)
+			}
+		}
+	})
+
+	t.Run("enforce", func(t *testing.T) {
+		dst := filepath.Join(td, "enforce")
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "-d", valuesFile(t, td, 1), "--set", "image=app:latest", "--policy", policies)
+		if code := getExitCode(err); code != 10 {
+			t.Fatalf("expected exit code 10, got %d\n%s", code, stderr)
+		}
+		for _, want := range []string{
+			"[templr:error:policy] deploy.yaml: no-latest: images must be pinned",
+			"[templr:error:policy] deploy.yaml: replicas: .replicas",
+			"[templr:error:policy] notes.txt: no-latest:",
+			"3 policy violations",
+		} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("expected %q in stderr, got:\n%s", want, stderr)
+			}
+		}
+		if strings.Contains(stderr, "notes.txt: replicas") {
+			t.Errorf("replicas rule should only apply to *.yaml:\n%s", stderr)
+		}
+		if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+			t.Errorf("expected no files to be written, got %d", len(entries))
+		}
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		dst := filepath.Join(td, "warn")
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "-d", valuesFile(t, td, 1), "--set", "image=app:1.2", "--policy", policies, "--policy-mode", "warn")
+		if err != nil {
+			t.Fatalf("walk failed in warn mode: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stderr, "[templr:warn:policy] deploy.yaml: replicas:") {
+			t.Errorf("expected policy warning, got:\n%s", stderr)
+		}
+		if _, err := os.Stat(filepath.Join(dst, "deploy.yaml")); err != nil {
+			t.Errorf("expected deploy.yaml to be written in warn mode: %v", err)
+		}
+	})
+
+	t.Run("invalid_policy", func(t *testing.T) {
+		bad := filepath.Join(td, "bad.yaml")
+		if err := os.WriteFile(bad, []byte("rules:\n  - assert: 'true'\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err := run(t, bin, "render", "--no-color", "-i", filepath.Join(src, "notes.txt.tpl"), "--policy", bad)
+		if code := getExitCode(err); code == 0 || !strings.Contains(stderr, "has no id") {
+			t.Errorf("expected missing id error, got %d\n%s", code, stderr)
+		}
+	})
+
+	t.Run("rego", func(t *testing.T) {
+		if _, err := exec.LookPath("opa"); err != nil {
+			t.Skip("opa not installed")
+		}
+		rego := filepath.Join(td, "deny.rego")
+		policy := "package templr\n\ndeny contains msg if {\n\tinput.data.replicas < 2\n\tmsg := \"at least 2 replicas\"\n}\n"
+		if err := os.WriteFile(rego, []byte(policy), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err := run(t, bin, "render", "--no-color", "-i", filepath.Join(src, "deploy.yaml.tpl"), "-d", valuesFile(t, td, 1), "--set", "image=a:1", "--policy", rego)
+		if code := getExitCode(err); code != 10 || !strings.Contains(stderr, "stdout: templr.deny: at least 2 replicas") {
+			t.Errorf("expected rego violation, got %d\n%s", code, stderr)
+		}
+	})
+}
+
+// valuesFile writes a values file setting replicas.
+func valuesFile(t *testing.T, dir string, replicas int) string {
+	t.Helper()
+	path := filepath.Join(dir, fmt.Sprintf("values-%d.yaml", replicas))
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("replicas: %d\n", replicas)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,195 @@
+package e2e
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProvenanceVerify(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	dst := filepath.Join(td, "out")
+	if err := os.MkdirAll(filepath.Join(src, "conf"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(src, "app.yaml.tpl"):      "name: {{ .name }}\n",
+		filepath.Join(src, "conf", "a.txt.tpl"): "{{ include \"_greet.tpl\" . }}\n",
+		filepath.Join(src, "_greet.tpl"):        "hello {{ .name }}",
+		filepath.Join(src, "values.yaml"):       "name: demo\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privDER, _ := x509.MarshalPKCS8PrivateKey(priv)
+	pubDER, _ := x509.MarshalPKIXPublicKey(pub)
+	keyFile := filepath.Join(td, "key.pem")
+	pubFile := filepath.Join(td, "key.pub.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pubFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("unsigned", func(t *testing.T) {
+		prov := filepath.Join(td, "plain.intoto.json")
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--provenance", prov)
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		b, err := os.ReadFile(prov)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var st struct {
+			Type    string `json:"_type"`
+			Subject []struct {
+				Name   string            `json:"name"`
+				Digest map[string]string `json:"digest"`
+			} `json:"subject"`
+			Predicate struct {
+				BuildDefinition struct {
+					ResolvedDependencies []struct {
+						Name string `json:"name"`
+					} `json:"resolvedDependencies"`
+				} `json:"buildDefinition"`
+			} `json:"predicate"`
+		}
+		if err := json.Unmarshal(b, &st); err != nil {
+			t.Fatalf("invalid provenance: %v\n%s", err, b)
+		}
+		if st.Type != "https://in-toto.io/Statement/v1" || len(st.Subject) != 2 {
+			t.Fatalf("unexpected statement:\n%s", b)
+		}
+		if st.Subject[0].Name != "app.yaml" || st.Subject[1].Name != "conf/a.txt" || len(st.Subject[0].Digest["sha256"]) != 64 {
+			t.Errorf("unexpected subjects:\n%s", b)
+		}
+		var deps []string
+		for _, d := range st.Predicate.BuildDefinition.ResolvedDependencies {
+			deps = append(deps, filepath.Base(d.Name))
+		}
+		if got := strings.Join(deps, ","); got != "_greet.tpl,app.yaml.tpl,a.txt.tpl,values.yaml" {
+			t.Errorf("unexpected dependencies: %s", got)
+		}
+
+		stdout, stderr, err := run(t, bin, "verify", "--no-color", "--provenance", prov)
+		if err != nil {
+			t.Fatalf("verify failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "verified 2 outputs and 4 inputs") {
+			t.Errorf("unexpected verify output: %s", stdout)
+		}
+
+		_, stderr, err = run(t, bin, "verify", "--no-color", "--provenance", prov, "--key", pubFile)
+		if code := getExitCode(err); code != 11 || !strings.Contains(stderr, "is not signed") {
+			t.Errorf("expected unsigned statement to fail with --key, got %d\n%s", code, stderr)
+		}
+	})
+
+	t.Run("signed_and_tampered", func(t *testing.T) {
+		prov := filepath.Join(td, "signed.intoto.json")
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--provenance", prov, "--provenance-key", keyFile)
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		if _, stderr, err := run(t, bin, "verify", "--no-color", "--provenance", prov, "--key", pubFile); err != nil {
+			t.Fatalf("verify failed: %v\n%s", err, stderr)
+		}
+
+		// another key does not verify
+		otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+		otherDER, _ := x509.MarshalPKIXPublicKey(otherPub)
+		otherFile := filepath.Join(td, "other.pub.pem")
+		if err := os.WriteFile(otherFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: otherDER}), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err = run(t, bin, "verify", "--no-color", "--provenance", prov, "--key", otherFile)
+		if code := getExitCode(err); code != 11 || !strings.Contains(stderr, "signature does not match") {
+			t.Errorf("expected signature mismatch, got %d\n%s", code, stderr)
+		}
+
+		// edited output and template
+		if err := os.WriteFile(filepath.Join(dst, "app.yaml"), []byte("name: edited\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(src, "_greet.tpl"), []byte("hi {{ .name }}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err = run(t, bin, "verify", "--no-color", "--provenance", prov, "--key", pubFile)
+		if code := getExitCode(err); code != 11 {
+			t.Fatalf("expected exit code 11, got %d\n%s", code, stderr)
+		}
+		for _, want := range []string{"output app.yaml has changed", "template _greet.tpl has changed", "2 files changed since"} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("expected %q in stderr, got:\n%s", want, stderr)
+			}
+		}
+	})
+
+	t.Run("other_builder", func(t *testing.T) {
+		prov := filepath.Join(td, "builder.intoto.json")
+		if _, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--provenance", prov); err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		b, err := os.ReadFile(prov)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var st map[string]any
+		if err := json.Unmarshal(b, &st); err != nil {
+			t.Fatal(err)
+		}
+		builder := st["predicate"].(map[string]any)["runDetails"].(map[string]any)["builder"].(map[string]any)
+		version := builder["version"].(map[string]any)
+		if !strings.HasPrefix(version["functions"].(string), "sha256:") {
+			t.Fatalf("expected a function set hash, got %v", version)
+		}
+
+		// the same binary verifies without warnings
+		_, stderr, err := run(t, bin, "verify", "--no-color", "--provenance", prov)
+		if err != nil || strings.Contains(stderr, "warn") {
+			t.Fatalf("unexpected verify result: %v\n%s", err, stderr)
+		}
+
+		version["templr"] = "0.0.1"
+		version["functions"] = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+		b, _ = json.Marshal(st)
+		if err := os.WriteFile(prov, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err = run(t, bin, "verify", "--no-color", "--provenance", prov)
+		if err != nil {
+			t.Fatalf("a version mismatch should only warn: %v\n%s", err, stderr)
+		}
+		for _, want := range []string{"rendered by templr 0.0.1", "different template function set (sha256:000000000000"} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("expected %q in stderr, got:\n%s", want, stderr)
+			}
+		}
+	})
+
+	t.Run("key_requires_provenance", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--provenance-key", keyFile)
+		if code := getExitCode(err); code == 0 || !strings.Contains(stderr, "--provenance-key requires --provenance") {
+			t.Errorf("expected usage error, got %d\n%s", code, stderr)
+		}
+	})
+}
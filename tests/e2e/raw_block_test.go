@@ -0,0 +1,93 @@
+package e2e
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRawBlocks(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(td, name)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	t.Run("render", func(t *testing.T) {
+		tpl := write("workflow.tpl", "name: {{ .name }}\n{{- raw }}\nrun: echo ${{ github.sha }} `x` {{ end }}\n{{- endraw }}\nafter: {{ .name }}\n")
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "--set", "name=web")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if want := "name: web\nrun: echo ${{ github.sha }} `x` {{ end }}\nafter: web\n"; stdout != want {
+			t.Fatalf("expected %q, got %q", want, stdout)
+		}
+	})
+
+	t.Run("line_numbers_kept", func(t *testing.T) {
+		tpl := write("lines.tpl", "{{ raw }}\n{{ a }}\n{{ endraw }}\n{{ .a.b }}\n")
+		_, stderr, err := run(t, bin, "render", "--no-color", "--strict", "-i", tpl)
+		if err == nil || !strings.Contains(stderr, "lines.tpl:4:") {
+			t.Fatalf("expected an error on line 4, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("tags_in_comments_and_strings", func(t *testing.T) {
+		tpl := write("quoted.tpl", "{{/* {{ raw }} */}}a {{ \"{{ raw }}\" }} {{- /* {{ endraw }} */ -}} {{ `{{ raw }}` }} {{ raw }}{{ .x }}{{ endraw }}\n")
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if want := "a {{ raw }}{{ raw }} {{ .x }}\n"; stdout != want {
+			t.Fatalf("expected %q, got %q", want, stdout)
+		}
+	})
+
+	t.Run("unterminated", func(t *testing.T) {
+		tpl := write("open.tpl", "a\n{{ raw }}\n{{ .x }}\n")
+		_, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl)
+		if code := getExitCode(err); code != 2 {
+			t.Fatalf("expected exit code 2, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "open.tpl:2: unterminated raw block") {
+			t.Fatalf("expected the unterminated block to be reported, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("walk_and_fmt", func(t *testing.T) {
+		src := filepath.Join(td, "src")
+		if err := os.MkdirAll(src, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		tpl := filepath.Join(src, "chart.yaml.tpl")
+		if err := os.WriteFile(tpl, []byte("{{if .name}}\n{{raw}}{{ if x }}  {{.Values}}{{endraw}}\n{{end}}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command(bin, "fmt", tpl)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("fmt failed: %v\n%s", err, out)
+		}
+		formatted, _ := os.ReadFile(tpl)
+		if want := "{{ if .name }}\n{{ raw }}{{ if x }}  {{.Values}}{{ endraw }}\n{{ end }}\n"; string(formatted) != want {
+			t.Fatalf("expected %q, got %q", want, formatted)
+		}
+
+		dst := filepath.Join(td, "out")
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--inject-guard=false", "--src", src, "--dst", dst, "--set", "name=web")
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		out, _ := os.ReadFile(filepath.Join(dst, "chart.yaml"))
+		if !strings.Contains(string(out), "{{ if x }}  {{.Values}}") {
+			t.Fatalf("expected the raw block as written, got:\n%s", out)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedaction(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		p := filepath.Join(td, name)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	vals := write("values.yaml", "db:\n  host: db.local\n  password: hunter2x\n  dsn: postgres://u:pw1234@db\napi:\n  access_token: tok-abc-123\nname: visible-name\n")
+	cfg := write("templr.yaml", "debug:\n  redact: [dsn]\n")
+	tpl := write("app.tpl", "host={{ .db.host }}\n")
+
+	t.Run("debug", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "render", "-i", tpl, "-d", vals, "--config", cfg, "--debug", "--set", "extra.apiKey=k3y-value")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		for _, secret := range []string{"hunter2x", "pw1234", "tok-abc-123", "k3y-value"} {
+			if strings.Contains(stderr, secret) {
+				t.Errorf("debug output leaks %q:\n%s", secret, stderr)
+			}
+		}
+		for _, want := range []string{"password: '[redacted]'", "dsn: '[redacted]'", "access_token: '[redacted]'", "host: db.local", "name: visible-name"} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("missing %q in debug output:\n%s", want, stderr)
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		bad := write("bad.tpl", "{{ clamp .db.password 1 2 }}\n")
+		_, stderr, err := run(t, bin, "render", "-i", bad, "-d", vals, "--no-color")
+		if getExitCode(err) != 2 {
+			t.Fatalf("expected exit 2, got %v\n%s", err, stderr)
+		}
+		if strings.Contains(stderr, "hunter2x") || !strings.Contains(stderr, `cannot use "[redacted]" as a number`) {
+			t.Fatalf("error message leaks the password:\n%s", stderr)
+		}
+	})
+
+	t.Run("values_diff", func(t *testing.T) {
+		next := write("next.yaml", "db:\n  host: db.local\n  password: changed99\n  dsn: postgres://u:pw1234@db\napi:\n  access_token: tok-abc-123\nname: visible-name\n")
+		stdout, stderr, err := run(t, bin, "values", "diff", "-f", vals, "-f", next)
+		if err != nil {
+			t.Fatalf("values diff failed: %v\n%s", err, stderr)
+		}
+		if want := `~ db.password: "[redacted]" (string) -> "[redacted]" (string)`; strings.TrimSpace(stdout) != want {
+			t.Fatalf("got %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("audit", func(t *testing.T) {
+		audit := filepath.Join(td, "audit.jsonl")
+		_, stderr, err := run(t, bin, "render", "-i", tpl, "-d", vals, "--audit-log", audit,
+			"--set", "db.password=s3t-secret", "--set=app.token=t0k3n-value", "--set", "name=bob")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		b, err := os.ReadFile(audit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record := string(b)
+		if strings.Contains(record, "s3t-secret") || strings.Contains(record, "t0k3n-value") {
+			t.Fatalf("audit record leaks a --set secret:\n%s", record)
+		}
+		for _, want := range []string{`"db.password=[redacted]"`, `"--set=app.token=[redacted]"`, `"name=bob"`} {
+			if !strings.Contains(record, want) {
+				t.Errorf("missing %s in audit record:\n%s", want, record)
+			}
+		}
+	})
+}
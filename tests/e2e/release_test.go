@@ -0,0 +1,102 @@
+package e2e
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReleaseManifest(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	sums := filepath.Join(td, "SHA256SUMS")
+	archives := []string{
+		"templr-darwin-amd64.tar.gz", "templr-darwin-arm64.tar.gz",
+		"templr-linux-amd64.tar.gz", "templr-linux-arm64.tar.gz", "templr-windows-amd64.zip",
+	}
+	var lines []string
+	for i, a := range archives {
+		lines = append(lines, strings.Repeat(string(rune('a'+i)), 64)+"  "+a)
+	}
+	if err := os.WriteFile(sums, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("all", func(t *testing.T) {
+		out := filepath.Join(td, "manifests")
+		_, stderr, err := run(t, bin, "release", "manifest", "--version", "v1.4.0", "--checksums", sums, "--output-dir", out)
+		if err != nil {
+			t.Fatalf("release manifest failed: %v\n%s", err, stderr)
+		}
+
+		brew, err := os.ReadFile(filepath.Join(out, "templr.rb"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			`version "1.4.0"`,
+			`url "https://github.com/kanopi/templr/releases/download/v1.4.0/templr-darwin-arm64.tar.gz"`,
+			`sha256 "` + strings.Repeat("b", 64) + `"`,
+		} {
+			if !strings.Contains(string(brew), want) {
+				t.Errorf("expected formula to contain %q, got:\n%s", want, brew)
+			}
+		}
+
+		b, err := os.ReadFile(filepath.Join(out, "templr.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var scoop struct {
+			Version      string `json:"version"`
+			Architecture map[string]struct {
+				URL  string `json:"url"`
+				Hash string `json:"hash"`
+			} `json:"architecture"`
+		}
+		if err := json.Unmarshal(b, &scoop); err != nil {
+			t.Fatalf("scoop manifest is not valid JSON: %v\n%s", err, b)
+		}
+		if scoop.Version != "1.4.0" || scoop.Architecture["64bit"].Hash != strings.Repeat("e", 64) {
+			t.Errorf("unexpected scoop manifest:\n%s", b)
+		}
+
+		control, err := os.ReadFile(filepath.Join(out, "control"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(control), "Version: 1.4.0\n") || !strings.Contains(string(control), "Architecture: amd64\n") {
+			t.Errorf("unexpected control file:\n%s", control)
+		}
+	})
+
+	t.Run("deb_stdout", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "release", "manifest", "--format", "deb", "--version", "1.4.0", "--arch", "arm64")
+		if err != nil {
+			t.Fatalf("release manifest failed: %v\n%s", err, stderr)
+		}
+		if !strings.HasPrefix(stdout, "Package: templr\n") || !strings.Contains(stdout, "Architecture: arm64\n") {
+			t.Errorf("unexpected control file:\n%s", stdout)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		if _, stderr, err := run(t, bin, "release", "manifest", "--format", "brew", "--version", "1.4.0"); err == nil || !strings.Contains(stderr, "--checksums") {
+			t.Errorf("expected brew without checksums to fail, got err=%v\n%s", err, stderr)
+		}
+		partial := filepath.Join(td, "partial")
+		if err := os.WriteFile(partial, []byte(lines[0]+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, stderr, err := run(t, bin, "release", "manifest", "--format", "brew", "--version", "1.4.0", "--checksums", partial); err == nil || !strings.Contains(stderr, "no checksum for templr-darwin-arm64.tar.gz") {
+			t.Errorf("expected missing checksum to fail, got err=%v\n%s", err, stderr)
+		}
+		if _, stderr, err := run(t, bin, "release", "manifest", "--version", "1.4.0", "--checksums", sums); err == nil || !strings.Contains(stderr, "--output-dir") {
+			t.Errorf("expected several formats on stdout to fail, got err=%v\n%s", err, stderr)
+		}
+	})
+}
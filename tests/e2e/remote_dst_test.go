@@ -0,0 +1,238 @@
+package e2e
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeBucket is an in-memory object store that counts uploads.
+type fakeBucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	puts    []string
+}
+
+func (b *fakeBucket) store(name string, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[name] = data
+	b.puts = append(b.puts, name)
+}
+
+func (b *fakeBucket) load(name string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data, ok := b.objects[name]
+	return data, ok
+}
+
+func (b *fakeBucket) takePuts() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	puts := b.puts
+	b.puts = nil
+	return puts
+}
+
+func writeRemoteSrc(t *testing.T) string {
+	t.Helper()
+	src := filepath.Join(t.TempDir(), "src")
+	if err := os.MkdirAll(filepath.Join(src, "conf"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(src, "index.html.tpl"):     "<h1>{{ .title }}</h1>\n",
+		filepath.Join(src, "conf", "a.yaml.tpl"): "static: true\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return src
+}
+
+func TestWalkS3Destination(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+	src := writeRemoteSrc(t)
+
+	bucket := &fakeBucket{objects: map[string][]byte{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDTEST/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") {
+			http.Error(w, "bad authorization "+auth, http.StatusForbidden)
+			return
+		}
+		name, ok := strings.CutPrefix(r.URL.Path, "/site-bucket/")
+		if !ok {
+			http.Error(w, "no such bucket", http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := bucket.load(name)
+			if !ok {
+				http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			sum := sha256.Sum256(data)
+			if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+				http.Error(w, "payload hash mismatch", http.StatusBadRequest)
+				return
+			}
+			bucket.store(name, data)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL_S3", srv.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDTEST")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-1")
+
+	dst := "s3://site-bucket/releases/v1"
+	stdout, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--set", "title=Hello")
+	if err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, "rendered index.html.tpl -> s3://site-bucket/releases/v1/index.html") {
+		t.Errorf("unexpected status lines:\n%s", stdout)
+	}
+	if got := strings.Join(bucket.takePuts(), ","); got != "releases/v1/conf/a.yaml,releases/v1/index.html,releases/v1/.templr-manifest.json" {
+		t.Errorf("unexpected uploads: %s", got)
+	}
+	if data, _ := bucket.load("releases/v1/index.html"); !strings.Contains(string(data), "<h1>Hello</h1>") {
+		t.Errorf("unexpected object content: %q", data)
+	}
+
+	// unchanged outputs are not uploaded again
+	stdout, stderr, err = run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--set", "title=Hello")
+	if err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	if puts := bucket.takePuts(); len(puts) != 0 || strings.Contains(stdout, "rendered") {
+		t.Errorf("expected no uploads, got %v\n%s", puts, stdout)
+	}
+
+	// only the changed output (and the manifest) is uploaded
+	stdout, _, err = run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--set", "title=Bye", "--dry-run")
+	if err != nil || !strings.Contains(stdout, "would render index.html.tpl -> s3://site-bucket/releases/v1/index.html (changed)") {
+		t.Errorf("unexpected dry run: %v\n%s", err, stdout)
+	}
+	if puts := bucket.takePuts(); len(puts) != 0 {
+		t.Errorf("dry run uploaded %v", puts)
+	}
+	if _, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--set", "title=Bye"); err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	if got := strings.Join(bucket.takePuts(), ","); got != "releases/v1/index.html,releases/v1/.templr-manifest.json" {
+		t.Errorf("unexpected uploads: %s", got)
+	}
+
+	// the service error is reported
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDOTHER")
+	_, stderr, err = run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst)
+	if getExitCode(err) == 0 || !strings.Contains(stderr, "403 Forbidden") {
+		t.Errorf("expected the 403 to be reported, got %v\n%s", err, stderr)
+	}
+}
+
+func TestWalkGCSDestination(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+	src := writeRemoteSrc(t)
+
+	bucket := &fakeBucket{objects: map[string][]byte{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/cfg/o":
+			if r.URL.Query().Get("uploadType") != "media" {
+				http.Error(w, "bad upload type", http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(r.Body)
+			bucket.store(r.URL.Query().Get("name"), data)
+			_, _ = w.Write([]byte("{}"))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/storage/v1/b/cfg/o/"):
+			data, ok := bucket.load(strings.TrimPrefix(r.URL.Path, "/storage/v1/b/cfg/o/"))
+			if !ok {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("STORAGE_EMULATOR_HOST", srv.URL)
+
+	for i := 0; i < 2; i++ {
+		if _, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", "gs://cfg/prod/", "--set", "title=Hi"); err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+	}
+	if got := strings.Join(bucket.takePuts(), ","); got != "prod/conf/a.yaml,prod/index.html,prod/.templr-manifest.json" {
+		t.Errorf("unexpected uploads: %s", got)
+	}
+	manifest, _ := bucket.load("prod/.templr-manifest.json")
+	if !strings.Contains(string(manifest), `"conf/a.yaml": "sha256:`) {
+		t.Errorf("unexpected manifest:\n%s", manifest)
+	}
+}
+
+func TestRemoteUnsupportedCredentials(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+	src := writeRemoteSrc(t)
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	for _, env := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_PROFILE", "AWS_SHARED_CREDENTIALS_FILE",
+		"AWS_CONFIG_FILE", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"GOOGLE_OAUTH_ACCESS_TOKEN", "STORAGE_EMULATOR_HOST"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	awsConfig := filepath.Join(home, "aws-config")
+	if err := os.WriteFile(awsConfig, []byte("[profile dev]\nsso_session = corp\nregion = eu-west-1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	adc := filepath.Join(home, "adc.json")
+	if err := os.WriteFile(adc, []byte(`{"type": "external_account", "audience": "x"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, dst, env, value, want string
+	}{
+		{"irsa", "s3://b/p", "AWS_WEB_IDENTITY_TOKEN_FILE", "/var/run/secrets/token", "AWS credentials from web identity tokens (AWS_WEB_IDENTITY_TOKEN_FILE) are not supported"},
+		{"container", "s3://b/p", "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "/v2/credentials/x", "AWS credentials from container credentials (AWS_CONTAINER_CREDENTIALS_*) are not supported"},
+		{"sso_profile", "s3://b/p", "AWS_PROFILE", "dev", `AWS credentials from the sso_session of profile "dev" in ` + awsConfig + " are not supported"},
+		{"external_account", "gs://b/p", "GOOGLE_APPLICATION_CREDENTIALS", adc, `type "external_account" is not supported (want service_account or authorized_user)`},
+	}
+	t.Setenv("AWS_CONFIG_FILE", awsConfig)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+			_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", tt.dst)
+			if getExitCode(err) == 0 || !strings.Contains(stderr, tt.want) {
+				t.Fatalf("expected an error containing %q, got %v\n%s", tt.want, err, stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWalkRenderOrder checks that templates render in sorted path order, not
+// directory traversal order, so logs and shared-value side effects are stable.
+func TestWalkRenderOrder(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	for _, name := range []string{"b.tpl", "a/z.tpl", "a.md.tpl", "a-b.tpl"} {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stdout, stderr, err := run(t, bin, "walk", "--src", src, "--dst", filepath.Join(td, "out"))
+	if err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	var order []string
+	for _, line := range strings.Split(stdout, "\n") {
+		if strings.HasPrefix(line, "rendered ") {
+			order = append(order, strings.Fields(line)[1])
+		}
+	}
+	want := []string{"a-b.tpl", "a.md.tpl", "a/z.tpl", "b.tpl"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Fatalf("render order = %v, want %v\n%s", order, want, stdout)
+	}
+}
+
+func TestWalkIsolateValues(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"a.tpl": "{{ $_ := set .cfg \"x\" \"leaked\" }}a\n",
+		"b.tpl": "b={{ .cfg.x }}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "b=leaked"},
+		{[]string{"--isolate-values"}, "b=<no value>"},
+	} {
+		dst := filepath.Join(td, "out"+strings.Join(tc.args, ""))
+		args := append([]string{"walk", "--src", src, "--dst", dst, "--set", "cfg.y=1"}, tc.args...)
+		_, stderr, err := run(t, bin, args...)
+		if err != nil {
+			t.Fatalf("walk %v failed: %v\n%s", tc.args, err, stderr)
+		}
+		b, err := os.ReadFile(filepath.Join(dst, "b"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), tc.want) {
+			t.Errorf("walk %v: expected %q, got:\n%s", tc.args, tc.want, b)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kanopi/templr/pkg/templr"
+)
+
+func TestRequiredFields(t *testing.T) {
+	values := "db:\n  host: db.local\n  user: \"\"\n  opts: {}\nname: app\n"
+	cases := []struct {
+		name    string
+		tpl     string
+		wantErr string
+	}{
+		{"all_present", `{{ requiredFields . (list "db.host" ".name") }}ok`, ""},
+		{"one_missing", `{{ requiredFields . (list "db.host" "db.port") }}`, "missing required value .db.port"},
+		{"all_missing_reported", `{{ requiredFields . (list "db.host" "db.port" "db.user" "db.opts" "cache.url" "name.first") }}`,
+			"missing 5 required values: .db.port, .db.user, .db.opts, .cache.url, .name.first"},
+		{"variadic", `{{ requiredAll .db "host" "port" }}`, "missing required value .port"},
+		{"variadic_present", `{{ requiredAll . "db.host" }}ok`, ""},
+		{"bad_list", `{{ requiredFields . "db.host" }}`, `cannot use "db.host" as a list of dotted paths`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := templr.RenderSingle(templr.Options{Template: tc.tpl, ValuesYAML: values})
+			if tc.wantErr == "" {
+				if err != nil || res.Output != "ok" {
+					t.Fatalf("unexpected result %q, %v", res.Output, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRequiredFieldsCLI(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	tpl := filepath.Join(t.TempDir(), "db.tpl")
+	if err := os.WriteFile(tpl, []byte(`{{ requiredFields . (list "db.host" "db.port") }}host={{ .db.host }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, err := run(t, bin, "render", "-i", tpl, "--set", "db.host=x", "--no-color")
+	if getExitCode(err) != 2 || !strings.Contains(stderr, "error calling requiredFields: missing required value .db.port") {
+		t.Fatalf("expected exit 2 naming .db.port, got %v\n%s", err, stderr)
+	}
+	if strings.Contains(stderr, "Tip:") {
+		t.Errorf("a missing value should not get an argument tip:\n%s", stderr)
+	}
+}
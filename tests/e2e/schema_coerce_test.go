@@ -0,0 +1,79 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchemaTypedSetValues(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(td, name)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	schema := write("schema.yml", `type: object
+properties:
+  replicas: {type: integer}
+  version: {type: string}
+  env: {type: string, enum: [dev, prod]}
+  app:
+    $ref: "#/definitions/app"
+definitions:
+  app:
+    type: object
+    properties:
+      PORT: {type: integer}
+`)
+	cfg := write("templr.yaml", "schema:\n  path: "+schema+"\n")
+	tpl := write("typed.tpl", "{{ kindOf .replicas }}:{{ .replicas }}|{{ kindOf .version }}:{{ .version }}|{{ kindOf .app.PORT }}|{{ kindOf .app.NAME }}\n")
+	env := write(".env", "PORT=8080\nNAME=42\n")
+
+	t.Run("typed", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "--config", cfg, "-i", tpl,
+			"--set", "replicas=3", "--set", "version=1.10", "-f", env, "--env-key", "app")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if want := "int64:3|string:1.10|int64|string"; !strings.Contains(stdout, want) {
+			t.Fatalf("expected %q, got:\n%s", want, stdout)
+		}
+	})
+
+	t.Run("without_schema", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", write("guess.tpl", "{{ kindOf .version }}\n"), "--set", "version=1.10")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "float64") {
+			t.Fatalf("expected the guessed type, got:\n%s", stdout)
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		args []string
+		want string
+	}{
+		"enum":     {[]string{"--set", "env=stage"}, "--set env=stage: must be one of dev, prod"},
+		"type":     {[]string{"--set", "replicas=three"}, "--set replicas=three: expected integer"},
+		"env_file": {[]string{"-f", write("bad.env", "PORT=http\n"), "--env-key", "app"}, "app.PORT=http: expected integer"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			args := append([]string{"render", "--no-color", "--config", cfg, "-i", tpl}, tc.args...)
+			_, stderr, err := run(t, bin, args...)
+			if code := getExitCode(err); code != 8 {
+				t.Fatalf("expected exit code 8, got %d\n%s", code, stderr)
+			}
+			if !strings.Contains(stderr, tc.want) {
+				t.Fatalf("expected %q, got:\n%s", tc.want, stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchemaDocs(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	schema := filepath.Join(td, "schema.yml")
+	if err := os.WriteFile(schema, []byte(`title: Service values
+description: Values of the service chart.
+type: object
+required: [image]
+properties:
+  image:
+    type: string
+    description: Container image <name:tag>.
+  replicas:
+    type: integer
+    default: 2
+    minimum: 1
+  env:
+    type: string
+    enum: [dev, prod]
+  ports:
+    type: array
+    items:
+      $ref: "#/definitions/port"
+definitions:
+  port:
+    type: object
+    properties:
+      name: {type: string}
+      next: {$ref: "#/definitions/port"}
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("markdown", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "schema", "docs", "--schema", schema)
+		if err != nil {
+			t.Fatalf("schema docs failed: %v\n%s", err, stderr)
+		}
+		for _, want := range []string{
+			"# Service values\n",
+			"| [`.image`](#image) | string | yes |  |",
+			"| [`.replicas`](#replicas) | integer | no | `2` |",
+			"<a id=\"ports-name\"></a>\n### `.ports[].name`",
+			"- Allowed values: `\"dev\"`, `\"prod\"`",
+			"- minimum: 1",
+			"Container image <name:tag>.",
+		} {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("expected %q in:\n%s", want, stdout)
+			}
+		}
+		if !strings.Contains(stdout, "### `.ports[].next`") || strings.Contains(stdout, ".ports[].next.name") {
+			t.Errorf("expected the recursive $ref to be documented once:\n%s", stdout)
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		out := filepath.Join(td, "values.html")
+		if _, stderr, err := run(t, bin, "schema", "docs", "--schema", schema, "--format", "html", "-o", out); err != nil {
+			t.Fatalf("schema docs failed: %v\n%s", err, stderr)
+		}
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{`<section id="replicas">`, `<a href="#ports-name">`, "Container image &lt;name:tag&gt;."} {
+			if !strings.Contains(string(b), want) {
+				t.Errorf("expected %q in:\n%s", want, b)
+			}
+		}
+	})
+
+	t.Run("bad_format", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "schema", "docs", "--schema", schema, "--format", "pdf")
+		if err == nil || !strings.Contains(stderr, "want markdown or html") {
+			t.Fatalf("expected a format error, got err=%v\n%s", err, stderr)
+		}
+	})
+}
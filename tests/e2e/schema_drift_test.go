@@ -0,0 +1,97 @@
+package e2e
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchemaDrift(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	files := map[string]string{
+		".templr.schema.yml": `type: object
+properties:
+  replicas: {type: integer, default: 1}
+  image:
+    $ref: "#/definitions/image"
+  resources:
+    type: object
+    default: {cpu: 100m}
+    properties:
+      cpu: {type: string, default: 100m}
+  debug: {type: boolean, default: false}
+definitions:
+  image:
+    type: object
+    properties:
+      tag: {type: string, default: latest}
+      pullPolicy: {type: string, default: IfNotPresent}
+`,
+		"values.yaml": "replicas: 1\nimage:\n  tag: v2\n",
+		"prod.yaml":   "image:\n  pullPolicy: IfNotPresent\nresources: {cpu: 100m}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(td, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("text", func(t *testing.T) {
+		stdout, stderr, err := runIn(t, td, bin, "schema", "drift", "-f", "prod.yaml")
+		if err != nil {
+			t.Fatalf("schema drift failed: %v\n%s", err, stderr)
+		}
+		want := `set to the schema default (candidates for deletion):
+  = image.pullPolicy: "IfNotPresent" in prod.yaml
+  = replicas: 1 in values.yaml
+  = resources: {"cpu":"100m"} in prod.yaml
+schema defaults relied on (not set):
+  ? debug: false
+`
+		if stdout != want {
+			t.Fatalf("got:\n%s\nwant:\n%s", stdout, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		stdout, stderr, err := runIn(t, td, bin, "schema", "drift", "--set", "debug=false", "--set", "replicas=3", "--format", "json")
+		if err != nil {
+			t.Fatalf("schema drift failed: %v\n%s", err, stderr)
+		}
+		var drift struct {
+			Redundant []struct {
+				Key   string   `json:"key"`
+				Files []string `json:"files"`
+			} `json:"redundant"`
+			Implicit []struct {
+				Key     string `json:"key"`
+				Default any    `json:"default"`
+			} `json:"implicit"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &drift); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+		}
+		if len(drift.Redundant) != 1 || drift.Redundant[0].Key != "debug" || len(drift.Redundant[0].Files) != 0 {
+			t.Errorf("expected only debug, set by --set, to be redundant, got %+v", drift.Redundant)
+		}
+		var implicit []string
+		for _, k := range drift.Implicit {
+			implicit = append(implicit, k.Key)
+		}
+		if got := strings.Join(implicit, ","); got != "image.pullPolicy,resources,resources.cpu" {
+			t.Errorf("unexpected implicit defaults %s", got)
+		}
+	})
+
+	t.Run("bad_format", func(t *testing.T) {
+		_, stderr, err := runIn(t, td, bin, "schema", "drift", "--format", "yaml")
+		if getExitCode(err) != 1 || !strings.Contains(stderr, `unknown format "yaml"`) {
+			t.Fatalf("expected a usage error, got %v\n%s", err, stderr)
+		}
+	})
+}
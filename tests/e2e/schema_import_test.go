@@ -0,0 +1,120 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchemaImport(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(td, name)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	api := write("api.yaml", `openapi: 3.0.3
+info: {title: demo, version: "1"}
+paths: {}
+components:
+  schemas:
+    Config:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string, example: web}
+        replicas: {type: integer, nullable: true}
+        db: {$ref: '#/components/schemas/Database'}
+    Database:
+      type: object
+      properties:
+        port: {type: integer}
+        replica: {$ref: '#/components/schemas/Database'}
+`)
+
+	t.Run("openapi", func(t *testing.T) {
+		out := filepath.Join(td, "openapi.schema.yml")
+		_, stderr, err := run(t, bin, "schema", "import", "--openapi", api, "--path", "#/components/schemas/Config", "-o", out)
+		if err != nil {
+			t.Fatalf("schema import failed: %v\n%s", err, stderr)
+		}
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := string(b)
+		for _, want := range []string{"$ref: '#/definitions/Database'", "- \"null\"", "examples:", "definitions:"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("expected schema to contain %q, got:\n%s", want, got)
+			}
+		}
+
+		good := write("good.yaml", "name: web\nreplicas: null\ndb:\n  port: 5432\n  replica:\n    port: 5433\n")
+		if _, stderr, err := run(t, bin, "schema", "validate", "--schema", out, "-d", good, "--schema-mode", "error"); err != nil {
+			t.Fatalf("valid data rejected: %v\n%s", err, stderr)
+		}
+		bad := write("bad.yaml", "name: web\ndb:\n  replica:\n    port: high\n")
+		if _, _, err := run(t, bin, "schema", "validate", "--schema", out, "-d", bad, "--schema-mode", "error"); err == nil {
+			t.Fatalf("expected nested $ref violation to fail validation")
+		}
+	})
+
+	t.Run("openapi_missing_path", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "schema", "import", "--openapi", api, "--path", "#/components/schemas/Nope")
+		if err == nil || !strings.Contains(stderr, "Nope") {
+			t.Fatalf("expected error naming the missing schema, got err=%v\n%s", err, stderr)
+		}
+	})
+
+	t.Run("crd", func(t *testing.T) {
+		crd := write("crd.yaml", `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata: {name: widgets.example.com}
+spec:
+  group: example.com
+  names: {kind: Widget, plural: widgets}
+  scope: Namespaced
+  versions:
+    - name: v1alpha1
+      served: true
+      storage: false
+      schema:
+        openAPIV3Schema: {type: object}
+    - name: v1
+      served: true
+      storage: true
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            spec:
+              type: object
+              x-kubernetes-preserve-unknown-fields: true
+              properties:
+                port:
+                  x-kubernetes-int-or-string: true
+`)
+		stdout, stderr, err := run(t, bin, "schema", "import", "--crd", crd, "--path", "#/properties/spec")
+		if err != nil {
+			t.Fatalf("schema import failed: %v\n%s", err, stderr)
+		}
+		if strings.Contains(stdout, "x-kubernetes") {
+			t.Errorf("expected x- extensions to be dropped, got:\n%s", stdout)
+		}
+		if !strings.Contains(stdout, "anyOf:") || !strings.Contains(stdout, "port:") {
+			t.Errorf("expected int-or-string port as anyOf, got:\n%s", stdout)
+		}
+
+		stdout, _, err = run(t, bin, "schema", "import", "--crd", crd, "--crd-version", "v1alpha1")
+		if err != nil || strings.Contains(stdout, "properties") {
+			t.Fatalf("expected the v1alpha1 schema, got err=%v\n%s", err, stdout)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package e2e
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSchemaRemoteURL(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	schema := "type: object\nproperties:\n  replicas: {type: integer}\nrequired: [replicas]\n"
+	sum := sha256.Sum256([]byte(schema))
+	digest := hex.EncodeToString(sum[:])
+
+	var requests, notModified atomic.Int32
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(schema))
+	}))
+	defer srv.Close()
+	url := srv.URL + "/app.yml"
+
+	td := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(td, "cache"))
+	data := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(data, []byte("replicas: 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	validate := func(args ...string) (string, string, error) {
+		return run(t, bin, append([]string{"schema", "validate", "--no-color", "--data", data, "--schema-mode", "error", "--schema", url}, args...)...)
+	}
+
+	t.Run("fetch_then_etag", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			stdout, stderr, err := validate()
+			if err != nil {
+				t.Fatalf("validate %d failed: %v\n%s", i, err, stderr)
+			}
+			if !strings.Contains(stdout, "Validation passed") {
+				t.Fatalf("expected a pass, got:\n%s", stdout)
+			}
+		}
+		if notModified.Load() != 1 {
+			t.Fatalf("expected the second run to be revalidated with 304, got %d", notModified.Load())
+		}
+	})
+
+	t.Run("pin_match_uses_cache", func(t *testing.T) {
+		before := requests.Load()
+		if _, stderr, err := validate("--schema-sha256", digest); err != nil {
+			t.Fatalf("validate failed: %v\n%s", err, stderr)
+		}
+		if requests.Load() != before {
+			t.Fatalf("expected a pinned cached schema to skip the request")
+		}
+	})
+
+	t.Run("pin_mismatch", func(t *testing.T) {
+		_, stderr, err := validate("--schema-sha256", strings.Repeat("0", 64))
+		if code := getExitCode(err); code != 8 {
+			t.Fatalf("expected exit 8, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "does not match the pinned") {
+			t.Fatalf("expected a pin error, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("registry_down_uses_cache", func(t *testing.T) {
+		down.Store(true)
+		defer down.Store(false)
+		_, stderr, err := validate()
+		if err != nil {
+			t.Fatalf("validate failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stderr, "using the cached copy") {
+			t.Fatalf("expected a cache warning, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("plain_http_rejected", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "schema", "validate", "--data", data, "--schema", "http://schemas.example.com/app.yml")
+		if err == nil || !strings.Contains(stderr, "only https is allowed") {
+			t.Fatalf("expected plain http to be rejected, got err=%v\n%s", err, stderr)
+		}
+	})
+}
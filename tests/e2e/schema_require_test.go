@@ -0,0 +1,80 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequireSchema(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		".templr.schema.yml": "type: object\nproperties:\n  port: {type: integer, maximum: 65535}\nrequired: [port]\n",
+		"src/a.conf.tpl":     "port={{ .port }}\n",
+		"src/b.conf.tpl":     "b\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(td, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	walk := func(t *testing.T, args ...string) (string, string, error) {
+		return runIn(t, td, bin, append([]string{"walk", "--src", "src", "--dst", "out"}, args...)...)
+	}
+
+	t.Run("invalid_values_render_nothing", func(t *testing.T) {
+		_, stderr, err := walk(t, "--require-schema", "--set", "port=70000")
+		if code := getExitCode(err); code != 8 {
+			t.Fatalf("expected exit code 8, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "[templr:error:schema]") || !strings.Contains(stderr, "nothing was rendered") {
+			t.Errorf("expected the schema errors, got:\n%s", stderr)
+		}
+		if _, err := os.Stat(filepath.Join(td, "out")); !os.IsNotExist(err) {
+			t.Errorf("expected no output tree, got %v", err)
+		}
+	})
+
+	t.Run("valid_values", func(t *testing.T) {
+		if _, stderr, err := walk(t, "--require-schema", "--set", "port=8080"); err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+	})
+
+	t.Run("not_required", func(t *testing.T) {
+		if _, stderr, err := runIn(t, td, bin, "render", "-i", "src/b.conf.tpl"); err != nil {
+			t.Fatalf("expected render without --require-schema to skip validation: %v\n%s", err, stderr)
+		}
+	})
+
+	t.Run("config_enforce", func(t *testing.T) {
+		cfg := filepath.Join(td, "enforce.yaml")
+		if err := os.WriteFile(cfg, []byte("schema:\n  enforce: render\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err := runIn(t, td, bin, "render", "--config", cfg, "-i", "src/b.conf.tpl")
+		if code := getExitCode(err); code != 8 || !strings.Contains(stderr, "missing property 'port'") {
+			t.Fatalf("expected schema.enforce to validate render, got %d\n%s", code, stderr)
+		}
+	})
+
+	t.Run("no_schema", func(t *testing.T) {
+		dir := t.TempDir()
+		tpl := filepath.Join(dir, "x.tpl")
+		if err := os.WriteFile(tpl, []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err := runIn(t, dir, bin, "render", "--require-schema", "-i", tpl)
+		if code := getExitCode(err); code != 8 || !strings.Contains(stderr, "no schema file found") {
+			t.Fatalf("expected a missing schema error, got %d\n%s", code, stderr)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSequenceFunctions(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	cases := []struct {
+		name, tpl, want string
+	}{
+		{"seq_is_sprigs_string", `{{ seq 3 | replace " " "," }}|{{ seq 5 2 }}|{{ kindOf (seq 2) }}`, "1,2,3|5 4 3 2|string"},
+		{"seqList_prints_like_seq", `{{ seqList 3 }}|{{ seqList 5 2 }}|{{ seqList 0 2 10 }}|{{ seqList 5 2 1 }}`, "1 2 3|5 4 3 2|0 2 4 6 8 10|"},
+		{"seqList_range", `{{ range seqList 1 3 }}node-{{ . }} {{ end }}`, "node-1 node-2 node-3 "},
+		{"seqList_from_values", `{{ range seqList .replicas }}{{ . }}{{ end }}`, "1234"},
+		{"seqStep", `{{ seqStep 0 100 25 }}|{{ seqStep 10 0 -5 }}|{{ seqStep 0 10 -1 }}`, "0 25 50 75 100|10 5 0|"},
+		{"chunk_list_first", `{{ range chunk (list "a" "b" "c" "d" "e") 2 }}{{ join "," . }};{{ end }}`, "a,b;c,d;e;"},
+		{"chunk_sprig_order", `{{ list 1 2 3 | chunk 2 }}`, "[[1 2] [3]]"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tpl := filepath.Join(td, tc.name+".tpl")
+			if err := os.WriteFile(tpl, []byte(tc.tpl), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			stdout, stderr, err := run(t, bin, "render", "-i", tpl, "--set", "replicas=4")
+			if err != nil {
+				t.Fatalf("render failed: %v\n%s", err, stderr)
+			}
+			if got := strings.TrimSuffix(stdout, "\n"); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("invalid_arguments", func(t *testing.T) {
+		for tpl, want := range map[string]string{
+			`{{ seqStep 1 10 0 }}`:     "seqStep: step must not be 0",
+			`{{ chunk (list 1 2) 0 }}`: "chunk: size must be positive",
+			`{{ chunk "abc" "x" }}`:    `chunk: cannot use "x" as an integer`,
+			`{{ seqList 1.5 }}`:        "seqList: cannot use 1.5 (float64) as an integer",
+			`{{ seqList 1 2 3 4 }}`:    "seqList: takes 1 to 3 arguments",
+		} {
+			path := filepath.Join(td, "invalid.tpl")
+			if err := os.WriteFile(path, []byte(tpl), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, stderr, err := run(t, bin, "render", "--no-color", "-i", path)
+			if code := getExitCode(err); code != 2 || !strings.Contains(stderr, want) {
+				t.Errorf("%s: expected exit code 2 and %q, got %d\n%s", tpl, want, code, stderr)
+			}
+		}
+	})
+}
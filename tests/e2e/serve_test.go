@@ -0,0 +1,172 @@
+package e2e
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kanopi/templr/pkg/rpc/templrv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// startServe runs `templr serve --grpc 127.0.0.1:0` with args and returns a
+// client of the address it reports.
+func startServe(t *testing.T, args ...string) templrv1.RenderServiceClient {
+	t.Helper()
+	wd, _ := os.Getwd()
+	bin := buildTemplr(t, wd)
+
+	cmd := exec.Command(bin, append([]string{"serve", "--grpc", "127.0.0.1:0"}, args...)...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Signal(os.Interrupt)
+		_ = cmd.Wait()
+	})
+
+	line, err := bufio.NewReader(stderr).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read serve banner: %v", err)
+	}
+	addr := strings.TrimSpace(line[strings.LastIndex(line, " ")+1:])
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return templrv1.NewRenderServiceClient(conn)
+}
+
+func TestServeGRPC(t *testing.T) {
+	client := startServe(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	t.Run("render_single", func(t *testing.T) {
+		res, err := client.RenderSingle(ctx, &templrv1.RenderSingleRequest{
+			Template: `Hello {{ .name }} {{ call .Files.Get "motd.txt" }}`,
+			Options: &templrv1.RenderOptions{
+				Values: "name: World\n",
+				Files:  map[string]string{"motd.txt": "hi"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.GetOutput() != "Hello World hi" {
+			t.Fatalf("unexpected output: %q", res.GetOutput())
+		}
+	})
+
+	t.Run("render_error", func(t *testing.T) {
+		_, err := client.RenderSingle(ctx, &templrv1.RenderSingleRequest{
+			Template: `{{ .missing.key }}`,
+			Options:  &templrv1.RenderOptions{Strict: true},
+		})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected InvalidArgument, got %v", err)
+		}
+	})
+
+	t.Run("render_tree", func(t *testing.T) {
+		res, err := client.RenderTree(ctx, &templrv1.RenderTreeRequest{
+			Templates: map[string]string{
+				"_helpers.tpl":   `{{ define "greet" }}hi {{ . }}{{ end }}`,
+				"app/config.tpl": `{{ include "greet" .name }}`,
+				"empty.tpl":      `{{ if false }}x{{ end }}`,
+			},
+			Options: &templrv1.RenderOptions{Values: "name: ops\n"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := res.GetOutputs()
+		if len(out) != 1 || out["app/config"] != "hi ops" {
+			t.Fatalf("unexpected outputs: %v", out)
+		}
+	})
+
+	t.Run("lint", func(t *testing.T) {
+		res, err := client.Lint(ctx, &templrv1.LintRequest{Template: "{{ .a }}\n{{ if .b }}", Values: "a: 1\n"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		issues := res.GetIssues()
+		if len(issues) != 1 || issues[0].GetRule() != "parse" || issues[0].GetSeverity() != "error" {
+			t.Fatalf("expected one parse error, got %v", issues)
+		}
+
+		res, err = client.Lint(ctx, &templrv1.LintRequest{Template: "{{ .a }} {{ .b }}", Values: "a: 1\n"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		issues = res.GetIssues()
+		if len(issues) != 1 || !strings.Contains(issues[0].GetMessage(), ".b") {
+			t.Fatalf("expected .b reported as undefined, got %v", issues)
+		}
+	})
+
+	t.Run("validate_schema", func(t *testing.T) {
+		schema := "type: object\nrequired: [replicas]\nproperties:\n  replicas:\n    type: integer\n"
+		res, err := client.ValidateSchema(ctx, &templrv1.ValidateSchemaRequest{Schema: schema, Values: "replicas: 3\n"})
+		if err != nil || !res.GetValid() {
+			t.Fatalf("expected valid values, got %v, %v", res, err)
+		}
+		res, err = client.ValidateSchema(ctx, &templrv1.ValidateSchemaRequest{Schema: schema, Values: "replicas: three\n"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, e := range res.GetErrors() {
+			found = found || e.GetPath() == ".replicas"
+		}
+		if res.GetValid() || !found {
+			t.Fatalf("expected a .replicas error, got %v", res)
+		}
+	})
+}
+
+func TestServeHostFuncs(t *testing.T) {
+	t.Setenv("TEMPLR_SERVE_SECRET", "s3cret")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req := &templrv1.RenderSingleRequest{Template: `{{ env "TEMPLR_SERVE_SECRET" }}`}
+
+	t.Run("disabled_by_default", func(t *testing.T) {
+		client := startServe(t)
+		for _, tpl := range []string{req.Template, `{{ expandenv "$TEMPLR_SERVE_SECRET" }}`, `{{ getHostByName "localhost" }}`} {
+			_, err := client.RenderSingle(ctx, &templrv1.RenderSingleRequest{Template: tpl})
+			if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "not defined") {
+				t.Fatalf("%s: expected an undefined function error, got %v", tpl, err)
+			}
+		}
+		_, err := client.RenderTree(ctx, &templrv1.RenderTreeRequest{Templates: map[string]string{"a.tpl": req.Template}})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected InvalidArgument from RenderTree, got %v", err)
+		}
+	})
+
+	t.Run("allow_host_funcs", func(t *testing.T) {
+		client := startServe(t, "--allow-host-funcs")
+		res, err := client.RenderSingle(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.GetOutput() != "s3cret" {
+			t.Fatalf("unexpected output: %q", res.GetOutput())
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSince(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	repo := initGitRepo(t)
+	write := func(name, content string) {
+		p := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit := func() {
+		gitIn(t, repo, "add", "-A")
+		gitIn(t, repo, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-qm", "c")
+	}
+	write("templates/_helpers.tpl", `{{ define "app.name" }}{{ .app.name }}{{ end }}`)
+	write("templates/app.yaml.tpl", "name: {{ include \"app.name\" . }}\n")
+	write("templates/db.yaml.tpl", "host: {{ .db.host }}\n")
+	write("templates/static.txt.tpl", "static\n")
+	write("templates/values.yaml", "app: {name: x}\ndb: {host: a}\n")
+	write("lint/ok.tpl", "{{ .a }}\n")
+	write("lint/broken.tpl", "{{ .a \n")
+	commit()
+
+	walk := func(dst string) string {
+		t.Helper()
+		stdout, stderr, err := runIn(t, repo, bin, "walk", "--src", "templates", "--dst", dst, "--since", "HEAD")
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		return stdout
+	}
+	rendered := func(dst string) []string {
+		entries, _ := os.ReadDir(filepath.Join(repo, dst))
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+
+	t.Run("helper_changed", func(t *testing.T) {
+		write("templates/_helpers.tpl", `{{ define "app.name" }}{{ .app.name | upper }}{{ end }}`)
+		stdout := walk("out1")
+		if got := strings.Join(rendered("out1"), ","); got != "app.yaml" {
+			t.Fatalf("expected only app.yaml, got %q\n%s", got, stdout)
+		}
+		if !strings.Contains(stdout, "1 of 3 templates changed since HEAD") {
+			t.Fatalf("expected a summary line, got:\n%s", stdout)
+		}
+		commit()
+	})
+
+	t.Run("values_changed", func(t *testing.T) {
+		write("templates/values.yaml", "app: {name: x}\ndb: {host: b}\n")
+		stdout := walk("out2")
+		if got := strings.Join(rendered("out2"), ","); got != "db.yaml" {
+			t.Fatalf("expected only db.yaml, got %q\n%s", got, stdout)
+		}
+		commit()
+	})
+
+	t.Run("lint", func(t *testing.T) {
+		if _, stderr, err := runIn(t, repo, bin, "lint", "--src", "lint", "--since", "HEAD"); err != nil {
+			t.Fatalf("expected the unchanged broken template to be out of scope: %v\n%s", err, stderr)
+		}
+		write("lint/broken.tpl", "{{ .b \n")
+		if _, _, err := runIn(t, repo, bin, "lint", "--src", "lint", "--since", "HEAD"); getExitCode(err) == 0 {
+			t.Fatal("expected the changed broken template to be linted")
+		}
+	})
+
+	t.Run("bad_ref", func(t *testing.T) {
+		_, stderr, err := runIn(t, repo, bin, "walk", "--src", "templates", "--dst", "out3", "--since", "nope")
+		if getExitCode(err) == 0 || !strings.Contains(stderr, "not a commit") {
+			t.Fatalf("expected a usage error, got:\n%s", stderr)
+		}
+	})
+}
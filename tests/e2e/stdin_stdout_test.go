@@ -33,3 +33,69 @@ func TestStdinStdoutRender(t *testing.T) {
 		t.Fatalf("expected stdout to contain rendered content, got:\n%s", got)
 	}
 }
+
+func TestStdinTemplateSubcommands(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	vals := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(vals, []byte("name: templr\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	helpers := filepath.Join(td, "helpers")
+	if err := os.MkdirAll(helpers, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(helpers, "_helpers.tpl"), []byte(`{{ define "greet" }}Hi {{ .name }}{{ end }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(helpers, "footer.tpl"), []byte(`-- {{ .name }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	runStdin := func(stdin string, args ...string) (string, string, error) {
+		cmd := exec.Command(bin, args...)
+		cmd.Stdin = bytes.NewBufferString(stdin)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	t.Run("lint_stdin", func(t *testing.T) {
+		stdout, stderr, err := runStdin("{{ .name }\n", "lint", "--no-color", "-i", "-", "-d", vals)
+		if err == nil {
+			t.Fatalf("expected lint of broken stdin template to fail\nstdout: %s\nstderr: %s", stdout, stderr)
+		}
+		if !strings.Contains(stdout+stderr, "stdin") {
+			t.Fatalf("expected issue to name stdin, got:\nstdout: %s\nstderr: %s", stdout, stderr)
+		}
+
+		stdout, stderr, err = runStdin("Hello {{ .name }}\n", "lint", "--no-color", "-i", "-", "-d", vals)
+		if err != nil {
+			t.Fatalf("lint of valid stdin template failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+		}
+	})
+
+	t.Run("render_helpers_dir", func(t *testing.T) {
+		stdout, stderr, err := runStdin(`{{ template "greet" . }}`, "render", "--no-color", "-d", vals, "--helpers-dir", helpers)
+		if err != nil {
+			t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+		}
+		if got := normalizeOut(stdout); !strings.Contains(got, "Hi templr") {
+			t.Fatalf("expected helper output, got:\n%s", got)
+		}
+	})
+
+	t.Run("dir_stdin_entry", func(t *testing.T) {
+		stdout, stderr, err := runStdin(`{{ template "greet" . }} {{ template "footer.tpl" . }}`, "dir", "--no-color", "--dir", helpers, "-i", "-", "-d", vals)
+		if err != nil {
+			t.Fatalf("dir failed: %v\nstderr: %s", err, stderr)
+		}
+		if got := normalizeOut(stdout); !strings.Contains(got, "Hi templr -- templr") {
+			t.Fatalf("expected stdin entry rendered with dir templates, got:\n%s", got)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package e2e
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -283,6 +284,43 @@ func TestSubcommandVersion(t *testing.T) {
 	}
 }
 
+// TestVersionJSON tests the structured build information of version --format json
+func TestVersionJSON(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	stdout, stderr, err := run(t, bin, "version", "--format", "json")
+	if err != nil {
+		t.Fatalf("version --format json failed: %v, stderr=%s", err, stderr)
+	}
+	var info struct {
+		Version   string          `json:"version"`
+		GoVersion string          `json:"go_version"`
+		Platform  string          `json:"platform"`
+		Features  map[string]bool `json:"features"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &info); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if info.Version == "" || !strings.HasPrefix(info.GoVersion, "go") || info.Platform == "" {
+		t.Fatalf("missing build information: %+v", info)
+	}
+	for _, f := range []string{"wasm", "network_functions", "sops", "fips"} {
+		if _, ok := info.Features[f]; !ok {
+			t.Errorf("expected feature %q in %v", f, info.Features)
+		}
+	}
+
+	plain, _, _ := run(t, bin, "version")
+	if strings.TrimSpace(plain) != info.Version {
+		t.Errorf("expected plain version %q to match JSON version %q", strings.TrimSpace(plain), info.Version)
+	}
+
+	if _, _, err := run(t, bin, "version", "--format", "xml"); getExitCode(err) != 1 {
+		t.Errorf("expected exit code 1 for an invalid format, got %v", err)
+	}
+}
+
 // TestSubcommandHelp tests help output
 func TestSubcommandHelp(t *testing.T) {
 	start, _ := os.Getwd()
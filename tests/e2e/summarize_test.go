@@ -0,0 +1,64 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	repo := initGitRepo(t)
+	write := func(name, content string) {
+		p := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("deploy/app.yaml", "# #templr generated\nimage: {tag: \"1.2\"}\ndb: {password: old-secret}\n")
+	write("deploy/gone.yaml", "# #templr generated\nx: 1\n")
+	write("deploy/notes.txt", "hand-written\n")
+	gitIn(t, repo, "add", ".")
+	gitIn(t, repo, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-qm", "base")
+
+	write("deploy/app.yaml", "# #templr generated\nimage: {tag: \"1.3\"}\ndb: {password: new-secret}\nreplicas: 2\n")
+	write("deploy/worker.yaml", "# #templr generated\nw: 1\n")
+	write("deploy/notes.txt", "edited by hand\n")
+	if err := os.Remove(filepath.Join(repo, "deploy/gone.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := runIn(t, repo, bin, "summarize", "--base", "HEAD", "deploy")
+	if err != nil {
+		t.Fatalf("summarize failed: %v\n%s", err, stderr)
+	}
+	for _, want := range []string{
+		"Compared with `HEAD`: 1 added, 1 changed, 1 removed generated files.",
+		"| `deploy/app.yaml` | changed |",
+		"| `deploy/gone.yaml` | removed |",
+		"| `deploy/worker.yaml` | added |",
+		"- `image.tag`: `\"1.2\"` → `\"1.3\"`",
+		"- added `replicas`: `2`",
+		"- `db.password`: `\"[redacted]\"` → `\"[redacted]\"`",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "notes.txt") || strings.Contains(stdout, "secret") {
+		t.Errorf("expected hand-written files and secrets to be left out:\n%s", stdout)
+	}
+
+	t.Run("bad_base", func(t *testing.T) {
+		_, stderr, err := runIn(t, repo, bin, "summarize", "--base", "no-such-ref")
+		if code := getExitCode(err); code == 0 || !strings.Contains(stderr, "not a commit") {
+			t.Fatalf("expected a usage error, got %d\n%s", code, stderr)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateScopes(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	write := func(rel, content string) string {
+		p := filepath.Join(td, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	write("src/_helpers.tpl", `{{ define "greet" }}hello {{ .name }}{{ end }}`)
+	write("src/app.conf.tpl", "app={{ .name }} {{ .missing }}\n")
+	write("src/workflows/.templr.yaml", "template:\n  left_delimiter: \"[[\"\n  right_delimiter: \"]]\"\n")
+	write("src/workflows/ci.yml.tpl", "name: [[ .name ]]\nrun: echo ${{ github.sha }} [[ include \"greet\" . ]]\n")
+	write("src/prod/db.conf.tpl", "db={{ .name }}\n")
+	cfg := write("templr.yaml", "template:\n  scopes:\n    - path: prod\n      strict: true\n")
+	src, dst := filepath.Join(td, "src"), filepath.Join(td, "out")
+
+	t.Run("delimiters_by_directory", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--config", cfg, "--set", "name=web")
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		ci, err := os.ReadFile(filepath.Join(dst, "workflows", "ci.yml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "name: web\nrun: echo ${{ github.sha }} hello web"; !strings.Contains(string(ci), want) {
+			t.Fatalf("expected %q, got:\n%s", want, ci)
+		}
+		if _, err := os.Stat(filepath.Join(dst, "workflows", ".templr.yaml")); !os.IsNotExist(err) {
+			t.Fatalf("expected the scope config not to be rendered, got err=%v", err)
+		}
+	})
+
+	t.Run("strict_by_path", func(t *testing.T) {
+		write("src/prod/db.conf.tpl", "db={{ .name }} {{ .password }}\n")
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--config", cfg, "--set", "name=web")
+		if code := getExitCode(err); code != 4 {
+			t.Fatalf("expected exit code 4, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "prod/db.conf.tpl") || strings.Contains(stderr, "app.conf.tpl") {
+			t.Fatalf("expected only the prod template to be strict, got:\n%s", stderr)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTfvars(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	tfvars := filepath.Join(td, "terraform.tfvars")
+	if err := os.WriteFile(tfvars, []byte(`# shared with terraform
+region   = "eu-west-1"
+replicas = 3
+enabled  = true
+tags = {
+  team          = "web" // owner
+  "cost-center" = "42"
+}
+zones = ["a", "b",
+  "c",
+]
+policy = <<-EOT
+    {"Resource": "$${aws:username}"}
+  EOT
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) string {
+		p := filepath.Join(td, name)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	t.Run("values_file", func(t *testing.T) {
+		tpl := write("values.tpl", `{{ .region }} {{ .replicas }} {{ .enabled }} {{ index .tags "cost-center" }} {{ join "," .zones }} {{ .policy }}`)
+		stdout, stderr, err := run(t, bin, "render", "-i", tpl, "-f", tfvars)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		want := "eu-west-1 3 true 42 a,b,c {\"Resource\": \"${aws:username}\"}\n"
+		if stdout != want {
+			t.Errorf("got %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("toTfvars", func(t *testing.T) {
+		tpl := write("to.tpl", `{{ dict "region" .region "replicas" .replicas "tags" .tags "zones" .zones "policy" .policy | toTfvars }}`)
+		stdout, stderr, err := run(t, bin, "render", "-i", tpl, "-f", tfvars)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		want := `policy   = "{\"Resource\": \"$${aws:username}\"}\n"
+region   = "eu-west-1"
+replicas = 3
+tags     = {
+  cost-center = "42"
+  team        = "web"
+}
+zones = ["a", "b", "c"]
+`
+		if stdout != want {
+			t.Errorf("got:\n%s\nwant:\n%s", stdout, want)
+		}
+	})
+
+	t.Run("round_trip", func(t *testing.T) {
+		tpl := write("round.tpl", `{{ $v := .Files.Get "terraform.tfvars" | fromTfvars }}{{ eq (toJson $v) (toJson (fromTfvars (toTfvars $v))) }}`)
+		stdout, stderr, err := run(t, bin, "render", "-i", tpl)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if strings.TrimSpace(stdout) != "true" {
+			t.Errorf("round trip changed the values: %q", stdout)
+		}
+	})
+
+	t.Run("expressions_rejected", func(t *testing.T) {
+		bad := write("bad.tfvars", "region = var.default_region\n")
+		tpl := write("bad.tpl", "{{ .region }}")
+		_, stderr, err := run(t, bin, "render", "-i", tpl, "-f", bad)
+		if err == nil || !strings.Contains(stderr, "tfvars line 1: unsupported expression var") {
+			t.Errorf("expected an unsupported expression error, got %v\n%s", err, stderr)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTracingConsoleExporter(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	dst := filepath.Join(td, "dst")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a.tpl"), []byte("hello {{ .name }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// No OTEL_* variables: no spans are printed
+	t.Setenv("OTEL_TRACES_EXPORTER", "")
+	_, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--set", "name=x")
+	if err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	if strings.Contains(stderr, "templr.walk") {
+		t.Fatalf("unexpected spans without OTEL configuration:\n%s", stderr)
+	}
+
+	t.Setenv("OTEL_TRACES_EXPORTER", "console")
+	_, stderr, err = run(t, bin, "walk", "--src", src, "--dst", filepath.Join(td, "dst2"), "--set", "name=x")
+	if err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	for _, span := range []string{"templr.walk", "templr.load_values", "templr.parse", "templr.execute", "templr.write"} {
+		if !strings.Contains(stderr, `"Name":"`+span+`"`) {
+			t.Errorf("missing span %s in:\n%s", span, stderr)
+		}
+	}
+	b, err := os.ReadFile(filepath.Join(td, "dst2", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "hello x") {
+		t.Fatalf("unexpected output: %s", b)
+	}
+}
@@ -0,0 +1,171 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// buildReleaseTemplr builds templr with version set as by a release.
+func buildReleaseTemplr(t *testing.T, version string) string {
+	t.Helper()
+	start, _ := os.Getwd()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+	bin := filepath.Join(t.TempDir(), "templr")
+	build := exec.CommandContext(ctx, "go", "build", "-ldflags", "-X main.Version="+version, "-o", bin, ".")
+	build.Dir = repoRoot(start)
+	build.Env = append(os.Environ(), "CGO_ENABLED=0")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("build failed: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// runInTerminal runs bin under script(1), so that its stdio is a terminal,
+// and returns what it printed.
+func runInTerminal(t *testing.T, bin string, args ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("script"); err != nil {
+		t.Skip("script(1) is not available")
+	}
+	line := bin
+	for _, a := range args {
+		line += " '" + a + "'"
+	}
+	out, err := exec.Command("script", "-qec", line, "/dev/null").CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s failed: %v\n%s", line, err, out)
+	}
+	return strings.ReplaceAll(string(out), "\r\n", "\n")
+}
+
+// TestUpdateCheckNotInteractive checks that a release build does not look up
+// the latest release when stderr is not a terminal, as in scripts and CI.
+func TestUpdateCheckNotInteractive(t *testing.T) {
+	bin := buildReleaseTemplr(t, "1.0.0")
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"tag_name":"v9.0.0"}`))
+	}))
+	defer srv.Close()
+
+	for _, env := range []string{"CI", "GITHUB_ACTIONS"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("TEMPLR_UPDATE_CHECK_URL", srv.URL)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	stdout, stderr, err := run(t, bin, "version")
+	if err != nil {
+		t.Fatalf("version failed: %v\n%s", err, stderr)
+	}
+	if strings.TrimSpace(stdout) != "1.0.0" {
+		t.Errorf("unexpected version %q", stdout)
+	}
+	if strings.Contains(stderr, "is available") || requests.Load() != 0 {
+		t.Errorf("expected no update check, got %d requests and stderr:\n%s", requests.Load(), stderr)
+	}
+}
+
+// TestUpdateCheckInteractive checks that an interactive run of a release
+// build looks up the latest release once a day, waiting for the lookup so
+// that the run that makes it reports it and caches it for the next ones.
+func TestUpdateCheckInteractive(t *testing.T) {
+	bin := buildReleaseTemplr(t, "1.0.0")
+
+	var requests atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+		_, _ = w.Write([]byte(`{"tag_name":"v9.0.0"}`))
+	}))
+	defer srv.Close()
+
+	for _, env := range []string{"CI", "GITHUB_ACTIONS", "TEMPLR_NO_UPDATE_CHECK"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("TEMPLR_UPDATE_CHECK_URL", srv.URL)
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	cachePath := filepath.Join(cacheHome, "templr", "update-check.json")
+	notice := "templr v9.0.0 is available (you have 1.0.0)"
+
+	// The first run looks the release up and reports it
+	out := runInTerminal(t, bin, "version")
+	if !strings.Contains(out, notice) || requests.Load() != 1 {
+		t.Fatalf("expected one request and the notice, got %d requests and:\n%s", requests.Load(), out)
+	}
+	b, err := os.ReadFile(cachePath)
+	if err != nil || !strings.Contains(string(b), `"latest":"v9.0.0"`) {
+		t.Fatalf("unexpected cache %s: %v", b, err)
+	}
+
+	// The next runs report the cached release without a request
+	out = runInTerminal(t, bin, "version")
+	if !strings.Contains(out, notice) || requests.Load() != 1 {
+		t.Fatalf("expected the cached notice, got %d requests and:\n%s", requests.Load(), out)
+	}
+
+	// A failed lookup keeps the cached release and is recorded, so that it
+	// is not retried on the next run
+	stale := `{"checked_at":"2020-01-01T00:00:00Z","latest":"v9.0.0"}`
+	if err := os.WriteFile(cachePath, []byte(stale), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	status.Store(http.StatusInternalServerError)
+	for range 2 {
+		out = runInTerminal(t, bin, "version")
+		if !strings.Contains(out, notice) {
+			t.Errorf("expected the cached notice after a failed lookup:\n%s", out)
+		}
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected one more request, got %d", requests.Load()-1)
+	}
+	if b, _ := os.ReadFile(cachePath); strings.Contains(string(b), "2020-") {
+		t.Errorf("failed lookup not recorded: %s", b)
+	}
+}
+
+// TestUpdateCheckPseudoVersion checks that builds from a commit, whose
+// version is a pseudo-version, and prereleases do not check for updates.
+func TestUpdateCheckPseudoVersion(t *testing.T) {
+	var bins []string
+	for _, version := range []string{"v0.0.0-20261017030656-4b9810a72bfa", "v1.2.0-rc.1"} {
+		bins = append(bins, buildReleaseTemplr(t, version))
+	}
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"tag_name":"v9.0.0"}`))
+	}))
+	defer srv.Close()
+
+	for _, env := range []string{"CI", "GITHUB_ACTIONS", "TEMPLR_NO_UPDATE_CHECK"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("TEMPLR_UPDATE_CHECK_URL", srv.URL)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	for _, bin := range bins {
+		if out := runInTerminal(t, bin, "version"); strings.Contains(out, "is available") {
+			t.Errorf("unexpected notice:\n%s", out)
+		}
+	}
+	if requests.Load() != 0 {
+		t.Errorf("expected no update check, got %d requests", requests.Load())
+	}
+}
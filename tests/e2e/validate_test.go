@@ -0,0 +1,96 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateOutputs(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	if err := os.MkdirAll(filepath.Join(src, "nginx"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(src, "app.service.tpl"):    "[Unit]\nDescription={{ .name }}\n\n[Service]\nExecStart=/usr/bin/{{ .name }} \\\n  --port {{ .port }}\n\n[Install]\nWantedBy=multi-user.target\n",
+		filepath.Join(src, "worker.service.tpl"): "[Unit]\nDescription=worker\n[Service]\nExecStrat=/usr/bin/worker\n",
+		filepath.Join(src, "nginx", "site.conf.tpl"): `server {
+    listen {{ .port }};
+    server_name example.com; # comment with { brace
+    location / {
+        proxy_pass http://127.0.0.1:{{ .port }};
+        add_header X-Test "a;b";
+    }
+{{- if .broken }}
+    location /api {
+        proxy_pass http://api
+    }
+{{- end }}
+}
+`,
+		filepath.Join(src, "notes.txt.tpl"): "[not a unit\n",
+		filepath.Join(src, "values.yaml"):   "name: app\nport: 8080\n",
+		filepath.Join(td, "templr.yaml"): `render:
+  validate:
+    - files: ["*.service"]
+      validate: systemd-unit
+    - files: ["nginx/*.conf"]
+      validate: nginx
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := filepath.Join(td, "templr.yaml")
+
+	t.Run("walk", func(t *testing.T) {
+		dst := filepath.Join(td, "out")
+		_, stderr, err := run(t, bin, "walk", "--no-color", "--src", src, "--dst", dst, "--config", cfg, "--set", "broken=true")
+		if code := getExitCode(err); code != 12 {
+			t.Fatalf("exit code = %d, want 12\n%s", code, stderr)
+		}
+		for _, want := range []string{
+			`[templr:error:validate] worker.service (from worker.service.tpl): systemd-unit: [Service] has no ExecStart=`,
+			`[templr:error:validate] nginx/site.conf (from nginx/site.conf.tpl): nginx: line 9: directive "proxy_pass" is not terminated by ";"`,
+			"2 outputs failed validation",
+		} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("stderr lacks %q:\n%s", want, stderr)
+			}
+		}
+		// valid outputs are written, rejected ones are not
+		for name, exists := range map[string]bool{"app.service": true, "notes.txt": true, "worker.service": false, "nginx/site.conf": false} {
+			if _, err := os.Stat(filepath.Join(dst, name)); (err == nil) != exists {
+				t.Errorf("%s exists = %v, want %v", name, err == nil, exists)
+			}
+		}
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "-i", filepath.Join(src, "nginx", "site.conf.tpl"), "-d", filepath.Join(src, "values.yaml"), "--config", cfg)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "listen 8080;") {
+			t.Errorf("unexpected output:\n%s", stdout)
+		}
+	})
+
+	t.Run("unknown_validator", func(t *testing.T) {
+		bad := filepath.Join(td, "bad.yaml")
+		if err := os.WriteFile(bad, []byte("render:\n  validate:\n    - files: ['*.conf']\n      validate: apache\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err := run(t, bin, "walk", "--src", src, "--dst", filepath.Join(td, "out2"), "--config", bad)
+		if code := getExitCode(err); code != 1 || !strings.Contains(stderr, `unknown validator "apache"`) {
+			t.Errorf("exit code = %d, want 1 with an unknown validator error\n%s", code, stderr)
+		}
+	})
+}
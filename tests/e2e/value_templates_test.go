@@ -0,0 +1,63 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderValueTemplate(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	values := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(values, []byte(`customer: acme
+snippets:
+  banner: "Welcome {{ .customer | upper }}"
+  env: "{{ env \"HOME\" }}"
+  set: "{{ set . \"customer\" \"other\" }}"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tpl := func(name, key string) string {
+		p := filepath.Join(td, name)
+		if err := os.WriteFile(p, []byte("{{ renderValueTemplate .snippets."+key+" . }} {{ .customer }}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl("banner.tpl", "banner"), "-d", values)
+		if code := getExitCode(err); code != 2 {
+			t.Fatalf("expected exit code 2, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "--allow-value-templates") {
+			t.Fatalf("expected a hint at the flag, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("render", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl("banner.tpl", "banner"), "-d", values, "--allow-value-templates")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if strings.TrimSpace(stdout) != "Welcome ACME acme" {
+			t.Fatalf("unexpected output %q", stdout)
+		}
+	})
+
+	t.Run("sandbox", func(t *testing.T) {
+		for _, key := range []string{"env", "set"} {
+			_, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl(key+".tpl", key), "-d", values, "--allow-value-templates")
+			if code := getExitCode(err); code != 2 {
+				t.Fatalf("%s: expected exit code 2, got %d\n%s", key, code, stderr)
+			}
+			if !strings.Contains(stderr, key+" is not available in value templates") {
+				t.Fatalf("%s: expected the sandbox to reject it, got:\n%s", key, stderr)
+			}
+		}
+	})
+}
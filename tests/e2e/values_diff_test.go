@@ -0,0 +1,73 @@
+package e2e
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValuesDiff(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		p := filepath.Join(td, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	common := write("common.yaml", "image:\n  repo: app\n")
+	staging := write("staging.yaml", "image:\n  tag: \"1.2\"\nreplicas: 2\ndebug: true\nhosts: [a, b]\n")
+	prod := write("prod.yaml", "image:\n  tag: \"1.3\"\nreplicas: \"3\"\nhosts: [a, b, c]\nextra:\n  x: 1\n")
+	write("src/app.tpl", "{{ .image.tag }} {{ .replicas }}\n")
+	oldSet, newSet := common+","+staging, common+","+prod
+
+	t.Run("text", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "values", "diff", "-f", oldSet, "-f", newSet)
+		if err != nil {
+			t.Fatalf("values diff failed: %v\n%s", err, stderr)
+		}
+		want := `- debug: true (bool)
++ extra: {"x":1} (map)
+~ hosts: ["a","b"] (list) -> ["a","b","c"] (list)
+~ image.tag: "1.2" (string) -> "1.3" (string)
+~ replicas: 2 (int) -> "3" (string)
+`
+		if stdout != want {
+			t.Fatalf("unexpected diff:\n%s\nwant:\n%s", stdout, want)
+		}
+	})
+
+	t.Run("src_scope", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "values", "diff", "-f", oldSet, "-f", newSet, "--src", filepath.Join(td, "src"), "--format", "json")
+		if err != nil {
+			t.Fatalf("values diff failed: %v\n%s", err, stderr)
+		}
+		var changes []struct{ Op, Key, OldType, NewType string }
+		if err := json.Unmarshal([]byte(stdout), &changes); err != nil {
+			t.Fatalf("bad json %q: %v", stdout, err)
+		}
+		var keys []string
+		for _, c := range changes {
+			keys = append(keys, c.Op+" "+c.Key)
+		}
+		if strings.Join(keys, ",") != "changed image.tag,changed replicas" {
+			t.Fatalf("expected only referenced keys, got %v", keys)
+		}
+	})
+
+	t.Run("needs_two_sets", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "values", "diff", "-f", oldSet)
+		if code := getExitCode(err); code != 1 {
+			t.Fatalf("expected exit code 1, got %d\n%s", code, stderr)
+		}
+	})
+}
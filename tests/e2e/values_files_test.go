@@ -0,0 +1,74 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTOMLAndEnvValues(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(td, name)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	cfg := write("config.toml", "name = \"templr\"\n\n[db]\nport = 5432\n")
+	env := write(".env", `# comment
+export API_URL=https://example.com # trailing comment
+TOKEN='$SECRET stays'
+MOTD="hello\nworld"
+EMPTY=
+`)
+
+	t.Run("toml", func(t *testing.T) {
+		tpl := write("toml.tpl", "{{ .name }}:{{ .db.port }}\n")
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "-d", cfg)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "templr:5432") {
+			t.Fatalf("unexpected output:\n%s", stdout)
+		}
+	})
+
+	t.Run("env_top_level", func(t *testing.T) {
+		tpl := write("env.tpl", "{{ .API_URL }}|{{ .TOKEN }}|{{ .MOTD }}|{{ .EMPTY }}|{{ .name }}\n")
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "-d", cfg, "-f", env)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if want := "https://example.com|$SECRET stays|hello\nworld||templr"; !strings.Contains(stdout, want) {
+			t.Fatalf("expected %q, got:\n%s", want, stdout)
+		}
+	})
+
+	t.Run("env_key", func(t *testing.T) {
+		tpl := write("envkey.tpl", "{{ .app.env.API_URL }}|{{ .name }}\n")
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "-d", cfg, "-f", env, "--env-key", "app.env")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "https://example.com|templr") {
+			t.Fatalf("unexpected output:\n%s", stdout)
+		}
+	})
+
+	t.Run("env_invalid", func(t *testing.T) {
+		bad := write("bad.env", "OK=1\nnot a pair\n")
+		tpl := write("bad.tpl", "x\n")
+		_, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "-f", bad)
+		if code := getExitCode(err); code != 3 {
+			t.Fatalf("expected exit code 3, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "line 2") {
+			t.Fatalf("expected error naming line 2, got:\n%s", stderr)
+		}
+	})
+}
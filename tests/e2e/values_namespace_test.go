@@ -0,0 +1,94 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValuesNamespace(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	tpl := filepath.Join(td, "deploy.yaml.tpl")
+	if err := os.WriteFile(tpl, []byte("replicas: {{ .Values.replicas }}\ntag: {{ .image.tag }}/{{ .Values.image.tag }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	values := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(values, []byte("replicas: 3\nimage:\n  tag: v1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	want := "replicas: 3\ntag: v1/v1\n"
+
+	t.Run("flag", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "-i", tpl, "-d", values, "--strict", "--values-namespace")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if stdout != want {
+			t.Errorf("got %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("config", func(t *testing.T) {
+		cfg := filepath.Join(td, "templr.yaml")
+		if err := os.WriteFile(cfg, []byte("template:\n  values_namespace: true\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		src := filepath.Join(td, "src")
+		if err := os.MkdirAll(src, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := os.ReadFile(tpl)
+		if err := os.WriteFile(filepath.Join(src, "deploy.yaml.tpl"), b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		dst := filepath.Join(td, "out")
+		_, stderr, err := run(t, bin, "walk", "--config", cfg, "--src", src, "--dst", dst, "-d", values, "--inject-guard=false")
+		if err != nil {
+			t.Fatalf("walk failed: %v\n%s", err, stderr)
+		}
+		if got, _ := os.ReadFile(filepath.Join(dst, "deploy.yaml")); string(got) != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		_, stderr, err := run(t, bin, "render", "-i", tpl, "-d", values, "--strict", "--no-color")
+		if getExitCode(err) == 0 {
+			t.Fatal("expected the strict render to fail without --values-namespace")
+		}
+		if !strings.Contains(stderr, "Run with --values-namespace") {
+			t.Errorf("expected a hint to use --values-namespace, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("lint", func(t *testing.T) {
+		stdout, _, err := run(t, bin, "lint", "-i", tpl, "-d", values, "--values-namespace", "--no-color")
+		if err != nil {
+			t.Fatalf("lint failed: %v", err)
+		}
+		if strings.Contains(stdout, "undefined") {
+			t.Errorf("expected .Values references to resolve, got:\n%s", stdout)
+		}
+	})
+
+	t.Run("values key wins", func(t *testing.T) {
+		own := filepath.Join(td, "own.yaml")
+		if err := os.WriteFile(own, []byte("Values:\n  replicas: 1\n  image: {tag: v0}\nimage:\n  tag: v1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		stdout, stderr, err := run(t, bin, "render", "-i", tpl, "-d", own, "--values-namespace")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if stdout != "replicas: 1\ntag: v1/v0\n" {
+			t.Errorf("unexpected output %q", stdout)
+		}
+		if !strings.Contains(stderr, "[templr:warn:values-namespace]") {
+			t.Errorf("expected a warning, got:\n%s", stderr)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValuesReferences(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(td, name)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	values := write("values.yaml", `domain: example.com
+registry: registry.${.domain}
+image: ${.registry}/app
+port: 8080
+url: "https://${.domain}:${.port}"
+hosts: [a, b]
+first: ${.hosts.0}
+all: ${.hosts}
+literal: "$${.domain}"
+`)
+	tpl := write("refs.tpl", "{{ .image }}|{{ .url }}|{{ .first }}|{{ len .all }}|{{ .literal }}\n")
+
+	t.Run("resolved", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "-d", values, "--resolve-refs")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if want := "registry.example.com/app|https://example.com:8080|a|2|${.domain}"; !strings.Contains(stdout, want) {
+			t.Fatalf("expected %q, got:\n%s", want, stdout)
+		}
+	})
+
+	t.Run("set_overrides_before_resolving", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "-d", values, "--resolve-refs", "--set", "domain=example.org")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "registry.example.org/app|https://example.org:8080") {
+			t.Fatalf("unexpected output:\n%s", stdout)
+		}
+	})
+
+	t.Run("off_by_default", func(t *testing.T) {
+		stdout, stderr, err := run(t, bin, "render", "--no-color", "-i", write("plain.tpl", "{{ .image }}\n"), "-d", values)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "${.registry}/app") {
+			t.Fatalf("expected the reference to stay as written, got:\n%s", stdout)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		cyclic := write("cycle.yaml", "a:\n  b: ${.c}\nc: x-${.a.b}\n")
+		_, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "-d", cyclic, "--resolve-refs")
+		if code := getExitCode(err); code != 3 {
+			t.Fatalf("expected exit code 3, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "values reference cycle: .a.b -> .c -> .a.b") {
+			t.Fatalf("expected the cycle to be reported, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("undefined", func(t *testing.T) {
+		missing := write("missing.yaml", "a: ${.nope}\n")
+		_, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "-d", missing, "--resolve-refs")
+		if err == nil || !strings.Contains(stderr, "${.nope} is not defined") {
+			t.Fatalf("expected an undefined reference error, got:\n%s", stderr)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValuesWhenGuard(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(td, name)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	base := write("values.yaml", "gpu:\n  enabled: false\nenv: dev\nimage: app\n")
+	gpu := write("gpu.yaml", "_when: .gpu.enabled\nimage: app-cuda\n")
+	prod := write("prod.yaml", "_when: eq .env \"prod\"\nreplicas: 3\n")
+	off := write("off.yaml", "_when: false\nimage: never\n")
+	tpl := write("when.tpl", "{{ .image }}|{{ .replicas | default 1 }}|{{ hasKey . \"_when\" }}\n")
+
+	render := func(t *testing.T, args ...string) string {
+		t.Helper()
+		args = append([]string{"render", "--no-color", "-i", tpl, "-d", base}, args...)
+		stdout, stderr, err := run(t, bin, args...)
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		return stdout
+	}
+
+	t.Run("skipped", func(t *testing.T) {
+		if got := render(t, "-f", gpu, "-f", prod, "-f", off); !strings.Contains(got, "app|1|false") {
+			t.Fatalf("expected every overlay to be skipped, got:\n%s", got)
+		}
+	})
+
+	t.Run("enabled_by_set", func(t *testing.T) {
+		got := render(t, "-f", gpu, "-f", prod, "--set", "gpu.enabled=true", "--set", "env=prod")
+		if !strings.Contains(got, "app-cuda|3|false") {
+			t.Fatalf("expected both overlays to apply, got:\n%s", got)
+		}
+	})
+
+	t.Run("enabled_by_earlier_file", func(t *testing.T) {
+		on := write("on.yaml", "gpu:\n  enabled: true\n")
+		if got := render(t, "-f", on, "-f", gpu); !strings.Contains(got, "app-cuda|1|false") {
+			t.Fatalf("expected the gpu overlay to apply, got:\n%s", got)
+		}
+	})
+
+	t.Run("undefined_parent", func(t *testing.T) {
+		// The values.yaml of td defines gpu, so this case gets a directory of its own.
+		dir := t.TempDir()
+		files := map[string]string{
+			"values.yaml": "env: dev\nimage: app\n",
+			"gpu.yaml":    "_when: .gpu.enabled\nimage: app-cuda\n",
+			"dev.yaml":    "_when: or .gpu.enabled (eq .env \"dev\")\nreplicas: 2\n",
+			"when.tpl":    "{{ .image }}|{{ .replicas | default 1 }}\n",
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		stdout, stderr, err := runIn(t, dir, bin, "render", "--no-color", "-i", "when.tpl", "-f", "gpu.yaml", "-f", "dev.yaml")
+		if err != nil {
+			t.Fatalf("render failed: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "app|2") {
+			t.Fatalf("expected .gpu.enabled to be false without gpu, got:\n%s", stdout)
+		}
+	})
+
+	t.Run("invalid_guard", func(t *testing.T) {
+		bad := write("bad.yaml", "_when: [1]\n")
+		_, stderr, err := run(t, bin, "render", "--no-color", "-i", tpl, "-f", bad)
+		if code := getExitCode(err); code != 3 {
+			t.Fatalf("expected exit code 3, got %d\n%s", code, stderr)
+		}
+		if !strings.Contains(stderr, "_when must be a bool or an expression") {
+			t.Fatalf("expected an invalid guard error, got:\n%s", stderr)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWalkRenameAndFlatten(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	src := filepath.Join(td, "src")
+	for name, content := range map[string]string{
+		"services/api/config.tpl":    "api\n",
+		"services/worker/config.tpl": "worker\n",
+		"base/app.yaml.tpl":          "app: {{ .name }}\n",
+	} {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dst := filepath.Join(td, "out")
+	_, stderr, err := run(t, bin, "walk", "--src", src, "--dst", dst, "--set", "name=x",
+		"--rename", `services/(.*)/config\.tpl=>conf/$1.conf`, "--flatten")
+	if err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	for _, name := range []string{"conf/api.conf", "conf/worker.conf", "app.yaml"} {
+		if _, err := os.Stat(filepath.Join(dst, filepath.FromSlash(name))); err != nil {
+			t.Errorf("expected output %s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "services")); !os.IsNotExist(err) {
+		t.Errorf("source layout mirrored despite rename rules")
+	}
+
+	// Rules from the config file apply too
+	cfg := filepath.Join(td, "templr.yaml")
+	if err := os.WriteFile(cfg, []byte("render:\n  rename:\n    - from: 'base/(.*)\\.tpl'\n      to: 'etc/$1'\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dst2 := filepath.Join(td, "out2")
+	_, stderr, err = run(t, bin, "walk", "--src", src, "--dst", dst2, "--config", cfg)
+	if err != nil {
+		t.Fatalf("walk failed: %v\n%s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dst2, "etc", "app.yaml")); err != nil {
+		t.Errorf("config rename rule not applied: %v", err)
+	}
+
+	for _, tc := range []struct{ rule, want string }{
+		{"services/(.*)=>../$1", "outside the destination"},
+		{"no-arrow", "want FROM=>TO"},
+		{"(=>x", "invalid rename rule"},
+	} {
+		_, stderr, err := run(t, bin, "walk", "--src", src, "--dst", filepath.Join(td, "bad"), "--rename", tc.rule)
+		if err == nil || !strings.Contains(stderr, tc.want) {
+			t.Errorf("%s: expected %q, got err=%v\n%s", tc.rule, tc.want, err, stderr)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestToYamlSorted(t *testing.T) {
+	start, _ := os.Getwd()
+	bin := buildTemplr(t, start)
+
+	td := t.TempDir()
+	values := filepath.Join(td, "values.yaml")
+	if err := os.WriteFile(values, []byte("cfg:\n  b: [1, 2]\n  a10: 1\n  a2:\n    z: 1\n    y: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	render := func(src string) (string, string, error) {
+		tpl := filepath.Join(td, "t.tpl")
+		if err := os.WriteFile(tpl, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return run(t, bin, "render", "--no-color", "-i", tpl, "-d", values)
+	}
+
+	stdout, stderr, err := render("{{ toYamlSorted .cfg }}")
+	if err != nil {
+		t.Fatalf("render failed: %v\n%s", err, stderr)
+	}
+	if want := "a10: 1\na2:\n    \"y\": 2\n    z: 1\nb:\n    - 1\n    - 2\n"; stdout != want {
+		t.Fatalf("toYamlSorted: got %q, want %q", stdout, want)
+	}
+
+	stdout, stderr, err = render("spec:{{ toYamlPretty .cfg 2 | trim | nindent 2 }}\n")
+	if err != nil {
+		t.Fatalf("render failed: %v\n%s", err, stderr)
+	}
+	if want := "spec:\n  a10: 1\n  a2:\n    \"y\": 2\n    z: 1\n  b:\n    - 1\n    - 2\n"; stdout != want {
+		t.Fatalf("toYamlPretty: got %q, want %q", stdout, want)
+	}
+
+	_, stderr, err = render("{{ toYamlPretty .cfg 1 }}")
+	if code := getExitCode(err); code != 2 || !strings.Contains(stderr, "indent must be between 2 and 9") {
+		t.Fatalf("expected a bad indent error, got %d:\n%s", code, stderr)
+	}
+}
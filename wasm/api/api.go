@@ -0,0 +1,56 @@
+// Package api defines the JSON documents exchanged with the WebAssembly
+// build of templr and its C-shared library (ffi/). The TypeScript types of
+// the npm package are generated from them.
+package api
+
+//go:generate go run ../cmd/tsgen -o ../../npm/types.d.ts
+
+// RenderRequest is a render of one template. With the streaming API the
+// template, helpers, values and files come from templrSetFile instead.
+type RenderRequest struct {
+	Template       string            `json:"template,omitempty"`
+	Values         string            `json:"values,omitempty"`  // YAML or JSON
+	Helpers        string            `json:"helpers,omitempty"` // templates parsed before Template
+	DefaultMissing string            `json:"defaultMissing,omitempty"`
+	Strict         bool              `json:"strict,omitempty"`
+	Files          map[string]string `json:"files,omitempty"` // the .Files API
+	InjectGuard    bool              `json:"injectGuard,omitempty"`
+	GuardMarker    string            `json:"guardMarker,omitempty"`
+}
+
+// RenderResponse is the result of a render, or of setting an input.
+type RenderResponse struct {
+	Output   string   `json:"output,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// LintIssue is one problem lint found in the template.
+type LintIssue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"` // "error" or "warn"
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+// LintResponse lists the issues of the template, or the error that
+// stopped lint.
+type LintResponse struct {
+	Issues []LintIssue `json:"issues"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// TreeRequest is a render of several templates sharing the helpers, values
+// and files of RenderRequest, whose Template is not used.
+type TreeRequest struct {
+	RenderRequest
+	Templates map[string]string `json:"templates"` // slash-separated path -> template text
+}
+
+// TreeResponse holds the outputs of a tree render by path, without the .tpl
+// extension; partials and empty outputs are left out.
+type TreeResponse struct {
+	Outputs  map[string]string `json:"outputs,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Warnings []string          `json:"warnings,omitempty"`
+}
@@ -5,37 +5,109 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 	"syscall/js"
+	"text/template"
 
+	"github.com/kanopi/templr/pkg/lint"
 	"github.com/kanopi/templr/pkg/templr"
+	"github.com/kanopi/templr/wasm/api"
+	"gopkg.in/yaml.v3"
 )
 
-type in struct {
-	Template       string            `json:"template"`
-	Values         string            `json:"values"`
-	Helpers        string            `json:"helpers"`
-	DefaultMissing string            `json:"defaultMissing"`
-	Strict         bool              `json:"strict"`
-	Files          map[string]string `json:"files"`
-	InjectGuard    bool              `json:"injectGuard"`
-	GuardMarker    string            `json:"guardMarker"`
-}
+// Resource limits of the playground: the browser tab is the only process,
+// so a runaway input or loop must fail instead of exhausting its memory.
+const (
+	maxSessionBytes = 32 << 20 // template, helpers, values and files together
+	maxOutputBytes  = 16 << 20
+)
 
-type out struct {
-	Output   string   `json:"output,omitempty"`
-	Error    string   `json:"error,omitempty"`
-	Warnings []string `json:"warnings,omitempty"`
+// session holds the inputs set one by one through the streaming API, so
+// large inputs never go through a single JSON string. The reserved names
+// "template", "helpers" and "values" hold those inputs; any other name is a
+// file of the .Files API.
+var session = map[string]string{}
+
+// sessionBytes is the total size of the session inputs.
+func sessionBytes() int {
+	n := 0
+	for _, s := range session {
+		n += len(s)
+	}
+	return n
 }
 
+// render renders the one JSON document of the original API.
 func render(this js.Value, args []js.Value) any {
 	if len(args) != 1 {
-		return toJS(out{Error: "templrRender expects one JSON argument"})
+		return toJS(api.RenderResponse{Error: "templrRender expects one JSON argument"})
 	}
-	var req in
+	if len(args[0].String()) > maxSessionBytes {
+		return toJS(api.RenderResponse{Error: fmt.Sprintf("input exceeds %d bytes; use templrSetFile and templrRenderSession", maxSessionBytes)})
+	}
+	var req api.RenderRequest
 	if err := json.Unmarshal([]byte(args[0].String()), &req); err != nil {
-		return toJS(out{Error: "bad JSON: " + err.Error()})
+		return toJS(api.RenderResponse{Error: "bad JSON: " + err.Error()})
+	}
+	return renderRequest(req)
+}
+
+// setFile sets (name, content) or, with a third true argument, appends
+// content to name, so a large input can be sent in chunks.
+func setFile(this js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return toJS(api.RenderResponse{Error: "templrSetFile expects a name and its content"})
+	}
+	name, content := args[0].String(), args[1].String()
+	prev := session[name]
+	if len(args) < 3 || !args[2].Truthy() {
+		prev = ""
+	}
+	if sessionBytes()-len(session[name])+len(prev)+len(content) > maxSessionBytes {
+		return toJS(api.RenderResponse{Error: fmt.Sprintf("inputs exceed %d bytes", maxSessionBytes)})
+	}
+	session[name] = prev + content
+	return toJS(api.RenderResponse{})
+}
+
+// removeFile drops one input of the session.
+func removeFile(this js.Value, args []js.Value) any {
+	if len(args) != 1 {
+		return toJS(api.RenderResponse{Error: "templrRemoveFile expects a name"})
+	}
+	delete(session, args[0].String())
+	return toJS(api.RenderResponse{})
+}
+
+// reset drops every input of the session.
+func reset(this js.Value, args []js.Value) any {
+	session = map[string]string{}
+	return toJS(api.RenderResponse{})
+}
+
+// renderSession renders the session inputs with the options of an optional
+// JSON argument (strict, defaultMissing, injectGuard, guardMarker).
+func renderSession(this js.Value, args []js.Value) any {
+	var req api.RenderRequest
+	if len(args) > 0 && args[0].Truthy() {
+		if err := json.Unmarshal([]byte(args[0].String()), &req); err != nil {
+			return toJS(api.RenderResponse{Error: "bad JSON: " + err.Error()})
+		}
 	}
+	req.Template, req.Helpers, req.Values = session["template"], session["helpers"], session["values"]
+	req.Files = map[string]string{}
+	for name, content := range session {
+		switch name {
+		case "template", "helpers", "values":
+		default:
+			req.Files[name] = content
+		}
+	}
+	return renderRequest(req)
+}
 
+func renderRequest(req api.RenderRequest) any {
 	// Collect warnings
 	var warnings []string
 
@@ -47,6 +119,7 @@ func render(this js.Value, args []js.Value) any {
 		DefaultMissing: req.DefaultMissing,
 		InjectGuard:    req.InjectGuard,
 		GuardMarker:    req.GuardMarker,
+		MaxOutputSize:  maxOutputBytes,
 		WarnFunc: func(msg string) {
 			warnings = append(warnings, msg)
 		},
@@ -57,14 +130,61 @@ func render(this js.Value, args []js.Value) any {
 
 	res, err := templr.RenderSingle(opts)
 	if err != nil {
-		return toJS(out{Error: err.Error(), Warnings: warnings})
+		return toJS(api.RenderResponse{Error: err.Error(), Warnings: warnings})
 	}
-	return toJS(out{Output: res.Output, Warnings: warnings})
+	return toJS(api.RenderResponse{Output: res.Output, Warnings: warnings})
+}
+
+// lintSession checks the session template: parse errors, then references
+// missing from the values and the registered rules.
+func lintSession(this js.Value, args []js.Value) any {
+	res := api.LintResponse{Issues: []api.LintIssue{}}
+	var tpl *template.Template
+	tpl = template.New("template").Funcs(templr.BuildFuncMap(&tpl))
+	if h := session["helpers"]; h != "" {
+		if _, err := tpl.New("helpers").Parse(h); err != nil {
+			res.Issues = append(res.Issues, api.LintIssue{Rule: "parse", Severity: lint.SeverityError, Line: lint.ExtractLineNumber(err.Error()), Message: err.Error()})
+			return toJS(res)
+		}
+	}
+	t, err := tpl.New("template").Parse(session["template"])
+	if err != nil {
+		res.Issues = append(res.Issues, api.LintIssue{Rule: "parse", Severity: lint.SeverityError, Line: lint.ExtractLineNumber(err.Error()), Message: err.Error()})
+		return toJS(res)
+	}
+	var values map[string]any
+	if v := session["values"]; v != "" {
+		if err := yaml.Unmarshal([]byte(v), &values); err != nil {
+			res.Error = "values: " + err.Error()
+			return toJS(res)
+		}
+		if values == nil {
+			values = map[string]any{}
+		}
+	}
+	rules := append([]lint.Rule{lint.UndefinedRule{}}, lint.RegisteredRules()...)
+	for _, is := range lint.Run(t.Tree, &lint.Context{File: "template", Name: "template", Source: []byte(session["template"]), Values: values}, rules) {
+		res.Issues = append(res.Issues, api.LintIssue{Rule: is.RuleID(), Severity: is.Severity, Line: is.Line, Message: is.Message})
+	}
+	sort.SliceStable(res.Issues, func(i, j int) bool { return res.Issues[i].Line < res.Issues[j].Line })
+	return toJS(res)
+}
+
+// funcs lists the functions of this build, as `templr funcs --format json`.
+func funcs(this js.Value, args []js.Value) any {
+	return toJS(templr.Funcs())
 }
 
 func toJS(v any) js.Value { b, _ := json.Marshal(v); return js.ValueOf(string(b)) }
 
 func main() {
 	js.Global().Set("templrRender", js.FuncOf(render))
+	js.Global().Set("templrSetFile", js.FuncOf(setFile))
+	js.Global().Set("templrRemoveFile", js.FuncOf(removeFile))
+	js.Global().Set("templrReset", js.FuncOf(reset))
+	js.Global().Set("templrRenderSession", js.FuncOf(renderSession))
+	js.Global().Set("templrLint", js.FuncOf(lintSession))
+	js.Global().Set("templrFuncs", js.FuncOf(funcs))
+	js.Global().Set("templrLite", js.ValueOf(templr.LiteBuild))
 	select {}
 }
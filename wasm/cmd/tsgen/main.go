@@ -0,0 +1,78 @@
+// Command tsgen writes the TypeScript declarations of the JSON documents
+// exchanged with the WebAssembly build, for the npm package.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/kanopi/templr/pkg/templr"
+	"github.com/kanopi/templr/wasm/api"
+)
+
+// types are declared in this order.
+var types = []any{
+	api.RenderRequest{},
+	api.RenderResponse{},
+	api.LintIssue{},
+	api.LintResponse{},
+	templr.FuncInfo{},
+}
+
+func main() {
+	out := flag.String("o", "", "output file (default stdout)")
+	flag.Parse()
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by tsgen from wasm/api; DO NOT EDIT.\n")
+	for _, v := range types {
+		t := reflect.TypeOf(v)
+		fmt.Fprintf(&b, "\nexport interface %s {\n", t.Name())
+		for i := range t.NumField() {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			optional := ""
+			if strings.Contains(opts, "omitempty") {
+				optional = "?"
+			}
+			fmt.Fprintf(&b, "  %s%s: %s;\n", name, optional, tsType(f.Type))
+		}
+		b.WriteString("}\n")
+	}
+
+	if *out == "" {
+		os.Stdout.Write(b.Bytes())
+		return
+	}
+	if err := os.WriteFile(*out, b.Bytes(), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// tsType returns the TypeScript type of a field of type t.
+func tsType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		return "number"
+	case reflect.Slice:
+		return tsType(t.Elem()) + "[]"
+	case reflect.Map:
+		return "Record<" + tsType(t.Key()) + ", " + tsType(t.Elem()) + ">"
+	case reflect.Struct:
+		return t.Name()
+	default:
+		panic("tsgen: unsupported type " + t.String())
+	}
+}
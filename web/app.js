@@ -1236,6 +1236,21 @@ class PlaygroundApp {
     const errors = [];
     const successes = [];
 
+    // Find helpers (look for _helpers with any template extension)
+    let helpers = '';
+    for (const [hPath, hContent] of Object.entries(files)) {
+      if (hPath.includes('_helpers') && hasTemplateExtension(hPath)) {
+        helpers += hContent + '\n';
+      }
+    }
+
+    // Send the shared inputs once; each template is then set and rendered
+    // on its own, so no input goes through one large JSON string.
+    window.templrReset();
+    window.templrSetFile('values', valuesContent);
+    window.templrSetFile('helpers', helpers);
+    const options = JSON.stringify({ defaultMissing, strict, injectGuard, guardMarker });
+
     for (const [path, content] of Object.entries(files)) {
       if (!hasTemplateExtension(path)) continue;
 
@@ -1247,27 +1262,9 @@ class PlaygroundApp {
 
       this.logDebug(`Rendering template: ${path}`);
 
-      // Find helpers (look for _helpers with any template extension)
-      let helpers = '';
-      for (const [hPath, hContent] of Object.entries(files)) {
-        if (hPath.includes('_helpers') && hasTemplateExtension(hPath)) {
-          helpers += hContent + '\n';
-        }
-      }
-
-      const payload = JSON.stringify({
-        template: content,
-        values: valuesContent,
-        helpers: helpers,
-        defaultMissing,
-        strict,
-        files: {},
-        injectGuard,
-        guardMarker
-      });
-
       try {
-        const res = JSON.parse(window.templrRender(payload));
+        const set = JSON.parse(window.templrSetFile('template', content));
+        const res = set.error ? set : JSON.parse(window.templrRenderSession(options));
         if (res.error) {
           errors.push(`${path}: ${res.error}`);
           this.logError(`Failed to render ${path}: ${res.error}`);